@@ -0,0 +1,158 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aclartifact
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sync"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// ACL grants users other than the owner access to an artifact.
+type ACL struct {
+	// Owner is the user whose namespace holds the artifact.
+	Owner string `json:"owner"`
+	// Readers may load the artifact and list its versions.
+	Readers []string `json:"readers,omitempty"`
+	// Writers may also save new versions. Writers are readers too.
+	Writers []string `json:"writers,omitempty"`
+}
+
+// CanRead reports whether principal may read the artifact.
+func (a *ACL) CanRead(principal string) bool {
+	return principal == a.Owner || slices.Contains(a.Readers, principal) || slices.Contains(a.Writers, principal)
+}
+
+// CanWrite reports whether principal may save versions of the artifact.
+func (a *ACL) CanWrite(principal string) bool {
+	return principal == a.Owner || slices.Contains(a.Writers, principal)
+}
+
+// Store keeps the ACLs of artifacts, identified by references without
+// version.
+type Store interface {
+	// ACL returns the ACL of ref, or nil if it has none.
+	ACL(ctx context.Context, ref artifactx.Ref) (*ACL, error)
+	// SetACL replaces the ACL of ref; a nil acl removes it.
+	SetACL(ctx context.Context, ref artifactx.Ref, acl *ACL) error
+}
+
+// MemoryStore is a [Store] kept in memory.
+type MemoryStore struct {
+	mu   sync.Mutex
+	acls map[artifactx.Ref]ACL
+}
+
+// NewMemoryStore returns an empty [MemoryStore].
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{acls: map[artifactx.Ref]ACL{}}
+}
+
+// ACL implements [Store].
+func (m *MemoryStore) ACL(ctx context.Context, ref artifactx.Ref) (*ACL, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	acl, ok := m.acls[ref]
+	if !ok {
+		return nil, nil
+	}
+	acl.Readers, acl.Writers = slices.Clone(acl.Readers), slices.Clone(acl.Writers)
+	return &acl, nil
+}
+
+// SetACL implements [Store].
+func (m *MemoryStore) SetACL(ctx context.Context, ref artifactx.Ref, acl *ACL) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if acl == nil {
+		delete(m.acls, ref)
+		return nil
+	}
+	m.acls[ref] = ACL{Owner: acl.Owner, Readers: slices.Clone(acl.Readers), Writers: slices.Clone(acl.Writers)}
+	return nil
+}
+
+// ServiceStore is a [Store] keeping every ACL as a JSON artifact of a
+// service, at the same user, session and file name as the artifact it
+// protects, in the app named after the app of the artifact with the suffix
+// ".acl". It may be the decorated service itself.
+type ServiceStore struct {
+	srv artifact.Service
+}
+
+// NewServiceStore returns a [ServiceStore] saving to srv.
+func NewServiceStore(srv artifact.Service) *ServiceStore {
+	return &ServiceStore{srv: srv}
+}
+
+func (s *ServiceStore) appName(ref artifactx.Ref) string {
+	return ref.AppName + ".acl"
+}
+
+// ACL implements [Store].
+func (s *ServiceStore) ACL(ctx context.Context, ref artifactx.Ref) (*ACL, error) {
+	resp, err := s.srv.Load(ctx, &artifact.LoadRequest{
+		AppName: s.appName(ref), UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName,
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load acl: %w", err)
+	}
+	data, _, err := artifactx.EncodePart(resp.Part)
+	if err != nil {
+		return nil, err
+	}
+	acl := &ACL{}
+	if err := json.Unmarshal(data, acl); err != nil {
+		return nil, fmt.Errorf("failed to decode acl: %w", err)
+	}
+	return acl, nil
+}
+
+// SetACL implements [Store]. Every change is saved as a new version, so the
+// history of the ACL is kept until it is removed.
+func (s *ServiceStore) SetACL(ctx context.Context, ref artifactx.Ref, acl *ACL) error {
+	if acl == nil {
+		err := s.srv.Delete(ctx, &artifact.DeleteRequest{
+			AppName: s.appName(ref), UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName,
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to delete acl: %w", err)
+		}
+		return nil
+	}
+	data, err := json.Marshal(acl)
+	if err != nil {
+		return fmt.Errorf("failed to encode acl: %w", err)
+	}
+	if _, err := s.srv.Save(ctx, &artifact.SaveRequest{
+		AppName: s.appName(ref), UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName,
+		Part: genai.NewPartFromBytes(data, "application/json"),
+	}); err != nil {
+		return fmt.Errorf("failed to save acl: %w", err)
+	}
+	return nil
+}
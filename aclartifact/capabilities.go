@@ -0,0 +1,172 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aclartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// The write capabilities are checked like Save, and forwarded to the
+// decorated service; they fail with [errors.ErrUnsupported] if it does not
+// implement them.
+
+var (
+	_ artifactx.JSONUpdater = (*Service)(nil)
+	_ artifactx.Uploader    = (*Service)(nil)
+	_ artifactx.Relabeler   = (*Service)(nil)
+	_ artifactx.Pinner      = (*Service)(nil)
+)
+
+// UpdateJSON implements [artifactx.JSONUpdater].
+func (s *Service) UpdateJSON(ctx context.Context, req *artifactx.UpdateJSONRequest) (*artifact.SaveResponse, error) {
+	if err := s.authorize(ctx, req.AppName, req.UserID, req.SessionID, req.FileName, true); err != nil {
+		return nil, err
+	}
+	return artifactx.UpdateJSON(ctx, s.Service, req)
+}
+
+// uploader returns the uploader of the decorated service.
+func (s *Service) uploader() (artifactx.Uploader, error) {
+	u, ok := artifactx.As[artifactx.Uploader](s.Service)
+	if !ok {
+		return nil, fmt.Errorf("upload: %w", errors.ErrUnsupported)
+	}
+	return u, nil
+}
+
+// authorizeUpload checks that the principal of ctx may write the artifact of
+// an upload, as recorded by the decorated service, so that the check holds
+// whichever process started the upload.
+func (s *Service) authorizeUpload(ctx context.Context, u artifactx.Uploader, uploadID string) error {
+	up, err := u.UploadStatus(ctx, uploadID)
+	if err != nil {
+		return err
+	}
+	return s.authorize(ctx, up.AppName, up.UserID, up.SessionID, up.FileName, true)
+}
+
+// BeginUpload implements [artifactx.Uploader].
+func (s *Service) BeginUpload(ctx context.Context, req *artifactx.UploadRequest) (*artifactx.Upload, error) {
+	if err := s.authorize(ctx, req.AppName, req.UserID, req.SessionID, req.FileName, true); err != nil {
+		return nil, err
+	}
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	return u.BeginUpload(ctx, req)
+}
+
+// AppendChunk implements [artifactx.Uploader].
+func (s *Service) AppendChunk(ctx context.Context, uploadID string, offset int64, data []byte) (*artifactx.Upload, error) {
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeUpload(ctx, u, uploadID); err != nil {
+		return nil, err
+	}
+	return u.AppendChunk(ctx, uploadID, offset, data)
+}
+
+// UploadStatus implements [artifactx.Uploader].
+func (s *Service) UploadStatus(ctx context.Context, uploadID string) (*artifactx.Upload, error) {
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	up, err := u.UploadStatus(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorize(ctx, up.AppName, up.UserID, up.SessionID, up.FileName, true); err != nil {
+		return nil, err
+	}
+	return up, nil
+}
+
+// CommitUpload implements [artifactx.Uploader]. The grant is checked again,
+// so that an upload started before it was revoked is not saved.
+func (s *Service) CommitUpload(ctx context.Context, uploadID string) (*artifact.SaveResponse, error) {
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.authorizeUpload(ctx, u, uploadID); err != nil {
+		return nil, err
+	}
+	return u.CommitUpload(ctx, uploadID)
+}
+
+// AbortUpload implements [artifactx.Uploader].
+func (s *Service) AbortUpload(ctx context.Context, uploadID string) error {
+	u, err := s.uploader()
+	if err != nil {
+		return err
+	}
+	if err := s.authorizeUpload(ctx, u, uploadID); err != nil {
+		return err
+	}
+	return u.AbortUpload(ctx, uploadID)
+}
+
+// Relabel implements [artifactx.Relabeler].
+func (s *Service) Relabel(ctx context.Context, req *artifact.LoadRequest, change *artifactx.MetadataChange) error {
+	if err := s.authorize(ctx, req.AppName, req.UserID, req.SessionID, req.FileName, true); err != nil {
+		return err
+	}
+	r, ok := artifactx.As[artifactx.Relabeler](s.Service)
+	if !ok {
+		return fmt.Errorf("relabel: %w", errors.ErrUnsupported)
+	}
+	return r.Relabel(ctx, req, change)
+}
+
+// pinner returns the pinner of the decorated service, after checking that
+// the principal of ctx may write the artifact of req.
+func (s *Service) pinner(ctx context.Context, req *artifact.LoadRequest) (artifactx.Pinner, error) {
+	if err := s.authorize(ctx, req.AppName, req.UserID, req.SessionID, req.FileName, true); err != nil {
+		return nil, err
+	}
+	p, ok := artifactx.As[artifactx.Pinner](s.Service)
+	if !ok {
+		return nil, fmt.Errorf("pin: %w", errors.ErrUnsupported)
+	}
+	return p, nil
+}
+
+// Pin implements [artifactx.Pinner].
+func (s *Service) Pin(ctx context.Context, req *artifact.LoadRequest) error {
+	p, err := s.pinner(ctx, req)
+	if err != nil {
+		return err
+	}
+	return p.Pin(ctx, req)
+}
+
+// Unpin implements [artifactx.Pinner].
+func (s *Service) Unpin(ctx context.Context, req *artifact.LoadRequest) error {
+	p, err := s.pinner(ctx, req)
+	if err != nil {
+		return err
+	}
+	return p.Unpin(ctx, req)
+}
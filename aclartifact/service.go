@@ -0,0 +1,232 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package aclartifact provides an [artifact.Service] decorator that
+// authorizes requests by the principal making them, so that a user can share
+// single artifacts with other users' agents without opening their whole
+// namespace.
+//
+// Requests must carry a principal, attached with [artifactx.WithPrincipal];
+// requests without one fail with [fs.ErrPermission]. A principal has full
+// access to its own user namespace, the one named by the UserID of the
+// request. Artifacts of other users are readable or writable as granted by
+// their [ACL]; listing another user's artifacts, deleting and moving them is
+// reserved to the owner.
+//
+// The capabilities reading or writing single artifacts, [artifactx.Stater],
+// [artifactx.Opener], [artifactx.Mover], [artifactx.JSONUpdater],
+// [artifactx.Uploader], [artifactx.Relabeler] and [artifactx.Pinner], are
+// checked like Load and Save, and fail with [errors.ErrUnsupported] if the
+// decorated service does not implement them. The decorator is an
+// [artifactx.Forwarder] forwarding no other capability, such as
+// [artifactx.Walker], so that they cannot bypass the checks.
+package aclartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Service is an [artifact.Service] enforcing artifact ACLs.
+type Service struct {
+	artifact.Service
+	store Store
+}
+
+var (
+	_ artifactx.Forwarder = (*Service)(nil)
+	_ artifactx.Stater    = (*Service)(nil)
+	_ artifactx.Opener    = (*Service)(nil)
+	_ artifactx.Mover     = (*Service)(nil)
+)
+
+// NewService returns a service that forwards the requests allowed by the
+// ACLs of store to next.
+func NewService(next artifact.Service, store Store) *Service {
+	return &Service{Service: next, store: store}
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// Forwards implements [artifactx.Forwarder]. No capability of the decorated
+// service is forwarded, since none of them checks the ACLs.
+func (s *Service) Forwards(capability any) bool {
+	return false
+}
+
+// aclRef identifies the artifact an ACL applies to. User scoped artifacts
+// are shared by all sessions of the user, so their session is the user
+// namespace.
+func aclRef(appName, userID, sessionID, fileName string) artifactx.Ref {
+	if artifactx.IsUserScoped(fileName) {
+		sessionID = artifactx.UserNamespace
+	}
+	return artifactx.Ref{AppName: appName, UserID: userID, SessionID: sessionID, FileName: fileName}
+}
+
+// principal returns the principal of ctx, or [fs.ErrPermission].
+func principal(ctx context.Context) (string, error) {
+	p, ok := artifactx.PrincipalFrom(ctx)
+	if !ok {
+		return "", fmt.Errorf("no principal: %w", fs.ErrPermission)
+	}
+	return p, nil
+}
+
+// owner checks that the principal of ctx owns the namespace of userID.
+func owner(ctx context.Context, userID string) error {
+	p, err := principal(ctx)
+	if err != nil {
+		return err
+	}
+	if p != userID {
+		return fmt.Errorf("principal %q does not own the artifacts of %q: %w", p, userID, fs.ErrPermission)
+	}
+	return nil
+}
+
+// authorize checks that the principal of ctx may read, or write if write is
+// set, the artifact.
+func (s *Service) authorize(ctx context.Context, appName, userID, sessionID, fileName string, write bool) error {
+	p, err := principal(ctx)
+	if err != nil {
+		return err
+	}
+	if p == userID {
+		return nil
+	}
+	acl, err := s.store.ACL(ctx, aclRef(appName, userID, sessionID, fileName))
+	if err != nil {
+		return err
+	}
+	if acl != nil && (write && acl.CanWrite(p) || !write && acl.CanRead(p)) {
+		return nil
+	}
+	access := "read"
+	if write {
+		access = "write"
+	}
+	return fmt.Errorf("principal %q may not %s artifact %q of %q: %w", p, access, fileName, userID, fs.ErrPermission)
+}
+
+// ACL returns the ACL of an artifact, which only its owner may read.
+func (s *Service) ACL(ctx context.Context, appName, userID, sessionID, fileName string) (*ACL, error) {
+	if err := owner(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.store.ACL(ctx, aclRef(appName, userID, sessionID, fileName))
+}
+
+// SetACL replaces the ACL of an artifact, which only its owner may do; a nil
+// acl revokes all grants. The owner of acl is set to userID.
+func (s *Service) SetACL(ctx context.Context, appName, userID, sessionID, fileName string, acl *ACL) error {
+	if err := owner(ctx, userID); err != nil {
+		return err
+	}
+	if acl != nil {
+		acl = &ACL{Owner: userID, Readers: acl.Readers, Writers: acl.Writers}
+	}
+	return s.store.SetACL(ctx, aclRef(appName, userID, sessionID, fileName), acl)
+}
+
+// Save implements [artifact.Service].
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	if err := s.authorize(ctx, req.AppName, req.UserID, req.SessionID, req.FileName, true); err != nil {
+		return nil, err
+	}
+	return s.Service.Save(ctx, req)
+}
+
+// Load implements [artifact.Service].
+func (s *Service) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	if err := s.authorize(ctx, req.AppName, req.UserID, req.SessionID, req.FileName, false); err != nil {
+		return nil, err
+	}
+	return s.Service.Load(ctx, req)
+}
+
+// Versions implements [artifact.Service].
+func (s *Service) Versions(ctx context.Context, req *artifact.VersionsRequest) (*artifact.VersionsResponse, error) {
+	if err := s.authorize(ctx, req.AppName, req.UserID, req.SessionID, req.FileName, false); err != nil {
+		return nil, err
+	}
+	return s.Service.Versions(ctx, req)
+}
+
+// List implements [artifact.Service].
+func (s *Service) List(ctx context.Context, req *artifact.ListRequest) (*artifact.ListResponse, error) {
+	if err := owner(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+	return s.Service.List(ctx, req)
+}
+
+// Delete implements [artifact.Service]. Deleting all versions also removes
+// the ACL, so that grants do not carry over to a new artifact of that name.
+func (s *Service) Delete(ctx context.Context, req *artifact.DeleteRequest) error {
+	if err := owner(ctx, req.UserID); err != nil {
+		return err
+	}
+	if err := s.Service.Delete(ctx, req); err != nil {
+		return err
+	}
+	if req.Version != 0 {
+		return nil
+	}
+	return s.store.SetACL(ctx, aclRef(req.AppName, req.UserID, req.SessionID, req.FileName), nil)
+}
+
+// Move implements [artifactx.Mover]. The ACL does not follow the artifact.
+func (s *Service) Move(ctx context.Context, req *artifactx.MoveRequest) error {
+	if err := owner(ctx, req.UserID); err != nil {
+		return err
+	}
+	if err := artifactx.Move(ctx, s.Service, req); err != nil {
+		return err
+	}
+	return s.store.SetACL(ctx, aclRef(req.AppName, req.UserID, req.SessionID, req.FileName), nil)
+}
+
+// Stat implements [artifactx.Stater].
+func (s *Service) Stat(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Attributes, error) {
+	if err := s.authorize(ctx, req.AppName, req.UserID, req.SessionID, req.FileName, false); err != nil {
+		return nil, err
+	}
+	stater, ok := artifactx.As[artifactx.Stater](s.Service)
+	if !ok {
+		return nil, fmt.Errorf("stat: %w", errors.ErrUnsupported)
+	}
+	return stater.Stat(ctx, req)
+}
+
+// Open implements [artifactx.Opener].
+func (s *Service) Open(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Reader, error) {
+	if err := s.authorize(ctx, req.AppName, req.UserID, req.SessionID, req.FileName, false); err != nil {
+		return nil, err
+	}
+	opener, ok := artifactx.As[artifactx.Opener](s.Service)
+	if !ok {
+		return nil, fmt.Errorf("open: %w", errors.ErrUnsupported)
+	}
+	return opener.Open(ctx, req)
+}
@@ -0,0 +1,193 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aclartifact_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/aclartifact"
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+)
+
+func TestACL(t *testing.T) {
+	next, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, store := range map[string]aclartifact.Store{
+		"memory":  aclartifact.NewMemoryStore(),
+		"service": aclartifact.NewServiceStore(next),
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv := aclartifact.NewService(next, store)
+			alice := artifactx.WithPrincipal(t.Context(), "alice")
+			bob := artifactx.WithPrincipal(t.Context(), "bob")
+			carol := artifactx.WithPrincipal(t.Context(), "carol")
+			fileName := "user:profile-" + name
+			save := func(ctx context.Context, text string) error {
+				_, err := srv.Save(ctx, &artifact.SaveRequest{
+					AppName: "app", UserID: "alice", SessionID: "s1", FileName: fileName, Part: genai.NewPartFromText(text),
+				})
+				return err
+			}
+			load := func(ctx context.Context) error {
+				_, err := srv.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "alice", SessionID: "other", FileName: fileName})
+				return err
+			}
+
+			if err := save(t.Context(), "anonymous"); !errors.Is(err, fs.ErrPermission) {
+				t.Errorf("Save() without principal error = %v, want %v", err, fs.ErrPermission)
+			}
+			if err := save(alice, "v1"); err != nil {
+				t.Fatalf("Save() by owner failed: %v", err)
+			}
+			if err := load(bob); !errors.Is(err, fs.ErrPermission) {
+				t.Errorf("Load() before sharing error = %v, want %v", err, fs.ErrPermission)
+			}
+			if err := srv.SetACL(bob, "app", "alice", "s1", fileName, &aclartifact.ACL{Readers: []string{"bob"}}); !errors.Is(err, fs.ErrPermission) {
+				t.Errorf("SetACL() by another user error = %v, want %v", err, fs.ErrPermission)
+			}
+
+			if err := srv.SetACL(alice, "app", "alice", "s1", fileName, &aclartifact.ACL{Readers: []string{"bob"}, Writers: []string{"carol"}}); err != nil {
+				t.Fatalf("SetACL() failed: %v", err)
+			}
+			if acl, err := srv.ACL(alice, "app", "alice", "s2", fileName); err != nil || acl.Owner != "alice" || !acl.CanRead("bob") {
+				t.Errorf("ACL() = (%+v, %v), want readable by bob", acl, err)
+			}
+			if err := load(bob); err != nil {
+				t.Errorf("Load() by reader failed: %v", err)
+			}
+			if _, err := srv.Stat(bob, &artifact.LoadRequest{AppName: "app", UserID: "alice", SessionID: "s1", FileName: fileName}); err != nil {
+				t.Errorf("Stat() by reader failed: %v", err)
+			}
+			if err := save(bob, "v2"); !errors.Is(err, fs.ErrPermission) {
+				t.Errorf("Save() by reader error = %v, want %v", err, fs.ErrPermission)
+			}
+			if err := save(carol, "v2"); err != nil {
+				t.Errorf("Save() by writer failed: %v", err)
+			}
+			if _, err := srv.List(carol, &artifact.ListRequest{AppName: "app", UserID: "alice", SessionID: "s1"}); !errors.Is(err, fs.ErrPermission) {
+				t.Errorf("List() by writer error = %v, want %v", err, fs.ErrPermission)
+			}
+			del := &artifact.DeleteRequest{AppName: "app", UserID: "alice", SessionID: "s1", FileName: fileName}
+			if err := srv.Delete(carol, del); !errors.Is(err, fs.ErrPermission) {
+				t.Errorf("Delete() by writer error = %v, want %v", err, fs.ErrPermission)
+			}
+
+			if err := srv.Delete(alice, del); err != nil {
+				t.Fatalf("Delete() by owner failed: %v", err)
+			}
+			if err := save(alice, "new"); err != nil {
+				t.Fatal(err)
+			}
+			if err := load(bob); !errors.Is(err, fs.ErrPermission) {
+				t.Errorf("Load() of a recreated artifact error = %v, want %v", err, fs.ErrPermission)
+			}
+		})
+	}
+}
+
+// TestCapabilities checks that the capabilities of the decorated service do
+// not bypass the ACLs.
+func TestCapabilities(t *testing.T) {
+	next, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := aclartifact.NewService(next, aclartifact.NewMemoryStore())
+	if u, ok := artifactx.As[artifactx.JSONUpdater](srv); !ok || u != artifactx.JSONUpdater(srv) {
+		t.Error("As[JSONUpdater]() did not find the checked UpdateJSON")
+	}
+	if _, ok := artifactx.As[artifactx.Walker](srv); ok {
+		t.Error("As[Walker]() found the walker of the decorated service")
+	}
+	if _, ok := artifactx.As[artifactx.Stater](srv); !ok {
+		t.Error("As[Stater]() did not find the checked Stat")
+	}
+
+	alice := artifactx.WithPrincipal(t.Context(), "alice")
+	bob := artifactx.WithPrincipal(t.Context(), "bob")
+	if _, err := srv.Save(alice, &artifact.SaveRequest{
+		AppName: "app", UserID: "alice", SessionID: "s1", FileName: "plan.json",
+		Part: genai.NewPartFromBytes([]byte(`{"goal":"plan"}`), "application/json"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	req := &artifactx.UpdateJSONRequest{
+		AppName: "app", UserID: "alice", SessionID: "s1", FileName: "plan.json",
+		Type: artifactx.MergePatch, Patch: []byte(`{"goal":"pwned"}`),
+	}
+	if _, err := artifactx.UpdateJSON(bob, srv, req); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("UpdateJSON() by another user error = %v, want %v", err, fs.ErrPermission)
+	}
+	if _, err := artifactx.UpdateJSON(alice, srv, req); err != nil {
+		t.Errorf("UpdateJSON() by owner failed: %v", err)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	next, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := aclartifact.NewService(next, aclartifact.NewMemoryStore())
+	alice := artifactx.WithPrincipal(t.Context(), "alice")
+	bob := artifactx.WithPrincipal(t.Context(), "bob")
+	req := &artifactx.UploadRequest{AppName: "app", UserID: "alice", SessionID: "s1", FileName: "report.pdf", MIMEType: "application/pdf"}
+
+	if _, err := srv.BeginUpload(bob, req); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("BeginUpload() by another user error = %v, want %v", err, fs.ErrPermission)
+	}
+	up, err := srv.BeginUpload(alice, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.AppendChunk(bob, up.ID, 0, []byte("forged")); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("AppendChunk() by another user error = %v, want %v", err, fs.ErrPermission)
+	}
+	if _, err := srv.CommitUpload(bob, up.ID); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("CommitUpload() by another user error = %v, want %v", err, fs.ErrPermission)
+	}
+
+	// A writer may upload, until the grant is revoked.
+	if err := srv.SetACL(alice, "app", "alice", "s1", "report.pdf", &aclartifact.ACL{Writers: []string{"bob"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.AppendChunk(bob, up.ID, 0, []byte("%PDF")); err != nil {
+		t.Errorf("AppendChunk() by writer failed: %v", err)
+	}
+	if err := srv.SetACL(alice, "app", "alice", "s1", "report.pdf", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.CommitUpload(bob, up.ID); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("CommitUpload() after revoking error = %v, want %v", err, fs.ErrPermission)
+	}
+	if resp, err := srv.CommitUpload(alice, up.ID); err != nil || resp.Version != 1 {
+		t.Errorf("CommitUpload() by owner = (%v, %v), want version 1", resp, err)
+	}
+
+	// Without an uploader in the decorated service, uploads are refused.
+	bare := aclartifact.NewService(struct{ artifact.Service }{next}, aclartifact.NewMemoryStore())
+	if _, err := bare.BeginUpload(alice, req); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("BeginUpload() without an uploader error = %v, want %v", err, errors.ErrUnsupported)
+	}
+}
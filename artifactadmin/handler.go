@@ -0,0 +1,214 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package artifactadmin provides an [http.Handler] with administrative
+// endpoints for an artifact service stack, to mount into the server of the
+// host application:
+//
+//	admin := artifactadmin.NewHandler(srv, artifactadmin.Options{Gatherer: reg})
+//	mux.Handle("/admin/", http.StripPrefix("/admin", admin))
+//
+// The endpoints use the capabilities found in the decoration chain of the
+// service and answer 501 Not Implemented when it has none of them:
+//
+//	GET  /healthz      checks the storage with [artifactx.HealthChecker]
+//	GET  /usage        statsartifact statistics and rangecacheartifact usage
+//	POST /gc           prunes the store with [artifactx.Prune]
+//	POST /cache/flush  empties the rangecacheartifact cache
+//	GET  /metrics      Prometheus metrics of [Options.Gatherer]
+//
+// Responses are JSON, except for metrics. /usage?refresh=true walks the
+// store before answering and /gc?dryRun=true lists the versions pruning
+// would remove without removing them. The handler does not authenticate
+// requests: it belongs on an internal port or behind the authentication
+// of the host application.
+package artifactadmin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/rangecacheartifact"
+	"github.com/chinglinwen/adk-artifact/statsartifact"
+)
+
+// Options configures [NewHandler].
+type Options struct {
+	// Gatherer, if set, is served on /metrics, for example the registry
+	// promartifact registers its metrics on.
+	Gatherer prometheus.Gatherer
+	// HealthTimeout bounds health checks. The default is 5 seconds.
+	HealthTimeout time.Duration
+}
+
+// Health is the response of /healthz.
+type Health struct {
+	Status string `json:"status"` // "ok" or "unavailable"
+	Error  string `json:"error,omitempty"`
+}
+
+// Usage is the response of /usage. Sections are left out when the stack
+// has no corresponding layer.
+type Usage struct {
+	Stats *statsartifact.Snapshot `json:"stats,omitempty"`
+	Total *statsartifact.Usage    `json:"total,omitempty"`
+	Cache *CacheUsage             `json:"cache,omitempty"`
+}
+
+// CacheUsage is the content of a rangecacheartifact cache.
+type CacheUsage struct {
+	Blocks int   `json:"blocks"`
+	Bytes  int64 `json:"bytes"`
+}
+
+// GCResult is the response of /gc.
+type GCResult struct {
+	// Removed is the number of versions removed, or that would be removed
+	// in a dry run.
+	Removed int `json:"removed"`
+	// DryRun lists the versions that would be removed in a dry run.
+	DryRun []artifactx.Removal `json:"dryRun,omitempty"`
+}
+
+type handler struct {
+	srv  artifact.Service
+	opts Options
+	gcMu sync.Mutex // held while pruning
+}
+
+// NewHandler returns the administrative endpoints for srv.
+func NewHandler(srv artifact.Service, opts Options) http.Handler {
+	if opts.HealthTimeout <= 0 {
+		opts.HealthTimeout = 5 * time.Second
+	}
+	h := &handler{srv: srv, opts: opts}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", h.health)
+	mux.HandleFunc("GET /usage", h.usage)
+	mux.HandleFunc("POST /gc", h.gc)
+	mux.HandleFunc("POST /cache/flush", h.flushCache)
+	if opts.Gatherer != nil {
+		mux.Handle("GET /metrics", promhttp.HandlerFor(opts.Gatherer, promhttp.HandlerOpts{}))
+	}
+	return mux
+}
+
+// health answers 503 if the storage is unhealthy. Stacks without a
+// [artifactx.HealthChecker] have nothing to check and are reported healthy.
+func (h *handler) health(w http.ResponseWriter, r *http.Request) {
+	checker, ok := artifactx.As[artifactx.HealthChecker](h.srv)
+	if !ok {
+		writeJSON(w, http.StatusOK, Health{Status: "ok"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), h.opts.HealthTimeout)
+	defer cancel()
+	if err := checker.HealthCheck(ctx); err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, Health{Status: "unavailable", Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, Health{Status: "ok"})
+}
+
+func (h *handler) usage(w http.ResponseWriter, r *http.Request) {
+	stats, hasStats := artifactx.As[*statsartifact.Service](h.srv)
+	cache, hasCache := artifactx.As[*rangecacheartifact.Service](h.srv)
+	if !hasStats && !hasCache {
+		writeError(w, http.StatusNotImplemented, errors.New("no statistics or cache in the stack"))
+		return
+	}
+	var resp Usage
+	if hasStats {
+		if refresh, _ := strconv.ParseBool(r.URL.Query().Get("refresh")); refresh {
+			if err := stats.Refresh(r.Context()); err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+		snap, err := stats.Stats(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		total := snap.Total()
+		resp.Stats, resp.Total = snap, &total
+	}
+	if hasCache {
+		blocks, bytes := cache.Usage()
+		resp.Cache = &CacheUsage{Blocks: blocks, Bytes: bytes}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// gc prunes the store, answering 409 if it is already being pruned. The
+// request context is used, so pruning stops if the client goes away.
+func (h *handler) gc(w http.ResponseWriter, r *http.Request) {
+	if !h.gcMu.TryLock() {
+		writeError(w, http.StatusConflict, errors.New("garbage collection already running"))
+		return
+	}
+	defer h.gcMu.Unlock()
+	ctx := r.Context()
+	var result GCResult
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun"))
+	if dryRun {
+		var mu sync.Mutex
+		result.DryRun = []artifactx.Removal{}
+		ctx = artifactx.WithDryRun(ctx, func(rm artifactx.Removal) {
+			mu.Lock()
+			defer mu.Unlock()
+			result.DryRun = append(result.DryRun, rm)
+		})
+	}
+	n, err := artifactx.Prune(ctx, h.srv)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	result.Removed = n
+	if dryRun {
+		result.Removed = len(result.DryRun)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *handler) flushCache(w http.ResponseWriter, r *http.Request) {
+	cache, ok := artifactx.As[*rangecacheartifact.Service](h.srv)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, errors.New("no cache in the stack"))
+		return
+	}
+	blocks, bytes := cache.Flush()
+	writeJSON(w, http.StatusOK, CacheUsage{Blocks: blocks, Bytes: bytes})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,153 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactadmin_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactadmin"
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"github.com/chinglinwen/adk-artifact/promartifact"
+	"github.com/chinglinwen/adk-artifact/rangecacheartifact"
+	"github.com/chinglinwen/adk-artifact/statsartifact"
+)
+
+// do sends a request to h and decodes the JSON response into v, if not nil.
+func do(t *testing.T, h http.Handler, method, target string, v any) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s %s: could not decode %q: %v", method, target, rec.Body, err)
+		}
+	}
+	return rec.Code
+}
+
+func TestHandler(t *testing.T) {
+	ctx := t.Context()
+	backend, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := rangecacheartifact.NewService(backend, rangecacheartifact.Options{Dir: t.TempDir(), BlockSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := prometheus.NewRegistry()
+	metrics, err := promartifact.NewService(cache, reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := statsartifact.NewService(metrics, statsartifact.Options{})
+	h := artifactadmin.NewHandler(srv, artifactadmin.Options{Gatherer: reg})
+
+	req := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "notes.txt"}
+	for _, text := range []string{"v1", "v2", "v3 is longer"} {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Part: genai.NewPartFromText(text)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := artifactx.OpenRange(ctx, srv, req, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var health artifactadmin.Health
+	if code := do(t, h, "GET", "/healthz", &health); code != http.StatusOK || health.Status != "ok" {
+		t.Errorf("GET /healthz = %d %+v, want 200 ok", code, health)
+	}
+
+	var usage artifactadmin.Usage
+	if code := do(t, h, "GET", "/usage?refresh=true", &usage); code != http.StatusOK || usage.Total == nil || usage.Total.Versions != 3 || usage.Cache == nil || usage.Cache.Blocks == 0 {
+		t.Errorf("GET /usage = %d %+v, want 3 versions and cached blocks", code, usage)
+	}
+
+	var flushed artifactadmin.CacheUsage
+	if code := do(t, h, "POST", "/cache/flush", &flushed); code != http.StatusOK || flushed.Blocks == 0 {
+		t.Errorf("POST /cache/flush = %d %+v, want the cached blocks removed", code, flushed)
+	}
+	if blocks, _ := cache.Usage(); blocks != 0 {
+		t.Errorf("cache holds %d blocks after a flush, want 0", blocks)
+	}
+
+	setter, _ := artifactx.As[artifactx.RetentionSetter](srv)
+	setter.SetRetention(artifactx.Retention{MaxVersions: 1})
+	var gc artifactadmin.GCResult
+	if code := do(t, h, "POST", "/gc?dryRun=true", &gc); code != http.StatusOK || gc.Removed != 2 || len(gc.DryRun) != 2 || gc.DryRun[0].Reason != artifactx.ByRetention {
+		t.Errorf("POST /gc?dryRun=true = %d %+v, want 2 versions reported", code, gc)
+	}
+	versions := func() []int64 {
+		resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Versions
+	}
+	if got := versions(); len(got) != 3 {
+		t.Errorf("versions after a dry run = %v, want all 3", got)
+	}
+	gc = artifactadmin.GCResult{}
+	if code := do(t, h, "POST", "/gc", &gc); code != http.StatusOK || gc.Removed != 2 {
+		t.Errorf("POST /gc = %d %+v, want 2 versions removed", code, gc)
+	}
+	if got := versions(); !slices.Equal(got, []int64{3}) {
+		t.Errorf("versions after /gc = %v, want [3]", got)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "adk_artifact_") {
+		t.Errorf("GET /metrics = %d, want the metrics of promartifact", rec.Code)
+	}
+	if code := do(t, h, "GET", "/gc", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /gc = %d, want %d", code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandlerUnsupported(t *testing.T) {
+	backend, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := artifactadmin.NewHandler(backend, artifactadmin.Options{})
+	for _, target := range []string{"GET /usage", "POST /cache/flush"} {
+		method, path, _ := strings.Cut(target, " ")
+		var resp map[string]string
+		if code := do(t, h, method, path, &resp); code != http.StatusNotImplemented || resp["error"] == "" {
+			t.Errorf("%s = %d %v, want %d with an error", target, code, resp, http.StatusNotImplemented)
+		}
+	}
+	if code := do(t, h, "GET", "/metrics", nil); code != http.StatusNotFound {
+		t.Errorf("GET /metrics without a gatherer = %d, want %d", code, http.StatusNotFound)
+	}
+}
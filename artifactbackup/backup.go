@@ -0,0 +1,380 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package artifactbackup snapshots an artifact store to a tar archive or to a
+// secondary store, and restores it.
+//
+// Backups copy the stored bytes of every version with its attributes: its
+// content type, metadata, author, creation time, and whether it is pinned or
+// immutable. Restoring recreates every version under its original key and
+// version number. Restoring from a secondary store is a [Backup] in the
+// opposite direction.
+//
+// Backups are incremental: a [Manifest] records every version already backed
+// up with its creation time, and the next backup only copies the versions it
+// does not record, including older versions added since and versions
+// deleted and saved again.
+//
+// [WriteSessionZip] exports the latest versions of a session as a zip archive
+// for users, which [SessionZipHandler] serves over HTTP.
+package artifactbackup
+
+import (
+	"archive/tar"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// The PAX records holding the attributes of an archived version.
+const (
+	contentTypeRecord = "ADKARTIFACT.content_type"
+	metadataRecord    = "ADKARTIFACT.metadata" // JSON object
+	createdRecord     = "ADKARTIFACT.created"  // RFC 3339
+	pinnedRecord      = "ADKARTIFACT.pinned"
+	immutableRecord   = "ADKARTIFACT.immutable"
+)
+
+// Manifest records what previous backups copied.
+type Manifest struct {
+	// Versions maps "app/user/session/file" to the versions backed up and
+	// when each was created.
+	Versions map[string]map[int64]time.Time `json:"versions,omitempty"`
+	// Latest maps "app/user/session/file" to the latest version backed up
+	// by releases that did not record Versions. The versions up to it count
+	// as backed up.
+	Latest map[string]int64 `json:"latest,omitempty"`
+}
+
+// ReadManifest reads a manifest written by [Manifest.WriteFile].
+// A missing file yields an empty manifest, i.e. a full backup.
+func ReadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Manifest{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	return m, nil
+}
+
+// WriteFile writes the manifest to path.
+func (m *Manifest) WriteFile(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return nil
+}
+
+func artifactKey(ref artifactx.Ref) string {
+	return strings.Join([]string{ref.AppName, ref.UserID, ref.SessionID, ref.FileName}, "/")
+}
+
+// covers reports whether the version of ref created at created was backed
+// up. A version recorded with another creation time was saved again since.
+func (m *Manifest) covers(ref artifactx.Ref, created time.Time) bool {
+	if m == nil {
+		return false
+	}
+	key := artifactKey(ref)
+	if t, ok := m.Versions[key][ref.Version]; ok {
+		return t.Equal(created)
+	}
+	return ref.Version <= m.Latest[key]
+}
+
+func (m *Manifest) record(ref artifactx.Ref, created time.Time) {
+	if m == nil {
+		return
+	}
+	if m.Versions == nil {
+		m.Versions = map[string]map[int64]time.Time{}
+	}
+	key := artifactKey(ref)
+	if m.Versions[key] == nil {
+		m.Versions[key] = map[int64]time.Time{}
+	}
+	m.Versions[key][ref.Version] = created
+}
+
+// Result summarizes a backup or restore.
+type Result struct {
+	Versions int
+	Bytes    int64
+}
+
+// pending returns the versions of src not covered by m, in layout order.
+func pending(ctx context.Context, src artifact.Service, m *Manifest) ([]artifactx.Ref, error) {
+	w, ok := artifactx.As[artifactx.Walker](src)
+	if !ok {
+		return nil, fmt.Errorf("source does not support enumerating artifacts")
+	}
+	// The refs of every artifact, to get their creation times with one
+	// listing per artifact.
+	artifacts := map[string][]artifactx.Ref{}
+	err := w.Walk(ctx, func(ref artifactx.Ref) error {
+		key := artifactKey(ref)
+		artifacts[key] = append(artifacts[key], ref)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate artifacts: %w", err)
+	}
+	var refs []artifactx.Ref
+	for _, walked := range artifacts {
+		ref := walked[0]
+		attrs, err := artifactx.ListVersions(ctx, src, &artifactx.ListVersionsRequest{
+			AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName,
+		})
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // deleted since walking
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list versions of %s: %w", artifactKey(ref), err)
+		}
+		created := make(map[int64]time.Time, len(attrs))
+		for _, a := range attrs {
+			created[a.Version] = a.Created
+		}
+		for _, ref := range walked {
+			if t, ok := created[ref.Version]; ok && !m.covers(ref, t) {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	slices.SortFunc(refs, func(a, b artifactx.Ref) int {
+		return cmp.Or(strings.Compare(artifactKey(a), artifactKey(b)), cmp.Compare(a.Version, b.Version))
+	})
+	return refs, nil
+}
+
+// open returns the stored bytes of the version of ref with its attributes,
+// loading it if src cannot stream.
+func open(ctx context.Context, src artifact.Service, ref artifactx.Ref) ([]byte, *artifactx.Attributes, error) {
+	req := &artifact.LoadRequest{
+		AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName, Version: ref.Version,
+	}
+	var r *artifactx.Reader
+	var err error
+	if opener, ok := artifactx.As[artifactx.Opener](src); ok {
+		r, err = opener.Open(ctx, req)
+	} else {
+		r, err = artifactx.OpenLoaded(ctx, src, req)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", ref, err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", ref, err)
+	}
+	return data, &r.Attributes, nil
+}
+
+// restore saves data, the stored bytes of a version with attrs, to dst as
+// the version of ref with the same attributes. The bytes are saved as
+// inline data of the stored content type, which backends store as is.
+func restore(ctx context.Context, dst artifact.Service, ref artifactx.Ref, data []byte, attrs *artifactx.Attributes) error {
+	ctx = artifactx.WithCreatedTime(artifactx.WithMetadata(ctx, attrs.Metadata), attrs.Created)
+	if attrs.Immutable {
+		ctx = artifactx.WithImmutable(ctx)
+	}
+	if _, err := dst.Save(ctx, &artifact.SaveRequest{
+		AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName,
+		Part: &genai.Part{InlineData: &genai.Blob{Data: data, MIMEType: attrs.ContentType}}, Version: ref.Version,
+	}); err != nil {
+		return fmt.Errorf("failed to save %s: %w", ref, err)
+	}
+	if !attrs.Pinned {
+		return nil
+	}
+	pinner, ok := artifactx.As[artifactx.Pinner](dst)
+	if !ok {
+		return nil
+	}
+	if err := pinner.Pin(ctx, &artifact.LoadRequest{
+		AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName, Version: ref.Version,
+	}); err != nil {
+		return fmt.Errorf("failed to pin %s: %w", ref, err)
+	}
+	return nil
+}
+
+// Backup copies every version of src not covered by m to dst, keeping keys,
+// version numbers and attributes, and records the copied versions in m. A
+// nil m copies everything. src must implement [artifactx.Walker] and
+// [artifactx.Stater]. Requests are made with [artifactx.Background]
+// priority.
+func Backup(ctx context.Context, src, dst artifact.Service, m *Manifest) (*Result, error) {
+	ctx = artifactx.WithPriority(ctx, artifactx.Background)
+	refs, err := pending(ctx, src, m)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{}
+	for _, ref := range refs {
+		data, attrs, err := open(ctx, src, ref)
+		if err != nil {
+			return res, err
+		}
+		if err := restore(ctx, dst, ref, data, attrs); err != nil {
+			return res, err
+		}
+		res.Versions++
+		res.Bytes += int64(len(data))
+		m.record(ref, attrs.Created)
+	}
+	return res, nil
+}
+
+// WriteArchive writes every version of src not covered by m to w as a tar
+// archive with its attributes, and records the archived versions in m. A nil
+// m archives everything. src must implement [artifactx.Walker] and
+// [artifactx.Stater]. Requests are made with [artifactx.Background]
+// priority.
+func WriteArchive(ctx context.Context, src artifact.Service, w io.Writer, m *Manifest) (*Result, error) {
+	ctx = artifactx.WithPriority(ctx, artifactx.Background)
+	refs, err := pending(ctx, src, m)
+	if err != nil {
+		return nil, err
+	}
+	tw := tar.NewWriter(w)
+	res := &Result{}
+	for _, ref := range refs {
+		data, attrs, err := open(ctx, src, ref)
+		if err != nil {
+			return res, err
+		}
+		records := map[string]string{contentTypeRecord: attrs.ContentType}
+		if len(attrs.Metadata) > 0 {
+			md, err := json.Marshal(attrs.Metadata)
+			if err != nil {
+				return res, fmt.Errorf("failed to encode metadata of %s: %w", ref, err)
+			}
+			records[metadataRecord] = string(md)
+		}
+		if !attrs.Created.IsZero() {
+			records[createdRecord] = attrs.Created.UTC().Format(time.RFC3339Nano)
+		}
+		if attrs.Pinned {
+			records[pinnedRecord] = "true"
+		}
+		if attrs.Immutable {
+			records[immutableRecord] = "true"
+		}
+		hdr := &tar.Header{
+			Name:       ref.String(),
+			Mode:       0644,
+			Size:       int64(len(data)),
+			ModTime:    attrs.Created,
+			Format:     tar.FormatPAX,
+			PAXRecords: records,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return res, fmt.Errorf("failed to write archive header: %w", err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return res, fmt.Errorf("failed to write archive entry: %w", err)
+		}
+		res.Versions++
+		res.Bytes += int64(len(data))
+		m.record(ref, attrs.Created)
+	}
+	if err := tw.Close(); err != nil {
+		return res, fmt.Errorf("failed to close archive: %w", err)
+	}
+	return res, nil
+}
+
+// archivedAttributes returns the attributes of a version recorded in the
+// PAX records of hdr. Archives written before the attributes were recorded
+// only have the content type.
+func archivedAttributes(hdr *tar.Header) (*artifactx.Attributes, error) {
+	attrs := &artifactx.Attributes{
+		ContentType: cmp.Or(hdr.PAXRecords[contentTypeRecord], "application/octet-stream"),
+		Pinned:      hdr.PAXRecords[pinnedRecord] == "true",
+		Immutable:   hdr.PAXRecords[immutableRecord] == "true",
+	}
+	if md, ok := hdr.PAXRecords[metadataRecord]; ok {
+		if err := json.Unmarshal([]byte(md), &attrs.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode metadata: %w", err)
+		}
+	}
+	if created, ok := hdr.PAXRecords[createdRecord]; ok {
+		t, err := time.Parse(time.RFC3339Nano, created)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode creation time: %w", err)
+		}
+		attrs.Created = t
+	}
+	return attrs, nil
+}
+
+// RestoreArchive saves every version of an archive written by [WriteArchive]
+// to dst under its original key and version number, with its attributes.
+// Incremental archives are restored by calling RestoreArchive on each of
+// them, oldest first.
+func RestoreArchive(ctx context.Context, r io.Reader, dst artifact.Service) (*Result, error) {
+	tr := tar.NewReader(r)
+	res := &Result{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return res, fmt.Errorf("failed to read archive: %w", err)
+		}
+		ref, err := artifactx.ParseRef(hdr.Name)
+		if err != nil {
+			return res, err
+		}
+		attrs, err := archivedAttributes(hdr)
+		if err != nil {
+			return res, fmt.Errorf("failed to read archive entry %q: %w", hdr.Name, err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return res, fmt.Errorf("failed to read archive entry %q: %w", hdr.Name, err)
+		}
+		if err := restore(ctx, dst, ref, data, attrs); err != nil {
+			return res, err
+		}
+		res.Versions++
+		res.Bytes += int64(len(data))
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactbackup_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"gocloud.dev/blob/memblob"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactbackup"
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/blobartifact"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+)
+
+func newService(t *testing.T) artifact.Service {
+	t.Helper()
+	srv, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+func save(t *testing.T, srv artifact.Service, fileName, data string) {
+	t.Helper()
+	if _, err := srv.Save(t.Context(), &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: fileName,
+		Part: genai.NewPartFromBytes([]byte(data), "application/json"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// snapshot returns every version of srv with its content.
+func snapshot(t *testing.T, srv artifact.Service) map[artifactx.Ref]*genai.Part {
+	t.Helper()
+	got := map[artifactx.Ref]*genai.Part{}
+	err := srv.(artifactx.Walker).Walk(t.Context(), func(ref artifactx.Ref) error {
+		resp, err := srv.Load(context.Background(), &artifact.LoadRequest{
+			AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName, Version: ref.Version,
+		})
+		if err != nil {
+			return err
+		}
+		got[ref] = resp.Part
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestArchiveIncremental(t *testing.T) {
+	ctx := t.Context()
+	src := newService(t)
+	save(t, src, "file", "v1")
+	save(t, src, "file", "v2")
+	save(t, src, "user:profile", "p1")
+
+	m, err := artifactbackup.ReadManifest(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var full, incr bytes.Buffer
+	res, err := artifactbackup.WriteArchive(ctx, src, &full, m)
+	if err != nil || res.Versions != 3 {
+		t.Fatalf("WriteArchive() = (%+v, %v), want 3 versions", res, err)
+	}
+
+	save(t, src, "file", "v3")
+	res, err = artifactbackup.WriteArchive(ctx, src, &incr, m)
+	if err != nil || res.Versions != 1 {
+		t.Fatalf("incremental WriteArchive() = (%+v, %v), want 1 version", res, err)
+	}
+
+	dst := newService(t)
+	for _, archive := range []*bytes.Buffer{&full, &incr} {
+		if _, err := artifactbackup.RestoreArchive(ctx, archive, dst); err != nil {
+			t.Fatalf("RestoreArchive() failed: %v", err)
+		}
+	}
+	if diff := cmp.Diff(snapshot(t, src), snapshot(t, dst)); diff != "" {
+		t.Errorf("restored store mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBackupToSecondaryStore(t *testing.T) {
+	ctx := t.Context()
+	src, dst := newService(t), newService(t)
+	save(t, src, "file", "v1")
+
+	m := &artifactbackup.Manifest{}
+	if _, err := artifactbackup.Backup(ctx, src, dst, m); err != nil {
+		t.Fatalf("Backup() failed: %v", err)
+	}
+	save(t, src, "file", "v2")
+	res, err := artifactbackup.Backup(ctx, src, dst, m)
+	if err != nil || res.Versions != 1 {
+		t.Fatalf("incremental Backup() = (%+v, %v), want 1 version", res, err)
+	}
+	if diff := cmp.Diff(snapshot(t, src), snapshot(t, dst)); diff != "" {
+		t.Errorf("backup mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// stored returns the stored bytes and attributes of every version of srv.
+func stored(t *testing.T, srv artifact.Service) map[artifactx.Ref]string {
+	t.Helper()
+	got := map[artifactx.Ref]string{}
+	err := srv.(artifactx.Walker).Walk(t.Context(), func(ref artifactx.Ref) error {
+		r, err := srv.(artifactx.Opener).Open(t.Context(), &artifact.LoadRequest{
+			AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName, Version: ref.Version,
+		})
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		a := r.Attributes
+		got[ref] = fmt.Sprintf("%q %s %v created=%v pinned=%t immutable=%t", data, a.ContentType, a.Metadata, a.Created.UTC(), a.Pinned, a.Immutable)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestBackupAttributes(t *testing.T) {
+	ctx := t.Context()
+	clock := artifactx.NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	src, err := fsartifact.New(t.TempDir(), fsartifact.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := func(fileName string) *artifact.SaveRequest {
+		return &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: fileName}
+	}
+	// UTF-16 text, which loading converts to UTF-8.
+	r := req("notes.txt")
+	r.Part = genai.NewPartFromBytes([]byte("\xff\xfeh\x00i\x00"), "text/plain")
+	if _, err := src.Save(artifactx.WithAuthor(ctx, "alice"), r); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Hour)
+	r = req("input.pdf")
+	r.Part = genai.NewPartFromBytes([]byte("%PDF"), "application/pdf")
+	if _, err := src.Save(artifactx.WithImmutable(artifactx.WithMetadata(ctx, map[string]string{"source": "upload"})), r); err != nil {
+		t.Fatal(err)
+	}
+	if err := src.(artifactx.Pinner).Pin(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "notes.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Hour) // the copies are made later
+	want := stored(t, src)
+
+	dst := blobartifact.New(memblob.OpenBucket(nil), blobartifact.WithClock(clock))
+	if _, err := artifactbackup.Backup(ctx, src, dst, nil); err != nil {
+		t.Fatalf("Backup() failed: %v", err)
+	}
+	if diff := cmp.Diff(want, stored(t, dst)); diff != "" {
+		t.Errorf("Backup() mismatch (-want +got):\n%s", diff)
+	}
+
+	var archive bytes.Buffer
+	if _, err := artifactbackup.WriteArchive(ctx, src, &archive, nil); err != nil {
+		t.Fatalf("WriteArchive() failed: %v", err)
+	}
+	restored, err := fsartifact.New(t.TempDir(), fsartifact.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := artifactbackup.RestoreArchive(ctx, &archive, restored); err != nil {
+		t.Fatalf("RestoreArchive() failed: %v", err)
+	}
+	if diff := cmp.Diff(want, stored(t, restored)); diff != "" {
+		t.Errorf("RestoreArchive() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestManifestVersions(t *testing.T) {
+	ctx := t.Context()
+	clock := artifactx.NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	src, err := fsartifact.New(t.TempDir(), fsartifact.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	saveVersion := func(version int64, data string) {
+		t.Helper()
+		clock.Advance(time.Minute)
+		if _, err := src.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+			Part: genai.NewPartFromBytes([]byte(data), "application/json"), Version: version,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	saveVersion(2, "v2")
+	saveVersion(3, "v3")
+
+	m := &artifactbackup.Manifest{}
+	dst := newService(t)
+	if res, err := artifactbackup.Backup(ctx, src, dst, m); err != nil || res.Versions != 2 {
+		t.Fatalf("Backup() = (%+v, %v), want 2 versions", res, err)
+	}
+	if res, err := artifactbackup.Backup(ctx, src, dst, m); err != nil || res.Versions != 0 {
+		t.Fatalf("Backup() without changes = (%+v, %v), want no versions", res, err)
+	}
+
+	// A version below the latest backed up, and a version saved again.
+	saveVersion(1, "v1")
+	if err := src.Delete(ctx, &artifact.DeleteRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Version: 2}); err != nil {
+		t.Fatal(err)
+	}
+	saveVersion(2, "v2 again")
+	if res, err := artifactbackup.Backup(ctx, src, dst, m); err != nil || res.Versions != 2 {
+		t.Fatalf("incremental Backup() = (%+v, %v), want versions 1 and 2", res, err)
+	}
+	if diff := cmp.Diff(snapshot(t, src), snapshot(t, dst)); diff != "" {
+		t.Errorf("backup mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactbackup
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// zipName returns the name of the entry of fileName in a session archive:
+// user scoped artifacts are stored below "user/", and names cannot escape
+// the archive.
+func zipName(fileName string) string {
+	dir := ""
+	if artifactx.IsUserScoped(fileName) {
+		dir, fileName = "user/", strings.TrimPrefix(fileName, "user:")
+	}
+	return dir + strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(fileName, `\`, "/")), "/")
+}
+
+// zipNames gives the artifacts of an archive distinct entry names, as
+// different file names can have the same entry name, such as `a\b` and
+// "a/b" or "user:x" and "user/x", and a file can have the name of the
+// directory of another, such as "a" and "a/b", which archive tools cannot
+// extract both of.
+type zipNames struct {
+	files, dirs map[string]bool
+}
+
+// add returns the entry name of fileName: its [zipName], with "~2", "~3"
+// and so on added to the first element taken by an earlier entry.
+func (z *zipNames) add(fileName string) string {
+	if z.files == nil {
+		z.files, z.dirs = map[string]bool{}, map[string]bool{}
+	}
+	elems := strings.Split(zipName(fileName), "/")
+	for i, elem := range elems {
+		parent := strings.Join(elems[:i], "/")
+		taken := func(name string) bool {
+			name = path.Join(parent, name)
+			return z.files[name] || (i == len(elems)-1 && z.dirs[name])
+		}
+		ext := path.Ext(elem)
+		for n := 2; taken(elems[i]); n++ {
+			elems[i] = fmt.Sprintf("%s~%d%s", strings.TrimSuffix(elem, ext), n, ext)
+		}
+		if i < len(elems)-1 {
+			z.dirs[path.Join(parent, elems[i])] = true
+		}
+	}
+	name := strings.Join(elems, "/")
+	z.files[name] = true
+	return name
+}
+
+// WriteSessionZip writes the latest version of every artifact of a session,
+// including the user scoped artifacts visible from it, to w as a zip
+// archive. Versions are streamed if src implements [artifactx.Opener].
+// Artifacts whose entry names would collide are given distinct ones with a
+// "~2" suffix, in the order of their file names.
+func WriteSessionZip(ctx context.Context, src artifact.Service, w io.Writer, appName, userID, sessionID string) (*Result, error) {
+	list, err := src.List(ctx, &artifact.ListRequest{AppName: appName, UserID: userID, SessionID: sessionID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+	return writeZip(ctx, src, w, appName, userID, sessionID, list.FileNames)
+}
+
+func writeZip(ctx context.Context, src artifact.Service, w io.Writer, appName, userID, sessionID string, fileNames []string) (*Result, error) {
+	zw := zip.NewWriter(w)
+	res := &Result{}
+	var names zipNames
+	for _, fileName := range slices.Sorted(slices.Values(fileNames)) {
+		reader, err := artifactx.Open(ctx, src, &artifact.LoadRequest{AppName: appName, UserID: userID, SessionID: sessionID, FileName: fileName})
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // deleted since listing
+		}
+		if err != nil {
+			return res, fmt.Errorf("failed to load %q: %w", fileName, err)
+		}
+		hdr := &zip.FileHeader{Name: names.add(fileName), Method: zip.Deflate, Modified: reader.ModTime}
+		entry, err := zw.CreateHeader(hdr)
+		if err != nil {
+			reader.Close()
+			return res, fmt.Errorf("failed to write archive header: %w", err)
+		}
+		n, err := io.Copy(entry, reader)
+		reader.Close()
+		if err != nil {
+			return res, fmt.Errorf("failed to write archive entry: %w", err)
+		}
+		res.Versions++
+		res.Bytes += n
+	}
+	if err := zw.Close(); err != nil {
+		return res, fmt.Errorf("failed to close archive: %w", err)
+	}
+	return res, nil
+}
+
+// SessionZipHandler returns a handler downloading the artifacts of a session
+// as a zip archive written by [WriteSessionZip]. The session is taken from
+// the "app", "user" and "session" wildcards of the route it is registered
+// with:
+//
+//	mux.Handle("GET /apps/{app}/users/{user}/sessions/{session}/artifacts.zip",
+//		artifactbackup.SessionZipHandler(srv))
+//
+// The handler does no access control: wrap srv with aclartifact, or the
+// handler in one authenticating the user of the route. Sessions
+// without artifacts are answered with 404 Not Found. Failures after the
+// archive started are logged with logger, nil meaning [slog.Default], and
+// cut the response short.
+func SessionZipHandler(srv artifact.Service, logger *slog.Logger) http.Handler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		appName, userID, sessionID := r.PathValue("app"), r.PathValue("user"), r.PathValue("session")
+		if appName == "" || userID == "" || sessionID == "" {
+			http.Error(w, "app, user and session are required", http.StatusBadRequest)
+			return
+		}
+		ctx := r.Context()
+		list, err := srv.List(ctx, &artifact.ListRequest{AppName: appName, UserID: userID, SessionID: sessionID})
+		switch {
+		case errors.Is(err, fs.ErrPermission):
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		case err != nil && !errors.Is(err, fs.ErrNotExist):
+			logger.ErrorContext(ctx, "failed to list session artifacts", "session", sessionID, "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		case err != nil || len(list.FileNames) == 0:
+			http.Error(w, "session has no artifacts", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(sessionID) + ".zip"}))
+		if r.Method == http.MethodHead {
+			return
+		}
+		if _, err := writeZip(ctx, srv, w, appName, userID, sessionID, list.FileNames); err != nil {
+			logger.ErrorContext(ctx, "failed to write session archive", "session", sessionID, "error", err)
+			// Abort the response rather than let the client keep a
+			// truncated archive that looks complete.
+			panic(http.ErrAbortHandler)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactbackup_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactbackup"
+)
+
+// unzip returns the content of the entries of a zip archive by name.
+func unzip(t *testing.T, archive []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := map[string]string{}
+	for _, f := range zr.File {
+		if _, ok := entries[f.Name]; ok {
+			t.Errorf("archive has several %q entries", f.Name)
+		}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[f.Name] = string(data)
+	}
+	return entries
+}
+
+func TestSessionZipHandler(t *testing.T) {
+	srv := newService(t)
+	save(t, srv, "report.json", `{"v":1}`)
+	save(t, srv, "report.json", `{"v":2}`)
+	save(t, srv, "user:profile.json", `{"name":"x"}`)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /apps/{app}/users/{user}/sessions/{session}/artifacts.zip", artifactbackup.SessionZipHandler(srv, nil))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/apps/app/users/user/sessions/session/artifacts.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/zip" {
+		t.Fatalf("GET = %s (%s), want a zip archive", resp.Status, resp.Header.Get("Content-Type"))
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := unzip(t, body)
+	want := map[string]string{"report.json": `{"v":2}`, "user/profile.json": `{"name":"x"}`}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("archive mismatch (-want +got):\n%s", diff)
+	}
+
+	resp, err = http.Get(ts.URL + "/apps/app/users/other/sessions/session/artifacts.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET of an empty session = %s, want 404 Not Found", resp.Status)
+	}
+}
+
+func TestWriteSessionZipCollisions(t *testing.T) {
+	srv := artifact.InMemoryService()
+	for _, fileName := range []string{"a", "a/b", `a\b`, "user/notes.txt", "user:notes.txt"} {
+		save(t, srv, fileName, fileName)
+	}
+	var buf bytes.Buffer
+	res, err := artifactbackup.WriteSessionZip(t.Context(), srv, &buf, "app", "user", "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Versions != 5 {
+		t.Errorf("WriteSessionZip() wrote %d versions, want 5", res.Versions)
+	}
+	want := map[string]string{
+		"a": "a", "a~2/b": "a/b", "a~2/b~2": `a\b`,
+		"user/notes.txt": "user/notes.txt", "user/notes~2.txt": "user:notes.txt",
+	}
+	if diff := cmp.Diff(want, unzip(t, buf.Bytes())); diff != "" {
+		t.Errorf("archive mismatch (-want +got):\n%s", diff)
+	}
+}
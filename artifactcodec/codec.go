@@ -0,0 +1,164 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package artifactcodec saves Go values as structured artifacts and loads
+// them back into typed values.
+//
+//	ref := artifactx.Ref{AppName: "app", UserID: "user", SessionID: "session", FileName: "plan.json"}
+//	version, err := artifactcodec.SaveJSON(ctx, srv, ref, plan)
+//	...
+//	plan, err := artifactcodec.LoadJSON[Plan](ctx, srv, ref)
+package artifactcodec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// ErrContentType is returned when a loaded artifact was not saved in the
+// format of the requested codec.
+var ErrContentType = errors.New("unexpected artifact content type")
+
+// Codec converts values to and from artifact bytes.
+type Codec struct {
+	// ContentType is the media type artifacts are saved with.
+	ContentType string
+	Marshal     func(v any) ([]byte, error)
+	Unmarshal   func(data []byte, v any) error
+}
+
+var (
+	// JSON encodes values with encoding/json as application/json.
+	JSON = Codec{ContentType: "application/json", Marshal: json.Marshal, Unmarshal: json.Unmarshal}
+	// YAML encodes values with gopkg.in/yaml.v3 as application/yaml.
+	YAML = Codec{ContentType: "application/yaml", Marshal: yaml.Marshal, Unmarshal: yaml.Unmarshal}
+	// Proto encodes [proto.Message] values in the protobuf wire format as application/x-protobuf.
+	Proto = Codec{ContentType: "application/x-protobuf", Marshal: marshalProto, Unmarshal: unmarshalProto}
+)
+
+func marshalProto(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func unmarshalProto(data []byte, v any) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T is not a proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// Save encodes v with c and saves it as ref. A zero ref.Version creates a
+// new version. It returns the saved version.
+func Save(ctx context.Context, srv artifact.Service, ref artifactx.Ref, c Codec, v any) (int64, error) {
+	data, err := c.Marshal(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode %s: %w", c.ContentType, err)
+	}
+	resp, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName,
+		Part: genai.NewPartFromBytes(data, c.ContentType), Version: ref.Version,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Version, nil
+}
+
+// LoadInto loads ref and decodes it with c into v. A zero ref.Version loads
+// the latest version. Artifacts saved as text are accepted by the text based
+// codecs, so JSON written by a model as a plain text part loads too.
+func LoadInto(ctx context.Context, srv artifact.Service, ref artifactx.Ref, c Codec, v any) error {
+	resp, err := srv.Load(ctx, &artifact.LoadRequest{
+		AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName, Version: ref.Version,
+	})
+	if err != nil {
+		return err
+	}
+	var data []byte
+	var contentType string
+	switch {
+	case resp.Part.InlineData != nil:
+		data, contentType = resp.Part.InlineData.Data, resp.Part.InlineData.MIMEType
+	case resp.Part.Text != "":
+		data, contentType = []byte(resp.Part.Text), "text/plain"
+	default:
+		return fmt.Errorf("artifact %q holds a %s part: %w", ref.FileName, artifactx.PartKind(resp.Part), ErrContentType)
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != c.ContentType && (mediaType != "text/plain" || c.ContentType == Proto.ContentType) {
+		return fmt.Errorf("artifact %q has content type %q, want %q: %w", ref.FileName, contentType, c.ContentType, ErrContentType)
+	}
+	if err := c.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", c.ContentType, err)
+	}
+	return nil
+}
+
+// Load loads ref and decodes it with c into a new T.
+func Load[T any](ctx context.Context, srv artifact.Service, ref artifactx.Ref, c Codec) (T, error) {
+	var v T
+	err := LoadInto(ctx, srv, ref, c, &v)
+	return v, err
+}
+
+// SaveJSON saves v as a JSON artifact.
+func SaveJSON(ctx context.Context, srv artifact.Service, ref artifactx.Ref, v any) (int64, error) {
+	return Save(ctx, srv, ref, JSON, v)
+}
+
+// LoadJSON loads a JSON artifact into a new T.
+func LoadJSON[T any](ctx context.Context, srv artifact.Service, ref artifactx.Ref) (T, error) {
+	return Load[T](ctx, srv, ref, JSON)
+}
+
+// SaveYAML saves v as a YAML artifact.
+func SaveYAML(ctx context.Context, srv artifact.Service, ref artifactx.Ref, v any) (int64, error) {
+	return Save(ctx, srv, ref, YAML, v)
+}
+
+// LoadYAML loads a YAML artifact into a new T.
+func LoadYAML[T any](ctx context.Context, srv artifact.Service, ref artifactx.Ref) (T, error) {
+	return Load[T](ctx, srv, ref, YAML)
+}
+
+// SaveProto saves m as a protobuf artifact.
+func SaveProto(ctx context.Context, srv artifact.Service, ref artifactx.Ref, m proto.Message) (int64, error) {
+	return Save(ctx, srv, ref, Proto, m)
+}
+
+// LoadProto loads a protobuf artifact into a new message of type M, which is
+// a generated message pointer type such as *pb.Report.
+func LoadProto[M proto.Message](ctx context.Context, srv artifact.Service, ref artifactx.Ref) (M, error) {
+	var zero M
+	m := zero.ProtoReflect().Type().New().Interface().(M)
+	if err := LoadInto(ctx, srv, ref, Proto, m); err != nil {
+		return zero, err
+	}
+	return m, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactcodec_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/chinglinwen/adk-artifact/artifactcodec"
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+type plan struct {
+	Goal  string   `json:"goal" yaml:"goal"`
+	Steps []string `json:"steps" yaml:"steps"`
+}
+
+var ref = artifactx.Ref{AppName: "app", UserID: "user", SessionID: "session", FileName: "plan"}
+
+func TestJSONAndYAML(t *testing.T) {
+	ctx := t.Context()
+	want := plan{Goal: "ship", Steps: []string{"build", "test"}}
+	for _, tc := range []struct {
+		name string
+		save func(artifact.Service) (int64, error)
+		load func(artifact.Service) (plan, error)
+	}{
+		{
+			"json",
+			func(srv artifact.Service) (int64, error) { return artifactcodec.SaveJSON(ctx, srv, ref, want) },
+			func(srv artifact.Service) (plan, error) { return artifactcodec.LoadJSON[plan](ctx, srv, ref) },
+		},
+		{
+			"yaml",
+			func(srv artifact.Service) (int64, error) { return artifactcodec.SaveYAML(ctx, srv, ref, want) },
+			func(srv artifact.Service) (plan, error) { return artifactcodec.LoadYAML[plan](ctx, srv, ref) },
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := artifact.InMemoryService()
+			if v, err := tc.save(srv); err != nil || v != 1 {
+				t.Fatalf("Save() = (%d, %v), want (1, nil)", v, err)
+			}
+			got, err := tc.load(srv)
+			if err != nil {
+				t.Fatalf("Load() failed: %v", err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadJSONFromText(t *testing.T) {
+	ctx := t.Context()
+	srv := artifact.InMemoryService()
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName,
+		Part: genai.NewPartFromText(`{"goal":"ship"}`),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := artifactcodec.LoadJSON[plan](ctx, srv, ref)
+	if err != nil || got.Goal != "ship" {
+		t.Errorf("LoadJSON() = (%+v, %v), want goal %q", got, err, "ship")
+	}
+}
+
+func TestProto(t *testing.T) {
+	ctx := t.Context()
+	srv := artifact.InMemoryService()
+	want, err := structpb.NewStruct(map[string]any{"goal": "ship", "steps": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := artifactcodec.SaveProto(ctx, srv, ref, want); err != nil {
+		t.Fatalf("SaveProto() failed: %v", err)
+	}
+	got, err := artifactcodec.LoadProto[*structpb.Struct](ctx, srv, ref)
+	if err != nil {
+		t.Fatalf("LoadProto() failed: %v", err)
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("LoadProto() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := artifactcodec.LoadJSON[plan](ctx, srv, ref); !errors.Is(err, artifactcodec.ErrContentType) {
+		t.Errorf("LoadJSON(proto artifact) = %v, want %v", err, artifactcodec.ErrContentType)
+	}
+}
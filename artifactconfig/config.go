@@ -0,0 +1,384 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package artifactconfig builds a complete [artifact.Service] stack, the
+// backend and the layers wrapping it, from a YAML or JSON file and
+// environment variables, so that deployments tune storage without code
+// changes:
+//
+//	cfg, err := artifactconfig.FromEnv()
+//	if err != nil {
+//		...
+//	}
+//	srv, err := artifactconfig.Open(ctx, cfg)
+//
+// A file such as
+//
+//	backend: s3://agent-artifacts
+//	prefix: prod/
+//	s3:
+//	  region: eu-west-1
+//	retries:
+//	  maxAttempts: 5
+//	  maxBackoff: 20s
+//	encryption:
+//	  algorithm: aws:kms
+//	  kmsKeyId: alias/artifacts
+//	retention:
+//	  maxVersions: 20
+//	  maxAge: 30d
+//	cache:
+//	  dir: /var/cache/artifacts
+//	  maxBytes: 10GiB
+//
+// is selected with ADK_ARTIFACT_CONFIG, and every setting can be overridden
+// by the environment variable listed in [EnvVars]. The rate limits,
+// retention, quota and log level of a running stack can be changed with
+// [Stack.Reload] or [Stack.WatchFile].
+package artifactconfig
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"mime"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes an artifact service stack. The zero value of a section
+// leaves the corresponding layer or setting out.
+type Config struct {
+	// Backend is the storage: a directory path or an fs:// URL for
+	// fsartifact, an s3://BUCKET URL for s3artifact, or the URL of a bucket
+	// of another registered gocloud.dev blob driver for blobartifact.
+	Backend string `json:"backend" yaml:"backend"`
+	// Layout is the key layout: "default", "escaped", "zero-based" or
+	// "python".
+	Layout string `json:"layout,omitempty" yaml:"layout,omitempty"`
+	// Prefix keeps the objects of bucket backends below a prefix.
+	Prefix string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	// MaxLoadSize makes Load fail for larger versions.
+	MaxLoadSize Size `json:"maxLoadSize,omitempty" yaml:"maxLoadSize,omitempty"`
+	// DefaultContentType is the content type of versions saved without
+	// one. Empty keeps text/plain for file system backends and the type
+	// buckets detect.
+	DefaultContentType string `json:"defaultContentType,omitempty" yaml:"defaultContentType,omitempty"`
+
+	S3         S3Config         `json:"s3,omitzero" yaml:"s3,omitempty"`
+	Retries    RetryConfig      `json:"retries,omitzero" yaml:"retries,omitempty"`
+	Encryption EncryptionConfig `json:"encryption,omitzero" yaml:"encryption,omitempty"`
+	Retention  RetentionConfig  `json:"retention,omitzero" yaml:"retention,omitempty"`
+	Cache      CacheConfig      `json:"cache,omitzero" yaml:"cache,omitempty"`
+	RateLimits RateLimitConfig  `json:"rateLimits,omitzero" yaml:"rateLimits,omitempty"`
+	Quota      QuotaConfig      `json:"quota,omitzero" yaml:"quota,omitempty"`
+	// LogLevel is the minimum level of the messages the services log,
+	// such as "debug" or "warn"; empty means "info".
+	LogLevel string `json:"logLevel,omitempty" yaml:"logLevel,omitempty"`
+}
+
+// S3Config configures the S3 client of s3:// backends.
+type S3Config struct {
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+	// Endpoint is the URL of an S3 compatible store, such as MinIO, which
+	// is then addressed with path-style requests.
+	Endpoint string `json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+}
+
+// RetryConfig configures the retries of failed storage requests by the S3
+// client. Other blob drivers retry on their own.
+type RetryConfig struct {
+	// MaxAttempts is the number of attempts of a request, the first one
+	// included.
+	MaxAttempts int `json:"maxAttempts,omitempty" yaml:"maxAttempts,omitempty"`
+	// MaxBackoff bounds the delay between attempts.
+	MaxBackoff Duration `json:"maxBackoff,omitempty" yaml:"maxBackoff,omitempty"`
+}
+
+// EncryptionConfig configures the server-side encryption of the objects
+// written to s3:// backends.
+type EncryptionConfig struct {
+	// Algorithm is "AES256" or "aws:kms".
+	Algorithm string `json:"algorithm,omitempty" yaml:"algorithm,omitempty"`
+	KMSKeyID  string `json:"kmsKeyId,omitempty" yaml:"kmsKeyId,omitempty"`
+	BucketKey bool   `json:"bucketKey,omitempty" yaml:"bucketKey,omitempty"`
+}
+
+// RetentionConfig limits the versions kept per artifact, see
+// [artifactx.Retention].
+type RetentionConfig struct {
+	MaxVersions int      `json:"maxVersions,omitempty" yaml:"maxVersions,omitempty"`
+	MaxAge      Duration `json:"maxAge,omitempty" yaml:"maxAge,omitempty"`
+}
+
+// CacheConfig configures the local disk cache of byte ranges of
+// rangecacheartifact, added if Dir is set.
+type CacheConfig struct {
+	Dir       string `json:"dir,omitempty" yaml:"dir,omitempty"`
+	MaxBytes  Size   `json:"maxBytes,omitempty" yaml:"maxBytes,omitempty"`
+	BlockSize Size   `json:"blockSize,omitempty" yaml:"blockSize,omitempty"`
+}
+
+// RateLimitConfig limits the bandwidth of transfers in bytes per second,
+// see throttleartifact.Limits.
+type RateLimitConfig struct {
+	Upload         Size `json:"upload,omitempty" yaml:"upload,omitempty"`
+	Download       Size `json:"download,omitempty" yaml:"download,omitempty"`
+	GlobalUpload   Size `json:"globalUpload,omitempty" yaml:"globalUpload,omitempty"`
+	GlobalDownload Size `json:"globalDownload,omitempty" yaml:"globalDownload,omitempty"`
+}
+
+// QuotaConfig bounds the disk space of file system backends, see
+// fsartifact.SpaceLimit.
+type QuotaConfig struct {
+	MaxSize Size `json:"maxSize,omitempty" yaml:"maxSize,omitempty"`
+	MinFree Size `json:"minFree,omitempty" yaml:"minFree,omitempty"`
+	Evict   bool `json:"evict,omitempty" yaml:"evict,omitempty"`
+}
+
+// Layouts are the names of the key layouts of [Config.Layout].
+var Layouts = []string{"default", "escaped", "zero-based", "python"}
+
+// Validate checks that c describes a stack [Open] can build.
+func (c *Config) Validate() error {
+	var errs []error
+	kind, _ := backendKind(c.Backend)
+	switch {
+	case c.Backend == "":
+		errs = append(errs, errors.New("backend is required"))
+	case kind == "fs" && c.Prefix != "":
+		errs = append(errs, errors.New("prefix is not supported by file system backends"))
+	}
+	if kind != "fs" && c.Quota != (QuotaConfig{}) {
+		errs = append(errs, errors.New("quota requires a file system backend"))
+	}
+	if c.Layout != "" && !slices.Contains(Layouts, c.Layout) {
+		errs = append(errs, fmt.Errorf("unknown layout %q, want one of %s", c.Layout, strings.Join(Layouts, ", ")))
+	}
+	if kind != "s3" {
+		if c.S3 != (S3Config{}) {
+			errs = append(errs, errors.New("s3 settings require an s3:// backend"))
+		}
+		if c.Retries != (RetryConfig{}) {
+			errs = append(errs, errors.New("retries require an s3:// backend"))
+		}
+		if c.Encryption != (EncryptionConfig{}) {
+			errs = append(errs, errors.New("encryption requires an s3:// backend"))
+		}
+	}
+	if c.Retries.MaxAttempts < 0 || c.Retries.MaxBackoff < 0 {
+		errs = append(errs, errors.New("retries must not be negative"))
+	}
+	switch alg := types.ServerSideEncryption(c.Encryption.Algorithm); {
+	case alg == "" && c.Encryption != (EncryptionConfig{}):
+		errs = append(errs, errors.New("encryption algorithm is required"))
+	case alg != "" && alg != types.ServerSideEncryptionAes256 && alg != types.ServerSideEncryptionAwsKms:
+		errs = append(errs, fmt.Errorf("unknown encryption algorithm %q, want %q or %q", alg, types.ServerSideEncryptionAes256, types.ServerSideEncryptionAwsKms))
+	case alg == types.ServerSideEncryptionAes256 && (c.Encryption.KMSKeyID != "" || c.Encryption.BucketKey):
+		errs = append(errs, errors.New("kmsKeyId and bucketKey require aws:kms encryption"))
+	}
+	if c.DefaultContentType != "" {
+		if _, _, err := mime.ParseMediaType(c.DefaultContentType); err != nil {
+			errs = append(errs, fmt.Errorf("invalid default content type %q: %w", c.DefaultContentType, err))
+		}
+	}
+	if _, err := c.logLevel(); err != nil {
+		errs = append(errs, err)
+	}
+	if c.MaxLoadSize < 0 || c.Retention.MaxVersions < 0 || c.Retention.MaxAge < 0 || c.Cache.MaxBytes < 0 || c.Cache.BlockSize < 0 ||
+		c.RateLimits.Upload < 0 || c.RateLimits.Download < 0 || c.RateLimits.GlobalUpload < 0 || c.RateLimits.GlobalDownload < 0 ||
+		c.Quota.MaxSize < 0 || c.Quota.MinFree < 0 {
+		errs = append(errs, errors.New("sizes, durations and counts must not be negative"))
+	}
+	if c.Cache.Dir == "" && c.Cache != (CacheConfig{}) {
+		errs = append(errs, errors.New("cache dir is required"))
+	}
+	return errors.Join(errs...)
+}
+
+// logLevel returns the level of [Config.LogLevel].
+func (c *Config) logLevel() (slog.Level, error) {
+	var level slog.Level
+	if c.LogLevel == "" {
+		return level, nil
+	}
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return level, fmt.Errorf("invalid log level %q", c.LogLevel)
+	}
+	return level, nil
+}
+
+// Load reads the configuration file at path, YAML unless its extension is
+// .json. Unknown settings are errors, so that typos are not ignored.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+	c := &Config{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(c)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err = dec.Decode(c); errors.Is(err, io.EOF) {
+			err = nil // empty file
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse config file '%s': %w", path, err)
+	}
+	return c, nil
+}
+
+// ConfigEnvVar names the environment variable holding the path of the
+// configuration file of [FromEnv].
+const ConfigEnvVar = "ADK_ARTIFACT_CONFIG"
+
+// EnvVars maps the environment variables [FromEnv] reads to the settings
+// they override.
+var EnvVars = map[string]string{
+	"ADK_ARTIFACT_BACKEND":                "backend",
+	"ADK_ARTIFACT_LAYOUT":                 "layout",
+	"ADK_ARTIFACT_PREFIX":                 "prefix",
+	"ADK_ARTIFACT_MAX_LOAD_SIZE":          "maxLoadSize",
+	"ADK_ARTIFACT_DEFAULT_CONTENT_TYPE":   "defaultContentType",
+	"ADK_ARTIFACT_S3_REGION":              "s3.region",
+	"ADK_ARTIFACT_S3_ENDPOINT":            "s3.endpoint",
+	"ADK_ARTIFACT_RETRY_MAX_ATTEMPTS":     "retries.maxAttempts",
+	"ADK_ARTIFACT_RETRY_MAX_BACKOFF":      "retries.maxBackoff",
+	"ADK_ARTIFACT_ENCRYPTION":             "encryption.algorithm",
+	"ADK_ARTIFACT_ENCRYPTION_KMS_KEY_ID":  "encryption.kmsKeyId",
+	"ADK_ARTIFACT_ENCRYPTION_BUCKET_KEY":  "encryption.bucketKey",
+	"ADK_ARTIFACT_RETENTION_MAX_VERSIONS": "retention.maxVersions",
+	"ADK_ARTIFACT_RETENTION_MAX_AGE":      "retention.maxAge",
+	"ADK_ARTIFACT_CACHE_DIR":              "cache.dir",
+	"ADK_ARTIFACT_CACHE_MAX_BYTES":        "cache.maxBytes",
+	"ADK_ARTIFACT_CACHE_BLOCK_SIZE":       "cache.blockSize",
+}
+
+// FromEnv returns the configuration of the file named by [ConfigEnvVar],
+// if set, overridden by the environment variables of [EnvVars], and
+// validated.
+func FromEnv() (*Config, error) {
+	return fromEnv(os.LookupEnv)
+}
+
+func fromEnv(lookup func(string) (string, bool)) (*Config, error) {
+	c := &Config{}
+	if path, ok := lookup(ConfigEnvVar); ok && path != "" {
+		var err error
+		if c, err = Load(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.ApplyEnv(lookup); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid artifact configuration: %w", err)
+	}
+	return c, nil
+}
+
+// ApplyEnv overrides the settings of c with the environment variables of
+// [EnvVars] that lookup, such as [os.LookupEnv], finds.
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	setters := map[string]func(string) error{
+		"backend":                   setString(&c.Backend),
+		"layout":                    setString(&c.Layout),
+		"prefix":                    setString(&c.Prefix),
+		"maxLoadSize":               setText(&c.MaxLoadSize),
+		"defaultContentType":        setString(&c.DefaultContentType),
+		"s3.region":                 setString(&c.S3.Region),
+		"s3.endpoint":               setString(&c.S3.Endpoint),
+		"retries.maxAttempts":       setInt(&c.Retries.MaxAttempts),
+		"retries.maxBackoff":        setText(&c.Retries.MaxBackoff),
+		"encryption.algorithm":      setString(&c.Encryption.Algorithm),
+		"encryption.kmsKeyId":       setString(&c.Encryption.KMSKeyID),
+		"encryption.bucketKey":      setBool(&c.Encryption.BucketKey),
+		"retention.maxVersions":     setInt(&c.Retention.MaxVersions),
+		"retention.maxAge":          setText(&c.Retention.MaxAge),
+		"cache.dir":                 setString(&c.Cache.Dir),
+		"cache.maxBytes":            setText(&c.Cache.MaxBytes),
+		"cache.blockSize":           setText(&c.Cache.BlockSize),
+		"rateLimits.upload":         setText(&c.RateLimits.Upload),
+		"rateLimits.download":       setText(&c.RateLimits.Download),
+		"rateLimits.globalUpload":   setText(&c.RateLimits.GlobalUpload),
+		"rateLimits.globalDownload": setText(&c.RateLimits.GlobalDownload),
+		"quota.maxSize":             setText(&c.Quota.MaxSize),
+		"quota.minFree":             setText(&c.Quota.MinFree),
+		"quota.evict":               setBool(&c.Quota.Evict),
+		"logLevel":                  setString(&c.LogLevel),
+	}
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(EnvVars)) {
+		v, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := setters[EnvVars[name]](v); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func setString(p *string) func(string) error {
+	return func(v string) error {
+		*p = v
+		return nil
+	}
+}
+
+func setText(u encoding.TextUnmarshaler) func(string) error {
+	return func(v string) error {
+		return u.UnmarshalText([]byte(v))
+	}
+}
+
+func setInt(p *int) func(string) error {
+	return func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", v)
+		}
+		*p = n
+		return nil
+	}
+}
+
+func setBool(p *bool) func(string) error {
+	return func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", v)
+		}
+		*p = b
+		return nil
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactconfig_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	_ "gocloud.dev/blob/memblob"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactconfig"
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	want := &artifactconfig.Config{
+		Backend: "s3://agent-artifacts",
+		Prefix:  "prod/",
+		S3:      artifactconfig.S3Config{Region: "eu-west-1"},
+		Retries: artifactconfig.RetryConfig{MaxAttempts: 5, MaxBackoff: artifactconfig.Duration(20 * time.Second)},
+		Encryption: artifactconfig.EncryptionConfig{
+			Algorithm: "aws:kms", KMSKeyID: "alias/artifacts",
+		},
+		Retention: artifactconfig.RetentionConfig{MaxVersions: 20, MaxAge: artifactconfig.Duration(30 * 24 * time.Hour)},
+		Cache:     artifactconfig.CacheConfig{Dir: "/var/cache/artifacts", MaxBytes: 10 << 30, BlockSize: 1 << 20},
+	}
+	for name, content := range map[string]string{
+		"artifacts.yaml": `
+backend: s3://agent-artifacts
+prefix: prod/
+s3:
+  region: eu-west-1
+retries:
+  maxAttempts: 5
+  maxBackoff: 20s
+encryption:
+  algorithm: aws:kms
+  kmsKeyId: alias/artifacts
+retention:
+  maxVersions: 20
+  maxAge: 30d
+cache:
+  dir: /var/cache/artifacts
+  maxBytes: 10GiB
+  blockSize: 1048576
+`,
+		"artifacts.json": `{
+  "backend": "s3://agent-artifacts", "prefix": "prod/", "s3": {"region": "eu-west-1"},
+  "retries": {"maxAttempts": 5, "maxBackoff": "20s"},
+  "encryption": {"algorithm": "aws:kms", "kmsKeyId": "alias/artifacts"},
+  "retention": {"maxVersions": 20, "maxAge": "720h"},
+  "cache": {"dir": "/var/cache/artifacts", "maxBytes": "10GiB", "blockSize": 1048576}
+}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := artifactconfig.Load(writeFile(t, name, content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+			}
+			if err := got.Validate(); err != nil {
+				t.Errorf("Validate() = %v", err)
+			}
+		})
+	}
+
+	if _, err := artifactconfig.Load(writeFile(t, "typo.yaml", "backend: /tmp\nretension:\n  maxVersions: 3\n")); err == nil {
+		t.Error("Load() of an unknown setting succeeded")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(artifactconfig.ConfigEnvVar, writeFile(t, "artifacts.yaml", "backend: /srv/artifacts\nretention:\n  maxVersions: 5\n"))
+	t.Setenv("ADK_ARTIFACT_RETENTION_MAX_VERSIONS", "3")
+	t.Setenv("ADK_ARTIFACT_MAX_LOAD_SIZE", "64MiB")
+	t.Setenv("ADK_ARTIFACT_CACHE_DIR", "/var/cache/artifacts")
+	t.Setenv("ADK_ARTIFACT_DEFAULT_CONTENT_TYPE", "application/octet-stream")
+	c, err := artifactconfig.FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Backend != "/srv/artifacts" || c.Retention.MaxVersions != 3 || c.MaxLoadSize != 64<<20 || c.Cache.Dir != "/var/cache/artifacts" ||
+		c.DefaultContentType != "application/octet-stream" {
+		t.Errorf("FromEnv() = %+v, want the file overridden by the environment", c)
+	}
+
+	t.Setenv("ADK_ARTIFACT_ENCRYPTION", "AES256")
+	if _, err := artifactconfig.FromEnv(); err == nil || !strings.Contains(err.Error(), "encryption requires an s3:// backend") {
+		t.Errorf("FromEnv() with encryption of a file system backend error = %v", err)
+	}
+	t.Setenv("ADK_ARTIFACT_MAX_LOAD_SIZE", "lots")
+	if _, err := artifactconfig.FromEnv(); err == nil || !strings.Contains(err.Error(), "ADK_ARTIFACT_MAX_LOAD_SIZE") {
+		t.Errorf("FromEnv() with an invalid size error = %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, c := range []artifactconfig.Config{
+		{},
+		{Backend: "/srv/artifacts", Layout: "flat"},
+		{Backend: "/srv/artifacts", Prefix: "prod/"},
+		{Backend: "mem://", Retries: artifactconfig.RetryConfig{MaxAttempts: 3}},
+		{Backend: "s3://bucket", Encryption: artifactconfig.EncryptionConfig{Algorithm: "rot13"}},
+		{Backend: "s3://bucket", Encryption: artifactconfig.EncryptionConfig{Algorithm: "AES256", KMSKeyID: "alias/artifacts"}},
+		{Backend: "s3://bucket", Cache: artifactconfig.CacheConfig{MaxBytes: 1 << 30}},
+		{Backend: "/srv/artifacts", DefaultContentType: "text/"},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded", c)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	ctx := t.Context()
+	for _, backend := range []string{t.TempDir(), "fs://" + t.TempDir(), "mem://"} {
+		srv, err := artifactconfig.Open(ctx, &artifactconfig.Config{
+			Backend:   backend,
+			Retention: artifactconfig.RetentionConfig{MaxVersions: 2},
+			Cache:     artifactconfig.CacheConfig{Dir: t.TempDir()},
+		})
+		if err != nil {
+			t.Fatalf("Open(%s) failed: %v", backend, err)
+		}
+		if _, ok := artifactx.As[artifactx.Prefetcher](srv); !ok {
+			t.Errorf("Open(%s) returned a service without the cache", backend)
+		}
+		for _, text := range []string{"v1", "v2", "v3"} {
+			if _, err := srv.Save(ctx, &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "notes.txt", Part: genai.NewPartFromText(text)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "notes.txt"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Versions) != 2 {
+			t.Errorf("Versions() of %s = %v, want 2 versions kept by the retention", backend, resp.Versions)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	ctx := t.Context()
+	c := &artifactconfig.Config{Backend: t.TempDir(), Retention: artifactconfig.RetentionConfig{MaxVersions: 3}}
+	srv, err := artifactconfig.Open(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	save := func(data []byte) error {
+		_, err := srv.Save(ctx, &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "data.bin", Part: genai.NewPartFromBytes(data, "application/octet-stream")})
+		return err
+	}
+
+	reloaded := *c
+	reloaded.Retention.MaxVersions = 1
+	reloaded.Quota = artifactconfig.QuotaConfig{MaxSize: 64 << 10}
+	reloaded.RateLimits = artifactconfig.RateLimitConfig{Download: 1 << 20}
+	reloaded.LogLevel = "debug"
+	if err := srv.Reload(&reloaded); err != nil {
+		t.Fatalf("Reload() failed: %v", err)
+	}
+	for range 2 {
+		if err := save([]byte("small")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "data.bin"})
+	if err != nil || len(resp.Versions) != 1 {
+		t.Errorf("Versions() after Reload() = (%v, %v), want 1 version kept by the new retention", resp, err)
+	}
+	if err := save(make([]byte, 128<<10)); !errors.Is(err, artifactx.ErrStorageFull) {
+		t.Errorf("Save() over the new quota = %v, want error(%v)", err, artifactx.ErrStorageFull)
+	}
+	if got := srv.Config(); got != reloaded {
+		t.Errorf("Config() = %+v, want %+v", got, reloaded)
+	}
+
+	for _, change := range []func(c *artifactconfig.Config){
+		func(c *artifactconfig.Config) { c.Backend = t.TempDir() },
+		func(c *artifactconfig.Config) { c.Cache.Dir = t.TempDir() },
+	} {
+		c := reloaded
+		change(&c)
+		if err := srv.Reload(&c); !errors.Is(err, artifactconfig.ErrRestartRequired) {
+			t.Errorf("Reload(%+v) = %v, want error(%v)", c, err, artifactconfig.ErrRestartRequired)
+		}
+	}
+	invalid := reloaded
+	invalid.LogLevel = "loud"
+	if err := srv.Reload(&invalid); err == nil {
+		t.Error("Reload() with an invalid log level succeeded")
+	}
+	if got := srv.Config(); got != reloaded {
+		t.Errorf("Config() after rejected reloads = %+v, want %+v", got, reloaded)
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, "artifacts.yaml", "backend: "+dir+"\nretention:\n  maxVersions: 3\n")
+	c, err := artifactconfig.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := artifactconfig.Open(t.Context(), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	reloads := make(chan error, 1)
+	done := make(chan error)
+	go func() {
+		done <- srv.WatchFile(ctx, path, func(err error) {
+			select {
+			case reloads <- err:
+			default:
+			}
+		})
+	}()
+
+	// The file is rewritten until the watch, started concurrently, sees it.
+	var reloadErr error
+	for i := 0; ; i++ {
+		content := fmt.Sprintf("# revision %d\nbackend: %s\nretention:\n  maxVersions: 1\n", i, dir)
+		if err := os.WriteFile(path+".tmp", []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(path+".tmp", path); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case reloadErr = <-reloads:
+		case <-time.After(500 * time.Millisecond):
+			continue
+		}
+		break
+	}
+	if reloadErr != nil {
+		t.Errorf("reload failed: %v", reloadErr)
+	}
+	if got := srv.Config().Retention.MaxVersions; got != 1 {
+		t.Errorf("retention after the file changed keeps %d versions, want 1", got)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("WatchFile() = %v, want nil once the context is done", err)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactconfig
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/blobartifact"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"github.com/chinglinwen/adk-artifact/rangecacheartifact"
+	"github.com/chinglinwen/adk-artifact/s3artifact"
+	"github.com/chinglinwen/adk-artifact/throttleartifact"
+)
+
+// backendKind returns the kind of backend of [Config.Backend], "fs", "s3"
+// or "blob", and the directory, bucket name or bucket URL it stores in.
+func backendKind(backend string) (string, string) {
+	scheme, rest, ok := strings.Cut(backend, "://")
+	switch {
+	case !ok:
+		return "fs", backend
+	case scheme == "fs":
+		return "fs", rest
+	case scheme == "s3":
+		if u, err := url.Parse(backend); err == nil && (u.Path == "" || u.Path == "/") && u.RawQuery == "" {
+			return "s3", u.Host
+		}
+		// Bucket URLs with options are left to the blob driver.
+		return "blob", backend
+	default:
+		return "blob", backend
+	}
+}
+
+// KeyBuilder returns the key builder of a layout of [Layouts], the default
+// one for "".
+func KeyBuilder(layout string) (artifactx.KeyBuilder, error) {
+	switch layout {
+	case "", "default":
+		return artifactx.DefaultKeyBuilder, nil
+	case "escaped":
+		return artifactx.EscapedKeyBuilder, nil
+	case "zero-based":
+		return artifactx.ZeroBasedKeyBuilder(artifactx.DefaultKeyBuilder), nil
+	case "python":
+		return artifactx.PythonKeyBuilder, nil
+	default:
+		return nil, fmt.Errorf("unknown layout %q", layout)
+	}
+}
+
+// Open validates c and builds the service it describes: the backend,
+// configured with the layout, prefix, load size limit, retention, quota,
+// retries and encryption of c, wrapped by throttleartifact with the rate
+// limits of c and by rangecacheartifact if a cache is set. The blob drivers
+// of other schemes than s3 must be registered by the application, for
+// example by importing gocloud.dev/blob/gcsblob.
+func Open(ctx context.Context, c *Config) (*Stack, error) {
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid artifact configuration: %w", err)
+	}
+	keys, err := KeyBuilder(c.Layout)
+	if err != nil {
+		return nil, err
+	}
+	level, _ := c.logLevel()
+	s := &Stack{cfg: *c, level: new(slog.LevelVar)}
+	s.level.Set(level)
+	logger := slog.New(levelHandler{slog.Default().Handler(), s.level})
+
+	var srv artifact.Service
+	switch kind, location := backendKind(c.Backend); kind {
+	case "fs":
+		srv, err = fsartifact.New(location,
+			fsartifact.WithKeyBuilder(keys),
+			fsartifact.WithRetention(c.retention()),
+			fsartifact.WithSpaceLimit(c.spaceLimit()),
+			fsartifact.WithMaxLoadSize(int64(c.MaxLoadSize)),
+			fsartifact.WithDefaultContentType(c.DefaultContentType),
+			fsartifact.WithLogger(logger),
+		)
+	case "s3":
+		srv, err = s3artifact.New(ctx, location, append(c.s3Options(keys), s3artifact.WithLogger(logger))...)
+	default:
+		srv, err = blobartifact.NewService(ctx, location,
+			blobartifact.WithPrefix(c.Prefix),
+			blobartifact.WithKeyBuilder(keys),
+			blobartifact.WithRetention(c.retention()),
+			blobartifact.WithMaxLoadSize(int64(c.MaxLoadSize)),
+			blobartifact.WithDefaultContentType(c.DefaultContentType),
+			blobartifact.WithLogger(logger),
+		)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The throttle is always in place so that Reload can set rate limits;
+	// without limits it forwards transfers unchanged.
+	s.throttle = throttleartifact.NewService(srv, c.limits())
+	srv = s.throttle
+	if c.Cache.Dir != "" {
+		srv, err = rangecacheartifact.NewService(srv, rangecacheartifact.Options{
+			Dir:       c.Cache.Dir,
+			MaxBytes:  int64(c.Cache.MaxBytes),
+			BlockSize: int64(c.Cache.BlockSize),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.Service = srv
+	return s, nil
+}
+
+// s3Options returns the options of s3artifact for c.
+func (c *Config) s3Options(keys artifactx.KeyBuilder) []s3artifact.Option {
+	var awsOpts []func(*config.LoadOptions) error
+	if c.S3.Region != "" {
+		awsOpts = append(awsOpts, config.WithRegion(c.S3.Region))
+	}
+	if r := c.Retries; r != (RetryConfig{}) {
+		awsOpts = append(awsOpts, config.WithRetryer(func() aws.Retryer {
+			return retry.NewStandard(func(o *retry.StandardOptions) {
+				if r.MaxAttempts > 0 {
+					o.MaxAttempts = r.MaxAttempts
+				}
+				if r.MaxBackoff > 0 {
+					o.MaxBackoff = time.Duration(r.MaxBackoff)
+				}
+			})
+		}))
+	}
+	opts := []s3artifact.Option{
+		s3artifact.WithAWSConfig(awsOpts...),
+		s3artifact.WithPrefix(c.Prefix),
+		s3artifact.WithKeyBuilder(keys),
+		s3artifact.WithRetention(c.retention()),
+		s3artifact.WithMaxLoadSize(int64(c.MaxLoadSize)),
+		s3artifact.WithDefaultContentType(c.DefaultContentType),
+	}
+	if c.S3.Endpoint != "" {
+		opts = append(opts, s3artifact.WithClientOptions(func(o *s3.Options) {
+			o.BaseEndpoint = aws.String(c.S3.Endpoint)
+			o.UsePathStyle = true
+		}))
+	}
+	if e := c.Encryption; e.Algorithm != "" {
+		opts = append(opts, s3artifact.WithServerSideEncryption(s3artifact.ServerSideEncryption{
+			Algorithm: types.ServerSideEncryption(e.Algorithm),
+			KMSKeyID:  e.KMSKeyID,
+			BucketKey: e.BucketKey,
+		}))
+	}
+	return opts
+}
+
+func (c *Config) retention() artifactx.Retention {
+	return artifactx.Retention{MaxVersions: c.Retention.MaxVersions, MaxAge: time.Duration(c.Retention.MaxAge)}
+}
+
+func (c *Config) spaceLimit() fsartifact.SpaceLimit {
+	return fsartifact.SpaceLimit{MaxSize: int64(c.Quota.MaxSize), MinFree: int64(c.Quota.MinFree), Evict: c.Quota.Evict}
+}
+
+func (c *Config) limits() throttleartifact.Limits {
+	r := c.RateLimits
+	return throttleartifact.Limits{
+		Upload:         int(r.Upload),
+		Download:       int(r.Download),
+		GlobalUpload:   int(r.GlobalUpload),
+		GlobalDownload: int(r.GlobalDownload),
+	}
+}
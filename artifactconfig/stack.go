@@ -0,0 +1,204 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactconfig
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"github.com/chinglinwen/adk-artifact/throttleartifact"
+)
+
+// ErrRestartRequired is returned by [Stack.Reload] for changes of settings
+// that only a new stack can apply.
+var ErrRestartRequired = errors.New("restart required")
+
+// Stack is the service built by [Open]. The rate limits, retention, quota
+// and log level of its configuration can be changed while it serves
+// requests, with [Stack.Reload] or [Stack.WatchFile].
+type Stack struct {
+	artifact.Service
+
+	mu       sync.Mutex // serializes reloads
+	cfg      Config
+	level    *slog.LevelVar
+	throttle *throttleartifact.Service
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Stack) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// Config returns the configuration in effect.
+func (s *Stack) Config() Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg
+}
+
+// Reload applies the rate limits, retention, quota and log level of c.
+// Running transfers keep their per-operation rate limits and the new
+// retention and quota are enforced from the next Save. Changes of other
+// settings are rejected with [ErrRestartRequired], leaving the stack
+// unchanged.
+func (s *Stack) Reload(c *Config) error {
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("invalid artifact configuration: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if changed := fixedChanges(&s.cfg, c); len(changed) > 0 {
+		return fmt.Errorf("%w: %s changed", ErrRestartRequired, strings.Join(changed, ", "))
+	}
+	// The quota is set first: it is the only setting that can fail.
+	if c.Quota != s.cfg.Quota {
+		l, ok := artifactx.As[fsartifact.SpaceLimiter](s.Service)
+		if !ok {
+			return fmt.Errorf("quota: %w", errors.ErrUnsupported)
+		}
+		if err := l.SetSpaceLimit(c.spaceLimit()); err != nil {
+			return err
+		}
+	}
+	if c.RateLimits != s.cfg.RateLimits {
+		s.throttle.SetLimits(c.limits())
+	}
+	if c.Retention != s.cfg.Retention {
+		if r, ok := artifactx.As[artifactx.RetentionSetter](s.Service); ok {
+			r.SetRetention(c.retention())
+		}
+	}
+	level, _ := c.logLevel()
+	s.level.Set(level)
+	s.cfg = *c
+	return nil
+}
+
+// fixedChanges returns the settings that differ between old and c and that
+// [Stack.Reload] cannot apply.
+func fixedChanges(old, c *Config) []string {
+	var changed []string
+	for _, f := range []struct {
+		name string
+		same bool
+	}{
+		{"backend", old.Backend == c.Backend},
+		{"layout", old.Layout == c.Layout},
+		{"prefix", old.Prefix == c.Prefix},
+		{"maxLoadSize", old.MaxLoadSize == c.MaxLoadSize},
+		{"defaultContentType", old.DefaultContentType == c.DefaultContentType},
+		{"s3", old.S3 == c.S3},
+		{"retries", old.Retries == c.Retries},
+		{"encryption", old.Encryption == c.Encryption},
+		{"cache", old.Cache == c.Cache},
+	} {
+		if !f.same {
+			changed = append(changed, f.name)
+		}
+	}
+	return changed
+}
+
+// reloadDelay is how long [Stack.WatchFile] waits for changes to settle,
+// as editors and deployment tools write a file in several steps.
+const reloadDelay = 100 * time.Millisecond
+
+// WatchFile reloads the stack whenever the configuration file at path
+// changes, until ctx is done, reading it like [FromEnv] with the overrides
+// of the environment. onReload, if not nil, is called with the result of
+// every reload; a file that fails to load or to apply leaves the stack
+// unchanged.
+//
+// The directory of path is watched rather than the file, so that files
+// replaced by a rename, as Kubernetes does for mounted ConfigMaps, are
+// followed.
+func (s *Stack) WatchFile(ctx context.Context, path string, onReload func(error)) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer w.Close()
+	if err := w.Add(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to watch %q: %w", path, err)
+	}
+	last, _ := os.ReadFile(path)
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-w.Errors:
+			return fmt.Errorf("failed to watch %q: %w", path, err)
+		case <-w.Events:
+			settled = time.After(reloadDelay)
+		case <-settled:
+			settled = nil
+			data, err := os.ReadFile(path)
+			if err == nil && bytes.Equal(data, last) {
+				// another file of the directory changed
+				continue
+			}
+			last = data
+			err = s.reloadFile(path)
+			if onReload != nil {
+				onReload(err)
+			}
+		}
+	}
+}
+
+func (s *Stack) reloadFile(path string) error {
+	c, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if err := c.ApplyEnv(os.LookupEnv); err != nil {
+		return err
+	}
+	return s.Reload(c)
+}
+
+// levelHandler is a [slog.Handler] logging the records from a level that
+// can be changed, in place of the level of the handler it wraps.
+type levelHandler struct {
+	slog.Handler
+	level slog.Leveler
+}
+
+func (h levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return levelHandler{h.Handler.WithAttrs(attrs), h.level}
+}
+
+func (h levelHandler) WithGroup(name string) slog.Handler {
+	return levelHandler{h.Handler.WithGroup(name), h.level}
+}
@@ -0,0 +1,197 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package artifactlabel applies or removes metadata across the artifacts of
+// a store matching a filter, for large scale reclassification jobs such as
+// tagging everything an app produced before a cutoff.
+//
+// Versions are enumerated with [artifactx.Walker], filtered with
+// [artifactx.Stater] and changed in place with [artifactx.Relabeler], by a
+// bounded number of workers.
+package artifactlabel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// DefaultConcurrency is the number of versions changed at once unless configured.
+const DefaultConcurrency = 8
+
+// Filter selects the versions to change. The zero Filter selects all of them.
+type Filter struct {
+	// Prefix selects the versions whose [artifactx.Ref.String] starts with
+	// it, e.g. "myapp/alice/" for the artifacts of a user.
+	Prefix string
+	// OlderThan and NewerThan select the versions created before or after
+	// that long ago, if set.
+	OlderThan, NewerThan time.Duration
+	// MinSize and MaxSize select the versions whose stored size is at least
+	// or at most that many bytes, if set.
+	MinSize, MaxSize int64
+}
+
+// match reports whether the version described by attrs is selected.
+func (f *Filter) match(attrs *artifactx.Attributes, now time.Time) bool {
+	age := now.Sub(attrs.Created)
+	switch {
+	case f.OlderThan > 0 && age < f.OlderThan,
+		f.NewerThan > 0 && age >= f.NewerThan,
+		f.MinSize > 0 && attrs.Size < f.MinSize,
+		f.MaxSize > 0 && attrs.Size > f.MaxSize:
+		return false
+	}
+	return true
+}
+
+// Progress reports the state of a [Relabel] job.
+type Progress struct {
+	// Scanned is the number of versions walked so far.
+	Scanned int
+	// Matched is the number of versions selected by the filter so far.
+	Matched int
+	// Changed is the number of matched versions whose metadata was changed;
+	// the others already had it.
+	Changed int
+	// Failed is the number of versions that could not be inspected or
+	// changed; they are not counted as matched.
+	Failed int
+}
+
+// Failure records a version that could not be changed.
+type Failure struct {
+	Ref artifactx.Ref
+	Err error
+}
+
+// Result is the outcome of a [Relabel] job.
+type Result struct {
+	Progress
+	Failures []Failure
+}
+
+// Options configures [Relabel].
+type Options struct {
+	// Concurrency is the number of versions changed at once; defaults to
+	// [DefaultConcurrency].
+	Concurrency int
+	// OnProgress, if set, is called after every version, never concurrently.
+	OnProgress func(Progress)
+	// Clock defaults to [artifactx.SystemClock].
+	Clock artifactx.Clock
+}
+
+// Relabel applies change to the metadata of every version of srv matched by
+// filter. Versions that cannot be changed are recorded in the result and do
+// not stop the job, which stops on errors of the walk and when ctx is done.
+// The storage requests are made at [artifactx.Background] priority.
+func Relabel(ctx context.Context, srv artifact.Service, filter Filter, change *artifactx.MetadataChange, opts Options) (*Result, error) {
+	if err := change.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid metadata change: %w", err)
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	if opts.Clock == nil {
+		opts.Clock = artifactx.SystemClock
+	}
+	walker, ok := artifactx.As[artifactx.Walker](srv)
+	if !ok {
+		return nil, fmt.Errorf("relabeling requires a service implementing artifactx.Walker")
+	}
+	stater, ok := artifactx.As[artifactx.Stater](srv)
+	if !ok {
+		return nil, fmt.Errorf("relabeling requires a service implementing artifactx.Stater")
+	}
+	relabeler, ok := artifactx.As[artifactx.Relabeler](srv)
+	if !ok {
+		return nil, fmt.Errorf("relabeling requires a service implementing artifactx.Relabeler")
+	}
+
+	ctx = artifactx.WithPriority(ctx, artifactx.Background)
+	now := opts.Clock.Now()
+	res := &Result{}
+	var mu sync.Mutex
+	// update records the outcome of a version under mu and reports progress.
+	update := func(fn func()) {
+		mu.Lock()
+		defer mu.Unlock()
+		fn()
+		if opts.OnProgress != nil {
+			opts.OnProgress(res.Progress)
+		}
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(opts.Concurrency)
+	err := walker.Walk(gctx, func(ref artifactx.Ref) error {
+		if !strings.HasPrefix(ref.String(), filter.Prefix) {
+			update(func() { res.Scanned++ })
+			return nil
+		}
+		g.Go(func() error {
+			matched, changed, err := relabel(gctx, stater, relabeler, ref, filter, change, now)
+			update(func() {
+				res.Scanned++
+				switch {
+				case err != nil:
+					res.Failed++
+					res.Failures = append(res.Failures, Failure{Ref: ref, Err: err})
+				case matched:
+					res.Matched++
+					if changed {
+						res.Changed++
+					}
+				}
+			})
+			return nil
+		})
+		return gctx.Err()
+	})
+	g.Wait()
+	if err != nil {
+		return res, fmt.Errorf("failed to walk artifacts: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// relabel changes the version ref if filter matches it.
+func relabel(ctx context.Context, stater artifactx.Stater, relabeler artifactx.Relabeler, ref artifactx.Ref, filter Filter, change *artifactx.MetadataChange, now time.Time) (matched, changed bool, err error) {
+	req := ref.LoadRequest()
+	attrs, err := stater.Stat(ctx, req)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to stat %s: %w", ref, err)
+	}
+	if !filter.match(attrs, now) {
+		return false, false, nil
+	}
+	if _, changed = change.Apply(attrs.Metadata); !changed {
+		return true, false, nil
+	}
+	if err := relabeler.Relabel(ctx, req, change); err != nil {
+		return true, false, fmt.Errorf("failed to relabel %s: %w", ref, err)
+	}
+	return true, true, nil
+}
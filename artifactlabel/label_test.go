@@ -0,0 +1,122 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactlabel_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactlabel"
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+)
+
+func TestRelabel(t *testing.T) {
+	ctx := t.Context()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := artifactx.NewManualClock(start)
+	srv, err := fsartifact.New(t.TempDir(), fsartifact.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	save := func(appName, fileName string, size int, md map[string]string) {
+		t.Helper()
+		if _, err := srv.Save(artifactx.WithMetadata(ctx, md), &artifact.SaveRequest{
+			AppName: appName, UserID: "alice", SessionID: "s1", FileName: fileName,
+			Part: genai.NewPartFromBytes(bytes.Repeat([]byte("x"), size), "application/octet-stream"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save("app", "old-big", 100, map[string]string{"stage": "draft"})
+	save("app", "old-small", 1, map[string]string{"stage": "draft"})
+	save("other", "old-big", 100, nil)
+	clock.Advance(40 * 24 * time.Hour)
+	save("app", "old-big", 100, map[string]string{"stage": "draft"}) // a new version of an old artifact
+	clock.Advance(time.Hour)
+
+	var (
+		mu      sync.Mutex
+		updates []artifactlabel.Progress
+	)
+	change := &artifactx.MetadataChange{Set: map[string]string{"class": "archive"}, Remove: []string{"stage"}}
+	filter := artifactlabel.Filter{Prefix: "app/", OlderThan: 30 * 24 * time.Hour, MinSize: 10}
+	res, err := artifactlabel.Relabel(ctx, srv, filter, change, artifactlabel.Options{
+		Concurrency: 2,
+		Clock:       clock,
+		OnProgress: func(p artifactlabel.Progress) {
+			mu.Lock()
+			defer mu.Unlock()
+			updates = append(updates, p)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := artifactlabel.Progress{Scanned: 4, Matched: 1, Changed: 1}
+	if diff := cmp.Diff(want, res.Progress); diff != "" || len(res.Failures) != 0 {
+		t.Errorf("Relabel() = %+v, want %+v (-want +got):\n%s", res, want, diff)
+	}
+	if len(updates) != 4 || updates[3] != want {
+		t.Errorf("progress updates = %v, want 4 ending with %v", updates, want)
+	}
+
+	stat := func(appName, fileName string, version int64) map[string]string {
+		t.Helper()
+		attrs, err := srv.(artifactx.Stater).Stat(ctx, &artifact.LoadRequest{
+			AppName: appName, UserID: "alice", SessionID: "s1", FileName: fileName, Version: version,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return attrs.Metadata
+	}
+	for _, tc := range []struct {
+		appName, fileName string
+		version           int64
+		want              map[string]string
+	}{
+		{"app", "old-big", 1, map[string]string{"class": "archive"}},
+		{"app", "old-big", 2, map[string]string{"stage": "draft"}},
+		{"app", "old-small", 1, map[string]string{"stage": "draft"}},
+		{"other", "old-big", 1, nil},
+	} {
+		if diff := cmp.Diff(tc.want, stat(tc.appName, tc.fileName, tc.version)); diff != "" {
+			t.Errorf("metadata of %s/%s/%d mismatch (-want +got):\n%s", tc.appName, tc.fileName, tc.version, diff)
+		}
+	}
+
+	// Versions that already have the metadata are matched but not changed.
+	res, err = artifactlabel.Relabel(ctx, srv, filter, change, artifactlabel.Options{Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (artifactlabel.Progress{Scanned: 4, Matched: 1}); res.Progress != want {
+		t.Errorf("second Relabel() = %+v, want %+v", res.Progress, want)
+	}
+
+	if _, err := artifactlabel.Relabel(ctx, srv, artifactlabel.Filter{}, &artifactx.MetadataChange{}, artifactlabel.Options{}); err == nil {
+		t.Errorf("Relabel() of an empty change succeeded, want an error")
+	}
+	if _, err := artifactlabel.Relabel(ctx, artifact.InMemoryService(), artifactlabel.Filter{}, change, artifactlabel.Options{}); err == nil {
+		t.Errorf("Relabel() of a service without artifactx.Walker succeeded, want an error")
+	}
+}
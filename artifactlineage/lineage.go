@@ -0,0 +1,284 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package artifactlineage records the provenance of artifact versions, the
+// input versions a version was derived from and the agent and tool that
+// derived it, and queries the lineage graph they form, to explain how a
+// generated report came to be.
+//
+// Provenance is stored with the derived version, as metadata under
+// [MetadataKey], so it is kept, copied and deleted with it and needs no
+// store of its own. It is attached when the version is saved, with a
+// context returned by [WithDerivation], or afterwards with [Record].
+// Querying requires a service implementing [artifactx.Stater], and building
+// the whole graph with [Index] one implementing [artifactx.Walker].
+//
+// Buckets limit the size of the metadata of an object, to 2 KiB for S3, which
+// bounds the number of inputs a version can record there.
+package artifactlineage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// MetadataKey is the metadata key under which the provenance of a version
+// is stored, as JSON.
+const MetadataKey = "provenance"
+
+// Derivation describes how a version was produced.
+type Derivation struct {
+	// Inputs are the versions the version was derived from. They must name
+	// explicit versions, which unlike the latest version never change.
+	Inputs []artifactx.Ref `json:"inputs"`
+	// Agent and Tool name the agent and the tool that derived the version,
+	// if known.
+	Agent string `json:"agent,omitempty"`
+	Tool  string `json:"tool,omitempty"`
+}
+
+// Validate checks that d names explicit input versions.
+func (d *Derivation) Validate() error {
+	if len(d.Inputs) == 0 {
+		return errors.New("no inputs")
+	}
+	for _, in := range d.Inputs {
+		if in.AppName == "" || in.UserID == "" || in.SessionID == "" || in.FileName == "" || in.Version <= 0 {
+			return fmt.Errorf("input %s must name an artifact version", in)
+		}
+	}
+	return nil
+}
+
+// stored is the representation of a [Derivation] under [MetadataKey]; refs
+// are in the short form of [artifactx.Ref.String].
+type stored struct {
+	Inputs []string `json:"inputs"`
+	Agent  string   `json:"agent,omitempty"`
+	Tool   string   `json:"tool,omitempty"`
+}
+
+func (d *Derivation) metadata() (map[string]string, error) {
+	if err := d.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid derivation: %w", err)
+	}
+	s := stored{Agent: d.Agent, Tool: d.Tool}
+	for _, in := range d.Inputs {
+		s.Inputs = append(s.Inputs, in.String())
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode derivation: %w", err)
+	}
+	return map[string]string{MetadataKey: string(data)}, nil
+}
+
+// parse returns the derivation stored in md, or nil if there is none.
+func parse(md map[string]string) (*Derivation, error) {
+	v, ok := md[MetadataKey]
+	if !ok {
+		return nil, nil
+	}
+	var s stored
+	if err := json.Unmarshal([]byte(v), &s); err != nil {
+		return nil, fmt.Errorf("failed to decode derivation: %w", err)
+	}
+	d := &Derivation{Agent: s.Agent, Tool: s.Tool}
+	for _, in := range s.Inputs {
+		ref, err := artifactx.ParseRef(in)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode derivation: %w", err)
+		}
+		d.Inputs = append(d.Inputs, ref)
+	}
+	return d, nil
+}
+
+// WithDerivation returns a copy of ctx that makes Save record d as the
+// provenance of the version it writes.
+func WithDerivation(ctx context.Context, d Derivation) (context.Context, error) {
+	md, err := d.metadata()
+	if err != nil {
+		return nil, err
+	}
+	return artifactx.WithMetadata(ctx, md), nil
+}
+
+// Record records d as the provenance of the saved version output, or of the
+// latest version if output.Version is zero, replacing what was recorded. srv
+// must implement [artifactx.Relabeler].
+func Record(ctx context.Context, srv artifact.Service, output artifactx.Ref, d Derivation) error {
+	md, err := d.metadata()
+	if err != nil {
+		return err
+	}
+	relabeler, ok := artifactx.As[artifactx.Relabeler](srv)
+	if !ok {
+		return fmt.Errorf("recording provenance requires a service implementing artifactx.Relabeler")
+	}
+	return relabeler.Relabel(ctx, output.LoadRequest(), &artifactx.MetadataChange{Set: md})
+}
+
+// Edge links a version to the versions it was derived from.
+type Edge struct {
+	Output artifactx.Ref `json:"output"`
+	Derivation
+}
+
+// Lookup returns the provenance of the version ref, or of the latest version
+// if ref.Version is zero, with the number of the version as its output. The
+// edge has no inputs if no provenance was recorded.
+func Lookup(ctx context.Context, srv artifact.Service, ref artifactx.Ref) (*Edge, error) {
+	stater, ok := artifactx.As[artifactx.Stater](srv)
+	if !ok {
+		return nil, fmt.Errorf("provenance queries require a service implementing artifactx.Stater")
+	}
+	return lookup(ctx, stater, ref)
+}
+
+func lookup(ctx context.Context, stater artifactx.Stater, ref artifactx.Ref) (*Edge, error) {
+	attrs, err := stater.Stat(ctx, ref.LoadRequest())
+	if err != nil {
+		return nil, err
+	}
+	ref.Version = attrs.Version
+	d, err := parse(attrs.Metadata)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ref, err)
+	}
+	if d == nil {
+		return &Edge{Output: ref}, nil
+	}
+	return &Edge{Output: ref, Derivation: *d}, nil
+}
+
+// Graph is a set of provenance edges.
+type Graph struct {
+	Edges []Edge `json:"edges"`
+	// Missing are inputs that no longer exist, such as deleted versions.
+	Missing []artifactx.Ref `json:"missing,omitempty"`
+}
+
+// Lineage returns the graph of the versions ref was derived from, directly
+// or not, or of the latest version if ref.Version is zero. Its first edge is
+// the one of ref; versions without recorded provenance have no edge.
+func Lineage(ctx context.Context, srv artifact.Service, ref artifactx.Ref) (*Graph, error) {
+	stater, ok := artifactx.As[artifactx.Stater](srv)
+	if !ok {
+		return nil, fmt.Errorf("provenance queries require a service implementing artifactx.Stater")
+	}
+	g := &Graph{Edges: []Edge{}}
+	seen := map[artifactx.Ref]bool{}
+	queue := []artifactx.Ref{ref}
+	for len(queue) > 0 {
+		ref, queue = queue[0], queue[1:]
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		e, err := lookup(ctx, stater, ref)
+		if errors.Is(err, fs.ErrNotExist) && len(seen) > 1 {
+			g.Missing = append(g.Missing, ref)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(e.Inputs) == 0 {
+			continue
+		}
+		g.Edges = append(g.Edges, *e)
+		queue = append(queue, e.Inputs...)
+	}
+	return g, nil
+}
+
+// Index walks srv and returns the graph of all recorded provenance, to
+// query the versions derived from a version with [Graph.Descendants].
+func Index(ctx context.Context, srv artifact.Service) (*Graph, error) {
+	walker, ok := artifactx.As[artifactx.Walker](srv)
+	if !ok {
+		return nil, fmt.Errorf("indexing provenance requires a service implementing artifactx.Walker")
+	}
+	stater, ok := artifactx.As[artifactx.Stater](srv)
+	if !ok {
+		return nil, fmt.Errorf("provenance queries require a service implementing artifactx.Stater")
+	}
+	g := &Graph{Edges: []Edge{}}
+	err := walker.Walk(ctx, func(ref artifactx.Ref) error {
+		e, err := lookup(ctx, stater, ref)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil // deleted since listing
+		}
+		if err != nil {
+			return err
+		}
+		if len(e.Inputs) > 0 {
+			g.Edges = append(g.Edges, *e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk artifacts: %w", err)
+	}
+	return g, nil
+}
+
+// Ancestors returns the versions ref was derived from in g, directly or not,
+// nearest first.
+func (g *Graph) Ancestors(ref artifactx.Ref) []artifactx.Ref {
+	inputs := map[artifactx.Ref][]artifactx.Ref{}
+	for _, e := range g.Edges {
+		inputs[e.Output] = e.Inputs
+	}
+	return traverse(ref, inputs)
+}
+
+// Descendants returns the versions derived from ref in g, directly or not,
+// nearest first.
+func (g *Graph) Descendants(ref artifactx.Ref) []artifactx.Ref {
+	outputs := map[artifactx.Ref][]artifactx.Ref{}
+	for _, e := range g.Edges {
+		for _, in := range e.Inputs {
+			outputs[in] = append(outputs[in], e.Output)
+		}
+	}
+	return traverse(ref, outputs)
+}
+
+// traverse returns the refs reachable from ref through next, breadth first.
+func traverse(ref artifactx.Ref, next map[artifactx.Ref][]artifactx.Ref) []artifactx.Ref {
+	var out []artifactx.Ref
+	seen := map[artifactx.Ref]bool{ref: true}
+	queue := slices.Clone(next[ref])
+	for len(queue) > 0 {
+		r := queue[0]
+		queue = queue[1:]
+		if seen[r] {
+			continue
+		}
+		seen[r] = true
+		out = append(out, r)
+		queue = append(queue, next[r]...)
+	}
+	return out
+}
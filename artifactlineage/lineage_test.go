@@ -0,0 +1,113 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactlineage_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactlineage"
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+)
+
+func TestLineage(t *testing.T) {
+	ctx := t.Context()
+	srv, err := fsartifact.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := func(fileName string, version int64) artifactx.Ref {
+		return artifactx.Ref{AppName: "app", UserID: "alice", SessionID: "s1", FileName: fileName, Version: version}
+	}
+	save := func(fileName string, d *artifactlineage.Derivation) {
+		t.Helper()
+		saveCtx := ctx
+		if d != nil {
+			if saveCtx, err = artifactlineage.WithDerivation(ctx, *d); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := srv.Save(saveCtx, &artifact.SaveRequest{
+			AppName: "app", UserID: "alice", SessionID: "s1", FileName: fileName, Part: genai.NewPartFromText(fileName),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// sales.csv and rates.csv -> summary.json -> report.pdf, and chart.png -> report.pdf
+	save("sales.csv", nil)
+	save("rates.csv", nil)
+	save("chart.png", nil)
+	summary := artifactlineage.Derivation{Inputs: []artifactx.Ref{ref("sales.csv", 1), ref("rates.csv", 1)}, Agent: "analyst", Tool: "pandas"}
+	save("summary.json", &summary)
+	save("report.pdf", nil)
+	report := artifactlineage.Derivation{Inputs: []artifactx.Ref{ref("summary.json", 1), ref("chart.png", 1)}, Agent: "writer"}
+	if err := artifactlineage.Record(ctx, srv, ref("report.pdf", 0), report); err != nil {
+		t.Fatalf("Record() failed: %v", err)
+	}
+
+	e, err := artifactlineage.Lookup(ctx, srv, ref("summary.json", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&artifactlineage.Edge{Output: ref("summary.json", 1), Derivation: summary}, e); diff != "" {
+		t.Errorf("Lookup() mismatch (-want +got):\n%s", diff)
+	}
+	if e, err := artifactlineage.Lookup(ctx, srv, ref("sales.csv", 1)); err != nil || len(e.Inputs) != 0 {
+		t.Errorf("Lookup() of an input = (%+v, %v), want no inputs", e, err)
+	}
+
+	// Deleted inputs are reported as missing.
+	if err := srv.Delete(ctx, &artifact.DeleteRequest{AppName: "app", UserID: "alice", SessionID: "s1", FileName: "chart.png"}); err != nil {
+		t.Fatal(err)
+	}
+	g, err := artifactlineage.Lineage(ctx, srv, ref("report.pdf", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &artifactlineage.Graph{
+		Edges: []artifactlineage.Edge{
+			{Output: ref("report.pdf", 1), Derivation: report},
+			{Output: ref("summary.json", 1), Derivation: summary},
+		},
+		Missing: []artifactx.Ref{ref("chart.png", 1)},
+	}
+	if diff := cmp.Diff(want, g); diff != "" {
+		t.Errorf("Lineage() mismatch (-want +got):\n%s", diff)
+	}
+	wantAncestors := []artifactx.Ref{ref("summary.json", 1), ref("chart.png", 1), ref("sales.csv", 1), ref("rates.csv", 1)}
+	if diff := cmp.Diff(wantAncestors, g.Ancestors(ref("report.pdf", 1))); diff != "" {
+		t.Errorf("Ancestors() mismatch (-want +got):\n%s", diff)
+	}
+
+	index, err := artifactlineage.Index(ctx, srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Edges) != 2 {
+		t.Errorf("Index() = %+v, want 2 edges", index)
+	}
+	wantDescendants := []artifactx.Ref{ref("summary.json", 1), ref("report.pdf", 1)}
+	if diff := cmp.Diff(wantDescendants, index.Descendants(ref("sales.csv", 1))); diff != "" {
+		t.Errorf("Descendants() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := artifactlineage.WithDerivation(ctx, artifactlineage.Derivation{Inputs: []artifactx.Ref{ref("sales.csv", 0)}}); err == nil {
+		t.Errorf("WithDerivation() of the latest version of an input succeeded, want an error")
+	}
+}
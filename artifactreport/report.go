@@ -0,0 +1,218 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package artifactreport analyzes what an artifact store holds to guide
+// cleanup policies: the largest artifacts, the artifacts with the most
+// versions, and the sessions nobody saved to for a while.
+//
+// Reports are computed by walking the store, which must implement
+// [artifactx.Walker] and [artifactx.Stater], and can be written as JSON or
+// CSV.
+package artifactreport
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// DefaultTop is the number of entries of each ranking unless configured.
+const DefaultTop = 20
+
+// DefaultStaleAfter is how long a session must be untouched to be reported
+// as stale unless configured.
+const DefaultStaleAfter = 30 * 24 * time.Hour
+
+// Options configures [Analyze].
+type Options struct {
+	// Top is the number of entries of each ranking; defaults to [DefaultTop].
+	Top int
+	// StaleAfter is how long since the last save a session is reported as
+	// stale; defaults to [DefaultStaleAfter].
+	StaleAfter time.Duration
+	// Clock defaults to [artifactx.SystemClock].
+	Clock artifactx.Clock
+}
+
+// File describes an artifact with all its versions.
+type File struct {
+	AppName   string `json:"appName"`
+	UserID    string `json:"userId"`
+	SessionID string `json:"sessionId"`
+	FileName  string `json:"fileName"`
+	// Versions is the number of stored versions.
+	Versions int `json:"versions"`
+	// Bytes is the total size of the stored versions.
+	Bytes int64 `json:"bytes"`
+	// Modified is when the latest version was saved.
+	Modified time.Time `json:"modified"`
+}
+
+// Session describes the session scoped artifacts of a session.
+type Session struct {
+	AppName   string `json:"appName"`
+	UserID    string `json:"userId"`
+	SessionID string `json:"sessionId"`
+	Files     int    `json:"files"`
+	Versions  int    `json:"versions"`
+	Bytes     int64  `json:"bytes"`
+	// Modified is when the last version in the session was saved.
+	Modified time.Time `json:"modified"`
+}
+
+// Report is the result of [Analyze].
+type Report struct {
+	Generated time.Time `json:"generated"`
+	// Largest are the artifacts with the most stored bytes, largest first.
+	Largest []File `json:"largest"`
+	// MostVersioned are the artifacts with the most versions, most first.
+	MostVersioned []File `json:"mostVersioned"`
+	// StaleSessions are the sessions untouched for [Options.StaleAfter],
+	// least recently modified first. User scoped artifacts belong to no
+	// session and are not considered.
+	StaleSessions []Session `json:"staleSessions"`
+}
+
+// Analyze walks srv and reports on what it holds.
+func Analyze(ctx context.Context, srv artifact.Service, opts Options) (*Report, error) {
+	if opts.Top <= 0 {
+		opts.Top = DefaultTop
+	}
+	if opts.StaleAfter <= 0 {
+		opts.StaleAfter = DefaultStaleAfter
+	}
+	if opts.Clock == nil {
+		opts.Clock = artifactx.SystemClock
+	}
+	walker, ok := artifactx.As[artifactx.Walker](srv)
+	if !ok {
+		return nil, fmt.Errorf("reports require a service implementing artifactx.Walker")
+	}
+	stater, ok := artifactx.As[artifactx.Stater](srv)
+	if !ok {
+		return nil, fmt.Errorf("reports require a service implementing artifactx.Stater")
+	}
+
+	now := opts.Clock.Now()
+	files := map[[4]string]*File{}
+	err := walker.Walk(ctx, func(ref artifactx.Ref) error {
+		attrs, err := stater.Stat(ctx, ref.LoadRequest())
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", ref, err)
+		}
+		id := [4]string{ref.AppName, ref.UserID, ref.SessionID, ref.FileName}
+		f := files[id]
+		if f == nil {
+			f = &File{AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName}
+			files[id] = f
+		}
+		f.Versions++
+		f.Bytes += attrs.Size
+		if attrs.Created.After(f.Modified) {
+			f.Modified = attrs.Created
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk artifacts: %w", err)
+	}
+
+	sessions := map[[3]string]*Session{}
+	all := make([]File, 0, len(files))
+	for _, f := range files {
+		all = append(all, *f)
+		if f.SessionID == artifactx.UserNamespace {
+			continue
+		}
+		id := [3]string{f.AppName, f.UserID, f.SessionID}
+		s := sessions[id]
+		if s == nil {
+			s = &Session{AppName: f.AppName, UserID: f.UserID, SessionID: f.SessionID}
+			sessions[id] = s
+		}
+		s.Files++
+		s.Versions += f.Versions
+		s.Bytes += f.Bytes
+		if f.Modified.After(s.Modified) {
+			s.Modified = f.Modified
+		}
+	}
+
+	r := &Report{Generated: now, Largest: []File{}, MostVersioned: []File{}, StaleSessions: []Session{}}
+	slices.SortFunc(all, func(a, b File) int { return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), compareFiles(a, b)) })
+	r.Largest = append(r.Largest, all[:min(opts.Top, len(all))]...)
+	slices.SortFunc(all, func(a, b File) int { return cmp.Or(cmp.Compare(b.Versions, a.Versions), compareFiles(a, b)) })
+	r.MostVersioned = append(r.MostVersioned, all[:min(opts.Top, len(all))]...)
+
+	for _, s := range sessions {
+		if now.Sub(s.Modified) >= opts.StaleAfter {
+			r.StaleSessions = append(r.StaleSessions, *s)
+		}
+	}
+	slices.SortFunc(r.StaleSessions, func(a, b Session) int {
+		return cmp.Or(a.Modified.Compare(b.Modified), strings.Compare(a.AppName, b.AppName),
+			strings.Compare(a.UserID, b.UserID), strings.Compare(a.SessionID, b.SessionID))
+	})
+	return r, nil
+}
+
+func compareFiles(a, b File) int {
+	return cmp.Or(strings.Compare(a.AppName, b.AppName), strings.Compare(a.UserID, b.UserID),
+		strings.Compare(a.SessionID, b.SessionID), strings.Compare(a.FileName, b.FileName))
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// csvHeader are the columns written by [Report.WriteCSV]. The section column
+// is "largest", "most-versioned" or "stale-session"; files is empty for
+// artifacts and file is empty for sessions.
+var csvHeader = []string{"section", "app", "user", "session", "file", "files", "versions", "bytes", "modified"}
+
+// WriteCSV writes the report as CSV with a header row, one row per entry.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader)
+	fileRow := func(section string, f File) []string {
+		return []string{section, f.AppName, f.UserID, f.SessionID, f.FileName, "",
+			strconv.Itoa(f.Versions), strconv.FormatInt(f.Bytes, 10), f.Modified.UTC().Format(time.RFC3339)}
+	}
+	for _, f := range r.Largest {
+		cw.Write(fileRow("largest", f))
+	}
+	for _, f := range r.MostVersioned {
+		cw.Write(fileRow("most-versioned", f))
+	}
+	for _, s := range r.StaleSessions {
+		cw.Write([]string{"stale-session", s.AppName, s.UserID, s.SessionID, "", strconv.Itoa(s.Files),
+			strconv.Itoa(s.Versions), strconv.FormatInt(s.Bytes, 10), s.Modified.UTC().Format(time.RFC3339)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactreport_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactreport"
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+)
+
+func TestAnalyze(t *testing.T) {
+	ctx := t.Context()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := artifactx.NewManualClock(start)
+	srv, err := fsartifact.New(t.TempDir(), fsartifact.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	save := func(sessionID, fileName string, size int) {
+		t.Helper()
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: sessionID, FileName: fileName,
+			Part: genai.NewPartFromBytes(bytes.Repeat([]byte("x"), size), "application/octet-stream"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save("old", "big", 100)
+	clock.Advance(40 * 24 * time.Hour)
+	save("new", "notes", 1)
+	save("new", "notes", 2)
+	save("new", "notes", 3)
+	save("new", "user:profile", 10)
+	clock.Advance(time.Hour)
+
+	r, err := artifactreport.Analyze(ctx, srv, artifactreport.Options{Top: 2, Clock: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	modified := start.Add(40 * 24 * time.Hour)
+	big := artifactreport.File{AppName: "app", UserID: "user", SessionID: "old", FileName: "big", Versions: 1, Bytes: 100, Modified: start}
+	notes := artifactreport.File{AppName: "app", UserID: "user", SessionID: "new", FileName: "notes", Versions: 3, Bytes: 6, Modified: modified}
+	profile := artifactreport.File{AppName: "app", UserID: "user", SessionID: artifactx.UserNamespace, FileName: "user:profile", Versions: 1, Bytes: 10, Modified: modified}
+	want := &artifactreport.Report{
+		Generated:     modified.Add(time.Hour),
+		Largest:       []artifactreport.File{big, profile},
+		MostVersioned: []artifactreport.File{notes, big},
+		StaleSessions: []artifactreport.Session{{AppName: "app", UserID: "user", SessionID: "old", Files: 1, Versions: 1, Bytes: 100, Modified: start}},
+	}
+	if diff := cmp.Diff(want, r); diff != "" {
+		t.Errorf("Analyze() mismatch (-want +got):\n%s", diff)
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 6 || rows[5][0] != "stale-session" || rows[5][3] != "old" || rows[5][7] != "100" {
+		t.Errorf("WriteCSV() rows = %v, want a header and 5 entries ending with the stale session", rows)
+	}
+}
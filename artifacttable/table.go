@@ -0,0 +1,192 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package artifacttable stores tabular data as CSV artifacts with a column
+// schema kept in the artifact metadata, and reads them back row by row.
+//
+// Reading streams the artifact when the service implements
+// [artifactx.Opener], so large datasets are never held in memory at once.
+package artifacttable
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"slices"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// SchemaMetadataKey is the metadata key holding the JSON encoded [Schema].
+const SchemaMetadataKey = "table-schema"
+
+// ContentType is the content type of CSV table artifacts.
+var ContentType = mime.FormatMediaType("text/csv", map[string]string{"header": "present"})
+
+// Column describes a table column.
+type Column struct {
+	Name string `json:"name"`
+	// Type is a free-form type name such as "string", "int" or "float".
+	Type string `json:"type,omitempty"`
+}
+
+// Schema describes the columns of a table.
+type Schema struct {
+	Columns []Column `json:"columns"`
+}
+
+// Names returns the column names.
+func (s Schema) Names() []string {
+	names := make([]string, len(s.Columns))
+	for i, c := range s.Columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// SaveCSV saves rows as a CSV artifact whose first record is the header
+// derived from schema, and records schema in the artifact metadata.
+// A zero ref.Version creates a new version. It returns the saved version.
+func SaveCSV(ctx context.Context, srv artifact.Service, ref artifactx.Ref, schema Schema, rows iter.Seq[[]string]) (int64, error) {
+	if len(schema.Columns) == 0 {
+		return 0, fmt.Errorf("schema has no columns")
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(schema.Names()); err != nil {
+		return 0, fmt.Errorf("failed to write header: %w", err)
+	}
+	n := 0
+	for row := range rows {
+		n++
+		if len(row) != len(schema.Columns) {
+			return 0, fmt.Errorf("row %d has %d fields, want %d", n, len(row), len(schema.Columns))
+		}
+		if err := w.Write(row); err != nil {
+			return 0, fmt.Errorf("failed to write row %d: %w", n, err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return 0, fmt.Errorf("failed to write csv: %w", err)
+	}
+
+	encoded, err := json.Marshal(schema)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	ctx = artifactx.WithMetadata(ctx, map[string]string{SchemaMetadataKey: string(encoded)})
+	resp, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName,
+		Part: genai.NewPartFromBytes(buf.Bytes(), ContentType), Version: ref.Version,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Version, nil
+}
+
+// Table reads the rows of a CSV table artifact.
+type Table struct {
+	// Schema is the recorded schema, or one with untyped columns named after
+	// the header if the artifact has none.
+	Schema Schema
+	// Version is the opened version. It is zero when the latest version was
+	// requested from a service that does not implement [artifactx.Opener].
+	Version int64
+
+	r  io.Closer
+	cr *csv.Reader
+}
+
+// OpenCSV opens a CSV table artifact. A zero ref.Version opens the latest
+// version. The caller must close the table.
+func OpenCSV(ctx context.Context, srv artifact.Service, ref artifactx.Ref) (*Table, error) {
+	req := &artifact.LoadRequest{
+		AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName, Version: ref.Version,
+	}
+	var r io.ReadCloser
+	t := &Table{}
+	var encodedSchema string
+	if o, ok := artifactx.As[artifactx.Opener](srv); ok {
+		ar, err := o.Open(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		r, t.Version, encodedSchema = ar, ar.Version, ar.Metadata[SchemaMetadataKey]
+	} else {
+		resp, err := srv.Load(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		data, _, err := artifactx.EncodePart(resp.Part)
+		if err != nil {
+			return nil, err
+		}
+		r, t.Version = io.NopCloser(bytes.NewReader(data)), ref.Version
+	}
+	t.r = r
+	t.cr = csv.NewReader(r)
+
+	header, err := t.cr.Read()
+	if err != nil {
+		r.Close()
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if encodedSchema != "" {
+		if err := json.Unmarshal([]byte(encodedSchema), &t.Schema); err != nil {
+			r.Close()
+			return nil, fmt.Errorf("failed to decode schema: %w", err)
+		}
+		if !slices.Equal(t.Schema.Names(), header) {
+			r.Close()
+			return nil, fmt.Errorf("header %q does not match schema columns %q", header, t.Schema.Names())
+		}
+	} else {
+		for _, name := range header {
+			t.Schema.Columns = append(t.Schema.Columns, Column{Name: name})
+		}
+	}
+	t.cr.FieldsPerRecord = len(header)
+	return t, nil
+}
+
+// Rows returns an iterator over the remaining rows. Iteration stops after
+// the first error.
+func (t *Table) Rows() iter.Seq2[[]string, error] {
+	return func(yield func([]string, error) bool) {
+		for {
+			row, err := t.cr.Read()
+			if err == io.EOF {
+				return
+			}
+			if !yield(row, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Close releases the underlying reader.
+func (t *Table) Close() error {
+	return t.r.Close()
+}
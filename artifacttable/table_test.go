@@ -0,0 +1,74 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifacttable_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifacttable"
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+)
+
+func TestCSV(t *testing.T) {
+	fsSrv, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := artifacttable.Schema{Columns: []artifacttable.Column{{Name: "city", Type: "string"}, {Name: "temp", Type: "float"}}}
+	rows := [][]string{{"Paris", "21.5"}, {"Oslo", "8"}}
+
+	for _, tc := range []struct {
+		name       string
+		srv        artifact.Service
+		wantSchema artifacttable.Schema
+	}{
+		// The file system backend streams and keeps metadata.
+		{"fs", fsSrv, schema},
+		// The in-memory backend keeps no metadata, so the schema comes from the header.
+		{"inmemory", artifact.InMemoryService(), artifacttable.Schema{Columns: []artifacttable.Column{{Name: "city"}, {Name: "temp"}}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := t.Context()
+			ref := artifactx.Ref{AppName: "app", UserID: "user", SessionID: "session", FileName: "weather.csv"}
+			if _, err := artifacttable.SaveCSV(ctx, tc.srv, ref, schema, slices.Values(rows)); err != nil {
+				t.Fatalf("SaveCSV() failed: %v", err)
+			}
+
+			table, err := artifacttable.OpenCSV(ctx, tc.srv, ref)
+			if err != nil {
+				t.Fatalf("OpenCSV() failed: %v", err)
+			}
+			defer table.Close()
+			if diff := cmp.Diff(tc.wantSchema, table.Schema); diff != "" {
+				t.Errorf("OpenCSV() schema mismatch (-want +got):\n%s", diff)
+			}
+			var got [][]string
+			for row, err := range table.Rows() {
+				if err != nil {
+					t.Fatalf("Rows() failed: %v", err)
+				}
+				got = append(got, row)
+			}
+			if diff := cmp.Diff(rows, got); diff != "" {
+				t.Errorf("Rows() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
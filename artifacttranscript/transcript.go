@@ -0,0 +1,196 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package artifacttranscript associates artifact versions with the events
+// of an ADK session, so that replay tools can show which artifacts were
+// produced at which point of the conversation.
+//
+// Versions are associated with events in two ways: by the artifact delta
+// ADK records in the actions of the event that saved them, and by the event
+// ID stored with a version, under [EventIDMetadataKey], for versions saved
+// outside of the agent, such as by a pipeline reacting to an event. The ID
+// is attached at save time with [WithEventID] or afterwards with [Attach].
+package artifacttranscript
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/session"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// EventIDMetadataKey is the metadata key under which the ID of the event a
+// version belongs to is stored.
+const EventIDMetadataKey = "event-id"
+
+// WithEventID returns a copy of ctx that makes Save associate the version it
+// writes with the session event eventID.
+func WithEventID(ctx context.Context, eventID string) context.Context {
+	return artifactx.WithMetadata(ctx, map[string]string{EventIDMetadataKey: eventID})
+}
+
+// Attach associates the saved version ref, or the latest version if
+// ref.Version is zero, with the session event eventID, replacing any
+// previous association. srv must implement [artifactx.Relabeler].
+func Attach(ctx context.Context, srv artifact.Service, ref artifactx.Ref, eventID string) error {
+	if eventID == "" {
+		return errors.New("event ID is required")
+	}
+	relabeler, ok := artifactx.As[artifactx.Relabeler](srv)
+	if !ok {
+		return fmt.Errorf("attaching versions to events requires a service implementing artifactx.Relabeler")
+	}
+	return relabeler.Relabel(ctx, ref.LoadRequest(), &artifactx.MetadataChange{Set: map[string]string{EventIDMetadataKey: eventID}})
+}
+
+// Entry lists the versions associated with an event.
+type Entry struct {
+	EventID   string          `json:"eventId"`
+	Timestamp time.Time       `json:"timestamp"`
+	Author    string          `json:"author,omitempty"`
+	Artifacts []artifactx.Ref `json:"artifacts"`
+}
+
+// Transcript lists the versions associated with the events of a session, in
+// the order of the events.
+type Transcript struct {
+	// Entries holds an entry for every event with associated versions.
+	Entries []Entry `json:"entries"`
+	// Unattached are the versions of the session associated with no event
+	// of the session.
+	Unattached []artifactx.Ref `json:"unattached,omitempty"`
+
+	// events is the position of every event of the session.
+	events map[string]int
+}
+
+// Bundle returns the transcript of sess. If srv implements
+// [artifactx.Stater], it also lists the versions of the session, scanning
+// them for event IDs stored with [WithEventID] or [Attach] and reporting
+// the others as unattached; otherwise only the artifact deltas of the
+// events are used.
+func Bundle(ctx context.Context, srv artifact.Service, sess session.Session) (*Transcript, error) {
+	t := &Transcript{Entries: []Entry{}, events: map[string]int{}}
+	entries := map[string]*Entry{}
+	attached := map[artifactx.Ref]bool{}
+	var order []*Entry
+	for ev := range sess.Events().All() {
+		t.events[ev.ID] = len(t.events)
+		e := &Entry{EventID: ev.ID, Timestamp: ev.Timestamp, Author: ev.Author}
+		entries[ev.ID] = e
+		order = append(order, e)
+		for fileName, version := range ev.Actions.ArtifactDelta {
+			ref := artifactx.Ref{AppName: sess.AppName(), UserID: sess.UserID(), SessionID: sess.ID(), FileName: fileName, Version: version}
+			e.Artifacts = append(e.Artifacts, ref)
+			attached[ref] = true
+		}
+	}
+
+	if stater, ok := artifactx.As[artifactx.Stater](srv); ok {
+		refs, err := sessionVersions(ctx, srv, sess)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			attrs, err := stater.Stat(ctx, ref.LoadRequest())
+			if errors.Is(err, fs.ErrNotExist) {
+				continue // deleted since listing
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to stat %s: %w", ref, err)
+			}
+			if e := entries[attrs.Metadata[EventIDMetadataKey]]; e != nil && !attached[ref] {
+				e.Artifacts = append(e.Artifacts, ref)
+				attached[ref] = true
+			}
+			// User scoped versions saved from other sessions are not unattached.
+			if !attached[ref] && !artifactx.IsUserScoped(ref.FileName) {
+				t.Unattached = append(t.Unattached, ref)
+			}
+		}
+	}
+
+	for _, e := range order {
+		if len(e.Artifacts) == 0 {
+			continue
+		}
+		slices.SortFunc(e.Artifacts, compareRefs)
+		t.Entries = append(t.Entries, *e)
+	}
+	return t, nil
+}
+
+// sessionVersions returns the versions of the artifacts visible from sess.
+func sessionVersions(ctx context.Context, srv artifact.Service, sess session.Session) ([]artifactx.Ref, error) {
+	list, err := srv.List(ctx, &artifact.ListRequest{AppName: sess.AppName(), UserID: sess.UserID(), SessionID: sess.ID()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+	var refs []artifactx.Ref
+	for _, fileName := range list.FileNames {
+		resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: sess.AppName(), UserID: sess.UserID(), SessionID: sess.ID(), FileName: fileName})
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list versions of %q: %w", fileName, err)
+		}
+		for _, v := range slices.Sorted(slices.Values(resp.Versions)) {
+			refs = append(refs, artifactx.Ref{AppName: sess.AppName(), UserID: sess.UserID(), SessionID: sess.ID(), FileName: fileName, Version: v})
+		}
+	}
+	return refs, nil
+}
+
+func compareRefs(a, b artifactx.Ref) int {
+	return cmp.Or(strings.Compare(a.FileName, b.FileName), cmp.Compare(a.Version, b.Version))
+}
+
+// Range returns the entries of the events from the event fromID through
+// the event toID, in the order of the events, for a transcript returned by
+// [Bundle]. An empty fromID starts at the
+// first event and an empty toID ends at the last one.
+func (t *Transcript) Range(fromID, toID string) ([]Entry, error) {
+	from, to := 0, len(t.events)-1
+	if fromID != "" {
+		i, ok := t.events[fromID]
+		if !ok {
+			return nil, fmt.Errorf("event %q is not in the session", fromID)
+		}
+		from = i
+	}
+	if toID != "" {
+		i, ok := t.events[toID]
+		if !ok {
+			return nil, fmt.Errorf("event %q is not in the session", toID)
+		}
+		to = i
+	}
+	var out []Entry
+	for _, e := range t.Entries {
+		if i := t.events[e.EventID]; i >= from && i <= to {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifacttranscript_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifacttranscript"
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+)
+
+func TestBundle(t *testing.T) {
+	ctx := t.Context()
+	srv, err := fsartifact.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions := session.InMemoryService()
+	created, err := sessions.Create(ctx, &session.CreateRequest{AppName: "app", UserID: "alice", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess := created.Session
+	ref := func(fileName string, version int64) artifactx.Ref {
+		return artifactx.Ref{AppName: "app", UserID: "alice", SessionID: "s1", FileName: fileName, Version: version}
+	}
+	save := func(ctx context.Context, fileName string) int64 {
+		t.Helper()
+		resp, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "alice", SessionID: "s1", FileName: fileName, Part: genai.NewPartFromText(fileName),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Version
+	}
+	appendEvent := func(author string, delta map[string]int64) *session.Event {
+		t.Helper()
+		ev := session.NewEvent("inv")
+		ev.Author = author
+		ev.Actions.ArtifactDelta = delta
+		if err := sessions.AppendEvent(ctx, sess, ev); err != nil {
+			t.Fatal(err)
+		}
+		return ev
+	}
+
+	// The user asks, a tool saves a chart (recorded in the delta), a pipeline
+	// renders a report for the answer (attached by ID), and a draft was never
+	// associated.
+	question := appendEvent("user", nil)
+	chart := appendEvent("agent", map[string]int64{"chart.png": save(ctx, "chart.png")})
+	answer := appendEvent("agent", nil)
+	save(artifacttranscript.WithEventID(ctx, answer.ID), "report.pdf")
+	save(ctx, "draft.txt")
+	// The artifact delta of an event takes precedence over attached IDs.
+	if err := artifacttranscript.Attach(ctx, srv, ref("chart.png", 0), answer.ID); err != nil {
+		t.Fatalf("Attach() failed: %v", err)
+	}
+	save(ctx, "report.pdf")
+	if err := artifacttranscript.Attach(ctx, srv, ref("report.pdf", 0), answer.ID); err != nil {
+		t.Fatalf("Attach() failed: %v", err)
+	}
+
+	got, err := sessions.Get(ctx, &session.GetRequest{AppName: "app", UserID: "alice", SessionID: "s1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr, err := artifacttranscript.Bundle(ctx, srv, got.Session)
+	if err != nil {
+		t.Fatalf("Bundle() failed: %v", err)
+	}
+	want := &artifacttranscript.Transcript{
+		Entries: []artifacttranscript.Entry{
+			{EventID: chart.ID, Author: "agent", Artifacts: []artifactx.Ref{ref("chart.png", 1)}},
+			{EventID: answer.ID, Author: "agent", Artifacts: []artifactx.Ref{ref("report.pdf", 1), ref("report.pdf", 2)}},
+		},
+		Unattached: []artifactx.Ref{ref("draft.txt", 1)},
+	}
+	opts := cmp.Options{cmpopts.IgnoreUnexported(artifacttranscript.Transcript{}), cmpopts.IgnoreFields(artifacttranscript.Entry{}, "Timestamp")}
+	if diff := cmp.Diff(want, tr, opts); diff != "" {
+		t.Errorf("Bundle() mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, tc := range []struct {
+		from, to string
+		want     []artifacttranscript.Entry
+	}{
+		{"", "", want.Entries},
+		{question.ID, chart.ID, want.Entries[:1]},
+		{answer.ID, "", want.Entries[1:]},
+		{"", question.ID, nil},
+	} {
+		got, err := tr.Range(tc.from, tc.to)
+		if err != nil {
+			t.Fatalf("Range(%q, %q) failed: %v", tc.from, tc.to, err)
+		}
+		if diff := cmp.Diff(tc.want, got, opts); diff != "" {
+			t.Errorf("Range(%q, %q) mismatch (-want +got):\n%s", tc.from, tc.to, diff)
+		}
+	}
+	if _, err := tr.Range("unknown", ""); err == nil {
+		t.Errorf("Range() from an unknown event succeeded, want an error")
+	}
+}
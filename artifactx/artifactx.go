@@ -0,0 +1,56 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package artifactx defines extensions to [artifact.Service] shared by the
+// backends in this module.
+//
+// The backends return a plain [artifact.Service]. Additional capabilities are
+// exposed as optional interfaces declared here, which callers discover with
+// [As], looking through any decorators wrapping the backend:
+//
+//	if c, ok := artifactx.As[artifactx.Checker](srv); ok {
+//		report, err := c.Check(ctx, nil)
+//		...
+//	}
+package artifactx
+
+import (
+	"fmt"
+
+	"google.golang.org/adk/artifact"
+)
+
+// UserNamespace is the path segment used in place of the session ID for
+// artifacts whose filename starts with "user:".
+const UserNamespace = "user"
+
+// Ref identifies a single stored artifact version.
+type Ref struct {
+	AppName   string `json:"appName"`
+	UserID    string `json:"userId"`
+	SessionID string `json:"sessionId"`
+	FileName  string `json:"fileName"`
+	Version   int64  `json:"version"`
+}
+
+// String returns the ref in the "app/user/session/file/version" form used by
+// the storage layout.
+func (r Ref) String() string {
+	return fmt.Sprintf("%s/%s/%s/%s/%d", r.AppName, r.UserID, r.SessionID, r.FileName, r.Version)
+}
+
+// LoadRequest returns the request loading the referenced version.
+func (r Ref) LoadRequest() *artifact.LoadRequest {
+	return &artifact.LoadRequest{AppName: r.AppName, UserID: r.UserID, SessionID: r.SessionID, FileName: r.FileName, Version: r.Version}
+}
@@ -0,0 +1,153 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"time"
+
+	"google.golang.org/adk/artifact"
+)
+
+// AsOf returns a read-only view of srv as it was at t: List, Versions and
+// Load only see the versions created at or before t, according to
+// [Attributes.Created], so that debugging tools can reconstruct what an
+// agent saw at a past step. Versions deleted since are not restored.
+//
+// srv must implement [VersionLister] or [Stater]. Save and Delete fail with
+// [errors.ErrUnsupported]. The view does not unwrap to srv, so that
+// capabilities of srv ignoring t are not reachable through it.
+func AsOf(srv artifact.Service, t time.Time) artifact.Service {
+	return &asOfService{srv: srv, t: t}
+}
+
+type asOfService struct {
+	srv artifact.Service
+	t   time.Time
+}
+
+var _ Stater = (*asOfService)(nil)
+
+func (s *asOfService) readOnly() error {
+	return fmt.Errorf("view as of %s is read-only: %w", s.t.Format(time.RFC3339), errors.ErrUnsupported)
+}
+
+// Save implements [artifact.Service].
+func (s *asOfService) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	return nil, s.readOnly()
+}
+
+// Delete implements [artifact.Service].
+func (s *asOfService) Delete(ctx context.Context, req *artifact.DeleteRequest) error {
+	return s.readOnly()
+}
+
+// attributes returns the attributes of the versions created at or before t,
+// latest first, failing with [fs.ErrNotExist] if there are none.
+func (s *asOfService) attributes(ctx context.Context, req *artifact.VersionsRequest) ([]*Attributes, error) {
+	attrs, err := ListVersions(ctx, s.srv, &ListVersionsRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	attrs = slices.DeleteFunc(attrs, func(a *Attributes) bool { return a.Created.After(s.t) })
+	if len(attrs) == 0 {
+		return nil, fmt.Errorf("artifact not found as of %s: %w", s.t.Format(time.RFC3339), fs.ErrNotExist)
+	}
+	return attrs, nil
+}
+
+// Versions implements [artifact.Service].
+func (s *asOfService) Versions(ctx context.Context, req *artifact.VersionsRequest) (*artifact.VersionsResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	attrs, err := s.attributes(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]int64, len(attrs))
+	for i, a := range attrs {
+		versions[i] = a.Version
+	}
+	slices.Sort(versions)
+	return &artifact.VersionsResponse{Versions: versions}, nil
+}
+
+// List implements [artifact.Service].
+func (s *asOfService) List(ctx context.Context, req *artifact.ListRequest) (*artifact.ListResponse, error) {
+	resp, err := s.srv.List(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, name := range resp.FileNames {
+		_, err := s.attributes(ctx, &artifact.VersionsRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: name,
+		})
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return &artifact.ListResponse{FileNames: names}, nil
+}
+
+// resolve returns the attributes of the requested version, or of the latest
+// one if req.Version is zero, among those visible as of t.
+func (s *asOfService) resolve(ctx context.Context, req *artifact.LoadRequest) (*Attributes, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	attrs, err := s.attributes(ctx, &artifact.VersionsRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if req.Version == 0 {
+		return slices.MaxFunc(attrs, func(a, b *Attributes) int { return cmp.Compare(a.Version, b.Version) }), nil
+	}
+	i := slices.IndexFunc(attrs, func(a *Attributes) bool { return a.Version == req.Version })
+	if i < 0 {
+		return nil, fmt.Errorf("artifact '%s' version %d not found as of %s: %w", req.FileName, req.Version, s.t.Format(time.RFC3339), fs.ErrNotExist)
+	}
+	return attrs[i], nil
+}
+
+// Load implements [artifact.Service].
+func (s *asOfService) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	attrs, err := s.resolve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *req
+	resolved.Version = attrs.Version
+	return s.srv.Load(ctx, &resolved)
+}
+
+// Stat implements [Stater].
+func (s *asOfService) Stat(ctx context.Context, req *artifact.LoadRequest) (*Attributes, error) {
+	return s.resolve(ctx, req)
+}
@@ -0,0 +1,70 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx_test
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+)
+
+func TestAsOf(t *testing.T) {
+	ctx := t.Context()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := artifactx.NewManualClock(start)
+	srv, err := fsartifact.New(t.TempDir(), fsartifact.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := artifactx.Scoped(srv, "app", "user", "session")
+	save := func(name, text string) {
+		t.Helper()
+		clock.Advance(time.Minute)
+		if _, err := scope.Save(ctx, name, genai.NewPartFromText(text)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save("plan", "v1")   // 00:01
+	save("plan", "v2")   // 00:02
+	save("result", "r1") // 00:03
+	save("plan", "v3")   // 00:04
+
+	past := artifactx.Scoped(artifactx.AsOf(srv, start.Add(2*time.Minute+30*time.Second)), "app", "user", "session")
+	if names, err := past.List(ctx); err != nil || !slices.Equal(names, []string{"plan"}) {
+		t.Errorf("List() as of 00:02:30 = (%v, %v), want [plan]", names, err)
+	}
+	if versions, err := past.Versions(ctx, "plan"); err != nil || !slices.Equal(versions, []int64{1, 2}) {
+		t.Errorf("Versions() as of 00:02:30 = (%v, %v), want [1 2]", versions, err)
+	}
+	if part, err := past.Load(ctx, "plan"); err != nil || part.Text != "v2" {
+		t.Errorf("Load() as of 00:02:30 = (%v, %v), want v2", part, err)
+	}
+	if _, err := past.LoadVersion(ctx, "plan", 3); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("LoadVersion(3) as of 00:02:30 error = %v, want %v", err, fs.ErrNotExist)
+	}
+	if _, err := past.Load(ctx, "result"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Load(result) as of 00:02:30 error = %v, want %v", err, fs.ErrNotExist)
+	}
+	if _, err := past.Save(ctx, "plan", genai.NewPartFromText("v4")); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Save() to the view error = %v, want %v", err, errors.ErrUnsupported)
+	}
+}
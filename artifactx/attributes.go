@@ -0,0 +1,105 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"io"
+	"maps"
+	"time"
+
+	"google.golang.org/adk/artifact"
+)
+
+// Attributes describes a stored artifact version without its content.
+type Attributes struct {
+	Version int64
+	// ContentType is the stored content type; see [EncodePart].
+	ContentType string
+	// Size is the stored size in bytes.
+	Size    int64
+	ModTime time.Time
+	// Created is when the version was saved, according to the [Clock] of the
+	// backend. It equals ModTime unless the storage sets its own times.
+	Created time.Time
+	// Metadata is the metadata attached with [WithMetadata] when the version was saved.
+	Metadata map[string]string
+	// ETag identifies the content of the version for HTTP caching. It is the
+	// quoted SHA-256 of the content if the backend recorded one, see
+	// [ChecksumETag], and an ETag of the storage otherwise.
+	ETag string
+	// Pinned reports whether the version is pinned, see [Pinner].
+	Pinned bool
+	// Immutable reports whether the version was saved with
+	// [WithImmutable].
+	Immutable bool
+}
+
+// Stater is implemented by services that can describe a version without loading it.
+type Stater interface {
+	// Stat returns the attributes of the requested version, or of the latest
+	// version if req.Version is zero.
+	Stat(ctx context.Context, req *artifact.LoadRequest) (*Attributes, error)
+}
+
+// Reader streams the stored content of an artifact version.
+type Reader struct {
+	io.ReadCloser
+	Attributes
+}
+
+// Opener is implemented by services that can stream a version instead of
+// loading it into memory.
+type Opener interface {
+	// Open returns a reader for the stored bytes of the requested version, or
+	// of the latest version if req.Version is zero. The caller must close it.
+	Open(ctx context.Context, req *artifact.LoadRequest) (*Reader, error)
+}
+
+// RangeOpener is implemented by services that can stream part of a version,
+// reading only the requested bytes from storage.
+type RangeOpener interface {
+	// OpenRange returns a reader for length bytes of the stored content of
+	// the requested version from offset, or up to the end if length is
+	// negative. The attributes of the reader describe the whole version.
+	OpenRange(ctx context.Context, req *artifact.LoadRequest, offset, length int64) (*Reader, error)
+}
+
+type metadataKey struct{}
+
+// WithMetadata returns a copy of ctx that makes Save attach md to the version
+// it writes. Keys should be lower case: S3 does not preserve their case.
+func WithMetadata(ctx context.Context, md map[string]string) context.Context {
+	merged := maps.Clone(MetadataFrom(ctx))
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	maps.Copy(merged, md)
+	return context.WithValue(ctx, metadataKey{}, merged)
+}
+
+// MetadataFrom returns the metadata attached to ctx with [WithMetadata].
+func MetadataFrom(ctx context.Context) map[string]string {
+	md, _ := ctx.Value(metadataKey{}).(map[string]string)
+	return md
+}
+
+// URLSigner is implemented by services whose storage can issue URLs that
+// grant direct, time limited access to a version, such as presigned S3 URLs.
+type URLSigner interface {
+	// SignedURL returns a URL to download the requested version, or the
+	// latest version if req.Version is zero, valid for expiry.
+	SignedURL(ctx context.Context, req *artifact.LoadRequest, expiry time.Duration) (string, error)
+}
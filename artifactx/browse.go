@@ -0,0 +1,130 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"google.golang.org/adk/artifact"
+)
+
+// Browser is implemented by services that can enumerate the users and
+// sessions holding artifacts, for admin tools browsing the store.
+//
+// Both methods fail with [errors.ErrUnsupported] if the key layout of the
+// service is not a [HierarchyKeyBuilder].
+type Browser interface {
+	// ListUsers returns the users of an app with artifacts, sorted.
+	ListUsers(ctx context.Context, appName string) ([]string, error)
+	// ListSessions returns the sessions of a user with artifacts, sorted.
+	// User scoped artifacts are not in a session and not reported.
+	ListSessions(ctx context.Context, appName, userID string) ([]string, error)
+}
+
+// UsersPrefix returns the prefix of [HierarchyKeyBuilder.UsersPrefix], or an
+// error if kb is not one or appName is empty.
+func UsersPrefix(kb KeyBuilder, appName string) (string, error) {
+	h, ok := kb.(HierarchyKeyBuilder)
+	if !ok {
+		return "", fmt.Errorf("key layout cannot be browsed: %w", errors.ErrUnsupported)
+	}
+	if appName == "" {
+		return "", fmt.Errorf("app name is required")
+	}
+	return h.UsersPrefix(appName), nil
+}
+
+// SessionsPrefix returns the prefix of [HierarchyKeyBuilder.SessionsPrefix],
+// or an error if kb is not one or a name is empty.
+func SessionsPrefix(kb KeyBuilder, appName, userID string) (string, error) {
+	h, ok := kb.(HierarchyKeyBuilder)
+	if !ok {
+		return "", fmt.Errorf("key layout cannot be browsed: %w", errors.ErrUnsupported)
+	}
+	if appName == "" || userID == "" {
+		return "", fmt.Errorf("app name and user ID are required")
+	}
+	return h.SessionsPrefix(appName, userID), nil
+}
+
+// Sessions returns the sorted session IDs stored as the children of a
+// sessions prefix of kb, leaving out the user namespace.
+func Sessions(kb KeyBuilder, children []string) []string {
+	h, ok := kb.(HierarchyKeyBuilder)
+	if !ok {
+		return nil
+	}
+	var sessions []string
+	for _, child := range children {
+		if id, ok := h.SessionID(child); ok {
+			sessions = append(sessions, id)
+		}
+	}
+	slices.Sort(sessions)
+	return sessions
+}
+
+// FileFinder is implemented by services that can find the sessions holding
+// an artifact without listing each session.
+type FileFinder interface {
+	// FindFile returns a ref to the latest version of the artifact named
+	// fileName in every session of a user that has one, sorted by session
+	// ID. A user scoped artifact is found once, with [UserNamespace] as its
+	// session ID.
+	FindFile(ctx context.Context, appName, userID, fileName string) ([]Ref, error)
+}
+
+// FindFile returns a ref to the latest version of the artifact named fileName
+// in every session of a user that has one, sorted by session ID, with
+// [FileFinder] if srv implements it. Otherwise it lists the sessions with
+// [Browser] and the versions of the artifact in each.
+func FindFile(ctx context.Context, srv artifact.Service, appName, userID, fileName string) ([]Ref, error) {
+	if f, ok := As[FileFinder](srv); ok {
+		return f.FindFile(ctx, appName, userID, fileName)
+	}
+	if fileName == "" {
+		return nil, fmt.Errorf("file name is required")
+	}
+	sessions := []string{UserNamespace}
+	if !strings.HasPrefix(fileName, userPrefix) {
+		b, ok := As[Browser](srv)
+		if !ok {
+			return nil, fmt.Errorf("finding files requires a service implementing artifactx.Browser")
+		}
+		var err error
+		if sessions, err = b.ListSessions(ctx, appName, userID); err != nil {
+			return nil, err
+		}
+	}
+	var refs []Ref
+	for _, sessionID := range sessions {
+		resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: appName, UserID: userID, SessionID: sessionID, FileName: fileName})
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Versions) > 0 {
+			refs = append(refs, Ref{AppName: appName, UserID: userID, SessionID: sessionID, FileName: fileName, Version: slices.Max(resp.Versions)})
+		}
+	}
+	return refs, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"bytes"
+	"mime"
+	"strings"
+)
+
+// utf8BOM is the byte order mark of UTF-8.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// DetectCharset returns the charset of data by its byte order mark:
+// "utf-8", "utf-16le" or "utf-16be", or "" without one.
+func DetectCharset(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, utf8BOM):
+		return "utf-8"
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		return "utf-16le"
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		return "utf-16be"
+	}
+	return ""
+}
+
+// WithCharset returns contentType with the charset of data recorded, if
+// contentType is text without a charset parameter and data starts with a
+// byte order mark, such as the UTF-16 exports of Windows tools. Other
+// content types are returned unchanged.
+func WithCharset(contentType string, data []byte) string {
+	charset := DetectCharset(data)
+	if charset == "" || !IsText(contentType) {
+		return contentType
+	}
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if _, ok := params["charset"]; ok {
+		return contentType
+	}
+	params["charset"] = charset
+	return mime.FormatMediaType(mediaType, params)
+}
+
+// NormalizeCharset returns text data of contentType in UTF-8 without a byte
+// order mark, with the charset parameter of contentType set to utf-8. Data
+// in UTF-16 is decoded, by its byte order mark for the plain "utf-16"
+// charset. Content that is not text, has no charset parameter, is in
+// another charset or is UTF-16 with an odd number of bytes is returned
+// unchanged.
+func NormalizeCharset(data []byte, contentType string) ([]byte, string) {
+	if !strings.Contains(contentType, "charset=") {
+		// Spare parsing for the common case.
+		return data, contentType
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !IsText(mediaType) {
+		return data, contentType
+	}
+	original := data
+	switch charset := strings.ToLower(params["charset"]); charset {
+	case "utf-8", "utf8":
+		if !bytes.HasPrefix(data, utf8BOM) {
+			return data, contentType
+		}
+		data = data[len(utf8BOM):]
+	case "utf-16", "utf-16le", "utf-16be":
+		// A byte order mark wins over the charset; plain utf-16 without
+		// one is big endian (RFC 2781).
+		bigEndian := charset != "utf-16le"
+		if detected := DetectCharset(data); detected == "utf-16le" || detected == "utf-16be" {
+			bigEndian = detected == "utf-16be"
+			data = data[2:]
+		}
+		text, err := decodeUTF16(data, bigEndian)
+		if err != nil {
+			// Keep malformed data as stored, with its charset.
+			return original, contentType
+		}
+		data = []byte(text)
+	default:
+		return data, contentType
+	}
+	params["charset"] = "utf-8"
+	return data, mime.FormatMediaType(mediaType, params)
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx_test
+
+import (
+	"bytes"
+	"testing"
+
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// utf16LE is "hé" in UTF-16LE with a byte order mark.
+var utf16LE = []byte{0xff, 0xfe, 'h', 0, 0xe9, 0}
+
+func TestWithCharset(t *testing.T) {
+	for _, tc := range []struct {
+		contentType string
+		data        []byte
+		want        string
+	}{
+		{"text/csv", utf16LE, "text/csv; charset=utf-16le"},
+		{"application/json", []byte{0xfe, 0xff, 0, '{', 0, '}'}, "application/json; charset=utf-16be"},
+		{"text/plain", []byte("\xef\xbb\xbfhi"), "text/plain; charset=utf-8"},
+		{"text/plain", []byte("hi"), "text/plain"},
+		// A charset given on Save is kept; binary content is not text.
+		{"text/csv; charset=windows-1252", utf16LE, "text/csv; charset=windows-1252"},
+		{"application/octet-stream", utf16LE, "application/octet-stream"},
+	} {
+		if got := artifactx.WithCharset(tc.contentType, tc.data); got != tc.want {
+			t.Errorf("WithCharset(%q, % x) = %q, want %q", tc.contentType, tc.data, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeCharset(t *testing.T) {
+	for _, tc := range []struct {
+		data           []byte
+		contentType    string
+		want, wantType string
+	}{
+		{utf16LE, "text/csv; charset=utf-16le", "hé", "text/csv; charset=utf-8"},
+		{[]byte{0xfe, 0xff, 0, 'h', 0, 0xe9}, "text/csv; charset=utf-16", "hé", "text/csv; charset=utf-8"},
+		{[]byte{0, 'h', 0, 0xe9}, "text/csv; charset=UTF-16", "hé", "text/csv; charset=utf-8"},
+		{[]byte{'h', 0, 0xe9, 0}, "text/csv; charset=utf-16le", "hé", "text/csv; charset=utf-8"},
+		{[]byte("\xef\xbb\xbfhé"), "text/plain; charset=utf-8", "hé", "text/plain; charset=utf-8"},
+		{[]byte("hé"), "text/plain; charset=utf-8", "hé", "text/plain; charset=utf-8"},
+		// Other charsets and content types are left alone, and so is
+		// UTF-16 with a trailing odd byte.
+		{[]byte{0xff, 0xfe, 'h', 0, 0xe9}, "text/csv; charset=utf-16le", "\xff\xfeh\x00\xe9", "text/csv; charset=utf-16le"},
+		{[]byte("h\xe9"), "text/plain; charset=iso-8859-1", "h\xe9", "text/plain; charset=iso-8859-1"},
+		{utf16LE, "application/octet-stream; charset=utf-16le", string(utf16LE), "application/octet-stream; charset=utf-16le"},
+	} {
+		got, gotType := artifactx.NormalizeCharset(tc.data, tc.contentType)
+		if string(got) != tc.want || gotType != tc.wantType {
+			t.Errorf("NormalizeCharset(% x, %q) = (%q, %q), want (%q, %q)", tc.data, tc.contentType, got, gotType, tc.want, tc.wantType)
+		}
+	}
+}
+
+func TestDecodePartCharset(t *testing.T) {
+	data, contentType, err := artifactx.EncodePart(&genai.Part{InlineData: &genai.Blob{Data: utf16LE, MIMEType: "text/csv"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, utf16LE) || contentType != "text/csv; charset=utf-16le" {
+		t.Errorf("EncodePart() = (% x, %q), want the data as is with its charset", data, contentType)
+	}
+	part, err := artifactx.DecodePart(data, contentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(part.InlineData.Data) != "hé" || part.InlineData.MIMEType != "text/csv; charset=utf-8" {
+		t.Errorf("DecodePart() = (%q, %q), want UTF-8", part.InlineData.Data, part.InlineData.MIMEType)
+	}
+}
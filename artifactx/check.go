@@ -0,0 +1,109 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// AnomalyKind classifies a problem found by [Checker.Check].
+type AnomalyKind string
+
+const (
+	// MissingMetadata is a version whose metadata (content type) is missing.
+	MissingMetadata AnomalyKind = "missing_metadata"
+	// InvalidVersion is an entry in an artifact directory whose name is not a version number.
+	InvalidVersion AnomalyKind = "invalid_version"
+	// VersionGap is a hole in the version sequence of an artifact.
+	VersionGap AnomalyKind = "version_gap"
+	// ChecksumMismatch is a version whose content does not match its recorded checksum.
+	ChecksumMismatch AnomalyKind = "checksum_mismatch"
+	// OrphanedObject is an object that does not belong to any artifact version.
+	OrphanedObject AnomalyKind = "orphaned_object"
+	// InvalidMetadata is a version whose metadata does not follow the [Meta] format.
+	InvalidMetadata AnomalyKind = "invalid_metadata"
+	// OutdatedMetadata is a version whose metadata is in a format older than [MetaFormat].
+	OutdatedMetadata AnomalyKind = "outdated_metadata"
+)
+
+// Anomaly is a single problem found by [Checker.Check].
+type Anomaly struct {
+	Kind AnomalyKind `json:"kind"`
+	// Key is the backend specific path or object key the anomaly refers to.
+	Key    string `json:"key"`
+	Detail string `json:"detail,omitempty"`
+	// Repairable reports whether [Checker.Repair] knows how to fix the anomaly.
+	Repairable bool `json:"repairable"`
+}
+
+// CheckOptions configures [Checker.Check].
+type CheckOptions struct {
+	// VerifyChecksums downloads every version and compares it with the
+	// checksum recorded by the backend. Backends that record no checksums
+	// ignore it.
+	VerifyChecksums bool
+	// VerifyMetadata reads the metadata of every version and validates it
+	// against the [Meta] format.
+	VerifyMetadata bool
+}
+
+// CheckReport is the result of [Checker.Check].
+type CheckReport struct {
+	// Objects is the number of objects scanned.
+	Objects   int       `json:"objects"`
+	Anomalies []Anomaly `json:"anomalies"`
+}
+
+// Repairable returns the anomalies that [Checker.Repair] can fix.
+func (r *CheckReport) Repairable() []Anomaly {
+	var out []Anomaly
+	for _, a := range r.Anomalies {
+		if a.Repairable {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Checker is implemented by services that can verify the consistency of
+// their backing store.
+type Checker interface {
+	// Check scans the whole store and reports anomalies. It does not modify anything.
+	Check(ctx context.Context, opts *CheckOptions) (*CheckReport, error)
+	// Repair fixes the repairable anomalies of a report produced by Check
+	// and returns how many were fixed.
+	Repair(ctx context.Context, report *CheckReport) (int, error)
+}
+
+// VersionGaps reports a [VersionGap] anomaly for every hole in versions.
+// key identifies the artifact in the reported anomalies.
+//
+// Gaps are informational: deleting a single version legitimately leaves one.
+func VersionGaps(key string, versions []int64) []Anomaly {
+	versions = slices.Sorted(slices.Values(versions))
+	var out []Anomaly
+	for i := 1; i < len(versions); i++ {
+		if versions[i]-versions[i-1] > 1 {
+			out = append(out, Anomaly{
+				Kind:   VersionGap,
+				Key:    key,
+				Detail: fmt.Sprintf("missing versions %d-%d", versions[i-1]+1, versions[i]-1),
+			})
+		}
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"maps"
+
+	"lukechampine.com/blake3"
+)
+
+// ChecksumAlgorithm is a hash function backends can record the checksum of
+// versions with. SHA-256 is the default; BLAKE3 is a faster cryptographic
+// alternative for large uploads, and CRC32C only detects corruption.
+type ChecksumAlgorithm struct {
+	// Name identifies the algorithm in metadata, e.g. "sha256".
+	Name string
+	// New returns a new hash computing the checksum.
+	New func() hash.Hash
+	// Cryptographic reports whether equal checksums imply equal content for
+	// all practical purposes. Only those checksums are reported as strong
+	// ETags.
+	Cryptographic bool
+}
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// The checksum algorithms known to this module.
+var (
+	ChecksumSHA256 = &ChecksumAlgorithm{Name: "sha256", New: sha256.New, Cryptographic: true}
+	ChecksumBLAKE3 = &ChecksumAlgorithm{Name: "blake3", New: func() hash.Hash { return blake3.New(32, nil) }, Cryptographic: true}
+	ChecksumCRC32C = &ChecksumAlgorithm{Name: "crc32c", New: func() hash.Hash { return crc32.New(castagnoli) }}
+)
+
+var checksumAlgorithms = []*ChecksumAlgorithm{ChecksumSHA256, ChecksumBLAKE3, ChecksumCRC32C}
+
+// ChecksumAlgorithmByName returns the algorithm named name, or nil if it is
+// not known.
+func ChecksumAlgorithmByName(name string) *ChecksumAlgorithm {
+	for _, a := range checksumAlgorithms {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Sum returns the hex checksum of data.
+func (a *ChecksumAlgorithm) Sum(data []byte) string {
+	if a == ChecksumSHA256 {
+		return Checksum(data)
+	}
+	h := a.New()
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// MetadataKey returns the metadata key under which backends that store
+// metadata with objects record checksums of the algorithm. It is
+// [ChecksumMetadataKey] for SHA-256.
+func (a *ChecksumAlgorithm) MetadataKey() string {
+	return ReservedMetadataPrefix + a.Name
+}
+
+// WithChecksum returns a copy of md recording sum under the metadata key of
+// the algorithm.
+func (a *ChecksumAlgorithm) WithChecksum(md map[string]string, sum string) map[string]string {
+	out := maps.Clone(md)
+	if out == nil {
+		out = make(map[string]string, 1)
+	}
+	out[a.MetadataKey()] = sum
+	return out
+}
+
+// String returns the name of the algorithm.
+func (a *ChecksumAlgorithm) String() string {
+	return a.Name
+}
+
+// valid reports whether sum is a lowercase hex checksum of the algorithm.
+func (a *ChecksumAlgorithm) valid(sum string) bool {
+	b, err := hex.DecodeString(sum)
+	return err == nil && len(b) == a.New().Size() && hex.EncodeToString(b) == sum
+}
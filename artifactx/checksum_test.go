@@ -0,0 +1,45 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx_test
+
+import (
+	"testing"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+func TestChecksumAlgorithm(t *testing.T) {
+	for _, tc := range []struct {
+		alg  *artifactx.ChecksumAlgorithm
+		want string
+	}{
+		{artifactx.ChecksumSHA256, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{artifactx.ChecksumBLAKE3, "ea8f163db38682925e4491c5e58d4bb3506ef8c14eb78a86e908c5624a67200f"},
+		{artifactx.ChecksumCRC32C, "9a71bb4c"},
+	} {
+		if got := tc.alg.Sum([]byte("hello")); got != tc.want {
+			t.Errorf("%s: Sum() = %s, want %s", tc.alg, got, tc.want)
+		}
+		if got := artifactx.ChecksumAlgorithmByName(tc.alg.Name); got != tc.alg {
+			t.Errorf("ChecksumAlgorithmByName(%q) = %v, want %v", tc.alg.Name, got, tc.alg)
+		}
+	}
+	if artifactx.ChecksumSHA256.MetadataKey() != artifactx.ChecksumMetadataKey {
+		t.Errorf("MetadataKey() of SHA-256 = %q, want %q", artifactx.ChecksumSHA256.MetadataKey(), artifactx.ChecksumMetadataKey)
+	}
+	if got := artifactx.ChecksumAlgorithmByName("md5"); got != nil {
+		t.Errorf("ChecksumAlgorithmByName(md5) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock tells the backends the time, for version timestamps and retention.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SystemClock is the [Clock] reading the system time.
+var SystemClock Clock = systemClock{}
+
+// ManualClock is a [Clock] that only moves when told to, for tests.
+type ManualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewManualClock returns a clock set to now.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// Now implements [Clock].
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set sets the clock to now.
+func (c *ManualClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// CreatedMetadataKey is the metadata key under which backends that cannot
+// set the modification time of stored objects record when a version was
+// written, in RFC 3339 format. It is reported as [Attributes.Created] and
+// never in [Attributes.Metadata].
+const CreatedMetadataKey = "adk-created"
+
+// WithCreated returns a copy of md recording created under [CreatedMetadataKey].
+func WithCreated(md map[string]string, created time.Time) map[string]string {
+	out := make(map[string]string, len(md)+1)
+	for k, v := range md {
+		out[k] = v
+	}
+	out[CreatedMetadataKey] = created.UTC().Format(time.RFC3339Nano)
+	return out
+}
+
+// SplitCreated returns md without [CreatedMetadataKey] and the time recorded
+// under it, or fallback if there is none.
+func SplitCreated(md map[string]string, fallback time.Time) (map[string]string, time.Time) {
+	s, ok := md[CreatedMetadataKey]
+	if !ok {
+		return md, fallback
+	}
+	created, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		created = fallback
+	}
+	out := make(map[string]string, len(md)-1)
+	for k, v := range md {
+		if k != CreatedMetadataKey {
+			out[k] = v
+		}
+	}
+	if len(out) == 0 {
+		out = nil
+	}
+	return out, created
+}
+
+type createdKey struct{}
+
+// WithCreatedTime returns a copy of ctx under which Save records created as
+// the creation time of the version it writes instead of the time of its
+// [Clock], so that copies such as restored backups keep the times of the
+// originals.
+func WithCreatedTime(ctx context.Context, created time.Time) context.Context {
+	return context.WithValue(ctx, createdKey{}, created)
+}
+
+// CreatedTimeFrom returns the time attached to ctx with [WithCreatedTime],
+// or now if there is none.
+func CreatedTimeFrom(ctx context.Context, now time.Time) time.Time {
+	if created, ok := ctx.Value(createdKey{}).(time.Time); ok && !created.IsZero() {
+		return created
+	}
+	return now
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"google.golang.org/adk/artifact"
+)
+
+// CloneRequest copies the artifacts of a session into a new session.
+type CloneRequest struct {
+	AppName, UserID         string
+	SessionID, NewSessionID string
+}
+
+// Validate checks that the request names a source and a different
+// destination session.
+func (r *CloneRequest) Validate() error {
+	if r.AppName == "" || r.UserID == "" || r.SessionID == "" || r.NewSessionID == "" {
+		return fmt.Errorf("app name, user ID, session ID and new session ID are required")
+	}
+	if r.SessionID == r.NewSessionID {
+		return fmt.Errorf("session ID and new session ID are the same")
+	}
+	return nil
+}
+
+// SessionCloner is implemented by services that can clone sessions natively,
+// without copying their content through the caller.
+type SessionCloner interface {
+	// CloneSession copies all versions of the session scoped artifacts of a
+	// session into the new session, keeping their numbers, content type and
+	// metadata. User scoped artifacts are shared by the sessions already and
+	// are not copied. It fails with [fs.ErrExist] if the new session has
+	// artifacts of its own.
+	CloneSession(ctx context.Context, req *CloneRequest) error
+}
+
+// CloneSession clones a session with [SessionCloner] if srv implements it,
+// so that a conversation can be branched without uploading its artifacts
+// again. Otherwise the versions are copied with Load and Save, keeping their
+// numbers and, if srv implements [Stater], their metadata.
+func CloneSession(ctx context.Context, srv artifact.Service, req *CloneRequest) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("request validation failed: %w", err)
+	}
+	if c, ok := As[SessionCloner](srv); ok {
+		return c.CloneSession(ctx, req)
+	}
+
+	names, err := SessionFileNames(ctx, srv, req.AppName, req.UserID, req.SessionID)
+	if err != nil {
+		return err
+	}
+	existing, err := SessionFileNames(ctx, srv, req.AppName, req.UserID, req.NewSessionID)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("session %q has artifacts: %w", req.NewSessionID, fs.ErrExist)
+	}
+	for _, name := range names {
+		resp, err := srv.Versions(ctx, &artifact.VersionsRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: name,
+		})
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // deleted since listing
+		}
+		if err != nil {
+			return err
+		}
+		if err := copyVersions(ctx, srv, req.AppName, req.UserID, req.SessionID, name, req.NewSessionID, name, resp.Versions); err != nil {
+			return fmt.Errorf("failed to clone %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// SessionFileNames returns the names of the session scoped artifacts of a
+// session, leaving out the user scoped ones List also returns.
+func SessionFileNames(ctx context.Context, srv artifact.Service, appName, userID, sessionID string) ([]string, error) {
+	resp, err := srv.List(ctx, &artifact.ListRequest{AppName: appName, UserID: userID, SessionID: sessionID})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, name := range resp.FileNames {
+		if !IsUserScoped(name) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/adk/artifact"
+)
+
+// ArchivePrefix is the top level key prefix under which backends keep the
+// archives of compacted versions, outside of the artifact layout.
+const ArchivePrefix = ".archive"
+
+// CompactRequest selects the versions [Compactor.Compact] archives.
+type CompactRequest struct {
+	AppName, UserID, SessionID, FileName string
+	// Keep is the number of latest versions left in place. It must be at
+	// least one, so that new versions keep being numbered after the
+	// archived ones.
+	Keep int
+}
+
+// Validate checks that the request names an artifact and keeps a version.
+func (r *CompactRequest) Validate() error {
+	if err := (&artifact.VersionsRequest{
+		AppName: r.AppName, UserID: r.UserID, SessionID: r.SessionID, FileName: r.FileName,
+	}).Validate(); err != nil {
+		return err
+	}
+	if r.Keep < 1 {
+		return fmt.Errorf("compaction must keep at least one version, got %d", r.Keep)
+	}
+	return nil
+}
+
+// Compactor is implemented by services that can bundle the old versions of
+// an artifact into a single archive object, to reduce the object counts and
+// listing costs of artifacts with thousands of versions. Archived versions
+// are no longer returned by Versions or Load until they are restored.
+type Compactor interface {
+	// Compact moves the versions of an artifact but the latest req.Keep
+	// into its archive, and returns the versions it moved. Pinned versions
+	// are left in place. Compactions of the same artifact must not run
+	// concurrently.
+	Compact(ctx context.Context, req *CompactRequest) ([]int64, error)
+	// ArchivedVersions returns the versions in the archive of an artifact,
+	// in ascending order.
+	ArchivedVersions(ctx context.Context, req *artifact.VersionsRequest) ([]int64, error)
+	// RestoreVersion saves an archived version again under its number,
+	// with its content type and metadata. It fails if the version exists.
+	// The version stays in the archive.
+	RestoreVersion(ctx context.Context, req *artifact.LoadRequest) error
+}
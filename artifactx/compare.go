@@ -0,0 +1,221 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"google.golang.org/adk/artifact"
+)
+
+// ContentComparison tells whether two versions have the same content.
+type ContentComparison int
+
+const (
+	// ContentUnknown means the attributes of the versions cannot tell: they
+	// have the same size and no comparable checksums.
+	ContentUnknown ContentComparison = iota
+	// ContentEqual means the versions have the same content.
+	ContentEqual
+	// ContentChanged means the versions have different content.
+	ContentChanged
+)
+
+// String returns "unknown", "equal" or "changed".
+func (c ContentComparison) String() string {
+	switch c {
+	case ContentEqual:
+		return "equal"
+	case ContentChanged:
+		return "changed"
+	}
+	return "unknown"
+}
+
+// VersionComparison is the result of [CompareVersions].
+type VersionComparison struct {
+	// A and B are the attributes of the compared versions.
+	A, B *Attributes
+	// SizeDelta is the size of B minus the size of A.
+	SizeDelta int64
+	// Content tells whether the contents are the same.
+	Content ContentComparison
+	// ContentTypeChanged reports whether the stored content types differ.
+	ContentTypeChanged bool
+	// Metadata turns the metadata of A into that of B, or is nil if they
+	// are equal.
+	Metadata *MetadataChange
+}
+
+// Changed reports whether the versions differ in content, content type or
+// metadata. Unknown content counts as changed.
+func (c *VersionComparison) Changed() bool {
+	return c.Content != ContentEqual || c.ContentTypeChanged || c.Metadata != nil
+}
+
+// CompareOptions controls [CompareVersions].
+type CompareOptions struct {
+	// Content makes CompareVersions read both versions when their
+	// attributes cannot tell whether the contents are the same. Without
+	// it, the comparison is metadata only and may report
+	// [ContentUnknown].
+	Content bool
+}
+
+// CompareVersions compares the versions a and b of artifacts, usually two
+// versions of the same artifact, from their attributes: their sizes, their
+// checksums and their metadata, without downloading them. Versions whose
+// sizes or checksums differ are reported as [ContentChanged] right away;
+// with opts.Content, versions the attributes cannot tell apart are read and
+// compared. srv must implement [Stater], and [Opener] for opts.Content,
+// otherwise the versions are loaded.
+func CompareVersions(ctx context.Context, srv artifact.Service, a, b *artifact.LoadRequest, opts *CompareOptions) (*VersionComparison, error) {
+	if opts == nil {
+		opts = &CompareOptions{}
+	}
+	stater, ok := As[Stater](srv)
+	if !ok {
+		return nil, fmt.Errorf("comparing versions requires a service implementing artifactx.Stater")
+	}
+	attrsA, err := stater.Stat(ctx, a)
+	if err != nil {
+		return nil, err
+	}
+	attrsB, err := stater.Stat(ctx, b)
+	if err != nil {
+		return nil, err
+	}
+	c := &VersionComparison{
+		A:                  attrsA,
+		B:                  attrsB,
+		SizeDelta:          attrsB.Size - attrsA.Size,
+		Content:            compareContent(attrsA, attrsB),
+		ContentTypeChanged: attrsA.ContentType != attrsB.ContentType,
+		Metadata:           diffMetadata(attrsA.Metadata, attrsB.Metadata),
+	}
+	if c.Content == ContentUnknown && opts.Content {
+		// Pin the compared versions, in case a zero version moved.
+		a, b = versionRequest(a, attrsA.Version), versionRequest(b, attrsB.Version)
+		sumA, err := contentSum(ctx, srv, a)
+		if err != nil {
+			return nil, err
+		}
+		sumB, err := contentSum(ctx, srv, b)
+		if err != nil {
+			return nil, err
+		}
+		c.Content = ContentChanged
+		if bytes.Equal(sumA, sumB) {
+			c.Content = ContentEqual
+		}
+	}
+	return c, nil
+}
+
+// compareContent compares the contents of two versions by their attributes.
+func compareContent(a, b *Attributes) ContentComparison {
+	switch {
+	case a.Size != b.Size:
+		return ContentChanged
+	case isStrongETag(a.ETag) && a.ETag == b.ETag:
+		return ContentEqual
+	case isChecksumETag(a.ETag) && isChecksumETag(b.ETag):
+		// The same algorithm is not guaranteed, but 256 bit checksums of
+		// different algorithms are equally unlikely to collide.
+		return ContentChanged
+	}
+	return ContentUnknown
+}
+
+// isStrongETag reports whether etag identifies content rather than being a
+// weak validator.
+func isStrongETag(etag string) bool {
+	return etag != "" && !strings.HasPrefix(etag, "W/")
+}
+
+// isChecksumETag reports whether etag is a [ChecksumETag] of a 256 bit
+// checksum, such as SHA-256 or BLAKE3. Storage ETags, such as those of S3
+// multipart uploads, may differ for the same content and are not.
+func isChecksumETag(etag string) bool {
+	sum, ok := strings.CutPrefix(etag, `"`)
+	if !ok {
+		return false
+	}
+	sum, ok = strings.CutSuffix(sum, `"`)
+	return ok && ChecksumSHA256.valid(sum)
+}
+
+// diffMetadata returns the change turning a into b, or nil if they are equal.
+func diffMetadata(a, b map[string]string) *MetadataChange {
+	var c MetadataChange
+	for k, v := range b {
+		if old, ok := a[k]; !ok || old != v {
+			if c.Set == nil {
+				c.Set = map[string]string{}
+			}
+			c.Set[k] = v
+		}
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			c.Remove = append(c.Remove, k)
+		}
+	}
+	if c.Set == nil && c.Remove == nil {
+		return nil
+	}
+	slices.Sort(c.Remove)
+	return &c
+}
+
+// versionRequest returns a copy of req for version.
+func versionRequest(req *artifact.LoadRequest, version int64) *artifact.LoadRequest {
+	r := *req
+	r.Version = version
+	return &r
+}
+
+// contentSum returns the SHA-256 of the stored content of a version,
+// streaming it with [Opener] if srv implements it.
+func contentSum(ctx context.Context, srv artifact.Service, req *artifact.LoadRequest) ([]byte, error) {
+	h := sha256.New()
+	if o, ok := As[Opener](srv); ok {
+		r, err := o.Open(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if _, err := io.Copy(h, r); err != nil {
+			return nil, fmt.Errorf("could not read artifact '%s' version %d: %w", req.FileName, req.Version, err)
+		}
+		return h.Sum(nil), nil
+	}
+	resp, err := srv.Load(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := EncodePart(resp.Part)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(data)
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+)
+
+func TestCompareVersions(t *testing.T) {
+	ctx := t.Context()
+	for _, tc := range []struct {
+		name      string
+		checksum  *artifactx.ChecksumAlgorithm
+		data      []string
+		metadata  []map[string]string
+		want      artifactx.ContentComparison
+		wantFull  artifactx.ContentComparison
+		wantDelta int64
+		wantMeta  *artifactx.MetadataChange
+	}{
+		{
+			name: "same content", checksum: artifactx.ChecksumSHA256, data: []string{"report", "report"},
+			metadata: []map[string]string{{"stage": "draft", "owner": "ann"}, {"stage": "final"}},
+			want:     artifactx.ContentEqual, wantFull: artifactx.ContentEqual,
+			wantMeta: &artifactx.MetadataChange{Set: map[string]string{"stage": "final"}, Remove: []string{"owner"}},
+		},
+		{
+			name: "checksums differ", checksum: artifactx.ChecksumBLAKE3, data: []string{"report", "Report"},
+			want: artifactx.ContentChanged, wantFull: artifactx.ContentChanged,
+		},
+		{
+			name: "sizes differ", checksum: artifactx.ChecksumCRC32C, data: []string{"report", "report v2"},
+			want: artifactx.ContentChanged, wantFull: artifactx.ContentChanged, wantDelta: 3,
+		},
+		// CRC32C checksums are not compared: the versions are read.
+		{
+			name: "weak checksums", checksum: artifactx.ChecksumCRC32C, data: []string{"report", "Report"},
+			want: artifactx.ContentUnknown, wantFull: artifactx.ContentChanged,
+		},
+		{
+			name: "weak checksums, same content", checksum: artifactx.ChecksumCRC32C, data: []string{"report", "report"},
+			want: artifactx.ContentUnknown, wantFull: artifactx.ContentEqual,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, err := fsartifact.New(t.TempDir(), fsartifact.WithChecksumAlgorithm(tc.checksum))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, data := range tc.data {
+				saveCtx := ctx
+				if tc.metadata != nil {
+					saveCtx = artifactx.WithMetadata(ctx, tc.metadata[i])
+				}
+				if _, err := srv.Save(saveCtx, &artifact.SaveRequest{
+					AppName: "app", UserID: "user", SessionID: "session", FileName: "report", Part: genai.NewPartFromText(data),
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			a := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "report", Version: 1}
+			latest := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "report"}
+			for _, opts := range []*artifactx.CompareOptions{nil, {Content: true}} {
+				c, err := artifactx.CompareVersions(ctx, srv, a, latest, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := tc.want
+				if opts != nil {
+					want = tc.wantFull
+				}
+				if c.Content != want || c.SizeDelta != tc.wantDelta || c.B.Version != 2 || c.ContentTypeChanged {
+					t.Errorf("CompareVersions(%+v) = {Content: %v, SizeDelta: %d, B.Version: %d, ContentTypeChanged: %t}, want {%v, %d, 2, false}",
+						opts, c.Content, c.SizeDelta, c.B.Version, c.ContentTypeChanged, want, tc.wantDelta)
+				}
+				if diff := cmp.Diff(tc.wantMeta, c.Metadata); diff != "" {
+					t.Errorf("CompareVersions().Metadata mismatch (-want +got):\n%s", diff)
+				}
+				if got, wantChanged := c.Changed(), want != artifactx.ContentEqual || tc.wantMeta != nil; got != wantChanged {
+					t.Errorf("CompareVersions().Changed() = %t, want %t", got, wantChanged)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type ifNewerThanKey struct{}
+
+// WithIfNewerThan returns a copy of ctx whose Loads fail with
+// [ErrNotModified] instead of reading a version numbered version or lower.
+// Versions never change once saved, so a polling agent that passes the last
+// version it read only downloads the latest version when a new one was
+// saved. The check needs no more than resolving the requested version.
+func WithIfNewerThan(ctx context.Context, version int64) context.Context {
+	return context.WithValue(ctx, ifNewerThanKey{}, version)
+}
+
+// IfNewerThanFrom returns the version attached to ctx with [WithIfNewerThan].
+func IfNewerThanFrom(ctx context.Context) (int64, bool) {
+	v, ok := ctx.Value(ifNewerThanKey{}).(int64)
+	return v, ok
+}
+
+type ifModifiedSinceKey struct{}
+
+// WithIfModifiedSince returns a copy of ctx whose Loads fail with
+// [ErrNotModified] instead of reading a version created at or before t, as
+// the If-Modified-Since header of HTTP.
+func WithIfModifiedSince(ctx context.Context, t time.Time) context.Context {
+	return context.WithValue(ctx, ifModifiedSinceKey{}, t)
+}
+
+// IfModifiedSinceFrom returns the time attached to ctx with [WithIfModifiedSince].
+func IfModifiedSinceFrom(ctx context.Context) (time.Time, bool) {
+	t, ok := ctx.Value(ifModifiedSinceKey{}).(time.Time)
+	return t, ok
+}
+
+// CheckNewerThan returns [ErrNotModified] if version is not newer than the
+// version attached to ctx with [WithIfNewerThan].
+func CheckNewerThan(ctx context.Context, version int64) error {
+	if known, ok := IfNewerThanFrom(ctx); ok && version <= known {
+		return fmt.Errorf("%w: version %d, have %d", ErrNotModified, version, known)
+	}
+	return nil
+}
+
+// CheckModifiedSince returns [ErrNotModified] if created is not after the
+// time attached to ctx with [WithIfModifiedSince].
+func CheckModifiedSince(ctx context.Context, created time.Time) error {
+	if since, ok := IfModifiedSinceFrom(ctx); ok && !created.After(since) {
+		return fmt.Errorf("%w: created %s", ErrNotModified, created.UTC().Format(time.RFC3339Nano))
+	}
+	return nil
+}
+
+// WithoutConditions returns a copy of ctx without the conditions attached
+// with [WithIfNewerThan], [WithIfModifiedSince] and [WithIfNoneMatch], for
+// the loads a decorator makes on its own behalf, such as loading the
+// versions a requested version points at.
+func WithoutConditions(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, ifNewerThanKey{}, nil)
+	ctx = context.WithValue(ctx, ifModifiedSinceKey{}, nil)
+	return context.WithValue(ctx, ifNoneMatchKey{}, nil)
+}
+
+// NeedsAttributes reports whether ctx carries conditions that are checked
+// against the attributes of a version: [WithIfNoneMatch] or
+// [WithIfModifiedSince].
+func NeedsAttributes(ctx context.Context) bool {
+	_, since := IfModifiedSinceFrom(ctx)
+	return since || len(IfNoneMatchFrom(ctx)) > 0
+}
+
+// CheckConditions returns [ErrNotModified] if the version described by
+// attrs fails one of the conditions attached to ctx.
+func CheckConditions(ctx context.Context, attrs *Attributes) error {
+	if err := CheckNewerThan(ctx, attrs.Version); err != nil {
+		return err
+	}
+	if err := CheckNotModified(ctx, attrs.ETag); err != nil {
+		return err
+	}
+	return CheckModifiedSince(ctx, attrs.Created)
+}
@@ -0,0 +1,53 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import "context"
+
+// RemovalReason is what removes a version.
+type RemovalReason string
+
+const (
+	// ByDelete is a version removed by Delete.
+	ByDelete RemovalReason = "delete"
+	// ByRetention is a version expired by a [Retention] policy.
+	ByRetention RemovalReason = "retention"
+	// ByEviction is a version evicted to free storage space.
+	ByEviction RemovalReason = "eviction"
+)
+
+// Removal is a version that a destructive operation would remove under
+// [WithDryRun].
+type Removal struct {
+	Ref
+	Reason RemovalReason `json:"reason"`
+}
+
+type dryRunKey struct{}
+
+// WithDryRun returns a copy of ctx under which backends report the versions
+// that Delete, retention and eviction would remove to report instead of
+// removing them, e.g. to validate a new retention policy in production.
+// Saves made with ctx still save; only what they would clean up is spared.
+func WithDryRun(ctx context.Context, report func(Removal)) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, report)
+}
+
+// DryRunFrom returns the function attached to ctx with [WithDryRun], and
+// whether there is one.
+func DryRunFrom(ctx context.Context) (func(Removal), bool) {
+	report, ok := ctx.Value(dryRunKey{}).(func(Removal))
+	return report, ok && report != nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotModified is returned by Load when the version matches one of the
+// ETags attached with [WithIfNoneMatch], or fails the conditions attached
+// with [WithIfNewerThan] or [WithIfModifiedSince].
+var ErrNotModified = errors.New("artifact not modified")
+
+// ChecksumMetadataKey is the metadata key under which backends record the
+// hex SHA-256 of a version's content. It is reported as [Attributes.ETag]
+// and never in [Attributes.Metadata].
+const ChecksumMetadataKey = "adk-sha256"
+
+// Checksum returns the hex SHA-256 of data.
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ChecksumETag returns the strong ETag of content with the hex checksum of
+// a cryptographic [ChecksumAlgorithm], such as SHA-256.
+func ChecksumETag(checksum string) string {
+	return `"` + checksum + `"`
+}
+
+// WithChecksum returns a copy of md recording checksum under
+// [ChecksumMetadataKey].
+func WithChecksum(md map[string]string, checksum string) map[string]string {
+	out := make(map[string]string, len(md)+1)
+	for k, v := range md {
+		out[k] = v
+	}
+	out[ChecksumMetadataKey] = checksum
+	return out
+}
+
+// SplitChecksum returns md without [ChecksumMetadataKey] and the checksum
+// recorded under it, or "" if there is none.
+func SplitChecksum(md map[string]string) (map[string]string, string) {
+	checksum, ok := md[ChecksumMetadataKey]
+	if !ok {
+		return md, ""
+	}
+	out := make(map[string]string, len(md)-1)
+	for k, v := range md {
+		if k != ChecksumMetadataKey {
+			out[k] = v
+		}
+	}
+	if len(out) == 0 {
+		out = nil
+	}
+	return out, checksum
+}
+
+type ifNoneMatchKey struct{}
+
+// WithIfNoneMatch returns a copy of ctx whose Loads fail with
+// [ErrNotModified] instead of reading a version whose ETag is one of etags,
+// as the If-None-Match header of HTTP. The ETag "*" matches any version.
+func WithIfNoneMatch(ctx context.Context, etags ...string) context.Context {
+	return context.WithValue(ctx, ifNoneMatchKey{}, etags)
+}
+
+// IfNoneMatchFrom returns the ETags attached to ctx with [WithIfNoneMatch].
+func IfNoneMatchFrom(ctx context.Context) []string {
+	etags, _ := ctx.Value(ifNoneMatchKey{}).([]string)
+	return etags
+}
+
+// CheckNotModified returns [ErrNotModified] if etag matches one of the ETags
+// attached to ctx with [WithIfNoneMatch]. ETags are compared weakly, ignoring
+// a "W/" prefix, as HTTP does for If-None-Match.
+func CheckNotModified(ctx context.Context, etag string) error {
+	if etag == "" {
+		return nil
+	}
+	for _, e := range IfNoneMatchFrom(ctx) {
+		if e == "*" || strings.TrimPrefix(e, "W/") == strings.TrimPrefix(etag, "W/") {
+			return fmt.Errorf("%w: etag %s", ErrNotModified, etag)
+		}
+	}
+	return nil
+}
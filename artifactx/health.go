@@ -0,0 +1,32 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrBackendUnavailable is returned by services that fail requests without
+// trying them because their storage is known to be down, such as
+// breakerartifact with an open circuit.
+var ErrBackendUnavailable = errors.New("artifact backend unavailable")
+
+// HealthChecker is implemented by services that can verify their storage is
+// reachable and usable, for wiring into readiness probes.
+type HealthChecker interface {
+	// HealthCheck returns nil if the service can serve requests.
+	HealthCheck(ctx context.Context) error
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"errors"
+	"maps"
+)
+
+// ImmutableMetadataKey is the metadata key under which backends that store
+// metadata with the object mark immutable versions.
+const ImmutableMetadataKey = "adk-immutable"
+
+// ErrImmutable is returned by Save when the artifact was saved with
+// [WithImmutable], so its content cannot be replaced.
+var ErrImmutable = errors.New("artifact is immutable")
+
+type immutableKey struct{}
+
+// WithImmutable returns a copy of ctx under which Save marks the version it
+// writes immutable: backends implementing the flag fail any later Save of
+// the file, including the overwrite of a version, with [ErrImmutable]. It
+// protects canonical inputs, such as the original upload of a user, from
+// being replaced by agent tools. Delete still removes immutable artifacts.
+func WithImmutable(ctx context.Context) context.Context {
+	return context.WithValue(ctx, immutableKey{}, true)
+}
+
+// ImmutableFrom reports whether ctx was returned by [WithImmutable].
+func ImmutableFrom(ctx context.Context) bool {
+	immutable, _ := ctx.Value(immutableKey{}).(bool)
+	return immutable
+}
+
+// WithImmutableFlag returns a copy of md marking a version as immutable
+// under [ImmutableMetadataKey], or not.
+func WithImmutableFlag(md map[string]string, immutable bool) map[string]string {
+	out := maps.Clone(md)
+	if out == nil {
+		out = map[string]string{}
+	}
+	if immutable {
+		out[ImmutableMetadataKey] = "true"
+	} else {
+		delete(out, ImmutableMetadataKey)
+	}
+	return out
+}
+
+// SplitImmutable returns md without [ImmutableMetadataKey] and whether it
+// marks the version as immutable.
+func SplitImmutable(md map[string]string) (map[string]string, bool) {
+	if _, ok := md[ImmutableMetadataKey]; !ok {
+		return md, false
+	}
+	out := maps.Clone(md)
+	delete(out, ImmutableMetadataKey)
+	return out, md[ImmutableMetadataKey] == "true"
+}
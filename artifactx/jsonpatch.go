@@ -0,0 +1,527 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ErrPatchFailed is returned when a JSON patch cannot be applied to a
+// document: a "test" operation failed or a path does not exist.
+var ErrPatchFailed = errors.New("JSON patch failed")
+
+// ApplyJSONPatch applies the JSON Patch (RFC 6902) patch to the JSON
+// document doc. The operations are applied in order and the patch fails as
+// a whole, with [ErrPatchFailed] if an operation does not apply. The order
+// of object members is kept and the result is compact.
+func ApplyJSONPatch(doc, patch []byte) ([]byte, error) {
+	var ops []struct {
+		Op    string           `json:"op"`
+		Path  *string          `json:"path"`
+		From  *string          `json:"from"`
+		Value *json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("invalid JSON patch: %w", err)
+	}
+	root, err := decodeJSON(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+	for i, op := range ops {
+		if op.Path == nil {
+			return nil, fmt.Errorf("invalid JSON patch: operation %d has no path", i)
+		}
+		path, err := parsePointer(*op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON patch: operation %d: %w", i, err)
+		}
+		var value any
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, fmt.Errorf("invalid JSON patch: %s operation %d has no value", op.Op, i)
+			}
+			if value, err = decodeJSON(*op.Value); err != nil {
+				return nil, fmt.Errorf("invalid JSON patch: operation %d: %w", i, err)
+			}
+		case "move", "copy":
+			if op.From == nil {
+				return nil, fmt.Errorf("invalid JSON patch: %s operation %d has no from", op.Op, i)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("invalid JSON patch: operation %d has unknown op %q", i, op.Op)
+		}
+		if root, err = applyOp(root, op.Op, path, op.From, value); err != nil {
+			return nil, fmt.Errorf("%w: %s operation %d on %q: %w", ErrPatchFailed, op.Op, i, *op.Path, err)
+		}
+	}
+	return encodeJSON(root)
+}
+
+// ApplyMergePatch applies the JSON Merge Patch (RFC 7386) patch to the JSON
+// document doc: members of patch objects replace the members of doc
+// recursively and null members remove them. The order of object members
+// is kept and the result is compact.
+func ApplyMergePatch(doc, patch []byte) ([]byte, error) {
+	p, err := decodeJSON(patch)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON merge patch: %w", err)
+	}
+	root, err := decodeJSON(doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+	return encodeJSON(mergePatch(root, p))
+}
+
+func applyOp(root any, op string, path []string, from *string, value any) (any, error) {
+	switch op {
+	case "add":
+		return addValue(root, path, value)
+	case "remove":
+		root, _, err := removeValue(root, path)
+		return root, err
+	case "replace":
+		return replaceValue(root, path, value)
+	case "test":
+		got, err := getValue(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equalJSON(got, value) {
+			return nil, errors.New("test failed")
+		}
+		return root, nil
+	}
+	src, err := parsePointer(*from)
+	if err != nil {
+		return nil, err
+	}
+	if op == "copy" {
+		value, err := getValue(root, src)
+		if err != nil {
+			return nil, err
+		}
+		return addValue(root, path, cloneJSON(value))
+	}
+	// move
+	if len(path) > len(src) && strings.HasPrefix(pointerString(path), pointerString(src)+"/") {
+		return nil, errors.New("cannot move a value into itself")
+	}
+	root, value, err = removeValue(root, src)
+	if err != nil {
+		return nil, err
+	}
+	return addValue(root, path, value)
+}
+
+// jsonObject is a decoded JSON object keeping the order of its members.
+type jsonObject struct {
+	keys   []string
+	values map[string]any
+}
+
+func newJSONObject() *jsonObject {
+	return &jsonObject{values: map[string]any{}}
+}
+
+func (o *jsonObject) set(key string, value any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *jsonObject) remove(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// decodeJSON decodes one JSON value into nil, bool, [json.Number], string,
+// []any or *jsonObject.
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return v, nil
+}
+
+func decodeValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		o := newJSONObject()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			o.set(key.(string), v)
+		}
+		_, err := dec.Token()
+		return o, err
+	case json.Delim('['):
+		a := []any{}
+		for dec.More() {
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		_, err := dec.Token()
+		return a, err
+	}
+	return tok, nil
+}
+
+func encodeJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := appendJSON(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func appendJSON(buf *bytes.Buffer, v any) error {
+	switch v := v.(type) {
+	case *jsonObject:
+		buf.WriteByte('{')
+		for i, k := range v.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			appendJSONString(buf, k)
+			buf.WriteByte(':')
+			if err := appendJSON(buf, v.values[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []any:
+		buf.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := appendJSON(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case string:
+		appendJSONString(buf, v)
+	case json.Number:
+		buf.WriteString(v.String())
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case nil:
+		buf.WriteString("null")
+	default:
+		return fmt.Errorf("unexpected JSON value of type %T", v)
+	}
+	return nil
+}
+
+func appendJSONString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)               // strings always encode
+	buf.Truncate(buf.Len() - 1) // the newline written by Encode
+}
+
+// parsePointer splits the JSON Pointer (RFC 6901) p into its unescaped
+// reference tokens.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if p[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON pointer %q", p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func pointerString(tokens []string) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(t, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// arrayIndex parses the reference token of an element of an array of n
+// elements; "-" and n are only valid if end is set.
+func arrayIndex(token string, n int, end bool) (int, error) {
+	if token == "-" && end {
+		return n, nil
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i > n || (i == n && !end) {
+		return 0, fmt.Errorf("array index %s out of range", token)
+	}
+	return i, nil
+}
+
+func getValue(v any, path []string) (any, error) {
+	for _, token := range path {
+		switch c := v.(type) {
+		case *jsonObject:
+			child, ok := c.values[token]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", token)
+			}
+			v = child
+		case []any:
+			i, err := arrayIndex(token, len(c), false)
+			if err != nil {
+				return nil, err
+			}
+			v = c[i]
+		default:
+			return nil, fmt.Errorf("cannot find %q in a scalar value", token)
+		}
+	}
+	return v, nil
+}
+
+// addValue adds value at path in v and returns the updated v.
+func addValue(v any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	token := path[0]
+	switch c := v.(type) {
+	case *jsonObject:
+		if len(path) == 1 {
+			c.set(token, value)
+			return c, nil
+		}
+		child, ok := c.values[token]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", token)
+		}
+		child, err := addValue(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		c.set(token, child)
+		return c, nil
+	case []any:
+		i, err := arrayIndex(token, len(c), len(path) == 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 1 {
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		}
+		if c[i], err = addValue(c[i], path[1:], value); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	return nil, fmt.Errorf("cannot add %q to a scalar value", token)
+}
+
+// replaceValue replaces the value at path in v, in the same position, and
+// returns the updated v.
+func replaceValue(v any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := getValue(v, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	token := path[len(path)-1]
+	switch c := parent.(type) {
+	case *jsonObject:
+		if _, ok := c.values[token]; !ok {
+			return nil, fmt.Errorf("member %q not found", token)
+		}
+		c.set(token, value)
+	case []any:
+		i, err := arrayIndex(token, len(c), false)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = value
+	default:
+		return nil, fmt.Errorf("cannot replace %q in a scalar value", token)
+	}
+	return v, nil
+}
+
+// removeValue removes the value at path in v and returns the updated v and
+// the removed value.
+func removeValue(v any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, v, nil
+	}
+	token := path[0]
+	switch c := v.(type) {
+	case *jsonObject:
+		child, ok := c.values[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("member %q not found", token)
+		}
+		if len(path) == 1 {
+			c.remove(token)
+			return c, child, nil
+		}
+		child, removed, err := removeValue(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		c.set(token, child)
+		return c, removed, nil
+	case []any:
+		i, err := arrayIndex(token, len(c), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(path) == 1 {
+			removed := c[i]
+			return append(c[:i:i], c[i+1:]...), removed, nil
+		}
+		child, removed, err := removeValue(c[i], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		c[i] = child
+		return c, removed, nil
+	}
+	return nil, nil, fmt.Errorf("cannot remove %q from a scalar value", token)
+}
+
+func cloneJSON(v any) any {
+	switch v := v.(type) {
+	case *jsonObject:
+		o := newJSONObject()
+		for _, k := range v.keys {
+			o.set(k, cloneJSON(v.values[k]))
+		}
+		return o
+	case []any:
+		a := make([]any, len(v))
+		for i, e := range v {
+			a[i] = cloneJSON(e)
+		}
+		return a
+	}
+	return v
+}
+
+// equalJSON reports whether a and b are equal JSON values; numbers are
+// compared by value and object members regardless of their order.
+func equalJSON(a, b any) bool {
+	switch a := a.(type) {
+	case *jsonObject:
+		b, ok := b.(*jsonObject)
+		if !ok || len(a.keys) != len(b.keys) {
+			return false
+		}
+		for k, v := range a.values {
+			w, ok := b.values[k]
+			if !ok || !equalJSON(v, w) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equalJSON(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, okx := new(big.Rat).SetString(a.String())
+		y, oky := new(big.Rat).SetString(b.String())
+		return okx && oky && x.Cmp(y) == 0
+	}
+	return a == b
+}
+
+func mergePatch(target, patch any) any {
+	p, ok := patch.(*jsonObject)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(*jsonObject)
+	if !ok {
+		t = newJSONObject()
+	}
+	for _, k := range p.keys {
+		if v := p.values[k]; v == nil {
+			t.remove(k)
+		} else {
+			t.set(k, mergePatch(t.values[k], v))
+		}
+	}
+	return t
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	for _, tc := range []struct {
+		name, doc, patch, want string
+		wantErr                error
+	}{
+		// The examples of RFC 6902, appendix A.
+		{"add member", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"foo":"bar","baz":"qux"}`, nil},
+		{"add element", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`, nil},
+		{"remove member", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`, nil},
+		{"remove element", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`, nil},
+		{"replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`, nil},
+		{"move member", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, nil},
+		{"move element", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`, nil},
+		{"test", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`, nil},
+		{"test failed", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, "", artifactx.ErrPatchFailed},
+		{"add nested", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`, nil},
+		{"add to missing parent", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, "", artifactx.ErrPatchFailed},
+		{"add array", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`, nil},
+		{"escaped pointer", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10},{"op":"remove","path":"/~1"}]`, `{"~1":10}`, nil},
+		{"test numbers by value", `{"n":1}`, `[{"op":"test","path":"/n","value":1.0}]`, `{"n":1}`, nil},
+
+		{"copy", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`, nil},
+		{"replace root", `{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`, nil},
+		{"move into itself", `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/b/c"}]`, "", artifactx.ErrPatchFailed},
+		{"index out of range", `[1]`, `[{"op":"add","path":"/2","value":1}]`, "", artifactx.ErrPatchFailed},
+		{"leading zero index", `[1,2]`, `[{"op":"remove","path":"/01"}]`, "", artifactx.ErrPatchFailed},
+		{"keeps member order and HTML", `{"z":"<b>","a":1}`, `[{"op":"add","path":"/m","value":true}]`, `{"z":"<b>","a":1,"m":true}`, nil},
+	} {
+		got, err := artifactx.ApplyJSONPatch([]byte(tc.doc), []byte(tc.patch))
+		if tc.wantErr != nil {
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("%s: ApplyJSONPatch() = (%s, %v), want error(%v)", tc.name, got, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil || string(got) != tc.want {
+			t.Errorf("%s: ApplyJSONPatch() = (%s, %v), want %s", tc.name, got, err, tc.want)
+		}
+	}
+	for _, patch := range []string{`{}`, `[{"op":"add","value":1}]`, `[{"op":"add","path":"/a"}]`, `[{"op":"frob","path":"/a"}]`, `[{"op":"remove","path":"a"}]`} {
+		if _, err := artifactx.ApplyJSONPatch([]byte(`{"a":1}`), []byte(patch)); err == nil || errors.Is(err, artifactx.ErrPatchFailed) {
+			t.Errorf("ApplyJSONPatch(%s) = %v, want an invalid patch error", patch, err)
+		}
+	}
+	if _, err := artifactx.ApplyJSONPatch([]byte(`{"a":1} x`), []byte(`[]`)); err == nil {
+		t.Error("ApplyJSONPatch() of an invalid document succeeded")
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	// The examples of RFC 7386, appendix A.
+	for _, tc := range []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	} {
+		got, err := artifactx.ApplyMergePatch([]byte(tc.doc), []byte(tc.patch))
+		if err != nil || string(got) != tc.want {
+			t.Errorf("ApplyMergePatch(%s, %s) = (%s, %v), want %s", tc.doc, tc.patch, got, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KeyBuilder maps artifact versions to slash separated storage keys. The
+// file system backend uses the keys as paths below its root directory.
+type KeyBuilder interface {
+	// VersionKey returns the key of a version.
+	VersionKey(appName, userID, sessionID, fileName string, version int64) string
+	// ArtifactPrefix returns the prefix, ending in "/", shared by the keys of
+	// all versions of an artifact and by no other key.
+	ArtifactPrefix(appName, userID, sessionID, fileName string) string
+	// ListPrefixes returns the prefixes, ending in "/", whose direct children
+	// are the artifacts visible from a session.
+	ListPrefixes(appName, userID, sessionID string) []string
+	// ParseKey returns the version stored under key, or an error if key is
+	// not a version key. User scoped artifacts are reported with
+	// [UserNamespace] as their session ID.
+	ParseKey(key string) (Ref, error)
+}
+
+// HierarchyKeyBuilder is implemented by key builders that nest sessions
+// below users below apps, so that the hierarchy can be browsed by prefix;
+// see [Browser].
+type HierarchyKeyBuilder interface {
+	KeyBuilder
+	// UsersPrefix returns the prefix, ending in "/", whose direct children
+	// are the users of an app.
+	UsersPrefix(appName string) string
+	// SessionsPrefix returns the prefix, ending in "/", whose direct children
+	// are the sessions of a user and the user namespace.
+	SessionsPrefix(appName, userID string) string
+	// SessionID returns the session ID stored as the child segment of a
+	// sessions prefix, or false if segment is the user namespace.
+	SessionID(segment string) (string, bool)
+}
+
+// ErrReservedSessionID is returned by writes and listings naming a session
+// that the key layout cannot tell apart from the user namespace, such as a
+// session literally named [UserNamespace] in [DefaultKeyBuilder].
+var ErrReservedSessionID = errors.New("session ID is reserved by the key layout")
+
+// ErrReservedAppName is returned by writes, deletes and listings naming an
+// app that starts with ".". Such names are reserved for the state backends
+// keep beside the artifacts, such as [UploadsPrefix], [IndexPrefix] and
+// [ArchivePrefix], which their walks, checks and backups skip.
+var ErrReservedAppName = errors.New("app name is reserved")
+
+// CheckAppName returns an error wrapping [ErrReservedAppName] if appName is
+// reserved. Backends call it for writes, deletes and listings, so that they
+// cannot reach the state kept below the reserved names.
+func CheckAppName(appName string) error {
+	if strings.HasPrefix(appName, ".") {
+		return fmt.Errorf("%w: %q starts with \".\"", ErrReservedAppName, appName)
+	}
+	return nil
+}
+
+// SessionIDChecker is implemented by key builders that cannot store every
+// session ID unambiguously.
+type SessionIDChecker interface {
+	// CheckSessionID returns an error wrapping [ErrReservedSessionID] if
+	// fileName, or the listing of the session if it is empty, cannot be
+	// stored unambiguously in the session.
+	CheckSessionID(sessionID, fileName string) error
+}
+
+// CheckSessionID calls [SessionIDChecker.CheckSessionID] if kb implements it.
+// Backends call it for writes and listings only, so that data stored before
+// the check existed can still be read and migrated.
+func CheckSessionID(kb KeyBuilder, sessionID, fileName string) error {
+	if c, ok := kb.(SessionIDChecker); ok {
+		return c.CheckSessionID(sessionID, fileName)
+	}
+	return nil
+}
+
+// DefaultKeyBuilder is the "app/user/session/file/version" layout, with
+// "user:" files stored under the [UserNamespace] session.
+var DefaultKeyBuilder KeyBuilder = defaultKeyBuilder{}
+
+var (
+	_ HierarchyKeyBuilder = defaultKeyBuilder{}
+	_ SessionIDChecker    = defaultKeyBuilder{}
+)
+
+type defaultKeyBuilder struct{}
+
+func (b defaultKeyBuilder) VersionKey(appName, userID, sessionID, fileName string, version int64) string {
+	return b.ArtifactPrefix(appName, userID, sessionID, fileName) + strconv.FormatInt(version, 10)
+}
+
+func (defaultKeyBuilder) ArtifactPrefix(appName, userID, sessionID, fileName string) string {
+	if strings.HasPrefix(fileName, userPrefix) {
+		sessionID = UserNamespace
+	}
+	// Concatenation rather than Sprintf: keys are built for every operation.
+	return appName + "/" + userID + "/" + sessionID + "/" + fileName + "/"
+}
+
+func (defaultKeyBuilder) ListPrefixes(appName, userID, sessionID string) []string {
+	return []string{
+		fmt.Sprintf("%s/%s/%s/", appName, userID, sessionID),
+		fmt.Sprintf("%s/%s/%s/", appName, userID, UserNamespace),
+	}
+}
+
+func (defaultKeyBuilder) ParseKey(key string) (Ref, error) {
+	return ParseRef(key)
+}
+
+func (defaultKeyBuilder) UsersPrefix(appName string) string {
+	return appName + "/"
+}
+
+func (defaultKeyBuilder) SessionsPrefix(appName, userID string) string {
+	return fmt.Sprintf("%s/%s/", appName, userID)
+}
+
+func (defaultKeyBuilder) SessionID(segment string) (string, bool) {
+	return segment, segment != UserNamespace
+}
+
+// CheckSessionID rejects the session [UserNamespace], whose directory is the
+// user namespace, except for "user:" files, which are stored there anyway.
+func (defaultKeyBuilder) CheckSessionID(sessionID, fileName string) error {
+	if sessionID == UserNamespace && !strings.HasPrefix(fileName, userPrefix) {
+		return fmt.Errorf("%w: %q collides with the user namespace; use EscapedKeyBuilder", ErrReservedSessionID, sessionID)
+	}
+	return nil
+}
+
+// EscapedKeyBuilder is the layout of [DefaultKeyBuilder] with the user
+// namespace stored as "~user", so that a session literally named
+// [UserNamespace] does not collide with it. Session IDs starting with "~" are
+// escaped with another "~".
+//
+// Existing data in the default layout is migrated by copying it to a store
+// using this layout, e.g. with artifactbackup.Backup.
+var EscapedKeyBuilder KeyBuilder = escapedKeyBuilder{}
+
+var _ HierarchyKeyBuilder = escapedKeyBuilder{}
+
+// escapedUserNamespace is the segment of the user namespace in
+// [EscapedKeyBuilder].
+const escapedUserNamespace = "~" + UserNamespace
+
+type escapedKeyBuilder struct{}
+
+func escapeSession(sessionID string) string {
+	if strings.HasPrefix(sessionID, "~") {
+		return "~" + sessionID
+	}
+	return sessionID
+}
+
+func (b escapedKeyBuilder) VersionKey(appName, userID, sessionID, fileName string, version int64) string {
+	return b.ArtifactPrefix(appName, userID, sessionID, fileName) + strconv.FormatInt(version, 10)
+}
+
+func (escapedKeyBuilder) ArtifactPrefix(appName, userID, sessionID, fileName string) string {
+	segment := escapeSession(sessionID)
+	if strings.HasPrefix(fileName, userPrefix) {
+		segment = escapedUserNamespace
+	}
+	return appName + "/" + userID + "/" + segment + "/" + fileName + "/"
+}
+
+func (escapedKeyBuilder) ListPrefixes(appName, userID, sessionID string) []string {
+	return []string{
+		fmt.Sprintf("%s/%s/%s/", appName, userID, escapeSession(sessionID)),
+		fmt.Sprintf("%s/%s/%s/", appName, userID, escapedUserNamespace),
+	}
+}
+
+func (b escapedKeyBuilder) ParseKey(key string) (Ref, error) {
+	ref, err := ParseRef(key)
+	if err != nil {
+		return Ref{}, err
+	}
+	sessionID, ok := b.SessionID(ref.SessionID)
+	switch {
+	case !ok:
+		if !strings.HasPrefix(ref.FileName, userPrefix) {
+			return Ref{}, fmt.Errorf("invalid artifact key %q: only user: files belong to the user namespace", key)
+		}
+		ref.SessionID = UserNamespace
+	case strings.HasPrefix(ref.SessionID, "~") && !strings.HasPrefix(ref.SessionID, "~~"):
+		return Ref{}, fmt.Errorf("invalid artifact key %q: unknown escaped session %q", key, ref.SessionID)
+	case strings.HasPrefix(ref.FileName, userPrefix):
+		return Ref{}, fmt.Errorf("invalid artifact key %q: user: files belong to the user namespace", key)
+	default:
+		ref.SessionID = sessionID
+	}
+	return ref, nil
+}
+
+func (escapedKeyBuilder) UsersPrefix(appName string) string {
+	return appName + "/"
+}
+
+func (escapedKeyBuilder) SessionsPrefix(appName, userID string) string {
+	return fmt.Sprintf("%s/%s/", appName, userID)
+}
+
+func (escapedKeyBuilder) SessionID(segment string) (string, bool) {
+	if segment == escapedUserNamespace {
+		return "", false
+	}
+	if strings.HasPrefix(segment, "~~") {
+		return segment[1:], true
+	}
+	return segment, true
+}
+
+// ZeroBasedKeyBuilder returns the layout of kb with versions numbered from 0
+// in keys, as the artifact services of the Python ADK number them, so that
+// Go and Python services can share a store. Services keep numbering versions
+// from 1, since version 0 requests the latest version: the version a Python
+// service saves as 0 is version 1 to Go services.
+//
+// Existing data is converted by copying it to a store using the new layout,
+// e.g. with artifactbackup.Backup, which keeps the version numbers of the
+// services.
+func ZeroBasedKeyBuilder(kb KeyBuilder) KeyBuilder {
+	z := zeroBasedKeyBuilder{kb}
+	if h, ok := kb.(HierarchyKeyBuilder); ok {
+		return zeroBasedHierarchyKeyBuilder{z, h}
+	}
+	return z
+}
+
+// PythonKeyBuilder is the object key layout of the GcsArtifactService of the
+// Python ADK: "app/user/session/file/version", with user scoped artifacts in
+// the session "user" and versions numbered from 0. Go and Python agents using
+// it read each other's artifacts in the same bucket without a migration.
+var PythonKeyBuilder = ZeroBasedKeyBuilder(DefaultKeyBuilder)
+
+type zeroBasedKeyBuilder struct {
+	KeyBuilder
+}
+
+func (b zeroBasedKeyBuilder) VersionKey(appName, userID, sessionID, fileName string, version int64) string {
+	return b.KeyBuilder.VersionKey(appName, userID, sessionID, fileName, version-1)
+}
+
+func (b zeroBasedKeyBuilder) ParseKey(key string) (Ref, error) {
+	ref, err := b.KeyBuilder.ParseKey(key)
+	if err != nil {
+		return Ref{}, err
+	}
+	if ref.Version < 0 {
+		return Ref{}, fmt.Errorf("invalid artifact key %q: negative version", key)
+	}
+	ref.Version++
+	return ref, nil
+}
+
+func (b zeroBasedKeyBuilder) CheckSessionID(sessionID, fileName string) error {
+	return CheckSessionID(b.KeyBuilder, sessionID, fileName)
+}
+
+type zeroBasedHierarchyKeyBuilder struct {
+	zeroBasedKeyBuilder
+	h HierarchyKeyBuilder
+}
+
+func (b zeroBasedHierarchyKeyBuilder) UsersPrefix(appName string) string {
+	return b.h.UsersPrefix(appName)
+}
+
+func (b zeroBasedHierarchyKeyBuilder) SessionsPrefix(appName, userID string) string {
+	return b.h.SessionsPrefix(appName, userID)
+}
+
+func (b zeroBasedHierarchyKeyBuilder) SessionID(segment string) (string, bool) {
+	return b.h.SessionID(segment)
+}
+
+// IsInvalidVersionKey reports whether key is laid out like a version key of
+// kb except for its last segment, i.e. it sits among the versions of an
+// artifact without being one.
+func IsInvalidVersionKey(kb KeyBuilder, key string) bool {
+	i := strings.LastIndex(key, "/")
+	if i < 0 {
+		return false
+	}
+	_, err := kb.ParseKey(key[:i+1] + "1")
+	return err == nil
+}
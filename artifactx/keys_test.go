@@ -0,0 +1,107 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+func TestEscapedKeyBuilder(t *testing.T) {
+	kb := artifactx.EscapedKeyBuilder
+	for _, tc := range []struct {
+		sessionID, fileName string
+		key                 string
+		ref                 artifactx.Ref
+	}{
+		{"s1", "file", "app/u/s1/file/1", artifactx.Ref{SessionID: "s1", FileName: "file"}},
+		{"user", "file", "app/u/user/file/1", artifactx.Ref{SessionID: "user", FileName: "file"}},
+		{"s1", "user:file", "app/u/~user/user:file/1", artifactx.Ref{SessionID: "user", FileName: "user:file"}},
+		{"~user", "file", "app/u/~~user/file/1", artifactx.Ref{SessionID: "~user", FileName: "file"}},
+	} {
+		key := kb.VersionKey("app", "u", tc.sessionID, tc.fileName, 1)
+		if key != tc.key {
+			t.Errorf("VersionKey(%q, %q) = %q, want %q", tc.sessionID, tc.fileName, key, tc.key)
+		}
+		tc.ref.AppName, tc.ref.UserID, tc.ref.Version = "app", "u", 1
+		if ref, err := kb.ParseKey(key); err != nil || ref != tc.ref {
+			t.Errorf("ParseKey(%q) = (%+v, %v), want %+v", key, ref, err, tc.ref)
+		}
+	}
+	for _, key := range []string{"app/u/~user/file/1", "app/u/s1/user:file/1", "app/u/~x/file/1"} {
+		if _, err := kb.ParseKey(key); err == nil {
+			t.Errorf("ParseKey(%q) succeeded, want an error", key)
+		}
+	}
+}
+
+func TestCheckSessionID(t *testing.T) {
+	for _, tc := range []struct {
+		kb                  artifactx.KeyBuilder
+		sessionID, fileName string
+		wantErr             bool
+	}{
+		{artifactx.DefaultKeyBuilder, "s1", "file", false},
+		{artifactx.DefaultKeyBuilder, "user", "file", true},
+		{artifactx.DefaultKeyBuilder, "user", "", true},
+		{artifactx.DefaultKeyBuilder, "user", "user:file", false},
+		{artifactx.EscapedKeyBuilder, "user", "file", false},
+	} {
+		err := artifactx.CheckSessionID(tc.kb, tc.sessionID, tc.fileName)
+		if got := errors.Is(err, artifactx.ErrReservedSessionID); got != tc.wantErr {
+			t.Errorf("CheckSessionID(%T, %q, %q) = %v, want error: %t", tc.kb, tc.sessionID, tc.fileName, err, tc.wantErr)
+		}
+	}
+}
+
+func TestCheckAppName(t *testing.T) {
+	for _, tc := range []struct {
+		appName string
+		wantErr bool
+	}{
+		{"app", false},
+		{"my.app", false},
+		{artifactx.UploadsPrefix, true},
+		{".other", true},
+	} {
+		err := artifactx.CheckAppName(tc.appName)
+		if got := errors.Is(err, artifactx.ErrReservedAppName); got != tc.wantErr {
+			t.Errorf("CheckAppName(%q) = %v, want error: %t", tc.appName, err, tc.wantErr)
+		}
+	}
+}
+
+func TestZeroBasedKeyBuilder(t *testing.T) {
+	kb := artifactx.ZeroBasedKeyBuilder(artifactx.DefaultKeyBuilder)
+	if _, ok := kb.(artifactx.HierarchyKeyBuilder); !ok {
+		t.Errorf("ZeroBasedKeyBuilder(DefaultKeyBuilder) does not implement HierarchyKeyBuilder")
+	}
+	key := kb.VersionKey("app", "u", "s1", "file", 1)
+	if want := "app/u/s1/file/0"; key != want {
+		t.Errorf("VersionKey(version 1) = %q, want %q", key, want)
+	}
+	want := artifactx.Ref{AppName: "app", UserID: "u", SessionID: "s1", FileName: "file", Version: 1}
+	if ref, err := kb.ParseKey(key); err != nil || ref != want {
+		t.Errorf("ParseKey(%q) = (%+v, %v), want %+v", key, ref, err, want)
+	}
+	if _, err := kb.ParseKey("app/u/s1/file/-1"); err == nil {
+		t.Errorf("ParseKey() of a negative version succeeded, want an error")
+	}
+	if err := artifactx.CheckSessionID(kb, "user", "file"); !errors.Is(err, artifactx.ErrReservedSessionID) {
+		t.Errorf("CheckSessionID() of session %q = %v, want error(%v)", "user", err, artifactx.ErrReservedSessionID)
+	}
+}
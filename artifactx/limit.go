@@ -0,0 +1,75 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrTooLarge is returned by Load for versions larger than the load size
+// limit, before their content is read. Such versions can still be streamed
+// with [Opener].
+var ErrTooLarge = errors.New("artifact too large")
+
+// ErrStorageFull is returned by Save when the backend reached the storage
+// limit it is configured with and could not make room.
+var ErrStorageFull = errors.New("artifact storage full")
+
+type maxLoadSizeKey struct{}
+
+// WithMaxLoadSize returns a copy of ctx whose Loads fail with [ErrTooLarge]
+// for versions of more than n bytes. It can only lower the limit a backend
+// is configured with.
+func WithMaxLoadSize(ctx context.Context, n int64) context.Context {
+	return context.WithValue(ctx, maxLoadSizeKey{}, n)
+}
+
+// MaxLoadSize returns the load size limit for ctx given the limit of the
+// service, the lower of the two if both are set. Zero means no limit.
+func MaxLoadSize(ctx context.Context, limit int64) int64 {
+	if n, ok := ctx.Value(maxLoadSizeKey{}).(int64); ok && n > 0 && (limit <= 0 || n < limit) {
+		return n
+	}
+	return max(limit, 0)
+}
+
+// CheckLoadSize returns [ErrTooLarge] if size exceeds limit, unless limit is
+// zero.
+func CheckLoadSize(size, limit int64) error {
+	if limit > 0 && size > limit {
+		return fmt.Errorf("%w: %d bytes exceed the limit of %d", ErrTooLarge, size, limit)
+	}
+	return nil
+}
+
+// ReadAllLimit is [io.ReadAll] failing with [ErrTooLarge] as soon as more
+// than limit bytes are read, unless limit is zero, so that content growing
+// after its size was checked cannot exhaust memory either.
+func ReadAllLimit(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, limit)
+	}
+	return data, nil
+}
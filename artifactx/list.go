@@ -0,0 +1,233 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/artifact"
+)
+
+// ListOrder is the order of the files returned by [ListDetailed].
+type ListOrder int
+
+const (
+	// ByName orders files by name, ascending, like List.
+	ByName ListOrder = iota
+	// ByNameDesc orders files by name, descending.
+	ByNameDesc
+	// ByModTime orders files by the modification time of their latest
+	// version, the most recently saved first.
+	ByModTime
+	// BySize orders files by the size of their latest version, the largest
+	// first.
+	BySize
+)
+
+// String returns the name of o, as accepted by [ParseListOrder].
+func (o ListOrder) String() string {
+	switch o {
+	case ByName:
+		return "name"
+	case ByNameDesc:
+		return "-name"
+	case ByModTime:
+		return "modified"
+	case BySize:
+		return "size"
+	}
+	return fmt.Sprintf("ListOrder(%d)", int(o))
+}
+
+// ParseListOrder parses the name of an order: "name", "-name", "modified"
+// or "size", for query parameters and flags.
+func ParseListOrder(s string) (ListOrder, error) {
+	for _, o := range []ListOrder{ByName, ByNameDesc, ByModTime, BySize} {
+		if strings.EqualFold(s, o.String()) {
+			return o, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid list order %q: want name, -name, modified or size", s)
+}
+
+// ListDetailedRequest selects the files returned by [DetailedLister].
+type ListDetailedRequest struct {
+	AppName, UserID, SessionID string
+	Order                      ListOrder
+	// Limit is the maximum number of files returned, the first ones in
+	// Order. Zero means all.
+	Limit int
+}
+
+// Validate checks the request like [artifact.ListRequest.Validate].
+func (r *ListDetailedRequest) Validate() error {
+	return r.ListRequest().Validate()
+}
+
+// ListRequest returns the equivalent [artifact.ListRequest].
+func (r *ListDetailedRequest) ListRequest() *artifact.ListRequest {
+	return &artifact.ListRequest{AppName: r.AppName, UserID: r.UserID, SessionID: r.SessionID}
+}
+
+// FileInfo describes a file of a listing by its latest version.
+type FileInfo struct {
+	FileName string
+	// Version is the latest version.
+	Version int64
+	// Size is the stored size of the latest version in bytes.
+	Size    int64
+	ModTime time.Time
+}
+
+// DetailedLister is implemented by services that can list files together
+// with the size and modification time of their latest version, from the
+// listing of their storage rather than the metadata of every version.
+type DetailedLister interface {
+	// ListDetailed returns the files of a session and the user scoped
+	// files, in the requested order.
+	ListDetailed(ctx context.Context, req *ListDetailedRequest) ([]*FileInfo, error)
+}
+
+// ListDetailed returns the files of a session and the user scoped files in
+// the requested order, with [DetailedLister] if srv implements it.
+// Otherwise it stats the latest version of every listed file, which
+// requires srv to implement [Stater].
+func ListDetailed(ctx context.Context, srv artifact.Service, req *ListDetailedRequest) ([]*FileInfo, error) {
+	if l, ok := As[DetailedLister](srv); ok {
+		return l.ListDetailed(ctx, req)
+	}
+	stater, ok := As[Stater](srv)
+	if !ok {
+		return nil, fmt.Errorf("detailed listing requires a service implementing artifactx.Stater")
+	}
+	resp, err := srv.List(ctx, req.ListRequest())
+	if err != nil {
+		return nil, err
+	}
+	files := make([]*FileInfo, 0, len(resp.FileNames))
+	for _, name := range resp.FileNames {
+		a, err := stater.Stat(ctx, &artifact.LoadRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: name,
+		})
+		if errors.Is(err, fs.ErrNotExist) {
+			// deleted since listing
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, &FileInfo{FileName: name, Version: a.Version, Size: a.Size, ModTime: a.ModTime})
+	}
+	return SortFiles(files, req.Order, req.Limit), nil
+}
+
+// SortFiles sorts files in order, breaking ties by name, and cuts them to
+// limit unless it is zero. It sorts files in place and returns it.
+func SortFiles(files []*FileInfo, order ListOrder, limit int) []*FileInfo {
+	slices.SortFunc(files, func(a, b *FileInfo) int {
+		var c int
+		switch order {
+		case ByNameDesc:
+			return strings.Compare(b.FileName, a.FileName)
+		case ByModTime:
+			c = b.ModTime.Compare(a.ModTime)
+		case BySize:
+			c = cmp.Compare(b.Size, a.Size)
+		}
+		return cmp.Or(c, strings.Compare(a.FileName, b.FileName))
+	})
+	if limit > 0 && len(files) > limit {
+		files = files[:limit]
+	}
+	return files
+}
+
+// RecentArtifact describes an artifact by its latest version.
+type RecentArtifact struct {
+	// Ref references the latest version. User scoped artifacts have
+	// [UserNamespace] as their session ID, like in [Walker].
+	Ref
+	// Size is the stored size of the latest version in bytes.
+	Size    int64
+	ModTime time.Time
+}
+
+// RecentLister is implemented by services that can list the artifacts of a
+// user across sessions in one pass over the storage of the user.
+type RecentLister interface {
+	// ListRecent returns the artifacts of all the sessions of a user and
+	// the user scoped artifacts, the most recently saved first, at most
+	// limit of them unless it is zero.
+	ListRecent(ctx context.Context, appName, userID string, limit int) ([]*RecentArtifact, error)
+}
+
+// ListRecent returns the most recently saved artifacts of a user across all
+// their sessions, for activity feeds, with [RecentLister] if srv implements
+// it. Otherwise it lists every session with [Browser] and [ListDetailed],
+// which finds the user scoped artifacts of users with sessions only.
+func ListRecent(ctx context.Context, srv artifact.Service, appName, userID string, limit int) ([]*RecentArtifact, error) {
+	if l, ok := As[RecentLister](srv); ok {
+		return l.ListRecent(ctx, appName, userID, limit)
+	}
+	b, ok := As[Browser](srv)
+	if !ok {
+		return nil, fmt.Errorf("listing recent artifacts requires a service implementing artifactx.Browser")
+	}
+	sessions, err := b.ListSessions(ctx, appName, userID)
+	if err != nil {
+		return nil, err
+	}
+	var recent []*RecentArtifact
+	for i, sessionID := range sessions {
+		files, err := ListDetailed(ctx, srv, &ListDetailedRequest{
+			AppName: appName, UserID: userID, SessionID: sessionID, Order: ByModTime, Limit: limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			ref := Ref{AppName: appName, UserID: userID, SessionID: sessionID, FileName: f.FileName, Version: f.Version}
+			if strings.HasPrefix(f.FileName, userPrefix) {
+				if i > 0 {
+					// listed with the first session
+					continue
+				}
+				ref.SessionID = UserNamespace
+			}
+			recent = append(recent, &RecentArtifact{Ref: ref, Size: f.Size, ModTime: f.ModTime})
+		}
+	}
+	return SortRecent(recent, limit), nil
+}
+
+// SortRecent sorts artifacts the most recently saved first, breaking ties by
+// ref, and cuts them to limit unless it is zero. It sorts artifacts in
+// place and returns it.
+func SortRecent(artifacts []*RecentArtifact, limit int) []*RecentArtifact {
+	slices.SortFunc(artifacts, func(a, b *RecentArtifact) int {
+		return cmp.Or(b.ModTime.Compare(a.ModTime), strings.Compare(a.Ref.String(), b.Ref.String()))
+	})
+	if limit > 0 && len(artifacts) > limit {
+		artifacts = artifacts[:limit]
+	}
+	return artifacts
+}
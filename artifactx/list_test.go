@@ -0,0 +1,80 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+)
+
+// browsingService exposes only the browsing and stat capabilities of a
+// service, so that the listing helpers take their fallbacks.
+type browsingService struct {
+	artifact.Service
+	artifactx.Browser
+	artifactx.Stater
+}
+
+func TestParseListOrder(t *testing.T) {
+	for _, o := range []artifactx.ListOrder{artifactx.ByName, artifactx.ByNameDesc, artifactx.ByModTime, artifactx.BySize} {
+		if got, err := artifactx.ParseListOrder(o.String()); err != nil || got != o {
+			t.Errorf("ParseListOrder(%q) = (%v, %v), want %v", o.String(), got, err, o)
+		}
+	}
+	if _, err := artifactx.ParseListOrder("date"); err == nil {
+		t.Errorf("ParseListOrder(\"date\") succeeded, want an error")
+	}
+}
+
+func TestListRecentFallback(t *testing.T) {
+	ctx := t.Context()
+	clock := artifactx.NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	fsSrv, err := fsartifact.New(t.TempDir(), fsartifact.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	browser, _ := artifactx.As[artifactx.Browser](fsSrv)
+	stater, _ := artifactx.As[artifactx.Stater](fsSrv)
+	srv := &browsingService{Service: fsSrv, Browser: browser, Stater: stater}
+	for _, save := range []struct{ sessionID, fileName string }{
+		{"s1", "draft"}, {"s2", "budget.xlsx"}, {"s1", "user:profile"}, {"s2", "draft"},
+	} {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: save.sessionID, FileName: save.fileName, Part: genai.NewPartFromText("x"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		clock.Advance(time.Minute)
+	}
+	recent, err := artifactx.ListRecent(ctx, srv, "app", "user", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, a := range recent {
+		got = append(got, fmt.Sprintf("%s/%s/%d", a.SessionID, a.FileName, a.Version))
+	}
+	if want := []string{"s2/draft/1", "user/user:profile/1", "s2/budget.xlsx/1", "s1/draft/1"}; !slices.Equal(got, want) {
+		t.Errorf("ListRecent() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLocked is returned for writes to an artifact another caller holds a
+// lease on, and for attempts to take or release such a lease.
+var ErrLocked = errors.New("artifact is locked")
+
+// LockRequest asks for an exclusive write lease on an artifact.
+type LockRequest struct {
+	AppName, UserID, SessionID, FileName string
+	// Owner identifies the holder in errors and listings, e.g. an agent name.
+	Owner string
+	// TTL is how long the lease lasts unless renewed. Zero means the
+	// default of the service.
+	TTL time.Duration
+}
+
+// Validate checks that the request names an artifact.
+func (r *LockRequest) Validate() error {
+	if r.AppName == "" || r.UserID == "" || r.SessionID == "" || r.FileName == "" {
+		return fmt.Errorf("app name, user ID, session ID and file name are required")
+	}
+	if r.TTL < 0 {
+		return fmt.Errorf("negative TTL %v", r.TTL)
+	}
+	return nil
+}
+
+// Lease is an exclusive write lease on an artifact.
+type Lease struct {
+	LockRequest
+	// Token proves ownership of the lease; attach it to writes with
+	// [WithLease].
+	Token   string
+	Expires time.Time
+}
+
+// Locker is implemented by services that grant exclusive write leases. While
+// a lease is held, Saves, Deletes and Moves of the artifact fail with
+// [ErrLocked] unless their context carries the lease token. Reads are not
+// affected.
+type Locker interface {
+	// Lock takes a lease on an artifact, failing with [ErrLocked] if another
+	// unexpired lease is held. Locking again with the token of the current
+	// lease in the context renews it.
+	Lock(ctx context.Context, req *LockRequest) (*Lease, error)
+	// Unlock releases the lease with token. Releasing an expired lease is
+	// not an error; releasing another holder's lease fails with [ErrLocked].
+	Unlock(ctx context.Context, lease *Lease) error
+}
+
+type leaseKey struct{}
+
+// WithLease returns a copy of ctx whose writes are made as the holder of the
+// lease with token.
+func WithLease(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, leaseKey{}, token)
+}
+
+// LeaseFrom returns the lease token attached to ctx with [WithLease].
+func LeaseFrom(ctx context.Context) string {
+	token, _ := ctx.Value(leaseKey{}).(string)
+	return token
+}
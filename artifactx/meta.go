@@ -0,0 +1,253 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The metadata of a version is stored in one of two representations of the
+// same document, described by [Meta]:
+//
+//   - as a JSON sidecar (the ".meta" files of fsartifact), valid against
+//     [MetaSchema];
+//   - as the content type and custom metadata of an object (blobartifact and
+//     s3artifact), with the fields other than the content type and the
+//     application metadata recorded under the reserved keys
+//     [MetaFormatMetadataKey], [ChecksumMetadataKey], [CreatedMetadataKey],
+//     [PinnedMetadataKey], [ImmutableMetadataKey] and the
+//     [ChecksumAlgorithm.MetadataKey] of other checksum algorithms.
+//
+// Metadata written before the format was versioned is format 0: a sidecar
+// holding the bare content type or a JSON document without "format", or an
+// object without [MetaFormatMetadataKey]. Readers accept it, [Meta.Upgrade]
+// converts it and [Checker.Repair] rewrites it in the current format.
+
+// MetaFormat is the version of the metadata format written by this module.
+const MetaFormat = 1
+
+// MetaFormatMetadataKey is the metadata key under which backends that store
+// metadata with objects record its format.
+const MetaFormatMetadataKey = "adk-meta-format"
+
+// ReservedMetadataPrefix starts the metadata keys reserved for the fields of
+// [Meta]. Application metadata may not use it.
+const ReservedMetadataPrefix = "adk-"
+
+// MetaSchema is the JSON Schema of the sidecar representation of [Meta].
+//
+//go:embed meta.schema.json
+var MetaSchema []byte
+
+// ErrUnsupportedMetaFormat is returned when reading metadata written in a
+// format newer than [MetaFormat].
+var ErrUnsupportedMetaFormat = errors.New("unsupported metadata format")
+
+// Meta is the metadata of a version, in the format shared by all backends.
+type Meta struct {
+	// Format is the version of the format, 0 for metadata written before
+	// the format was versioned.
+	Format      int               `json:"format"`
+	ContentType string            `json:"contentType"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	// SHA256 is the hex checksum of the version, if known.
+	SHA256 string `json:"sha256,omitempty"`
+	// Checksums are the hex checksums of the version computed with
+	// algorithms other than SHA-256, by [ChecksumAlgorithm.Name].
+	Checksums map[string]string `json:"checksums,omitempty"`
+	// Created is when the version was written. Backends fall back to the
+	// modification time of the content if it is zero.
+	Created time.Time `json:"created,omitzero"`
+	// Pinned exempts the version from retention and eviction.
+	Pinned bool `json:"pinned,omitempty"`
+	// Immutable forbids saving the file again, see [WithImmutable].
+	Immutable bool `json:"immutable,omitempty"`
+}
+
+// ParseMeta decodes the sidecar representation of the metadata of a
+// version, including the bare content type of format 0. It fails with
+// [ErrUnsupportedMetaFormat] for formats newer than [MetaFormat].
+func ParseMeta(data []byte) (*Meta, error) {
+	if !bytes.HasPrefix(data, []byte("{")) {
+		return &Meta{ContentType: string(data)}, nil
+	}
+	m := &Meta{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata: %w", err)
+	}
+	if m.Format > MetaFormat {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedMetaFormat, m.Format)
+	}
+	return m, nil
+}
+
+// MetaFromObject returns the metadata of a version stored as an object with
+// contentType and the custom metadata md.
+func MetaFromObject(contentType string, md map[string]string) *Meta {
+	m := &Meta{ContentType: contentType}
+	if s, ok := md[MetaFormatMetadataKey]; ok {
+		format, err := strconv.Atoi(s)
+		if err != nil {
+			format = -1 // reported by Validate
+		}
+		m.Format = format
+	}
+	md, m.Created = SplitCreated(md, time.Time{})
+	md, m.SHA256 = SplitChecksum(md)
+	md, m.Pinned = SplitPinned(md)
+	md, m.Immutable = SplitImmutable(md)
+	cloned := false
+	for _, a := range checksumAlgorithms[1:] {
+		sum, ok := md[a.MetadataKey()]
+		if !ok {
+			continue
+		}
+		if !cloned {
+			md, cloned = maps.Clone(md), true
+		}
+		delete(md, a.MetadataKey())
+		m.SetChecksum(a, sum)
+	}
+	if _, ok := md[MetaFormatMetadataKey]; ok {
+		if !cloned {
+			md = maps.Clone(md)
+		}
+		delete(md, MetaFormatMetadataKey)
+	}
+	if len(md) == 0 {
+		md = nil
+	}
+	m.Metadata = md
+	return m
+}
+
+// ObjectMetadata returns the custom metadata of the object storing the
+// version described by m. The content type is stored separately.
+func (m *Meta) ObjectMetadata() map[string]string {
+	md := maps.Clone(m.Metadata)
+	if m.SHA256 != "" {
+		md = WithChecksum(md, m.SHA256)
+	}
+	for name, sum := range m.Checksums {
+		if md == nil {
+			md = map[string]string{}
+		}
+		md[ReservedMetadataPrefix+name] = sum
+	}
+	if !m.Created.IsZero() {
+		md = WithCreated(md, m.Created)
+	}
+	md = WithPinned(md, m.Pinned)
+	md = WithImmutableFlag(md, m.Immutable)
+	md[MetaFormatMetadataKey] = strconv.Itoa(m.Format)
+	return md
+}
+
+// Upgrade converts m to [MetaFormat] and reports whether it changed.
+func (m *Meta) Upgrade() bool {
+	if m.Format == MetaFormat {
+		return false
+	}
+	// Format 0 sidecars could be empty; Load always read them as text.
+	if m.ContentType == "" {
+		m.ContentType = "text/plain"
+	}
+	m.Format = MetaFormat
+	return true
+}
+
+// Validate reports whether m is valid metadata in its format. Format 0 is
+// valid as long as the fields it shares with the current format are.
+func (m *Meta) Validate() error {
+	var errs []error
+	if m.Format < 0 || m.Format > MetaFormat {
+		errs = append(errs, fmt.Errorf("%w: %d", ErrUnsupportedMetaFormat, m.Format))
+	}
+	if m.ContentType == "" {
+		if m.Format > 0 {
+			errs = append(errs, errors.New("missing content type"))
+		}
+	} else if _, _, err := mime.ParseMediaType(m.ContentType); err != nil {
+		errs = append(errs, fmt.Errorf("invalid content type %q: %w", m.ContentType, err))
+	}
+	if m.SHA256 != "" {
+		if b, err := hex.DecodeString(m.SHA256); err != nil || len(b) != 32 || strings.ToLower(m.SHA256) != m.SHA256 {
+			errs = append(errs, fmt.Errorf("invalid sha256 %q", m.SHA256))
+		}
+	}
+	for name, sum := range m.Checksums {
+		switch a := ChecksumAlgorithmByName(name); {
+		case a == nil || a == ChecksumSHA256:
+			errs = append(errs, fmt.Errorf("unknown checksum algorithm %q", name))
+		case !a.valid(sum):
+			errs = append(errs, fmt.Errorf("invalid %s %q", name, sum))
+		}
+	}
+	for k := range m.Metadata {
+		switch {
+		case k == "":
+			errs = append(errs, errors.New("empty metadata key"))
+		case strings.HasPrefix(k, ReservedMetadataPrefix):
+			errs = append(errs, fmt.Errorf("metadata key %q is reserved", k))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SetChecksum records sum as the checksum of the version computed with a.
+func (m *Meta) SetChecksum(a *ChecksumAlgorithm, sum string) {
+	if a == ChecksumSHA256 {
+		m.SHA256 = sum
+		return
+	}
+	if m.Checksums == nil {
+		m.Checksums = map[string]string{}
+	}
+	m.Checksums[a.Name] = sum
+}
+
+// Checksum returns the checksum of the version recorded with the first of
+// SHA-256, BLAKE3 and CRC32C that has one, or nil and "" if none is.
+func (m *Meta) Checksum() (*ChecksumAlgorithm, string) {
+	if m.SHA256 != "" {
+		return ChecksumSHA256, m.SHA256
+	}
+	for _, a := range checksumAlgorithms[1:] {
+		if sum := m.Checksums[a.Name]; sum != "" {
+			return a, sum
+		}
+	}
+	return nil, ""
+}
+
+// ETag returns the strong ETag of the version if it has a checksum of a
+// cryptographic algorithm, and "" otherwise so that backends fall back to
+// an ETag of the storage.
+func (m *Meta) ETag() string {
+	if a, sum := m.Checksum(); a != nil && a.Cryptographic {
+		return ChecksumETag(sum)
+	}
+	return ""
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx_test
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+func TestParseMeta(t *testing.T) {
+	for _, tc := range []struct {
+		data    string
+		want    artifactx.Meta
+		wantErr error
+	}{
+		{"image/png", artifactx.Meta{ContentType: "image/png"}, nil},
+		{`{"contentType":"text/plain","sha256":"ab"}`, artifactx.Meta{ContentType: "text/plain", SHA256: "ab"}, nil},
+		{`{"format":1,"contentType":"text/plain","pinned":true}`, artifactx.Meta{Format: 1, ContentType: "text/plain", Pinned: true}, nil},
+		{`{"format":2,"contentType":"text/plain"}`, artifactx.Meta{}, artifactx.ErrUnsupportedMetaFormat},
+	} {
+		m, err := artifactx.ParseMeta([]byte(tc.data))
+		if tc.wantErr != nil {
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("ParseMeta(%s) error = %v, want %v", tc.data, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil || m.Format != tc.want.Format || m.ContentType != tc.want.ContentType || m.SHA256 != tc.want.SHA256 || m.Pinned != tc.want.Pinned {
+			t.Errorf("ParseMeta(%s) = (%+v, %v), want %+v", tc.data, m, err, tc.want)
+		}
+	}
+}
+
+func TestMetaValidate(t *testing.T) {
+	sum := artifactx.Checksum([]byte("hello"))
+	for _, tc := range []struct {
+		name  string
+		m     artifactx.Meta
+		valid bool
+	}{
+		{"current", artifactx.Meta{Format: 1, ContentType: "text/plain; charset=utf-8", SHA256: sum, Metadata: map[string]string{"k": "v"}}, true},
+		{"format 0", artifactx.Meta{ContentType: "image/png"}, true},
+		{"empty format 0", artifactx.Meta{}, true},
+		{"newer format", artifactx.Meta{Format: 2, ContentType: "text/plain"}, false},
+		{"missing content type", artifactx.Meta{Format: 1}, false},
+		{"invalid content type", artifactx.Meta{Format: 1, ContentType: "text/"}, false},
+		{"short sha256", artifactx.Meta{Format: 1, ContentType: "text/plain", SHA256: "abcd"}, false},
+		{"crc32c", artifactx.Meta{Format: 1, ContentType: "text/plain", Checksums: map[string]string{"crc32c": artifactx.ChecksumCRC32C.Sum([]byte("hello"))}}, true},
+		{"short blake3", artifactx.Meta{Format: 1, ContentType: "text/plain", Checksums: map[string]string{"blake3": "abcd"}}, false},
+		{"unknown checksum", artifactx.Meta{Format: 1, ContentType: "text/plain", Checksums: map[string]string{"md5": "abcd"}}, false},
+		{"reserved key", artifactx.Meta{Format: 1, ContentType: "text/plain", Metadata: map[string]string{artifactx.PinnedMetadataKey: "true"}}, false},
+	} {
+		if err := tc.m.Validate(); (err == nil) != tc.valid {
+			t.Errorf("%s: Validate() = %v, want valid: %t", tc.name, err, tc.valid)
+		}
+	}
+}
+
+func TestMetaUpgrade(t *testing.T) {
+	m, err := artifactx.ParseMeta(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Upgrade() || m.Format != artifactx.MetaFormat || m.ContentType != "text/plain" {
+		t.Errorf("Upgrade() of an empty format 0 sidecar = %+v, want text/plain in format %d", m, artifactx.MetaFormat)
+	}
+	if m.Upgrade() {
+		t.Errorf("Upgrade() of current metadata reported a change")
+	}
+	if err := m.Validate(); err != nil {
+		t.Errorf("Validate() after Upgrade() = %v", err)
+	}
+}
+
+func TestMetaObjectMetadata(t *testing.T) {
+	m := &artifactx.Meta{
+		Format:      artifactx.MetaFormat,
+		ContentType: "image/png",
+		Metadata:    map[string]string{"source": "camera"},
+		SHA256:      artifactx.Checksum([]byte("png")),
+		Checksums:   map[string]string{"blake3": artifactx.ChecksumBLAKE3.Sum([]byte("png"))},
+		Created:     time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		Pinned:      true,
+		Immutable:   true,
+	}
+	got := artifactx.MetaFromObject(m.ContentType, m.ObjectMetadata())
+	if got.Format != m.Format || got.SHA256 != m.SHA256 || !got.Created.Equal(m.Created) || !got.Pinned || !got.Immutable || !maps.Equal(got.Metadata, m.Metadata) || !maps.Equal(got.Checksums, m.Checksums) {
+		t.Errorf("MetaFromObject(ObjectMetadata()) = %+v, want %+v", got, m)
+	}
+	// Objects written before the format was versioned, or by other ADKs.
+	if got := artifactx.MetaFromObject("image/png", map[string]string{"source": "camera"}); got.Format != 0 {
+		t.Errorf("MetaFromObject() without a format = %+v, want format 0", got)
+	}
+	if got := artifactx.MetaFromObject("image/png", map[string]string{artifactx.MetaFormatMetadataKey: "x"}); got.Validate() == nil {
+		t.Errorf("Validate() of an unparsable object format succeeded, want an error")
+	}
+}
+
+func TestMetaETag(t *testing.T) {
+	blake3 := artifactx.ChecksumBLAKE3.Sum([]byte("hello"))
+	for _, tc := range []struct {
+		name string
+		m    artifactx.Meta
+		want string
+	}{
+		{"sha256", artifactx.Meta{SHA256: "ab", Checksums: map[string]string{"blake3": blake3}}, artifactx.ChecksumETag("ab")},
+		{"blake3", artifactx.Meta{Checksums: map[string]string{"blake3": blake3, "crc32c": "ab"}}, artifactx.ChecksumETag(blake3)},
+		{"crc32c", artifactx.Meta{Checksums: map[string]string{"crc32c": "ab"}}, ""},
+		{"none", artifactx.Meta{}, ""},
+	} {
+		if got := tc.m.ETag(); got != tc.want {
+			t.Errorf("%s: ETag() = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMetaSchema(t *testing.T) {
+	var schema struct {
+		Required   []string                   `json:"required"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(artifactx.MetaSchema, &schema); err != nil {
+		t.Fatalf("MetaSchema is not JSON: %v", err)
+	}
+	data, err := json.Marshal(&artifactx.Meta{
+		Format: 1, ContentType: "text/plain", Metadata: map[string]string{"k": "v"}, SHA256: "x", Checksums: map[string]string{"blake3": "x"}, Created: time.Now(), Pinned: true, Immutable: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	for k := range doc {
+		if _, ok := schema.Properties[k]; !ok {
+			t.Errorf("MetaSchema does not describe %q", k)
+		}
+	}
+	for _, k := range schema.Required {
+		if _, ok := doc[k]; !ok {
+			t.Errorf("Meta does not encode the required %q", k)
+		}
+	}
+}
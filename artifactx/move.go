@@ -0,0 +1,149 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"google.golang.org/adk/artifact"
+)
+
+// userPrefix marks the filenames of user scoped artifacts.
+const userPrefix = "user:"
+
+// IsUserScoped reports whether fileName names an artifact of the user
+// namespace, shared by all sessions of the user.
+func IsUserScoped(fileName string) bool {
+	return strings.HasPrefix(fileName, userPrefix)
+}
+
+// MoveRequest renames an artifact of a session, with all its versions.
+// Either name may be a "user:" name, moving the artifact between the session
+// and the user namespace.
+type MoveRequest struct {
+	AppName, UserID, SessionID string
+	FileName, NewFileName      string
+}
+
+// Validate checks that the request names a source and a different destination.
+func (r *MoveRequest) Validate() error {
+	if r.AppName == "" || r.UserID == "" || r.SessionID == "" {
+		return fmt.Errorf("app name, user ID and session ID are required")
+	}
+	if r.FileName == "" || r.NewFileName == "" {
+		return fmt.Errorf("file name and new file name are required")
+	}
+	if r.FileName == r.NewFileName {
+		return fmt.Errorf("file name and new file name are the same")
+	}
+	return nil
+}
+
+// Mover is implemented by services that can move artifacts natively, without
+// copying their content through the caller.
+type Mover interface {
+	// Move moves all versions of an artifact, keeping their numbers, content
+	// type and metadata. It fails with [fs.ErrNotExist] if the artifact has
+	// no versions and with [fs.ErrExist] if the destination has any.
+	Move(ctx context.Context, req *MoveRequest) error
+}
+
+// Move moves an artifact with [Mover] if srv implements it. Otherwise the
+// versions are copied with Load and Save, keeping their numbers and, if srv
+// implements [Stater], their metadata, and then the source is deleted.
+func Move(ctx context.Context, srv artifact.Service, req *MoveRequest) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("request validation failed: %w", err)
+	}
+	if m, ok := As[Mover](srv); ok {
+		return m.Move(ctx, req)
+	}
+
+	src := &artifact.VersionsRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName}
+	resp, err := srv.Versions(ctx, src)
+	if err != nil {
+		return err
+	}
+	dst := &artifact.VersionsRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.NewFileName}
+	if existing, err := srv.Versions(ctx, dst); err == nil && len(existing.Versions) > 0 {
+		return fmt.Errorf("artifact %q: %w", req.NewFileName, fs.ErrExist)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if err := copyVersions(ctx, srv, req.AppName, req.UserID, req.SessionID, req.FileName, req.SessionID, req.NewFileName, resp.Versions); err != nil {
+		return err
+	}
+	return srv.Delete(ctx, &artifact.DeleteRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName})
+}
+
+// copyVersions copies versions of an artifact with Load and Save, keeping
+// their numbers and, if srv implements [Stater], their metadata.
+func copyVersions(ctx context.Context, srv artifact.Service, appName, userID, sessionID, fileName, newSessionID, newFileName string, versions []int64) error {
+	stater, _ := As[Stater](srv)
+	// Oldest first, so that services ignoring SaveRequest.Version still keep
+	// the numbers of versions without gaps.
+	for _, v := range slices.Sorted(slices.Values(versions)) {
+		load := &artifact.LoadRequest{AppName: appName, UserID: userID, SessionID: sessionID, FileName: fileName, Version: v}
+		loaded, err := srv.Load(ctx, load)
+		if err != nil {
+			return fmt.Errorf("failed to load version %d: %w", v, err)
+		}
+		saveCtx := ctx
+		if stater != nil {
+			attrs, err := stater.Stat(ctx, load)
+			if err != nil {
+				return fmt.Errorf("failed to stat version %d: %w", v, err)
+			}
+			saveCtx = WithMetadata(ctx, attrs.Metadata)
+		}
+		if _, err := srv.Save(saveCtx, &artifact.SaveRequest{
+			AppName: appName, UserID: userID, SessionID: newSessionID, FileName: newFileName,
+			Part: loaded.Part, Version: v,
+		}); err != nil {
+			return fmt.Errorf("failed to save version %d: %w", v, err)
+		}
+	}
+	return nil
+}
+
+// Promote moves a session scoped artifact into the user namespace, so that
+// it outlives the session, and returns its new "user:" name.
+func Promote(ctx context.Context, srv artifact.Service, appName, userID, sessionID, fileName string) (string, error) {
+	if strings.HasPrefix(fileName, userPrefix) {
+		return "", fmt.Errorf("artifact %q is already user scoped", fileName)
+	}
+	newName := userPrefix + fileName
+	return newName, Move(ctx, srv, &MoveRequest{
+		AppName: appName, UserID: userID, SessionID: sessionID, FileName: fileName, NewFileName: newName,
+	})
+}
+
+// Demote moves a "user:" artifact into the given session and returns its
+// new name without the prefix.
+func Demote(ctx context.Context, srv artifact.Service, appName, userID, sessionID, fileName string) (string, error) {
+	newName, ok := strings.CutPrefix(fileName, userPrefix)
+	if !ok {
+		return "", fmt.Errorf("artifact %q is not user scoped", fileName)
+	}
+	return newName, Move(ctx, srv, &MoveRequest{
+		AppName: appName, UserID: userID, SessionID: sessionID, FileName: fileName, NewFileName: newName,
+	})
+}
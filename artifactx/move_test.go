@@ -0,0 +1,98 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx_test
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// TestPromoteFallback covers the Load and Save fallback for services without
+// [artifactx.Mover]; the backends are covered by the service suite.
+func TestPromoteFallback(t *testing.T) {
+	ctx := t.Context()
+	scope := artifactx.Scoped(artifact.InMemoryService(), "app", "user", "session")
+	for _, text := range []string{"v1", "v2"} {
+		if _, err := scope.Save(ctx, "notes", genai.NewPartFromText(text)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	name, err := scope.Promote(ctx, "notes")
+	if err != nil || name != "user:notes" {
+		t.Fatalf("Promote() = (%q, %v), want user:notes", name, err)
+	}
+	if versions, err := scope.Versions(ctx, name); err != nil || !slices.Equal(slices.Sorted(slices.Values(versions)), []int64{1, 2}) {
+		t.Errorf("Versions() = (%v, %v), want [1 2]", versions, err)
+	}
+	if part, err := scope.LoadVersion(ctx, name, 1); err != nil || part.Text != "v1" {
+		t.Errorf("LoadVersion(1) = (%v, %v), want v1", part, err)
+	}
+	if _, err := scope.Load(ctx, "notes"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Load() of the source = %v, want %v", err, fs.ErrNotExist)
+	}
+
+	if _, err := scope.Promote(ctx, name); err == nil {
+		t.Error("Promote() of a user scoped artifact succeeded")
+	}
+	if _, err := scope.Save(ctx, "notes", genai.NewPartFromText("new")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scope.Demote(ctx, name); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("Demote() onto an existing artifact = %v, want %v", err, fs.ErrExist)
+	}
+}
+
+// TestCloneFallback covers the Load and Save fallback for services without
+// [artifactx.SessionCloner].
+func TestCloneFallback(t *testing.T) {
+	ctx := t.Context()
+	scope := artifactx.Scoped(artifact.InMemoryService(), "app", "user", "session")
+	for _, text := range []string{"v1", "v2"} {
+		if _, err := scope.Save(ctx, "notes", genai.NewPartFromText(text)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := scope.Save(ctx, "user:profile", genai.NewPartFromText("shared")); err != nil {
+		t.Fatal(err)
+	}
+
+	branch, err := scope.Clone(ctx, "branch")
+	if err != nil {
+		t.Fatalf("Clone() failed: %v", err)
+	}
+	if part, err := branch.LoadVersion(ctx, "notes", 1); err != nil || part.Text != "v1" {
+		t.Errorf("LoadVersion(1) in the clone = (%v, %v), want v1", part, err)
+	}
+	if versions, err := branch.Versions(ctx, "user:profile"); err != nil || len(versions) != 1 {
+		t.Errorf("Versions(user:profile) in the clone = (%v, %v), want the shared version only", versions, err)
+	}
+	if _, err := branch.Save(ctx, "notes", genai.NewPartFromText("branched")); err != nil {
+		t.Fatal(err)
+	}
+	if part, err := scope.Load(ctx, "notes"); err != nil || part.Text != "v2" {
+		t.Errorf("Load() in the source after saving to the clone = (%v, %v), want v2", part, err)
+	}
+	if _, err := scope.Clone(ctx, "branch"); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("Clone() onto a session with artifacts = %v, want %v", err, fs.ErrExist)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+
+	"google.golang.org/adk/artifact"
+)
+
+// WriteTo implements [io.WriterTo] by handing the stored content to w, so
+// that [io.Copy] from a Reader uses the fastest path of the backend, such
+// as sendfile from a file to a socket.
+func (r *Reader) WriteTo(w io.Writer) (int64, error) {
+	if wt, ok := r.ReadCloser.(io.WriterTo); ok {
+		return wt.WriteTo(w)
+	}
+	return io.Copy(w, r.ReadCloser)
+}
+
+// Open streams the requested version with [Opener] if srv implements it.
+// Otherwise the version is loaded into memory and read from there.
+func Open(ctx context.Context, srv artifact.Service, req *artifact.LoadRequest) (*Reader, error) {
+	if opener, ok := As[Opener](srv); ok {
+		return opener.Open(ctx, req)
+	}
+	resp, err := srv.Load(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	data, contentType, err := EncodePart(resp.Part)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{
+		ReadCloser: io.NopCloser(bytes.NewReader(data)),
+		Attributes: Attributes{Version: req.Version, ContentType: contentType, Size: int64(len(data))},
+	}, nil
+}
+
+// OpenLoaded implements [Opener.Open] on top of Load, for decorators that
+// implement Opener over services that cannot stream. Unlike the fallback of
+// [Open], it looks up the latest version first if req.Version is zero, so
+// that the reader reports its number.
+func OpenLoaded(ctx context.Context, srv artifact.Service, req *artifact.LoadRequest) (*Reader, error) {
+	versioned := *req
+	if versioned.Version == 0 {
+		resp, err := srv.Versions(ctx, &artifact.VersionsRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Versions) == 0 {
+			return nil, fmt.Errorf("artifact '%s' not found: %w", req.FileName, fs.ErrNotExist)
+		}
+		versioned.Version = slices.Max(resp.Versions)
+	}
+	resp, err := srv.Load(ctx, &versioned)
+	if err != nil {
+		return nil, err
+	}
+	data, contentType, err := EncodePart(resp.Part)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{
+		ReadCloser: io.NopCloser(bytes.NewReader(data)),
+		Attributes: Attributes{Version: versioned.Version, ContentType: contentType, Size: int64(len(data))},
+	}, nil
+}
+
+// OpenRange streams length bytes of the requested version from offset, or
+// up to the end if length is negative. Only the requested bytes are read
+// from storage if the [Opener] of srv also implements [RangeOpener];
+// otherwise the bytes before offset are read and discarded.
+func OpenRange(ctx context.Context, srv artifact.Service, req *artifact.LoadRequest, offset, length int64) (*Reader, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid offset %d", offset)
+	}
+	// A decorator implementing Opener but not RangeOpener, such as an
+	// access control check, must not be bypassed by a service it wraps.
+	if opener, ok := As[Opener](srv); ok {
+		if ro, ok := opener.(RangeOpener); ok {
+			return ro.OpenRange(ctx, req, offset, length)
+		}
+	}
+	r, err := Open(ctx, srv, req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.CopyN(io.Discard, r.ReadCloser, offset); err != nil && err != io.EOF {
+		r.Close()
+		return nil, fmt.Errorf("failed to skip to offset %d of artifact '%s': %w", offset, req.FileName, err)
+	}
+	if length >= 0 {
+		r.ReadCloser = LimitReadCloser(r.ReadCloser, length)
+	}
+	return r, nil
+}
+
+// LimitReadCloser returns a ReadCloser reading at most n bytes from rc and
+// closing rc.
+func LimitReadCloser(rc io.ReadCloser, n int64) io.ReadCloser {
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(rc, n), rc}
+}
+
+// LoadTo writes the stored content of the requested version, or of the
+// latest version if req.Version is zero, to w without holding it in memory,
+// for callers streaming artifacts to sockets or files. It returns the
+// number of bytes written and the attributes of the version.
+//
+// Only services implementing [Opener] stream versions; other services load
+// them into memory first.
+func LoadTo(ctx context.Context, srv artifact.Service, req *artifact.LoadRequest, w io.Writer) (int64, *Attributes, error) {
+	r, err := Open(ctx, srv, req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer r.Close()
+	n, err := r.WriteTo(w)
+	if err != nil {
+		return n, nil, fmt.Errorf("failed to copy artifact '%s' version %d: %w", req.FileName, r.Version, err)
+	}
+	return n, &r.Attributes, nil
+}
+
+// LoadInto reads the stored content of the requested version, or of the
+// latest version if req.Version is zero, into buf, so that callers can
+// reuse their buffers across loads. It returns the number of bytes read and
+// the attributes of the version, and fails with [io.ErrShortBuffer] if the
+// version does not fit in buf.
+func LoadInto(ctx context.Context, srv artifact.Service, req *artifact.LoadRequest, buf []byte) (int, *Attributes, error) {
+	r, err := Open(ctx, srv, req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer r.Close()
+	if r.Size > int64(len(buf)) {
+		return 0, nil, fmt.Errorf("artifact '%s' version %d of %d bytes: %w", req.FileName, r.Version, r.Size, io.ErrShortBuffer)
+	}
+	n, err := io.ReadFull(r, buf)
+	switch err {
+	case io.EOF, io.ErrUnexpectedEOF:
+		return n, &r.Attributes, nil
+	case nil:
+		// buf is full: the version fits only if nothing is left.
+		var probe [1]byte
+		if m, _ := io.ReadFull(r, probe[:]); m == 0 {
+			return n, &r.Attributes, nil
+		}
+		return 0, nil, fmt.Errorf("artifact '%s' version %d grew past %d bytes: %w", req.FileName, r.Version, len(buf), io.ErrShortBuffer)
+	default:
+		return 0, nil, fmt.Errorf("failed to read artifact '%s' version %d: %w", req.FileName, r.Version, err)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+)
+
+func TestLoadIntoAndLoadTo(t *testing.T) {
+	ctx := t.Context()
+	fsSrv, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, srv := range map[string]artifact.Service{
+		"Opener":   fsSrv,
+		"InMemory": artifact.InMemoryService(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			content := []byte("a report of 24 bytes....")
+			if _, err := srv.Save(ctx, &artifact.SaveRequest{
+				AppName: "app", UserID: "user", SessionID: "session", FileName: "report",
+				Part: genai.NewPartFromBytes(content, "application/pdf"),
+			}); err != nil {
+				t.Fatal(err)
+			}
+			req := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "report"}
+
+			var w bytes.Buffer
+			n, attrs, err := artifactx.LoadTo(ctx, srv, req, &w)
+			if err != nil || n != int64(len(content)) || !bytes.Equal(w.Bytes(), content) {
+				t.Errorf("LoadTo() = (%d, %v) writing %q, want %d bytes writing %q", n, err, w.Bytes(), len(content), content)
+			} else if attrs.ContentType != "application/pdf" {
+				t.Errorf("LoadTo() content type = %q, want application/pdf", attrs.ContentType)
+			}
+
+			buf := make([]byte, 64)
+			if n, _, err := artifactx.LoadInto(ctx, srv, req, buf); err != nil || !bytes.Equal(buf[:n], content) {
+				t.Errorf("LoadInto() = (%q, %v), want %q", buf[:n], err, content)
+			}
+			exact := make([]byte, len(content))
+			if n, _, err := artifactx.LoadInto(ctx, srv, req, exact); err != nil || n != len(content) {
+				t.Errorf("LoadInto() of an exact buffer = (%d, %v), want %d", n, err, len(content))
+			}
+			if _, _, err := artifactx.LoadInto(ctx, srv, req, make([]byte, 8)); !errors.Is(err, io.ErrShortBuffer) {
+				t.Errorf("LoadInto() of a short buffer = %v, want error(%v)", err, io.ErrShortBuffer)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+)
+
+// FileDataContentType is the content type of a stored reference artifact: a
+// part whose FileData points at an external URI. The object holds the JSON
+// encoded [genai.FileData] instead of the referenced bytes.
+const FileDataContentType = "application/vnd.adk.file-data+json"
+
+// PartContentType is the media type of a stored part that has neither inline
+// data nor text, such as a function call or an executable code block, or
+// that has fields qualifying its content, see [EncodePart]. The object
+// holds the JSON encoded [genai.Part] and the "kind" parameter names its
+// content field, as returned by [PartKind].
+const PartContentType = "application/vnd.adk.part+json"
+
+// TextContentType is the content type of a stored text part. Its "kind"
+// parameter tells it apart from inline data of type text/plain, so that
+// [DecodePart] returns a text part as it was saved. Text saved before it
+// was recorded loads as inline data.
+const TextContentType = "text/plain; charset=utf-8; kind=text"
+
+// PartKind returns the name of the content field set on part, e.g.
+// "inline_data" or "function_call", or "" if part has no content Save supports.
+func PartKind(part *genai.Part) string {
+	switch {
+	case part.InlineData != nil:
+		return "inline_data"
+	case part.Text != "":
+		return "text"
+	case part.FileData != nil:
+		return "file_data"
+	case part.FunctionCall != nil:
+		return "function_call"
+	case part.FunctionResponse != nil:
+		return "function_response"
+	case part.ExecutableCode != nil:
+		return "executable_code"
+	case part.CodeExecutionResult != nil:
+		return "code_execution_result"
+	}
+	return ""
+}
+
+// ValidateSave is [artifact.SaveRequest.Validate] extended to accept every
+// part kind reported by [PartKind], not only inline data and text.
+func ValidateSave(req *artifact.SaveRequest) error {
+	if req.Part == nil || req.Part.InlineData != nil || req.Part.Text != "" {
+		return req.Validate()
+	}
+	switch PartKind(req.Part) {
+	case "":
+		return fmt.Errorf("invalid save request: Part has no supported content: one of InlineData, Text, FileData, FunctionCall, FunctionResponse, ExecutableCode or CodeExecutionResult has to be set")
+	case "file_data":
+		if req.Part.FileData.FileURI == "" {
+			return fmt.Errorf("invalid save request: Part.FileData.FileURI has to be set")
+		}
+	}
+	// Validate the remaining fields against a stand-in part.
+	r := *req
+	r.Part = &genai.Part{Text: "stand-in"}
+	return r.Validate()
+}
+
+// EncodePart returns the bytes and content type a backend stores for part.
+// Inline data is stored as is, with the charset of text recorded by
+// [WithCharset], text with [TextContentType] and file data as the JSON
+// encoded [genai.FileData], unless part has fields that qualify its
+// content, such as Thought, ThoughtSignature or VideoMetadata: like parts
+// of other kinds, it is then stored whole with [PartContentType], so that
+// [DecodePart] returns it with all its fields.
+func EncodePart(part *genai.Part) ([]byte, string, error) {
+	kind := PartKind(part)
+	if kind != "" && hasPartFields(part) {
+		return encodeWhole(part, kind)
+	}
+	switch kind {
+	case "inline_data":
+		return part.InlineData.Data, WithCharset(part.InlineData.MIMEType, part.InlineData.Data), nil
+	case "text":
+		return []byte(part.Text), TextContentType, nil
+	case "file_data":
+		data, err := json.Marshal(part.FileData)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to encode file data: %w", err)
+		}
+		return data, FileDataContentType, nil
+	case "":
+		return nil, "", fmt.Errorf("part has no supported content")
+	default:
+		return encodeWhole(part, kind)
+	}
+}
+
+// hasPartFields reports whether part has fields other than its content
+// that the stored forms of inline data, text and file data leave out.
+func hasPartFields(part *genai.Part) bool {
+	return part.Thought || len(part.ThoughtSignature) > 0 || part.VideoMetadata != nil || part.MediaResolution != nil ||
+		(part.InlineData != nil && part.InlineData.DisplayName != "")
+}
+
+// encodeWhole encodes part, whose content field is kind, as JSON.
+func encodeWhole(part *genai.Part, kind string) ([]byte, string, error) {
+	var v any = part
+	if vm := part.VideoMetadata; vm != nil {
+		v = &exactPart{Part: part, VideoMetadata: &exactVideoMetadata{
+			EndOffset: formatOffset(vm.EndOffset), FPS: vm.FPS, StartOffset: formatOffset(vm.StartOffset),
+		}}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode %s part: %w", kind, err)
+	}
+	return data, mime.FormatMediaType(PartContentType, map[string]string{"kind": kind}), nil
+}
+
+// exactPart is the JSON form of a part with video metadata, whose offsets
+// genai encodes in whole seconds. They are encoded exactly instead, in a
+// form its decoder reads.
+type exactPart struct {
+	*genai.Part
+	VideoMetadata *exactVideoMetadata `json:"videoMetadata,omitempty"`
+}
+
+type exactVideoMetadata struct {
+	EndOffset   string   `json:"endOffset,omitempty"`
+	FPS         *float64 `json:"fps,omitempty"`
+	StartOffset string   `json:"startOffset,omitempty"`
+}
+
+func formatOffset(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+// DecodePart rebuilds the part a backend stored as data with contentType.
+// Inline text is normalized to UTF-8 by [NormalizeCharset].
+func DecodePart(data []byte, contentType string) (*genai.Part, error) {
+	if !hasMediaTypePrefix(contentType, FileDataContentType) && !hasMediaTypePrefix(contentType, PartContentType) &&
+		!(hasMediaTypePrefix(contentType, "text/plain") && strings.Contains(contentType, "kind=")) {
+		// Spare parsing, which allocates the parameters, for plain content.
+		return genai.NewPartFromBytes(NormalizeCharset(data, contentType)), nil
+	}
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/plain":
+		if params["kind"] == "text" {
+			return genai.NewPartFromText(string(data)), nil
+		}
+	case FileDataContentType:
+		fd := &genai.FileData{}
+		if err := json.Unmarshal(data, fd); err != nil {
+			return nil, fmt.Errorf("failed to decode file data: %w", err)
+		}
+		return &genai.Part{FileData: fd}, nil
+	case PartContentType:
+		part := &genai.Part{}
+		if err := json.Unmarshal(data, part); err != nil {
+			return nil, fmt.Errorf("failed to decode part: %w", err)
+		}
+		return part, nil
+	}
+	return genai.NewPartFromBytes(NormalizeCharset(data, contentType)), nil
+}
+
+// hasMediaTypePrefix reports whether contentType may have the media type
+// mediaType, ignoring case like [mime.ParseMediaType].
+func hasMediaTypePrefix(contentType, mediaType string) bool {
+	contentType = strings.TrimLeft(contentType, " \t")
+	return len(contentType) >= len(mediaType) && strings.EqualFold(contentType[:len(mediaType)], mediaType)
+}
@@ -0,0 +1,143 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx_test
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+func TestEncodeDecodePart(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		part *genai.Part
+	}{
+		{"inline_data", genai.NewPartFromBytes([]byte{1, 2, 3}, "image/png")},
+		{"text", genai.NewPartFromText("Summarize the report.")},
+		{"file_data", genai.NewPartFromURI("gs://bucket/report.pdf", "application/pdf")},
+		{"function_call", genai.NewPartFromFunctionCall("lookup", map[string]any{"city": "Paris"})},
+		{"function_response", genai.NewPartFromFunctionResponse("lookup", map[string]any{"temp": 21.5})},
+		{"executable_code", genai.NewPartFromExecutableCode("print(1)", genai.LanguagePython)},
+		{"code_execution_result", genai.NewPartFromCodeExecutionResult(genai.OutcomeOK, "1\n")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := artifactx.PartKind(tc.part); got != tc.name {
+				t.Errorf("PartKind() = %q, want %q", got, tc.name)
+			}
+			if err := artifactx.ValidateSave(&artifact.SaveRequest{
+				AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: tc.part,
+			}); err != nil {
+				t.Errorf("ValidateSave() = %v, want nil", err)
+			}
+			data, contentType, err := artifactx.EncodePart(tc.part)
+			if err != nil {
+				t.Fatalf("EncodePart() failed: %v", err)
+			}
+			got, err := artifactx.DecodePart(data, contentType)
+			if err != nil {
+				t.Fatalf("DecodePart() failed: %v", err)
+			}
+			if diff := cmp.Diff(tc.part, got); diff != "" {
+				t.Errorf("DecodePart(EncodePart()) mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateSaveUnsupportedPart(t *testing.T) {
+	err := artifactx.ValidateSave(&artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: &genai.Part{Thought: true},
+	})
+	if err == nil {
+		t.Errorf("ValidateSave(empty part) succeeded, want error")
+	}
+}
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// TestEncodePartGolden checks the stored form of parts against
+// testdata/parts, so that changes of the format are deliberate, and that
+// the stored form decodes back to the same part.
+func TestEncodePartGolden(t *testing.T) {
+	fps := 2.5
+	tokens := int32(256)
+	for _, tc := range []struct {
+		name string
+		part *genai.Part
+	}{
+		{"inline_data", genai.NewPartFromBytes([]byte("PNG\x00"), "image/png")},
+		{"inline_data_display_name", &genai.Part{InlineData: &genai.Blob{Data: []byte("%PDF-1.7"), MIMEType: "application/pdf", DisplayName: "report.pdf"}}},
+		{"inline_data_video", &genai.Part{
+			InlineData:      &genai.Blob{Data: []byte("\x00\x00\x00\x18ftypmp42"), MIMEType: "video/mp4"},
+			VideoMetadata:   &genai.VideoMetadata{StartOffset: 1500 * time.Millisecond, EndOffset: 10 * time.Second, FPS: &fps},
+			MediaResolution: &genai.PartMediaResolution{Level: genai.PartMediaResolutionLevelMediaResolutionHigh, NumTokens: &tokens},
+		}},
+		{"inline_data_text", genai.NewPartFromBytes([]byte("col1,col2\n"), "text/plain")},
+		{"text", genai.NewPartFromText("Summarize the report.")},
+		{"text_thought", &genai.Part{Text: "The user wants a summary.", Thought: true, ThoughtSignature: []byte{0xca, 0xfe, 0x01}}},
+		{"file_data", genai.NewPartFromURI("gs://bucket/report.pdf", "application/pdf")},
+		{"file_data_video", &genai.Part{
+			FileData:      &genai.FileData{FileURI: "gs://bucket/clip.mp4", MIMEType: "video/mp4", DisplayName: "clip"},
+			VideoMetadata: &genai.VideoMetadata{EndOffset: 30 * time.Second},
+		}},
+		{"function_call_signature", &genai.Part{
+			FunctionCall:     &genai.FunctionCall{ID: "call-1", Name: "lookup", Args: map[string]any{"city": "Paris"}},
+			ThoughtSignature: []byte("signature"),
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			data, contentType, err := artifactx.EncodePart(tc.part)
+			if err != nil {
+				t.Fatalf("EncodePart() failed: %v", err)
+			}
+			path := filepath.Join("testdata", "parts", tc.name+".golden")
+			got := append([]byte("Content-Type: "+contentType+"\n\n"), data...)
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("EncodePart() = %q, want %q", got, want)
+			}
+
+			header, stored, _ := bytes.Cut(want, []byte("\n\n"))
+			decoded, err := artifactx.DecodePart(stored, string(bytes.TrimPrefix(header, []byte("Content-Type: "))))
+			if err != nil {
+				t.Fatalf("DecodePart() failed: %v", err)
+			}
+			if diff := cmp.Diff(tc.part, decoded); diff != "" {
+				t.Errorf("DecodePart() of %s mismatch (-want +got):\n%s", path, diff)
+			}
+		})
+	}
+}
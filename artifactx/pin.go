@@ -0,0 +1,65 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"maps"
+
+	"google.golang.org/adk/artifact"
+)
+
+// PinnedMetadataKey is the metadata key under which backends that store
+// metadata with the object mark pinned versions.
+const PinnedMetadataKey = "adk-pinned"
+
+// Pinner is implemented by services whose versions can be pinned. Pinned
+// versions are exempt from the cleanup a backend does on its own, such as
+// [Retention] and eviction, so that critical outputs survive aggressive
+// policies; Delete still removes them.
+type Pinner interface {
+	// Pin pins the requested version, or the latest version if req.Version
+	// is zero. It fails with [fs.ErrNotExist] if there is no such version.
+	Pin(ctx context.Context, req *artifact.LoadRequest) error
+	// Unpin reverts Pin. Unpinning a version that is not pinned is not an
+	// error.
+	Unpin(ctx context.Context, req *artifact.LoadRequest) error
+}
+
+// WithPinned returns a copy of md marking a version as pinned under
+// [PinnedMetadataKey], or not.
+func WithPinned(md map[string]string, pinned bool) map[string]string {
+	out := maps.Clone(md)
+	if out == nil {
+		out = map[string]string{}
+	}
+	if pinned {
+		out[PinnedMetadataKey] = "true"
+	} else {
+		delete(out, PinnedMetadataKey)
+	}
+	return out
+}
+
+// SplitPinned returns md without [PinnedMetadataKey] and whether it marks
+// the version as pinned.
+func SplitPinned(md map[string]string) (map[string]string, bool) {
+	if _, ok := md[PinnedMetadataKey]; !ok {
+		return md, false
+	}
+	out := maps.Clone(md)
+	delete(out, PinnedMetadataKey)
+	return out, md[PinnedMetadataKey] == "true"
+}
@@ -0,0 +1,46 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+
+	"google.golang.org/adk/artifact"
+)
+
+// Prefetcher is implemented by services with a cache tier, such as
+// rangecacheartifact, that can pull versions into it ahead of time, so that
+// the storage latency of the versions an agent step is predicted to read is
+// hidden from the interactive path.
+type Prefetcher interface {
+	// Prefetch starts pulling the referenced versions, or the latest
+	// versions of refs with a zero Version, into the cache and returns
+	// without waiting for them. They are read with ctx, so cancelling it
+	// stops the prefetches still running. Failures are not returned: a
+	// version that could not be prefetched is read from storage when it is
+	// needed.
+	Prefetch(ctx context.Context, refs ...Ref)
+}
+
+// Prefetch starts pulling the referenced versions into the cache tier of
+// srv, and reports whether srv has one. Without one there is nothing to
+// warm up, and the versions are read from storage when they are needed.
+func Prefetch(ctx context.Context, srv artifact.Service, refs ...Ref) bool {
+	p, ok := As[Prefetcher](srv)
+	if ok {
+		p.Prefetch(ctx, refs...)
+	}
+	return ok
+}
@@ -0,0 +1,192 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/adk/artifact"
+)
+
+// DefaultPreviewSize is the number of bytes [Preview] reads if none is
+// given, all that content type detection looks at.
+const DefaultPreviewSize = 512
+
+// PreviewSnippetLength is the maximum number of characters of
+// [PreviewResult.Snippet], not counting the ellipsis.
+const PreviewSnippetLength = 200
+
+// previewConcurrency bounds the previews [Previews] reads at once.
+const previewConcurrency = 8
+
+// PreviewResult is the beginning of a version, enough for file browsers to
+// show what it holds.
+type PreviewResult struct {
+	Attributes
+	// Head holds the first bytes of the stored content.
+	Head []byte
+	// DetectedType is the content type sniffed from Head, which may differ
+	// from the content type recorded by Save.
+	DetectedType string
+	// Snippet is Head decoded as text on a single line, "" if the content
+	// is not text. It ends with an ellipsis if the text goes on.
+	Snippet string
+	// Truncated reports whether the version is larger than Head.
+	Truncated bool
+}
+
+// Preview reads the first n bytes of the requested version, or of the latest
+// version if req.Version is zero, or [DefaultPreviewSize] bytes if n is not
+// positive. Services whose [Opener] implements [RangeOpener] only download
+// those bytes; others stream the version and stop reading after them, and
+// services implementing neither load the whole version.
+func Preview(ctx context.Context, srv artifact.Service, req *artifact.LoadRequest, n int) (*PreviewResult, error) {
+	if n <= 0 {
+		n = DefaultPreviewSize
+	}
+	r, err := OpenRange(ctx, srv, req, 0, int64(n))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	head, err := ReadAllSize(r, min(int64(n), r.Size), 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact '%s' version %d: %w", req.FileName, r.Version, err)
+	}
+	p := &PreviewResult{
+		Attributes:   r.Attributes,
+		Head:         head,
+		DetectedType: http.DetectContentType(head),
+		Truncated:    r.Size > int64(len(head)),
+	}
+	p.Snippet = snippet(head, p.DetectedType, p.ContentType, p.Truncated)
+	return p, nil
+}
+
+// Previews returns the [Preview] of every request, reading a few at a time,
+// for listings of many artifacts. The results are in the order of reqs; the
+// result of a failed request is nil and its error is part of the returned
+// error.
+func Previews(ctx context.Context, srv artifact.Service, reqs []*artifact.LoadRequest, n int) ([]*PreviewResult, error) {
+	results := make([]*PreviewResult, len(reqs))
+	errs := make([]error, len(reqs))
+	var g errgroup.Group
+	g.SetLimit(previewConcurrency)
+	for i, req := range reqs {
+		g.Go(func() error {
+			results[i], errs[i] = Preview(ctx, srv, req, n)
+			return nil
+		})
+	}
+	g.Wait()
+	return results, errors.Join(errs...)
+}
+
+// snippet decodes head as text on a single line if either content type is
+// textual or head is valid UTF-8 without NUL bytes.
+func snippet(head []byte, detected, stored string, truncated bool) string {
+	var text string
+	switch charset := charsetOf(detected); {
+	case charset == "utf-16be" || charset == "utf-16le":
+		// An odd byte is half a character: the head may have cut it.
+		b := head[2:]
+		text, _ = decodeUTF16(b[:len(b)&^1], charset == "utf-16be")
+		if len(b)%2 != 0 {
+			text += "\uFFFD"
+		}
+	case bytes.IndexByte(head, 0) >= 0:
+		return ""
+	case IsText(detected) || IsText(stored) || utf8.Valid(head):
+		text = strings.ToValidUTF8(string(head), "\uFFFD")
+		text = strings.TrimPrefix(text, "\uFEFF")
+	default:
+		return ""
+	}
+	if truncated {
+		// The last character may have been cut.
+		text = strings.TrimSuffix(text, "\uFFFD")
+	}
+	text = strings.Join(strings.Fields(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, text)), " ")
+	if utf8.RuneCountInString(text) > PreviewSnippetLength {
+		runes := []rune(text)
+		return strings.TrimRightFunc(string(runes[:PreviewSnippetLength]), unicode.IsSpace) + "…"
+	}
+	if truncated && text != "" {
+		return text + "…"
+	}
+	return text
+}
+
+// charsetOf returns the lower case charset parameter of contentType.
+func charsetOf(contentType string) string {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(params["charset"])
+}
+
+// IsText reports whether contentType is text in a plain or structured
+// format, such as text/markdown or application/json.
+func IsText(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	for _, suffix := range []string{"json", "xml", "yaml", "javascript", "csv"} {
+		if strings.HasSuffix(mediaType, "/"+suffix) || strings.HasSuffix(mediaType, "+"+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// errOddUTF16 reports UTF-16 data with a trailing odd byte.
+var errOddUTF16 = errors.New("utf-16 data has an odd number of bytes")
+
+// decodeUTF16 decodes b, failing with errOddUTF16 for a trailing odd byte
+// rather than dropping it.
+func decodeUTF16(b []byte, bigEndian bool) (string, error) {
+	if len(b)%2 != 0 {
+		return "", errOddUTF16
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		if bigEndian {
+			u[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+		} else {
+			u[i] = uint16(b[2*i+1])<<8 | uint16(b[2*i])
+		}
+	}
+	return string(utf16.Decode(u)), nil
+}
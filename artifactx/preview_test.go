@@ -0,0 +1,88 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx_test
+
+import (
+	"bytes"
+	"cmp"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+)
+
+func TestPreview(t *testing.T) {
+	ctx := t.Context()
+	fsSrv, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	long := strings.Repeat("déjà vu\n", 100)
+	utf16 := []byte{0xff, 0xfe, 'h', 0, 'i', 0}
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 100)...)
+	for name, srv := range map[string]artifact.Service{
+		"RangeOpener": fsSrv,
+		"InMemory":    artifact.InMemoryService(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, tc := range []struct {
+				fileName, mimeType string
+				data               []byte
+				size               int
+				wantType, want     string
+				wantTruncated      bool
+				// wantContentType is the stored content type, the given
+				// one with the charset of text with a byte order mark.
+				wantContentType string
+			}{
+				{"notes.md", "text/markdown", []byte("# Notes\n\n\tbuy  milk"), 0, "text/plain; charset=utf-8", "# Notes buy milk", false, "text/markdown"},
+				// The head cuts the second byte of "é".
+				{"long.txt", "text/plain", []byte(long), 2, "text/plain; charset=utf-8", "d…", true, "text/plain"},
+				{"long.json", "application/json", []byte(long), 0, "text/plain; charset=utf-8", strings.TrimSpace(strings.Repeat("déjà vu ", 25)) + "…", true, "application/json"},
+				{"utf16.txt", "text/plain", utf16, 0, "text/plain; charset=utf-16le", "hi", false, "text/plain; charset=utf-16le"},
+				{"image.png", "image/png", png, 16, "image/png", "", true, "image/png"},
+			} {
+				if _, err := srv.Save(ctx, &artifact.SaveRequest{
+					AppName: "app", UserID: "user", SessionID: "session", FileName: tc.fileName,
+					Part: genai.NewPartFromBytes(tc.data, tc.mimeType),
+				}); err != nil {
+					t.Fatal(err)
+				}
+				p, err := artifactx.Preview(ctx, srv, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: tc.fileName}, tc.size)
+				if err != nil {
+					t.Fatalf("Preview(%s) failed: %v", tc.fileName, err)
+				}
+				wantHead := tc.data[:min(len(tc.data), cmp.Or(tc.size, artifactx.DefaultPreviewSize))]
+				if !bytes.Equal(p.Head, wantHead) || p.DetectedType != tc.wantType || p.Snippet != tc.want || p.Truncated != tc.wantTruncated || p.ContentType != tc.wantContentType {
+					t.Errorf("Preview(%s) = {Head: %q, DetectedType: %q, Snippet: %q, Truncated: %t, ContentType: %q}, want {%q, %q, %q, %t, %q}",
+						tc.fileName, p.Head, p.DetectedType, p.Snippet, p.Truncated, p.ContentType, wantHead, tc.wantType, tc.want, tc.wantTruncated, tc.wantContentType)
+				}
+			}
+
+			reqs := []*artifact.LoadRequest{
+				{AppName: "app", UserID: "user", SessionID: "session", FileName: "notes.md"},
+				{AppName: "app", UserID: "user", SessionID: "session", FileName: "missing"},
+			}
+			ps, err := artifactx.Previews(ctx, srv, reqs, 0)
+			if err == nil || len(ps) != 2 || ps[0] == nil || ps[0].Snippet != "# Notes buy milk" || ps[1] != nil {
+				t.Errorf("Previews() = (%v, %v), want the preview of notes.md and an error for missing", ps, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import "context"
+
+// Priority classifies the work a request belongs to, for decorators that
+// schedule requests sharing a backend.
+type Priority int
+
+const (
+	// Foreground is interactive traffic, the default.
+	Foreground Priority = iota
+	// Background is maintenance work, such as retention pruning, backups,
+	// replication and pipelines, which yields to foreground requests.
+	Background
+)
+
+// String returns "foreground" or "background".
+func (p Priority) String() string {
+	if p == Background {
+		return "background"
+	}
+	return "foreground"
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx whose requests are made with priority p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFrom returns the priority attached to ctx with [WithPriority],
+// [Foreground] if there is none.
+func PriorityFrom(ctx context.Context) Priority {
+	p, _ := ctx.Value(priorityKey{}).(Priority)
+	return p
+}
@@ -0,0 +1,135 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/adk/artifact"
+)
+
+// Progress reports the state of a download started with [LoadWithProgress].
+type Progress struct {
+	Ref Ref
+	// Transferred is the number of bytes read so far.
+	Transferred int64
+	// Total is the stored size of the version, or -1 if unknown.
+	Total   int64
+	Elapsed time.Duration
+	// ETA is the estimated time left at the average rate so far, or -1 if unknown.
+	ETA time.Duration
+}
+
+// Done reports whether the download is complete.
+func (p Progress) Done() bool {
+	return p.Total >= 0 && p.Transferred >= p.Total
+}
+
+// ProgressChan returns a callback for [LoadWithProgress] that sends to ch.
+// Updates are dropped while ch is full, so a slow reader never stalls the
+// download; the final update is always delivered.
+func ProgressChan(ch chan<- Progress) func(Progress) {
+	return func(p Progress) {
+		if p.Done() {
+			ch <- p
+			return
+		}
+		select {
+		case ch <- p:
+		default:
+		}
+	}
+}
+
+// progressChunk is the size of the reads between two progress reports.
+const progressChunk = 64 << 10
+
+// LoadWithProgress loads the requested version like [artifact.Service.Load],
+// calling fn as bytes arrive. It stops as soon as ctx is done, returning
+// ctx.Err().
+//
+// Progress is reported per chunk for services that implement [Opener]; other
+// services are loaded in one call and fn is called once when done.
+func LoadWithProgress(ctx context.Context, srv artifact.Service, req *artifact.LoadRequest, fn func(Progress)) (*artifact.LoadResponse, error) {
+	ref := Ref{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: req.Version}
+	start := time.Now()
+
+	opener, ok := As[Opener](srv)
+	if !ok {
+		resp, err := srv.Load(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var size int64
+		if data, _, err := EncodePart(resp.Part); err == nil {
+			size = int64(len(data))
+		}
+		fn(Progress{Ref: ref, Transferred: size, Total: size, Elapsed: time.Since(start)})
+		return resp, nil
+	}
+
+	r, err := opener.Open(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	ref.Version = r.Version
+
+	var buf bytes.Buffer
+	if r.Size > 0 {
+		buf.Grow(int(r.Size))
+	}
+	chunk := make([]byte, progressChunk)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		n, err := r.Read(chunk)
+		buf.Write(chunk[:n])
+		if n > 0 || err == io.EOF {
+			p := Progress{Ref: ref, Transferred: int64(buf.Len()), Total: r.Size, Elapsed: time.Since(start), ETA: -1}
+			if err == io.EOF {
+				// Total may be unknown or stale; what was read is complete.
+				p.Total, p.ETA = p.Transferred, 0
+			} else if p.Total > 0 {
+				rate := float64(p.Transferred) / float64(p.Elapsed)
+				p.ETA = time.Duration(float64(p.Total-p.Transferred) / rate)
+			}
+			fn(p)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			return nil, fmt.Errorf("failed to read artifact %s: %w", ref, err)
+		}
+	}
+
+	part, err := DecodePart(buf.Bytes(), r.ContentType)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode artifact %s: %w", ref, err)
+	}
+	return &artifact.LoadResponse{Part: part}, nil
+}
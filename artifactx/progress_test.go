@@ -0,0 +1,107 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+)
+
+func TestLoadWithProgress(t *testing.T) {
+	ctx := t.Context()
+	srv, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("x"), 200<<10)
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "big.bin",
+		Part: genai.NewPartFromBytes(data, "application/octet-stream"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	req := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "big.bin"}
+
+	t.Run("Progress", func(t *testing.T) {
+		ch := make(chan artifactx.Progress, 100)
+		resp, err := artifactx.LoadWithProgress(ctx, srv, req, artifactx.ProgressChan(ch))
+		if err != nil {
+			t.Fatalf("LoadWithProgress() failed: %v", err)
+		}
+		close(ch)
+		if !bytes.Equal(resp.Part.InlineData.Data, data) {
+			t.Error("LoadWithProgress() returned different data")
+		}
+		var updates []artifactx.Progress
+		for p := range ch {
+			updates = append(updates, p)
+		}
+		if len(updates) < 2 {
+			t.Fatalf("got %d progress updates, want several", len(updates))
+		}
+		last := updates[len(updates)-1]
+		if !last.Done() || last.Transferred != int64(len(data)) || last.Ref.Version != 1 {
+			t.Errorf("last update = %+v, want done with %d bytes of version 1", last, len(data))
+		}
+		for i := 1; i < len(updates); i++ {
+			if updates[i].Transferred < updates[i-1].Transferred {
+				t.Errorf("update %d went backwards: %d < %d", i, updates[i].Transferred, updates[i-1].Transferred)
+			}
+		}
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		calls := 0
+		_, err := artifactx.LoadWithProgress(ctx, srv, req, func(p artifactx.Progress) {
+			calls++
+			cancel()
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("LoadWithProgress() = %v, want %v", err, context.Canceled)
+		}
+		if calls != 1 {
+			t.Errorf("got %d progress updates after cancel, want 1", calls)
+		}
+	})
+
+	t.Run("NotOpener", func(t *testing.T) {
+		mem := artifact.InMemoryService()
+		if _, err := mem.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "small", Part: genai.NewPartFromText("hi"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		var got []artifactx.Progress
+		resp, err := artifactx.LoadWithProgress(ctx, mem, &artifact.LoadRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "small",
+		}, func(p artifactx.Progress) { got = append(got, p) })
+		if err != nil || resp.Part.Text != "hi" {
+			t.Fatalf("LoadWithProgress() = (%v, %v), want text part", resp, err)
+		}
+		if len(got) != 1 || !got[0].Done() {
+			t.Errorf("progress updates = %+v, want one final update", got)
+		}
+	})
+}
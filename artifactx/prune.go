@@ -0,0 +1,79 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"slices"
+
+	"google.golang.org/adk/artifact"
+)
+
+// Pruner is implemented by services that remove what their policies no
+// longer keep, such as versions expired by a [Retention] or chunks no
+// version refers to anymore. Backends prune the artifacts they save as
+// they save them; Prune goes over the whole store, to catch up with
+// artifacts no longer saved to and with policies that changed.
+type Pruner interface {
+	// Prune removes what is no longer kept in the whole store and returns
+	// the number of versions removed.
+	Prune(ctx context.Context) (int, error)
+}
+
+// Prune runs every [Pruner] of the decoration chain of srv, the decorated
+// services first so that decorators see what they removed, and returns
+// the total number of versions removed. Under [WithDryRun], what would be
+// removed is reported instead.
+func Prune(ctx context.Context, srv artifact.Service) (int, error) {
+	var pruners []Pruner
+	for srv != nil {
+		if p, ok := srv.(Pruner); ok {
+			pruners = append(pruners, p)
+		}
+		w, ok := srv.(Wrapper)
+		if !ok {
+			break
+		}
+		srv = w.Unwrap()
+	}
+	total := 0
+	for _, p := range slices.Backward(pruners) {
+		n, err := p.Prune(ctx)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Artifacts returns the artifacts of the store of w, once each and with a
+// zero Version, in the order Walk reports them first.
+func Artifacts(ctx context.Context, w Walker) ([]Ref, error) {
+	seen := map[Ref]bool{}
+	var refs []Ref
+	err := w.Walk(ctx, func(ref Ref) error {
+		ref.Version = 0
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+
+	"google.golang.org/genai"
+)
+
+// RefContentType is the media type of a version that references a version
+// of another artifact instead of holding content. The part holds the JSON
+// encoded [Ref].
+const RefContentType = "application/vnd.adk.artifact-ref+json"
+
+// NewRefPart returns a part referencing the version ref, which must name an
+// explicit version. Services resolving references, such as refartifact,
+// load the referenced version in its place.
+func NewRefPart(ref Ref) (*genai.Part, error) {
+	if ref.AppName == "" || ref.UserID == "" || ref.SessionID == "" || ref.FileName == "" || ref.Version <= 0 {
+		return nil, fmt.Errorf("reference %s must name an artifact version", ref)
+	}
+	data, err := json.Marshal(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode reference: %w", err)
+	}
+	return genai.NewPartFromBytes(data, RefContentType), nil
+}
+
+// ParseRefPart returns the version part references, and false if part is not
+// a reference.
+func ParseRefPart(part *genai.Part) (Ref, bool, error) {
+	if part == nil || part.InlineData == nil {
+		return Ref{}, false, nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(part.InlineData.MIMEType); mediaType != RefContentType {
+		return Ref{}, false, nil
+	}
+	var ref Ref
+	if err := json.Unmarshal(part.InlineData.Data, &ref); err != nil {
+		return Ref{}, true, fmt.Errorf("failed to decode reference: %w", err)
+	}
+	return ref, true, nil
+}
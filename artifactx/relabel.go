@@ -0,0 +1,86 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"google.golang.org/adk/artifact"
+)
+
+// MetadataChange describes a change of the metadata attached to a version
+// with [WithMetadata].
+type MetadataChange struct {
+	// Set adds or replaces keys.
+	Set map[string]string
+	// Remove removes keys; removing a key that is not set is not an error.
+	Remove []string
+}
+
+// Validate checks that the change names keys applications may use.
+func (c *MetadataChange) Validate() error {
+	if len(c.Set) == 0 && len(c.Remove) == 0 {
+		return errors.New("no metadata to set or remove")
+	}
+	var errs []error
+	check := func(k string) {
+		switch {
+		case k == "":
+			errs = append(errs, errors.New("empty metadata key"))
+		case strings.HasPrefix(k, ReservedMetadataPrefix):
+			errs = append(errs, fmt.Errorf("metadata key %q is reserved", k))
+		}
+	}
+	for k := range c.Set {
+		check(k)
+	}
+	for _, k := range c.Remove {
+		check(k)
+		if _, ok := c.Set[k]; ok {
+			errs = append(errs, fmt.Errorf("metadata key %q is both set and removed", k))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Apply returns md with the change applied, and whether it differs from md.
+// md is not modified.
+func (c *MetadataChange) Apply(md map[string]string) (map[string]string, bool) {
+	out := maps.Clone(md)
+	if out == nil {
+		out = map[string]string{}
+	}
+	maps.Copy(out, c.Set)
+	for _, k := range c.Remove {
+		delete(out, k)
+	}
+	if len(out) == 0 {
+		out = nil
+	}
+	return out, !maps.Equal(md, out)
+}
+
+// Relabeler is implemented by services that can change the metadata of a
+// stored version in place, without saving a new version.
+type Relabeler interface {
+	// Relabel applies change to the metadata of the requested version, or of
+	// the latest version if req.Version is zero. It fails with
+	// [fs.ErrNotExist] if there is no such version.
+	Relabel(ctx context.Context, req *artifact.LoadRequest, change *MetadataChange) error
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Retention limits the versions kept per artifact. Backends apply it after
+// every Save; the latest version and pinned versions are always kept.
+type Retention struct {
+	// MaxVersions is the number of most recent versions kept; zero keeps all.
+	MaxVersions int
+	// MaxAge is how long versions are kept after they are written; zero keeps
+	// them forever.
+	MaxAge time.Duration
+}
+
+// RetentionSetter is implemented by backends whose retention can be changed
+// while they serve requests, so that long-running servers are reconfigured
+// without a restart. The new retention applies from the next Save.
+type RetentionSetter interface {
+	SetRetention(r Retention)
+}
+
+// RetentionReporter is implemented by backends that apply a retention, so
+// that decorators storing versions that depend on earlier versions, such as
+// deltas, can tell which versions the next Save removes.
+type RetentionReporter interface {
+	// Retention returns the retention applied from the next Save, and the
+	// clock the age of versions is measured with.
+	Retention() (Retention, Clock)
+}
+
+// VersionInfo is a stored version as seen by [Retention.Expired].
+type VersionInfo struct {
+	Version int64
+	ModTime time.Time
+	// Pinned versions are never expired, but count towards MaxVersions.
+	Pinned bool
+}
+
+// Expired returns the versions among versions that r does not keep at now,
+// oldest first.
+func (r Retention) Expired(versions []VersionInfo, now time.Time) []int64 {
+	sorted := slices.SortedFunc(slices.Values(versions), func(a, b VersionInfo) int {
+		return cmp.Compare(b.Version, a.Version)
+	})
+	var expired []int64
+	for i, v := range sorted {
+		if i == 0 || v.Pinned {
+			continue
+		}
+		if (r.MaxVersions > 0 && i >= r.MaxVersions) || (r.MaxAge > 0 && now.Sub(v.ModTime) > r.MaxAge) {
+			expired = append(expired, v.Version)
+		}
+	}
+	slices.Reverse(expired)
+	return expired
+}
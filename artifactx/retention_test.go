@@ -0,0 +1,62 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+func TestRetentionExpired(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	versions := []artifactx.VersionInfo{
+		{Version: 3, ModTime: now.Add(-1 * time.Hour)},
+		{Version: 1, ModTime: now.Add(-72 * time.Hour)},
+		{Version: 4, ModTime: now.Add(-48 * time.Hour)}, // latest, always kept
+		{Version: 2, ModTime: now.Add(-48 * time.Hour)},
+	}
+	for _, tc := range []struct {
+		name string
+		r    artifactx.Retention
+		want []int64
+	}{
+		{"none", artifactx.Retention{}, nil},
+		{"max versions", artifactx.Retention{MaxVersions: 2}, []int64{1, 2}},
+		{"max age", artifactx.Retention{MaxAge: 24 * time.Hour}, []int64{1, 2}},
+		{"both", artifactx.Retention{MaxVersions: 3, MaxAge: 60 * time.Hour}, []int64{1}},
+		{"latest too old", artifactx.Retention{MaxAge: time.Minute}, []int64{1, 2, 3}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.r.Expired(versions, now); !slices.Equal(got, tc.want) {
+				t.Errorf("Expired() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRetentionExpiredPinned(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	versions := []artifactx.VersionInfo{
+		{Version: 1, ModTime: now.Add(-72 * time.Hour), Pinned: true},
+		{Version: 2, ModTime: now.Add(-48 * time.Hour)},
+		{Version: 3, ModTime: now.Add(-1 * time.Hour)},
+	}
+	if got := (artifactx.Retention{MaxVersions: 1}).Expired(versions, now); !slices.Equal(got, []int64{2}) {
+		t.Errorf("Expired() = %v, want [2]", got)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+)
+
+// Scope is a view of a service bound to one session, so that callers name
+// artifacts by file name alone.
+type Scope struct {
+	srv       artifact.Service
+	appName   string
+	userID    string
+	sessionID string
+}
+
+// Scoped returns a view of srv bound to the given session.
+//
+//	scope := artifactx.Scoped(srv, "app", "user", "session")
+//	version, err := scope.Save(ctx, "report.md", genai.NewPartFromText(report))
+func Scoped(srv artifact.Service, appName, userID, sessionID string) *Scope {
+	return &Scope{srv: srv, appName: appName, userID: userID, sessionID: sessionID}
+}
+
+// Service returns the underlying service.
+func (s *Scope) Service() artifact.Service {
+	return s.srv
+}
+
+// Ref returns the reference of version of fileName in the scope.
+func (s *Scope) Ref(fileName string, version int64) Ref {
+	return Ref{AppName: s.appName, UserID: s.userID, SessionID: s.sessionID, FileName: fileName, Version: version}
+}
+
+// Save saves part as a new version of fileName and returns the version.
+func (s *Scope) Save(ctx context.Context, fileName string, part *genai.Part) (int64, error) {
+	resp, err := s.srv.Save(ctx, &artifact.SaveRequest{
+		AppName: s.appName, UserID: s.userID, SessionID: s.sessionID, FileName: fileName, Part: part,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return resp.Version, nil
+}
+
+// Load returns the latest version of fileName.
+func (s *Scope) Load(ctx context.Context, fileName string) (*genai.Part, error) {
+	return s.LoadVersion(ctx, fileName, 0)
+}
+
+// LoadVersion returns the given version of fileName, or the latest if version is zero.
+func (s *Scope) LoadVersion(ctx context.Context, fileName string, version int64) (*genai.Part, error) {
+	resp, err := s.srv.Load(ctx, &artifact.LoadRequest{
+		AppName: s.appName, UserID: s.userID, SessionID: s.sessionID, FileName: fileName, Version: version,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Part, nil
+}
+
+// Delete deletes all versions of fileName.
+func (s *Scope) Delete(ctx context.Context, fileName string) error {
+	return s.srv.Delete(ctx, &artifact.DeleteRequest{
+		AppName: s.appName, UserID: s.userID, SessionID: s.sessionID, FileName: fileName,
+	})
+}
+
+// List returns the file names of the session and of the user namespace.
+func (s *Scope) List(ctx context.Context) ([]string, error) {
+	resp, err := s.srv.List(ctx, &artifact.ListRequest{
+		AppName: s.appName, UserID: s.userID, SessionID: s.sessionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.FileNames, nil
+}
+
+// Versions returns the versions of fileName, in the order of the backend.
+func (s *Scope) Versions(ctx context.Context, fileName string) ([]int64, error) {
+	resp, err := s.srv.Versions(ctx, &artifact.VersionsRequest{
+		AppName: s.appName, UserID: s.userID, SessionID: s.sessionID, FileName: fileName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Versions, nil
+}
+
+// Promote moves fileName into the user namespace; see [Promote].
+func (s *Scope) Promote(ctx context.Context, fileName string) (string, error) {
+	return Promote(ctx, s.srv, s.appName, s.userID, s.sessionID, fileName)
+}
+
+// Demote moves the "user:" artifact fileName into the session; see [Demote].
+func (s *Scope) Demote(ctx context.Context, fileName string) (string, error) {
+	return Demote(ctx, s.srv, s.appName, s.userID, s.sessionID, fileName)
+}
+
+// LoadRendered renders the latest version of the template fileName with
+// data; see [LoadRendered].
+func (s *Scope) LoadRendered(ctx context.Context, fileName string, data any) (*genai.Part, error) {
+	return LoadRendered(ctx, s.srv, &artifact.LoadRequest{
+		AppName: s.appName, UserID: s.userID, SessionID: s.sessionID, FileName: fileName,
+	}, data)
+}
+
+// Clone copies the session scoped artifacts of the scope into newSessionID
+// and returns a scope for it; see [CloneSession].
+func (s *Scope) Clone(ctx context.Context, newSessionID string) (*Scope, error) {
+	if err := CloneSession(ctx, s.srv, &CloneRequest{
+		AppName: s.appName, UserID: s.userID, SessionID: s.sessionID, NewSessionID: newSessionID,
+	}); err != nil {
+		return nil, err
+	}
+	return Scoped(s.srv, s.appName, s.userID, newSessionID), nil
+}
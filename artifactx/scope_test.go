@@ -0,0 +1,65 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx_test
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+func TestScope(t *testing.T) {
+	ctx := t.Context()
+	srv := artifact.InMemoryService()
+	scope := artifactx.Scoped(srv, "app", "user", "session")
+	other := artifactx.Scoped(srv, "app", "user", "other")
+
+	for i, text := range []string{"v1", "v2"} {
+		if v, err := scope.Save(ctx, "notes", genai.NewPartFromText(text)); err != nil || v != int64(i+1) {
+			t.Fatalf("Save() = (%d, %v), want (%d, nil)", v, err, i+1)
+		}
+	}
+	if _, err := other.Save(ctx, "elsewhere", genai.NewPartFromText("x")); err != nil {
+		t.Fatal(err)
+	}
+
+	if part, err := scope.Load(ctx, "notes"); err != nil || part.Text != "v2" {
+		t.Errorf("Load() = (%v, %v), want v2", part, err)
+	}
+	if part, err := scope.LoadVersion(ctx, "notes", 1); err != nil || part.Text != "v1" {
+		t.Errorf("LoadVersion(1) = (%v, %v), want v1", part, err)
+	}
+	if names, err := scope.List(ctx); err != nil || !slices.Equal(names, []string{"notes"}) {
+		t.Errorf("List() = (%v, %v), want [notes]", names, err)
+	}
+	if versions, err := scope.Versions(ctx, "notes"); err != nil || !slices.Equal(slices.Sorted(slices.Values(versions)), []int64{1, 2}) {
+		t.Errorf("Versions() = (%v, %v), want [1 2]", versions, err)
+	}
+	if err := scope.Delete(ctx, "notes"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := scope.Load(ctx, "notes"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Load() after Delete() = %v, want %v", err, fs.ErrNotExist)
+	}
+	if got, want := scope.Ref("notes", 3).String(), "app/user/session/notes/3"; got != want {
+		t.Errorf("Ref() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// SmallObjectSize is the size up to which the buffers of [GetBuffer] are
+// reused. Most versions saved by agents, such as notes, scratchpads and
+// tool results, are smaller, and so is their metadata.
+const SmallObjectSize = 4 << 10
+
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// GetBuffer returns an empty buffer for short lived data, such as encoded
+// metadata, from a pool shared by the backends. Release it with
+// [PutBuffer] once its content is no longer referenced.
+func GetBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// PutBuffer returns b to the pool of [GetBuffer]. Buffers grown beyond
+// [SmallObjectSize] are left to the garbage collector, so that the pool
+// does not pin the memory of occasional large versions.
+func PutBuffer(b *bytes.Buffer) {
+	if b.Cap() > SmallObjectSize {
+		return
+	}
+	b.Reset()
+	bufferPool.Put(b)
+}
+
+// ReadAllSize is [ReadAllLimit] for content whose size is known, such as a
+// file or an object: the content is read into a slice of that size instead
+// of one grown as it is read. size is only a hint; content of another size
+// is still read whole.
+func ReadAllSize(r io.Reader, size, limit int64) ([]byte, error) {
+	if size < 0 || (limit > 0 && size > limit) {
+		return ReadAllLimit(r, limit)
+	}
+	// One more byte to detect content larger than announced.
+	data := make([]byte, size+1)
+	n, err := io.ReadFull(r, data)
+	switch err {
+	case io.EOF, io.ErrUnexpectedEOF:
+		return data[:n:n], nil
+	case nil:
+		return ReadAllLimit(io.MultiReader(bytes.NewReader(data), r), limit)
+	default:
+		return nil, err
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+func TestReadAllSize(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+		size    int64
+		limit   int64
+		wantErr error
+	}{
+		{name: "exact", content: "hello", size: 5},
+		{name: "empty", content: "", size: 0},
+		{name: "shorter than announced", content: "hel", size: 5},
+		{name: "longer than announced", content: "hello, world", size: 5},
+		{name: "unknown size", content: "hello", size: -1},
+		{name: "within the limit", content: "hello", size: 5, limit: 5},
+		{name: "announced over the limit", content: "hello", size: 5, limit: 4, wantErr: artifactx.ErrTooLarge},
+		{name: "grown over the limit", content: "hello, world", size: 5, limit: 8, wantErr: artifactx.ErrTooLarge},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := artifactx.ReadAllSize(strings.NewReader(tc.content), tc.size, tc.limit)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("ReadAllSize() error = %v, want %v", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil || string(got) != tc.content {
+				t.Errorf("ReadAllSize() = (%q, %v), want %q", got, err, tc.content)
+			}
+		})
+	}
+}
+
+func TestPutBuffer(t *testing.T) {
+	buf := artifactx.GetBuffer()
+	buf.WriteString("metadata")
+	artifactx.PutBuffer(buf)
+	if buf := artifactx.GetBuffer(); buf.Len() != 0 {
+		t.Errorf("GetBuffer() returned a buffer holding %q, want an empty one", buf.String())
+	}
+}
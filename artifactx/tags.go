@@ -0,0 +1,47 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"maps"
+)
+
+type requestTagsKey struct{}
+
+// WithRequestTags returns a copy of ctx carrying tags, merged over the tags
+// ctx already carries, that services attach to the storage requests they
+// make for the operation, such as a session or agent run ID, so that
+// storage side access logs can be correlated with agent traces:
+//
+//	ctx = artifactx.WithRequestTags(ctx, map[string]string{"session": sessionID, "run": runID})
+//
+// How tags are attached depends on the service; s3artifact adds them to the
+// User-Agent of S3 requests and fsartifact to its audit log entries.
+func WithRequestTags(ctx context.Context, tags map[string]string) context.Context {
+	merged := maps.Clone(RequestTagsFrom(ctx))
+	if merged == nil {
+		merged = make(map[string]string, len(tags))
+	}
+	maps.Copy(merged, tags)
+	return context.WithValue(ctx, requestTagsKey{}, merged)
+}
+
+// RequestTagsFrom returns the tags attached to ctx with [WithRequestTags].
+// The map must not be modified.
+func RequestTagsFrom(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(requestTagsKey{}).(map[string]string)
+	return tags
+}
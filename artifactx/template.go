@@ -0,0 +1,93 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"text/template"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+)
+
+// Media types of template artifacts. Text templates are executed with
+// [text/template] and render to text; HTML templates are executed with
+// [html/template] and render to text/html.
+const (
+	TemplateMIMEType     = "text/x-go-template"
+	HTMLTemplateMIMEType = "text/x-go-html-template"
+)
+
+// ErrNotTemplate is returned by [LoadRendered] for artifacts that are not
+// stored as templates.
+var ErrNotTemplate = errors.New("artifact is not a template")
+
+// executor is the part of text and html templates used to render.
+type executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// parseTemplate parses text as a template of the given media type.
+func parseTemplate(name, mimeType, text string) (executor, error) {
+	switch mimeType {
+	case TemplateMIMEType:
+		return template.New(name).Option("missingkey=error").Parse(text)
+	case HTMLTemplateMIMEType:
+		return htmltemplate.New(name).Option("missingkey=error").Parse(text)
+	}
+	return nil, fmt.Errorf("%w: content type %q", ErrNotTemplate, mimeType)
+}
+
+// NewTemplatePart returns a part storing text as a template of the given
+// media type, [TemplateMIMEType] or [HTMLTemplateMIMEType]. It fails if the
+// template does not parse, so that broken layouts are not saved.
+func NewTemplatePart(mimeType, text string) (*genai.Part, error) {
+	if _, err := parseTemplate("template", mimeType, text); err != nil {
+		return nil, err
+	}
+	return genai.NewPartFromBytes([]byte(text), mimeType), nil
+}
+
+// LoadRendered loads a template artifact and returns the result of executing
+// it with data. Keys missing from map data are errors rather than rendering
+// as "<no value>".
+func LoadRendered(ctx context.Context, srv artifact.Service, req *artifact.LoadRequest, data any) (*genai.Part, error) {
+	resp, err := srv.Load(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Part.InlineData == nil {
+		return nil, fmt.Errorf("artifact %q: %w: %s part", req.FileName, ErrNotTemplate, PartKind(resp.Part))
+	}
+	mimeType, _, _ := mime.ParseMediaType(resp.Part.InlineData.MIMEType)
+	tmpl, err := parseTemplate(req.FileName, mimeType, string(resp.Part.InlineData.Data))
+	if err != nil {
+		return nil, fmt.Errorf("artifact %q: %w", req.FileName, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render artifact %q: %w", req.FileName, err)
+	}
+	if mimeType == HTMLTemplateMIMEType {
+		return genai.NewPartFromBytes(buf.Bytes(), "text/html"), nil
+	}
+	return genai.NewPartFromText(buf.String()), nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx_test
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+func TestLoadRendered(t *testing.T) {
+	ctx := t.Context()
+	scope := artifactx.Scoped(artifact.InMemoryService(), "app", "user", "session")
+
+	if _, err := artifactx.NewTemplatePart(artifactx.TemplateMIMEType, "{{.Name"); err == nil {
+		t.Error("NewTemplatePart() with a broken template succeeded")
+	}
+
+	text, err := artifactx.NewTemplatePart(artifactx.TemplateMIMEType, "Report for {{.Name}}: {{len .Items}} items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	html, err := artifactx.NewTemplatePart(artifactx.HTMLTemplateMIMEType, "<h1>{{.Name}}</h1>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, part := range map[string]*genai.Part{"report.tmpl": text, "report.html.tmpl": html, "notes": genai.NewPartFromText("{{.Name}}")} {
+		if _, err := scope.Save(ctx, name, part); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data := map[string]any{"Name": "Q3 <draft>", "Items": []int{1, 2}}
+	part, err := scope.LoadRendered(ctx, "report.tmpl", data)
+	if err != nil {
+		t.Fatalf("LoadRendered() failed: %v", err)
+	}
+	if want := "Report for Q3 <draft>: 2 items"; part.Text != want {
+		t.Errorf("LoadRendered() = %q, want %q", part.Text, want)
+	}
+
+	part, err = scope.LoadRendered(ctx, "report.html.tmpl", data)
+	if err != nil {
+		t.Fatalf("LoadRendered(html) failed: %v", err)
+	}
+	if got, want := string(part.InlineData.Data), "<h1>Q3 &lt;draft&gt;</h1>"; got != want || part.InlineData.MIMEType != "text/html" {
+		t.Errorf("LoadRendered(html) = %q (%s), want %q (text/html)", got, part.InlineData.MIMEType, want)
+	}
+
+	if _, err := scope.LoadRendered(ctx, "report.tmpl", map[string]any{"Name": "x"}); err == nil {
+		t.Error("LoadRendered() with missing data succeeded")
+	}
+	if _, err := scope.LoadRendered(ctx, "notes", data); !errors.Is(err, artifactx.ErrNotTemplate) {
+		t.Errorf("LoadRendered(text) error = %v, want %v", err, artifactx.ErrNotTemplate)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import "google.golang.org/adk/artifact"
+
+// Wrapper is implemented by services that decorate another service.
+type Wrapper interface {
+	// Unwrap returns the decorated service.
+	Unwrap() artifact.Service
+}
+
+// As finds the first service in the decoration chain of srv, starting with
+// srv itself, that implements T. It is the service counterpart of [errors.As]:
+//
+//	if w, ok := artifactx.As[artifactx.Walker](srv); ok {
+//		...
+//	}
+//
+// It does not look past a [Forwarder] for the capabilities it does not
+// forward.
+func As[T any](srv artifact.Service) (T, bool) {
+	for srv != nil {
+		if t, ok := srv.(T); ok {
+			return t, true
+		}
+		w, ok := srv.(Wrapper)
+		if !ok {
+			break
+		}
+		if f, ok := srv.(Forwarder); ok && !f.Forwards((*T)(nil)) {
+			break
+		}
+		srv = w.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+// Forwarder is implemented by decorators enforcing a policy, such as access
+// control or scanning what is saved, that the capabilities of the decorated
+// service would bypass. [As] finds only the capabilities the decorator
+// implements itself and those it forwards, so that helpers fall back to
+// Load and Save, which the decorator enforces, or fail.
+type Forwarder interface {
+	Wrapper
+	// Forwards reports whether the capability, passed as a nil pointer to
+	// its type such as (*Stater)(nil), can be used on the decorated service
+	// without bypassing the policy.
+	Forwards(capability any) bool
+}
+
+// IsReadCapability reports whether capability, passed as to
+// [Forwarder.Forwards], only reads artifacts, for decorators whose policy
+// only covers writes.
+func IsReadCapability(capability any) bool {
+	switch capability.(type) {
+	case *Stater, *Opener, *RangeOpener, *URLSigner, *VersionLister,
+		*Browser, *Walker, *DetailedLister, *RecentLister, *FileFinder,
+		*HealthChecker, *Prefetcher, *ChangeWatcher, *RetentionReporter:
+		return true
+	}
+	return false
+}
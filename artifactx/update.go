@@ -0,0 +1,148 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+)
+
+// ErrConflict is returned by [JSONUpdater.UpdateJSON] when concurrent writes
+// kept it from saving the updated version.
+var ErrConflict = errors.New("concurrent update")
+
+// ErrNotJSON is returned by [JSONUpdater.UpdateJSON] when the latest version
+// is not stored as application/json, such as text, or the internal
+// encodings of file references, parts and decorators, which patching would
+// corrupt.
+var ErrNotJSON = errors.New("artifact is not JSON")
+
+// CheckJSON fails with [ErrNotJSON] unless contentType, the stored content
+// type of the latest version of fileName, is application/json.
+func CheckJSON(fileName, contentType string) error {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != "application/json" {
+		return fmt.Errorf("cannot update artifact '%s' of type %q: %w", fileName, contentType, ErrNotJSON)
+	}
+	return nil
+}
+
+// PatchType is the format of the patch of an [UpdateJSONRequest], named by
+// its media type.
+type PatchType string
+
+const (
+	// JSONPatch is a JSON Patch (RFC 6902), see [ApplyJSONPatch].
+	JSONPatch PatchType = "application/json-patch+json"
+	// MergePatch is a JSON Merge Patch (RFC 7386), see [ApplyMergePatch].
+	MergePatch PatchType = "application/merge-patch+json"
+)
+
+// UpdateJSONRequest asks to patch the latest version of a JSON artifact.
+type UpdateJSONRequest struct {
+	AppName, UserID, SessionID, FileName string
+	// Type is the format of Patch; empty means [JSONPatch].
+	Type  PatchType
+	Patch []byte
+	// Check, if set, is called with the patched document before it is
+	// saved; an error fails the update. Decorators use it to validate
+	// updates they would otherwise not see.
+	Check func(doc []byte) error
+}
+
+// Validate checks that the request names an artifact and has a patch of a
+// known type.
+func (r *UpdateJSONRequest) Validate() error {
+	if r.AppName == "" || r.UserID == "" || r.SessionID == "" || r.FileName == "" {
+		return fmt.Errorf("app name, user ID, session ID and file name are required")
+	}
+	if len(r.Patch) == 0 {
+		return fmt.Errorf("patch is required")
+	}
+	switch r.Type {
+	case "", JSONPatch, MergePatch:
+		return nil
+	}
+	return fmt.Errorf("unknown patch type %q", r.Type)
+}
+
+// Apply returns doc patched with the patch of the request, and checked
+// with Check.
+func (r *UpdateJSONRequest) Apply(doc []byte) ([]byte, error) {
+	apply := ApplyJSONPatch
+	if r.Type == MergePatch {
+		apply = ApplyMergePatch
+	}
+	out, err := apply(doc, r.Patch)
+	if err != nil {
+		return nil, err
+	}
+	if r.Check != nil {
+		if err := r.Check(out); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// JSONUpdater is implemented by services that patch JSON artifacts
+// atomically, so that agents maintaining a structured scratchpad do not
+// lose each other's updates to a read-modify-write race.
+type JSONUpdater interface {
+	// UpdateJSON applies the patch to the latest version and saves the
+	// result as a new version with the same content type, as Save does with
+	// ctx. It fails with [fs.ErrNotExist] if the artifact has no versions,
+	// with [ErrNotJSON] if the latest is not stored as application/json,
+	// with [ErrPatchFailed] if the patch does not apply and with
+	// [ErrConflict] if other writers keep saving versions in between.
+	UpdateJSON(ctx context.Context, req *UpdateJSONRequest) (*artifact.SaveResponse, error)
+}
+
+// UpdateJSON patches a JSON artifact with [JSONUpdater] if srv implements
+// it. Otherwise the latest version is loaded, patched and saved, which is
+// not atomic: an update saved in between by another writer is lost.
+func UpdateJSON(ctx context.Context, srv artifact.Service, req *UpdateJSONRequest) (*artifact.SaveResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	if u, ok := As[JSONUpdater](srv); ok {
+		return u.UpdateJSON(ctx, req)
+	}
+	resp, err := srv.Load(ctx, &artifact.LoadRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	data, contentType, err := EncodePart(resp.Part)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckJSON(req.FileName, contentType); err != nil {
+		return nil, err
+	}
+	data, err = req.Apply(data)
+	if err != nil {
+		return nil, err
+	}
+	return srv.Save(ctx, &artifact.SaveRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+		Part: genai.NewPartFromBytes(data, contentType),
+	})
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/adk/artifact"
+)
+
+// UploadsPrefix is the top level directory or key prefix under which backends
+// keep the state of resumable uploads. It is not part of the artifact layout.
+const UploadsPrefix = ".uploads"
+
+// IndexPrefix is the top level key prefix under which backends keep indexes
+// of the artifacts, outside of the artifact layout.
+const IndexPrefix = ".index"
+
+// ErrOffsetMismatch is returned by [Uploader.AppendChunk] when the chunk does
+// not start where the data received so far ends. Clients resume from the size
+// reported by [Uploader.UploadStatus].
+var ErrOffsetMismatch = errors.New("chunk offset does not match upload size")
+
+// UploadRequest describes the artifact version a resumable upload creates.
+type UploadRequest struct {
+	AppName   string `json:"appName"`
+	UserID    string `json:"userId"`
+	SessionID string `json:"sessionId"`
+	FileName  string `json:"fileName"`
+	// MIMEType is the MIME type of the InlineData part the upload is saved as.
+	MIMEType string `json:"mimeType"`
+}
+
+// Validate checks that the request names an artifact.
+func (r *UploadRequest) Validate() error {
+	return (&artifact.LoadRequest{
+		AppName: r.AppName, UserID: r.UserID, SessionID: r.SessionID, FileName: r.FileName,
+	}).Validate()
+}
+
+// Upload is the persisted state of a resumable upload.
+type Upload struct {
+	ID string `json:"id"`
+	UploadRequest
+	// Size is the number of bytes received so far.
+	Size    int64     `json:"size"`
+	Created time.Time `json:"created"`
+	// Metadata is the metadata attached with [WithMetadata] to the context
+	// of BeginUpload. It is attached to the version on commit.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Uploader is implemented by services that accept an artifact in chunks over
+// several calls, possibly from different processes.
+type Uploader interface {
+	// BeginUpload starts a resumable upload and returns its state.
+	BeginUpload(ctx context.Context, req *UploadRequest) (*Upload, error)
+	// AppendChunk appends data at offset, which must equal the size of the
+	// data received so far, and returns the updated state.
+	AppendChunk(ctx context.Context, uploadID string, offset int64, data []byte) (*Upload, error)
+	// UploadStatus returns the state of an upload, for resuming it.
+	UploadStatus(ctx context.Context, uploadID string) (*Upload, error)
+	// CommitUpload saves the received data as a new version of the artifact
+	// and discards the upload.
+	CommitUpload(ctx context.Context, uploadID string) (*artifact.SaveResponse, error)
+	// AbortUpload discards an upload and the data received so far.
+	AbortUpload(ctx context.Context, uploadID string) error
+}
+
+// NewUploadID returns a random upload ID.
+func NewUploadID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// ValidUploadID reports an error if id could not have been returned by
+// [NewUploadID], so that IDs from clients are safe to use in paths.
+func ValidUploadID(id string) error {
+	if b, err := hex.DecodeString(id); err != nil || len(b) != 16 {
+		return fmt.Errorf("invalid upload id %q", id)
+	}
+	return nil
+}
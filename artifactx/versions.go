@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"google.golang.org/adk/artifact"
+)
+
+// AuthorMetadataKey is the metadata key recording who saved a version; see
+// [WithAuthor].
+const AuthorMetadataKey = "author"
+
+// WithAuthor returns a copy of ctx that makes Save record author as the
+// author of the version it writes.
+func WithAuthor(ctx context.Context, author string) context.Context {
+	return WithMetadata(ctx, map[string]string{AuthorMetadataKey: author})
+}
+
+// Author returns the author recorded with [WithAuthor], if any.
+func (a *Attributes) Author() string {
+	return a.Metadata[AuthorMetadataKey]
+}
+
+// ListVersionsRequest selects the versions returned by [VersionLister].
+type ListVersionsRequest struct {
+	AppName, UserID, SessionID, FileName string
+	// Limit is the maximum number of versions returned, the latest ones.
+	// Zero means all.
+	Limit int
+}
+
+// Validate checks the request like [artifact.VersionsRequest.Validate].
+func (r *ListVersionsRequest) Validate() error {
+	return r.VersionsRequest().Validate()
+}
+
+// VersionsRequest returns the equivalent [artifact.VersionsRequest].
+func (r *ListVersionsRequest) VersionsRequest() *artifact.VersionsRequest {
+	return &artifact.VersionsRequest{AppName: r.AppName, UserID: r.UserID, SessionID: r.SessionID, FileName: r.FileName}
+}
+
+// VersionLister is implemented by services that can list versions together
+// with their attributes.
+type VersionLister interface {
+	// ListVersions returns the attributes of the versions of an artifact,
+	// latest first. Like Versions, it fails with [fs.ErrNotExist] if the
+	// artifact has no versions.
+	ListVersions(ctx context.Context, req *ListVersionsRequest) ([]*Attributes, error)
+}
+
+// ListVersions returns the attributes of the versions of an artifact, latest
+// first, with [VersionLister] if srv implements it. Otherwise it stats every
+// returned version, which requires srv to implement [Stater].
+func ListVersions(ctx context.Context, srv artifact.Service, req *ListVersionsRequest) ([]*Attributes, error) {
+	if l, ok := As[VersionLister](srv); ok {
+		return l.ListVersions(ctx, req)
+	}
+	stater, ok := As[Stater](srv)
+	if !ok {
+		return nil, fmt.Errorf("listing versions requires a service implementing artifactx.Stater")
+	}
+	resp, err := srv.Versions(ctx, req.VersionsRequest())
+	if err != nil {
+		return nil, err
+	}
+	versions := LatestVersions(resp.Versions, req.Limit)
+	attrs := make([]*Attributes, 0, len(versions))
+	for _, v := range versions {
+		a, err := stater.Stat(ctx, &artifact.LoadRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: v,
+		})
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs, nil
+}
+
+// LatestVersions returns versions sorted latest first, cut to limit unless
+// it is zero. versions is not modified.
+func LatestVersions(versions []int64, limit int) []int64 {
+	sorted := slices.Clone(versions)
+	slices.Sort(sorted)
+	slices.Reverse(sorted)
+	if limit > 0 && len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	return sorted
+}
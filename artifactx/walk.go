@@ -0,0 +1,51 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Walker is implemented by services that can enumerate every stored artifact version.
+type Walker interface {
+	// Walk calls fn for every artifact version in the store. User scoped
+	// artifacts are reported with [UserNamespace] as their session ID.
+	// Walk stops at the first error returned by fn and returns it.
+	Walk(ctx context.Context, fn func(Ref) error) error
+}
+
+// ParseRef parses the "app/user/session/file/version" form returned by [Ref.String].
+func ParseRef(s string) (Ref, error) {
+	// Cut rather than Split: refs are parsed for every entry of a listing.
+	var segments [5]string
+	rest := s
+	for i := range 4 {
+		var ok bool
+		if segments[i], rest, ok = strings.Cut(rest, "/"); !ok {
+			return Ref{}, fmt.Errorf("invalid artifact ref %q: want app/user/session/file/version", s)
+		}
+	}
+	if strings.Contains(rest, "/") {
+		return Ref{}, fmt.Errorf("invalid artifact ref %q: want app/user/session/file/version", s)
+	}
+	v, err := strconv.ParseInt(rest, 10, 64)
+	if err != nil {
+		return Ref{}, fmt.Errorf("invalid artifact ref %q: %w", s, err)
+	}
+	return Ref{AppName: segments[0], UserID: segments[1], SessionID: segments[2], FileName: segments[3], Version: v}, nil
+}
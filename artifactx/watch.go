@@ -0,0 +1,57 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package artifactx
+
+import "context"
+
+// ChangeOp is the kind of a [Change].
+type ChangeOp int
+
+const (
+	// VersionSaved reports a version that was saved or whose attributes
+	// changed.
+	VersionSaved ChangeOp = iota + 1
+	// VersionDeleted reports a version, or an artifact, that was deleted
+	// or moved away.
+	VersionDeleted
+)
+
+func (op ChangeOp) String() string {
+	switch op {
+	case VersionSaved:
+		return "saved"
+	case VersionDeleted:
+		return "deleted"
+	}
+	return "unknown"
+}
+
+// Change is a change of an artifact reported by a [ChangeWatcher]. A zero
+// Ref.Version stands for all versions of the artifact.
+type Change struct {
+	Ref
+	Op ChangeOp
+}
+
+// ChangeWatcher is implemented by services that can report the changes of
+// their artifacts, including those made by other processes sharing the
+// storage, so that caches in front of the service stay coherent.
+type ChangeWatcher interface {
+	// WatchChanges calls fn with the changes made from the time it is
+	// called until ctx is done, when it returns nil, or watching fails. A
+	// change may be reported more than once, and fn should be quick: it
+	// is called from the watching goroutine.
+	WatchChanges(ctx context.Context, fn func(Change)) error
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"gocloud.dev/blob"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.Browser = (*Service)(nil)
+
+// ListUsers implements [artifactx.Browser] with a delimiter listing.
+func (s *Service) ListUsers(ctx context.Context, appName string) ([]string, error) {
+	prefix, err := artifactx.UsersPrefix(s.keys, appName)
+	if err != nil {
+		return nil, err
+	}
+	names, err := s.subdirs(ctx, prefix)
+	slices.Sort(names)
+	return names, err
+}
+
+// ListSessions implements [artifactx.Browser] with a delimiter listing.
+func (s *Service) ListSessions(ctx context.Context, appName, userID string) ([]string, error) {
+	prefix, err := artifactx.SessionsPrefix(s.keys, appName, userID)
+	if err != nil {
+		return nil, err
+	}
+	names, err := s.subdirs(ctx, prefix)
+	return artifactx.Sessions(s.keys, names), err
+}
+
+// subdirs returns the names of the "directories" directly below prefix.
+func (s *Service) subdirs(ctx context.Context, prefix string) ([]string, error) {
+	iter := s.bucket.List(&blob.ListOptions{Prefix: prefix, Delimiter: "/"})
+	var names []string
+	for {
+		obj, err := iter.Next(ctx)
+		if err == io.EOF {
+			return names, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error iterating objects: %w", err)
+		}
+		if obj.IsDir {
+			names = append(names, strings.TrimSuffix(strings.TrimPrefix(obj.Key, prefix), "/"))
+		}
+	}
+}
+
+var _ artifactx.FileFinder = (*Service)(nil)
+
+// FindFile implements [artifactx.FileFinder] with one listing of the prefix
+// of the user rather than one per session. It fails with
+// [errors.ErrUnsupported] if the key layout is not an
+// [artifactx.HierarchyKeyBuilder].
+func (s *Service) FindFile(ctx context.Context, appName, userID, fileName string) ([]artifactx.Ref, error) {
+	if fileName == "" {
+		return nil, fmt.Errorf("file name is required")
+	}
+	prefix, err := artifactx.SessionsPrefix(s.keys, appName, userID)
+	if err != nil {
+		return nil, err
+	}
+	latest := map[string]artifactx.Ref{}
+	iter := s.bucket.List(&blob.ListOptions{Prefix: prefix})
+	for {
+		obj, err := iter.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error iterating objects: %w", err)
+		}
+		ref, err := s.keys.ParseKey(obj.Key)
+		if err != nil || ref.FileName != fileName {
+			continue
+		}
+		if r, ok := latest[ref.SessionID]; !ok || ref.Version > r.Version {
+			latest[ref.SessionID] = ref
+		}
+	}
+	refs := slices.Collect(maps.Values(latest))
+	slices.SortFunc(refs, func(a, b artifactx.Ref) int { return strings.Compare(a.SessionID, b.SessionID) })
+	return refs, nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.Checker = (*Service)(nil)
+
+// Check implements [artifactx.Checker].
+//
+// With opts.VerifyChecksums every object is downloaded and compared with the
+// checksum recorded in its metadata, or, for objects written without one,
+// with the MD5 reported by the bucket. Objects with neither (e.g. multipart
+// uploads of other tools) are skipped.
+func (s *Service) Check(ctx context.Context, opts *artifactx.CheckOptions) (*artifactx.CheckReport, error) {
+	if opts == nil {
+		opts = &artifactx.CheckOptions{}
+	}
+	report := &artifactx.CheckReport{}
+	// artifact prefix -> versions found under it
+	versions := map[string][]int64{}
+
+	iter := s.bucket.List(nil)
+	for {
+		obj, err := iter.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error iterating objects: %w", err)
+		}
+		if strings.HasPrefix(obj.Key, artifactx.UploadsPrefix+"/") || strings.HasPrefix(obj.Key, artifactx.IndexPrefix+"/") ||
+			strings.HasPrefix(obj.Key, artifactx.ArchivePrefix+"/") {
+			continue
+		}
+		report.Objects++
+
+		ref, err := s.keys.ParseKey(obj.Key)
+		if err != nil {
+			if artifactx.IsInvalidVersionKey(s.keys, obj.Key) {
+				report.Anomalies = append(report.Anomalies, artifactx.Anomaly{
+					Kind: artifactx.InvalidVersion, Key: obj.Key, Detail: fmt.Sprintf("%q is not a version number", path.Base(obj.Key)),
+				})
+			} else {
+				report.Anomalies = append(report.Anomalies, artifactx.Anomaly{
+					Kind: artifactx.OrphanedObject, Key: obj.Key, Detail: "object outside of the artifact layout",
+				})
+			}
+			continue
+		}
+		dir := path.Dir(obj.Key)
+		versions[dir] = append(versions[dir], ref.Version)
+
+		if opts.VerifyMetadata {
+			a, err := s.checkMeta(ctx, obj.Key)
+			if err != nil {
+				return nil, err
+			}
+			if a != nil {
+				report.Anomalies = append(report.Anomalies, *a)
+			}
+		}
+		if opts.VerifyChecksums {
+			a, err := s.verifyChecksum(ctx, obj)
+			if err != nil {
+				return nil, err
+			}
+			if a != nil {
+				report.Anomalies = append(report.Anomalies, *a)
+			}
+		}
+	}
+
+	for dir, vs := range versions {
+		report.Anomalies = append(report.Anomalies, artifactx.VersionGaps(dir, vs)...)
+	}
+	return report, nil
+}
+
+// verifyChecksum compares the content of obj with the checksum recorded in
+// its metadata, falling back to the MD5 reported by the bucket.
+func (s *Service) verifyChecksum(ctx context.Context, obj *blob.ListObject) (*artifactx.Anomaly, error) {
+	attrs, err := s.bucket.Attributes(ctx, obj.Key)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, nil // deleted since listing
+		}
+		return nil, fmt.Errorf("could not get attributes of object '%s': %w", obj.Key, err)
+	}
+	name, h, want := "md5", md5.New(), hex.EncodeToString(obj.MD5)
+	if a, sum := artifactx.MetaFromObject(attrs.ContentType, attrs.Metadata).Checksum(); a != nil {
+		name, h, want = a.Name, a.New(), sum
+	} else if len(obj.MD5) == 0 {
+		return nil, nil
+	}
+
+	r, err := s.bucket.NewReader(ctx, obj.Key, nil)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not get object '%s': %w", obj.Key, err)
+	}
+	defer r.Close()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, fmt.Errorf("could not read data from object '%s': %w", obj.Key, err)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != want {
+		return &artifactx.Anomaly{
+			Kind: artifactx.ChecksumMismatch, Key: obj.Key, Detail: fmt.Sprintf("%s %s, want %s", name, sum, want),
+		}, nil
+	}
+	return nil, nil
+}
+
+// checkMeta validates the metadata of the object at key against the
+// metadata format.
+func (s *Service) checkMeta(ctx context.Context, key string) (*artifactx.Anomaly, error) {
+	attrs, err := s.bucket.Attributes(ctx, key)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, nil // deleted since listing
+		}
+		return nil, fmt.Errorf("could not get attributes of object '%s': %w", key, err)
+	}
+	m := artifactx.MetaFromObject(attrs.ContentType, attrs.Metadata)
+	if err := m.Validate(); err != nil {
+		return &artifactx.Anomaly{Kind: artifactx.InvalidMetadata, Key: key, Detail: err.Error()}, nil
+	}
+	if m.Format < artifactx.MetaFormat {
+		return &artifactx.Anomaly{
+			Kind: artifactx.OutdatedMetadata, Key: key, Detail: fmt.Sprintf("format %d, current is %d", m.Format, artifactx.MetaFormat), Repairable: true,
+		}, nil
+	}
+	return nil, nil
+}
+
+// Repair implements [artifactx.Checker].
+//
+// Buckets store the content type with each object, so the only repairable
+// anomaly is outdated metadata, which is upgraded by rewriting the object.
+func (s *Service) Repair(ctx context.Context, report *artifactx.CheckReport) (int, error) {
+	repaired := 0
+	for _, a := range report.Repairable() {
+		if err := ctx.Err(); err != nil {
+			return repaired, err
+		}
+		if a.Kind != artifactx.OutdatedMetadata {
+			continue
+		}
+		attrs, err := s.bucket.Attributes(ctx, a.Key)
+		if err != nil {
+			return repaired, fmt.Errorf("could not get attributes of object '%s': %w", a.Key, err)
+		}
+		m := artifactx.MetaFromObject(attrs.ContentType, attrs.Metadata)
+		if !m.Upgrade() {
+			continue
+		}
+		if m.Created.IsZero() {
+			m.Created = attrs.ModTime
+		}
+		if err := s.rewrite(ctx, a.Key, &blob.WriterOptions{ContentType: m.ContentType, Metadata: m.ObjectMetadata()}); err != nil {
+			return repaired, err
+		}
+		repaired++
+	}
+	return repaired, nil
+}
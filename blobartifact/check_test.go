@@ -0,0 +1,71 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact_test
+
+import (
+	"testing"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/memblob"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/blobartifact"
+)
+
+func TestCheckChecksums(t *testing.T) {
+	ctx := t.Context()
+	for _, alg := range []*artifactx.ChecksumAlgorithm{artifactx.ChecksumSHA256, artifactx.ChecksumCRC32C, nil} {
+		bucket := memblob.OpenBucket(nil)
+		srv := blobartifact.New(bucket, blobartifact.WithChecksumAlgorithm(alg))
+		for range 2 {
+			if _, err := srv.Save(ctx, &artifact.SaveRequest{
+				AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("hello"),
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// Corruption the MD5 of the bucket does not see, such as a faulty
+		// copy: the object is rewritten with its metadata.
+		const key = "app/user/session/file/1"
+		attrs, err := bucket.Attributes(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := bucket.WriteAll(ctx, key, []byte("jello"), &blob.WriterOptions{ContentType: attrs.ContentType, Metadata: attrs.Metadata}); err != nil {
+			t.Fatal(err)
+		}
+
+		if report, err := srv.Check(ctx, nil); err != nil || len(report.Anomalies) != 0 {
+			t.Errorf("Check() with %v without VerifyChecksums = (%v, %v), want no anomalies", alg, report, err)
+		}
+		report, err := srv.Check(ctx, &artifactx.CheckOptions{VerifyChecksums: true})
+		if err != nil {
+			t.Fatalf("Check() failed: %v", err)
+		}
+		if alg == nil {
+			// Without a recorded checksum, only the MD5 of the bucket is
+			// compared, which matches the rewritten content.
+			if len(report.Anomalies) != 0 {
+				t.Errorf("Check() without checksums anomalies = %+v, want none", report.Anomalies)
+			}
+			continue
+		}
+		if len(report.Anomalies) != 1 || report.Anomalies[0].Kind != artifactx.ChecksumMismatch || report.Anomalies[0].Key != key {
+			t.Errorf("Check() with %v anomalies = %+v, want a checksum mismatch of %s", alg, report.Anomalies, key)
+		}
+	}
+}
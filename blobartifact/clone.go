@@ -0,0 +1,75 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.SessionCloner = (*Service)(nil)
+
+// CloneSession implements [artifactx.SessionCloner] with server side copies,
+// which keep the metadata of the versions. A failure part way may leave a
+// partial clone behind.
+func (s *Service) CloneSession(ctx context.Context, req *artifactx.CloneRequest) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("request validation failed: %w", err)
+	}
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return err
+	}
+	if err := artifactx.CheckSessionID(s.keys, req.NewSessionID, ""); err != nil {
+		return err
+	}
+	names, err := artifactx.SessionFileNames(ctx, s, req.AppName, req.UserID, req.SessionID)
+	if err != nil {
+		return err
+	}
+	existing, err := artifactx.SessionFileNames(ctx, s, req.AppName, req.UserID, req.NewSessionID)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("session %q has artifacts: %w", req.NewSessionID, fs.ErrExist)
+	}
+
+	for _, name := range names {
+		resp, err := s.listVersions(ctx, &artifact.VersionsRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: name,
+		})
+		if err != nil {
+			return err
+		}
+		if len(resp.Versions) == 0 {
+			continue // deleted since listing
+		}
+		for _, v := range resp.Versions {
+			src := s.buildKey(req.AppName, req.UserID, req.SessionID, name, v)
+			dst := s.buildKey(req.AppName, req.UserID, req.NewSessionID, name, v)
+			if err := s.bucket.Copy(ctx, dst, src, nil); err != nil {
+				s.reindex(ctx, req.AppName, req.UserID, req.NewSessionID, name)
+				return fmt.Errorf("failed to copy %q: %w", src, err)
+			}
+		}
+		s.reindex(ctx, req.AppName, req.UserID, req.NewSessionID, name)
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// The archive of an artifact is a tar file below [artifactx.ArchivePrefix]
+// with an entry per version, named after its number, whose PAX records hold
+// the content type and the metadata of the object. Compacting again
+// rewrites the archive with the versions it already holds.
+
+const (
+	// contentTypeRecord is the PAX record of the content type of a version.
+	contentTypeRecord = "ADKARTIFACT.content_type"
+	// metadataRecordPrefix prefixes the PAX records of the metadata.
+	metadataRecordPrefix = "ADKARTIFACT.metadata."
+)
+
+var _ artifactx.Compactor = (*Service)(nil)
+
+func (s *Service) archiveKey(appName, userID, sessionID, fileName string) string {
+	return artifactx.ArchivePrefix + "/" + s.buildKeyPrefix(appName, userID, sessionID, fileName) + "versions.tar"
+}
+
+// Compact implements [artifactx.Compactor]. The archive is written before
+// the versions are deleted, so that a failure leaves versions in both
+// places rather than in neither.
+func (s *Service) Compact(ctx context.Context, req *artifactx.CompactRequest) ([]int64, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return nil, err
+	}
+	appName, userID, sessionID, fileName := req.AppName, req.UserID, req.SessionID, req.FileName
+	resp, err := s.listVersions(ctx, &artifact.VersionsRequest{
+		AppName: appName, UserID: userID, SessionID: sessionID, FileName: fileName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifact versions: %w", err)
+	}
+	versions := slices.Sorted(slices.Values(resp.Versions))
+	var moved []int64
+	for _, v := range versions[:max(len(versions)-req.Keep, 0)] {
+		if !s.isPinned(ctx, s.buildKey(appName, userID, sessionID, fileName, v)) {
+			moved = append(moved, v)
+		}
+	}
+	if len(moved) == 0 {
+		return nil, nil
+	}
+
+	key := s.archiveKey(appName, userID, sessionID, fileName)
+	var old io.Reader // the previous archive, if any
+	r, err := s.bucket.NewReader(ctx, key, nil)
+	switch {
+	case err == nil:
+		defer r.Close()
+		old = r
+	case gcerrors.Code(err) != gcerrors.NotFound:
+		return nil, fmt.Errorf("failed to read archive '%s': %w", key, err)
+	}
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w, err := s.bucket.NewWriter(wctx, key, &blob.WriterOptions{ContentType: "application/x-tar"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create writer: %w", err)
+	}
+	if err := s.writeArchive(ctx, w, old, appName, userID, sessionID, fileName, moved); err != nil {
+		cancel() // keep the previous archive
+		w.Close()
+		return nil, fmt.Errorf("failed to write archive '%s': %w", key, err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive '%s': %w", key, err)
+	}
+
+	defer s.reindex(ctx, appName, userID, sessionID, fileName)
+	for _, v := range moved {
+		vkey := s.buildKey(appName, userID, sessionID, fileName, v)
+		if err := s.bucket.Delete(ctx, vkey); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			return nil, fmt.Errorf("failed to delete archived version '%s': %w", vkey, err)
+		}
+	}
+	return moved, nil
+}
+
+// writeArchive writes to w the entries of the previous archive old, if not
+// nil, but those of the versions being moved, followed by the moved
+// versions.
+func (s *Service) writeArchive(ctx context.Context, w io.Writer, old io.Reader, appName, userID, sessionID, fileName string, moved []int64) error {
+	tw := tar.NewWriter(w)
+	if old != nil {
+		tr := tar.NewReader(old)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if v, err := strconv.ParseInt(hdr.Name, 10, 64); err == nil && slices.Contains(moved, v) {
+				continue // restored since, and archived again
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if _, err := io.Copy(tw, tr); err != nil {
+				return err
+			}
+		}
+	}
+	for _, v := range moved {
+		key := s.buildKey(appName, userID, sessionID, fileName, v)
+		attrs, err := s.bucket.Attributes(ctx, key)
+		if err != nil {
+			return fmt.Errorf("could not get attributes of object '%s': %w", key, err)
+		}
+		records := map[string]string{contentTypeRecord: attrs.ContentType}
+		for k, val := range attrs.Metadata {
+			records[metadataRecordPrefix+k] = val
+		}
+		hdr := &tar.Header{
+			Name:       strconv.FormatInt(v, 10),
+			Mode:       0644,
+			Size:       attrs.Size,
+			ModTime:    attrs.ModTime,
+			Format:     tar.FormatPAX,
+			PAXRecords: records,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		r, err := s.newReader(ctx, key)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(tw, r)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read object '%s': %w", key, err)
+		}
+	}
+	return tw.Close()
+}
+
+// ArchivedVersions implements [artifactx.Compactor].
+func (s *Service) ArchivedVersions(ctx context.Context, req *artifact.VersionsRequest) ([]int64, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	var versions []int64
+	err := s.readArchive(ctx, req.AppName, req.UserID, req.SessionID, req.FileName, func(version int64, _ *tar.Header, _ io.Reader) (bool, error) {
+		versions = append(versions, version)
+		return false, nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(versions)
+	return slices.Compact(versions), nil
+}
+
+// RestoreVersion implements [artifactx.Compactor].
+func (s *Service) RestoreVersion(ctx context.Context, req *artifact.LoadRequest) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("request validation failed: %w", err)
+	}
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return err
+	}
+	if req.Version == 0 {
+		return fmt.Errorf("a version is required to restore an archived artifact")
+	}
+	key := s.buildKey(req.AppName, req.UserID, req.SessionID, req.FileName, req.Version)
+	found := false
+	err := s.readArchive(ctx, req.AppName, req.UserID, req.SessionID, req.FileName, func(version int64, hdr *tar.Header, r io.Reader) (bool, error) {
+		if version != req.Version {
+			return false, nil
+		}
+		found = true
+		opts := &blob.WriterOptions{ContentType: hdr.PAXRecords[contentTypeRecord], IfNotExist: true}
+		for k, v := range hdr.PAXRecords {
+			if name, ok := strings.CutPrefix(k, metadataRecordPrefix); ok {
+				if opts.Metadata == nil {
+					opts.Metadata = map[string]string{}
+				}
+				opts.Metadata[name] = v
+			}
+		}
+		if s.writerOptions != nil {
+			s.writerOptions(ctx, opts)
+		}
+		wctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		w, err := s.bucket.NewWriter(wctx, key, opts)
+		if err != nil {
+			return true, fmt.Errorf("failed to create writer: %w", err)
+		}
+		if _, err := io.Copy(w, r); err != nil {
+			cancel() // abort the write
+			w.Close()
+			return true, fmt.Errorf("failed to restore '%s': %w", key, err)
+		}
+		if err := w.Close(); err != nil {
+			if gcerrors.Code(err) == gcerrors.FailedPrecondition {
+				return true, fmt.Errorf("artifact '%s' exists: %w", key, fs.ErrExist)
+			}
+			return true, fmt.Errorf("failed to restore '%s': %w", key, err)
+		}
+		return true, nil
+	})
+	if err == nil && !found {
+		err = fs.ErrNotExist
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("artifact '%s' is not archived: %w", key, fs.ErrNotExist)
+	}
+	if err != nil {
+		return err
+	}
+	s.reindex(ctx, req.AppName, req.UserID, req.SessionID, req.FileName)
+	return nil
+}
+
+// readArchive calls fn with every entry of the archive of an artifact until
+// it returns true or an error. It fails with [fs.ErrNotExist] if the
+// artifact has no archive.
+func (s *Service) readArchive(ctx context.Context, appName, userID, sessionID, fileName string, fn func(version int64, hdr *tar.Header, r io.Reader) (bool, error)) error {
+	key := s.archiveKey(appName, userID, sessionID, fileName)
+	r, err := s.bucket.NewReader(ctx, key, nil)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return fs.ErrNotExist
+		}
+		return fmt.Errorf("failed to read archive '%s': %w", key, err)
+	}
+	defer r.Close()
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive '%s': %w", key, err)
+		}
+		version, err := strconv.ParseInt(hdr.Name, 10, 64)
+		if err != nil {
+			continue
+		}
+		if done, err := fn(version, hdr, tr); done || err != nil {
+			return err
+		}
+	}
+}
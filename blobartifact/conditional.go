@@ -0,0 +1,55 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// CheckConditionalWrites verifies that the bucket honors conditional writes,
+// which saves rely on to number versions: it writes a probe object below
+// [artifactx.UploadsPrefix] twice, the second time only if it does not
+// exist, and deletes it. It fails with [errors.ErrUnsupported] if the second
+// write succeeds, as in S3 compatible stores ignoring If-None-Match, where
+// concurrent saves of an artifact silently overwrite each other's versions.
+//
+// Call it once when deploying against such a store; saves do not probe.
+func (s *Service) CheckConditionalWrites(ctx context.Context) (err error) {
+	key := uploadPrefix(artifactx.NewUploadID()) + "probe"
+	opts := &blob.WriterOptions{ContentType: "text/plain", IfNotExist: true}
+	if err := s.bucket.WriteAll(ctx, key, []byte("1"), opts); err != nil {
+		return fmt.Errorf("failed to write probe object: %w", err)
+	}
+	defer func() {
+		if derr := s.bucket.Delete(ctx, key); derr != nil && gcerrors.Code(derr) != gcerrors.NotFound {
+			err = errors.Join(err, fmt.Errorf("failed to delete probe object: %w", derr))
+		}
+	}()
+	err = s.bucket.WriteAll(ctx, key, []byte("2"), opts)
+	switch {
+	case err == nil:
+		return fmt.Errorf("bucket overwrote an existing object in a conditional write: %w", errors.ErrUnsupported)
+	case gcerrors.Code(err) == gcerrors.FailedPrecondition:
+		return nil
+	}
+	return fmt.Errorf("failed to write probe object: %w", err)
+}
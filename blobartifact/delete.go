@@ -0,0 +1,101 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"gocloud.dev/gcerrors"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultDeleteConcurrency is the number of versions deleting all the
+// versions of an artifact deletes at once unless configured.
+const DefaultDeleteConcurrency = 16
+
+// DeleteErrorPolicy tells what deleting all the versions of an artifact does
+// when deleting one of them fails.
+type DeleteErrorPolicy int
+
+const (
+	// DeleteFailFast stops deleting at the first failure and returns it.
+	// The versions not deleted yet are kept.
+	DeleteFailFast DeleteErrorPolicy = iota
+	// DeleteBestEffort deletes every version it can, and returns the
+	// failures joined with [errors.Join].
+	DeleteBestEffort
+)
+
+// String returns "fail-fast" or "best-effort".
+func (p DeleteErrorPolicy) String() string {
+	if p == DeleteBestEffort {
+		return "best-effort"
+	}
+	return "fail-fast"
+}
+
+// deleteObjects deletes the objects at keys, DeleteConcurrency at a time,
+// under the delete error policy. Objects already gone are not an error.
+func (s *Service) deleteObjects(ctx context.Context, keys []string) error {
+	del := func(ctx context.Context, key string) error {
+		if err := s.bucket.Delete(ctx, key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			return fmt.Errorf("failed to delete artifact %s: %w", key, err)
+		}
+		return nil
+	}
+
+	if s.deletePolicy == DeleteBestEffort {
+		var (
+			g    errgroup.Group
+			mu   sync.Mutex
+			errs []error
+		)
+		g.SetLimit(s.deleteConcurrency)
+		for _, key := range keys {
+			if ctx.Err() != nil {
+				break
+			}
+			g.Go(func() error {
+				if err := del(ctx, key); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+				return nil
+			})
+		}
+		g.Wait()
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+		}
+		return errors.Join(errs...)
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.deleteConcurrency)
+	for _, key := range keys {
+		if gctx.Err() != nil {
+			break // a deletion failed: start no more
+		}
+		g.Go(func() error { return del(gctx, key) })
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,37 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.HealthChecker = (*Service)(nil)
+
+// HealthCheck implements [artifactx.HealthChecker] with
+// [blob.Bucket.IsAccessible].
+func (s *Service) HealthCheck(ctx context.Context) error {
+	ok, err := s.bucket.IsAccessible(ctx)
+	if err != nil {
+		return fmt.Errorf("bucket is not accessible: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("bucket does not exist")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"time"
+)
+
+// hedge calls fn and, if it has not returned after delay, calls it a second
+// time concurrently. The first successful result is returned and the other
+// attempt is cancelled; an error is only returned once both attempts failed,
+// or right away if the first attempt fails before the second started. A
+// delay of zero or less disables hedging.
+func hedge[T any](ctx context.Context, delay time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+	if delay <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		v   T
+		err error
+	}
+	results := make(chan result, 2) // buffered so that the loser never blocks
+	attempt := func() {
+		v, err := fn(ctx)
+		results <- result{v, err}
+	}
+	go attempt()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	pending := 1
+	hedged := false
+	var last result
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			hedged = true
+			pending++
+			go attempt()
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.v, nil
+			}
+			last = r
+			if !hedged {
+				return r.v, r.err
+			}
+		}
+	}
+	return last.v, last.err
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHedge(t *testing.T) {
+	ctx := t.Context()
+	t.Run("slow first attempt", func(t *testing.T) {
+		var calls atomic.Int32
+		cancelled := make(chan struct{})
+		got, err := hedge(ctx, time.Millisecond, func(ctx context.Context) (int, error) {
+			if calls.Add(1) == 1 {
+				<-ctx.Done() // stalls until the second attempt wins
+				close(cancelled)
+				return 0, ctx.Err()
+			}
+			return 2, nil
+		})
+		if err != nil || got != 2 {
+			t.Errorf("hedge() = (%d, %v), want the second attempt", got, err)
+		}
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			t.Error("the stalled attempt was not cancelled")
+		}
+	})
+	t.Run("fast attempt", func(t *testing.T) {
+		var calls atomic.Int32
+		got, err := hedge(ctx, time.Hour, func(ctx context.Context) (int, error) {
+			return int(calls.Add(1)), nil
+		})
+		if err != nil || got != 1 || calls.Load() != 1 {
+			t.Errorf("hedge() = (%d, %v) after %d calls, want a single attempt", got, err, calls.Load())
+		}
+	})
+	t.Run("failures", func(t *testing.T) {
+		errFirst, errSecond := errors.New("first"), errors.New("second")
+		var calls atomic.Int32
+		_, err := hedge(ctx, time.Millisecond, func(ctx context.Context) (int, error) {
+			if calls.Add(1) == 1 {
+				time.Sleep(20 * time.Millisecond)
+				return 0, errFirst
+			}
+			time.Sleep(40 * time.Millisecond)
+			return 0, errSecond
+		})
+		if !errors.Is(err, errSecond) {
+			t.Errorf("hedge() = %v, want the error of the last attempt", err)
+		}
+		if _, err := hedge(ctx, time.Hour, func(ctx context.Context) (int, error) { return 0, errFirst }); !errors.Is(err, errFirst) {
+			t.Errorf("hedge() failing before the delay = %v, want %v", err, errFirst)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"fmt"
+
+	"gocloud.dev/gcerrors"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// checkMutable returns [artifactx.ErrImmutable] if the object at key, the
+// latest version of fileName, was saved with [artifactx.WithImmutable].
+func (s *Service) checkMutable(ctx context.Context, key, fileName string) error {
+	attrs, err := s.bucket.Attributes(ctx, key)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			// deleted since listing
+			return nil
+		}
+		return fmt.Errorf("could not get attributes of object '%s': %w", key, err)
+	}
+	if _, immutable := artifactx.SplitImmutable(attrs.Metadata); immutable {
+		return fmt.Errorf("cannot save artifact '%s': %w", fileName, artifactx.ErrImmutable)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// The versions index keeps the versions of every artifact in a small JSON
+// object below [artifactx.IndexPrefix], rewritten by the service after every
+// change to the versions, so that Versions and loading the latest version
+// read one object instead of listing the bucket. Writes still list the
+// bucket to number versions. A rewrite keeps the indexed versions the
+// listing does not show yet, if they exist, so that listings showing
+// changes late do not drop them.
+//
+// The index is a cache: a missing index falls back to listing, and changes
+// made behind the back of the service, or concurrent writes to the same
+// artifact, may leave it stale until the next write to the artifact.
+
+// versionsIndex is the content of an index object.
+type versionsIndex struct {
+	Versions []int64 `json:"versions"`
+}
+
+func (s *Service) indexKey(appName, userID, sessionID, fileName string) string {
+	return artifactx.IndexPrefix + "/" + s.buildKeyPrefix(appName, userID, sessionID, fileName) + "versions.json"
+}
+
+// readIndex returns the indexed versions of an artifact, and false if the
+// index is disabled or has no usable entry for it.
+func (s *Service) readIndex(ctx context.Context, appName, userID, sessionID, fileName string) ([]int64, bool) {
+	if !s.index {
+		return nil, false
+	}
+	key := s.indexKey(appName, userID, sessionID, fileName)
+	data, err := s.bucket.ReadAll(ctx, key)
+	if err != nil {
+		if gcerrors.Code(err) != gcerrors.NotFound {
+			s.logger.WarnContext(ctx, "failed to read versions index", "key", key, "error", err)
+		}
+		return nil, false
+	}
+	var idx versionsIndex
+	if err := json.Unmarshal(data, &idx); err != nil {
+		s.logger.WarnContext(ctx, "failed to decode versions index", "key", key, "error", err)
+		return nil, false
+	}
+	return idx.Versions, true
+}
+
+// reindex rewrites the index entry of an artifact from a listing, removing
+// it if the artifact has no versions left, and drops its cached listing.
+// Failures are logged: the write that triggered it already succeeded.
+func (s *Service) reindex(ctx context.Context, appName, userID, sessionID, fileName string) {
+	s.reindexChanged(ctx, appName, userID, sessionID, fileName, nil, nil)
+}
+
+// reindexChanged is reindex after the caller wrote the added versions and
+// deleted the removed ones, which the listing may not show yet in stores
+// with eventually consistent listings.
+func (s *Service) reindexChanged(ctx context.Context, appName, userID, sessionID, fileName string, added, removed []int64) {
+	s.forgetListing(appName, userID, sessionID, fileName)
+	s.forgetLoads()
+	if !s.index {
+		return
+	}
+	key := s.indexKey(appName, userID, sessionID, fileName)
+	resp, err := s.listVersions(ctx, &artifact.VersionsRequest{
+		AppName: appName, UserID: userID, SessionID: sessionID, FileName: fileName,
+	})
+	if err == nil {
+		resp.Versions, err = s.unlistedVersions(ctx, appName, userID, sessionID, fileName, resp.Versions, added, removed)
+	}
+	if err == nil && len(resp.Versions) > 0 {
+		data, _ := json.Marshal(versionsIndex{Versions: resp.Versions})
+		err = s.bucket.WriteAll(ctx, key, data, &blob.WriterOptions{ContentType: "application/json"})
+		if err == nil {
+			return
+		}
+	}
+	// Without a listing, a stale entry is worse than none.
+	if derr := s.bucket.Delete(ctx, key); derr != nil && gcerrors.Code(derr) != gcerrors.NotFound && err == nil {
+		err = derr
+	}
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to update versions index", "key", key, "error", err)
+	}
+}
+
+// unlistedVersions adds to the listed versions of an artifact those the
+// listing may not show yet: the added ones, and the indexed ones that still
+// exist. It removes the removed ones, which it may still show.
+func (s *Service) unlistedVersions(ctx context.Context, appName, userID, sessionID, fileName string, listed, added, removed []int64) ([]int64, error) {
+	versions := append(slices.Clone(listed), added...)
+	indexed, _ := s.readIndex(ctx, appName, userID, sessionID, fileName)
+	for _, v := range indexed {
+		if slices.Contains(versions, v) || slices.Contains(removed, v) {
+			continue
+		}
+		// Only the versions in the index but not in the listing are
+		// checked, usually none or the few written in the last seconds.
+		ok, err := s.bucket.Exists(ctx, s.buildKey(appName, userID, sessionID, fileName, v))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			versions = append(versions, v)
+		}
+	}
+	versions = slices.DeleteFunc(versions, func(v int64) bool { return slices.Contains(removed, v) })
+	slices.Sort(versions)
+	return slices.Compact(versions), nil
+}
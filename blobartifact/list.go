@@ -0,0 +1,100 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"gocloud.dev/blob"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.DetailedLister = (*Service)(nil)
+
+// ListDetailed implements [artifactx.DetailedLister] with the listings List
+// makes: the size and modification time of the latest version of every
+// file come with its object, without fetching the attributes of any.
+func (s *Service) ListDetailed(ctx context.Context, req *artifactx.ListDetailedRequest) ([]*artifactx.FileInfo, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return nil, err
+	}
+	if err := artifactx.CheckSessionID(s.keys, req.SessionID, ""); err != nil {
+		return nil, err
+	}
+	latest := map[string]*artifactx.FileInfo{}
+	for _, prefix := range s.keys.ListPrefixes(req.AppName, req.UserID, req.SessionID) {
+		iter := s.bucket.List(&blob.ListOptions{Prefix: prefix})
+		for {
+			obj, err := iter.Next(ctx)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error iterating objects: %w", err)
+			}
+			ref, err := s.keys.ParseKey(obj.Key)
+			if err != nil {
+				continue
+			}
+			// Keys are listed in lexicographic order, where "10" sorts
+			// before "2": compare the versions.
+			if f := latest[ref.FileName]; f == nil || ref.Version > f.Version {
+				latest[ref.FileName] = &artifactx.FileInfo{FileName: ref.FileName, Version: ref.Version, Size: obj.Size, ModTime: obj.ModTime}
+			}
+		}
+	}
+	return artifactx.SortFiles(slices.Collect(maps.Values(latest)), req.Order, req.Limit), nil
+}
+
+var _ artifactx.RecentLister = (*Service)(nil)
+
+// ListRecent implements [artifactx.RecentLister] with one listing of the
+// prefix of the user. It fails with [errors.ErrUnsupported] if the key
+// layout is not an [artifactx.HierarchyKeyBuilder].
+func (s *Service) ListRecent(ctx context.Context, appName, userID string, limit int) ([]*artifactx.RecentArtifact, error) {
+	prefix, err := artifactx.SessionsPrefix(s.keys, appName, userID)
+	if err != nil {
+		return nil, err
+	}
+	latest := map[artifactx.Ref]*artifactx.RecentArtifact{}
+	iter := s.bucket.List(&blob.ListOptions{Prefix: prefix})
+	for {
+		obj, err := iter.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error iterating objects: %w", err)
+		}
+		ref, err := s.keys.ParseKey(obj.Key)
+		if err != nil {
+			continue
+		}
+		artifactRef := ref
+		artifactRef.Version = 0
+		if a := latest[artifactRef]; a == nil || ref.Version > a.Version {
+			latest[artifactRef] = &artifactx.RecentArtifact{Ref: ref, Size: obj.Size, ModTime: obj.ModTime}
+		}
+	}
+	return artifactx.SortRecent(slices.Collect(maps.Values(latest)), limit), nil
+}
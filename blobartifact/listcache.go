@@ -0,0 +1,127 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/adk/artifact"
+)
+
+// The listing cache of [WithListingCache] keeps the versions listed for
+// Versions and for resolving the latest version. Concurrent listings of
+// the same artifact share a single LIST request, and their result is
+// reused until the TTL expires. The service drops the entry of an artifact
+// whenever it changes its versions, so that its own writes are always seen;
+// writes of other processes are seen once the entry expires. Save and the
+// other writes number versions from a fresh listing.
+
+// maxListingEntries bounds the entries of the listing cache; expired ones
+// are dropped beyond it, and all of them if none expired.
+const maxListingEntries = 10000
+
+type listingEntry struct {
+	versions []int64
+	expires  time.Time
+}
+
+type listingCache struct {
+	ttl   time.Duration
+	group singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]listingEntry
+	// gen counts the invalidations, so that a listing that started before
+	// one does not fill the cache with what it invalidated.
+	gen uint64
+}
+
+// cachedVersions lists the versions of an artifact through the listing
+// cache.
+func (s *Service) cachedVersions(ctx context.Context, req *artifact.VersionsRequest) (*artifact.VersionsResponse, error) {
+	c := s.listings
+	key := s.buildKeyPrefix(req.AppName, req.UserID, req.SessionID, req.FileName)
+	now := s.clock.Now()
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	gen := c.gen
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return &artifact.VersionsResponse{Versions: slices.Clone(e.versions)}, nil
+	}
+
+	// The shared listing must not fail because the caller that started it
+	// went away; every caller still stops waiting when its ctx is done.
+	ch := c.group.DoChan(key, func() (any, error) {
+		resp, err := s.listVersions(context.WithoutCancel(ctx), req)
+		if err != nil {
+			return nil, err
+		}
+		if c.ttl > 0 {
+			now := s.clock.Now()
+			c.store(key, resp.Versions, now, now.Add(c.ttl), gen)
+		}
+		return resp.Versions, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return &artifact.VersionsResponse{Versions: slices.Clone(res.Val.([]int64))}, nil
+	}
+}
+
+// store caches the versions listed for key unless the cache was
+// invalidated since gen.
+func (c *listingCache) store(key string, versions []int64, now, expires time.Time, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.gen != gen {
+		return
+	}
+	if len(c.entries) >= maxListingEntries {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxListingEntries {
+			clear(c.entries)
+		}
+	}
+	c.entries[key] = listingEntry{versions: versions, expires: expires}
+}
+
+// forgetListing drops the cached versions of an artifact after a change to
+// them, and makes the next listing of it start afresh.
+func (s *Service) forgetListing(appName, userID, sessionID, fileName string) {
+	c := s.listings
+	if c == nil {
+		return
+	}
+	key := s.buildKeyPrefix(appName, userID, sessionID, fileName)
+	c.mu.Lock()
+	c.gen++
+	delete(c.entries, key)
+	c.mu.Unlock()
+	c.group.Forget(key)
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// The shared loads of [WithSharedLoads] key every read by the object of the
+// resolved version, so that concurrent loads of the same version make a
+// single GET and decode a single buffer. Loads of the latest version
+// resolve it first, and share only once they agree on it. Nothing is kept
+// once the read completes.
+
+type sharedLoads struct {
+	group singleflight.Group
+	// gen counts the changes to versions, so that a load starting after
+	// one never joins a read that started before it.
+	gen atomic.Uint64
+}
+
+// sharedLoad loads the object at key, sharing the read with the concurrent
+// loads of it.
+func (s *Service) sharedLoad(ctx context.Context, key string) (*genai.Part, error) {
+	l := s.loads
+	// The load size limit comes from the context, so loads with different
+	// limits do not share.
+	flight := fmt.Sprintf("%d/%d/%s", l.gen.Load(), artifactx.MaxLoadSize(ctx, s.maxLoad), key)
+	// The shared read must not fail because the caller that started it went
+	// away; every caller still stops waiting when its ctx is done.
+	ch := l.group.DoChan(flight, func() (any, error) {
+		return hedge(context.WithoutCancel(ctx), s.hedgeDelay, func(ctx context.Context) (*genai.Part, error) {
+			return s.load(ctx, key)
+		})
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return sharePart(res.Val.(*genai.Part)), nil
+	}
+}
+
+// sharePart returns a copy of part for one of the loads sharing it. The
+// structs are copied, so that a caller setting their fields does not affect
+// the others, but the data is not.
+func sharePart(part *genai.Part) *genai.Part {
+	p := *part
+	if part.InlineData != nil {
+		blob := *part.InlineData
+		p.InlineData = &blob
+	}
+	if part.FileData != nil {
+		fd := *part.FileData
+		p.FileData = &fd
+	}
+	return &p
+}
+
+// forgetLoads keeps loads starting after a change to versions from joining
+// the reads that started before it.
+func (s *Service) forgetLoads() {
+	if s.loads != nil {
+		s.loads.gen.Add(1)
+	}
+}
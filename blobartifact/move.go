@@ -0,0 +1,80 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"gocloud.dev/gcerrors"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.Mover = (*Service)(nil)
+
+// Move implements [artifactx.Mover] with server side copies. The source
+// versions are deleted once all of them are copied; a failure before leaves
+// the source intact and may leave a partial copy behind.
+func (s *Service) Move(ctx context.Context, req *artifactx.MoveRequest) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("request validation failed: %w", err)
+	}
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return err
+	}
+	if err := artifactx.CheckSessionID(s.keys, req.SessionID, req.NewFileName); err != nil {
+		return err
+	}
+	resp, err := s.listVersions(ctx, &artifact.VersionsRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+	})
+	if err != nil {
+		return err
+	}
+	if len(resp.Versions) == 0 {
+		return fmt.Errorf("artifact not found: %w", fs.ErrNotExist)
+	}
+	existing, err := s.listVersions(ctx, &artifact.VersionsRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.NewFileName,
+	})
+	if err != nil {
+		return err
+	}
+	if len(existing.Versions) > 0 {
+		return fmt.Errorf("artifact %q: %w", req.NewFileName, fs.ErrExist)
+	}
+
+	defer s.reindex(ctx, req.AppName, req.UserID, req.SessionID, req.FileName)
+	defer s.reindex(ctx, req.AppName, req.UserID, req.SessionID, req.NewFileName)
+	for _, v := range resp.Versions {
+		src := s.buildKey(req.AppName, req.UserID, req.SessionID, req.FileName, v)
+		dst := s.buildKey(req.AppName, req.UserID, req.SessionID, req.NewFileName, v)
+		if err := s.bucket.Copy(ctx, dst, src, nil); err != nil {
+			return fmt.Errorf("failed to copy %q: %w", src, err)
+		}
+	}
+	var errs []error
+	for _, v := range resp.Versions {
+		key := s.buildKey(req.AppName, req.UserID, req.SessionID, req.FileName, v)
+		if err := s.bucket.Delete(ctx, key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			errs = append(errs, fmt.Errorf("failed to delete %q: %w", key, err))
+		}
+	}
+	return errors.Join(errs...)
+}
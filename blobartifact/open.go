@@ -0,0 +1,169 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var (
+	_ artifactx.Stater        = (*Service)(nil)
+	_ artifactx.Opener        = (*Service)(nil)
+	_ artifactx.RangeOpener   = (*Service)(nil)
+	_ artifactx.VersionLister = (*Service)(nil)
+)
+
+// newReader opens key, mapping missing objects to [fs.ErrNotExist] and other
+// failures through the [WithReadError] hook.
+func (s *Service) newReader(ctx context.Context, key string) (*blob.Reader, error) {
+	return s.newRangeReader(ctx, key, 0, -1)
+}
+
+// newRangeReader is newReader for length bytes of key from offset.
+func (s *Service) newRangeReader(ctx context.Context, key string, offset, length int64) (*blob.Reader, error) {
+	reader, err := s.bucket.NewRangeReader(ctx, key, offset, length, nil)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, fmt.Errorf("artifact '%s' not found: %w", key, fs.ErrNotExist)
+		}
+		if s.readError != nil {
+			if mapped := s.readError(key, err); mapped != nil {
+				return nil, mapped
+			}
+		}
+		return nil, fmt.Errorf("could not get object '%s': %w", key, err)
+	}
+	return reader, nil
+}
+
+// Stat implements [artifactx.Stater].
+func (s *Service) Stat(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Attributes, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	key, version, err := s.resolveKey(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.attributes(ctx, key, version)
+}
+
+func (s *Service) attributes(ctx context.Context, key string, version int64) (*artifactx.Attributes, error) {
+	attrs, err := s.bucket.Attributes(ctx, key)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, fmt.Errorf("artifact '%s' not found: %w", key, fs.ErrNotExist)
+		}
+		return nil, fmt.Errorf("could not get attributes of object '%s': %w", key, err)
+	}
+	m := artifactx.MetaFromObject(cmp.Or(attrs.ContentType, s.defaultContentType), attrs.Metadata)
+	created := m.Created
+	if created.IsZero() {
+		created = attrs.ModTime
+	}
+	etag := m.ETag()
+	if etag == "" {
+		etag = attrs.ETag
+	}
+	return &artifactx.Attributes{
+		Version:     version,
+		ContentType: m.ContentType,
+		Size:        attrs.Size,
+		ModTime:     attrs.ModTime,
+		Created:     created,
+		Metadata:    m.Metadata,
+		ETag:        etag,
+		Pinned:      m.Pinned,
+		Immutable:   m.Immutable,
+	}, nil
+}
+
+// ListVersions implements [artifactx.VersionLister]. The attributes of the
+// selected versions are fetched concurrently.
+func (s *Service) ListVersions(ctx context.Context, req *artifactx.ListVersionsRequest) ([]*artifactx.Attributes, error) {
+	resp, err := s.Versions(ctx, req.VersionsRequest())
+	if err != nil {
+		return nil, err
+	}
+	versions := artifactx.LatestVersions(resp.Versions, req.Limit)
+	attrs := make([]*artifactx.Attributes, len(versions))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(8)
+	for i, v := range versions {
+		g.Go(func() error {
+			a, err := s.attributes(gctx, s.buildKey(req.AppName, req.UserID, req.SessionID, req.FileName, v), v)
+			if errors.Is(err, fs.ErrNotExist) {
+				// deleted since listing
+				return nil
+			}
+			attrs[i] = a
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(attrs, func(a *artifactx.Attributes) bool { return a == nil }), nil
+}
+
+// Open implements [artifactx.Opener].
+func (s *Service) Open(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Reader, error) {
+	attrs, err := s.Stat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	key := s.buildKey(req.AppName, req.UserID, req.SessionID, req.FileName, attrs.Version)
+	reader, err := s.newReader(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &artifactx.Reader{ReadCloser: reader, Attributes: *attrs}, nil
+}
+
+// OpenRange implements [artifactx.RangeOpener]. Only the requested range
+// is downloaded.
+func (s *Service) OpenRange(ctx context.Context, req *artifact.LoadRequest, offset, length int64) (*artifactx.Reader, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid offset %d", offset)
+	}
+	attrs, err := s.Stat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if offset >= attrs.Size || length == 0 {
+		// Stores reject ranges past the end of an object.
+		return &artifactx.Reader{ReadCloser: io.NopCloser(strings.NewReader("")), Attributes: *attrs}, nil
+	}
+	key := s.buildKey(req.AppName, req.UserID, req.SessionID, req.FileName, attrs.Version)
+	reader, err := s.newRangeReader(ctx, key, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	return &artifactx.Reader{ReadCloser: reader, Attributes: *attrs}, nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gocloud.dev/blob"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Option configures a service created with [New] or [NewService].
+type Option func(*Service)
+
+// WithPrefix keeps all objects of the service below prefix, such as
+// "adk/prod/", so that it can share a bucket with other data. A "/" is
+// appended to prefix if it does not end with one.
+//
+// The bucket is wrapped with [blob.PrefixedBucket], so a bucket passed to
+// [New] is taken over by the service and closed with it.
+func WithPrefix(prefix string) Option {
+	return func(s *Service) {
+		if prefix != "" && !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+		s.prefix = prefix
+	}
+}
+
+// WithLogger sets the logger for failures of best effort work, such as
+// removing expired versions. The default is [slog.Default].
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Service) {
+		s.logger = logger
+	}
+}
+
+// WithKeyBuilder sets the layout of object keys. The default is
+// [artifactx.DefaultKeyBuilder].
+func WithKeyBuilder(kb artifactx.KeyBuilder) Option {
+	return func(s *Service) {
+		s.keys = kb
+	}
+}
+
+// WithClock sets the clock stamping saved versions and driving retention.
+// The default is [artifactx.SystemClock]. Buckets set their own object
+// times, so the stamp is kept in the object metadata and reported as
+// [artifactx.Attributes.Created].
+func WithClock(c artifactx.Clock) Option {
+	return func(s *Service) {
+		s.clock = c
+	}
+}
+
+// WithRetention removes the versions r does not keep after every Save.
+func WithRetention(r artifactx.Retention) Option {
+	return func(s *Service) {
+		s.retention = r
+	}
+}
+
+// WithRangedDownload makes Load fetch objects of threshold bytes or more as
+// concurrent byte ranges of partSize bytes, at most concurrency at a time.
+// A zero threshold disables ranged downloads. The default is 16MiB ranges,
+// 8 at a time, for objects from 64MiB.
+func WithRangedDownload(threshold, partSize int64, concurrency int) Option {
+	return func(s *Service) {
+		s.ranged = rangedDownload{threshold: threshold, partSize: partSize, concurrency: max(concurrency, 1)}
+	}
+}
+
+// WithMaxLoadSize makes Load fail with [artifactx.ErrTooLarge] for objects
+// of more than n bytes instead of reading them into memory. Zero, the
+// default, means no limit; [artifactx.WithMaxLoadSize] lowers it per request.
+func WithMaxLoadSize(n int64) Option {
+	return func(s *Service) {
+		s.maxLoad = n
+	}
+}
+
+// WithHedgedLoad makes Load start a second attempt if the first has not
+// completed after delay, and return whichever completes first, cancelling
+// the other. It trades some extra requests for a lower tail latency. Zero,
+// the default, disables hedging.
+func WithHedgedLoad(delay time.Duration) Option {
+	return func(s *Service) {
+		s.hedgeDelay = delay
+	}
+}
+
+// WithVersionsIndex keeps an index object with the versions of every
+// artifact, updated on every write, so that Versions and loading the latest
+// version read it with a single GET instead of listing the bucket. It cuts
+// the latency of agents loading the same artifact every turn, at the cost
+// of two requests more per write.
+func WithVersionsIndex() Option {
+	return func(s *Service) {
+		s.index = true
+	}
+}
+
+// WithListingCache caches the versions listed for Versions and for loading
+// the latest version for ttl, and makes concurrent listings of the same
+// artifact share a single LIST request, so that a burst of loads of one
+// artifact lists it once. A zero ttl only shares concurrent listings.
+// Changes made through the service are seen at once, changes made by other
+// processes after at most ttl.
+func WithListingCache(ttl time.Duration) Option {
+	return func(s *Service) {
+		s.listings = &listingCache{ttl: ttl, entries: map[string]listingEntry{}}
+	}
+}
+
+// WithSharedLoads makes concurrent loads of the same version share a single
+// read of its object, so that agents fanning out over one artifact fetch
+// and buffer it once. The loads get their own Part, but share its data,
+// which callers must not modify. Loads starting after a change made
+// through the service never share a read that started before it.
+func WithSharedLoads() Option {
+	return func(s *Service) {
+		s.loads = &sharedLoads{}
+	}
+}
+
+// WithBulkDelete sets how many versions deleting all the versions of an
+// artifact deletes at once, [DefaultDeleteConcurrency] unless set, and what
+// it does when one of them fails. With [DeleteBestEffort], the error joins
+// all the failures and the versions that could be deleted are gone; the
+// archive of compacted versions is kept if any version failed.
+func WithBulkDelete(concurrency int, policy DeleteErrorPolicy) Option {
+	return func(s *Service) {
+		if concurrency > 0 {
+			s.deleteConcurrency = concurrency
+		}
+		s.deletePolicy = policy
+	}
+}
+
+// WithChecksumAlgorithm makes Save record the checksum of every version with
+// a instead of SHA-256, e.g. [artifactx.ChecksumBLAKE3] to hash large
+// versions faster. Checksums of [artifactx.ChecksumCRC32C] are not reported
+// as ETags, which then come from the bucket.
+//
+// A nil a disables checksums computed by the service, for buckets that
+// verify uploads with checksums of their own, such as those of
+// s3artifact.WithServerChecksum.
+func WithChecksumAlgorithm(a *artifactx.ChecksumAlgorithm) Option {
+	return func(s *Service) {
+		s.checksum = a
+	}
+}
+
+// WithDefaultContentType sets the content type of versions saved without
+// one, and of objects without one, instead of the type the bucket detects
+// from their content. The charset of text is recorded as by
+// [artifactx.WithCharset].
+func WithDefaultContentType(contentType string) Option {
+	return func(s *Service) {
+		s.defaultContentType = contentType
+	}
+}
+
+// WithWriterOptions calls fn with the options of every version written, after
+// the content type and metadata are set, so that a driver specific wrapper
+// can add its own settings.
+func WithWriterOptions(fn func(ctx context.Context, opts *blob.WriterOptions)) Option {
+	return func(s *Service) {
+		s.writerOptions = fn
+	}
+}
+
+// WithReadError calls fn with the errors opening version objects other than
+// missing ones. A non-nil result is returned instead of the generic error, so
+// that a driver specific wrapper can report conditions such as archived
+// objects.
+func WithReadError(fn func(key string, err error) error) Option {
+	return func(s *Service) {
+		s.readError = fn
+	}
+}
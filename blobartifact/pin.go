@@ -0,0 +1,107 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.Pinner = (*Service)(nil)
+
+// Pin implements [artifactx.Pinner]. The pin is recorded in the metadata of
+// the object, which buckets only allow to change by rewriting it: pinning
+// copies the version through the service.
+func (s *Service) Pin(ctx context.Context, req *artifact.LoadRequest) error {
+	return s.setPinned(ctx, req, true)
+}
+
+// Unpin implements [artifactx.Pinner].
+func (s *Service) Unpin(ctx context.Context, req *artifact.LoadRequest) error {
+	return s.setPinned(ctx, req, false)
+}
+
+func (s *Service) setPinned(ctx context.Context, req *artifact.LoadRequest, pinned bool) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("request validation failed: %w", err)
+	}
+	key, _, err := s.resolveKey(ctx, req)
+	if err != nil {
+		return err
+	}
+	attrs, err := s.bucket.Attributes(ctx, key)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return fmt.Errorf("artifact '%s' not found: %w", key, fs.ErrNotExist)
+		}
+		return fmt.Errorf("could not get attributes of object '%s': %w", key, err)
+	}
+	if _, was := artifactx.SplitPinned(attrs.Metadata); was == pinned {
+		return nil
+	}
+	// Keep the creation time, the checksum and the other metadata.
+	return s.rewrite(ctx, key, &blob.WriterOptions{
+		ContentType: attrs.ContentType,
+		Metadata:    artifactx.WithPinned(attrs.Metadata, pinned),
+	})
+}
+
+// rewrite writes the object at key again with opts, the only way buckets
+// allow to change its metadata.
+func (s *Service) rewrite(ctx context.Context, key string, opts *blob.WriterOptions) error {
+	reader, err := s.newReader(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	if s.writerOptions != nil {
+		s.writerOptions(ctx, opts)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w, err := s.bucket.NewWriter(ctx, key, opts)
+	if err != nil {
+		return fmt.Errorf("failed to rewrite object '%s': %w", key, err)
+	}
+	if _, err := io.Copy(w, reader); err != nil {
+		cancel() // discard the partial write
+		w.Close()
+		return fmt.Errorf("failed to rewrite object '%s': %w", key, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to rewrite object '%s': %w", key, err)
+	}
+	return nil
+}
+
+// isPinned reports whether the object at key is pinned. Objects whose
+// attributes cannot be read are reported as pinned, so that cleanup leaves
+// them alone, unless they no longer exist.
+func (s *Service) isPinned(ctx context.Context, key string) bool {
+	attrs, err := s.bucket.Attributes(ctx, key)
+	if err != nil {
+		return gcerrors.Code(err) != gcerrors.NotFound
+	}
+	_, pinned := artifactx.SplitPinned(attrs.Metadata)
+	return pinned
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"gocloud.dev/blob"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// rangedDownload configures how Load splits large objects into byte ranges
+// fetched concurrently, like the S3 download manager does.
+type rangedDownload struct {
+	// threshold is the object size from which ranges are used.
+	threshold int64
+	// partSize is the size of every range but the last.
+	partSize int64
+	// concurrency is the number of ranges fetched at once.
+	concurrency int
+}
+
+var defaultRangedDownload = rangedDownload{
+	threshold:   64 << 20,
+	partSize:    16 << 20,
+	concurrency: 8,
+}
+
+// readAll reads the whole object key. reader must be positioned at the start
+// of the object; it is used for the first range.
+func (d rangedDownload) readAll(ctx context.Context, bucket *blob.Bucket, key string, reader *blob.Reader) ([]byte, error) {
+	size := reader.Size()
+	if d.threshold <= 0 || size < d.threshold || size <= d.partSize {
+		return artifactx.ReadAllSize(reader, size, 0)
+	}
+
+	data := make([]byte, size)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(d.concurrency)
+	g.Go(func() error {
+		if _, err := io.ReadFull(reader, data[:d.partSize]); err != nil {
+			return fmt.Errorf("failed to read range 0-%d: %w", d.partSize-1, err)
+		}
+		return nil
+	})
+	for off := d.partSize; off < size; off += d.partSize {
+		part := data[off:min(off+d.partSize, size)]
+		g.Go(func() error {
+			r, err := bucket.NewRangeReader(gctx, key, off, int64(len(part)), nil)
+			if err != nil {
+				return fmt.Errorf("failed to open range %d-%d: %w", off, off+int64(len(part))-1, err)
+			}
+			defer r.Close()
+			if _, err := io.ReadFull(r, part); err != nil {
+				return fmt.Errorf("failed to read range %d-%d: %w", off, off+int64(len(part))-1, err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
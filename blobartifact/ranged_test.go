@@ -0,0 +1,58 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"gocloud.dev/blob/memblob"
+)
+
+func TestRangedDownload(t *testing.T) {
+	ctx := t.Context()
+	bucket := memblob.OpenBucket(nil)
+	var data []byte
+	for i := range 1000 {
+		data = fmt.Appendf(data, "%04d", i)
+	}
+	if err := bucket.WriteAll(ctx, "key", data, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range []rangedDownload{
+		{threshold: 100, partSize: 333, concurrency: 4},  // uneven last range
+		{threshold: 100, partSize: 1000, concurrency: 2}, // exact ranges
+		{threshold: 100, partSize: 7, concurrency: 1},    // sequential
+		{threshold: 1 << 20, partSize: 100, concurrency: 4},
+		defaultRangedDownload,
+	} {
+		t.Run(fmt.Sprintf("%+v", d), func(t *testing.T) {
+			reader, err := bucket.NewReader(ctx, "key", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer reader.Close()
+			got, err := d.readAll(ctx, bucket, "key", reader)
+			if err != nil {
+				t.Fatalf("readAll() failed: %v", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Error("readAll() returned different data")
+			}
+		})
+	}
+}
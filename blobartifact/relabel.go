@@ -0,0 +1,62 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.Relabeler = (*Service)(nil)
+
+// Relabel implements [artifactx.Relabeler]. Like [Service.Pin], it rewrites
+// the object, keeping its creation time and checksum.
+func (s *Service) Relabel(ctx context.Context, req *artifact.LoadRequest, change *artifactx.MetadataChange) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("request validation failed: %w", err)
+	}
+	if err := change.Validate(); err != nil {
+		return fmt.Errorf("invalid metadata change: %w", err)
+	}
+	key, _, err := s.resolveKey(ctx, req)
+	if err != nil {
+		return err
+	}
+	attrs, err := s.bucket.Attributes(ctx, key)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return fmt.Errorf("artifact '%s' not found: %w", key, fs.ErrNotExist)
+		}
+		return fmt.Errorf("could not get attributes of object '%s': %w", key, err)
+	}
+	m := artifactx.MetaFromObject(attrs.ContentType, attrs.Metadata)
+	md, changed := change.Apply(m.Metadata)
+	if !changed {
+		return nil
+	}
+	m.Metadata = md
+	m.Upgrade()
+	if m.Created.IsZero() {
+		m.Created = attrs.ModTime // the rewrite changes ModTime
+	}
+	return s.rewrite(ctx, key, &blob.WriterOptions{ContentType: m.ContentType, Metadata: m.ObjectMetadata()})
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"io"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var (
+	_ artifactx.RetentionSetter   = (*Service)(nil)
+	_ artifactx.RetentionReporter = (*Service)(nil)
+	_ artifactx.Pruner            = (*Service)(nil)
+)
+
+// SetRetention implements [artifactx.RetentionSetter].
+func (s *Service) SetRetention(r artifactx.Retention) {
+	s.retentionMu.Lock()
+	defer s.retentionMu.Unlock()
+	s.retention = r
+}
+
+// Retention implements [artifactx.RetentionReporter].
+func (s *Service) Retention() (artifactx.Retention, artifactx.Clock) {
+	return s.currentRetention(), s.clock
+}
+
+func (s *Service) currentRetention() artifactx.Retention {
+	s.retentionMu.RLock()
+	defer s.retentionMu.RUnlock()
+	return s.retention
+}
+
+// Prune implements [artifactx.Pruner] by applying the retention to every
+// artifact. Failures to delete versions are logged, as they are on Save.
+func (s *Service) Prune(ctx context.Context) (int, error) {
+	if s.currentRetention() == (artifactx.Retention{}) {
+		return 0, nil
+	}
+	refs, err := artifactx.Artifacts(ctx, s)
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, ref := range refs {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		if n := s.applyRetention(ctx, ref.AppName, ref.UserID, ref.SessionID, ref.FileName); n > 0 {
+			s.reindex(ctx, ref.AppName, ref.UserID, ref.SessionID, ref.FileName)
+			deleted += n
+		}
+	}
+	return deleted, nil
+}
+
+// applyRetention deletes the versions of an artifact that the retention
+// policy does not keep and returns how many it deleted. Failures are
+// logged: the Save that triggered it already succeeded.
+func (s *Service) applyRetention(ctx context.Context, appName, userID, sessionID, fileName string) int {
+	retention := s.currentRetention()
+	if retention == (artifactx.Retention{}) {
+		return 0
+	}
+	prefix := s.buildKeyPrefix(appName, userID, sessionID, fileName)
+	iter := s.bucket.List(&blob.ListOptions{Prefix: prefix})
+	var versions []artifactx.VersionInfo
+	for {
+		obj, err := iter.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to list versions for retention", "prefix", prefix, "error", err)
+			return 0
+		}
+		ref, err := s.keys.ParseKey(obj.Key)
+		if err != nil {
+			continue
+		}
+		modTime := obj.ModTime
+		if retention.MaxAge > 0 {
+			// Listing only reports the time the storage wrote the object.
+			if attrs, err := s.bucket.Attributes(ctx, obj.Key); err == nil {
+				_, modTime = artifactx.SplitCreated(attrs.Metadata, obj.ModTime)
+			}
+		}
+		versions = append(versions, artifactx.VersionInfo{Version: ref.Version, ModTime: modTime})
+	}
+	// Only the versions to expire are checked for pins, which otherwise
+	// takes a request per version.
+	deleted := 0
+	for _, v := range retention.Expired(versions, s.clock.Now()) {
+		key := s.buildKey(appName, userID, sessionID, fileName, v)
+		if s.isPinned(ctx, key) || s.dryRun(ctx, key, artifactx.ByRetention) {
+			continue
+		}
+		if err := s.bucket.Delete(ctx, key); err != nil {
+			if gcerrors.Code(err) != gcerrors.NotFound {
+				s.logger.WarnContext(ctx, "failed to delete expired version", "key", key, "error", err)
+			}
+			continue
+		}
+		deleted++
+	}
+	return deleted
+}
+
+// dryRun reports the object at key to the function attached to ctx with
+// [artifactx.WithDryRun], if any, and whether it did so and the object must
+// be kept.
+func (s *Service) dryRun(ctx context.Context, key string, reason artifactx.RemovalReason) bool {
+	report, ok := artifactx.DryRunFrom(ctx)
+	if !ok {
+		return false
+	}
+	if ref, err := s.keys.ParseKey(key); err == nil {
+		report(artifactx.Removal{Ref: ref, Reason: reason})
+	}
+	return true
+}
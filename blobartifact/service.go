@@ -0,0 +1,578 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package blobartifact provides an [artifact.Service] for any bucket of the
+// Go Cloud Development Kit (CDK), such as GCS, Azure Blob Storage, S3, or the
+// in-memory and file system buckets used in tests.
+//
+// Artifacts are organized by application name, user ID, session ID, and
+// filename, with support for versioning. Import the blob driver of the bucket
+// for its URL scheme to be registered:
+//
+//	import _ "gocloud.dev/blob/gcsblob"
+//
+//	srv, err := blobartifact.NewService(ctx, "gs://my-bucket")
+package blobartifact
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Service is an [artifact.Service] storing artifacts in a [blob.Bucket].
+type Service struct {
+	bucket        *blob.Bucket
+	keys          artifactx.KeyBuilder
+	logger        *slog.Logger
+	ranged        rangedDownload
+	clock         artifactx.Clock
+	prefix        string
+	writerOptions func(context.Context, *blob.WriterOptions)
+	readError     func(key string, err error) error
+	maxLoad       int64
+	hedgeDelay    time.Duration
+	index         bool
+	listings      *listingCache
+	loads         *sharedLoads
+	// deleteConcurrency and deletePolicy control deleting all the
+	// versions of an artifact.
+	deleteConcurrency int
+	deletePolicy      DeleteErrorPolicy
+	checksum          *artifactx.ChecksumAlgorithm
+	// defaultContentType is the content type of versions saved without
+	// one, or empty to have the bucket detect it.
+	defaultContentType string
+	// retentionMu guards retention, which can change at runtime.
+	retentionMu sync.RWMutex
+	retention   artifactx.Retention
+}
+
+// NewService opens the bucket at urlstr with [blob.OpenBucket] and returns a
+// service for it, configured by opts.
+func NewService(ctx context.Context, urlstr string, opts ...Option) (*Service, error) {
+	bucket, err := blob.OpenBucket(ctx, urlstr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bucket: %w", err)
+	}
+	return New(bucket, opts...), nil
+}
+
+// New returns a service storing artifacts in bucket, configured by opts.
+func New(bucket *blob.Bucket, opts ...Option) *Service {
+	s := &Service{
+		keys:              artifactx.DefaultKeyBuilder,
+		logger:            slog.Default(),
+		ranged:            defaultRangedDownload,
+		clock:             artifactx.SystemClock,
+		checksum:          artifactx.ChecksumSHA256,
+		deleteConcurrency: DefaultDeleteConcurrency,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.prefix != "" {
+		bucket = blob.PrefixedBucket(bucket, s.prefix)
+	}
+	s.bucket = bucket
+	return s
+}
+
+// Bucket returns the bucket of the service, below the prefix if one is set.
+func (s *Service) Bucket() *blob.Bucket {
+	return s.bucket
+}
+
+// newWriterOptions returns the options for writing a version of contentType
+// with metadata md, marked immutable under [artifactx.WithImmutable] and
+// created at the time attached with [artifactx.WithCreatedTime].
+func (s *Service) newWriterOptions(ctx context.Context, contentType string, md map[string]string) *blob.WriterOptions {
+	opts := &blob.WriterOptions{
+		ContentType: contentType,
+		Metadata:    artifactx.WithCreated(md, artifactx.CreatedTimeFrom(ctx, s.clock.Now())),
+	}
+	opts.Metadata[artifactx.MetaFormatMetadataKey] = strconv.Itoa(artifactx.MetaFormat)
+	if artifactx.ImmutableFrom(ctx) {
+		opts.Metadata[artifactx.ImmutableMetadataKey] = "true"
+	}
+	if s.writerOptions != nil {
+		s.writerOptions(ctx, opts)
+	}
+	return opts
+}
+
+// buildKey constructs the key of a version.
+func (s *Service) buildKey(appName, userID, sessionID, fileName string, version int64) string {
+	return s.keys.VersionKey(appName, userID, sessionID, fileName, version)
+}
+
+func (s *Service) buildKeyPrefix(appName, userID, sessionID, fileName string) string {
+	return s.keys.ArtifactPrefix(appName, userID, sessionID, fileName)
+}
+
+// resolveKey returns the key of the requested version, resolving a zero
+// version to the latest one.
+func (s *Service) resolveKey(ctx context.Context, req *artifact.LoadRequest) (string, int64, error) {
+	version := req.Version
+	if version == 0 {
+		response, err := s.versions(ctx, &artifact.VersionsRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+		})
+		if err != nil {
+			return "", 0, fmt.Errorf("failed to list artifact versions: %w", err)
+		}
+		if len(response.Versions) == 0 {
+			return "", 0, fmt.Errorf("artifact not found: %w", fs.ErrNotExist)
+		}
+		version = slices.Max(response.Versions)
+	}
+	return s.buildKey(req.AppName, req.UserID, req.SessionID, req.FileName, version), version, nil
+}
+
+// Save implements [artifact.Service]
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (_ *artifact.SaveResponse, err error) {
+	err = artifactx.ValidateSave(req)
+	if err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return nil, err
+	}
+	if err := artifactx.CheckSessionID(s.keys, req.SessionID, req.FileName); err != nil {
+		return nil, err
+	}
+	appName, userID, sessionID, fileName := req.AppName, req.UserID, req.SessionID, req.FileName
+	newArtifact := req.Part
+
+	data, contentType, err := artifactx.EncodePart(newArtifact)
+	if err != nil {
+		return nil, err
+	}
+	var nextVersion int64
+	if req.Version > 0 {
+		// Overwrite the requested version, unless the latest is immutable.
+		if _, err := s.nextVersion(ctx, appName, userID, sessionID, fileName, 0); err != nil {
+			return nil, err
+		}
+		nextVersion = req.Version
+		err = s.writeVersion(ctx, s.buildKey(appName, userID, sessionID, fileName, nextVersion), data, contentType, false)
+	} else {
+		nextVersion, err = s.writeNextVersion(ctx, appName, userID, sessionID, fileName, func(key string) error {
+			return s.writeVersion(ctx, key, data, contentType, true)
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.applyRetention(ctx, appName, userID, sessionID, fileName)
+	s.reindexChanged(ctx, appName, userID, sessionID, fileName, []int64{nextVersion}, nil)
+	return &artifact.SaveResponse{Version: nextVersion}, nil
+}
+
+// maxVersionAttempts bounds the versions a save tries to create before
+// giving up on concurrent saves taking them.
+const maxVersionAttempts = 100
+
+// writeNextVersion creates the version after the latest with write, which
+// must fail with an error of code [gcerrors.FailedPrecondition] if the
+// object at key exists. Concurrent saves of the artifact, or a listing not
+// showing the latest versions yet, make it try the next version. Stores
+// ignoring If-None-Match let the write overwrite the version instead; see
+// [Service.CheckConditionalWrites].
+func (s *Service) writeNextVersion(ctx context.Context, appName, userID, sessionID, fileName string, write func(key string) error) (int64, error) {
+	var taken int64
+	for attempt := 1; ; attempt++ {
+		version, err := s.nextVersion(ctx, appName, userID, sessionID, fileName, taken)
+		if err != nil {
+			return 0, err
+		}
+		err = write(s.buildKey(appName, userID, sessionID, fileName, version))
+		if err == nil {
+			return version, nil
+		}
+		if gcerrors.Code(err) != gcerrors.FailedPrecondition {
+			return 0, err
+		}
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if attempt == maxVersionAttempts {
+			return 0, fmt.Errorf("failed to save artifact '%s' after %d attempts: %w", fileName, maxVersionAttempts, artifactx.ErrConflict)
+		}
+		taken = version
+	}
+}
+
+// nextVersion returns the version after both the latest listed and taken,
+// failing with [artifactx.ErrImmutable] if the latest is immutable.
+func (s *Service) nextVersion(ctx context.Context, appName, userID, sessionID, fileName string, taken int64) (int64, error) {
+	response, err := s.listVersions(ctx, &artifact.VersionsRequest{
+		AppName: appName, UserID: userID, SessionID: sessionID, FileName: fileName,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list artifact versions: %w", err)
+	}
+	var latest int64
+	if len(response.Versions) > 0 {
+		latest = slices.Max(response.Versions)
+		if err := s.checkMutable(ctx, s.buildKey(appName, userID, sessionID, fileName, latest), fileName); err != nil {
+			return 0, err
+		}
+	}
+	return max(latest, taken) + 1, nil
+}
+
+// writeVersion writes the object of a version at key, with the metadata
+// attached to ctx. With ifNotExist, it fails with an error of code
+// [gcerrors.FailedPrecondition] if the object exists. A failed write or a
+// cancelled ctx aborts the write, leaving no partial object.
+func (s *Service) writeVersion(ctx context.Context, key string, data []byte, contentType string, ifNotExist bool) error {
+	if contentType == "" {
+		contentType = artifactx.WithCharset(s.defaultContentType, data)
+	}
+	md := artifactx.MetadataFrom(ctx)
+	if s.checksum != nil {
+		md = s.checksum.WithChecksum(md, s.checksum.Sum(data))
+	}
+	opts := s.newWriterOptions(ctx, contentType, md)
+	opts.IfNotExist = ifNotExist
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w, err := s.bucket.NewWriter(wctx, key, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create writer: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		cancel() // abort the write
+		w.Close()
+		return fmt.Errorf("failed to write data: %w", err)
+	}
+	// Drivers abort the writes of cancelled contexts on Close; check it
+	// here too, so that no driver commits a version of a cancelled save.
+	if err := ctx.Err(); err != nil {
+		cancel()
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to close writer: %w", err)
+	}
+	return nil
+}
+
+// Delete implements [artifact.Service]
+func (s *Service) Delete(ctx context.Context, req *artifact.DeleteRequest) error {
+	err := req.Validate()
+	if err != nil {
+		return fmt.Errorf("request validation failed: %w", err)
+	}
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return err
+	}
+	appName, userID, sessionID, fileName := req.AppName, req.UserID, req.SessionID, req.FileName
+	version := req.Version
+	if _, ok := artifactx.DryRunFrom(ctx); ok {
+		return s.dryRunDelete(ctx, req)
+	}
+
+	// The deleted versions, which the listing may still show.
+	var removed []int64
+	defer func() { s.reindexChanged(ctx, appName, userID, sessionID, fileName, nil, removed) }()
+
+	// Delete specific version
+	if version != 0 {
+		key := s.buildKey(appName, userID, sessionID, fileName, version)
+		if err := s.bucket.Delete(ctx, key); err != nil {
+			if gcerrors.Code(err) == gcerrors.NotFound {
+				// Deleting non-existing entry is not an error
+				return nil
+			}
+			return fmt.Errorf("failed to delete artifact: %w", err)
+		}
+		removed = []int64{version}
+		return nil
+	}
+
+	// Delete all versions
+	response, err := s.listVersions(ctx, &artifact.VersionsRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch versions on delete artifact: %w", err)
+	}
+
+	keys := make([]string, len(response.Versions))
+	for i, v := range response.Versions {
+		keys[i] = s.buildKey(appName, userID, sessionID, fileName, v)
+	}
+	if err := s.deleteObjects(ctx, keys); err != nil {
+		return err
+	}
+	removed = response.Versions
+
+	// Delete the compacted versions too.
+	key := s.archiveKey(appName, userID, sessionID, fileName)
+	if err := s.bucket.Delete(ctx, key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		return fmt.Errorf("failed to delete archive %s: %w", key, err)
+	}
+	return nil
+}
+
+// dryRunDelete reports the versions Delete would remove.
+func (s *Service) dryRunDelete(ctx context.Context, req *artifact.DeleteRequest) error {
+	if req.Version != 0 {
+		key := s.buildKey(req.AppName, req.UserID, req.SessionID, req.FileName, req.Version)
+		ok, err := s.bucket.Exists(ctx, key)
+		if err != nil {
+			return fmt.Errorf("failed to check artifact: %w", err)
+		}
+		if ok {
+			s.dryRun(ctx, key, artifactx.ByDelete)
+		}
+		return nil
+	}
+	response, err := s.listVersions(ctx, &artifact.VersionsRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch versions on delete artifact: %w", err)
+	}
+	for _, v := range response.Versions {
+		s.dryRun(ctx, s.buildKey(req.AppName, req.UserID, req.SessionID, req.FileName, v), artifactx.ByDelete)
+	}
+	return nil
+}
+
+// Load implements [artifact.Service]
+func (s *Service) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	key, version, err := s.resolveKey(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := artifactx.CheckNewerThan(ctx, version); err != nil {
+		return nil, fmt.Errorf("object '%s': %w", key, err)
+	}
+	if artifactx.NeedsAttributes(ctx) {
+		attrs, err := s.attributes(ctx, key, version)
+		if err != nil {
+			return nil, err
+		}
+		if err := artifactx.CheckConditions(ctx, attrs); err != nil {
+			return nil, fmt.Errorf("object '%s': %w", key, err)
+		}
+	}
+
+	if s.loads != nil {
+		part, err := s.sharedLoad(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return &artifact.LoadResponse{Part: part}, nil
+	}
+	// Each attempt reads the whole object, so a hedged attempt also covers
+	// a slow body, not just a slow first byte.
+	part, err := hedge(ctx, s.hedgeDelay, func(ctx context.Context) (*genai.Part, error) {
+		return s.load(ctx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &artifact.LoadResponse{Part: part}, nil
+}
+
+// load reads and decodes the object at key.
+func (s *Service) load(ctx context.Context, key string) (*genai.Part, error) {
+	data, contentType, err := s.readObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the genai.Part.
+	part, err := artifactx.DecodePart(data, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode object '%s': %w", key, err)
+	}
+	return part, nil
+}
+
+// readObject returns the content and content type of the object at key,
+// within the load size limit.
+func (s *Service) readObject(ctx context.Context, key string) (_ []byte, _ string, err error) {
+	reader, err := s.newReader(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		if closeErr := reader.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close object reader: %w", closeErr)
+		}
+	}()
+
+	if err := artifactx.CheckLoadSize(reader.Size(), artifactx.MaxLoadSize(ctx, s.maxLoad)); err != nil {
+		return nil, "", fmt.Errorf("object '%s': %w", key, err)
+	}
+
+	// Read all the content into a byte slice, in parallel ranges for large objects
+	data, err := s.ranged.readAll(ctx, s.bucket, key, reader)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not read data from object '%s': %w", key, err)
+	}
+	return data, cmp.Or(reader.ContentType(), s.defaultContentType), nil
+}
+
+// fetchFilenamesFromPrefix is a reusable helper function.
+func (s *Service) fetchFilenamesFromPrefix(ctx context.Context, prefix string, filenamesSet map[string]bool) error {
+	if filenamesSet == nil {
+		return fmt.Errorf("filenamesSet cannot be nil")
+	}
+
+	iter := s.bucket.List(&blob.ListOptions{
+		Prefix: prefix,
+	})
+
+	for {
+		obj, err := iter.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error iterating objects: %w", err)
+		}
+
+		ref, err := s.keys.ParseKey(obj.Key)
+		if err != nil {
+			// not an artifact version, e.g. nested below the artifacts of another layout
+			continue
+		}
+		filenamesSet[ref.FileName] = true
+	}
+
+	return nil
+}
+
+// List implements [artifact.Service]
+func (s *Service) List(ctx context.Context, req *artifact.ListRequest) (*artifact.ListResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return nil, err
+	}
+	if err := artifactx.CheckSessionID(s.keys, req.SessionID, ""); err != nil {
+		return nil, err
+	}
+	appName, userID, sessionID := req.AppName, req.UserID, req.SessionID
+	filenamesSet := map[string]bool{}
+
+	// Fetch filenames for the session and the user.
+	for _, prefix := range s.keys.ListPrefixes(appName, userID, sessionID) {
+		if err := s.fetchFilenamesFromPrefix(ctx, prefix, filenamesSet); err != nil {
+			return nil, fmt.Errorf("failed to fetch filenames: %w", err)
+		}
+	}
+
+	filenames := slices.Collect(maps.Keys(filenamesSet))
+	sort.Strings(filenames)
+	return &artifact.ListResponse{FileNames: filenames}, nil
+}
+
+// versions internal function that does not return error if versions are empty
+func (s *Service) versions(ctx context.Context, req *artifact.VersionsRequest) (*artifact.VersionsResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	if versions, ok := s.readIndex(ctx, req.AppName, req.UserID, req.SessionID, req.FileName); ok {
+		return &artifact.VersionsResponse{Versions: versions}, nil
+	}
+	if s.listings != nil {
+		return s.cachedVersions(ctx, req)
+	}
+	return s.listVersions(ctx, req)
+}
+
+// listVersions lists the versions in the bucket, ignoring the versions
+// index. Writes number versions with it, which the index may lag behind.
+func (s *Service) listVersions(ctx context.Context, req *artifact.VersionsRequest) (*artifact.VersionsResponse, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	appName, userID, sessionID, fileName := req.AppName, req.UserID, req.SessionID, req.FileName
+
+	prefix := s.buildKeyPrefix(appName, userID, sessionID, fileName)
+	iter := s.bucket.List(&blob.ListOptions{
+		Prefix: prefix,
+	})
+
+	versions := make([]int64, 0)
+	for {
+		obj, err := iter.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error iterating objects: %w", err)
+		}
+
+		ref, err := s.keys.ParseKey(obj.Key)
+		// if the key is not a version key, just ignore it
+		if err != nil {
+			continue
+		}
+		versions = append(versions, ref.Version)
+	}
+	// Keys are listed in lexicographic order, where "10" sorts before "2".
+	slices.Sort(versions)
+	return &artifact.VersionsResponse{Versions: versions}, nil
+}
+
+// Versions implements [artifact.Service] and returns an error if no versions are found.
+func (s *Service) Versions(ctx context.Context, req *artifact.VersionsRequest) (*artifact.VersionsResponse, error) {
+	response, err := s.versions(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Versions) == 0 {
+		return nil, fmt.Errorf("artifact not found: %w", fs.ErrNotExist)
+	}
+	return response, nil
+}
+
+// Close closes the bucket.
+func (s *Service) Close() error {
+	return s.bucket.Close()
+}
@@ -0,0 +1,549 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gocloud.dev/blob"
+	_ "gocloud.dev/blob/fileblob"
+	"gocloud.dev/blob/memblob"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/blobartifact"
+	"github.com/chinglinwen/adk-artifact/tests"
+)
+
+func TestBlobArtifactService(t *testing.T) {
+	for name, url := range map[string]func(t *testing.T) string{
+		"MemBlob":  func(t *testing.T) string { return "mem://" },
+		"FileBlob": func(t *testing.T) string { return "file://" + t.TempDir() },
+	} {
+		factory := func(t *testing.T) (artifact.Service, error) {
+			srv, err := blobartifact.NewService(t.Context(), url(t))
+			if err != nil {
+				return nil, err
+			}
+			t.Cleanup(func() { srv.Close() })
+			return srv, nil
+		}
+		tests.TestArtifactService(t, name, factory)
+		tests.TestArtifactServiceProperties(t, name, factory)
+	}
+	indexed := func(t *testing.T) (artifact.Service, error) {
+		return blobartifact.New(memblob.OpenBucket(nil), blobartifact.WithVersionsIndex()), nil
+	}
+	tests.TestArtifactService(t, "IndexedMemBlob", indexed)
+	tests.TestArtifactServiceProperties(t, "IndexedMemBlob", indexed)
+	// Writes through the service are seen through the listing cache.
+	tests.TestArtifactService(t, "CachedMemBlob", func(t *testing.T) (artifact.Service, error) {
+		return blobartifact.New(memblob.OpenBucket(nil), blobartifact.WithListingCache(time.Hour)), nil
+	})
+	tests.TestArtifactService(t, "SharedMemBlob", func(t *testing.T) (artifact.Service, error) {
+		return blobartifact.New(memblob.OpenBucket(nil), blobartifact.WithSharedLoads()), nil
+	})
+	// With zero delays, the eventually consistent bucket must behave like
+	// memblob.
+	tests.TestArtifactService(t, "EventualBlob", func(t *testing.T) (artifact.Service, error) {
+		return blobartifact.New(tests.OpenEventualBucket(nil)), nil
+	})
+}
+
+func TestReservedAppName(t *testing.T) {
+	ctx := t.Context()
+	srv := blobartifact.New(memblob.OpenBucket(nil), blobartifact.WithVersionsIndex())
+	for _, appName := range []string{artifactx.UploadsPrefix, artifactx.IndexPrefix, artifactx.ArchivePrefix} {
+		_, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: appName, UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("x"),
+		})
+		if !errors.Is(err, artifactx.ErrReservedAppName) {
+			t.Errorf("Save() in app %q = %v, want error(%v)", appName, err, artifactx.ErrReservedAppName)
+		}
+		_, err = srv.List(ctx, &artifact.ListRequest{AppName: appName, UserID: "user", SessionID: "session"})
+		if !errors.Is(err, artifactx.ErrReservedAppName) {
+			t.Errorf("List() of app %q = %v, want error(%v)", appName, err, artifactx.ErrReservedAppName)
+		}
+	}
+
+	// The index of app/user/session/file is below .index/app/user/session;
+	// it cannot be deleted as an artifact of the app named .index.
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("x"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	err := srv.Delete(ctx, &artifact.DeleteRequest{AppName: artifactx.IndexPrefix, UserID: "app", SessionID: "user", FileName: "session"})
+	if !errors.Is(err, artifactx.ErrReservedAppName) {
+		t.Errorf("Delete() in app %q = %v, want error(%v)", artifactx.IndexPrefix, err, artifactx.ErrReservedAppName)
+	}
+
+	// Nor can archives be compacted or restored as artifacts of .archive.
+	_, err = srv.Compact(ctx, &artifactx.CompactRequest{AppName: artifactx.ArchivePrefix, UserID: "app", SessionID: "user", FileName: "session", Keep: 1})
+	if !errors.Is(err, artifactx.ErrReservedAppName) {
+		t.Errorf("Compact() in app %q = %v, want error(%v)", artifactx.ArchivePrefix, err, artifactx.ErrReservedAppName)
+	}
+	err = srv.RestoreVersion(ctx, &artifact.LoadRequest{AppName: artifactx.ArchivePrefix, UserID: "app", SessionID: "user", FileName: "session", Version: 1})
+	if !errors.Is(err, artifactx.ErrReservedAppName) {
+		t.Errorf("RestoreVersion() in app %q = %v, want error(%v)", artifactx.ArchivePrefix, err, artifactx.ErrReservedAppName)
+	}
+}
+
+func TestWithChecksumAlgorithm(t *testing.T) {
+	ctx := t.Context()
+	for _, tc := range []struct {
+		alg      *artifactx.ChecksumAlgorithm
+		wantETag string
+	}{
+		{artifactx.ChecksumBLAKE3, artifactx.ChecksumETag(artifactx.ChecksumBLAKE3.Sum([]byte("v1")))},
+		{artifactx.ChecksumCRC32C, ""}, // the ETag of the bucket
+		{nil, ""},
+	} {
+		bucket := memblob.OpenBucket(nil)
+		srv := blobartifact.New(bucket, blobartifact.WithChecksumAlgorithm(tc.alg))
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("v1"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		attrs, err := bucket.Attributes(ctx, "app/user/session/file/1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := artifactx.MetaFromObject(attrs.ContentType, attrs.Metadata)
+		if alg, sum := m.Checksum(); alg != tc.alg || (alg != nil && sum != alg.Sum([]byte("v1"))) {
+			t.Errorf("%v: Checksum() = (%v, %q), want the %[1]v checksum", tc.alg, alg, sum)
+		}
+		stat, err := srv.Stat(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := tc.wantETag
+		if want == "" {
+			want = attrs.ETag
+		}
+		if stat.ETag != want {
+			t.Errorf("%v: Stat().ETag = %q, want %q", tc.alg, stat.ETag, want)
+		}
+	}
+}
+
+func TestWithDefaultContentType(t *testing.T) {
+	ctx := t.Context()
+	utf16 := []byte{0xff, 0xfe, 'h', 0, 0xe9, 0}
+	for _, tc := range []struct {
+		contentType string
+		data        []byte
+		want        string
+	}{
+		{"text/csv", utf16, "text/csv; charset=utf-16le"},
+		{"text/csv", []byte("a,b\n"), "text/csv"},
+		// Without a default, the bucket detects the content type.
+		{"", utf16, "text/plain; charset=utf-16le"},
+	} {
+		srv := blobartifact.New(memblob.OpenBucket(nil), blobartifact.WithDefaultContentType(tc.contentType))
+		req := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "export.csv"}
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+			Part: &genai.Part{InlineData: &genai.Blob{Data: tc.data}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if attrs, err := srv.Stat(ctx, req); err != nil || attrs.ContentType != tc.want {
+			t.Errorf("%q: Stat() = (%+v, %v), want %q", tc.contentType, attrs, err, tc.want)
+		}
+	}
+}
+
+func TestCancelledSave(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	// Cancel the second save once its writer is open, in the middle of it.
+	var writes int
+	srv := blobartifact.New(memblob.OpenBucket(nil), blobartifact.WithWriterOptions(func(_ context.Context, opts *blob.WriterOptions) {
+		opts.BeforeWrite = func(func(any) bool) error {
+			if writes++; writes == 2 {
+				cancel()
+			}
+			return nil
+		}
+	}))
+	req := &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+		Part: genai.NewPartFromText("v1"),
+	}
+	if _, err := srv.Save(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	req.Part = genai.NewPartFromText("v2")
+	if _, err := srv.Save(ctx, req); err == nil {
+		t.Error("Save() cancelled in the middle = nil, want an error")
+	}
+	resp, err := srv.Versions(t.Context(), &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+	if err != nil || !slices.Equal(resp.Versions, []int64{1}) {
+		t.Errorf("Versions() = (%v, %v), want [1]", resp, err)
+	}
+}
+
+func BenchmarkSmallObject(b *testing.B) {
+	srv := blobartifact.New(memblob.OpenBucket(nil))
+	ctx := b.Context()
+	part := genai.NewPartFromBytes(bytes.Repeat([]byte("x"), 1<<10), "application/json")
+	save := &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "scratchpad", Part: part}
+	load := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "scratchpad", Version: 1}
+	if _, err := srv.Save(ctx, save); err != nil {
+		b.Fatal(err)
+	}
+	b.Run("Save", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			save.Version = 1 // overwrite, so that the listings do not grow
+			if _, err := srv.Save(ctx, save); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Load", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := srv.Load(ctx, load); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestListingCache(t *testing.T) {
+	ctx := t.Context()
+	var lists atomic.Int64
+	clock := artifactx.NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	bucket := tests.OpenEventualBucket(&tests.EventualBucketOptions{
+		Clock: clock,
+		OnList: func(string) {
+			// Slow listings down, so that concurrent loads overlap.
+			lists.Add(1)
+			time.Sleep(20 * time.Millisecond)
+		},
+	})
+	srv := blobartifact.New(bucket, blobartifact.WithListingCache(time.Minute), blobartifact.WithClock(clock))
+	other := blobartifact.New(bucket, blobartifact.WithClock(clock))
+	save := func(srv *blobartifact.Service, text string) {
+		t.Helper()
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText(text),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := func() string {
+		t.Helper()
+		resp, err := srv.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Part.Text
+	}
+
+	save(srv, "v1")
+	lists.Store(0)
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Go(func() {
+			if _, err := srv.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"}); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+	wg.Wait()
+	if got := load(); got != "v1" || lists.Load() != 1 {
+		t.Errorf("51 loads = %q with %d listings, want v1 with 1 listing", got, lists.Load())
+	}
+
+	// Saves through the service are seen at once.
+	save(srv, "v2")
+	if got := load(); got != "v2" {
+		t.Errorf("Load() after a save = %q, want v2", got)
+	}
+	// Saves of other processes are seen once the listing expires.
+	save(other, "v3")
+	if got := load(); got != "v2" {
+		t.Errorf("Load() after a save of another service = %q, want the cached v2", got)
+	}
+	clock.Advance(time.Minute)
+	if got := load(); got != "v3" {
+		t.Errorf("Load() after the TTL = %q, want v3", got)
+	}
+}
+
+func TestSharedLoads(t *testing.T) {
+	ctx := t.Context()
+	var reads atomic.Int64
+	bucket := tests.OpenEventualBucket(&tests.EventualBucketOptions{
+		OnRead: func(string) {
+			// Slow reads down, so that concurrent loads overlap.
+			reads.Add(1)
+			time.Sleep(20 * time.Millisecond)
+		},
+	})
+	srv := blobartifact.New(bucket, blobartifact.WithSharedLoads())
+	save := &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+		Part: genai.NewPartFromBytes([]byte("v1"), "application/octet-stream"),
+	}
+	if _, err := srv.Save(ctx, save); err != nil {
+		t.Fatal(err)
+	}
+	load := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Version: 1}
+
+	reads.Store(0)
+	parts := make([]*genai.Part, 50)
+	var wg sync.WaitGroup
+	for i := range parts {
+		wg.Go(func() {
+			resp, err := srv.Load(ctx, load)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			parts[i] = resp.Part
+		})
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+	if reads.Load() != 1 {
+		t.Errorf("50 concurrent loads made %d reads, want 1", reads.Load())
+	}
+	for _, p := range parts[1:] {
+		if p == parts[0] || p.InlineData == parts[0].InlineData {
+			t.Fatal("concurrent loads share a Part, want their own")
+		}
+		if !bytes.Equal(p.InlineData.Data, []byte("v1")) {
+			t.Errorf("Load() = %q, want v1", p.InlineData.Data)
+		}
+	}
+
+	// An overwrite is seen by the loads after it.
+	save.Version = 1
+	save.Part = genai.NewPartFromBytes([]byte("v2"), "application/octet-stream")
+	if _, err := srv.Save(ctx, save); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := srv.Load(ctx, load)
+	if err != nil || !bytes.Equal(resp.Part.InlineData.Data, []byte("v2")) {
+		t.Errorf("Load() after an overwrite = (%v, %v), want v2", resp, err)
+	}
+}
+
+func TestBulkDelete(t *testing.T) {
+	ctx := t.Context()
+	const versions, concurrency = 40, 4
+	errFailed := errors.New("injected failure")
+	for _, policy := range []blobartifact.DeleteErrorPolicy{blobartifact.DeleteFailFast, blobartifact.DeleteBestEffort} {
+		var inFlight, maxInFlight atomic.Int64
+		bucket := tests.OpenEventualBucket(&tests.EventualBucketOptions{
+			OnDelete: func(key string) error {
+				n := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for m := maxInFlight.Load(); n > m && !maxInFlight.CompareAndSwap(m, n); m = maxInFlight.Load() {
+				}
+				time.Sleep(time.Millisecond)
+				if strings.HasSuffix(key, "/7") || strings.HasSuffix(key, "/9") {
+					return errFailed
+				}
+				return nil
+			},
+		})
+		srv := blobartifact.New(bucket, blobartifact.WithBulkDelete(concurrency, policy))
+		for range versions {
+			if _, err := srv.Save(ctx, &artifact.SaveRequest{
+				AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("v"),
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		delErr := srv.Delete(ctx, &artifact.DeleteRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+		if !errors.Is(delErr, errFailed) {
+			t.Errorf("%v: Delete() = %v, want the injected failure", policy, delErr)
+		}
+		if maxInFlight.Load() > concurrency {
+			t.Errorf("%v: Delete() deleted %d versions at once, want at most %d", policy, maxInFlight.Load(), concurrency)
+		}
+		resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch policy {
+		case blobartifact.DeleteBestEffort:
+			if !slices.Equal(resp.Versions, []int64{9, 7}) && !slices.Equal(resp.Versions, []int64{7, 9}) {
+				t.Errorf("%v: Versions() after Delete() = %v, want only the failed [7 9]", policy, resp.Versions)
+			}
+			if n := strings.Count(delErr.Error(), errFailed.Error()); n != 2 {
+				t.Errorf("%v: Delete() = %v, want both failures", policy, delErr)
+			}
+		case blobartifact.DeleteFailFast:
+			if len(resp.Versions) <= 2 {
+				t.Errorf("%v: Versions() after Delete() = %v, want the versions after the failure kept", policy, resp.Versions)
+			}
+		}
+	}
+}
+
+// TestEventualListing saves, uploads and deletes with listings that show
+// changes a minute late, as in some S3 compatible stores.
+func TestEventualListing(t *testing.T) {
+	ctx := t.Context()
+	clock := artifactx.NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	bucket := tests.OpenEventualBucket(&tests.EventualBucketOptions{Clock: clock, ListDelay: time.Minute})
+	srv := blobartifact.New(bucket, blobartifact.WithVersionsIndex(), blobartifact.WithClock(clock))
+	versions := func() []int64 {
+		t.Helper()
+		resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		slices.Sort(resp.Versions)
+		return resp.Versions
+	}
+	save := func(text string, want int64) {
+		t.Helper()
+		resp, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText(text),
+		})
+		if err != nil || resp.Version != want {
+			t.Fatalf("Save(%q) = (%v, %v), want version %d", text, resp, err, want)
+		}
+	}
+
+	// Saves see the versions the listing does not show yet.
+	save("v1", 1)
+	save("v2", 2)
+	if got := versions(); !slices.Equal(got, []int64{1, 2}) {
+		t.Errorf("Versions() = %v, want the indexed [1 2]", got)
+	}
+
+	clock.Advance(time.Minute)
+	upload, err := srv.BeginUpload(ctx, &artifactx.UploadRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file", MIMEType: "text/plain",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.AppendChunk(ctx, upload.ID, 0, []byte("v4")); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	save("v3", 3)
+	if resp, err := srv.CommitUpload(ctx, upload.ID); err != nil || resp.Version != 4 {
+		t.Errorf("CommitUpload() = (%v, %v), want version 4", resp, err)
+	}
+
+	// Deletes are indexed although the listing still shows the versions.
+	if err := srv.Delete(ctx, &artifact.DeleteRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Version: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if got := versions(); !slices.Equal(got, []int64{1, 2, 4}) {
+		t.Errorf("Versions() after deleting version 3 = %v, want [1 2 4]", got)
+	}
+	for want, text := range map[int64]string{1: "v1", 2: "v2", 4: "v4"} {
+		resp, err := srv.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Version: want})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := resp.Part.Text
+		if resp.Part.InlineData != nil {
+			got = string(resp.Part.InlineData.Data)
+		}
+		if got != text {
+			t.Errorf("Load(version %d) = %q, want %q", want, got, text)
+		}
+	}
+}
+
+// TestSaveTakesNextVersion saves without an index while the listing does
+// not show the versions yet, so that every save first tries a taken one.
+func TestSaveTakesNextVersion(t *testing.T) {
+	ctx := t.Context()
+	clock := artifactx.NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	bucket := tests.OpenEventualBucket(&tests.EventualBucketOptions{Clock: clock, ListDelay: time.Hour})
+	srv := blobartifact.New(bucket, blobartifact.WithClock(clock))
+	for want := int64(1); want <= 3; want++ {
+		resp, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText(fmt.Sprint(want)),
+		})
+		if err != nil || resp.Version != want {
+			t.Fatalf("Save() = (%v, %v), want version %d", resp, err, want)
+		}
+	}
+	resp, err := srv.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Version: 1})
+	if err != nil || resp.Part.Text != "1" {
+		t.Errorf("Load(version 1) = (%v, %v), want the first save", resp, err)
+	}
+}
+
+func TestCheckConditionalWrites(t *testing.T) {
+	ctx := t.Context()
+	bucket := memblob.OpenBucket(nil)
+	if err := blobartifact.New(bucket).CheckConditionalWrites(ctx); err != nil {
+		t.Errorf("CheckConditionalWrites() of memblob = %v, want nil", err)
+	}
+	ignoring := tests.OpenEventualBucket(&tests.EventualBucketOptions{IgnoreIfNotExist: true})
+	if err := blobartifact.New(ignoring).CheckConditionalWrites(ctx); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("CheckConditionalWrites() of a bucket ignoring If-None-Match = %v, want error(%v)", err, errors.ErrUnsupported)
+	}
+	for _, b := range []*blob.Bucket{bucket, ignoring} {
+		if obj, err := b.List(nil).Next(ctx); err != io.EOF {
+			t.Errorf("CheckConditionalWrites() left (%v, %v), want no objects", obj, err)
+		}
+	}
+}
+
+// TestConcurrentSaves saves to one artifact through several services
+// sharing a bucket, as several processes would.
+func TestConcurrentSaves(t *testing.T) {
+	bucket := memblob.OpenBucket(nil)
+	const services, saves = 8, 10
+	var wg sync.WaitGroup
+	for i := range services {
+		srv := blobartifact.New(bucket)
+		wg.Go(func() {
+			for j := range saves {
+				if _, err := srv.Save(t.Context(), &artifact.SaveRequest{
+					AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+					Part: genai.NewPartFromText(fmt.Sprint(i, j)),
+				}); err != nil {
+					t.Error(err)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	resp, err := blobartifact.New(bucket).Versions(t.Context(), &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+	if err != nil || len(resp.Versions) != services*saves || slices.Max(resp.Versions) != services*saves {
+		t.Errorf("Versions() = (%v, %v), want %d distinct versions", resp, err, services*saves)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gocloud.dev/blob"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.URLSigner = (*Service)(nil)
+
+// SignedURL implements [artifactx.URLSigner] with [blob.Bucket.SignedURL].
+// Not every driver supports signing, and some only when configured to, such
+// as fileblob with a URL signer.
+func (s *Service) SignedURL(ctx context.Context, req *artifact.LoadRequest, expiry time.Duration) (string, error) {
+	if err := req.Validate(); err != nil {
+		return "", fmt.Errorf("request validation failed: %w", err)
+	}
+	key, _, err := s.resolveKey(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	url, err := s.bucket.SignedURL(ctx, key, &blob.SignedURLOptions{Expiry: expiry})
+	if err != nil {
+		return "", fmt.Errorf("failed to sign url of '%s': %w", key, err)
+	}
+	return url, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"slices"
+
+	"gocloud.dev/gcerrors"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.JSONUpdater = (*Service)(nil)
+
+// maxUpdateAttempts bounds how many times UpdateJSON patches the latest
+// version again after losing the race for the next version number.
+const maxUpdateAttempts = 16
+
+// UpdateJSON implements [artifactx.JSONUpdater]. The new version is written
+// only if its object does not exist yet; if another writer saved it first,
+// the patch is applied again to the new latest version. Saves made with an
+// explicit version, and stores that ignore the condition, can still
+// overwrite it.
+func (s *Service) UpdateJSON(ctx context.Context, req *artifactx.UpdateJSONRequest) (*artifact.SaveResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return nil, err
+	}
+	if err := artifactx.CheckSessionID(s.keys, req.SessionID, req.FileName); err != nil {
+		return nil, err
+	}
+	for range maxUpdateAttempts {
+		version, err := s.updateJSON(ctx, req)
+		if gcerrors.Code(err) == gcerrors.FailedPrecondition {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.applyRetention(ctx, req.AppName, req.UserID, req.SessionID, req.FileName)
+		s.reindexChanged(ctx, req.AppName, req.UserID, req.SessionID, req.FileName, []int64{version}, nil)
+		return &artifact.SaveResponse{Version: version}, nil
+	}
+	return nil, fmt.Errorf("failed to update artifact '%s' after %d attempts: %w", req.FileName, maxUpdateAttempts, artifactx.ErrConflict)
+}
+
+// updateJSON makes one attempt of UpdateJSON and returns the saved version.
+func (s *Service) updateJSON(ctx context.Context, req *artifactx.UpdateJSONRequest) (int64, error) {
+	response, err := s.listVersions(ctx, &artifact.VersionsRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list artifact versions: %w", err)
+	}
+	if len(response.Versions) == 0 {
+		return 0, fmt.Errorf("artifact '%s' not found: %w", req.FileName, fs.ErrNotExist)
+	}
+	latest := slices.Max(response.Versions)
+	key := s.buildKey(req.AppName, req.UserID, req.SessionID, req.FileName, latest)
+	if err := s.checkMutable(ctx, key, req.FileName); err != nil {
+		return 0, err
+	}
+	data, contentType, err := s.readObject(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if err := artifactx.CheckJSON(req.FileName, contentType); err != nil {
+		return 0, err
+	}
+	data, err = req.Apply(data)
+	if err != nil {
+		return 0, err
+	}
+	next := s.buildKey(req.AppName, req.UserID, req.SessionID, req.FileName, latest+1)
+	if err := s.writeVersion(ctx, next, data, contentType, true); err != nil {
+		return 0, err
+	}
+	return latest + 1, nil
+}
@@ -0,0 +1,223 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.Uploader = (*Service)(nil)
+
+// An upload is kept under .uploads/<id>/ as a "state" JSON object and one
+// object per chunk, named after its zero padded offset so that listing
+// returns the chunks in order. Objects appear atomically, so the upload size
+// is the sum of the chunk sizes.
+
+func uploadPrefix(id string) string {
+	return fmt.Sprintf("%s/%s/", artifactx.UploadsPrefix, id)
+}
+
+func chunkKey(id string, offset int64) string {
+	return fmt.Sprintf("%schunks/%020d", uploadPrefix(id), offset)
+}
+
+// readUpload returns the state of the upload with its current size, and the
+// keys of its chunks in order.
+func (s *Service) readUpload(ctx context.Context, id string) (*artifactx.Upload, []string, error) {
+	if err := artifactx.ValidUploadID(id); err != nil {
+		return nil, nil, err
+	}
+	data, err := s.bucket.ReadAll(ctx, uploadPrefix(id)+"state")
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, nil, fmt.Errorf("upload %q not found: %w", id, fs.ErrNotExist)
+		}
+		return nil, nil, fmt.Errorf("failed to read upload state: %w", err)
+	}
+	u := &artifactx.Upload{}
+	if err := json.Unmarshal(data, u); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode upload state: %w", err)
+	}
+
+	var chunks []string
+	iter := s.bucket.List(&blob.ListOptions{Prefix: uploadPrefix(id) + "chunks/"})
+	for {
+		obj, err := iter.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("error iterating upload chunks: %w", err)
+		}
+		chunks = append(chunks, obj.Key)
+		u.Size += obj.Size
+	}
+	slices.Sort(chunks)
+	return u, chunks, nil
+}
+
+// BeginUpload implements [artifactx.Uploader].
+func (s *Service) BeginUpload(ctx context.Context, req *artifactx.UploadRequest) (*artifactx.Upload, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return nil, err
+	}
+	if err := artifactx.CheckSessionID(s.keys, req.SessionID, req.FileName); err != nil {
+		return nil, err
+	}
+	u := &artifactx.Upload{
+		ID:            artifactx.NewUploadID(),
+		UploadRequest: *req,
+		Created:       s.clock.Now(),
+		Metadata:      artifactx.MetadataFrom(ctx),
+	}
+	data, err := json.Marshal(u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode upload state: %w", err)
+	}
+	if err := s.bucket.WriteAll(ctx, uploadPrefix(u.ID)+"state", data, &blob.WriterOptions{ContentType: "application/json"}); err != nil {
+		return nil, fmt.Errorf("failed to write upload state: %w", err)
+	}
+	return u, nil
+}
+
+// AppendChunk implements [artifactx.Uploader]. Every chunk is stored as its
+// own object.
+func (s *Service) AppendChunk(ctx context.Context, uploadID string, offset int64, data []byte) (*artifactx.Upload, error) {
+	u, _, err := s.readUpload(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if offset != u.Size {
+		return nil, fmt.Errorf("%w: upload %q has %d bytes, chunk starts at %d", artifactx.ErrOffsetMismatch, uploadID, u.Size, offset)
+	}
+	if len(data) == 0 {
+		return u, nil
+	}
+	if err := s.bucket.WriteAll(ctx, chunkKey(uploadID, offset), data, nil); err != nil {
+		return nil, fmt.Errorf("failed to write chunk: %w", err)
+	}
+	u.Size += int64(len(data))
+	return u, nil
+}
+
+// UploadStatus implements [artifactx.Uploader].
+func (s *Service) UploadStatus(ctx context.Context, uploadID string) (*artifactx.Upload, error) {
+	u, _, err := s.readUpload(ctx, uploadID)
+	return u, err
+}
+
+// CommitUpload implements [artifactx.Uploader]. The chunks are streamed into
+// the version object one at a time.
+func (s *Service) CommitUpload(ctx context.Context, uploadID string) (*artifact.SaveResponse, error) {
+	u, chunks, err := s.readUpload(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	contentType, err := s.uploadContentType(ctx, u.MIMEType, chunks)
+	if err != nil {
+		return nil, err
+	}
+	nextVersion, err := s.writeNextVersion(ctx, u.AppName, u.UserID, u.SessionID, u.FileName, func(key string) error {
+		return s.writeChunks(ctx, key, contentType, u.Metadata, chunks)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.AbortUpload(ctx, uploadID); err != nil {
+		return nil, err
+	}
+	s.applyRetention(ctx, u.AppName, u.UserID, u.SessionID, u.FileName)
+	s.reindexChanged(ctx, u.AppName, u.UserID, u.SessionID, u.FileName, []int64{nextVersion}, nil)
+	return &artifact.SaveResponse{Version: nextVersion}, nil
+}
+
+// writeChunks writes the chunks of an upload into the object at key, unless
+// it exists, aborting the write on failures.
+func (s *Service) writeChunks(ctx context.Context, key, contentType string, md map[string]string, chunks []string) error {
+	opts := s.newWriterOptions(ctx, contentType, md)
+	opts.IfNotExist = true
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w, err := s.bucket.NewWriter(wctx, key, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create writer: %w", err)
+	}
+	for _, chunk := range chunks {
+		if err := s.bucket.Download(ctx, chunk, w, nil); err != nil {
+			cancel() // abort the write
+			w.Close()
+			return fmt.Errorf("failed to copy chunk %q: %w", chunk, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to close writer: %w", err)
+	}
+	return nil
+}
+
+// AbortUpload implements [artifactx.Uploader].
+func (s *Service) AbortUpload(ctx context.Context, uploadID string) error {
+	if err := artifactx.ValidUploadID(uploadID); err != nil {
+		return err
+	}
+	iter := s.bucket.List(&blob.ListOptions{Prefix: uploadPrefix(uploadID)})
+	for {
+		obj, err := iter.Next(ctx)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error iterating upload objects: %w", err)
+		}
+		if err := s.bucket.Delete(ctx, obj.Key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			return fmt.Errorf("failed to delete %q: %w", obj.Key, err)
+		}
+	}
+}
+
+// uploadContentType returns the content type of an upload of chunks,
+// contentType or the default, with the charset of text recorded.
+func (s *Service) uploadContentType(ctx context.Context, contentType string, chunks []string) (string, error) {
+	contentType = cmp.Or(contentType, s.defaultContentType)
+	if len(chunks) == 0 || !artifactx.IsText(contentType) {
+		return contentType, nil
+	}
+	r, err := s.newRangeReader(ctx, chunks[0], 0, 3)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	head, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to read chunk %q: %w", chunks[0], err)
+	}
+	return artifactx.WithCharset(contentType, head), nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobartifact
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.Walker = (*Service)(nil)
+
+// Walk implements [artifactx.Walker]. Objects that do not follow the artifact
+// layout are skipped; use [Service.Check] to find them.
+func (s *Service) Walk(ctx context.Context, fn func(artifactx.Ref) error) error {
+	iter := s.bucket.List(nil)
+	for {
+		obj, err := iter.Next(ctx)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error iterating objects: %w", err)
+		}
+		ref, err := s.keys.ParseKey(obj.Key)
+		if err != nil {
+			continue
+		}
+		if err := fn(ref); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,328 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package breakerartifact provides an [artifact.Service] decorator with a
+// circuit breaker, so that agents fail fast while the backend is down
+// instead of piling up timeouts.
+//
+// The breaker counts the requests and failures of consecutive windows.
+// Once enough requests of a window failed, the circuit opens and requests
+// fail with [artifactx.ErrBackendUnavailable] without being tried, or are
+// served by [Options.Fallback]. After [Options.OpenTimeout] the circuit is
+// half-open and lets a few probe requests through: it closes again if they
+// succeed and reopens if one fails. Requests whose context was cancelled
+// count as neither, so that callers giving up do not move the circuit.
+//
+// Streaming through [artifactx.Opener] and describing versions through
+// [artifactx.Stater] go through the breaker too; only opening the stream is
+// counted, not reading it.
+package breakerartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// State is the state of a circuit.
+type State int
+
+const (
+	// Closed lets all requests through.
+	Closed State = iota
+	// Open fails all requests.
+	Open
+	// HalfOpen lets a few probe requests through.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// Options configures [NewService]. Zero fields take the defaults noted.
+type Options struct {
+	// FailureRate is the fraction of failed requests of a window opening the
+	// circuit; defaults to 0.5.
+	FailureRate float64
+	// MinRequests is the number of requests a window needs before its
+	// failure rate is considered; defaults to 10.
+	MinRequests int
+	// Window is the length of the windows failures are counted in; defaults
+	// to 10 seconds.
+	Window time.Duration
+	// OpenTimeout is how long the circuit stays open before probing the
+	// backend; defaults to 30 seconds.
+	OpenTimeout time.Duration
+	// Probes is the number of concurrent probe requests while half-open,
+	// and of successful ones closing the circuit; defaults to 1.
+	Probes int
+	// IsFailure reports whether an error of the backend counts as a
+	// failure; defaults to [IsFailure].
+	IsFailure func(error) bool
+	// Fallback, if set, serves the requests the open circuit rejects, such
+	// as a replica in another region.
+	Fallback artifact.Service
+	// Clock defaults to [artifactx.SystemClock].
+	Clock artifactx.Clock
+	// Logger logs state changes; defaults to [slog.Default].
+	Logger *slog.Logger
+}
+
+// IsFailure is the default of [Options.IsFailure]. Errors caused by the
+// request rather than the backend, such as missing artifacts and cancelled
+// contexts, are not failures.
+func IsFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, target := range []error{
+		fs.ErrNotExist, fs.ErrExist, fs.ErrPermission, context.Canceled,
+		artifactx.ErrTooLarge, artifactx.ErrNotModified, artifactx.ErrLocked, artifactx.ErrReservedSessionID, artifactx.ErrReservedAppName,
+	} {
+		if errors.Is(err, target) {
+			return false
+		}
+	}
+	return true
+}
+
+// Service is an [artifact.Service] with a circuit breaker.
+type Service struct {
+	artifact.Service
+	opts   Options
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	state    State
+	windowAt time.Time // start of the window, or when the circuit opened
+	requests int
+	failures int
+	probing  int // probe requests in flight
+}
+
+var (
+	_ artifactx.Wrapper = (*Service)(nil)
+	_ artifactx.Opener  = (*Service)(nil)
+	_ artifactx.Stater  = (*Service)(nil)
+)
+
+// NewService returns a service that forwards to next through a circuit
+// breaker.
+func NewService(next artifact.Service, opts Options) *Service {
+	if opts.FailureRate <= 0 {
+		opts.FailureRate = 0.5
+	}
+	if opts.MinRequests <= 0 {
+		opts.MinRequests = 10
+	}
+	if opts.Window <= 0 {
+		opts.Window = 10 * time.Second
+	}
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = 30 * time.Second
+	}
+	if opts.Probes <= 0 {
+		opts.Probes = 1
+	}
+	if opts.IsFailure == nil {
+		opts.IsFailure = IsFailure
+	}
+	if opts.Clock == nil {
+		opts.Clock = artifactx.SystemClock
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	return &Service{
+		Service:  next,
+		opts:     opts,
+		logger:   opts.Logger.With("component", "breakerartifact"),
+		windowAt: opts.Clock.Now(),
+	}
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// State returns the current state of the circuit.
+func (s *Service) State() State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance(s.opts.Clock.Now())
+	return s.state
+}
+
+// setState changes the state and starts a new window. s.mu must be held.
+func (s *Service) setState(state State, now time.Time) {
+	if state != s.state {
+		s.logger.Warn("circuit state changed", "from", s.state, "to", state)
+	}
+	s.state, s.windowAt, s.requests, s.failures, s.probing = state, now, 0, 0, 0
+}
+
+// advance applies the transitions due to time passing. s.mu must be held.
+func (s *Service) advance(now time.Time) {
+	switch {
+	case s.state == Closed && now.Sub(s.windowAt) >= s.opts.Window:
+		s.windowAt, s.requests, s.failures = now, 0, 0
+	case s.state == Open && now.Sub(s.windowAt) >= s.opts.OpenTimeout:
+		s.setState(HalfOpen, now)
+	}
+}
+
+// allow reports whether a request may be tried, and whether it is a probe.
+func (s *Service) allow() (ok, probe bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance(s.opts.Clock.Now())
+	switch s.state {
+	case Closed:
+		return true, false
+	case HalfOpen:
+		if s.probing < s.opts.Probes {
+			s.probing++
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// record accounts for the outcome of a tried request.
+func (s *Service) record(probe, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.opts.Clock.Now()
+	switch {
+	case probe && s.state == HalfOpen:
+		s.probing--
+		if failed {
+			s.setState(Open, now)
+			return
+		}
+		if s.requests++; s.requests >= s.opts.Probes {
+			s.setState(Closed, now)
+		}
+	case !probe && s.state == Closed:
+		s.advance(now)
+		s.requests++
+		if failed {
+			s.failures++
+		}
+		if s.requests >= s.opts.MinRequests && float64(s.failures) >= s.opts.FailureRate*float64(s.requests) {
+			s.setState(Open, now)
+		}
+	}
+}
+
+// release gives back the probe slot of a tried request without accounting
+// for its outcome.
+func (s *Service) release(probe bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if probe && s.state == HalfOpen {
+		s.probing--
+	}
+}
+
+// call runs fn against the backend if the circuit allows it, and against
+// the fallback otherwise.
+func call[T any](ctx context.Context, s *Service, op string, fn func(artifact.Service) (T, error)) (T, error) {
+	ok, probe := s.allow()
+	if !ok {
+		if s.opts.Fallback != nil {
+			return fn(s.opts.Fallback)
+		}
+		var zero T
+		return zero, fmt.Errorf("%s: %w", op, artifactx.ErrBackendUnavailable)
+	}
+	v, err := fn(s.Service)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		s.release(probe)
+		return v, err
+	}
+	s.record(probe, s.opts.IsFailure(err))
+	return v, err
+}
+
+// Save implements [artifact.Service].
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	return call(ctx, s, "save", func(srv artifact.Service) (*artifact.SaveResponse, error) { return srv.Save(ctx, req) })
+}
+
+// Load implements [artifact.Service].
+func (s *Service) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	return call(ctx, s, "load", func(srv artifact.Service) (*artifact.LoadResponse, error) { return srv.Load(ctx, req) })
+}
+
+// Delete implements [artifact.Service].
+func (s *Service) Delete(ctx context.Context, req *artifact.DeleteRequest) error {
+	_, err := call(ctx, s, "delete", func(srv artifact.Service) (struct{}, error) { return struct{}{}, srv.Delete(ctx, req) })
+	return err
+}
+
+// List implements [artifact.Service].
+func (s *Service) List(ctx context.Context, req *artifact.ListRequest) (*artifact.ListResponse, error) {
+	return call(ctx, s, "list", func(srv artifact.Service) (*artifact.ListResponse, error) { return srv.List(ctx, req) })
+}
+
+// Versions implements [artifact.Service].
+func (s *Service) Versions(ctx context.Context, req *artifact.VersionsRequest) (*artifact.VersionsResponse, error) {
+	return call(ctx, s, "versions", func(srv artifact.Service) (*artifact.VersionsResponse, error) { return srv.Versions(ctx, req) })
+}
+
+// Open implements [artifactx.Opener]. Versions are loaded into memory if
+// the decorated service cannot stream.
+func (s *Service) Open(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Reader, error) {
+	return call(ctx, s, "open", func(srv artifact.Service) (*artifactx.Reader, error) {
+		if opener, ok := artifactx.As[artifactx.Opener](srv); ok {
+			return opener.Open(ctx, req)
+		}
+		return artifactx.OpenLoaded(ctx, srv, req)
+	})
+}
+
+// Stat implements [artifactx.Stater]. Versions are loaded to describe them
+// if the decorated service cannot.
+func (s *Service) Stat(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Attributes, error) {
+	return call(ctx, s, "stat", func(srv artifact.Service) (*artifactx.Attributes, error) {
+		if stater, ok := artifactx.As[artifactx.Stater](srv); ok {
+			return stater.Stat(ctx, req)
+		}
+		r, err := artifactx.OpenLoaded(ctx, srv, req)
+		if err != nil {
+			return nil, err
+		}
+		r.Close()
+		return &r.Attributes, nil
+	})
+}
@@ -0,0 +1,231 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package breakerartifact_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/breakerartifact"
+)
+
+var errDown = errors.New("connection refused")
+
+// flaky fails every Load while down is set, and counts the Loads tried.
+type flaky struct {
+	artifact.Service
+	down  atomic.Bool
+	loads atomic.Int32
+}
+
+func (f *flaky) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	f.loads.Add(1)
+	if f.down.Load() {
+		return nil, errDown
+	}
+	return f.Service.Load(ctx, req)
+}
+
+var req = &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"}
+
+func newFlaky(t *testing.T) *flaky {
+	t.Helper()
+	next := artifact.InMemoryService()
+	if _, err := next.Save(t.Context(), &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("primary"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return &flaky{Service: next}
+}
+
+func TestBreaker(t *testing.T) {
+	ctx := t.Context()
+	clock := artifactx.NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	backend := newFlaky(t)
+	srv := breakerartifact.NewService(backend, breakerartifact.Options{
+		MinRequests: 4, Window: time.Minute, OpenTimeout: time.Minute, Clock: clock,
+	})
+
+	// Missing artifacts are not failures.
+	for range 4 {
+		if _, err := srv.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "missing"}); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("Load() of a missing artifact = %v, want %v", err, fs.ErrNotExist)
+		}
+	}
+	if got := srv.State(); got != breakerartifact.Closed {
+		t.Fatalf("State() after missing artifacts = %v, want closed", got)
+	}
+
+	clock.Advance(time.Minute) // new window
+	backend.down.Store(true)
+	for range 4 {
+		if _, err := srv.Load(ctx, req); !errors.Is(err, errDown) {
+			t.Fatalf("Load() = %v, want %v", err, errDown)
+		}
+	}
+	if got := srv.State(); got != breakerartifact.Open {
+		t.Fatalf("State() after failures = %v, want open", got)
+	}
+	tried := backend.loads.Load()
+	if _, err := srv.Load(ctx, req); !errors.Is(err, artifactx.ErrBackendUnavailable) {
+		t.Errorf("Load() with an open circuit = %v, want %v", err, artifactx.ErrBackendUnavailable)
+	}
+	if backend.loads.Load() != tried {
+		t.Error("Load() with an open circuit reached the backend")
+	}
+
+	clock.Advance(time.Minute)
+	if got := srv.State(); got != breakerartifact.HalfOpen {
+		t.Fatalf("State() after the open timeout = %v, want half-open", got)
+	}
+	if _, err := srv.Load(ctx, req); !errors.Is(err, errDown) {
+		t.Errorf("probe Load() = %v, want %v", err, errDown)
+	}
+	if got := srv.State(); got != breakerartifact.Open {
+		t.Fatalf("State() after a failed probe = %v, want open", got)
+	}
+
+	clock.Advance(time.Minute)
+	backend.down.Store(false)
+	if resp, err := srv.Load(ctx, req); err != nil || resp.Part.Text != "primary" {
+		t.Errorf("probe Load() = (%v, %v), want primary", resp, err)
+	}
+	if got := srv.State(); got != breakerartifact.Closed {
+		t.Errorf("State() after a successful probe = %v, want closed", got)
+	}
+}
+
+func TestBreakerFallback(t *testing.T) {
+	ctx := t.Context()
+	backend := newFlaky(t)
+	backend.down.Store(true)
+	fallback := artifact.InMemoryService()
+	if _, err := fallback.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("fallback"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	srv := breakerartifact.NewService(backend, breakerartifact.Options{MinRequests: 1, Fallback: fallback})
+	if _, err := srv.Load(ctx, req); !errors.Is(err, errDown) {
+		t.Fatalf("Load() = %v, want %v", err, errDown)
+	}
+	if resp, err := srv.Load(ctx, req); err != nil || resp.Part.Text != "fallback" {
+		t.Errorf("Load() with an open circuit = (%v, %v), want the fallback", resp, err)
+	}
+}
+
+func TestBreakerCancelled(t *testing.T) {
+	clock := artifactx.NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	backend := newFlaky(t)
+	srv := breakerartifact.NewService(backend, breakerartifact.Options{MinRequests: 1, OpenTimeout: time.Minute, Clock: clock})
+	cancelled, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	// A failure of a cancelled request does not open the circuit.
+	backend.down.Store(true)
+	if _, err := srv.Load(cancelled, req); !errors.Is(err, errDown) {
+		t.Fatalf("Load() = %v, want %v", err, errDown)
+	}
+	if got := srv.State(); got != breakerartifact.Closed {
+		t.Fatalf("State() after a cancelled failure = %v, want closed", got)
+	}
+	if _, err := srv.Load(t.Context(), req); !errors.Is(err, errDown) {
+		t.Fatalf("Load() = %v, want %v", err, errDown)
+	}
+	if got := srv.State(); got != breakerartifact.Open {
+		t.Fatalf("State() after a failure = %v, want open", got)
+	}
+
+	// A cancelled probe neither closes the circuit nor keeps its slot.
+	clock.Advance(time.Minute)
+	backend.down.Store(false)
+	if _, err := srv.Load(cancelled, req); err != nil {
+		t.Fatalf("cancelled probe Load() = %v", err)
+	}
+	if got := srv.State(); got != breakerartifact.HalfOpen {
+		t.Fatalf("State() after a cancelled probe = %v, want half-open", got)
+	}
+	if _, err := srv.Load(t.Context(), req); err != nil {
+		t.Fatalf("probe Load() = %v", err)
+	}
+	if got := srv.State(); got != breakerartifact.Closed {
+		t.Errorf("State() after a successful probe = %v, want closed", got)
+	}
+}
+
+// broken streams and describes versions with failures.
+type broken struct {
+	artifact.Service
+}
+
+func (broken) Open(context.Context, *artifact.LoadRequest) (*artifactx.Reader, error) {
+	return nil, errDown
+}
+
+func (broken) Stat(context.Context, *artifact.LoadRequest) (*artifactx.Attributes, error) {
+	return nil, errDown
+}
+
+func TestBreakerCapabilities(t *testing.T) {
+	ctx := t.Context()
+	srv := breakerartifact.NewService(broken{artifact.InMemoryService()}, breakerartifact.Options{MinRequests: 2})
+	stater, ok := artifactx.As[artifactx.Stater](srv)
+	if !ok {
+		t.Fatal("As[Stater]() found no stater")
+	}
+	if _, err := stater.Stat(ctx, req); !errors.Is(err, errDown) {
+		t.Fatalf("Stat() = %v, want %v", err, errDown)
+	}
+	opener, ok := artifactx.As[artifactx.Opener](srv)
+	if !ok {
+		t.Fatal("As[Opener]() found no opener")
+	}
+	if _, err := opener.Open(ctx, req); !errors.Is(err, errDown) {
+		t.Fatalf("Open() = %v, want %v", err, errDown)
+	}
+	if got := srv.State(); got != breakerartifact.Open {
+		t.Fatalf("State() after failed Stat and Open = %v, want open", got)
+	}
+	if _, err := stater.Stat(ctx, req); !errors.Is(err, artifactx.ErrBackendUnavailable) {
+		t.Errorf("Stat() with an open circuit = %v, want %v", err, artifactx.ErrBackendUnavailable)
+	}
+	if _, err := opener.Open(ctx, req); !errors.Is(err, artifactx.ErrBackendUnavailable) {
+		t.Errorf("Open() with an open circuit = %v, want %v", err, artifactx.ErrBackendUnavailable)
+	}
+}
+
+func TestBreakerOpenLoaded(t *testing.T) {
+	ctx := t.Context()
+	srv := breakerartifact.NewService(newFlaky(t), breakerartifact.Options{})
+	var buf bytes.Buffer
+	_, attrs, err := artifactx.LoadTo(ctx, srv, req, &buf)
+	if err != nil || buf.String() != "primary" || attrs.Version != 1 {
+		t.Errorf("LoadTo() = (%q, %+v, %v), want version 1", buf.String(), attrs, err)
+	}
+	attrs, err = srv.Stat(ctx, req)
+	if err != nil || attrs.Version != 1 || attrs.Size != int64(len("primary")) {
+		t.Errorf("Stat() = (%+v, %v), want version 1", attrs, err)
+	}
+}
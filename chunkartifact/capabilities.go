@@ -0,0 +1,136 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunkartifact
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"slices"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var (
+	_ artifactx.Forwarder      = (*Service)(nil)
+	_ artifactx.DetailedLister = (*Service)(nil)
+	_ artifactx.RecentLister   = (*Service)(nil)
+	_ artifactx.VersionLister  = (*Service)(nil)
+	_ artifactx.FileFinder     = (*Service)(nil)
+)
+
+// Forwards implements [artifactx.Forwarder]. The capabilities of the
+// decorated service that would see manifests and chunk artifacts rather
+// than assembled versions, such as [artifactx.URLSigner],
+// [artifactx.RangeOpener], [artifactx.Walker] and [artifactx.JSONUpdater],
+// are not forwarded, and neither is [artifactx.Compactor], whose archived
+// manifests Sweep would not see. The helpers of artifactx fall back to
+// Load, Save and Stat for them.
+func (s *Service) Forwards(capability any) bool {
+	switch capability.(type) {
+	case *artifactx.Browser, *artifactx.HealthChecker, *artifactx.Locker, *artifactx.Pinner,
+		*artifactx.Relabeler, *artifactx.Uploader, *artifactx.SessionCloner, *artifactx.RetentionSetter,
+		*artifactx.Checker:
+		return true
+	}
+	return false
+}
+
+// listing returns s as a service whose only capabilities are Stat and the
+// [artifactx.Browser] of the decorated service, for the listing helpers of
+// artifactx to describe assembled versions and leave chunk artifacts out.
+func (s *Service) listing() artifact.Service {
+	if b, ok := artifactx.As[artifactx.Browser](s.Service); ok {
+		return struct {
+			artifact.Service
+			artifactx.Stater
+			artifactx.Browser
+		}{s, s, b}
+	}
+	return struct {
+		artifact.Service
+		artifactx.Stater
+	}{s, s}
+}
+
+// ListDetailed implements [artifactx.DetailedLister]. Files are listed
+// with List and the size of their assembled latest version.
+func (s *Service) ListDetailed(ctx context.Context, req *artifactx.ListDetailedRequest) ([]*artifactx.FileInfo, error) {
+	return artifactx.ListDetailed(ctx, s.listing(), req)
+}
+
+// ListRecent implements [artifactx.RecentLister] on top of the
+// [artifactx.Browser] of the decorated service and ListDetailed.
+func (s *Service) ListRecent(ctx context.Context, appName, userID string, limit int) ([]*artifactx.RecentArtifact, error) {
+	return artifactx.ListRecent(ctx, s.listing(), appName, userID, limit)
+}
+
+// ListVersions implements [artifactx.VersionLister], with the size and
+// content type of the assembled versions.
+func (s *Service) ListVersions(ctx context.Context, req *artifactx.ListVersionsRequest) ([]*artifactx.Attributes, error) {
+	if IsChunkFile(req.FileName) {
+		return nil, fmt.Errorf("artifact '%s' not found: %w", req.FileName, fs.ErrNotExist)
+	}
+	return artifactx.ListVersions(ctx, s.listing(), req)
+}
+
+// FindFile implements [artifactx.FileFinder]. Chunk artifacts are not
+// found.
+func (s *Service) FindFile(ctx context.Context, appName, userID, fileName string) ([]artifactx.Ref, error) {
+	if IsChunkFile(fileName) {
+		return nil, nil
+	}
+	if f, ok := artifactx.As[artifactx.FileFinder](s.Service); ok {
+		return f.FindFile(ctx, appName, userID, fileName)
+	}
+	return artifactx.FindFile(ctx, s.listing(), appName, userID, fileName)
+}
+
+// loadStored loads the version req names as stored, for decorated services
+// that cannot describe or stream versions, and returns its attributes with
+// its manifest if it is chunked, or its content otherwise.
+func (s *Service) loadStored(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Attributes, *Manifest, []byte, error) {
+	versioned := *req
+	if versioned.Version == 0 {
+		resp, err := s.Service.Versions(ctx, &artifact.VersionsRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+		})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if len(resp.Versions) == 0 {
+			return nil, nil, nil, fmt.Errorf("artifact '%s' not found: %w", req.FileName, fs.ErrNotExist)
+		}
+		versioned.Version = slices.Max(resp.Versions)
+	}
+	resp, err := s.Service.Load(ctx, &versioned)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	m, ok, err := ParseManifest(resp.Part)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("artifact '%s': %w", req.FileName, err)
+	}
+	if ok {
+		return &artifactx.Attributes{Version: versioned.Version, ContentType: m.ContentType, Size: m.Size}, m, nil, nil
+	}
+	data, contentType, err := artifactx.EncodePart(resp.Part)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return &artifactx.Attributes{Version: versioned.Version, ContentType: contentType, Size: int64(len(data))}, nil, data, nil
+}
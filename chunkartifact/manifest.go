@@ -0,0 +1,131 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunkartifact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// ManifestContentType is the media type of a version stored as chunks. The
+// part holds the JSON encoded [Manifest].
+const ManifestContentType = "application/vnd.adk.artifact-manifest+json"
+
+// ChunkFilePrefix starts the names of the artifacts holding chunks, followed
+// by the hex SHA-256 of the chunk. Chunks of "user:" files are user scoped
+// too.
+const ChunkFilePrefix = ".chunk-"
+
+// Manifest describes a version stored as chunks.
+type Manifest struct {
+	// ContentType is the media type of the assembled version.
+	ContentType string `json:"contentType"`
+	// Size is the size of the assembled version in bytes.
+	Size int64 `json:"size"`
+	// ChunkSize is the size of every chunk but the last.
+	ChunkSize int64   `json:"chunkSize"`
+	Chunks    []Chunk `json:"chunks"`
+}
+
+// Chunk is a part of the content of a version.
+type Chunk struct {
+	// SHA256 is the hex checksum of the chunk, naming the artifact holding
+	// it, see [ChunkFileName].
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// ChunkFileName returns the name of the artifact holding the chunk with
+// the hex checksum sum of a version of fileName.
+func ChunkFileName(fileName, sum string) string {
+	if artifactx.IsUserScoped(fileName) {
+		return "user:" + ChunkFilePrefix + sum
+	}
+	return ChunkFilePrefix + sum
+}
+
+// IsChunkFile reports whether fileName names an artifact holding a chunk.
+func IsChunkFile(fileName string) bool {
+	return strings.HasPrefix(strings.TrimPrefix(fileName, "user:"), ChunkFilePrefix)
+}
+
+// split returns the manifest of data cut into chunks of chunkSize bytes.
+func split(data []byte, contentType string, chunkSize int64) *Manifest {
+	m := &Manifest{ContentType: contentType, Size: int64(len(data)), ChunkSize: chunkSize}
+	for off := int64(0); off < m.Size; off += chunkSize {
+		chunk := data[off:min(off+chunkSize, m.Size)]
+		sum := sha256.Sum256(chunk)
+		m.Chunks = append(m.Chunks, Chunk{SHA256: hex.EncodeToString(sum[:]), Size: int64(len(chunk))})
+	}
+	return m
+}
+
+// offset returns the offset of chunk i in the assembled version.
+func (m *Manifest) offset(i int) int64 {
+	return int64(i) * m.ChunkSize
+}
+
+func (m *Manifest) validate() error {
+	var size int64
+	for i, c := range m.Chunks {
+		if len(c.SHA256) != sha256.Size*2 || strings.Trim(c.SHA256, "0123456789abcdef") != "" {
+			return fmt.Errorf("chunk %d has an invalid checksum %q", i, c.SHA256)
+		}
+		if c.Size <= 0 || c.Size > m.ChunkSize || (i < len(m.Chunks)-1 && c.Size != m.ChunkSize) {
+			return fmt.Errorf("chunk %d has an invalid size %d", i, c.Size)
+		}
+		size += c.Size
+	}
+	if size != m.Size {
+		return fmt.Errorf("chunks add up to %d bytes, want %d", size, m.Size)
+	}
+	return nil
+}
+
+// part returns the part holding m.
+func (m *Manifest) part() (*genai.Part, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return genai.NewPartFromBytes(data, ManifestContentType), nil
+}
+
+// ParseManifest returns the manifest held by part, and false if part is not
+// a manifest.
+func ParseManifest(part *genai.Part) (*Manifest, bool, error) {
+	if part == nil || part.InlineData == nil {
+		return nil, false, nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(part.InlineData.MIMEType); mediaType != ManifestContentType {
+		return nil, false, nil
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(part.InlineData.Data, m); err != nil {
+		return nil, false, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, false, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return m, true, nil
+}
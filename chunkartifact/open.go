@@ -0,0 +1,171 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunkartifact
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// manifest returns the manifest of the version req names, or nil if the
+// version is stored whole. With a [artifactx.Stater], versions stored whole
+// are not loaded.
+func (s *Service) manifest(ctx context.Context, req *artifact.LoadRequest) (*Manifest, error) {
+	if stater, ok := artifactx.As[artifactx.Stater](s.Service); ok {
+		attrs, err := stater.Stat(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if attrs.ContentType != ManifestContentType {
+			return nil, nil
+		}
+	}
+	resp, err := s.Service.Load(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	m, _, err := ParseManifest(resp.Part)
+	if err != nil {
+		return nil, fmt.Errorf("artifact '%s': %w", req.FileName, err)
+	}
+	return m, nil
+}
+
+// Stat implements [artifactx.Stater]. The size and content type of a
+// chunked version are those of the assembled version. If the decorated
+// service cannot describe versions, the stored version is loaded.
+func (s *Service) Stat(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Attributes, error) {
+	stater, ok := artifactx.As[artifactx.Stater](s.Service)
+	if !ok {
+		attrs, _, _, err := s.loadStored(ctx, req)
+		return attrs, err
+	}
+	attrs, err := stater.Stat(ctx, req)
+	if err != nil || attrs.ContentType != ManifestContentType {
+		return attrs, err
+	}
+	m, err := s.manifest(ctx, &artifact.LoadRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: attrs.Version,
+	})
+	if err != nil {
+		return nil, err
+	}
+	attrs.ContentType, attrs.Size = m.ContentType, m.Size
+	return attrs, nil
+}
+
+// Open implements [artifactx.Opener]. Chunked versions are streamed, with
+// up to [Options.Concurrency] chunks loaded ahead of the reader. Versions
+// stored whole are loaded into memory if the decorated service cannot
+// stream.
+func (s *Service) Open(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Reader, error) {
+	opener, ok := artifactx.As[artifactx.Opener](s.Service)
+	if !ok {
+		attrs, m, data, err := s.loadStored(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if m == nil {
+			return &artifactx.Reader{ReadCloser: io.NopCloser(bytes.NewReader(data)), Attributes: *attrs}, nil
+		}
+		return s.openChunks(ctx, req, m, attrs), nil
+	}
+	attrs, err := s.Stat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	versioned := &artifact.LoadRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: attrs.Version,
+	}
+	m, err := s.manifest(ctx, versioned)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return opener.Open(ctx, versioned)
+	}
+	return s.openChunks(ctx, versioned, m, attrs), nil
+}
+
+// openChunks returns a reader streaming the chunks of m, the manifest of
+// the version with attrs.
+func (s *Service) openChunks(ctx context.Context, req *artifact.LoadRequest, m *Manifest, attrs *artifactx.Attributes) *artifactx.Reader {
+	versioned := *req
+	versioned.Version = attrs.Version
+	rctx, cancel := context.WithCancel(ctx)
+	return &artifactx.Reader{
+		ReadCloser: &chunkReader{ctx: rctx, cancel: cancel, s: s, req: &versioned, m: m},
+		Attributes: *attrs,
+	}
+}
+
+type chunkResult struct {
+	data []byte
+	err  error
+}
+
+// chunkReader reads the chunks of a version in order, loading the next
+// ones in the background.
+type chunkReader struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	s      *Service
+	req    *artifact.LoadRequest
+	m      *Manifest
+
+	next    int // next chunk to load
+	pending []chan chunkResult
+	buf     []byte
+	err     error
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		for r.next < len(r.m.Chunks) && len(r.pending) < r.s.concurrency {
+			ch := make(chan chunkResult, 1)
+			i := r.next
+			go func() {
+				data, err := r.s.loadChunk(r.ctx, r.req, r.m, i)
+				ch <- chunkResult{data, err}
+			}()
+			r.pending = append(r.pending, ch)
+			r.next++
+		}
+		if len(r.pending) == 0 {
+			return 0, io.EOF
+		}
+		res := <-r.pending[0]
+		r.pending = r.pending[1:]
+		r.buf, r.err = res.data, res.err
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// Close stops loading chunks ahead.
+func (r *chunkReader) Close() error {
+	r.cancel()
+	return nil
+}
@@ -0,0 +1,327 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chunkartifact provides an [artifact.Service] decorator that stores
+// large artifacts as a manifest plus fixed-size chunks, and assembles them
+// again on Load, so that multi-gigabyte datasets are deduplicated, resumed
+// and transferred in parallel:
+//
+//	srv := chunkartifact.NewService(backend, chunkartifact.Options{ChunkSize: 16 << 20})
+//
+// Saves of inline data or text of at least [Options.Threshold] bytes are cut
+// into chunks of [Options.ChunkSize] bytes. Each chunk is saved as an
+// artifact of its own, named after its SHA-256 by [ChunkFileName], unless an
+// artifact of that name exists: chunks shared by versions, or saved by an
+// interrupted Save of the same data, are stored once. The version itself
+// holds a [Manifest] listing the chunks.
+//
+// Load, Stat and Open return the assembled version, checking the checksum of
+// every chunk; Open streams the chunks instead of assembling them in
+// memory. List leaves chunk artifacts out. Deleting a version leaves its
+// chunks, which other versions may share, to [Service.Sweep].
+//
+// Save, Load, List, Stat, Open and the listings of versions and files are
+// decorated. The other capabilities of the decorated service are forwarded
+// only if they are not affected by chunking, see [Service.Forwards], and
+// versions written through them, such as with [artifactx.Uploader], are
+// stored whole.
+package chunkartifact
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Defaults of [Options].
+const (
+	DefaultChunkSize   = 8 << 20
+	DefaultThreshold   = 32 << 20
+	DefaultConcurrency = 8
+)
+
+// ErrCorrupted is returned when a chunk does not match its checksum.
+var ErrCorrupted = errors.New("chunk does not match its checksum")
+
+// Options configures [NewService].
+type Options struct {
+	// ChunkSize is the size of the chunks; zero means [DefaultChunkSize].
+	ChunkSize int64
+	// Threshold is the size from which versions are chunked; zero means
+	// [DefaultThreshold].
+	Threshold int64
+	// Concurrency is the number of chunks saved or loaded at once; zero
+	// means [DefaultConcurrency].
+	Concurrency int
+}
+
+// Service is an [artifact.Service] that stores large artifacts as chunks.
+type Service struct {
+	artifact.Service
+	chunkSize   int64
+	threshold   int64
+	concurrency int
+}
+
+var (
+	_ artifactx.Wrapper = (*Service)(nil)
+	_ artifactx.Stater  = (*Service)(nil)
+	_ artifactx.Opener  = (*Service)(nil)
+	_ artifactx.Pruner  = (*Service)(nil)
+)
+
+// NewService returns a service that stores large artifacts in next as
+// chunks.
+func NewService(next artifact.Service, opts Options) *Service {
+	s := &Service{Service: next, chunkSize: opts.ChunkSize, threshold: opts.Threshold, concurrency: opts.Concurrency}
+	if s.chunkSize <= 0 {
+		s.chunkSize = DefaultChunkSize
+	}
+	if s.threshold <= 0 {
+		s.threshold = DefaultThreshold
+	}
+	if s.concurrency <= 0 {
+		s.concurrency = DefaultConcurrency
+	}
+	return s
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// Save implements [artifact.Service].
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	if IsChunkFile(req.FileName) {
+		return nil, fmt.Errorf("artifact name %q is reserved for chunks", req.FileName)
+	}
+	part := req.Part
+	if part == nil || (part.InlineData == nil && part.Text == "") {
+		return s.Service.Save(ctx, req)
+	}
+	data, contentType, err := artifactx.EncodePart(part)
+	if err != nil || int64(len(data)) < s.threshold {
+		return s.Service.Save(ctx, req)
+	}
+
+	m := split(data, contentType, s.chunkSize)
+	if err := s.saveChunks(ctx, req, m, data); err != nil {
+		return nil, err
+	}
+	manifest, err := m.part()
+	if err != nil {
+		return nil, err
+	}
+	saved := *req
+	saved.Part = manifest
+	return s.Service.Save(ctx, &saved)
+}
+
+// saveChunks saves the chunks of m missing from the scope of req.
+func (s *Service) saveChunks(ctx context.Context, req *artifact.SaveRequest, m *Manifest, data []byte) error {
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.concurrency)
+	seen := map[string]bool{}
+	for i, c := range m.Chunks {
+		if seen[c.SHA256] {
+			continue
+		}
+		seen[c.SHA256] = true
+		chunk := data[m.offset(i) : m.offset(i)+c.Size]
+		name := ChunkFileName(req.FileName, c.SHA256)
+		g.Go(func() error {
+			resp, err := s.Service.Versions(gctx, &artifact.VersionsRequest{
+				AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: name,
+			})
+			if err == nil && len(resp.Versions) > 0 {
+				return nil // stored already
+			}
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("failed to look up chunk %d: %w", i, err)
+			}
+			if _, err := s.Service.Save(gctx, &artifact.SaveRequest{
+				AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: name,
+				Part: genai.NewPartFromBytes(chunk, "application/octet-stream"),
+			}); err != nil {
+				return fmt.Errorf("failed to save chunk %d: %w", i, err)
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// loadChunk loads chunk i of m, a version of fileName, checking it.
+func (s *Service) loadChunk(ctx context.Context, req *artifact.LoadRequest, m *Manifest, i int) ([]byte, error) {
+	c := m.Chunks[i]
+	resp, err := s.Service.Load(artifactx.WithoutConditions(ctx), &artifact.LoadRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: ChunkFileName(req.FileName, c.SHA256),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chunk %d of artifact '%s': %w", i, req.FileName, err)
+	}
+	data, _, err := artifactx.EncodePart(resp.Part)
+	if err != nil {
+		return nil, err
+	}
+	if sum := sha256.Sum256(data); int64(len(data)) != c.Size || hex.EncodeToString(sum[:]) != c.SHA256 {
+		return nil, fmt.Errorf("chunk %d of artifact '%s': %w", i, req.FileName, ErrCorrupted)
+	}
+	return data, nil
+}
+
+// Load implements [artifact.Service].
+func (s *Service) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	resp, err := s.Service.Load(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	m, ok, err := ParseManifest(resp.Part)
+	if err != nil {
+		return nil, fmt.Errorf("artifact '%s': %w", req.FileName, err)
+	}
+	if !ok {
+		return resp, nil
+	}
+	if err := artifactx.CheckLoadSize(m.Size, artifactx.MaxLoadSize(ctx, 0)); err != nil {
+		return nil, fmt.Errorf("artifact '%s': %w", req.FileName, err)
+	}
+
+	data := make([]byte, m.Size)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.concurrency)
+	for i := range m.Chunks {
+		g.Go(func() error {
+			chunk, err := s.loadChunk(gctx, req, m, i)
+			if err != nil {
+				return err
+			}
+			copy(data[m.offset(i):], chunk)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	part, err := artifactx.DecodePart(data, m.ContentType)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode artifact '%s': %w", req.FileName, err)
+	}
+	return &artifact.LoadResponse{Part: part}, nil
+}
+
+// List implements [artifact.Service], leaving out chunk artifacts.
+func (s *Service) List(ctx context.Context, req *artifact.ListRequest) (*artifact.ListResponse, error) {
+	resp, err := s.Service.List(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &artifact.ListResponse{FileNames: slices.DeleteFunc(slices.Clone(resp.FileNames), IsChunkFile)}, nil
+}
+
+// Prune implements [artifactx.Pruner] by sweeping every session of the
+// store, see [Service.Sweep], which the decorated service must be able to
+// walk with [artifactx.Walker]. Like Sweep, it must not run while versions
+// are saved. The user scoped chunks of users without session scoped
+// artifacts are not swept.
+func (s *Service) Prune(ctx context.Context) (int, error) {
+	walker, ok := artifactx.As[artifactx.Walker](s.Service)
+	if !ok {
+		return 0, fmt.Errorf("prune: %w", errors.ErrUnsupported)
+	}
+	refs, err := artifactx.Artifacts(ctx, walker)
+	if err != nil {
+		return 0, err
+	}
+	swept := map[[3]string]bool{}
+	deleted := 0
+	for _, ref := range refs {
+		session := [3]string{ref.AppName, ref.UserID, ref.SessionID}
+		if ref.SessionID == artifactx.UserNamespace || swept[session] {
+			continue
+		}
+		swept[session] = true
+		n, err := s.Sweep(ctx, ref.AppName, ref.UserID, ref.SessionID)
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// Sweep deletes the chunk artifacts of a session, and of the user scope of
+// its user, that no version of the other artifacts listed with the session
+// refers to, and returns how many it deleted. It must not run while
+// versions are saved to the session: their chunks could be deleted before
+// their manifest is saved.
+func (s *Service) Sweep(ctx context.Context, appName, userID, sessionID string) (int, error) {
+	resp, err := s.Service.List(ctx, &artifact.ListRequest{AppName: appName, UserID: userID, SessionID: sessionID})
+	if err != nil {
+		return 0, err
+	}
+	referenced := map[string]bool{}
+	var chunks []string
+	for _, name := range resp.FileNames {
+		if IsChunkFile(name) {
+			chunks = append(chunks, name)
+			continue
+		}
+		versions, err := s.Service.Versions(ctx, &artifact.VersionsRequest{AppName: appName, UserID: userID, SessionID: sessionID, FileName: name})
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // deleted since listing
+		}
+		if err != nil {
+			return 0, err
+		}
+		for _, v := range versions.Versions {
+			req := &artifact.LoadRequest{AppName: appName, UserID: userID, SessionID: sessionID, FileName: name, Version: v}
+			m, err := s.manifest(ctx, req)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return 0, err
+			}
+			if m == nil {
+				continue
+			}
+			for _, c := range m.Chunks {
+				referenced[ChunkFileName(name, c.SHA256)] = true
+			}
+		}
+	}
+	deleted := 0
+	for _, name := range chunks {
+		if referenced[name] {
+			continue
+		}
+		if err := s.Service.Delete(ctx, &artifact.DeleteRequest{AppName: appName, UserID: userID, SessionID: sessionID, FileName: name}); err != nil {
+			return deleted, fmt.Errorf("failed to delete chunk artifact '%s': %w", name, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,231 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chunkartifact_test
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/chunkartifact"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+)
+
+func chunkFiles(t *testing.T, backend artifact.Service) []string {
+	t.Helper()
+	resp, err := backend.List(t.Context(), &artifact.ListRequest{AppName: "app", UserID: "user", SessionID: "session"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return slices.DeleteFunc(resp.FileNames, func(name string) bool { return !chunkartifact.IsChunkFile(name) })
+}
+
+func TestService(t *testing.T) {
+	ctx := t.Context()
+	backend, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := chunkartifact.NewService(backend, chunkartifact.Options{ChunkSize: 4, Threshold: 10, Concurrency: 2})
+	req := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "dataset.bin"}
+	save := func(fileName string, part *genai.Part) {
+		t.Helper()
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: fileName, Part: part}); err != nil {
+			t.Fatalf("Save(%s) failed: %v", fileName, err)
+		}
+	}
+
+	data := []byte("aaaabbbbaaaaccccdd") // chunks aaaa twice
+	save(req.FileName, genai.NewPartFromBytes(data, "application/x-parquet"))
+	if got := chunkFiles(t, backend); len(got) != 4 {
+		t.Errorf("chunk artifacts = %q, want 4", got)
+	}
+	stored, err := backend.Load(ctx, req)
+	if err != nil || stored.Part.InlineData.MIMEType != chunkartifact.ManifestContentType {
+		t.Fatalf("backend Load() = (%v, %v), want a manifest", stored, err)
+	}
+	resp, err := srv.Load(ctx, req)
+	if err != nil || !bytes.Equal(resp.Part.InlineData.Data, data) || resp.Part.InlineData.MIMEType != "application/x-parquet" {
+		t.Errorf("Load() = (%v, %v), want the assembled data", resp, err)
+	}
+	attrs, err := srv.Stat(ctx, req)
+	if err != nil || attrs.Size != int64(len(data)) || attrs.ContentType != "application/x-parquet" || attrs.Version != 1 {
+		t.Errorf("Stat() = (%+v, %v), want the assembled attributes", attrs, err)
+	}
+	r, err := srv.Open(ctx, req)
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("Open() read (%q, %v), want %q", got, err, data)
+	}
+
+	// Versions share the chunks they have in common.
+	save(req.FileName, genai.NewPartFromBytes([]byte("aaaabbbbeeee"), "application/x-parquet"))
+	if got := chunkFiles(t, backend); len(got) != 5 {
+		t.Errorf("chunk artifacts after a second version = %q, want 5", got)
+	}
+	// Small parts are stored whole; large text is chunked like data.
+	save("notes.txt", genai.NewPartFromText("short"))
+	long := strings.Repeat("0123456789", 3)
+	save("log.txt", genai.NewPartFromText(long))
+	if resp, err := srv.Load(ctx, &artifact.LoadRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: "log.txt"}); err != nil || resp.Part.Text != long {
+		t.Errorf("Load() of chunked text = (%v, %v), want %q", resp, err, long)
+	}
+	list, err := srv.List(ctx, &artifact.ListRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID})
+	if err != nil || !slices.Equal(list.FileNames, []string{"dataset.bin", "log.txt", "notes.txt"}) {
+		t.Errorf("List() = (%v, %v), want the artifacts without chunks", list, err)
+	}
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: chunkFiles(t, backend)[0], Part: genai.NewPartFromText("x")}); err == nil {
+		t.Error("Save() to a chunk artifact succeeded")
+	}
+
+	// Sweep keeps the chunks of remaining versions.
+	if err := srv.Delete(ctx, &artifact.DeleteRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	n, err := srv.Sweep(ctx, req.AppName, req.UserID, req.SessionID)
+	if err != nil || n != 2 {
+		t.Errorf("Sweep() = (%d, %v), want the cccc and dd chunks deleted", n, err)
+	}
+	if resp, err := srv.Load(ctx, req); err != nil || string(resp.Part.InlineData.Data) != "aaaabbbbeeee" {
+		t.Errorf("Load() after Sweep() = (%v, %v), want version 2", resp, err)
+	}
+}
+
+func TestServiceCorruptedChunk(t *testing.T) {
+	ctx := t.Context()
+	backend, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := chunkartifact.NewService(backend, chunkartifact.Options{ChunkSize: 4, Threshold: 8})
+	req := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "user:model.bin"}
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Part: genai.NewPartFromBytes([]byte("weights!"), "application/octet-stream")}); err != nil {
+		t.Fatal(err)
+	}
+	chunks := chunkFiles(t, backend)
+	if len(chunks) != 2 || !strings.HasPrefix(chunks[0], "user:") {
+		t.Fatalf("chunk artifacts = %q, want 2 user scoped chunks", chunks)
+	}
+	if _, err := backend.Save(ctx, &artifact.SaveRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: chunks[0], Part: genai.NewPartFromBytes([]byte("evil"), "application/octet-stream"), Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Load(ctx, req); !errors.Is(err, chunkartifact.ErrCorrupted) {
+		t.Errorf("Load() of a corrupted chunk = %v, want error(%v)", err, chunkartifact.ErrCorrupted)
+	}
+	if _, err := srv.Load(artifactx.WithMaxLoadSize(ctx, 4), req); !errors.Is(err, artifactx.ErrTooLarge) {
+		t.Errorf("Load() over the size limit = %v, want error(%v)", err, artifactx.ErrTooLarge)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	ctx := t.Context()
+	backend, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := chunkartifact.NewService(backend, chunkartifact.Options{ChunkSize: 4, Threshold: 8})
+	for _, session := range []string{"s1", "s2"} {
+		for _, data := range []string{"aaaabbbb", "aaaacccc"} {
+			if _, err := srv.Save(ctx, &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: session, FileName: "data.bin", Part: genai.NewPartFromBytes([]byte(data), "application/octet-stream")}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := srv.Delete(ctx, &artifact.DeleteRequest{AppName: "app", UserID: "user", SessionID: session, FileName: "data.bin", Version: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := artifactx.Prune(ctx, srv); err != nil || n != 2 {
+		t.Errorf("Prune() = (%d, %v), want the bbbb chunk of both sessions deleted", n, err)
+	}
+	if resp, err := srv.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "s2", FileName: "data.bin"}); err != nil || string(resp.Part.InlineData.Data) != "aaaacccc" {
+		t.Errorf("Load() after Prune() = (%v, %v), want version 2", resp, err)
+	}
+}
+
+func TestServiceCapabilities(t *testing.T) {
+	ctx := t.Context()
+	backend, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := chunkartifact.NewService(backend, chunkartifact.Options{ChunkSize: 4, Threshold: 8})
+	data := []byte("aaaabbbbcccc")
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "data.bin", Part: genai.NewPartFromBytes(data, "application/x-parquet")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Listings describe assembled versions and leave chunks out.
+	files, err := artifactx.ListDetailed(ctx, srv, &artifactx.ListDetailedRequest{AppName: "app", UserID: "user", SessionID: "session"})
+	if err != nil || len(files) != 1 || files[0].FileName != "data.bin" || files[0].Size != int64(len(data)) {
+		t.Errorf("ListDetailed() = (%v, %v), want data.bin of %d bytes", files, err, len(data))
+	}
+	recent, err := artifactx.ListRecent(ctx, srv, "app", "user", 0)
+	if err != nil || len(recent) != 1 || recent[0].FileName != "data.bin" || recent[0].Size != int64(len(data)) {
+		t.Errorf("ListRecent() = (%v, %v), want data.bin of %d bytes", recent, err, len(data))
+	}
+	versions, err := artifactx.ListVersions(ctx, srv, &artifactx.ListVersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "data.bin"})
+	if err != nil || len(versions) != 1 || versions[0].Size != int64(len(data)) || versions[0].ContentType != "application/x-parquet" {
+		t.Errorf("ListVersions() = (%v, %v), want the assembled version", versions, err)
+	}
+	refs, err := artifactx.FindFile(ctx, srv, "app", "user", chunkFiles(t, backend)[0])
+	if err != nil || len(refs) != 0 {
+		t.Errorf("FindFile() of a chunk = (%v, %v), want none", refs, err)
+	}
+
+	// Capabilities acting on stored manifests are not forwarded.
+	if _, ok := artifactx.As[artifactx.Walker](srv); ok {
+		t.Error("As[Walker]() found the walker of the backend")
+	}
+	if _, ok := artifactx.As[artifactx.JSONUpdater](srv); ok {
+		t.Error("As[JSONUpdater]() found the updater of the backend")
+	}
+	if _, ok := artifactx.As[artifactx.Pinner](srv); !ok {
+		t.Error("As[Pinner]() did not find the pinner of the backend")
+	}
+}
+
+func TestServiceWithoutOpener(t *testing.T) {
+	ctx := t.Context()
+	srv := chunkartifact.NewService(artifact.InMemoryService(), chunkartifact.Options{ChunkSize: 4, Threshold: 8})
+	for _, data := range []string{"aaaabbbbcccc", "small"} {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "data.bin", Part: genai.NewPartFromBytes([]byte(data), "application/octet-stream")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for version, want := range []string{"small", "aaaabbbbcccc", "small"} {
+		req := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "data.bin", Version: int64(version)}
+		wantVersion := cmp.Or(int64(version), 2)
+		attrs, err := srv.Stat(ctx, req)
+		if err != nil || attrs.Size != int64(len(want)) || attrs.Version != wantVersion {
+			t.Errorf("Stat(%d) = (%+v, %v), want %d bytes", version, attrs, err, len(want))
+		}
+		var buf bytes.Buffer
+		if _, _, err := artifactx.LoadTo(ctx, srv, req, &buf); err != nil || buf.String() != want {
+			t.Errorf("LoadTo(%d) = (%q, %v), want %q", version, buf.String(), err, want)
+		}
+	}
+}
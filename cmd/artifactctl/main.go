@@ -0,0 +1,484 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command artifactctl runs maintenance tasks against an artifact store.
+//
+// Usage:
+//
+//	artifactctl fsck [-repair] [-checksums] STORE
+//	artifactctl backup STORE (-archive FILE [-manifest FILE] | -dst-fs DIR | -dst-s3 BUCKET ...)
+//	artifactctl restore -archive FILE STORE
+//	artifactctl ls -app APP [-user USER [-session SESSION [-preview]]] STORE
+//	artifactctl report [-top N] [-stale-days DAYS] [-format json|csv] STORE
+//	artifactctl rebalance -shard NAME=URL -shard NAME=URL ...
+//	artifactctl lineage [-descendants] -ref APP/USER/SESSION/FILE/VERSION STORE
+//	artifactctl relabel [-prefix PREFIX] [-older-days DAYS] [-min-size BYTES] (-set KEY=VALUE | -remove KEY) ... STORE
+//	artifactctl abort-uploads [-older-hours HOURS] -s3 BUCKET [-endpoint URL] [-region REGION]
+//
+// STORE selects the artifact store: -fs DIR, -s3 BUCKET [-endpoint URL] [-region REGION],
+// or -blob URL for a bucket URL of a registered gocloud.dev blob driver
+// (s3:// and file:// are built in).
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	_ "gocloud.dev/blob/fileblob"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactbackup"
+	"github.com/chinglinwen/adk-artifact/artifactconfig"
+	"github.com/chinglinwen/adk-artifact/artifactlabel"
+	"github.com/chinglinwen/adk-artifact/artifactlineage"
+	"github.com/chinglinwen/adk-artifact/artifactreport"
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/blobartifact"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"github.com/chinglinwen/adk-artifact/s3artifact"
+	"github.com/chinglinwen/adk-artifact/shardartifact"
+)
+
+var commands = map[string]func(ctx context.Context, args []string) error{
+	"fsck":          fsck,
+	"backup":        backup,
+	"restore":       restore,
+	"ls":            ls,
+	"report":        report,
+	"rebalance":     rebalance,
+	"relabel":       relabel,
+	"lineage":       lineage,
+	"abort-uploads": abortUploads,
+}
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 || commands[os.Args[1]] == nil {
+		log.Fatalf("usage: artifactctl <command> [flags]\ncommands: fsck, backup, restore, ls, report, rebalance, relabel, lineage, abort-uploads")
+	}
+	if err := commands[os.Args[1]](context.Background(), os.Args[2:]); err != nil {
+		log.Fatalf("%s: %s", os.Args[1], err)
+	}
+}
+
+// backendFlags are the flags selecting the artifact store a command runs against.
+type backendFlags struct {
+	dir, bucket, endpoint, region, url, layout string
+}
+
+func (b *backendFlags) register(fs *flag.FlagSet, prefix string) {
+	fs.StringVar(&b.dir, prefix+"fs", "", "root directory of a file system store")
+	fs.StringVar(&b.bucket, prefix+"s3", "", "bucket name of an S3 store")
+	fs.StringVar(&b.endpoint, prefix+"endpoint", "", "custom S3 endpoint URL, e.g. for MinIO or SeaweedFS")
+	fs.StringVar(&b.region, prefix+"region", "", "S3 region")
+	fs.StringVar(&b.url, prefix+"blob", "", "bucket URL of a gocloud.dev blob store, e.g. file:///var/artifacts")
+	fs.StringVar(&b.layout, prefix+"layout", "default", `key layout: "default", "escaped", "zero-based" or "python"`)
+}
+
+func (b *backendFlags) open(ctx context.Context) (artifact.Service, error) {
+	keys, err := artifactconfig.KeyBuilder(b.layout)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case countSet(b.dir, b.bucket, b.url) > 1:
+		return nil, fmt.Errorf("only one of -fs, -s3 and -blob can be set")
+	case b.url != "":
+		return blobartifact.NewService(ctx, b.url, blobartifact.WithKeyBuilder(keys))
+	case b.dir != "":
+		return fsartifact.New(b.dir, fsartifact.WithKeyBuilder(keys))
+	case b.bucket != "":
+		var optFns []func(*config.LoadOptions) error
+		if b.region != "" {
+			optFns = append(optFns, config.WithRegion(b.region))
+		}
+		if b.endpoint != "" {
+			optFns = append(optFns, config.WithEndpointResolverWithOptions(aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+				return aws.Endpoint{
+					URL:               b.endpoint,
+					SigningRegion:     region,
+					HostnameImmutable: true,
+				}, nil
+			})))
+		}
+		return s3artifact.New(ctx, b.bucket, s3artifact.WithAWSConfig(optFns...), s3artifact.WithKeyBuilder(keys))
+	default:
+		return nil, fmt.Errorf("one of -fs, -s3 and -blob is required")
+	}
+}
+
+func countSet(values ...string) int {
+	n := 0
+	for _, v := range values {
+		if v != "" {
+			n++
+		}
+	}
+	return n
+}
+
+func fsck(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("fsck", flag.ExitOnError)
+	var backend backendFlags
+	backend.register(fs, "")
+	repair := fs.Bool("repair", false, "fix repairable anomalies")
+	checksums := fs.Bool("checksums", false, "download every version and verify its checksum")
+	metadata := fs.Bool("metadata", false, "read the metadata of every version and validate its format")
+	fs.Parse(args)
+
+	srv, err := backend.open(ctx)
+	if err != nil {
+		return err
+	}
+	c, ok := artifactx.As[artifactx.Checker](srv)
+	if !ok {
+		return fmt.Errorf("backend does not support consistency checks")
+	}
+	report, err := c.Check(ctx, &artifactx.CheckOptions{VerifyChecksums: *checksums, VerifyMetadata: *metadata})
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	if *repair {
+		n, err := c.Repair(ctx, report)
+		if err != nil {
+			return err
+		}
+		log.Printf("repaired %d of %d anomalies", n, len(report.Anomalies))
+	}
+	return nil
+}
+
+func backup(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	var src, dst backendFlags
+	src.register(fs, "")
+	dst.register(fs, "dst-")
+	archive := fs.String("archive", "", "write the backup as a tar archive to this file")
+	manifest := fs.String("manifest", "", "manifest of previous backups; only newer versions are copied and the manifest is updated")
+	fs.Parse(args)
+
+	srcSrv, err := src.open(ctx)
+	if err != nil {
+		return err
+	}
+	var m *artifactbackup.Manifest
+	if *manifest != "" {
+		if m, err = artifactbackup.ReadManifest(*manifest); err != nil {
+			return err
+		}
+	}
+
+	var res *artifactbackup.Result
+	if *archive != "" {
+		f, err := os.Create(*archive)
+		if err != nil {
+			return err
+		}
+		res, err = artifactbackup.WriteArchive(ctx, srcSrv, f, m)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	} else {
+		dstSrv, err := dst.open(ctx)
+		if err != nil {
+			return fmt.Errorf("destination: %w", err)
+		}
+		if res, err = artifactbackup.Backup(ctx, srcSrv, dstSrv, m); err != nil {
+			return err
+		}
+	}
+	log.Printf("backed up %d versions (%d bytes)", res.Versions, res.Bytes)
+
+	if m != nil {
+		return m.WriteFile(*manifest)
+	}
+	return nil
+}
+
+func restore(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	var backend backendFlags
+	backend.register(fs, "")
+	archive := fs.String("archive", "", "tar archive written by backup")
+	fs.Parse(args)
+
+	if *archive == "" {
+		return fmt.Errorf("-archive is required")
+	}
+	srv, err := backend.open(ctx)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(*archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	res, err := artifactbackup.RestoreArchive(ctx, f, srv)
+	if err != nil {
+		return err
+	}
+	log.Printf("restored %d versions (%d bytes)", res.Versions, res.Bytes)
+	return nil
+}
+
+// ls prints the users of an app, the sessions of a user, or the files
+// visible from a session, one per line.
+func ls(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("ls", flag.ExitOnError)
+	var backend backendFlags
+	backend.register(fs, "")
+	app := fs.String("app", "", "app to list the users of")
+	user := fs.String("user", "", "user to list the sessions of")
+	session := fs.String("session", "", "session to list the files of")
+	preview := fs.Bool("preview", false, "print the detected type and a text snippet of the latest version of every file")
+	fs.Parse(args)
+
+	if *app == "" {
+		return fmt.Errorf("-app is required")
+	}
+	srv, err := backend.open(ctx)
+	if err != nil {
+		return err
+	}
+	var names []string
+	switch {
+	case *session != "":
+		if *user == "" {
+			return fmt.Errorf("-session requires -user")
+		}
+		resp, err := srv.List(ctx, &artifact.ListRequest{AppName: *app, UserID: *user, SessionID: *session})
+		if err != nil {
+			return err
+		}
+		names = resp.FileNames
+		if *preview {
+			reqs := make([]*artifact.LoadRequest, len(names))
+			for i, name := range names {
+				reqs[i] = &artifact.LoadRequest{AppName: *app, UserID: *user, SessionID: *session, FileName: name}
+			}
+			previews, err := artifactx.Previews(ctx, srv, reqs, 0)
+			for i, p := range previews {
+				if p != nil {
+					fmt.Printf("%s\t%s\t%d\t%s\n", names[i], p.DetectedType, p.Size, p.Snippet)
+				}
+			}
+			return err
+		}
+	default:
+		b, ok := artifactx.As[artifactx.Browser](srv)
+		if !ok {
+			return fmt.Errorf("backend does not support browsing")
+		}
+		if *user != "" {
+			names, err = b.ListSessions(ctx, *app, *user)
+		} else {
+			names, err = b.ListUsers(ctx, *app)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		fmt.Println(name)
+	}
+	return nil
+}
+
+// report prints the largest and most versioned artifacts and the stale
+// sessions of the store.
+func report(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	var backend backendFlags
+	backend.register(fs, "")
+	top := fs.Int("top", artifactreport.DefaultTop, "number of artifacts in each ranking")
+	staleDays := fs.Int("stale-days", int(artifactreport.DefaultStaleAfter/(24*time.Hour)), "days without saves after which a session is stale")
+	format := fs.String("format", "json", `output format: "json" or "csv"`)
+	fs.Parse(args)
+
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	srv, err := backend.open(ctx)
+	if err != nil {
+		return err
+	}
+	r, err := artifactreport.Analyze(ctx, srv, artifactreport.Options{
+		Top:        *top,
+		StaleAfter: time.Duration(*staleDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		return err
+	}
+	if *format == "csv" {
+		return r.WriteCSV(os.Stdout)
+	}
+	return r.WriteJSON(os.Stdout)
+}
+
+// rebalance moves the artifacts of a sharded store to the shards owning
+// them after shards were added or removed.
+func rebalance(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("rebalance", flag.ExitOnError)
+	var shards []shardartifact.Shard
+	fs.Func("shard", "shard as NAME=URL with a gocloud.dev blob bucket URL; repeat for every shard", func(v string) error {
+		name, url, ok := strings.Cut(v, "=")
+		if !ok || name == "" || url == "" {
+			return fmt.Errorf("want NAME=URL")
+		}
+		srv, err := blobartifact.NewService(ctx, url)
+		if err != nil {
+			return err
+		}
+		shards = append(shards, shardartifact.Shard{Name: name, Service: srv})
+		return nil
+	})
+	fs.Parse(args)
+
+	srv, err := shardartifact.NewService(shards, shardartifact.Options{})
+	if err != nil {
+		return err
+	}
+	res, err := srv.Rebalance(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("moved %d artifacts (%d versions)", res.Artifacts, res.Versions)
+	return nil
+}
+
+// relabel sets or removes metadata keys on the versions matching a filter.
+func relabel(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("relabel", flag.ExitOnError)
+	var backend backendFlags
+	backend.register(fs, "")
+	var filter artifactlabel.Filter
+	fs.StringVar(&filter.Prefix, "prefix", "", `only versions whose "app/user/session/file/version" ref starts with this`)
+	olderDays := fs.Int("older-days", 0, "only versions created at least this many days ago")
+	newerDays := fs.Int("newer-days", 0, "only versions created less than this many days ago")
+	fs.Int64Var(&filter.MinSize, "min-size", 0, "only versions of at least this many bytes")
+	fs.Int64Var(&filter.MaxSize, "max-size", 0, "only versions of at most this many bytes")
+	change := &artifactx.MetadataChange{Set: map[string]string{}}
+	fs.Func("set", "metadata to set as KEY=VALUE; repeat for every key", func(v string) error {
+		k, val, ok := strings.Cut(v, "=")
+		if !ok {
+			return fmt.Errorf("want KEY=VALUE")
+		}
+		change.Set[k] = val
+		return nil
+	})
+	fs.Func("remove", "metadata key to remove; repeat for every key", func(v string) error {
+		change.Remove = append(change.Remove, v)
+		return nil
+	})
+	concurrency := fs.Int("concurrency", artifactlabel.DefaultConcurrency, "number of versions changed at once")
+	fs.Parse(args)
+
+	filter.OlderThan = time.Duration(*olderDays) * 24 * time.Hour
+	filter.NewerThan = time.Duration(*newerDays) * 24 * time.Hour
+	srv, err := backend.open(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := artifactlabel.Relabel(ctx, srv, filter, change, artifactlabel.Options{
+		Concurrency: *concurrency,
+		OnProgress: func(p artifactlabel.Progress) {
+			if p.Scanned%1000 == 0 {
+				log.Printf("scanned %d versions, matched %d, changed %d, failed %d", p.Scanned, p.Matched, p.Changed, p.Failed)
+			}
+		},
+	})
+	if res != nil {
+		for _, f := range res.Failures {
+			log.Printf("%s: %s", f.Ref, f.Err)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("scanned %d versions, matched %d, changed %d, failed %d", res.Scanned, res.Matched, res.Changed, res.Failed)
+	return nil
+}
+
+// lineage prints the provenance graph of a version as JSON: the versions it
+// was derived from, or with -descendants the versions derived from it.
+func lineage(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("lineage", flag.ExitOnError)
+	var backend backendFlags
+	backend.register(fs, "")
+	refFlag := fs.String("ref", "", "version as APP/USER/SESSION/FILE/VERSION")
+	descendants := fs.Bool("descendants", false, "print the versions derived from the version instead, walking the whole store")
+	fs.Parse(args)
+
+	ref, err := artifactx.ParseRef(*refFlag)
+	if err != nil {
+		return err
+	}
+	srv, err := backend.open(ctx)
+	if err != nil {
+		return err
+	}
+	var out any
+	if *descendants {
+		g, err := artifactlineage.Index(ctx, srv)
+		if err != nil {
+			return err
+		}
+		out = g.Descendants(ref)
+	} else if out, err = artifactlineage.Lineage(ctx, srv, ref); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func abortUploads(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("abort-uploads", flag.ExitOnError)
+	var backend backendFlags
+	backend.register(fs, "")
+	olderHours := fs.Int("older-hours", 24, "only uploads initiated at least this many hours ago")
+	fs.Parse(args)
+
+	srv, err := backend.open(ctx)
+	if err != nil {
+		return err
+	}
+	c, ok := artifactx.As[s3artifact.MultipartCleaner](srv)
+	if !ok {
+		return fmt.Errorf("backend has no multipart uploads: use -s3")
+	}
+	n, err := c.AbortIncompleteUploads(ctx, time.Duration(*olderHours)*time.Hour)
+	if err != nil {
+		return err
+	}
+	log.Printf("aborted %d incomplete uploads", n)
+	return nil
+}
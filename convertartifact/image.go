@@ -0,0 +1,88 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convertartifact
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"strconv"
+)
+
+// convertJPEG encodes an image as JPEG. The "max-size" parameter bounds the
+// longer side in pixels, keeping the aspect ratio; "quality" is the JPEG
+// quality from 1 to 100.
+func convertJPEG(ctx context.Context, data []byte, params map[string]string) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	opts := &jpeg.Options{Quality: jpeg.DefaultQuality}
+	if q, ok := params["quality"]; ok {
+		if opts.Quality, err = strconv.Atoi(q); err != nil || opts.Quality < 1 || opts.Quality > 100 {
+			return nil, fmt.Errorf("invalid quality %q", q)
+		}
+	}
+	if m, ok := params["max-size"]; ok {
+		maxSize, err := strconv.Atoi(m)
+		if err != nil || maxSize < 1 {
+			return nil, fmt.Errorf("invalid max-size %q", m)
+		}
+		img = shrink(img, maxSize)
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, opts); err != nil {
+		return nil, fmt.Errorf("failed to encode jpeg: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// shrink scales img down so that neither side exceeds maxSize, averaging the
+// source pixels covered by every destination pixel.
+func shrink(img image.Image, maxSize int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSize && h <= maxSize {
+		return img
+	}
+	dw, dh := maxSize, max(1, h*maxSize/w)
+	if h > w {
+		dw, dh = max(1, w*maxSize/h), maxSize
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := range dh {
+		y0, y1 := b.Min.Y+y*h/dh, b.Min.Y+max((y+1)*h/dh, y*h/dh+1)
+		for x := range dw {
+			x0, x1 := b.Min.X+x*w/dw, b.Min.X+max((x+1)*w/dw, x*w/dw+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a, n = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(bl / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,195 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package convertartifact provides an [artifact.Service] decorator that
+// converts artifacts to the media type requested on Load.
+//
+// Callers state the types they accept with [WithAccept], optionally with
+// parameters understood by the converters:
+//
+//	ctx = convertartifact.WithAccept(ctx, "image/jpeg; max-size=512")
+//	resp, err := srv.Load(ctx, req)
+//
+// Artifacts already of an accepted type are returned as stored. Others are
+// converted by the first converter found for one of the accepted types, in
+// order. Converters for further formats, such as Markdown to HTML, are
+// registered with [NewService]:
+//
+//	converters := convertartifact.DefaultConverters()
+//	converters[convertartifact.Conversion{From: "text/markdown", To: "text/html"}] = renderMarkdown
+package convertartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// ErrNotAcceptable is returned by Load when the artifact is of none of the
+// accepted types and cannot be converted to any of them.
+var ErrNotAcceptable = errors.New("artifact cannot be converted to an accepted type")
+
+// Conversion identifies what a [Converter] converts. From may be a wildcard
+// such as "image/*"; To is a media type without parameters.
+type Conversion struct {
+	From, To string
+}
+
+// Converter converts data to the target type of its [Conversion]. params are
+// the parameters of the accepted type, e.g. {"max-size": "512"}.
+type Converter func(ctx context.Context, data []byte, params map[string]string) ([]byte, error)
+
+// DefaultConverters returns the built-in converters: any decodable image to
+// JPEG, honoring the "max-size" and "quality" parameters, and any text type
+// to text/plain.
+func DefaultConverters() map[Conversion]Converter {
+	return map[Conversion]Converter{
+		{From: "image/*", To: "image/jpeg"}: convertJPEG,
+		{From: "text/*", To: "text/plain"}:  convertText,
+	}
+}
+
+type acceptKey struct{}
+
+// WithAccept returns a copy of ctx that makes Load return the artifact as one
+// of the given media types, in order of preference.
+func WithAccept(ctx context.Context, mediaTypes ...string) context.Context {
+	return context.WithValue(ctx, acceptKey{}, mediaTypes)
+}
+
+// AcceptFrom returns the media types attached to ctx with [WithAccept].
+func AcceptFrom(ctx context.Context) []string {
+	accept, _ := ctx.Value(acceptKey{}).([]string)
+	return accept
+}
+
+// Service is an [artifact.Service] that converts loaded artifacts.
+type Service struct {
+	artifact.Service
+	converters map[Conversion]Converter
+}
+
+var _ artifactx.Wrapper = (*Service)(nil)
+
+// NewService returns a service that loads from next and converts with
+// converters, such as those of [DefaultConverters].
+func NewService(next artifact.Service, converters map[Conversion]Converter) *Service {
+	return &Service{Service: next, converters: converters}
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// Load implements [artifact.Service], converting the artifact if the context
+// carries accepted types.
+func (s *Service) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	resp, err := s.Service.Load(ctx, req)
+	accept := AcceptFrom(ctx)
+	if err != nil || len(accept) == 0 {
+		return resp, err
+	}
+	part, err := s.convert(ctx, resp.Part, accept)
+	if err != nil {
+		return nil, fmt.Errorf("artifact %q: %w", req.FileName, err)
+	}
+	return &artifact.LoadResponse{Part: part}, nil
+}
+
+// partData returns the content and media type of parts with inline content.
+func partData(part *genai.Part) ([]byte, string, bool) {
+	switch {
+	case part.InlineData != nil:
+		return part.InlineData.Data, part.InlineData.MIMEType, true
+	case part.Text != "":
+		return []byte(part.Text), "text/plain", true
+	}
+	return nil, "", false
+}
+
+func (s *Service) convert(ctx context.Context, part *genai.Part, accept []string) (*genai.Part, error) {
+	data, from, ok := partData(part)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s parts are not convertible", ErrNotAcceptable, artifactx.PartKind(part))
+	}
+	from, _, _ = strings.Cut(from, ";")
+	from = strings.TrimSpace(from)
+
+	type candidate struct {
+		to     string
+		params map[string]string
+	}
+	var candidates []candidate
+	for _, a := range accept {
+		to, params, err := mime.ParseMediaType(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid accepted type %q: %w", a, err)
+		}
+		// Parameters ask for a transformation, so only a bare type accepts
+		// the stored content as is.
+		if len(params) == 0 && matches(to, from) {
+			return part, nil
+		}
+		candidates = append(candidates, candidate{to, params})
+	}
+	for _, c := range candidates {
+		conv := s.converter(from, c.to)
+		if conv == nil {
+			continue
+		}
+		out, err := conv(ctx, data, c.params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %s to %s: %w", from, c.to, err)
+		}
+		if c.to == "text/plain" {
+			return genai.NewPartFromText(string(out)), nil
+		}
+		return genai.NewPartFromBytes(out, c.to), nil
+	}
+	return nil, fmt.Errorf("%w: %s to any of %v", ErrNotAcceptable, from, accept)
+}
+
+// converter returns the converter from one type to another, preferring an
+// exact source type over a wildcard.
+func (s *Service) converter(from, to string) Converter {
+	if conv := s.converters[Conversion{From: from, To: to}]; conv != nil {
+		return conv
+	}
+	if major, _, ok := strings.Cut(from, "/"); ok {
+		return s.converters[Conversion{From: major + "/*", To: to}]
+	}
+	return nil
+}
+
+// matches reports whether the media type pattern, possibly a wildcard,
+// matches mediaType.
+func matches(pattern, mediaType string) bool {
+	if pattern == "*/*" || pattern == mediaType {
+		return true
+	}
+	major, ok := strings.CutSuffix(pattern, "/*")
+	return ok && strings.HasPrefix(mediaType, major+"/")
+}
+
+func convertText(ctx context.Context, data []byte, params map[string]string) ([]byte, error) {
+	return data, nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convertartifact_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/convertartifact"
+)
+
+func pngImage(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newService(t *testing.T, fileName string, part *genai.Part) *convertartifact.Service {
+	t.Helper()
+	converters := convertartifact.DefaultConverters()
+	converters[convertartifact.Conversion{From: "text/markdown", To: "text/html"}] = func(ctx context.Context, data []byte, params map[string]string) ([]byte, error) {
+		title, _ := strings.CutPrefix(string(data), "# ")
+		return []byte("<h1>" + strings.TrimSpace(title) + "</h1>"), nil
+	}
+	srv := convertartifact.NewService(artifact.InMemoryService(), converters)
+	if _, err := srv.Save(t.Context(), &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: fileName, Part: part,
+	}); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	return srv
+}
+
+func load(ctx context.Context, srv artifact.Service, fileName string) (*genai.Part, error) {
+	resp, err := srv.Load(ctx, &artifact.LoadRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: fileName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Part, nil
+}
+
+func TestConvertImage(t *testing.T) {
+	srv := newService(t, "chart.png", genai.NewPartFromBytes(pngImage(t, 200, 100), "image/png"))
+
+	part, err := load(t.Context(), srv, "chart.png")
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if got := part.InlineData.MIMEType; got != "image/png" {
+		t.Errorf("Load() without accept MIMEType = %q, want image/png", got)
+	}
+
+	ctx := convertartifact.WithAccept(t.Context(), "image/webp", "image/jpeg; max-size=50; quality=70")
+	part, err = load(ctx, srv, "chart.png")
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if got := part.InlineData.MIMEType; got != "image/jpeg" {
+		t.Fatalf("Load() MIMEType = %q, want image/jpeg", got)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(part.InlineData.Data))
+	if err != nil {
+		t.Fatalf("converted data is not a jpeg: %v", err)
+	}
+	if cfg.Width != 50 || cfg.Height != 25 {
+		t.Errorf("converted size = %dx%d, want 50x25", cfg.Width, cfg.Height)
+	}
+}
+
+func TestConvertAcceptsStoredType(t *testing.T) {
+	data := pngImage(t, 4, 4)
+	srv := newService(t, "chart.png", genai.NewPartFromBytes(data, "image/png"))
+
+	ctx := convertartifact.WithAccept(t.Context(), "image/*")
+	part, err := load(ctx, srv, "chart.png")
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if !bytes.Equal(part.InlineData.Data, data) {
+		t.Error("Load() converted an artifact of an accepted type")
+	}
+}
+
+func TestConvertText(t *testing.T) {
+	srv := newService(t, "notes.md", genai.NewPartFromBytes([]byte("# Notes\n"), "text/markdown"))
+
+	ctx := convertartifact.WithAccept(t.Context(), "text/html")
+	part, err := load(ctx, srv, "notes.md")
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if got := string(part.InlineData.Data); got != "<h1>Notes</h1>" {
+		t.Errorf("Load() = %q, want <h1>Notes</h1>", got)
+	}
+
+	ctx = convertartifact.WithAccept(t.Context(), "text/plain")
+	part, err = load(ctx, srv, "notes.md")
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if got := part.Text; got != "# Notes\n" {
+		t.Errorf("Load() text = %q, want %q", got, "# Notes\n")
+	}
+}
+
+func TestConvertNotAcceptable(t *testing.T) {
+	srv := newService(t, "notes.txt", genai.NewPartFromText("plain"))
+
+	ctx := convertartifact.WithAccept(t.Context(), "image/jpeg")
+	if _, err := load(ctx, srv, "notes.txt"); !errors.Is(err, convertartifact.ErrNotAcceptable) {
+		t.Errorf("Load() error = %v, want %v", err, convertartifact.ErrNotAcceptable)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package costartifact attributes the S3 requests made by an artifact
+// service to the apps and sessions they serve and estimates what they cost,
+// to find the agents behind a bill.
+//
+//	meter := costartifact.NewMeter(costartifact.StandardPrices)
+//	backend, err := s3artifact.New(ctx, bucket, s3artifact.WithClientOptions(meter.Register))
+//	srv := costartifact.NewService(backend, meter)
+//	...
+//	report := meter.Report()
+//
+// The [Meter] counts the requests the S3 client actually sends, retries
+// included, and [Service] tells it which app and session each one is for.
+package costartifact
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sync"
+
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// Class is a class of S3 requests priced alike.
+type Class string
+
+const (
+	List   Class = "LIST"
+	Get    Class = "GET"
+	Put    Class = "PUT"
+	Delete Class = "DELETE"
+)
+
+// ClassOf returns the class of the S3 operation named operation, such as
+// "GetObject". Operations S3 prices like GET requests, including unknown
+// ones, are [Get].
+func ClassOf(operation string) Class {
+	switch operation {
+	case "ListObjects", "ListObjectsV2", "ListObjectVersions", "ListParts", "ListMultipartUploads", "ListBuckets":
+		return List
+	case "PutObject", "CopyObject", "CreateMultipartUpload", "UploadPart", "UploadPartCopy", "CompleteMultipartUpload",
+		"PutObjectTagging", "PutObjectAcl", "PutObjectRetention", "PutObjectLegalHold", "RestoreObject":
+		return Put
+	case "DeleteObject", "DeleteObjects", "AbortMultipartUpload", "DeleteObjectTagging":
+		return Delete
+	}
+	return Get
+}
+
+// Prices is the price in dollars of a thousand requests of each class.
+type Prices map[Class]float64
+
+// StandardPrices are the request prices of S3 Standard in us-east-1.
+// DELETE requests are free.
+var StandardPrices = Prices{List: 0.005, Put: 0.005, Get: 0.0004}
+
+// Scope identifies what requests are made for.
+type Scope struct {
+	AppName   string `json:"app"`
+	SessionID string `json:"session"`
+}
+
+type scopeKey struct{}
+
+// WithScope returns a copy of ctx whose S3 requests are attributed to the
+// session sessionID of appName. [Service] sets it for every request; use it
+// for requests made to the backend directly.
+func WithScope(ctx context.Context, appName, sessionID string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, Scope{AppName: appName, SessionID: sessionID})
+}
+
+// ScopeFrom returns the scope attached to ctx with [WithScope], and the
+// zero Scope for unattributed requests.
+func ScopeFrom(ctx context.Context) Scope {
+	s, _ := ctx.Value(scopeKey{}).(Scope)
+	return s
+}
+
+// Meter counts S3 requests by scope and class.
+type Meter struct {
+	prices Prices
+
+	mu     sync.Mutex
+	counts map[Scope]map[Class]int64
+}
+
+// NewMeter returns a meter estimating costs with prices.
+func NewMeter(prices Prices) *Meter {
+	return &Meter{prices: prices, counts: map[Scope]map[Class]int64{}}
+}
+
+// Register adds the middleware counting requests to the options of an S3
+// client: pass it to [s3.NewFromConfig], or to s3artifact.WithClientOptions.
+//
+//	client := s3.NewFromConfig(cfg, meter.Register)
+func (m *Meter) Register(o *s3.Options) {
+	o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+		// Last in the finalize step, past the retries: every attempt is billed.
+		return stack.Finalize.Add(middleware.FinalizeMiddlewareFunc("ADKCostMeter", m.count), middleware.After)
+	})
+}
+
+func (m *Meter) count(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+	m.Add(ScopeFrom(ctx), ClassOf(awsmiddleware.GetOperationName(ctx)), 1)
+	return next.HandleFinalize(ctx, in)
+}
+
+// Add records n requests of class c made for scope.
+func (m *Meter) Add(scope Scope, c Class, n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := m.counts[scope]
+	if counts == nil {
+		counts = map[Class]int64{}
+		m.counts[scope] = counts
+	}
+	counts[c] += n
+}
+
+// Reset forgets the requests counted so far, e.g. at the start of a billing
+// period.
+func (m *Meter) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	clear(m.counts)
+}
+
+// Usage is the requests made for a scope and their estimated cost.
+type Usage struct {
+	Scope
+	Requests map[Class]int64 `json:"requests"`
+	// Cost is the estimated cost in dollars.
+	Cost float64 `json:"cost"`
+}
+
+// Report is the usage of all scopes, the most expensive first.
+type Report struct {
+	Scopes []Usage `json:"scopes"`
+	// Requests and Cost are the totals of all scopes.
+	Requests map[Class]int64 `json:"requests"`
+	Cost     float64         `json:"cost"`
+}
+
+// Report returns the requests counted so far.
+func (m *Meter) Report() *Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := &Report{Requests: map[Class]int64{}}
+	for scope, counts := range m.counts {
+		u := Usage{Scope: scope, Requests: map[Class]int64{}}
+		for c, n := range counts {
+			u.Requests[c] = n
+			u.Cost += float64(n) * m.prices[c] / 1000
+			r.Requests[c] += n
+		}
+		r.Cost += u.Cost
+		r.Scopes = append(r.Scopes, u)
+	}
+	slices.SortFunc(r.Scopes, func(a, b Usage) int {
+		return cmp.Or(cmp.Compare(b.Cost, a.Cost), cmp.Compare(a.AppName, b.AppName), cmp.Compare(a.SessionID, b.SessionID))
+	})
+	return r
+}
+
+// ReportHandler serves the [Report] of m as JSON.
+func ReportHandler(m *Meter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(m.Report())
+	})
+}
@@ -0,0 +1,189 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package costartifact_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/costartifact"
+	"github.com/chinglinwen/adk-artifact/s3artifact"
+)
+
+// fakeS3 serves the requests of a path style client on a single bucket.
+func fakeS3(t *testing.T) *httptest.Server {
+	var (
+		mu      sync.Mutex
+		objects = map[string]string{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+			prefix := r.URL.Query().Get("prefix")
+			fmt.Fprint(w, `<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">`)
+			for k, v := range objects {
+				if strings.HasPrefix(k, prefix) {
+					fmt.Fprintf(w, "<Contents><Key>%s</Key><Size>%d</Size><LastModified>2025-01-01T00:00:00.000Z</LastModified></Contents>", k, len(v))
+				}
+			}
+			fmt.Fprint(w, "</ListBucketResult>")
+		case r.Method == http.MethodPut:
+			data, _ := io.ReadAll(r.Body)
+			objects[key] = string(data)
+		case r.Method == http.MethodGet, r.Method == http.MethodHead:
+			v, ok := objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("Content-Length", fmt.Sprint(len(v)))
+			if r.Method == http.MethodGet {
+				io.WriteString(w, v)
+			}
+		case r.Method == http.MethodDelete:
+			delete(objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMeter(t *testing.T) {
+	ctx := t.Context()
+	server := fakeS3(t)
+	meter := costartifact.NewMeter(costartifact.Prices{costartifact.Put: 5, costartifact.List: 5, costartifact.Get: 0.4})
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+	}, meter.Register)
+	backend, err := s3artifact.New(ctx, "bucket", s3artifact.WithClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := costartifact.NewService(backend, meter)
+
+	for _, session := range []string{"s1", "s1", "s2"} {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: session, FileName: "file", Part: genai.NewPartFromText("hello"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := srv.Delete(ctx, &artifact.DeleteRequest{AppName: "app", UserID: "user", SessionID: "s2", FileName: "file", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	report := meter.Report()
+	if len(report.Scopes) != 2 {
+		t.Fatalf("Report() scopes = %+v, want s1 and s2", report.Scopes)
+	}
+	s1, s2 := report.Scopes[0], report.Scopes[1]
+	if s1.SessionID != "s1" || s1.Requests[costartifact.Put] != 2 || s1.Requests[costartifact.List] != 2 {
+		t.Errorf("usage of s1 = %+v, want the most expensive with 2 LIST and 2 PUT", s1)
+	}
+	if s2.SessionID != "s2" || s2.Requests[costartifact.Put] != 1 || s2.Requests[costartifact.Delete] != 1 {
+		t.Errorf("usage of s2 = %+v, want 1 PUT and 1 DELETE", s2)
+	}
+	want := float64(s1.Requests[costartifact.List]+s1.Requests[costartifact.Put])*5/1000 + float64(s1.Requests[costartifact.Get])*0.4/1000
+	if math.Abs(s1.Cost-want) > 1e-9 {
+		t.Errorf("cost of s1 = %v, want %v", s1.Cost, want)
+	}
+	if report.Cost != s1.Cost+s2.Cost {
+		t.Errorf("Report().Cost = %v, want the sum %v", report.Cost, s1.Cost+s2.Cost)
+	}
+
+	rec := httptest.NewRecorder()
+	costartifact.ReportHandler(meter).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	var served costartifact.Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &served); err != nil || len(served.Scopes) != 2 {
+		t.Errorf("ReportHandler() = (%s, %v), want the report", rec.Body, err)
+	}
+
+	meter.Reset()
+	if report := meter.Report(); len(report.Scopes) != 0 || report.Cost != 0 {
+		t.Errorf("Report() after Reset() = %+v, want nothing", report)
+	}
+}
+
+func TestClassOf(t *testing.T) {
+	for op, want := range map[string]costartifact.Class{
+		"ListObjectsV2": costartifact.List,
+		"GetObject":     costartifact.Get,
+		"HeadObject":    costartifact.Get,
+		"PutObject":     costartifact.Put,
+		"CopyObject":    costartifact.Put,
+		"UploadPart":    costartifact.Put,
+		"DeleteObjects": costartifact.Delete,
+		"Unknown":       costartifact.Get,
+	} {
+		if got := costartifact.ClassOf(op); got != want {
+			t.Errorf("ClassOf(%q) = %s, want %s", op, got, want)
+		}
+	}
+}
+
+func TestServiceOpenLoaded(t *testing.T) {
+	ctx := t.Context()
+	server := fakeS3(t)
+	meter := costartifact.NewMeter(costartifact.Prices{})
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+	}, meter.Register)
+	backend, err := s3artifact.New(ctx, "bucket", s3artifact.WithClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The backend without its capabilities cannot stream.
+	srv := costartifact.NewService(struct{ artifact.Service }{backend}, meter)
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "s1", FileName: "file", Part: genai.NewPartFromText("hello"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	meter.Reset()
+	var buf strings.Builder
+	_, attrs, err := artifactx.LoadTo(ctx, srv, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "s1", FileName: "file"}, &buf)
+	if err != nil || buf.String() != "hello" || attrs.Version != 1 {
+		t.Fatalf("LoadTo() = (%q, %+v, %v), want version 1", buf.String(), attrs, err)
+	}
+	report := meter.Report()
+	if len(report.Scopes) != 1 || report.Scopes[0].SessionID != "s1" || report.Scopes[0].Requests[costartifact.Get] == 0 {
+		t.Errorf("Report() = %+v, want the loads attributed to s1", report)
+	}
+}
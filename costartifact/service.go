@@ -0,0 +1,89 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package costartifact
+
+import (
+	"context"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Service is an [artifact.Service] that attributes the requests of the
+// decorated service to the app and session of every call.
+//
+// Calls made through capability interfaces of the decorated service other
+// than [artifactx.Opener], such as [artifactx.Walker], are not attributed.
+type Service struct {
+	artifact.Service
+	meter *Meter
+}
+
+var (
+	_ artifactx.Wrapper = (*Service)(nil)
+	_ artifactx.Opener  = (*Service)(nil)
+)
+
+// NewService returns a service that forwards to next, attributing its
+// requests for m. The S3 client of next must be registered with m.
+func NewService(next artifact.Service, m *Meter) *Service {
+	return &Service{Service: next, meter: m}
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// Meter returns the meter the requests are counted by.
+func (s *Service) Meter() *Meter {
+	return s.meter
+}
+
+// Save implements [artifact.Service].
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	return s.Service.Save(WithScope(ctx, req.AppName, req.SessionID), req)
+}
+
+// Load implements [artifact.Service].
+func (s *Service) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	return s.Service.Load(WithScope(ctx, req.AppName, req.SessionID), req)
+}
+
+// Delete implements [artifact.Service].
+func (s *Service) Delete(ctx context.Context, req *artifact.DeleteRequest) error {
+	return s.Service.Delete(WithScope(ctx, req.AppName, req.SessionID), req)
+}
+
+// List implements [artifact.Service].
+func (s *Service) List(ctx context.Context, req *artifact.ListRequest) (*artifact.ListResponse, error) {
+	return s.Service.List(WithScope(ctx, req.AppName, req.SessionID), req)
+}
+
+// Versions implements [artifact.Service].
+func (s *Service) Versions(ctx context.Context, req *artifact.VersionsRequest) (*artifact.VersionsResponse, error) {
+	return s.Service.Versions(WithScope(ctx, req.AppName, req.SessionID), req)
+}
+
+// Open implements [artifactx.Opener]. Versions are loaded into memory, and
+// metered as loads, if the decorated service cannot stream.
+func (s *Service) Open(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Reader, error) {
+	ctx = WithScope(ctx, req.AppName, req.SessionID)
+	if opener, ok := artifactx.As[artifactx.Opener](s.Service); ok {
+		return opener.Open(ctx, req)
+	}
+	return artifactx.OpenLoaded(ctx, s.Service, req)
+}
@@ -0,0 +1,150 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deltaartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var (
+	_ artifactx.Forwarder      = (*Service)(nil)
+	_ artifactx.DetailedLister = (*Service)(nil)
+	_ artifactx.RecentLister   = (*Service)(nil)
+	_ artifactx.VersionLister  = (*Service)(nil)
+	_ artifactx.Uploader       = (*Service)(nil)
+)
+
+// Forwards implements [artifactx.Forwarder]. The capabilities of the
+// decorated service that would expose deltas, such as
+// [artifactx.URLSigner] and [artifactx.RangeOpener], or that remove or
+// rewrite versions without storing whole the versions based on them, such
+// as [artifactx.Pruner], [artifactx.Compactor] and [artifactx.JSONUpdater],
+// are not forwarded. The helpers of artifactx fall back to Load, Save and
+// Stat for them, or fail.
+func (s *Service) Forwards(capability any) bool {
+	switch capability.(type) {
+	case *artifactx.Browser, *artifactx.Walker, *artifactx.FileFinder, *artifactx.HealthChecker,
+		*artifactx.Prefetcher, *artifactx.ChangeWatcher, *artifactx.Locker, *artifactx.Pinner,
+		*artifactx.Relabeler, *artifactx.Mover, *artifactx.SessionCloner, *artifactx.Checker,
+		*artifactx.RetentionSetter, *artifactx.RetentionReporter:
+		return true
+	}
+	return false
+}
+
+// listing returns s as a service whose only capabilities are Stat and the
+// [artifactx.Browser] of the decorated service, for the listing helpers of
+// artifactx to describe rebuilt versions.
+func (s *Service) listing() artifact.Service {
+	if b, ok := artifactx.As[artifactx.Browser](s.Service); ok {
+		return struct {
+			artifact.Service
+			artifactx.Stater
+			artifactx.Browser
+		}{s, s, b}
+	}
+	return struct {
+		artifact.Service
+		artifactx.Stater
+	}{s, s}
+}
+
+// ListDetailed implements [artifactx.DetailedLister], with the size of the
+// rebuilt latest versions.
+func (s *Service) ListDetailed(ctx context.Context, req *artifactx.ListDetailedRequest) ([]*artifactx.FileInfo, error) {
+	return artifactx.ListDetailed(ctx, s.listing(), req)
+}
+
+// ListRecent implements [artifactx.RecentLister] on top of the
+// [artifactx.Browser] of the decorated service and ListDetailed.
+func (s *Service) ListRecent(ctx context.Context, appName, userID string, limit int) ([]*artifactx.RecentArtifact, error) {
+	return artifactx.ListRecent(ctx, s.listing(), appName, userID, limit)
+}
+
+// ListVersions implements [artifactx.VersionLister], with the size and
+// content type of the rebuilt versions.
+func (s *Service) ListVersions(ctx context.Context, req *artifactx.ListVersionsRequest) ([]*artifactx.Attributes, error) {
+	return artifactx.ListVersions(ctx, s.listing(), req)
+}
+
+// uploader returns the uploader of the decorated service.
+func (s *Service) uploader() (artifactx.Uploader, error) {
+	u, ok := artifactx.As[artifactx.Uploader](s.Service)
+	if !ok {
+		return nil, fmt.Errorf("upload: %w", errors.ErrUnsupported)
+	}
+	return u, nil
+}
+
+// BeginUpload implements [artifactx.Uploader]. Uploaded versions are
+// stored whole.
+func (s *Service) BeginUpload(ctx context.Context, req *artifactx.UploadRequest) (*artifactx.Upload, error) {
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	return u.BeginUpload(ctx, req)
+}
+
+// AppendChunk implements [artifactx.Uploader].
+func (s *Service) AppendChunk(ctx context.Context, uploadID string, offset int64, data []byte) (*artifactx.Upload, error) {
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	return u.AppendChunk(ctx, uploadID, offset, data)
+}
+
+// UploadStatus implements [artifactx.Uploader].
+func (s *Service) UploadStatus(ctx context.Context, uploadID string) (*artifactx.Upload, error) {
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	return u.UploadStatus(ctx, uploadID)
+}
+
+// CommitUpload implements [artifactx.Uploader]. Like before a Save, the
+// versions the retention of the decorated service keeps that are deltas
+// against versions it removes are stored whole first.
+func (s *Service) CommitUpload(ctx context.Context, uploadID string) (*artifact.SaveResponse, error) {
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	up, err := u.UploadStatus(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.rebase(ctx, up.AppName, up.UserID, up.SessionID, up.FileName, 0); err != nil {
+		return nil, err
+	}
+	return u.CommitUpload(ctx, uploadID)
+}
+
+// AbortUpload implements [artifactx.Uploader].
+func (s *Service) AbortUpload(ctx context.Context, uploadID string) error {
+	u, err := s.uploader()
+	if err != nil {
+		return err
+	}
+	return u.AbortUpload(ctx, uploadID)
+}
@@ -0,0 +1,223 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deltaartifact
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// DeltaContentType is the media type of a version stored as a delta
+// against an earlier version.
+const DeltaContentType = "application/vnd.adk.artifact-delta"
+
+// blockSize is the size of the blocks of the base matched in the target.
+const blockSize = 64
+
+// Operations of a delta.
+const (
+	opCopy   = 'c' // offset and length in the base
+	opInsert = 'i' // length and bytes
+)
+
+// header describes a version stored as a delta.
+type header struct {
+	// base is the version the delta applies to.
+	base int64
+	// depth is the number of deltas applied to rebuild the version from a
+	// version stored whole, including this one.
+	depth int64
+	// size and sum are the size and SHA-256 of the rebuilt version.
+	size        int64
+	sum         [sha256.Size]byte
+	contentType string
+}
+
+// encodeDelta returns the delta rebuilding target, of contentType, from
+// base, described by h without its size and checksum.
+func encodeDelta(h header, base, target []byte, contentType string) []byte {
+	h.size, h.sum, h.contentType = int64(len(target)), sha256.Sum256(target), contentType
+	buf := binary.AppendUvarint(nil, uint64(h.base))
+	buf = binary.AppendUvarint(buf, uint64(h.depth))
+	buf = binary.AppendUvarint(buf, uint64(h.size))
+	buf = append(buf, h.sum[:]...)
+	buf = binary.AppendUvarint(buf, uint64(len(h.contentType)))
+	buf = append(buf, h.contentType...)
+	return appendOps(buf, base, target)
+}
+
+// appendOps appends to buf the operations rebuilding target from base. The
+// common prefix and suffix are copied, and the blocks of the base found in
+// the rest of the target with a rolling hash are copied as far as they
+// extend; the rest is inserted.
+func appendOps(buf, base, target []byte) []byte {
+	prefix := 0
+	for prefix < len(base) && prefix < len(target) && base[prefix] == target[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(base)-prefix && suffix < len(target)-prefix && base[len(base)-1-suffix] == target[len(target)-1-suffix] {
+		suffix++
+	}
+	if prefix > 0 {
+		buf = appendCopy(buf, 0, prefix)
+	}
+
+	t := target[prefix : len(target)-suffix]
+	index := map[uint32]int{}
+	for off := 0; off+blockSize <= len(base); off += blockSize {
+		h := hashBlock(base[off : off+blockSize])
+		if _, ok := index[h]; !ok {
+			index[h] = off
+		}
+	}
+	lit, i := 0, 0
+	var h uint32
+	if len(t) >= blockSize {
+		h = hashBlock(t[:blockSize])
+	}
+	for i+blockSize <= len(t) {
+		if off, ok := index[h]; ok && bytes.Equal(base[off:off+blockSize], t[i:i+blockSize]) {
+			start, from := i, off
+			for from > 0 && start > lit && base[from-1] == t[start-1] {
+				start--
+				from--
+			}
+			end, to := i+blockSize, off+blockSize
+			for end < len(t) && to < len(base) && t[end] == base[to] {
+				end++
+				to++
+			}
+			buf = appendInsert(buf, t[lit:start])
+			buf = appendCopy(buf, from, end-start)
+			i, lit = end, end
+			if i+blockSize <= len(t) {
+				h = hashBlock(t[i : i+blockSize])
+			}
+			continue
+		}
+		if i+blockSize < len(t) {
+			h = (h-uint32(t[i])*hashPow)*hashMul + uint32(t[i+blockSize])
+		}
+		i++
+	}
+	buf = appendInsert(buf, t[lit:])
+
+	if suffix > 0 {
+		buf = appendCopy(buf, len(base)-suffix, suffix)
+	}
+	return buf
+}
+
+// hashMul is the multiplier of the rolling hash; hashPow is hashMul to the
+// power blockSize-1, the weight of the first byte of a block.
+const hashMul = 16777619
+
+var hashPow = func() uint32 {
+	p := uint32(1)
+	for range blockSize - 1 {
+		p *= hashMul
+	}
+	return p
+}()
+
+func hashBlock(b []byte) uint32 {
+	var h uint32
+	for _, c := range b {
+		h = h*hashMul + uint32(c)
+	}
+	return h
+}
+
+func appendCopy(buf []byte, off, n int) []byte {
+	buf = append(buf, opCopy)
+	buf = binary.AppendUvarint(buf, uint64(off))
+	return binary.AppendUvarint(buf, uint64(n))
+}
+
+func appendInsert(buf, data []byte) []byte {
+	if len(data) == 0 {
+		return buf
+	}
+	buf = append(buf, opInsert)
+	buf = binary.AppendUvarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+// decodeHeader returns the header of the delta data and its operations.
+func decodeHeader(data []byte) (header, []byte, error) {
+	var h header
+	var vals [3]uint64
+	for i := range vals {
+		v, n := binary.Uvarint(data)
+		if n <= 0 || v > 1<<62 {
+			return h, nil, ErrCorrupted
+		}
+		vals[i], data = v, data[n:]
+	}
+	h.base, h.depth, h.size = int64(vals[0]), int64(vals[1]), int64(vals[2])
+	if len(data) < sha256.Size {
+		return h, nil, ErrCorrupted
+	}
+	copy(h.sum[:], data)
+	data = data[sha256.Size:]
+	n, l := binary.Uvarint(data)
+	if l <= 0 || n > uint64(len(data)-l) {
+		return h, nil, ErrCorrupted
+	}
+	h.contentType = string(data[l : l+int(n)])
+	return h, data[l+int(n):], nil
+}
+
+// applyOps rebuilds the version described by h from base and the
+// operations ops, checking its size and checksum.
+func applyOps(h header, base, ops []byte) ([]byte, error) {
+	out := make([]byte, 0, h.size)
+	for len(ops) > 0 {
+		op := ops[0]
+		ops = ops[1:]
+		a, n := binary.Uvarint(ops)
+		if n <= 0 {
+			return nil, ErrCorrupted
+		}
+		ops = ops[n:]
+		switch op {
+		case opCopy:
+			length, n := binary.Uvarint(ops)
+			if n <= 0 || a > uint64(len(base)) || length > uint64(len(base))-a {
+				return nil, ErrCorrupted
+			}
+			ops = ops[n:]
+			out = append(out, base[a:a+length]...)
+		case opInsert:
+			if a > uint64(len(ops)) {
+				return nil, ErrCorrupted
+			}
+			out = append(out, ops[:a]...)
+			ops = ops[a:]
+		default:
+			return nil, ErrCorrupted
+		}
+		if int64(len(out)) > h.size {
+			return nil, ErrCorrupted
+		}
+	}
+	if int64(len(out)) != h.size || sha256.Sum256(out) != h.sum {
+		return nil, fmt.Errorf("%w: rebuilt version does not match its checksum", ErrCorrupted)
+	}
+	return out, nil
+}
@@ -0,0 +1,509 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package deltaartifact provides an [artifact.Service] decorator that stores
+// new versions of large text artifacts as deltas against the previous
+// version, with periodic full snapshots, and rebuilds them on Load, so that
+// documents saved many times per session with small edits take a fraction
+// of their size:
+//
+//	srv := deltaartifact.NewService(backend, deltaartifact.Options{SnapshotInterval: 20})
+//
+// Saves of inline data or text of at least [Options.Threshold] bytes, with
+// a text content type as reported by [artifactx.IsText], are compared with
+// the latest version. The new version is stored as a delta of content type
+// [DeltaContentType] unless the delta is more than half its size, or
+// [Options.SnapshotInterval] versions would have to be rebuilt to read it,
+// in which case it is stored whole. Saves to an explicit version are
+// stored whole.
+//
+// Load, Stat and Open return the rebuilt version, checking its checksum.
+// Deleting a single version first stores whole the versions that are
+// deltas against it. If the decorated service applies a retention, as
+// reported by [artifactx.RetentionReporter], the versions it keeps that are
+// deltas against versions it removes are stored whole before every Save
+// and upload commit. Versions deleted by other means, such as the eviction
+// of fsartifact under a space limit, break the versions based on them: do
+// not decorate services that evict versions.
+//
+// The capabilities of the decorated service that would see deltas rather
+// than rebuilt versions, or remove or rewrite versions, are not forwarded,
+// see [Service.Forwards]. Versions written through the forwarded ones,
+// such as [artifactx.Uploader], are stored whole.
+package deltaartifact
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Defaults of [Options].
+const (
+	DefaultThreshold        = 1 << 20
+	DefaultSnapshotInterval = 10
+)
+
+// ErrCorrupted is returned when a delta is malformed or does not rebuild
+// the version it was computed from.
+var ErrCorrupted = errors.New("corrupted delta")
+
+// Options configures [NewService].
+type Options struct {
+	// Threshold is the size from which versions are stored as deltas; zero
+	// means [DefaultThreshold].
+	Threshold int64
+	// SnapshotInterval bounds the number of versions in a row stored as
+	// deltas, and so the number of deltas applied by Load, to one less;
+	// zero means [DefaultSnapshotInterval].
+	SnapshotInterval int
+}
+
+// Service is an [artifact.Service] that stores large text artifacts as
+// deltas.
+type Service struct {
+	artifact.Service
+	threshold        int64
+	snapshotInterval int64
+}
+
+var (
+	_ artifactx.Wrapper = (*Service)(nil)
+	_ artifactx.Stater  = (*Service)(nil)
+	_ artifactx.Opener  = (*Service)(nil)
+)
+
+// NewService returns a service that stores large text artifacts in next as
+// deltas.
+func NewService(next artifact.Service, opts Options) *Service {
+	s := &Service{Service: next, threshold: opts.Threshold, snapshotInterval: int64(opts.SnapshotInterval)}
+	if s.threshold <= 0 {
+		s.threshold = DefaultThreshold
+	}
+	if s.snapshotInterval <= 0 {
+		s.snapshotInterval = DefaultSnapshotInterval
+	}
+	return s
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// baseContext returns the context of the loads of the versions a delta is
+// based on, which neither the conditions nor the load size limit of the
+// caller apply to.
+func baseContext(ctx context.Context) context.Context {
+	return artifactx.WithMaxLoadSize(artifactx.WithoutConditions(ctx), 0)
+}
+
+// Save implements [artifact.Service].
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	kept, err := s.rebase(ctx, req.AppName, req.UserID, req.SessionID, req.FileName, req.Version)
+	if err != nil {
+		return nil, err
+	}
+	part := req.Part
+	if req.Version > 0 || part == nil || (part.InlineData == nil && part.Text == "") {
+		return s.Service.Save(ctx, req)
+	}
+	data, contentType, err := artifactx.EncodePart(part)
+	if err != nil || int64(len(data)) < s.threshold || !artifactx.IsText(contentType) {
+		return s.Service.Save(ctx, req)
+	}
+	delta := s.delta(ctx, req, data, contentType, kept)
+	if delta == nil {
+		return s.Service.Save(ctx, req)
+	}
+	saved := *req
+	saved.Part = genai.NewPartFromBytes(delta, DeltaContentType)
+	return s.Service.Save(ctx, &saved)
+}
+
+// rebase stores whole the versions of an artifact that the retention of the
+// decorated service keeps once version, or the next version if it is zero,
+// is saved, but that are deltas against versions it removes then. It
+// returns whether the retention keeps a version then.
+func (s *Service) rebase(ctx context.Context, appName, userID, sessionID, fileName string, version int64) (kept func(version int64) bool, err error) {
+	r, ok := artifactx.As[artifactx.RetentionReporter](s.Service)
+	if !ok {
+		return func(int64) bool { return true }, nil
+	}
+	retention, clock := r.Retention()
+	if retention == (artifactx.Retention{}) {
+		return func(int64) bool { return true }, nil
+	}
+	req := &artifactx.ListVersionsRequest{AppName: appName, UserID: userID, SessionID: sessionID, FileName: fileName}
+	versions, err := artifactx.ListVersions(baseContext(ctx), s.Service, req)
+	if errors.Is(err, fs.ErrNotExist) {
+		return func(int64) bool { return false }, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]artifactx.VersionInfo, 0, len(versions)+1)
+	var latest int64
+	for _, a := range versions {
+		// Backends age versions by their creation or modification time:
+		// the earlier one expires versions no later than the backend.
+		modTime := a.ModTime
+		if !a.Created.IsZero() && a.Created.Before(modTime) {
+			modTime = a.Created
+		}
+		infos = append(infos, artifactx.VersionInfo{Version: a.Version, ModTime: modTime, Pinned: a.Pinned})
+		latest = max(latest, a.Version)
+	}
+	now := clock.Now()
+	if version == 0 || version > latest {
+		infos = append(infos, artifactx.VersionInfo{Version: max(latest+1, version), ModTime: now})
+	}
+	remaining := map[int64]bool{}
+	for _, v := range infos {
+		remaining[v.Version] = true
+	}
+	for _, v := range retention.Expired(infos, now) {
+		delete(remaining, v)
+	}
+	kept = func(version int64) bool { return remaining[version] }
+
+	// In ascending order, so that the bases of a version are kept or
+	// stored whole by the time it is checked.
+	slices.SortFunc(versions, func(a, b *artifactx.Attributes) int { return cmp.Compare(a.Version, b.Version) })
+	for _, a := range versions {
+		if !kept(a.Version) || a.ContentType != DeltaContentType {
+			continue
+		}
+		versioned := &artifact.LoadRequest{
+			AppName: appName, UserID: userID, SessionID: sessionID, FileName: fileName, Version: a.Version,
+		}
+		h, _, _, err := s.load(baseContext(ctx), versioned)
+		if err != nil {
+			return nil, err
+		}
+		if h == nil || kept(h.base) {
+			continue
+		}
+		if err := s.storeWhole(ctx, versioned); err != nil {
+			return nil, fmt.Errorf("failed to store version %d of artifact '%s' whole: %w", a.Version, fileName, err)
+		}
+	}
+	return kept, nil
+}
+
+// delta returns the delta storing data as the version after the latest
+// version of the artifact req saves, or nil if it is to be stored whole,
+// such as when the latest version is not kept once it is saved. Failures
+// to read the latest version are not reported: the version is then stored
+// whole.
+func (s *Service) delta(ctx context.Context, req *artifact.SaveRequest, data []byte, contentType string, kept func(int64) bool) []byte {
+	resp, err := s.Service.Versions(ctx, &artifact.VersionsRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+	})
+	if err != nil || len(resp.Versions) == 0 {
+		return nil
+	}
+	latest := slices.Max(resp.Versions)
+	if !kept(latest) {
+		return nil
+	}
+	base, h, err := s.rebuild(baseContext(ctx), &artifact.LoadRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: latest,
+	})
+	if err != nil || h.depth+1 >= s.snapshotInterval {
+		return nil
+	}
+	delta := encodeDelta(header{base: latest, depth: h.depth + 1}, base, data, contentType)
+	if 2*len(delta) > len(data) {
+		return nil
+	}
+	return delta
+}
+
+// load loads the version req names as stored, returning its header and
+// operations if it is a delta, and its content otherwise.
+func (s *Service) load(ctx context.Context, req *artifact.LoadRequest) (*header, []byte, string, error) {
+	resp, err := s.Service.Load(ctx, req)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	data, contentType, err := artifactx.EncodePart(resp.Part)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("artifact '%s': %w", req.FileName, err)
+	}
+	if contentType != DeltaContentType {
+		return nil, data, contentType, nil
+	}
+	h, ops, err := decodeHeader(data)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("artifact '%s': %w", req.FileName, err)
+	}
+	return &h, ops, "", nil
+}
+
+// rebuild returns the content of the version req names and its header,
+// the zero header if it is stored whole.
+func (s *Service) rebuild(ctx context.Context, req *artifact.LoadRequest) ([]byte, header, error) {
+	h, data, _, err := s.load(ctx, req)
+	if err != nil || h == nil {
+		return data, header{}, err
+	}
+	data, err = s.apply(ctx, req, *h, data)
+	return data, *h, err
+}
+
+type link struct {
+	h   header
+	ops []byte
+}
+
+// apply rebuilds the version of the artifact req names described by h from
+// the operations ops, loading the versions it is based on with
+// [baseContext].
+func (s *Service) apply(ctx context.Context, req *artifact.LoadRequest, h header, ops []byte) ([]byte, error) {
+	ctx = baseContext(ctx)
+	chain := []link{{h, ops}}
+	var base []byte
+	for base == nil {
+		last := chain[len(chain)-1].h
+		next, data, _, err := s.load(ctx, &artifact.LoadRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: last.base,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to load version %d of artifact '%s': %w", last.base, req.FileName, err)
+		}
+		if next == nil {
+			base = data
+			break
+		}
+		// Bases come before the versions based on them, and are fewer
+		// deltas away from a version stored whole.
+		if next.base >= last.base || next.depth >= last.depth {
+			return nil, fmt.Errorf("artifact '%s' version %d: %w", req.FileName, last.base, ErrCorrupted)
+		}
+		chain = append(chain, link{*next, data})
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		data, err := applyOps(chain[i].h, base, chain[i].ops)
+		if err != nil {
+			return nil, fmt.Errorf("artifact '%s': %w", req.FileName, err)
+		}
+		base = data
+	}
+	return base, nil
+}
+
+// Load implements [artifact.Service].
+func (s *Service) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	resp, err := s.Service.Load(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	data, contentType, err := artifactx.EncodePart(resp.Part)
+	if err != nil || contentType != DeltaContentType {
+		return resp, nil
+	}
+	h, ops, err := decodeHeader(data)
+	if err != nil {
+		return nil, fmt.Errorf("artifact '%s': %w", req.FileName, err)
+	}
+	if err := artifactx.CheckLoadSize(h.size, artifactx.MaxLoadSize(ctx, 0)); err != nil {
+		return nil, fmt.Errorf("artifact '%s': %w", req.FileName, err)
+	}
+	data, err = s.apply(ctx, req, h, ops)
+	if err != nil {
+		return nil, err
+	}
+	part, err := artifactx.DecodePart(data, h.contentType)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode artifact '%s': %w", req.FileName, err)
+	}
+	return &artifact.LoadResponse{Part: part}, nil
+}
+
+// header returns the header of the version req names, or nil if it is
+// stored whole. With a [artifactx.Stater], versions stored whole are not
+// loaded.
+func (s *Service) header(ctx context.Context, req *artifact.LoadRequest) (*header, error) {
+	if stater, ok := artifactx.As[artifactx.Stater](s.Service); ok {
+		attrs, err := stater.Stat(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if attrs.ContentType != DeltaContentType {
+			return nil, nil
+		}
+	}
+	h, _, _, err := s.load(ctx, req)
+	return h, err
+}
+
+// Delete implements [artifact.Service]. Before a single version is deleted,
+// the versions that are deltas against it are stored whole, with the
+// metadata reported by the [artifactx.Stater] of the decorated service.
+func (s *Service) Delete(ctx context.Context, req *artifact.DeleteRequest) error {
+	if req.Version == 0 {
+		return s.Service.Delete(ctx, req)
+	}
+	resp, err := s.Service.Versions(ctx, &artifact.VersionsRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+	})
+	if err != nil {
+		return s.Service.Delete(ctx, req)
+	}
+	bctx := baseContext(ctx)
+	for _, v := range resp.Versions {
+		if v <= req.Version {
+			continue
+		}
+		versioned := &artifact.LoadRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: v,
+		}
+		h, err := s.header(bctx, versioned)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // deleted since listing
+		}
+		if err != nil {
+			return err
+		}
+		if h == nil || h.base != req.Version {
+			continue
+		}
+		if err := s.storeWhole(ctx, versioned); err != nil {
+			return fmt.Errorf("failed to store version %d of artifact '%s' whole: %w", v, req.FileName, err)
+		}
+	}
+	return s.Service.Delete(ctx, req)
+}
+
+// storeWhole replaces the version req names, a delta, with its content.
+func (s *Service) storeWhole(ctx context.Context, req *artifact.LoadRequest) error {
+	data, h, err := s.rebuild(baseContext(ctx), req)
+	if err != nil {
+		return err
+	}
+	if stater, ok := artifactx.As[artifactx.Stater](s.Service); ok {
+		attrs, err := stater.Stat(baseContext(ctx), req)
+		if err != nil {
+			return err
+		}
+		ctx = artifactx.WithMetadata(ctx, attrs.Metadata)
+	}
+	part, err := artifactx.DecodePart(data, h.contentType)
+	if err != nil {
+		return err
+	}
+	_, err = s.Service.Save(ctx, &artifact.SaveRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: req.Version, Part: part,
+	})
+	return err
+}
+
+// loadRebuilt loads and rebuilds the version req names, for decorated
+// services that cannot describe or stream versions.
+func (s *Service) loadRebuilt(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Attributes, []byte, error) {
+	versioned := *req
+	if versioned.Version == 0 {
+		resp, err := s.Service.Versions(ctx, &artifact.VersionsRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(resp.Versions) == 0 {
+			return nil, nil, fmt.Errorf("artifact '%s' not found: %w", req.FileName, fs.ErrNotExist)
+		}
+		versioned.Version = slices.Max(resp.Versions)
+	}
+	h, data, contentType, err := s.load(ctx, &versioned)
+	if err != nil {
+		return nil, nil, err
+	}
+	if h != nil {
+		if data, err = s.apply(ctx, &versioned, *h, data); err != nil {
+			return nil, nil, err
+		}
+		contentType = h.contentType
+	}
+	return &artifactx.Attributes{Version: versioned.Version, ContentType: contentType, Size: int64(len(data))}, data, nil
+}
+
+// Stat implements [artifactx.Stater]. The size and content type of a
+// version stored as a delta are those of the rebuilt version. If the
+// decorated service cannot describe versions, the version is loaded.
+func (s *Service) Stat(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Attributes, error) {
+	stater, ok := artifactx.As[artifactx.Stater](s.Service)
+	if !ok {
+		attrs, _, err := s.loadRebuilt(ctx, req)
+		return attrs, err
+	}
+	attrs, err := stater.Stat(ctx, req)
+	if err != nil || attrs.ContentType != DeltaContentType {
+		return attrs, err
+	}
+	h, _, _, err := s.load(baseContext(ctx), &artifact.LoadRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: attrs.Version,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if h == nil {
+		return nil, fmt.Errorf("artifact '%s' version %d: %w", req.FileName, attrs.Version, ErrCorrupted)
+	}
+	attrs.ContentType, attrs.Size = h.contentType, h.size
+	return attrs, nil
+}
+
+// Open implements [artifactx.Opener]. Versions stored as deltas, and all
+// versions if the decorated service cannot stream, are read into memory.
+func (s *Service) Open(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Reader, error) {
+	opener, ok := artifactx.As[artifactx.Opener](s.Service)
+	if !ok {
+		attrs, data, err := s.loadRebuilt(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return &artifactx.Reader{ReadCloser: io.NopCloser(bytes.NewReader(data)), Attributes: *attrs}, nil
+	}
+	r, err := opener.Open(ctx, req)
+	if err != nil || r.ContentType != DeltaContentType {
+		return r, err
+	}
+	delta, err := artifactx.ReadAllSize(r, r.Size, 0)
+	r.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact '%s' version %d: %w", req.FileName, r.Version, err)
+	}
+	h, ops, err := decodeHeader(delta)
+	if err != nil {
+		return nil, fmt.Errorf("artifact '%s': %w", req.FileName, err)
+	}
+	data, err := s.apply(ctx, req, h, ops)
+	if err != nil {
+		return nil, err
+	}
+	attrs := r.Attributes
+	attrs.ContentType, attrs.Size = h.contentType, h.size
+	return &artifactx.Reader{ReadCloser: io.NopCloser(bytes.NewReader(data)), Attributes: attrs}, nil
+}
@@ -0,0 +1,282 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deltaartifact_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/deltaartifact"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+)
+
+// notebook returns a text of n lines, with the lines of edits numbers
+// changed.
+func notebook(n int, edits map[int]string) string {
+	var b strings.Builder
+	for i := range n {
+		if line, ok := edits[i]; ok {
+			b.WriteString(line + "\n")
+			continue
+		}
+		fmt.Fprintf(&b, "cell %d: print(%d * %d)\n", i, i, i)
+	}
+	return b.String()
+}
+
+func TestService(t *testing.T) {
+	ctx := t.Context()
+	backend, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := deltaartifact.NewService(backend, deltaartifact.Options{Threshold: 1024, SnapshotInterval: 4})
+	req := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "notebook.ipynb"}
+	save := func(part *genai.Part) int64 {
+		t.Helper()
+		resp, err := srv.Save(ctx, &artifact.SaveRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Part: part})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Version
+	}
+	stored := func(version int64) string {
+		t.Helper()
+		versioned := *req
+		versioned.Version = version
+		resp, err := backend.Load(ctx, &versioned)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, contentType, err := artifactx.EncodePart(resp.Part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return contentType
+	}
+	load := func(version int64) string {
+		t.Helper()
+		versioned := *req
+		versioned.Version = version
+		resp, err := srv.Load(ctx, &versioned)
+		if err != nil {
+			t.Fatalf("Load(%d) failed: %v", version, err)
+		}
+		if resp.Part.InlineData == nil {
+			return resp.Part.Text
+		}
+		return string(resp.Part.InlineData.Data)
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	var texts []string
+	edits := map[int]string{}
+	for v := range 9 {
+		edits[rng.IntN(2000)] = fmt.Sprintf("cell edited in version %d", v+1)
+		delete(edits, rng.IntN(2000))
+		texts = append(texts, notebook(2000, edits))
+		save(genai.NewPartFromBytes([]byte(texts[v]), "application/x-ipynb+json"))
+	}
+	for v, want := range map[int64]string{
+		1: "application/x-ipynb+json", 2: deltaartifact.DeltaContentType, 4: deltaartifact.DeltaContentType,
+		5: "application/x-ipynb+json", 6: deltaartifact.DeltaContentType, 9: "application/x-ipynb+json",
+	} {
+		if got := stored(v); got != want {
+			t.Errorf("version %d stored as %q, want %q", v, got, want)
+		}
+	}
+	for v, want := range texts {
+		if got := load(int64(v + 1)); got != want {
+			t.Errorf("Load(%d) did not rebuild the saved text", v+1)
+		}
+	}
+	if got := load(0); got != texts[8] {
+		t.Errorf("Load() of the latest version did not return the saved text")
+	}
+
+	attrs, err := srv.Stat(ctx, &artifact.LoadRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attrs.ContentType != "application/x-ipynb+json" || attrs.Size != int64(len(texts[2])) {
+		t.Errorf("Stat() = (%q, %d), want (%q, %d)", attrs.ContentType, attrs.Size, "application/x-ipynb+json", len(texts[2]))
+	}
+	r, err := srv.Open(ctx, &artifact.LoadRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || string(data) != texts[2] || r.Size != int64(len(texts[2])) || r.Version != 3 {
+		t.Errorf("Open() read %d bytes of version %d, %v; want version 3", len(data), r.Version, err)
+	}
+	if _, err := srv.Load(artifactx.WithMaxLoadSize(ctx, 2048), req); !errors.Is(err, artifactx.ErrTooLarge) {
+		t.Errorf("Load() over the size limit error = %v, want %v", err, artifactx.ErrTooLarge)
+	}
+
+	// Version 3 is the base of version 4, which is stored whole before
+	// version 3 is deleted.
+	if err := srv.Delete(ctx, &artifact.DeleteRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if got := stored(4); got != "application/x-ipynb+json" {
+		t.Errorf("version 4 stored as %q after deleting version 3, want it stored whole", got)
+	}
+	if got := load(4); got != texts[3] {
+		t.Errorf("Load(4) after deleting version 3 did not return the saved text")
+	}
+
+	// Binary content, text over a rewrite and small text are stored whole.
+	binary := bytes.Repeat([]byte{0, 1, 2, 3}, 1024)
+	save(genai.NewPartFromBytes(binary, "application/octet-stream"))
+	binary[0] = 9
+	if v := save(genai.NewPartFromBytes(binary, "application/octet-stream")); stored(v) != "application/octet-stream" {
+		t.Errorf("binary version stored as %q, want it stored whole", stored(v))
+	}
+	rewrite := strings.Repeat("completely different\n", 200)
+	if v := save(genai.NewPartFromText(rewrite)); stored(v) != artifactx.TextContentType || load(v) != rewrite {
+		t.Errorf("rewritten text stored as %q, want it stored whole", stored(v))
+	}
+	if v := save(genai.NewPartFromText(rewrite + "one more line\n")); stored(v) != deltaartifact.DeltaContentType || load(v) != rewrite+"one more line\n" {
+		t.Errorf("edited text stored as %q, want a delta", stored(v))
+	}
+	if v := save(genai.NewPartFromText("short")); stored(v) != artifactx.TextContentType {
+		t.Errorf("small text stored as %q, want it stored whole", stored(v))
+	}
+}
+
+func TestServiceRetention(t *testing.T) {
+	ctx := t.Context()
+	backend, err := fsartifact.New(t.TempDir(), fsartifact.WithRetention(artifactx.Retention{MaxVersions: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := deltaartifact.NewService(backend, deltaartifact.Options{Threshold: 1024, SnapshotInterval: 10})
+	req := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "notebook.ipynb"}
+
+	// The versions the retention keeps are stored whole before the versions
+	// they are deltas against are removed, with the retention the backend
+	// has at the time.
+	texts := map[int64]string{}
+	edits := map[int]string{}
+	for v := int64(1); v <= 8; v++ {
+		if v == 7 {
+			backend.(artifactx.RetentionSetter).SetRetention(artifactx.Retention{MaxVersions: 2})
+		}
+		edits[int(v)*100] = fmt.Sprintf("cell edited in version %d", v)
+		texts[v] = notebook(2000, edits)
+		resp, err := srv.Save(ctx, &artifact.SaveRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Part: genai.NewPartFromBytes([]byte(texts[v]), "application/x-ipynb+json")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Version != v {
+			t.Fatalf("Save() = version %d, want %d", resp.Version, v)
+		}
+	}
+	versions, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions.Versions) != 2 {
+		t.Fatalf("Versions() = %v, want 2 versions", versions.Versions)
+	}
+	for _, v := range versions.Versions {
+		versioned := *req
+		versioned.Version = v
+		resp, err := srv.Load(ctx, &versioned)
+		if err != nil {
+			t.Fatalf("Load(%d) failed: %v", v, err)
+		}
+		if string(resp.Part.InlineData.Data) != texts[v] {
+			t.Errorf("Load(%d) did not rebuild the saved text", v)
+		}
+	}
+	oldest := *req
+	oldest.Version = 7
+	resp, err := backend.Load(ctx, &oldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Part.InlineData.MIMEType; got != "application/x-ipynb+json" {
+		t.Errorf("oldest kept version stored as %q, want it stored whole", got)
+	}
+}
+
+func TestServiceCapabilities(t *testing.T) {
+	ctx := t.Context()
+	backend, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := deltaartifact.NewService(backend, deltaartifact.Options{Threshold: 1024})
+	req := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "notebook.ipynb"}
+	text := notebook(2000, nil)
+	for _, edited := range []string{text, text + "one more cell\n"} {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Part: genai.NewPartFromText(edited)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	size := int64(len(text + "one more cell\n"))
+
+	// Listings describe rebuilt versions.
+	versions, err := artifactx.ListVersions(ctx, srv, &artifactx.ListVersionsRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName})
+	if err != nil || len(versions) != 2 || versions[0].Size != size || versions[0].ContentType != artifactx.TextContentType {
+		t.Errorf("ListVersions() = (%v, %v), want the rebuilt versions", versions, err)
+	}
+	files, err := artifactx.ListDetailed(ctx, srv, &artifactx.ListDetailedRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID})
+	if err != nil || len(files) != 1 || files[0].Size != size {
+		t.Errorf("ListDetailed() = (%v, %v), want the rebuilt size %d", files, err, size)
+	}
+
+	// Capabilities removing or rewriting versions are not forwarded.
+	if _, ok := artifactx.As[artifactx.Pruner](srv); ok {
+		t.Error("As[Pruner]() found the pruner of the backend")
+	}
+	if _, ok := artifactx.As[artifactx.JSONUpdater](srv); ok {
+		t.Error("As[JSONUpdater]() found the updater of the backend")
+	}
+	if _, ok := artifactx.As[artifactx.RetentionSetter](srv); !ok {
+		t.Error("As[RetentionSetter]() did not find the retention of the backend")
+	}
+}
+
+func TestServiceWithoutOpener(t *testing.T) {
+	ctx := t.Context()
+	srv := deltaartifact.NewService(artifact.InMemoryService(), deltaartifact.Options{Threshold: 1024})
+	req := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "notebook.ipynb"}
+	texts := []string{notebook(2000, nil), notebook(2000, map[int]string{7: "edited"})}
+	for _, text := range texts {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Part: genai.NewPartFromText(text)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	attrs, err := srv.Stat(ctx, req)
+	if err != nil || attrs.Version != 2 || attrs.Size != int64(len(texts[1])) {
+		t.Errorf("Stat() = (%+v, %v), want version 2 of %d bytes", attrs, err, len(texts[1]))
+	}
+	var buf bytes.Buffer
+	if _, _, err := artifactx.LoadTo(ctx, srv, req, &buf); err != nil || buf.String() != texts[1] {
+		t.Errorf("LoadTo() = %v, did not read the rebuilt version", err)
+	}
+}
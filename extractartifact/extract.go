@@ -0,0 +1,222 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extractartifact
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+func extractPDF(ctx context.Context, data []byte) (text string, err error) {
+	// The PDF parser panics on some malformed documents.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed PDF: %v", r)
+		}
+	}()
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	plain, err := r.GetPlainText()
+	if err != nil {
+		return "", err
+	}
+	b, err := io.ReadAll(plain)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// openZipXML returns a decoder for the named member of an Office Open XML package.
+func openZipXML(zr *zip.Reader, name string) (*xml.Decoder, io.Closer, error) {
+	f, err := zr.Open(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	return xml.NewDecoder(f), f, nil
+}
+
+func extractDOCX(ctx context.Context, data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	dec, c, err := openZipXML(zr, "word/document.xml")
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+
+	var sb strings.Builder
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse word/document.xml: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				sb.WriteByte('\t')
+			case "br", "cr":
+				sb.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				sb.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(t)
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+func extractXLSX(ctx context.Context, data []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	shared, err := sharedStrings(zr)
+	if err != nil {
+		return "", err
+	}
+
+	var sheets []string
+	for _, f := range zr.File {
+		if strings.HasPrefix(f.Name, "xl/worksheets/") && strings.HasSuffix(f.Name, ".xml") {
+			sheets = append(sheets, f.Name)
+		}
+	}
+	// sheet2.xml before sheet10.xml.
+	slices.SortFunc(sheets, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(a) - len(b)
+		}
+		return strings.Compare(a, b)
+	})
+
+	var sb strings.Builder
+	for i, name := range sheets {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		if err := writeSheet(&sb, zr, name, shared); err != nil {
+			return "", err
+		}
+	}
+	return sb.String(), nil
+}
+
+// sharedStrings reads the shared string table of a workbook, which most cells
+// refer to by index.
+func sharedStrings(zr *zip.Reader) ([]string, error) {
+	dec, c, err := openZipXML(zr, "xl/sharedStrings.xml")
+	if err != nil {
+		// Workbooks without text cells have no shared string table.
+		return nil, nil
+	}
+	defer c.Close()
+
+	var table struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+	if err := dec.Decode(&table); err != nil {
+		return nil, fmt.Errorf("failed to parse xl/sharedStrings.xml: %w", err)
+	}
+	strs := make([]string, len(table.Items))
+	for i, item := range table.Items {
+		s := item.Text
+		for _, r := range item.Runs {
+			s += r.Text
+		}
+		strs[i] = s
+	}
+	return strs, nil
+}
+
+// writeSheet writes the rows of a worksheet to sb, one line per row with
+// tab-separated cells.
+func writeSheet(sb *strings.Builder, zr *zip.Reader, name string, shared []string) error {
+	dec, c, err := openZipXML(zr, name)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := dec.Decode(&sheet); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	for _, row := range sheet.Rows {
+		for i, cell := range row.Cells {
+			if i > 0 {
+				sb.WriteByte('\t')
+			}
+			switch cell.Type {
+			case "s":
+				idx, err := strconv.Atoi(cell.Value)
+				if err != nil || idx < 0 || idx >= len(shared) {
+					return fmt.Errorf("%s: invalid shared string index %q", name, cell.Value)
+				}
+				sb.WriteString(shared[idx])
+			case "inlineStr":
+				sb.WriteString(cell.Inline)
+			default:
+				sb.WriteString(cell.Value)
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package extractartifact provides an [artifact.Service] decorator that
+// extracts the plain text of uploaded documents on Save.
+//
+// For every saved PDF, DOCX or XLSX artifact "<name>" the text is saved as a
+// derived artifact "<name>.txt" in the same session, so later steps can hand
+// documents to a model without parsing them again.
+package extractartifact
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"path"
+	"strconv"
+	"strings"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Media types of the documents handled by [DefaultExtractors].
+const (
+	PDF  = "application/pdf"
+	DOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	XLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// Metadata keys recorded on derived artifacts.
+const (
+	SourceFileMetadataKey    = "extracted-from"
+	SourceVersionMetadataKey = "extracted-from-version"
+)
+
+// Extractor returns the plain text of a document.
+type Extractor func(ctx context.Context, data []byte) (string, error)
+
+// DefaultExtractors returns the extractors for PDF, DOCX and XLSX documents,
+// keyed by media type.
+func DefaultExtractors() map[string]Extractor {
+	return map[string]Extractor{
+		PDF:  extractPDF,
+		DOCX: extractDOCX,
+		XLSX: extractXLSX,
+	}
+}
+
+// extensions maps file extensions to media types, for documents uploaded
+// without a specific MIME type.
+var extensions = map[string]string{
+	".pdf":  PDF,
+	".docx": DOCX,
+	".xlsx": XLSX,
+}
+
+// Service is an [artifact.Service] that saves the extracted text of documents
+// next to them.
+type Service struct {
+	artifact.Service
+	extractors map[string]Extractor
+}
+
+var _ artifactx.Wrapper = (*Service)(nil)
+
+// NewService returns a service that saves to next and, for every saved
+// document whose media type has an extractor, also saves its text. A nil
+// extractors map means [DefaultExtractors].
+func NewService(next artifact.Service, extractors map[string]Extractor) *Service {
+	if extractors == nil {
+		extractors = DefaultExtractors()
+	}
+	return &Service{Service: next, extractors: extractors}
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// DerivedName returns the name of the artifact holding the text of fileName.
+func DerivedName(fileName string) string {
+	return fileName + ".txt"
+}
+
+func (s *Service) extractor(fileName string, part *genai.Part) (Extractor, bool) {
+	if part.InlineData == nil {
+		return nil, false
+	}
+	mediaType, _, _ := mime.ParseMediaType(part.InlineData.MIMEType)
+	if mediaType == "" || mediaType == "application/octet-stream" || mediaType == "application/zip" {
+		mediaType = extensions[strings.ToLower(path.Ext(fileName))]
+	}
+	e, ok := s.extractors[mediaType]
+	return e, ok
+}
+
+// Save implements [artifact.Service]. The document is saved first; if the
+// text cannot be extracted or saved, the document stays saved and the error
+// is returned along with the response.
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	resp, err := s.Service.Save(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	extract, ok := s.extractor(req.FileName, req.Part)
+	if !ok {
+		return resp, nil
+	}
+	text, err := extract(ctx, req.Part.InlineData.Data)
+	if err != nil {
+		return resp, fmt.Errorf("failed to extract text of %q version %d: %w", req.FileName, resp.Version, err)
+	}
+	ctx = artifactx.WithMetadata(ctx, map[string]string{
+		SourceFileMetadataKey:    req.FileName,
+		SourceVersionMetadataKey: strconv.FormatInt(resp.Version, 10),
+	})
+	if _, err := s.Service.Save(ctx, &artifact.SaveRequest{
+		AppName:   req.AppName,
+		UserID:    req.UserID,
+		SessionID: req.SessionID,
+		FileName:  DerivedName(req.FileName),
+		Part:      genai.NewPartFromText(text),
+	}); err != nil {
+		return resp, fmt.Errorf("failed to save text of %q version %d: %w", req.FileName, resp.Version, err)
+	}
+	return resp, nil
+}
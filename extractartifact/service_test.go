@@ -0,0 +1,160 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extractartifact_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/extractartifact"
+)
+
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// minimalPDF returns a one-page PDF showing text.
+func minimalPDF(text string) []byte {
+	stream := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func TestExtract(t *testing.T) {
+	docx := zipFiles(t, map[string]string{
+		"word/document.xml": `<?xml version="1.0"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>Quarterly</w:t></w:r><w:r><w:t xml:space="preserve"> report</w:t></w:r></w:p>
+<w:p><w:r><w:t>Revenue</w:t><w:tab/><w:t>up</w:t></w:r></w:p>
+</w:body></w:document>`,
+	})
+	xlsx := zipFiles(t, map[string]string{
+		"xl/sharedStrings.xml": `<?xml version="1.0"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>region</t></si><si><t>sales</t></si><si><r><t>No</t></r><r><t>rth</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<?xml version="1.0"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>42</v></c></row>
+<row r="3"><c r="A3" t="inlineStr"><is><t>South</t></is></c><c r="B3"><v>7</v></c></row>
+</sheetData></worksheet>`,
+	})
+
+	for _, tc := range []struct {
+		fileName, mimeType string
+		data               []byte
+		want               string
+	}{
+		{"report.docx", extractartifact.DOCX, docx, "Quarterly report\nRevenue\tup\n"},
+		{"sales.xlsx", "application/octet-stream", xlsx, "region\tsales\nNorth\t42\nSouth\t7\n"},
+		{"scan.pdf", extractartifact.PDF, minimalPDF("Hello PDF"), "Hello PDF"},
+	} {
+		t.Run(tc.fileName, func(t *testing.T) {
+			ctx := t.Context()
+			mem := artifact.InMemoryService()
+			srv := extractartifact.NewService(mem, nil)
+			if _, err := srv.Save(ctx, &artifact.SaveRequest{
+				AppName: "app", UserID: "user", SessionID: "session", FileName: tc.fileName,
+				Part: genai.NewPartFromBytes(tc.data, tc.mimeType),
+			}); err != nil {
+				t.Fatalf("Save() failed: %v", err)
+			}
+			resp, err := mem.Load(ctx, &artifact.LoadRequest{
+				AppName: "app", UserID: "user", SessionID: "session", FileName: extractartifact.DerivedName(tc.fileName),
+			})
+			if err != nil {
+				t.Fatalf("Load(derived) failed: %v", err)
+			}
+			if got := resp.Part.Text; strings.TrimSpace(got) != strings.TrimSpace(tc.want) {
+				t.Errorf("derived text = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExtractSkipsOtherTypes(t *testing.T) {
+	ctx := t.Context()
+	mem := artifact.InMemoryService()
+	srv := extractartifact.NewService(mem, nil)
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "notes.txt",
+		Part: genai.NewPartFromText("plain"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := mem.Load(ctx, &artifact.LoadRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "notes.txt.txt",
+	})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Load(derived) error = %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestExtractMalformed(t *testing.T) {
+	ctx := t.Context()
+	mem := artifact.InMemoryService()
+	srv := extractartifact.NewService(mem, nil)
+	resp, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "broken.pdf",
+		Part: genai.NewPartFromBytes([]byte("not a pdf"), extractartifact.PDF),
+	})
+	if err == nil {
+		t.Fatal("Save() succeeded, want extraction error")
+	}
+	if resp == nil || resp.Version != 1 {
+		t.Errorf("Save() response = %+v, want the document saved as version 1", resp)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// audit logs an entry for a completed operation if an audit logger is set.
+// The tags of [artifactx.WithRequestTags] are logged in a "tags" group.
+func (s *fsService) audit(ctx context.Context, op, appName, userID, sessionID, fileName string, version int64, err error) {
+	if s.auditLog == nil {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("op", op),
+		slog.String("app", appName),
+		slog.String("user", userID),
+		slog.String("session", sessionID),
+	}
+	if fileName != "" {
+		attrs = append(attrs, slog.String("file", fileName))
+	}
+	if version != 0 {
+		attrs = append(attrs, slog.Int64("version", version))
+	}
+	if tags := artifactx.RequestTagsFrom(ctx); len(tags) > 0 {
+		var group []any
+		for _, key := range slices.Sorted(maps.Keys(tags)) {
+			group = append(group, slog.String(key, tags[key]))
+		}
+		attrs = append(attrs, slog.Group("tags", group...))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	s.auditLog.LogAttrs(ctx, slog.LevelInfo, "artifact "+op, attrs...)
+}
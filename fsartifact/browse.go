@@ -0,0 +1,68 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.Browser = (*fsService)(nil)
+
+// ListUsers implements [artifactx.Browser] by reading the directory of the app.
+func (s *fsService) ListUsers(ctx context.Context, appName string) ([]string, error) {
+	prefix, err := artifactx.UsersPrefix(s.keys, appName)
+	if err != nil {
+		return nil, err
+	}
+	names, err := s.subdirs(prefix)
+	slices.Sort(names)
+	return names, err
+}
+
+// ListSessions implements [artifactx.Browser] by reading the directory of the user.
+func (s *fsService) ListSessions(ctx context.Context, appName, userID string) ([]string, error) {
+	prefix, err := artifactx.SessionsPrefix(s.keys, appName, userID)
+	if err != nil {
+		return nil, err
+	}
+	names, err := s.subdirs(prefix)
+	return artifactx.Sessions(s.keys, names), err
+}
+
+// subdirs returns the names of the directories below prefix.
+func (s *fsService) subdirs(prefix string) ([]string, error) {
+	if err := s.checkPath(s.keyPath(prefix)); err != nil {
+		return nil, err
+	}
+	entries, err := s.readDir(s.keyPath(prefix))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
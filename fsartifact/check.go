@@ -0,0 +1,228 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.Checker = (*fsService)(nil)
+
+// Check implements [artifactx.Checker].
+//
+// With opts.VerifyChecksums every version is read and compared with the
+// checksum recorded in its sidecar. Versions saved before sidecars recorded
+// checksums are skipped.
+func (s *fsService) Check(ctx context.Context, opts *artifactx.CheckOptions) (*artifactx.CheckReport, error) {
+	if opts == nil {
+		opts = &artifactx.CheckOptions{}
+	}
+	report := &artifactx.CheckReport{}
+	// artifact directory -> versions found in it
+	versions := map[string][]int64{}
+
+	err := filepath.WalkDir(s.rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path == filepath.Join(s.rootDir, artifactx.UploadsPrefix) || path == filepath.Join(s.rootDir, locksDir) ||
+				path == filepath.Join(s.rootDir, journalsDir) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil // symbolic links are never followed
+		}
+		if path == filepath.Join(s.rootDir, journalFile) {
+			return nil
+		}
+		report.Objects++
+
+		rel, err := filepath.Rel(s.rootDir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		inLayout := func(key string) bool {
+			_, err := s.keys.ParseKey(key)
+			return err == nil || artifactx.IsInvalidVersionKey(s.keys, key)
+		}
+
+		if base, ok := strings.CutSuffix(key, ".meta"); ok {
+			if !inLayout(base) {
+				report.Anomalies = append(report.Anomalies, artifactx.Anomaly{
+					Kind: artifactx.OrphanedObject, Key: rel, Detail: "file outside of the artifact layout",
+				})
+				return nil
+			}
+			if _, err := os.Stat(strings.TrimSuffix(path, ".meta")); os.IsNotExist(err) {
+				report.Anomalies = append(report.Anomalies, artifactx.Anomaly{
+					Kind: artifactx.OrphanedObject, Key: rel, Detail: fmt.Sprintf("metadata for missing version %q", filepath.Base(base)), Repairable: true,
+				})
+				return nil
+			}
+			if opts.VerifyMetadata {
+				if a := s.checkMeta(path, rel); a != nil {
+					report.Anomalies = append(report.Anomalies, *a)
+				}
+			}
+			return nil
+		}
+
+		ref, err := s.keys.ParseKey(key)
+		if err != nil {
+			if artifactx.IsInvalidVersionKey(s.keys, key) {
+				report.Anomalies = append(report.Anomalies, artifactx.Anomaly{
+					Kind: artifactx.InvalidVersion, Key: rel, Detail: fmt.Sprintf("%q is not a version number", d.Name()),
+				})
+			} else {
+				report.Anomalies = append(report.Anomalies, artifactx.Anomaly{
+					Kind: artifactx.OrphanedObject, Key: rel, Detail: "file outside of the artifact layout",
+				})
+			}
+			return nil
+		}
+		dir := filepath.Dir(rel)
+		versions[dir] = append(versions[dir], ref.Version)
+
+		if _, err := os.Stat(path + ".meta"); os.IsNotExist(err) {
+			report.Anomalies = append(report.Anomalies, artifactx.Anomaly{
+				Kind: artifactx.MissingMetadata, Key: rel, Detail: "content type falls back to text/plain", Repairable: true,
+			})
+			return nil
+		}
+		if opts.VerifyChecksums {
+			a, err := s.verifyChecksum(path, rel)
+			if err != nil {
+				return err
+			}
+			if a != nil {
+				report.Anomalies = append(report.Anomalies, *a)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk root dir: %w", err)
+	}
+
+	for dir, vs := range versions {
+		report.Anomalies = append(report.Anomalies, artifactx.VersionGaps(filepath.ToSlash(dir), vs)...)
+	}
+	return report, nil
+}
+
+// verifyChecksum compares the content of the version file at path with the
+// checksum recorded in its sidecar. Sidecars that cannot be read are
+// reported by checkMeta instead.
+func (s *fsService) verifyChecksum(path, rel string) (*artifactx.Anomaly, error) {
+	m, err := s.readSidecar(path)
+	if err != nil {
+		return nil, nil
+	}
+	a, want := m.Checksum()
+	if a == nil {
+		return nil, nil
+	}
+	sum, err := s.hashFile(path, a)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // removed since listing
+		}
+		return nil, fmt.Errorf("could not read %q: %w", rel, err)
+	}
+	if sum != want {
+		return &artifactx.Anomaly{
+			Kind: artifactx.ChecksumMismatch, Key: rel, Detail: fmt.Sprintf("%s %s, want %s", a, sum, want),
+		}, nil
+	}
+	return nil, nil
+}
+
+// checkMeta validates the sidecar at path against the metadata format.
+func (s *fsService) checkMeta(path, rel string) *artifactx.Anomaly {
+	data, err := s.readFileNoFollow(path)
+	if err != nil {
+		return &artifactx.Anomaly{Kind: artifactx.InvalidMetadata, Key: rel, Detail: err.Error()}
+	}
+	m, err := artifactx.ParseMeta(data)
+	if err == nil {
+		err = m.Validate()
+	}
+	switch {
+	case err != nil:
+		return &artifactx.Anomaly{Kind: artifactx.InvalidMetadata, Key: rel, Detail: err.Error()}
+	case m.Format < artifactx.MetaFormat:
+		return &artifactx.Anomaly{
+			Kind: artifactx.OutdatedMetadata, Key: rel, Detail: fmt.Sprintf("format %d, current is %d", m.Format, artifactx.MetaFormat), Repairable: true,
+		}
+	}
+	return nil
+}
+
+// Repair implements [artifactx.Checker].
+//
+// Missing metadata is recreated from the detected content type, outdated
+// metadata is rewritten in the current format and orphaned metadata files
+// are removed. Other anomalies are left untouched.
+func (s *fsService) Repair(ctx context.Context, report *artifactx.CheckReport) (int, error) {
+	repaired := 0
+	for _, a := range report.Repairable() {
+		if err := ctx.Err(); err != nil {
+			return repaired, err
+		}
+		path := filepath.Join(s.rootDir, filepath.FromSlash(a.Key))
+		switch a.Kind {
+		case artifactx.MissingMetadata:
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return repaired, fmt.Errorf("failed to read %q: %w", a.Key, err)
+			}
+			if err := s.writeMeta(path, &meta{ContentType: http.DetectContentType(data)}); err != nil {
+				return repaired, err
+			}
+		case artifactx.OutdatedMetadata:
+			version := strings.TrimSuffix(path, ".meta")
+			m, err := s.readMeta(version)
+			if err != nil {
+				return repaired, err
+			}
+			if err := s.writeMeta(version, m); err != nil {
+				return repaired, err
+			}
+		case artifactx.OrphanedObject:
+			if err := s.remove(path); err != nil && !os.IsNotExist(err) {
+				return repaired, fmt.Errorf("failed to remove %q: %w", a.Key, err)
+			}
+		default:
+			continue
+		}
+		repaired++
+	}
+	return repaired, nil
+}
@@ -0,0 +1,204 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+)
+
+func TestCheck(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	srv, err := fsartifact.NewService(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+			Part: genai.NewPartFromText("hello"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	artDir := filepath.Join(dir, "app", "user", "session", "file")
+	if err := os.Remove(filepath.Join(artDir, "2")); err != nil { // gap + orphaned meta
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(artDir, "3.meta")); err != nil { // missing meta
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(artDir, "latest"), nil, 0644); err != nil { // invalid version
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "app", "stray"), nil, 0644); err != nil { // orphan
+		t.Fatal(err)
+	}
+
+	c := srv.(artifactx.Checker)
+	report, err := c.Check(ctx, nil)
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	var got []artifactx.AnomalyKind
+	for _, a := range report.Anomalies {
+		got = append(got, a.Kind)
+	}
+	slices.Sort(got)
+	want := []artifactx.AnomalyKind{
+		artifactx.InvalidVersion, artifactx.MissingMetadata, artifactx.OrphanedObject, artifactx.OrphanedObject, artifactx.VersionGap,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("Check() anomalies = %v, want %v", got, want)
+	}
+
+	n, err := c.Repair(ctx, report)
+	if err != nil || n != 2 {
+		t.Fatalf("Repair() = (%d, %v), want (2, nil)", n, err)
+	}
+	report, err = c.Check(ctx, nil)
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	if r := report.Repairable(); len(r) != 0 {
+		t.Errorf("Check() after Repair() = %v, want no repairable anomalies", r)
+	}
+}
+
+func TestCheckMetadata(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	srv, err := fsartifact.NewService(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+			Part: genai.NewPartFromText("hello"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	artDir := filepath.Join(dir, "app", "user", "session", "file")
+	// Sidecars written by older versions of the package.
+	if err := os.WriteFile(filepath.Join(artDir, "1.meta"), []byte("text/plain"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(artDir, "2.meta"), []byte(`{"contentType":"text/plain"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(artDir, "3.meta"), []byte(`{"format":1,"contentType":"text/plain","metadata":{"adk-pinned":"true"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := srv.(artifactx.Checker)
+	if report, err := c.Check(ctx, nil); err != nil || len(report.Anomalies) != 0 {
+		t.Errorf("Check() without VerifyMetadata = (%v, %v), want no anomalies", report, err)
+	}
+	opts := &artifactx.CheckOptions{VerifyMetadata: true}
+	report, err := c.Check(ctx, opts)
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	var got []artifactx.AnomalyKind
+	for _, a := range report.Anomalies {
+		got = append(got, a.Kind)
+	}
+	slices.Sort(got)
+	want := []artifactx.AnomalyKind{artifactx.InvalidMetadata, artifactx.OutdatedMetadata, artifactx.OutdatedMetadata}
+	if !slices.Equal(got, want) {
+		t.Fatalf("Check() anomalies = %v, want %v", got, want)
+	}
+	if n, err := c.Repair(ctx, report); err != nil || n != 2 {
+		t.Fatalf("Repair() = (%d, %v), want (2, nil)", n, err)
+	}
+	report, err = c.Check(ctx, opts)
+	if err != nil || len(report.Anomalies) != 1 || report.Anomalies[0].Kind != artifactx.InvalidMetadata {
+		t.Errorf("Check() after Repair() = (%v, %v), want only the invalid metadata", report, err)
+	}
+	resp, err := srv.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Version: 1})
+	if err != nil || resp.Part.InlineData == nil || string(resp.Part.InlineData.Data) != "hello" {
+		t.Errorf("Load() of an upgraded version = (%v, %v), want %q", resp, err, "hello")
+	}
+}
+
+func TestCheckChecksums(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	srv, err := fsartifact.NewService(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+			Part: genai.NewPartFromText("hello"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Bit rot in the first version, of the same size.
+	if err := os.WriteFile(filepath.Join(dir, "app", "user", "session", "file", "1"), []byte("jello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := srv.(artifactx.Checker)
+	if report, err := c.Check(ctx, nil); err != nil || len(report.Anomalies) != 0 {
+		t.Errorf("Check() without VerifyChecksums = (%v, %v), want no anomalies", report, err)
+	}
+	report, err := c.Check(ctx, &artifactx.CheckOptions{VerifyChecksums: true})
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	want := filepath.Join("app", "user", "session", "file", "1")
+	if len(report.Anomalies) != 1 || report.Anomalies[0].Kind != artifactx.ChecksumMismatch || report.Anomalies[0].Key != want {
+		t.Errorf("Check() anomalies = %+v, want a checksum mismatch of %s", report.Anomalies, want)
+	}
+	if r := report.Repairable(); len(r) != 0 {
+		t.Errorf("Repairable() = %v, want corruption not repairable", r)
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	ctx := t.Context()
+	dir := filepath.Join(t.TempDir(), "root")
+	srv, err := fsartifact.NewService(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker := srv.(artifactx.HealthChecker)
+	if err := checker.HealthCheck(ctx); err != nil {
+		t.Fatalf("HealthCheck() = %v, want nil", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("HealthCheck() left %d files behind", len(entries))
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := checker.HealthCheck(ctx); err == nil {
+		t.Error("HealthCheck() with missing root dir = nil, want error")
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.SessionCloner = (*fsService)(nil)
+
+// CloneSession implements [artifactx.SessionCloner] by copying the version
+// files and their sidecars, keeping their modification times so that the
+// clones report the same creation times.
+func (s *fsService) CloneSession(ctx context.Context, req *artifactx.CloneRequest) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("request validation failed: %w", err)
+	}
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return err
+	}
+	if err := artifactx.CheckSessionID(s.keys, req.NewSessionID, ""); err != nil {
+		return err
+	}
+	names, err := artifactx.SessionFileNames(ctx, s, req.AppName, req.UserID, req.SessionID)
+	if err != nil {
+		return err
+	}
+	existing, err := artifactx.SessionFileNames(ctx, s, req.AppName, req.UserID, req.NewSessionID)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("session %q has artifacts: %w", req.NewSessionID, fs.ErrExist)
+	}
+
+	for _, name := range names {
+		resp, err := s.versions(ctx, &artifact.VersionsRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: name,
+		})
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // deleted since listing
+		}
+		if err != nil {
+			return err
+		}
+		for _, v := range resp.Versions {
+			src := s.buildPath(req.AppName, req.UserID, req.SessionID, name, v)
+			dst := s.buildPath(req.AppName, req.UserID, req.NewSessionID, name, v)
+			if err := s.mkdirAll(filepath.Dir(dst)); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+			if err := s.copyFile(src+".meta", dst+".meta"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			// The version file last, so that it is never visible without
+			// its sidecar.
+			if err := s.copyFile(src, dst); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// copyFile copies the file at src to dst, keeping its modification time.
+func (s *fsService) copyFile(src, dst string) error {
+	in, err := s.openNoFollow(src, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := s.create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		s.remove(dst)
+		return fmt.Errorf("failed to copy %q: %w", src, err)
+	}
+	if err := out.Close(); err != nil {
+		s.remove(dst)
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := s.chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+		return fmt.Errorf("failed to set file time: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"google.golang.org/adk/artifact"
+)
+
+// DefaultRootDir returns the directory [NewDefaultService] stores the
+// artifacts of appName in, below the per-user data directory of the OS:
+//
+//   - on Windows, %AppData%\<appName>\artifacts;
+//   - on macOS, ~/Library/Application Support/<appName>/artifacts;
+//   - elsewhere, $XDG_DATA_HOME/<appName>/artifacts, where XDG_DATA_HOME
+//     defaults to ~/.local/share.
+func DefaultRootDir(appName string) (string, error) {
+	if appName == "" || appName == "." || appName == ".." || strings.ContainsAny(appName, `/\`) {
+		return "", fmt.Errorf("invalid app name %q", appName)
+	}
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName, "artifacts"), nil
+}
+
+func dataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		dir := os.Getenv("AppData")
+		if dir == "" {
+			return "", errors.New("%AppData% is not defined")
+		}
+		return dir, nil
+	case "darwin", "ios":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, "Library", "Application Support"), nil
+	}
+	// Relative paths are invalid per the XDG Base Directory specification.
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+// NewDefaultService creates a FS service storing the artifacts of appName in
+// [DefaultRootDir], configured by opts. It suits desktop agents, which have
+// no deployment to configure a directory for them.
+func NewDefaultService(appName string, opts ...Option) (artifact.Service, error) {
+	dir, err := DefaultRootDir(appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find data dir: %w", err)
+	}
+	return New(dir, opts...)
+}
@@ -0,0 +1,47 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.HealthChecker = (*fsService)(nil)
+
+// HealthCheck implements [artifactx.HealthChecker] by writing, reading back
+// and removing a temporary file in the root directory.
+func (s *fsService) HealthCheck(ctx context.Context) error {
+	f, err := os.CreateTemp(s.rootDir, ".healthcheck-*")
+	if err != nil {
+		return fmt.Errorf("root dir is not writable: %w", err)
+	}
+	defer s.remove(f.Name())
+	_, err = f.WriteString("ok")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write health check file: %w", err)
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil || string(data) != "ok" {
+		return fmt.Errorf("failed to read back health check file: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"fmt"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// checkMutable returns [artifactx.ErrImmutable] if the version file at path,
+// the latest version of fileName, was saved with [artifactx.WithImmutable].
+// Versions whose sidecar cannot be read are not saved over.
+func (s *fsService) checkMutable(path, fileName string) error {
+	m, err := s.readSidecar(path)
+	if err != nil {
+		return err
+	}
+	if m.Immutable {
+		return fmt.Errorf("cannot save artifact '%s': %w", fileName, artifactx.ErrImmutable)
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// journalsDir is the directory of the journals in the root directory,
+	// one per service.
+	journalsDir = ".journals"
+	// journalFile is the journal of earlier releases, shared by all the
+	// services of the root directory. It is still recovered.
+	journalFile = ".journal"
+)
+
+// With [WithJournal], every service appends the intent of every Save and
+// Delete to a journal of its own in rootDir/.journals, synced to disk
+// before anything changes, and a "done" record once the operation
+// completed. A service keeps its journal locked while it runs, so the
+// journals nobody holds the lock of were left by a crash. A service
+// starting replays them, and then removes them; the operations without a
+// done record were interrupted:
+//
+//   - saves are rolled back unless their sidecar was written and matches the
+//     content, so that no partial version is left to shift the numbering;
+//   - deletes are completed, as removing files is idempotent.
+//
+// Replaying takes the locks of the artifacts, so that it does not race with
+// the services using them. Done records are synced too, since completing a
+// delete again could remove an artifact saved since. A journal is
+// truncated whenever no operation of its service is in flight, so it stays
+// small.
+
+// journalEntry is a line of the journal.
+type journalEntry struct {
+	Seq int64  `json:"seq"`
+	Op  string `json:"op"` // "save", "delete" or "done"
+	// Path is the version file or artifact directory, relative to the root.
+	Path string `json:"path,omitempty"`
+}
+
+type journal struct {
+	mu       sync.Mutex
+	f        *os.File
+	seq      int64
+	inflight int
+}
+
+// openJournal starts the journal of the service, and recovers the
+// operations that crashed services left in theirs.
+func (s *fsService) openJournal() error {
+	dir := filepath.Join(s.rootDir, journalsDir)
+	if err := s.mkdirAll(dir); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	// The journal is locked before looking for the others, so that
+	// services starting together do not take each other's for abandoned.
+	j, err := s.createJournal(dir)
+	if err != nil {
+		return err
+	}
+	entries, err := s.readDir(dir)
+	if err != nil {
+		j.f.Close()
+		return fmt.Errorf("failed to list journals: %w", err)
+	}
+	paths := []string{filepath.Join(s.rootDir, journalFile)}
+	for _, e := range entries {
+		if path := filepath.Join(dir, e.Name()); path != j.f.Name() {
+			paths = append(paths, path)
+		}
+	}
+	for _, path := range paths {
+		if err := s.recoverJournal(path); err != nil {
+			j.f.Close()
+			return fmt.Errorf("failed to recover journal: %w", err)
+		}
+	}
+	s.journal = j
+	return nil
+}
+
+// createJournal creates and locks a new journal in dir.
+func (s *fsService) createJournal(dir string) (*journal, error) {
+	for {
+		var id [8]byte
+		rand.Read(id[:])
+		path := filepath.Join(dir, hex.EncodeToString(id[:]))
+		f, err := s.openNoFollow(path, os.O_RDWR|os.O_CREATE|os.O_EXCL|os.O_APPEND, s.perm.fileMode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create journal: %w", err)
+		}
+		if err := lockFile(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock journal: %w", err)
+		}
+		// Another service may have recovered and removed the empty journal
+		// before it was locked.
+		info, err := f.Stat()
+		if err == nil {
+			if cur, lerr := s.lstat(path); lerr == nil && os.SameFile(info, cur) {
+				if err := s.fixPerm(path, s.perm.fileMode); err != nil {
+					f.Close()
+					return nil, err
+				}
+				return &journal{f: f}, nil
+			}
+		}
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create journal: %w", err)
+		}
+	}
+}
+
+// recoverJournal replays the operations of the journal at path that have
+// no done record, and removes it, unless a running service holds it.
+func (s *fsService) recoverJournal(path string) error {
+	f, err := s.openNoFollow(path, os.O_RDONLY, 0)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	ok, err := tryLockFile(f)
+	if err != nil {
+		return fmt.Errorf("failed to lock journal: %w", err)
+	}
+	if !ok {
+		return nil // the journal of a running service
+	}
+	defer unlockFile(f)
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	var pending []journalEntry
+	done := map[int64]bool{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		var e journalEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			continue // torn write of the last record
+		}
+		if e.Op == "done" {
+			done[e.Seq] = true
+		} else {
+			pending = append(pending, e)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	for _, e := range pending {
+		if done[e.Seq] {
+			continue
+		}
+		if err := s.replay(e); err != nil {
+			return err
+		}
+	}
+	if err := s.remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// replay rolls back or completes an interrupted operation, holding the lock
+// of its artifact.
+func (s *fsService) replay(e journalEntry) error {
+	path := filepath.Join(s.rootDir, filepath.FromSlash(e.Path))
+	if !s.below(path) {
+		return fmt.Errorf("journal entry %d: %q is outside of the root directory", e.Seq, e.Path)
+	}
+	if err := s.checkPath(filepath.Dir(path)); err != nil {
+		return err
+	}
+	// The path is a version file, or the directory of an artifact.
+	dir := path
+	if ref, err := s.keys.ParseKey(e.Path); err == nil {
+		dir = s.buildDir(ref.AppName, ref.UserID, ref.SessionID, ref.FileName)
+	}
+	unlock, err := s.lockArtifacts(dir)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	ctx := context.Background()
+	switch e.Op {
+	case "save":
+		if s.saveComplete(path) {
+			return nil
+		}
+		s.logger.WarnContext(ctx, "rolling back interrupted save", "path", path)
+		if err := s.remove(path + tmpSuffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := s.remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err := s.remove(path + ".meta"); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	case "delete":
+		s.logger.WarnContext(ctx, "completing interrupted delete", "path", path)
+		if err := s.removeAll(path); err != nil {
+			return err
+		}
+		if err := s.remove(path + ".meta"); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveComplete reports whether the version file at path has a sidecar with
+// the checksum of its content, the last thing a save writes.
+func (s *fsService) saveComplete(path string) bool {
+	m, err := s.readSidecar(path)
+	if err != nil {
+		return false
+	}
+	a, want := m.Checksum()
+	if a == nil {
+		return false
+	}
+	if _, err := s.lstat(path + ".meta"); err != nil {
+		return false
+	}
+	sum, err := s.hashFile(path, a)
+	return err == nil && sum == want
+}
+
+// intent records that op is about to change path, returning the function
+// recording that it is done. Without a journal, it does nothing.
+func (s *fsService) intent(ctx context.Context, op, path string) (done func(), err error) {
+	j := s.journal
+	if j == nil {
+		return func() {}, nil
+	}
+	rel, err := filepath.Rel(s.rootDir, path)
+	if err != nil {
+		return nil, err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.seq++
+	seq := j.seq
+	if err := j.append(journalEntry{Seq: seq, Op: op, Path: filepath.ToSlash(rel)}); err != nil {
+		return nil, fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := j.f.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to sync journal: %w", err)
+	}
+	j.inflight++
+	return func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		j.inflight--
+		var err error
+		if j.inflight == 0 {
+			err = j.f.Truncate(0)
+		} else {
+			err = j.append(journalEntry{Seq: seq, Op: "done"})
+		}
+		if err == nil {
+			err = j.f.Sync()
+		}
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to write journal", "error", err)
+		}
+	}, nil
+}
+
+func (j *journal) append(e journalEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = j.f.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,129 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.DetailedLister = (*fsService)(nil)
+
+// ListDetailed implements [artifactx.DetailedLister] from the directory
+// entries of the files, without reading their sidecars.
+func (s *fsService) ListDetailed(ctx context.Context, req *artifactx.ListDetailedRequest) (_ []*artifactx.FileInfo, err error) {
+	defer func() { s.audit(ctx, "list", req.AppName, req.UserID, req.SessionID, "", 0, err) }()
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return nil, err
+	}
+	if err := artifactx.CheckSessionID(s.keys, req.SessionID, ""); err != nil {
+		return nil, err
+	}
+	var files []*artifactx.FileInfo
+	for _, name := range s.fileNames(req.AppName, req.UserID, req.SessionID) {
+		resp, err := s.versions(ctx, &artifact.VersionsRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: name,
+		})
+		if errors.Is(err, fs.ErrNotExist) {
+			// an empty directory, or deleted since listing
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		version := slices.Max(resp.Versions)
+		path := s.buildPath(req.AppName, req.UserID, req.SessionID, name, version)
+		info, err := s.lstatNoFollow(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not stat file '%s': %w", path, err)
+		}
+		files = append(files, &artifactx.FileInfo{FileName: name, Version: version, Size: info.Size(), ModTime: info.ModTime()})
+	}
+	return artifactx.SortFiles(files, req.Order, req.Limit), nil
+}
+
+var _ artifactx.RecentLister = (*fsService)(nil)
+
+// ListRecent implements [artifactx.RecentLister] by walking the directory
+// of the user, without reading sidecars. It fails with
+// [errors.ErrUnsupported] if the key layout is not an
+// [artifactx.HierarchyKeyBuilder].
+func (s *fsService) ListRecent(ctx context.Context, appName, userID string, limit int) ([]*artifactx.RecentArtifact, error) {
+	prefix, err := artifactx.SessionsPrefix(s.keys, appName, userID)
+	if err != nil {
+		return nil, err
+	}
+	dir := s.keyPath(prefix)
+	if err := s.checkPath(dir); err != nil {
+		return nil, err
+	}
+	latest := map[artifactx.Ref]*artifactx.RecentArtifact{}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll // no artifacts
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || strings.HasSuffix(path, ".meta") {
+			return nil // symbolic links are never followed
+		}
+		rel, err := filepath.Rel(s.rootDir, path)
+		if err != nil {
+			return err
+		}
+		ref, err := s.keys.ParseKey(filepath.ToSlash(rel))
+		if err != nil {
+			return nil
+		}
+		artifactRef := ref
+		artifactRef.Version = 0
+		if a := latest[artifactRef]; a != nil && a.Version > ref.Version {
+			return nil
+		}
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil // deleted since listing
+		}
+		if err != nil {
+			return err
+		}
+		latest[artifactRef] = &artifactx.RecentArtifact{Ref: ref, Size: info.Size(), ModTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts of user '%s': %w", userID, err)
+	}
+	return artifactx.SortRecent(slices.Collect(maps.Values(latest)), limit), nil
+}
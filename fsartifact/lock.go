@@ -0,0 +1,119 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// locksDir is the directory of the lock files in the root directory.
+const locksDir = ".locks"
+
+// lockArtifacts takes an exclusive advisory lock on each of the artifacts
+// named by dirs, their directories, and returns the function releasing them.
+// The locks are held by an open file, so they exclude other goroutines and
+// other processes sharing the root directory alike, such as an agent and a
+// sidecar saving to the same artifact: without them, both could number
+// their versions the same and overwrite each other.
+//
+// Lock files are kept in rootDir/.locks under a hash of the artifact and
+// never removed, since removing a lock file races with locking it.
+func (s *fsService) lockArtifacts(dirs ...string) (unlock func(), err error) {
+	if len(dirs) > 1 {
+		// A fixed order avoids deadlocks between operations on several artifacts.
+		dirs = slices.SortedFunc(slices.Values(dirs), cmp.Compare)
+		dirs = slices.Compact(dirs)
+	}
+	files := make([]*os.File, 0, len(dirs))
+	unlock = func() {
+		for _, f := range slices.Backward(files) {
+			unlockFile(f)
+			f.Close()
+		}
+	}
+	for _, dir := range dirs {
+		f, err := s.lockFile(dir)
+		if err != nil {
+			unlock()
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return unlock, nil
+}
+
+// tryLockArtifact is lockArtifacts for a single artifact, failing with
+// ok false instead of waiting if another operation holds its lock.
+func (s *fsService) tryLockArtifact(dir string) (unlock func(), ok bool, err error) {
+	f, err := s.openLockFile(dir)
+	if err != nil {
+		return nil, false, err
+	}
+	if ok, err := tryLockFile(f); err != nil || !ok {
+		f.Close()
+		return nil, false, err
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, true, nil
+}
+
+func (s *fsService) lockFile(dir string) (*os.File, error) {
+	f, err := s.openLockFile(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		rel, _ := filepath.Rel(s.rootDir, dir)
+		return nil, fmt.Errorf("failed to lock %q: %w", rel, err)
+	}
+	return f, nil
+}
+
+// openLockFile opens the lock file of the artifact at dir, creating it if
+// needed.
+func (s *fsService) openLockFile(dir string) (*os.File, error) {
+	rel, err := filepath.Rel(s.rootDir, dir)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256([]byte(filepath.ToSlash(rel)))
+	path := filepath.Join(s.rootDir, locksDir, hex.EncodeToString(sum[:16]))
+	if _, err := s.lstat(path); os.IsNotExist(err) {
+		if err := s.mkdirAll(filepath.Dir(path)); err != nil {
+			return nil, fmt.Errorf("failed to create lock directory: %w", err)
+		}
+		f, err := s.create(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+		f.Close()
+	}
+	// Read access suffices to lock, and lets users of a shared root lock
+	// files created by others.
+	f, err := s.openNoFollow(path, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	return f, nil
+}
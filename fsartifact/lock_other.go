@@ -0,0 +1,61 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package fsartifact
+
+import (
+	"os"
+	"sync"
+)
+
+// Without file locks, artifacts are only locked within the process.
+var (
+	fileLocksMu sync.Mutex
+	fileLocks   = map[string]*sync.Mutex{}
+)
+
+func lockFile(f *os.File) error {
+	fileLocksMu.Lock()
+	mu, ok := fileLocks[f.Name()]
+	if !ok {
+		mu = &sync.Mutex{}
+		fileLocks[f.Name()] = mu
+	}
+	fileLocksMu.Unlock()
+	mu.Lock()
+	return nil
+}
+
+// tryLockFile locks f like lockFile, but reports false instead of waiting if
+// it is locked already.
+func tryLockFile(f *os.File) (bool, error) {
+	fileLocksMu.Lock()
+	mu, ok := fileLocks[f.Name()]
+	if !ok {
+		mu = &sync.Mutex{}
+		fileLocks[f.Name()] = mu
+	}
+	fileLocksMu.Unlock()
+	return mu.TryLock(), nil
+}
+
+func unlockFile(f *os.File) error {
+	fileLocksMu.Lock()
+	mu := fileLocks[f.Name()]
+	fileLocksMu.Unlock()
+	mu.Unlock()
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// meta is the content of the ".meta" sidecar file stored next to every
+// version, in the sidecar representation of [artifactx.Meta].
+type meta = artifactx.Meta
+
+// etag returns the ETag of the version file described by info: the
+// cryptographic checksum if one is recorded, and a weak ETag of its size and
+// modification time otherwise.
+func etag(m *meta, info fs.FileInfo) string {
+	if e := m.ETag(); e != "" {
+		return e
+	}
+	return fmt.Sprintf(`W/"%x-%x"`, info.Size(), info.ModTime().UnixNano())
+}
+
+// hashFile returns the hex checksum with a of the file at path.
+func (s *fsService) hashFile(path string, a *artifactx.ChecksumAlgorithm) (string, error) {
+	f, err := s.openNoFollow(path, os.O_RDONLY, 0)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := a.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// readSidecar reads the sidecar of the version file at path. A missing
+// sidecar yields metadata without a content type; see [fsService.readMeta].
+func (s *fsService) readSidecar(path string) (*meta, error) {
+	// Sidecars are small and decoded into new values: read them into a
+	// pooled buffer.
+	buf := artifactx.GetBuffer()
+	defer artifactx.PutBuffer(buf)
+	f, err := s.openNoFollow(path+".meta", os.O_RDONLY, 0)
+	if os.IsNotExist(err) {
+		return &meta{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata file: %w", err)
+	}
+	_, err = buf.ReadFrom(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata file: %w", err)
+	}
+	m, err := artifactx.ParseMeta(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid metadata file: %w", err)
+	}
+	return m, nil
+}
+
+// readMeta is [fsService.readSidecar] with the default content type for
+// versions saved without one, or before sidecars recorded it.
+func (s *fsService) readMeta(path string) (*meta, error) {
+	m, err := s.readSidecar(path)
+	if err != nil {
+		return nil, err
+	}
+	if m.ContentType == "" {
+		m.ContentType = s.defaultContentType
+	}
+	return m, nil
+}
+
+// writeMeta writes the sidecar of the version file at path, in the current
+// format.
+func (s *fsService) writeMeta(path string, m *meta) error {
+	m.Upgrade()
+	buf := artifactx.GetBuffer()
+	defer artifactx.PutBuffer(buf)
+	if err := json.NewEncoder(buf).Encode(m); err != nil {
+		return fmt.Errorf("failed to encode metadata file: %w", err)
+	}
+	data := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	var old int64
+	if info, err := s.lstat(path + ".meta"); err == nil {
+		old = info.Size()
+	}
+	if err := s.writeFile(path+".meta", data); err != nil {
+		return fmt.Errorf("failed to write metadata file: %w", err)
+	}
+	s.addUsage(int64(len(data)) - old)
+	return nil
+}
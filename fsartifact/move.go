@@ -0,0 +1,71 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.Mover = (*fsService)(nil)
+
+// Move implements [artifactx.Mover] by renaming the directory of the artifact.
+func (s *fsService) Move(ctx context.Context, req *artifactx.MoveRequest) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("request validation failed: %w", err)
+	}
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return err
+	}
+	if err := artifactx.CheckSessionID(s.keys, req.SessionID, req.NewFileName); err != nil {
+		return err
+	}
+	src := s.buildDir(req.AppName, req.UserID, req.SessionID, req.FileName)
+	dst := s.buildDir(req.AppName, req.UserID, req.SessionID, req.NewFileName)
+	unlock, err := s.lockArtifacts(src, dst)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, err := s.versions(ctx, &artifact.VersionsRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+	}); err != nil {
+		return err
+	}
+	if _, err := s.versions(ctx, &artifact.VersionsRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.NewFileName,
+	}); err == nil {
+		return fmt.Errorf("artifact %q: %w", req.NewFileName, fs.ErrExist)
+	} else if errors.Is(err, ErrSymlink) {
+		return err
+	}
+	// An empty directory left behind by deleted versions is in the way of
+	// the rename.
+	s.remove(dst)
+	if err := s.mkdirAll(filepath.Dir(filepath.Clean(dst))); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := s.rename(src, dst); err != nil {
+		return fmt.Errorf("failed to move artifact: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrSymlink is returned for artifact paths that traverse a symbolic link
+// below the root directory. The service never follows such links, so that
+// other writers of a shared root directory cannot redirect its reads and
+// writes elsewhere. The root directory itself may be a link.
+var ErrSymlink = errors.New("symbolic link in artifact path")
+
+// Paths below the root directory are checked for links before an operation,
+// which then goes through an [os.Root] of the root directory: a link put in
+// place between the check and the operation can at worst redirect it to
+// another file below the root, never outside of it.
+
+func symlinkError(path string) error {
+	return fmt.Errorf("%q: %w", path, ErrSymlink)
+}
+
+// below reports whether path is below the root directory.
+func (s *fsService) below(path string) bool {
+	rel, err := filepath.Rel(s.rootDir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// rel returns path relative to the root directory, and whether it is the
+// root directory or below it.
+func (s *fsService) rel(path string) (string, bool) {
+	if s.root == nil {
+		return "", false
+	}
+	if filepath.Clean(path) == filepath.Clean(s.rootDir) {
+		return ".", true
+	}
+	if !s.below(path) {
+		return "", false
+	}
+	rel, _ := filepath.Rel(s.rootDir, path)
+	return rel, true
+}
+
+// checkPath fails with [ErrSymlink] if a component of path below the root
+// directory is a symbolic link. The check ends at the first missing
+// component; what is created there later is created by the service.
+func (s *fsService) checkPath(path string) error {
+	rel, ok := s.rel(path)
+	if !ok || rel == "." {
+		return nil
+	}
+	current := s.rootDir
+	for _, name := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, name)
+		info, err := os.Lstat(current)
+		if err != nil {
+			return nil // reported by the operation
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return symlinkError(current)
+		}
+	}
+	return nil
+}
+
+// rootErr returns [ErrSymlink] for the failure err of an operation on path
+// below the root directory if path traverses a link, such as one the root
+// refused to follow outside of it, and err otherwise.
+func (s *fsService) rootErr(path string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if info, lerr := os.Lstat(path); lerr == nil && info.Mode()&fs.ModeSymlink != 0 {
+		return symlinkError(path)
+	}
+	if lerr := s.checkPath(path); lerr != nil {
+		return lerr
+	}
+	return err
+}
+
+// openNoFollow opens path like [os.OpenFile], failing with [ErrSymlink]
+// instead of following a symbolic link in its last component. The
+// components before must have been checked with checkPath.
+func (s *fsService) openNoFollow(path string, flag int, perm fs.FileMode) (*os.File, error) {
+	rel, ok := s.rel(path)
+	if !ok {
+		return os.OpenFile(path, flag|oNoFollow, perm)
+	}
+	f, err := s.root.OpenFile(rel, flag|oNoFollow, perm)
+	if err != nil {
+		return nil, s.rootErr(path, err)
+	}
+	// The root follows links that stay below it; refuse those that were
+	// in place when the file was opened.
+	info, err := s.root.Lstat(rel)
+	if err == nil && info.Mode()&fs.ModeSymlink != 0 {
+		f.Close()
+		return nil, symlinkError(path)
+	}
+	return f, nil
+}
+
+// readFileNoFollow reads the file at path like [os.ReadFile] with
+// openNoFollow.
+func (s *fsService) readFileNoFollow(path string) ([]byte, error) {
+	f, err := s.openNoFollow(path, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// lstatNoFollow returns the file info of path, failing with [ErrSymlink] if
+// it is a symbolic link.
+func (s *fsService) lstatNoFollow(path string) (fs.FileInfo, error) {
+	info, err := s.lstat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		return nil, symlinkError(path)
+	}
+	return info, nil
+}
+
+// The methods below run the [os] function of their name on a path in the
+// root directory through the root.
+
+func (s *fsService) lstat(path string) (fs.FileInfo, error) {
+	if rel, ok := s.rel(path); ok {
+		info, err := s.root.Lstat(rel)
+		return info, s.rootErr(path, err)
+	}
+	return os.Lstat(path)
+}
+
+func (s *fsService) readDir(dir string) ([]os.DirEntry, error) {
+	rel, ok := s.rel(dir)
+	if !ok {
+		return os.ReadDir(dir)
+	}
+	f, err := s.root.Open(rel)
+	if err != nil {
+		return nil, s.rootErr(dir, err)
+	}
+	defer f.Close()
+	entries, err := f.ReadDir(-1)
+	slices.SortFunc(entries, func(a, b os.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, err
+}
+
+func (s *fsService) mkdir(dir string, perm fs.FileMode) error {
+	if rel, ok := s.rel(dir); ok {
+		return s.rootErr(dir, s.root.Mkdir(rel, perm))
+	}
+	return os.Mkdir(dir, perm)
+}
+
+func (s *fsService) remove(path string) error {
+	if rel, ok := s.rel(path); ok {
+		return s.root.Remove(rel)
+	}
+	return os.Remove(path)
+}
+
+func (s *fsService) removeAll(path string) error {
+	if rel, ok := s.rel(path); ok {
+		return s.root.RemoveAll(rel)
+	}
+	return os.RemoveAll(path)
+}
+
+func (s *fsService) rename(oldpath, newpath string) error {
+	oldrel, ok1 := s.rel(oldpath)
+	newrel, ok2 := s.rel(newpath)
+	if ok1 && ok2 {
+		return s.rootErr(newpath, s.root.Rename(oldrel, newrel))
+	}
+	return os.Rename(oldpath, newpath)
+}
+
+func (s *fsService) chmod(path string, mode fs.FileMode) error {
+	if rel, ok := s.rel(path); ok {
+		return s.root.Chmod(rel, mode)
+	}
+	return os.Chmod(path, mode)
+}
+
+func (s *fsService) chown(path string, uid, gid int) error {
+	if rel, ok := s.rel(path); ok {
+		return s.root.Lchown(rel, uid, gid)
+	}
+	return os.Chown(path, uid, gid)
+}
+
+func (s *fsService) chtimes(path string, atime, mtime time.Time) error {
+	if rel, ok := s.rel(path); ok {
+		return s.root.Chtimes(rel, atime, mtime)
+	}
+	return os.Chtimes(path, atime, mtime)
+}
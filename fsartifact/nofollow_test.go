@@ -0,0 +1,96 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+)
+
+func TestSymlinksAreNotFollowed(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symbolic links requires privileges on windows")
+	}
+	ctx := t.Context()
+	root, outside := t.TempDir(), t.TempDir()
+	srv, err := fsartifact.New(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	save := func(sessionID, fileName string) error {
+		_, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: sessionID, FileName: fileName, Part: genai.NewPartFromText("data"),
+		})
+		return err
+	}
+	if err := save("session", "file"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another writer of the root replaces a session with a link.
+	sessionDir := filepath.Join(root, "app", "user", "linked")
+	if err := os.Symlink(outside, sessionDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := save("linked", "file"); !errors.Is(err, fsartifact.ErrSymlink) {
+		t.Errorf("Save() through a linked directory = %v, want %v", err, fsartifact.ErrSymlink)
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Errorf("Save() wrote %d entries outside the root", len(entries))
+	}
+	if err := srv.Delete(ctx, &artifact.DeleteRequest{AppName: "app", UserID: "user", SessionID: "linked", FileName: "file"}); !errors.Is(err, fsartifact.ErrSymlink) {
+		t.Errorf("Delete() through a linked directory = %v, want %v", err, fsartifact.ErrSymlink)
+	}
+
+	// ... or a version file with a link to a file outside.
+	secret := filepath.Join(outside, "secret")
+	if err := os.WriteFile(secret, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	version := filepath.Join(root, "app", "user", "session", "file", "1")
+	if err := os.Remove(version); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, version); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Version: 1}); !errors.Is(err, fsartifact.ErrSymlink) {
+		t.Errorf("Load() of a linked version = %v, want %v", err, fsartifact.ErrSymlink)
+	}
+	if _, err := srv.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"}); err == nil {
+		t.Error("Load() of the latest version succeeded although the only version is a link")
+	}
+
+	// A linked root directory is fine.
+	linkedRoot := filepath.Join(t.TempDir(), "root")
+	if err := os.Symlink(root, linkedRoot); err != nil {
+		t.Fatal(err)
+	}
+	srv, err = fsartifact.New(linkedRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := save("session", "other"); err != nil {
+		t.Errorf("Save() below a linked root = %v", err)
+	}
+}
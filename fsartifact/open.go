@@ -0,0 +1,175 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var (
+	_ artifactx.Stater        = (*fsService)(nil)
+	_ artifactx.Opener        = (*fsService)(nil)
+	_ artifactx.RangeOpener   = (*fsService)(nil)
+	_ artifactx.VersionLister = (*fsService)(nil)
+)
+
+// Stat implements [artifactx.Stater].
+func (s *fsService) Stat(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Attributes, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	path, version, err := s.resolvePath(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.lstatNoFollow(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("artifact '%s' version %d not found: %w", req.FileName, version, fs.ErrNotExist)
+		}
+		return nil, fmt.Errorf("could not stat file '%s': %w", path, err)
+	}
+	return s.attributes(path, version, info)
+}
+
+// ListVersions implements [artifactx.VersionLister].
+func (s *fsService) ListVersions(ctx context.Context, req *artifactx.ListVersionsRequest) ([]*artifactx.Attributes, error) {
+	resp, err := s.versions(ctx, req.VersionsRequest())
+	if err != nil {
+		return nil, err
+	}
+	var attrs []*artifactx.Attributes
+	for _, v := range artifactx.LatestVersions(resp.Versions, req.Limit) {
+		path := s.buildPath(req.AppName, req.UserID, req.SessionID, req.FileName, v)
+		info, err := s.lstatNoFollow(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				// deleted since listing
+				continue
+			}
+			return nil, fmt.Errorf("could not stat file '%s': %w", path, err)
+		}
+		a, err := s.attributes(path, v, info)
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs, nil
+}
+
+// Open implements [artifactx.Opener].
+func (s *fsService) Open(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Reader, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	path, version, err := s.resolvePath(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	f, err := s.openNoFollow(path, os.O_RDONLY, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("artifact '%s' version %d not found: %w", req.FileName, version, fs.ErrNotExist)
+		}
+		return nil, fmt.Errorf("could not open file '%s': %w", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("could not stat file '%s': %w", path, err)
+	}
+	attrs, err := s.attributes(path, version, info)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &artifactx.Reader{ReadCloser: f, Attributes: *attrs}, nil
+}
+
+// OpenRange implements [artifactx.RangeOpener].
+func (s *fsService) OpenRange(ctx context.Context, req *artifact.LoadRequest, offset, length int64) (*artifactx.Reader, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid offset %d", offset)
+	}
+	r, err := s.Open(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	f := r.ReadCloser.(*os.File)
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("could not seek file of artifact '%s': %w", req.FileName, err)
+	}
+	if length >= 0 {
+		r.ReadCloser = artifactx.LimitReadCloser(f, length)
+	}
+	return r, nil
+}
+
+// notModified returns [artifactx.ErrNotModified] if the version file at path
+// fails the conditions attached to ctx. The version number is checked
+// before the file is looked at. The metadata read to check the other
+// conditions is returned, so that Load does not read it again, and is nil
+// if none was read.
+func (s *fsService) notModified(ctx context.Context, path string, version int64) (*meta, error) {
+	if err := artifactx.CheckNewerThan(ctx, version); err != nil {
+		return nil, err
+	}
+	if !artifactx.NeedsAttributes(ctx) {
+		return nil, nil
+	}
+	info, err := s.lstatNoFollow(path)
+	if err != nil {
+		return nil, nil // reported by the read
+	}
+	m, err := s.readMeta(path)
+	if err != nil {
+		return nil, err
+	}
+	return m, artifactx.CheckConditions(ctx, metaAttributes(m, version, info))
+}
+
+func (s *fsService) attributes(path string, version int64, info fs.FileInfo) (*artifactx.Attributes, error) {
+	m, err := s.readMeta(path)
+	if err != nil {
+		return nil, err
+	}
+	return metaAttributes(m, version, info), nil
+}
+
+// metaAttributes returns the attributes of the version file described by m
+// and info.
+func metaAttributes(m *meta, version int64, info fs.FileInfo) *artifactx.Attributes {
+	return &artifactx.Attributes{
+		Version:     version,
+		ContentType: m.ContentType,
+		Size:        info.Size(),
+		ModTime:     info.ModTime(),
+		Created:     info.ModTime(),
+		Metadata:    m.Metadata,
+		ETag:        etag(m, info),
+		Pinned:      m.Pinned,
+		Immutable:   m.Immutable,
+	}
+}
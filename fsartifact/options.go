@@ -0,0 +1,142 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"io/fs"
+	"log/slog"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Option configures a service created with [New].
+type Option func(*fsService)
+
+// WithLogger sets the logger for failures of best effort work, such as
+// removing expired versions. The default is [slog.Default].
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *fsService) {
+		s.logger = logger
+	}
+}
+
+// WithAuditLogger logs an entry to logger for every Save, UpdateJSON, Load,
+// Delete, List and Versions, with the request, its outcome and the tags of
+// [artifactx.WithRequestTags]. Nothing is audited by default.
+func WithAuditLogger(logger *slog.Logger) Option {
+	return func(s *fsService) {
+		s.auditLog = logger
+	}
+}
+
+// WithModes sets the modes of the files and directories the service
+// creates, [DefaultFileMode] and [DefaultDirMode] by default, e.g. 0664 and
+// 0775 (or 02775 to keep the group of new files) for artifact trees shared
+// by the users of a group. The umask of the process applies as for any file
+// created, unless exact is set: then the modes are set as given.
+func WithModes(fileMode, dirMode fs.FileMode, exact bool) Option {
+	return func(s *fsService) {
+		s.perm.fileMode, s.perm.dirMode, s.perm.exact = fileMode, dirMode, exact
+	}
+}
+
+// WithOwner changes the owner and group of the files and directories the
+// service creates to uid and gid, which requires the privilege to do so; -1
+// leaves the respective ID unchanged.
+func WithOwner(uid, gid int) Option {
+	return func(s *fsService) {
+		s.perm.uid, s.perm.gid = uid, gid
+	}
+}
+
+// WithKeyBuilder sets the layout of artifacts below the root directory. The
+// default is [artifactx.DefaultKeyBuilder].
+func WithKeyBuilder(kb artifactx.KeyBuilder) Option {
+	return func(s *fsService) {
+		s.keys = kb
+	}
+}
+
+// WithClock sets the clock stamping saved versions and driving retention.
+// The default is [artifactx.SystemClock].
+func WithClock(c artifactx.Clock) Option {
+	return func(s *fsService) {
+		s.clock = c
+	}
+}
+
+// WithRetention removes the versions r does not keep after every Save.
+func WithRetention(r artifactx.Retention) Option {
+	return func(s *fsService) {
+		s.retention = r
+	}
+}
+
+// WithMaxLoadSize makes Load fail with [artifactx.ErrTooLarge] for versions
+// of more than n bytes instead of reading them into memory. Zero, the
+// default, means no limit; [artifactx.WithMaxLoadSize] lowers it per request.
+func WithMaxLoadSize(n int64) Option {
+	return func(s *fsService) {
+		s.maxLoad = n
+	}
+}
+
+// WithSpaceLimit bounds the disk space used by the service to l, checked
+// before every Save and uploaded chunk, so that artifacts cannot fill the
+// disk of a device. Writes over the limit fail with
+// [artifactx.ErrStorageFull] unless l.Evict is set.
+//
+// Computing the total size walks the root directory, which is only cheap
+// for the small trees of edge devices.
+func WithSpaceLimit(l SpaceLimit) Option {
+	return func(s *fsService) {
+		s.space = l
+	}
+}
+
+// WithJournal records the intent of every Save and Delete in a journal in
+// the root directory, so that a service created after a crash rolls back
+// the saves and completes the deletes the crash interrupted, keeping the
+// version numbering consistent. It costs two syncs of the journal per
+// operation. Every service has a journal of its own, so services of several
+// processes can share the root directory.
+func WithJournal() Option {
+	return func(s *fsService) {
+		s.journaled = true
+	}
+}
+
+// WithDefaultContentType sets the content type of versions saved without
+// one, and of versions whose metadata does not record one, instead of
+// text/plain. The charset of text is recorded as by [artifactx.WithCharset].
+func WithDefaultContentType(contentType string) Option {
+	return func(s *fsService) {
+		if contentType != "" {
+			s.defaultContentType = contentType
+		}
+	}
+}
+
+// WithChecksumAlgorithm makes Save and uploads record the checksum of every
+// version with a instead of SHA-256, e.g. [artifactx.ChecksumBLAKE3] to
+// hash large versions faster. Versions keep the checksum they were written
+// with. Checksums of [artifactx.ChecksumCRC32C] are not reported as ETags.
+func WithChecksumAlgorithm(a *artifactx.ChecksumAlgorithm) Option {
+	return func(s *fsService) {
+		if a != nil {
+			s.checksum = a
+		}
+	}
+}
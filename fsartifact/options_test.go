@@ -0,0 +1,783 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactbackup"
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"github.com/chinglinwen/adk-artifact/tests"
+)
+
+// tenantKeys nests the default layout below a tenant directory.
+type tenantKeys struct {
+	tenant string
+}
+
+func (k tenantKeys) VersionKey(appName, userID, sessionID, fileName string, version int64) string {
+	return k.tenant + "/" + artifactx.DefaultKeyBuilder.VersionKey(appName, userID, sessionID, fileName, version)
+}
+
+func (k tenantKeys) ArtifactPrefix(appName, userID, sessionID, fileName string) string {
+	return k.tenant + "/" + artifactx.DefaultKeyBuilder.ArtifactPrefix(appName, userID, sessionID, fileName)
+}
+
+func (k tenantKeys) ListPrefixes(appName, userID, sessionID string) []string {
+	var prefixes []string
+	for _, p := range artifactx.DefaultKeyBuilder.ListPrefixes(appName, userID, sessionID) {
+		prefixes = append(prefixes, k.tenant+"/"+p)
+	}
+	return prefixes
+}
+
+func (k tenantKeys) ParseKey(key string) (artifactx.Ref, error) {
+	rest, ok := strings.CutPrefix(key, k.tenant+"/")
+	if !ok {
+		return artifactx.Ref{}, fmt.Errorf("key %q outside of tenant %q", key, k.tenant)
+	}
+	return artifactx.DefaultKeyBuilder.ParseKey(rest)
+}
+
+func TestWithKeyBuilder(t *testing.T) {
+	tests.TestArtifactService(t, "FSArtifactTenant", func(t *testing.T) (artifact.Service, error) {
+		return fsartifact.New(t.TempDir(), fsartifact.WithKeyBuilder(tenantKeys{"acme"}))
+	})
+
+	ctx := t.Context()
+	dir := t.TempDir()
+	srv, err := fsartifact.New(dir, fsartifact.WithKeyBuilder(tenantKeys{"acme"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("x"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "acme", "app", "user", "session", "file", "1")); err != nil {
+		t.Errorf("version not stored below the tenant directory: %v", err)
+	}
+	report, err := srv.(artifactx.Checker).Check(ctx, nil)
+	if err != nil || len(report.Anomalies) != 0 {
+		t.Errorf("Check() = (%+v, %v), want no anomalies", report, err)
+	}
+}
+
+func TestWithRetention(t *testing.T) {
+	ctx := t.Context()
+	srv, err := fsartifact.New(t.TempDir(), fsartifact.WithRetention(artifactx.Retention{MaxVersions: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 4 {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+			Part: genai.NewPartFromText(fmt.Sprint(i)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+	if err != nil || !slices.Equal(resp.Versions, []int64{3, 4}) {
+		t.Errorf("Versions() = (%v, %v), want [3 4]", resp, err)
+	}
+}
+
+func TestRetentionDryRun(t *testing.T) {
+	ctx := t.Context()
+	srv, err := fsartifact.New(t.TempDir(), fsartifact.WithRetention(artifactx.Retention{MaxVersions: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var removed []artifactx.Removal
+	ctx = artifactx.WithDryRun(ctx, func(r artifactx.Removal) { removed = append(removed, r) })
+	for i := range 3 {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+			Part: genai.NewPartFromText(fmt.Sprint(i)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []artifactx.Removal{{
+		Ref:    artifactx.Ref{AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Version: 1},
+		Reason: artifactx.ByRetention,
+	}}
+	if !slices.Equal(removed, want) {
+		t.Errorf("dry run reported %v, want %v", removed, want)
+	}
+	resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+	if err != nil || !slices.Equal(resp.Versions, []int64{1, 2, 3}) {
+		t.Errorf("Versions() = (%v, %v), want [1 2 3]", resp, err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	ctx := t.Context()
+	srv, err := fsartifact.New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fileName := range []string{"file", "user:profile"} {
+		for i := range 3 {
+			if _, err := srv.Save(ctx, &artifact.SaveRequest{
+				AppName: "app", UserID: "user", SessionID: "session", FileName: fileName,
+				Part: genai.NewPartFromText(fmt.Sprint(i)),
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	pruner, _ := artifactx.As[artifactx.Pruner](srv)
+	if n, err := pruner.Prune(ctx); err != nil || n != 0 {
+		t.Errorf("Prune() without retention = (%d, %v), want nothing removed", n, err)
+	}
+	setter, _ := artifactx.As[artifactx.RetentionSetter](srv)
+	setter.SetRetention(artifactx.Retention{MaxVersions: 2})
+	if n, err := pruner.Prune(ctx); err != nil || n != 2 {
+		t.Errorf("Prune() = (%d, %v), want 2 versions removed", n, err)
+	}
+	for _, fileName := range []string{"file", "user:profile"} {
+		resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: fileName})
+		if err != nil || !slices.Equal(resp.Versions, []int64{2, 3}) {
+			t.Errorf("Versions(%s) = (%v, %v), want [2 3]", fileName, resp, err)
+		}
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	ctx := t.Context()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := artifactx.NewManualClock(start)
+	srv, err := fsartifact.New(t.TempDir(),
+		fsartifact.WithClock(clock),
+		fsartifact.WithRetention(artifactx.Retention{MaxAge: time.Hour}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	save := func() {
+		t.Helper()
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+			Part: genai.NewPartFromText("data"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save()
+	clock.Advance(30 * time.Minute)
+	save()
+	clock.Advance(45 * time.Minute)
+	save() // version 1 is now older than an hour
+
+	resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+	if err != nil || !slices.Equal(resp.Versions, []int64{2, 3}) {
+		t.Errorf("Versions() = (%v, %v), want [2 3]", resp, err)
+	}
+	stater, _ := artifactx.As[artifactx.Stater](srv)
+	attrs, err := stater.Stat(ctx, &artifact.LoadRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Version: 2,
+	})
+	if want := start.Add(30 * time.Minute); err != nil || !attrs.Created.Equal(want) {
+		t.Errorf("Stat() = (%v, %v), want created at %v", attrs, err, want)
+	}
+}
+
+func TestReservedSessionID(t *testing.T) {
+	ctx := t.Context()
+	srv, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: artifactx.UserNamespace, FileName: "file", Part: genai.NewPartFromText("x"),
+	})
+	if !errors.Is(err, artifactx.ErrReservedSessionID) {
+		t.Errorf("Save() in session %q = %v, want error(%v)", artifactx.UserNamespace, err, artifactx.ErrReservedSessionID)
+	}
+	_, err = srv.List(ctx, &artifact.ListRequest{AppName: "app", UserID: "user", SessionID: artifactx.UserNamespace})
+	if !errors.Is(err, artifactx.ErrReservedSessionID) {
+		t.Errorf("List() of session %q = %v, want error(%v)", artifactx.UserNamespace, err, artifactx.ErrReservedSessionID)
+	}
+}
+
+func TestReservedAppName(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	srv, err := fsartifact.New(dir, fsartifact.WithJournal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, appName := range []string{artifactx.UploadsPrefix, ".journals", ".journal", ".locks"} {
+		_, err = srv.Save(ctx, &artifact.SaveRequest{
+			AppName: appName, UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("x"),
+		})
+		if !errors.Is(err, artifactx.ErrReservedAppName) {
+			t.Errorf("Save() in app %q = %v, want error(%v)", appName, err, artifactx.ErrReservedAppName)
+		}
+		_, err = srv.List(ctx, &artifact.ListRequest{AppName: appName, UserID: "user", SessionID: "session"})
+		if !errors.Is(err, artifactx.ErrReservedAppName) {
+			t.Errorf("List() of app %q = %v, want error(%v)", appName, err, artifactx.ErrReservedAppName)
+		}
+		err = srv.Delete(ctx, &artifact.DeleteRequest{AppName: appName, UserID: "user", SessionID: "session", FileName: "file"})
+		if !errors.Is(err, artifactx.ErrReservedAppName) {
+			t.Errorf("Delete() in app %q = %v, want error(%v)", appName, err, artifactx.ErrReservedAppName)
+		}
+	}
+	// Lock files are the only entries of .locks.
+	entries, err := os.ReadDir(filepath.Join(dir, ".locks"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			t.Errorf("directory %q created in .locks", e.Name())
+		}
+	}
+}
+
+func TestEscapedKeyBuilderMigration(t *testing.T) {
+	tests.TestArtifactService(t, "FSArtifactEscaped", func(t *testing.T) (artifact.Service, error) {
+		return fsartifact.New(t.TempDir(), fsartifact.WithKeyBuilder(artifactx.EscapedKeyBuilder))
+	})
+
+	ctx := t.Context()
+	// A version of a session named "user", written before sessions were checked.
+	oldDir := t.TempDir()
+	legacy := filepath.Join(oldDir, "app", "alice", "user", "notes")
+	if err := os.MkdirAll(legacy, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(legacy, "1"), []byte("session notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old, err := fsartifact.NewService(oldDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := old.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "alice", SessionID: "s1", FileName: "user:profile", Part: genai.NewPartFromText("profile"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	escaped, err := fsartifact.New(t.TempDir(), fsartifact.WithKeyBuilder(artifactx.EscapedKeyBuilder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := artifactbackup.Backup(ctx, old, escaped, nil); err != nil {
+		t.Fatalf("Backup() failed: %v", err)
+	}
+	list, err := escaped.List(ctx, &artifact.ListRequest{AppName: "app", UserID: "alice", SessionID: artifactx.UserNamespace})
+	if err != nil || !slices.Equal(list.FileNames, []string{"notes", "user:profile"}) {
+		t.Errorf("List() of session %q = (%v, %v), want [notes user:profile]", artifactx.UserNamespace, list, err)
+	}
+	list, err = escaped.List(ctx, &artifact.ListRequest{AppName: "app", UserID: "alice", SessionID: "s2"})
+	if err != nil || !slices.Equal(list.FileNames, []string{"user:profile"}) {
+		t.Errorf("List() of another session = (%v, %v), want [user:profile]", list, err)
+	}
+}
+
+func TestZeroBasedKeyBuilder(t *testing.T) {
+	tests.TestArtifactService(t, "FSArtifactZeroBased", func(t *testing.T) (artifact.Service, error) {
+		return fsartifact.New(t.TempDir(), fsartifact.WithKeyBuilder(artifactx.ZeroBasedKeyBuilder(artifactx.DefaultKeyBuilder)))
+	})
+
+	ctx := t.Context()
+	old, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"v1", "v2"} {
+		if _, err := old.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "alice", SessionID: "s1", FileName: "notes", Part: genai.NewPartFromText(text),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := t.TempDir()
+	zero, err := fsartifact.New(dir, fsartifact.WithKeyBuilder(artifactx.ZeroBasedKeyBuilder(artifactx.DefaultKeyBuilder)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := artifactbackup.Backup(ctx, old, zero, nil); err != nil {
+		t.Fatalf("Backup() failed: %v", err)
+	}
+	// Python services store the first version as 0.
+	for name, want := range map[string]string{"0": "v1", "1": "v2"} {
+		data, err := os.ReadFile(filepath.Join(dir, "app", "alice", "s1", "notes", name))
+		if err != nil || string(data) != want {
+			t.Errorf("version file %q = (%q, %v), want %q", name, data, err, want)
+		}
+	}
+	versions, err := zero.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "alice", SessionID: "s1", FileName: "notes"})
+	if err != nil || !slices.Equal(versions.Versions, []int64{1, 2}) {
+		t.Errorf("ListVersions() = (%v, %v), want [1 2]", versions, err)
+	}
+}
+
+func TestWithMaxLoadSize(t *testing.T) {
+	ctx := t.Context()
+	srv, err := fsartifact.New(t.TempDir(), fsartifact.WithMaxLoadSize(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := artifactx.Scoped(srv, "app", "user", "session")
+	for _, text := range []string{"tiny", "too large"} {
+		if _, err := scope.Save(ctx, text, genai.NewPartFromText(text)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := scope.Load(ctx, "tiny"); err != nil {
+		t.Errorf("Load() within the limit failed: %v", err)
+	}
+	if _, err := scope.Load(ctx, "too large"); !errors.Is(err, artifactx.ErrTooLarge) {
+		t.Errorf("Load() above the limit error = %v, want %v", err, artifactx.ErrTooLarge)
+	}
+	// A request can lower the limit, not raise it.
+	if _, err := scope.Load(artifactx.WithMaxLoadSize(ctx, 100), "too large"); !errors.Is(err, artifactx.ErrTooLarge) {
+		t.Errorf("Load() with a higher request limit error = %v, want %v", err, artifactx.ErrTooLarge)
+	}
+}
+
+func TestWithAuditLogger(t *testing.T) {
+	var buf bytes.Buffer
+	srv, err := fsartifact.New(t.TempDir(), fsartifact.WithAuditLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := artifactx.WithRequestTags(t.Context(), map[string]string{"run": "r1"})
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("data"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "missing"}); err == nil {
+		t.Fatal("Load() of a missing artifact succeeded")
+	}
+
+	type entry struct {
+		Op      string            `json:"op"`
+		Session string            `json:"session"`
+		File    string            `json:"file"`
+		Version int64             `json:"version"`
+		Tags    map[string]string `json:"tags"`
+		Error   string            `json:"error"`
+	}
+	var entries []entry
+	for line := range strings.Lines(buf.String()) {
+		var e entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("audit log has %d entries, want one per operation:\n%s", len(entries), buf.String())
+	}
+	if e := entries[0]; e.Op != "save" || e.Session != "session" || e.File != "file" || e.Version != 1 || e.Tags["run"] != "r1" || e.Error != "" {
+		t.Errorf("save entry = %+v, want version 1 tagged with the run", e)
+	}
+	if e := entries[1]; e.Op != "load" || e.File != "missing" || e.Tags["run"] != "r1" || e.Error == "" {
+		t.Errorf("load entry = %+v, want the error", e)
+	}
+}
+
+func TestWithModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on windows")
+	}
+	root := t.TempDir()
+	srv, err := fsartifact.New(root, fsartifact.WithModes(0660, 0770, true), fsartifact.WithOwner(os.Getuid(), os.Getgid()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Save(t.Context(), &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("data"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		want := fs.FileMode(0660)
+		if d.IsDir() {
+			want = 0770 | fs.ModeDir
+		}
+		if info.Mode() != want {
+			t.Errorf("mode of %s = %v, want %v", path, info.Mode(), want)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewDefaultService(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" || runtime.GOOS == "ios" {
+		t.Skip("XDG_DATA_HOME is only used on other systems")
+	}
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	srv, err := fsartifact.NewDefaultService("agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Save(t.Context(), &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("data"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dataHome, "agent", "artifacts", "app", "user", "session", "file")); err != nil {
+		t.Errorf("artifact not stored below XDG_DATA_HOME: %v", err)
+	}
+	if _, err := fsartifact.DefaultRootDir("../agent"); err == nil {
+		t.Errorf("DefaultRootDir(../agent) = nil error, want an error")
+	}
+}
+
+func TestWithSpaceLimit(t *testing.T) {
+	ctx := t.Context()
+	data := bytes.Repeat([]byte("x"), 400)
+	save := func(ctx context.Context, srv artifact.Service, fileName string) error {
+		_, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: fileName,
+			Part: genai.NewPartFromBytes(data, "application/octet-stream"),
+		})
+		return err
+	}
+
+	srv, err := fsartifact.New(t.TempDir(), fsartifact.WithSpaceLimit(fsartifact.SpaceLimit{MaxSize: 1000}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := save(ctx, srv, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := save(ctx, srv, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := save(ctx, srv, "c"); !errors.Is(err, artifactx.ErrStorageFull) {
+		t.Errorf("Save() over the limit = %v, want ErrStorageFull", err)
+	}
+
+	// Versions of 300 bytes take about 430 with their sidecar: two fit.
+	data = data[:300]
+	clock := artifactx.NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	srv, err = fsartifact.New(t.TempDir(), fsartifact.WithClock(clock), fsartifact.WithSpaceLimit(fsartifact.SpaceLimit{MaxSize: 1000, Evict: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := save(ctx, srv, "a"); err != nil {
+			t.Fatal(err)
+		}
+		clock.Advance(time.Minute)
+	}
+	versions := func(fileName string) []int64 {
+		t.Helper()
+		resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: fileName})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return slices.Sorted(slices.Values(resp.Versions))
+	}
+
+	// A dry run reports the eviction and keeps the version.
+	var removed []string
+	dryRun := artifactx.WithDryRun(ctx, func(r artifactx.Removal) {
+		if r.Reason == artifactx.ByEviction {
+			removed = append(removed, r.Ref.String())
+		}
+	})
+	if err := save(dryRun, srv, "b"); err != nil {
+		t.Fatalf("Save() in a dry run = %v", err)
+	}
+	if !slices.Equal(removed, []string{"app/user/session/a/1"}) {
+		t.Errorf("dry run reported evicting %v, want a/1", removed)
+	}
+	if got := versions("a"); !slices.Equal(got, []int64{1, 2}) {
+		t.Errorf("Versions(a) after a dry run = %v, want nothing evicted", got)
+	}
+	if err := srv.Delete(ctx, &artifact.DeleteRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The earliest saved version is evicted, but not the latest of a.
+	if err := save(ctx, srv, "b"); err != nil {
+		t.Fatalf("Save(b) = %v", err)
+	}
+	if got := versions("a"); !slices.Equal(got, []int64{2}) {
+		t.Errorf("Versions(a) = %v, want the oldest version evicted", got)
+	}
+	if err := save(ctx, srv, "c"); !errors.Is(err, artifactx.ErrStorageFull) {
+		t.Errorf("Save() with only latest versions to evict = %v, want ErrStorageFull", err)
+	}
+	if got := versions("b"); !slices.Equal(got, []int64{1}) {
+		t.Errorf("Versions(b) = %v, want the latest version kept", got)
+	}
+}
+
+func TestPinnedVersionsAreKept(t *testing.T) {
+	ctx := t.Context()
+	srv, err := fsartifact.New(t.TempDir(),
+		fsartifact.WithRetention(artifactx.Retention{MaxVersions: 1}),
+		fsartifact.WithSpaceLimit(fsartifact.SpaceLimit{MaxSize: 1000, Evict: true}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	save := func(fileName string, size int) error {
+		_, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: fileName,
+			Part: genai.NewPartFromBytes(bytes.Repeat([]byte("x"), size), "application/octet-stream"),
+		})
+		return err
+	}
+	if err := save("file", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.(artifactx.Pinner).Pin(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"}); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := save("file", 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+	if err != nil || !slices.Equal(slices.Sorted(slices.Values(resp.Versions)), []int64{1, 3}) {
+		t.Errorf("Versions() = (%v, %v), want the pinned and the latest version", resp, err)
+	}
+
+	// Version 1 is pinned and version 3 the latest: neither is evicted.
+	if err := save("other", 800); !errors.Is(err, artifactx.ErrStorageFull) {
+		t.Errorf("Save() = %v, want ErrStorageFull", err)
+	}
+	resp, err = srv.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+	if err != nil || !slices.Equal(slices.Sorted(slices.Values(resp.Versions)), []int64{1, 3}) {
+		t.Errorf("Versions() after eviction = (%v, %v), want both versions kept", resp, err)
+	}
+}
+
+func TestWithJournal(t *testing.T) {
+	ctx := t.Context()
+	root := t.TempDir()
+	srv, err := fsartifact.New(root, fsartifact.WithJournal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"file", "gone"} {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: name, Part: genai.NewPartFromText("v1"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	journals, err := filepath.Glob(filepath.Join(root, ".journals", "*"))
+	if err != nil || len(journals) != 1 {
+		t.Fatalf("journals = (%v, %v), want the one of the service", journals, err)
+	}
+	if data, err := os.ReadFile(journals[0]); err != nil || len(data) != 0 {
+		t.Errorf("journal = (%q, %v), want it empty without operations in flight", data, err)
+	}
+
+	// The save of a running service is left alone by a service starting.
+	inflight := filepath.Join(root, "app", "user", "session", "inflight", "1")
+	if err := os.MkdirAll(filepath.Dir(inflight), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(inflight, []byte("partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(journals[0], []byte(`{"seq":1,"op":"save","path":"app/user/session/inflight/1"}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsartifact.New(root, fsartifact.WithJournal()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(inflight); err != nil {
+		t.Errorf("the save in flight of a running service was rolled back: %v", err)
+	}
+	if err := os.RemoveAll(filepath.Dir(inflight)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash during a save of version 2 and a delete, after a
+	// save of version 1 completed.
+	if err := os.WriteFile(filepath.Join(root, "app", "user", "session", "file", "2"), []byte("partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	journal := `{"seq":1,"op":"save","path":"app/user/session/file/1"}
+{"seq":2,"op":"save","path":"app/user/session/file/2"}
+{"seq":3,"op":"delete","path":"app/user/session/gone"}
+{"seq":1,"op":"do`
+	if err := os.WriteFile(filepath.Join(root, ".journals", "crashed"), []byte(journal), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, err = fsartifact.New(root, fsartifact.WithJournal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+	if err != nil || !slices.Equal(resp.Versions, []int64{1}) {
+		t.Errorf("Versions() = (%v, %v), want the interrupted save rolled back", resp, err)
+	}
+	if _, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "gone"}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Versions() of the deleted artifact = %v, want the interrupted delete completed", err)
+	}
+	save, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("v2"),
+	})
+	if err != nil || save.Version != 2 {
+		t.Errorf("Save() = (%v, %v), want version 2", save, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".journals", "crashed")); !os.IsNotExist(err) {
+		t.Errorf("the recovered journal was kept: %v", err)
+	}
+	report, err := srv.(artifactx.Checker).Check(ctx, nil)
+	if err != nil || len(report.Anomalies) != 0 {
+		t.Errorf("Check() = (%+v, %v), want the journal ignored", report, err)
+	}
+}
+
+func TestWithChecksumAlgorithm(t *testing.T) {
+	ctx := t.Context()
+	for _, tc := range []struct {
+		alg    *artifactx.ChecksumAlgorithm
+		strong bool
+	}{
+		{artifactx.ChecksumBLAKE3, true},
+		{artifactx.ChecksumCRC32C, false},
+	} {
+		root := t.TempDir()
+		srv, err := fsartifact.New(root, fsartifact.WithJournal(), fsartifact.WithChecksumAlgorithm(tc.alg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("v1"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(filepath.Join(root, "app", "user", "session", "file", "1.meta"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := artifactx.ParseMeta(data)
+		if err != nil || m.SHA256 != "" || m.Checksums[tc.alg.Name] != tc.alg.Sum([]byte("v1")) {
+			t.Errorf("%s: sidecar = (%s, %v), want only the %[1]s checksum", tc.alg, data, err)
+		}
+		stater, _ := artifactx.As[artifactx.Stater](srv)
+		attrs, err := stater.Stat(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := attrs.ETag == artifactx.ChecksumETag(tc.alg.Sum([]byte("v1"))); got != tc.strong {
+			t.Errorf("%s: Stat().ETag = %q, want the checksum: %t", tc.alg, attrs.ETag, tc.strong)
+		}
+
+		// A save the journal saw start is kept if its checksum matches.
+		if err := os.WriteFile(filepath.Join(root, ".journal"), []byte(`{"seq":1,"op":"save","path":"app/user/session/file/1"}`+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		srv, err = fsartifact.New(root, fsartifact.WithJournal(), fsartifact.WithChecksumAlgorithm(tc.alg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+		if err != nil || !slices.Equal(resp.Versions, []int64{1}) {
+			t.Errorf("%s: Versions() after recovery = (%v, %v), want [1]", tc.alg, resp, err)
+		}
+	}
+}
+
+func TestWithDefaultContentType(t *testing.T) {
+	ctx := t.Context()
+	root := t.TempDir()
+	srv, err := fsartifact.New(root, fsartifact.WithDefaultContentType("text/csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stater, _ := artifactx.As[artifactx.Stater](srv)
+	uploader, _ := artifactx.As[artifactx.Uploader](srv)
+	req := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "export.csv"}
+	utf16 := []byte{0xff, 0xfe, 'h', 0, 0xe9, 0}
+
+	// A UTF-16 export saved without a content type gets the default with
+	// its charset, and loads as UTF-8.
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+		Part: &genai.Part{InlineData: &genai.Blob{Data: utf16}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if attrs, err := stater.Stat(ctx, req); err != nil || attrs.ContentType != "text/csv; charset=utf-16le" {
+		t.Errorf("Stat() = (%+v, %v), want text/csv in UTF-16LE", attrs, err)
+	}
+	resp, err := srv.Load(ctx, req)
+	if err != nil || string(resp.Part.InlineData.Data) != "hé" || resp.Part.InlineData.MIMEType != "text/csv; charset=utf-8" {
+		t.Errorf("Load() = (%v, %v), want hé in UTF-8", resp, err)
+	}
+
+	// So does an upload.
+	u, err := uploader.BeginUpload(ctx, &artifactx.UploadRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uploader.AppendChunk(ctx, u.ID, 0, utf16); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uploader.CommitUpload(ctx, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	if attrs, err := stater.Stat(ctx, req); err != nil || attrs.Version != 2 || attrs.ContentType != "text/csv; charset=utf-16le" {
+		t.Errorf("Stat() of the upload = (%+v, %v), want text/csv in UTF-16LE", attrs, err)
+	}
+
+	// Versions without a sidecar have the default content type.
+	if err := os.Remove(filepath.Join(root, "app", "user", "session", "export.csv", "1.meta")); err != nil {
+		t.Fatal(err)
+	}
+	req.Version = 1
+	if attrs, err := stater.Stat(ctx, req); err != nil || attrs.ContentType != "text/csv" {
+		t.Errorf("Stat() without a sidecar = (%+v, %v), want text/csv", attrs, err)
+	}
+}
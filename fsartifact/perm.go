@@ -0,0 +1,120 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Default modes of the files and directories created by the service, before
+// the umask of the process applies.
+const (
+	DefaultFileMode fs.FileMode = 0644
+	DefaultDirMode  fs.FileMode = 0755
+)
+
+// perm are the permission settings of [WithModes] and [WithOwner].
+type perm struct {
+	fileMode, dirMode fs.FileMode
+	exact             bool // ignore the umask
+	uid, gid          int  // -1 leaves them unchanged
+}
+
+var defaultPerm = perm{fileMode: DefaultFileMode, dirMode: DefaultDirMode, uid: -1, gid: -1}
+
+// fixPerm applies the exact mode and ownership to the file or directory at
+// path the service created.
+func (s *fsService) fixPerm(path string, mode fs.FileMode) error {
+	if s.perm.exact {
+		if err := s.chmod(path, mode); err != nil {
+			return fmt.Errorf("failed to set mode: %w", err)
+		}
+	}
+	if s.perm.uid != -1 || s.perm.gid != -1 {
+		if err := s.chown(path, s.perm.uid, s.perm.gid); err != nil {
+			return fmt.Errorf("failed to set owner: %w", err)
+		}
+	}
+	return nil
+}
+
+// mkdirAll creates dir and its missing parents like [os.MkdirAll], with the
+// directory mode and ownership of the service. Below the root directory, it
+// fails with [ErrSymlink] instead of following symbolic links.
+func (s *fsService) mkdirAll(dir string) error {
+	dir = filepath.Clean(dir)
+	if !s.below(dir) {
+		if _, err := os.Stat(dir); err == nil {
+			return nil
+		}
+		if err := os.MkdirAll(dir, s.perm.dirMode); err != nil {
+			return err
+		}
+		return s.fixPerm(dir, s.perm.dirMode)
+	}
+	info, err := s.lstat(dir)
+	switch {
+	case err == nil && info.Mode()&fs.ModeSymlink != 0:
+		return symlinkError(dir)
+	case err == nil && !info.IsDir():
+		return fmt.Errorf("%q is not a directory", dir)
+	case err == nil:
+		return nil
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+	if err := s.mkdirAll(filepath.Dir(dir)); err != nil {
+		return err
+	}
+	if err := s.mkdir(dir, s.perm.dirMode); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return s.mkdirAll(dir) // created concurrently
+		}
+		return err
+	}
+	return s.fixPerm(dir, s.perm.dirMode)
+}
+
+// writeFile writes data to the file at path like [os.WriteFile], with the
+// file mode and ownership of the service.
+func (s *fsService) writeFile(path string, data []byte) error {
+	f, err := s.create(path)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// create creates or truncates the file at path for writing, with the file
+// mode and ownership of the service.
+func (s *fsService) create(path string) (*os.File, error) {
+	f, err := s.openNoFollow(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, s.perm.fileMode)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fixPerm(path, s.perm.fileMode); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
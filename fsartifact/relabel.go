@@ -0,0 +1,59 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.Relabeler = (*fsService)(nil)
+
+// Relabel implements [artifactx.Relabeler] by rewriting the sidecar of the
+// version.
+func (s *fsService) Relabel(ctx context.Context, req *artifact.LoadRequest, change *artifactx.MetadataChange) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("request validation failed: %w", err)
+	}
+	if err := change.Validate(); err != nil {
+		return fmt.Errorf("invalid metadata change: %w", err)
+	}
+	path, version, err := s.resolvePath(ctx, req)
+	if err != nil {
+		return err
+	}
+	if _, err := s.lstatNoFollow(path); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("artifact '%s' version %d not found: %w", req.FileName, version, fs.ErrNotExist)
+		}
+		return fmt.Errorf("could not stat file '%s': %w", path, err)
+	}
+	m, err := s.readMeta(path)
+	if err != nil {
+		return err
+	}
+	md, changed := change.Apply(m.Metadata)
+	if !changed {
+		return nil
+	}
+	m.Metadata = md
+	return s.writeMeta(path, m)
+}
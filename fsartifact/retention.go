@@ -0,0 +1,130 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var (
+	_ artifactx.RetentionSetter   = (*fsService)(nil)
+	_ artifactx.RetentionReporter = (*fsService)(nil)
+	_ artifactx.Pruner            = (*fsService)(nil)
+)
+
+// SetRetention implements [artifactx.RetentionSetter].
+func (s *fsService) SetRetention(r artifactx.Retention) {
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+	s.retention = r
+}
+
+// Retention implements [artifactx.RetentionReporter].
+func (s *fsService) Retention() (artifactx.Retention, artifactx.Clock) {
+	return s.currentRetention(), s.clock
+}
+
+func (s *fsService) currentRetention() artifactx.Retention {
+	s.settingsMu.RLock()
+	defer s.settingsMu.RUnlock()
+	return s.retention
+}
+
+// Prune implements [artifactx.Pruner] by applying the retention to every
+// artifact. Failures to remove versions are logged, as they are on Save.
+func (s *fsService) Prune(ctx context.Context) (int, error) {
+	if s.currentRetention() == (artifactx.Retention{}) {
+		return 0, nil
+	}
+	refs, err := artifactx.Artifacts(ctx, s)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, ref := range refs {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+		removed += s.applyRetention(ctx, ref.AppName, ref.UserID, ref.SessionID, ref.FileName)
+	}
+	return removed, nil
+}
+
+// applyRetention removes the versions of an artifact that the retention
+// policy does not keep and returns how many it removed. Failures are
+// logged: the Save that triggered it already succeeded.
+func (s *fsService) applyRetention(ctx context.Context, appName, userID, sessionID, fileName string) int {
+	retention := s.currentRetention()
+	if retention == (artifactx.Retention{}) {
+		return 0
+	}
+	dir := s.buildDir(appName, userID, sessionID, fileName)
+	entries, err := s.readDir(dir)
+	if err != nil {
+		s.logger.WarnContext(ctx, "failed to list versions for retention", "dir", dir, "error", err)
+		return 0
+	}
+	var versions []artifactx.VersionInfo
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasSuffix(entry.Name(), ".meta") {
+			continue
+		}
+		ref, err := s.keys.ParseKey(s.keys.ArtifactPrefix(appName, userID, sessionID, fileName) + entry.Name())
+		if err != nil {
+			continue
+		}
+		v := ref.Version
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		versions = append(versions, artifactx.VersionInfo{
+			Version: v, ModTime: info.ModTime(), Pinned: s.pinned(filepath.Join(dir, entry.Name())),
+		})
+	}
+	removed := 0
+	for _, v := range retention.Expired(versions, s.clock.Now()) {
+		path := s.buildPath(appName, userID, sessionID, fileName, v)
+		if s.dryRun(ctx, path, artifactx.ByRetention) {
+			continue
+		}
+		if err := s.remove(path); err != nil && !os.IsNotExist(err) {
+			s.logger.WarnContext(ctx, "failed to remove expired version", "path", path, "error", err)
+			continue
+		}
+		s.remove(path + ".meta")
+		removed++
+	}
+	return removed
+}
+
+// dryRun reports the version file at path to the function attached to ctx
+// with [artifactx.WithDryRun], if any, and whether it did so and the version
+// must be kept.
+func (s *fsService) dryRun(ctx context.Context, path string, reason artifactx.RemovalReason) bool {
+	report, ok := artifactx.DryRunFrom(ctx)
+	if !ok {
+		return false
+	}
+	if ref, ok := s.ref(path); ok {
+		report(artifactx.Removal{Ref: ref, Reason: reason})
+	}
+	return true
+}
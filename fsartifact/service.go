@@ -21,196 +21,402 @@ package fsartifact
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
+	"log/slog"
 	"maps"
 	"os"
 	"path/filepath"
 	"slices"
 	"sort"
-	"strconv"
 	"strings"
+	"sync"
 
 	"google.golang.org/adk/artifact"
-	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
 )
 
 // fsService is a file system implementation of the Service.
 type fsService struct {
-	rootDir string
+	rootDir  string
+	root     *os.Root
+	keys     artifactx.KeyBuilder
+	logger   *slog.Logger
+	clock    artifactx.Clock
+	maxLoad  int64
+	auditLog *slog.Logger
+	perm     perm
+	spaceMu  sync.Mutex
+	// used is the disk usage counted by makeRoom once usedKnown, guarded
+	// by spaceMu.
+	used      int64
+	usedKnown bool
+	journaled bool
+	journal   *journal
+	checksum  *artifactx.ChecksumAlgorithm
+	// defaultContentType is the content type of versions saved without one.
+	defaultContentType string
+	// settingsMu guards the settings that can change at runtime.
+	settingsMu sync.RWMutex
+	retention  artifactx.Retention
+	space      SpaceLimit
 }
 
 // NewService creates a FS service for the specified root directory.
+//
+// It is equivalent to [New] without options.
 func NewService(rootDir string) (artifact.Service, error) {
-	if err := os.MkdirAll(rootDir, 0755); err != nil {
+	return New(rootDir)
+}
+
+// New creates a FS service for the specified root directory, configured by opts.
+func New(rootDir string, opts ...Option) (artifact.Service, error) {
+	s := &fsService{
+		rootDir:            rootDir,
+		keys:               artifactx.DefaultKeyBuilder,
+		logger:             slog.Default(),
+		clock:              artifactx.SystemClock,
+		perm:               defaultPerm,
+		checksum:           artifactx.ChecksumSHA256,
+		defaultContentType: "text/plain",
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if err := s.mkdirAll(rootDir); err != nil {
 		return nil, fmt.Errorf("failed to create root dir: %w", err)
 	}
-	return &fsService{
-		rootDir: rootDir,
-	}, nil
+	root, err := os.OpenRoot(rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open root dir: %w", err)
+	}
+	s.root = root
+	if s.space.MinFree > 0 {
+		if _, err := freeSpace(rootDir); err != nil {
+			return nil, fmt.Errorf("failed to get free disk space: %w", err)
+		}
+	}
+	if s.journaled {
+		if err := s.openJournal(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// stamp sets the modification time of the version file at path to the time
+// of the clock, or the time attached with [artifactx.WithCreatedTime], which
+// is what retention and Stat report as created.
+func (s *fsService) stamp(ctx context.Context, path string) error {
+	created := artifactx.CreatedTimeFrom(ctx, s.clock.Now())
+	if err := s.chtimes(path, created, created); err != nil {
+		return fmt.Errorf("failed to set file time: %w", err)
+	}
+	return nil
 }
 
-// fileHasUserNamespace checks if a filename indicates a user-namespaced blob.
-func fileHasUserNamespace(filename string) bool {
-	return strings.HasPrefix(filename, "user:")
+// keyPath converts a key of the key builder to a path below the root dir.
+func (s *fsService) keyPath(key string) string {
+	return filepath.Join(s.rootDir, filepath.FromSlash(key))
 }
 
 // buildPath constructs the file path in the file system.
 func (s *fsService) buildPath(appName, userID, sessionID, fileName string, version int64) string {
-	if fileHasUserNamespace(fileName) {
-		return filepath.Join(s.rootDir, appName, userID, "user", fileName, fmt.Sprintf("%d", version))
-	}
-	return filepath.Join(s.rootDir, appName, userID, sessionID, fileName, fmt.Sprintf("%d", version))
+	return s.keyPath(s.keys.VersionKey(appName, userID, sessionID, fileName, version))
 }
 
 // buildDir constructs the directory path for a specific artifact (containing versions).
 func (s *fsService) buildDir(appName, userID, sessionID, fileName string) string {
-	if fileHasUserNamespace(fileName) {
-		return filepath.Join(s.rootDir, appName, userID, "user", fileName)
-	}
-	return filepath.Join(s.rootDir, appName, userID, sessionID, fileName)
+	return s.keyPath(s.keys.ArtifactPrefix(appName, userID, sessionID, fileName))
 }
 
-func (s *fsService) buildSessionDir(appName, userID, sessionID string) string {
-	return filepath.Join(s.rootDir, appName, userID, sessionID)
-}
-
-func (s *fsService) buildUserDir(appName, userID string) string {
-	return filepath.Join(s.rootDir, appName, userID, "user")
+// resolvePath returns the path of the requested version, resolving a zero
+// version to the latest one.
+func (s *fsService) resolvePath(ctx context.Context, req *artifact.LoadRequest) (string, int64, error) {
+	version := req.Version
+	if version == 0 {
+		response, err := s.versions(ctx, &artifact.VersionsRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+		})
+		if err != nil {
+			return "", 0, err // artifact not found error comes from Versions
+		}
+		version = slices.Max(response.Versions)
+	}
+	return s.buildPath(req.AppName, req.UserID, req.SessionID, req.FileName, version), version, nil
 }
 
 // Save implements [artifact.Service]
-func (s *fsService) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
-	if err := req.Validate(); err != nil {
+func (s *fsService) Save(ctx context.Context, req *artifact.SaveRequest) (resp *artifact.SaveResponse, err error) {
+	defer func() {
+		var version int64
+		if resp != nil {
+			version = resp.Version
+		}
+		s.audit(ctx, "save", req.AppName, req.UserID, req.SessionID, req.FileName, version, err)
+	}()
+	if err := artifactx.ValidateSave(req); err != nil {
 		return nil, fmt.Errorf("request validation failed: %w", err)
 	}
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return nil, err
+	}
+	if err := artifactx.CheckSessionID(s.keys, req.SessionID, req.FileName); err != nil {
+		return nil, err
+	}
 	appName, userID, sessionID, fileName := req.AppName, req.UserID, req.SessionID, req.FileName
 	newArtifact := req.Part
-
-	nextVersion := int64(1)
+	unlock, err := s.lockArtifacts(s.buildDir(appName, userID, sessionID, fileName))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var latest int64
+	response, err := s.versions(ctx, &artifact.VersionsRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+	})
+	if err == nil && len(response.Versions) > 0 {
+		latest = slices.Max(response.Versions)
+		if err := s.checkMutable(s.buildPath(appName, userID, sessionID, fileName, latest), fileName); err != nil {
+			return nil, err
+		}
+	}
+	nextVersion := latest + 1
 	if req.Version > 0 {
 		nextVersion = req.Version
-	} else {
-		// Find next version
-		response, err := s.Versions(ctx, &artifact.VersionsRequest{
-			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
-		})
-		if err == nil && len(response.Versions) > 0 {
-			nextVersion = slices.Max(response.Versions) + 1
-		}
 	}
 
-	path := s.buildPath(appName, userID, sessionID, fileName, nextVersion)
-	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-		return nil, fmt.Errorf("failed to create directory: %w", err)
+	data, contentType, err := artifactx.EncodePart(newArtifact)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.writeVersion(ctx, s.buildPath(appName, userID, sessionID, fileName, nextVersion), data, contentType); err != nil {
+		return nil, err
 	}
 
-	var data []byte
-	var contentType string
+	s.applyRetention(ctx, appName, userID, sessionID, fileName)
+	return &artifact.SaveResponse{Version: nextVersion}, nil
+}
 
-	if newArtifact.InlineData != nil {
-		data = newArtifact.InlineData.Data
-		contentType = newArtifact.InlineData.MIMEType
-	} else {
-		data = []byte(newArtifact.Text)
-		contentType = "text/plain"
+// tmpSuffix is appended to the path of a version while it is written.
+const tmpSuffix = ".tmp"
+
+// writeVersion writes the version file at path and its sidecar, with the
+// metadata attached to ctx. The artifact must be locked. If ctx is
+// cancelled before the version file is in place, nothing is written.
+func (s *fsService) writeVersion(ctx context.Context, path string, data []byte, contentType string) error {
+	if err := s.mkdirAll(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
 	}
+	done, err := s.intent(ctx, "save", path)
+	if err != nil {
+		return err
+	}
+	defer done()
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
-		return nil, fmt.Errorf("failed to write file: %w", err)
+	if err := s.makeRoom(ctx, int64(len(data)), filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	// Write a temporary file renamed into place, so that a failed or
+	// cancelled save never leaves a partial version.
+	tmp := path + tmpSuffix
+	if err := s.writeFile(tmp, data); err != nil {
+		s.remove(tmp)
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := s.stamp(ctx, tmp); err != nil {
+		s.remove(tmp)
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		s.remove(tmp)
+		return err
+	}
+	if err := s.rename(tmp, path); err != nil {
+		s.remove(tmp)
+		return fmt.Errorf("failed to write file: %w", err)
 	}
 
 	// Write metadata file for ContentType
-	metaPath := path + ".meta"
-	if err := os.WriteFile(metaPath, []byte(contentType), 0644); err != nil {
+	if contentType == "" {
+		contentType = artifactx.WithCharset(s.defaultContentType, data)
+	}
+	m := &meta{ContentType: contentType, Metadata: artifactx.MetadataFrom(ctx), Immutable: artifactx.ImmutableFrom(ctx)}
+	m.SetChecksum(s.checksum, s.checksum.Sum(data))
+	if err := s.writeMeta(path, m); err != nil {
 		// Best effort cleanup
-		os.Remove(path)
-		return nil, fmt.Errorf("failed to write metadata file: %w", err)
+		s.remove(path)
+		return err
 	}
-
-	return &artifact.SaveResponse{Version: nextVersion}, nil
+	return nil
 }
 
 // Load implements [artifact.Service]
-func (s *fsService) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+func (s *fsService) Load(ctx context.Context, req *artifact.LoadRequest) (_ *artifact.LoadResponse, err error) {
+	defer func() { s.audit(ctx, "load", req.AppName, req.UserID, req.SessionID, req.FileName, req.Version, err) }()
 	if err := req.Validate(); err != nil {
 		return nil, fmt.Errorf("request validation failed: %w", err)
 	}
-	appName, userID, sessionID, fileName := req.AppName, req.UserID, req.SessionID, req.FileName
-	version := req.Version
-
-	if version == 0 {
-		response, err := s.Versions(ctx, &artifact.VersionsRequest{
-			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
-		})
-		if err != nil {
-			return nil, err // artifact not found error comes from Versions
-		}
-		version = slices.Max(response.Versions)
+	path, version, err := s.resolvePath(ctx, req)
+	if err != nil {
+		return nil, err
 	}
 
-	path := s.buildPath(appName, userID, sessionID, fileName, version)
-
-	data, err := os.ReadFile(path)
+	m, err := s.notModified(ctx, path, version)
+	if err != nil {
+		return nil, fmt.Errorf("artifact '%s' version %d: %w", req.FileName, version, err)
+	}
+	data, err := s.readFile(ctx, path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("artifact '%s' version %d not found: %w", fileName, version, fs.ErrNotExist)
+			return nil, fmt.Errorf("artifact '%s' version %d not found: %w", req.FileName, version, fs.ErrNotExist)
+		}
+		if errors.Is(err, artifactx.ErrTooLarge) {
+			return nil, fmt.Errorf("artifact '%s' version %d: %w", req.FileName, version, err)
 		}
 		return nil, fmt.Errorf("could not read file '%s': %w", path, err)
 	}
 
-	var contentType string
-	metaPath := path + ".meta"
-	metaData, err := os.ReadFile(metaPath)
-	if err == nil {
-		contentType = string(metaData)
-	} else {
-		contentType = "text/plain"
+	if m == nil {
+		if m, err = s.readMeta(path); err != nil {
+			return nil, err
+		}
 	}
 
-	part := genai.NewPartFromBytes(data, contentType)
+	part, err := artifactx.DecodePart(data, m.ContentType)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode file '%s': %w", path, err)
+	}
 	return &artifact.LoadResponse{Part: part}, nil
 }
 
+// readFile reads the version file at path within the load size limit.
+func (s *fsService) readFile(ctx context.Context, path string) ([]byte, error) {
+	f, err := s.openNoFollow(path, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	limit := artifactx.MaxLoadSize(ctx, s.maxLoad)
+	if err := artifactx.CheckLoadSize(info.Size(), limit); err != nil {
+		return nil, err
+	}
+	return artifactx.ReadAllSize(f, info.Size(), limit)
+}
+
 // Delete implements [artifact.Service]
-func (s *fsService) Delete(ctx context.Context, req *artifact.DeleteRequest) error {
+func (s *fsService) Delete(ctx context.Context, req *artifact.DeleteRequest) (err error) {
+	defer func() { s.audit(ctx, "delete", req.AppName, req.UserID, req.SessionID, req.FileName, req.Version, err) }()
 	if err := req.Validate(); err != nil {
 		return fmt.Errorf("request validation failed: %w", err)
 	}
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return err
+	}
 	appName, userID, sessionID, fileName := req.AppName, req.UserID, req.SessionID, req.FileName
 	version := req.Version
+	if err := s.checkPath(s.buildDir(appName, userID, sessionID, fileName)); err != nil {
+		return err
+	}
+	if _, ok := artifactx.DryRunFrom(ctx); ok {
+		return s.dryRunDelete(ctx, req)
+	}
+	unlock, err := s.lockArtifacts(s.buildDir(appName, userID, sessionID, fileName))
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	if version != 0 {
 		path := s.buildPath(appName, userID, sessionID, fileName, version)
-		err := os.Remove(path)
+		done, err := s.intent(ctx, "delete", path)
+		if err != nil {
+			return err
+		}
+		defer done()
+		err = s.remove(path)
 		// Clean up meta file as well
-		os.Remove(path + ".meta")
+		s.remove(path + ".meta")
 		if err != nil && !os.IsNotExist(err) {
 			return fmt.Errorf("failed to delete artifact file: %w", err)
 		}
+		// Remove the directory with the last version, so that List no
+		// longer returns the file; it fails if other versions are left.
+		s.remove(s.buildDir(appName, userID, sessionID, fileName))
 		return nil
 	}
 
 	// Delete all versions (remove the whole directory for the artifact)
 	dir := s.buildDir(appName, userID, sessionID, fileName)
-	err := os.RemoveAll(dir)
+	done, err := s.intent(ctx, "delete", dir)
 	if err != nil {
+		return err
+	}
+	defer done()
+	if err := s.removeAll(dir); err != nil {
 		return fmt.Errorf("failed to delete artifact directory: %w", err)
 	}
 	return nil
 }
 
+// dryRunDelete reports the versions Delete would remove.
+func (s *fsService) dryRunDelete(ctx context.Context, req *artifact.DeleteRequest) error {
+	versions := []int64{req.Version}
+	if req.Version == 0 {
+		resp, err := s.versions(ctx, &artifact.VersionsRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+		})
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		versions = resp.Versions
+	}
+	for _, v := range versions {
+		path := s.buildPath(req.AppName, req.UserID, req.SessionID, req.FileName, v)
+		if _, err := s.lstatNoFollow(path); err == nil {
+			s.dryRun(ctx, path, artifactx.ByDelete)
+		}
+	}
+	return nil
+}
+
 // List implements [artifact.Service]
-func (s *fsService) List(ctx context.Context, req *artifact.ListRequest) (*artifact.ListResponse, error) {
+func (s *fsService) List(ctx context.Context, req *artifact.ListRequest) (_ *artifact.ListResponse, err error) {
+	defer func() { s.audit(ctx, "list", req.AppName, req.UserID, req.SessionID, "", 0, err) }()
 	if err := req.Validate(); err != nil {
 		return nil, fmt.Errorf("request validation failed: %w", err)
 	}
-	appName, userID, sessionID := req.AppName, req.UserID, req.SessionID
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return nil, err
+	}
+	if err := artifactx.CheckSessionID(s.keys, req.SessionID, ""); err != nil {
+		return nil, err
+	}
+	return &artifact.ListResponse{FileNames: s.fileNames(req.AppName, req.UserID, req.SessionID)}, nil
+}
+
+// fileNames returns the sorted names of the files of a session and the user
+// scoped files.
+func (s *fsService) fileNames(appName, userID, sessionID string) []string {
 	filenamesSet := map[string]bool{}
 
 	// Helper to read dir
 	readDir := func(dir string) {
-		entries, err := os.ReadDir(dir)
+		if s.checkPath(dir) != nil {
+			return // not followed
+		}
+		entries, err := s.readDir(dir)
 		if err != nil {
 			return // Ignore missing dirs
 		}
@@ -221,26 +427,35 @@ func (s *fsService) List(ctx context.Context, req *artifact.ListRequest) (*artif
 		}
 	}
 
-	// List session artifacts
-	readDir(s.buildSessionDir(appName, userID, sessionID))
-
-	// List user artifacts
-	readDir(s.buildUserDir(appName, userID))
+	// List session and user artifacts
+	for _, prefix := range s.keys.ListPrefixes(appName, userID, sessionID) {
+		readDir(s.keyPath(prefix))
+	}
 
 	filenames := slices.Collect(maps.Keys(filenamesSet))
 	sort.Strings(filenames)
-	return &artifact.ListResponse{FileNames: filenames}, nil
+	return filenames
 }
 
 // Versions implements [artifact.Service]
-func (s *fsService) Versions(ctx context.Context, req *artifact.VersionsRequest) (*artifact.VersionsResponse, error) {
+func (s *fsService) Versions(ctx context.Context, req *artifact.VersionsRequest) (_ *artifact.VersionsResponse, err error) {
+	defer func() { s.audit(ctx, "versions", req.AppName, req.UserID, req.SessionID, req.FileName, 0, err) }()
+	return s.versions(ctx, req)
+}
+
+// versions lists the versions of an artifact without auditing, for use by
+// the other operations.
+func (s *fsService) versions(ctx context.Context, req *artifact.VersionsRequest) (*artifact.VersionsResponse, error) {
 	if err := req.Validate(); err != nil {
 		return nil, fmt.Errorf("request validation failed: %w", err)
 	}
 	appName, userID, sessionID, fileName := req.AppName, req.UserID, req.SessionID, req.FileName
 
 	dir := s.buildDir(appName, userID, sessionID, fileName)
-	entries, err := os.ReadDir(dir)
+	if err := s.checkPath(dir); err != nil {
+		return nil, err
+	}
+	entries, err := s.readDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, fmt.Errorf("artifact not found: %w", fs.ErrNotExist)
@@ -248,9 +463,10 @@ func (s *fsService) Versions(ctx context.Context, req *artifact.VersionsRequest)
 		return nil, fmt.Errorf("failed to list versions: %w", err)
 	}
 
-	var versions []int64
+	prefix := s.keys.ArtifactPrefix(appName, userID, sessionID, fileName)
+	versions := make([]int64, 0, len(entries)/2)
 	for _, entry := range entries {
-		if entry.IsDir() {
+		if !entry.Type().IsRegular() {
 			continue
 		}
 		name := entry.Name()
@@ -258,11 +474,11 @@ func (s *fsService) Versions(ctx context.Context, req *artifact.VersionsRequest)
 			continue
 		}
 
-		v, err := strconv.ParseInt(name, 10, 64)
+		ref, err := s.keys.ParseKey(prefix + name)
 		if err != nil {
 			continue
 		}
-		versions = append(versions, v)
+		versions = append(versions, ref.Version)
 	}
 
 	if len(versions) == 0 {
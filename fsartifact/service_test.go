@@ -15,11 +15,21 @@
 package fsartifact_test
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/chinglinwen/adk-artifact/fsartifact"
 	"github.com/chinglinwen/adk-artifact/tests"
 	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
 )
 
 func TestFSArtifactService(t *testing.T) {
@@ -28,4 +38,162 @@ func TestFSArtifactService(t *testing.T) {
 		return fsartifact.NewService(dir)
 	}
 	tests.TestArtifactService(t, "FSArtifact", factory)
+	tests.TestArtifactServiceProperties(t, "FSArtifact", factory)
+}
+
+func TestDeleteLastVersionRemovesFile(t *testing.T) {
+	ctx := t.Context()
+	srv, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("x"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list := func() []string {
+		t.Helper()
+		resp, err := srv.List(ctx, &artifact.ListRequest{AppName: "app", UserID: "user", SessionID: "session"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.FileNames
+	}
+	for _, tc := range []struct {
+		version int64
+		want    []string
+	}{
+		{1, []string{"file"}},
+		{2, nil}, // the last version
+	} {
+		if err := srv.Delete(ctx, &artifact.DeleteRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Version: tc.version,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if got := list(); !slices.Equal(got, tc.want) {
+			t.Errorf("List() after deleting version %d = %v, want %v", tc.version, got, tc.want)
+		}
+	}
+}
+
+// TestConcurrentSaves saves to one artifact through several services sharing
+// a root directory, as several processes would.
+func TestConcurrentSaves(t *testing.T) {
+	root := t.TempDir()
+	const services, saves = 8, 25
+	var wg sync.WaitGroup
+	errs := make(chan error, services*saves)
+	for i := range services {
+		srv, err := fsartifact.NewService(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Go(func() {
+			for j := range saves {
+				if _, err := srv.Save(t.Context(), &artifact.SaveRequest{
+					AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+					Part: genai.NewPartFromText(fmt.Sprint(i, j)),
+				}); err != nil {
+					errs <- err
+				}
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	srv, err := fsartifact.NewService(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := srv.Versions(t.Context(), &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+	if err != nil || len(resp.Versions) != services*saves || slices.Max(resp.Versions) != services*saves {
+		t.Errorf("Versions() = (%v, %v), want %d distinct versions", resp, err, services*saves)
+	}
+}
+
+// cancellingClock cancels a save in the middle, when it stamps its version.
+type cancellingClock struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+func (c *cancellingClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		c.cancel()
+	}
+	return time.Now()
+}
+
+func TestCancelledSave(t *testing.T) {
+	root := t.TempDir()
+	clock := &cancellingClock{}
+	srv, err := fsartifact.New(root, fsartifact.WithClock(clock), fsartifact.WithJournal())
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+		Part: genai.NewPartFromText("v1"),
+	}
+	if _, err := srv.Save(t.Context(), req); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	clock.mu.Lock()
+	clock.cancel = cancel
+	clock.mu.Unlock()
+	req.Part = genai.NewPartFromText("v2")
+	if _, err := srv.Save(ctx, req); err == nil {
+		t.Error("Save() cancelled in the middle = nil, want an error")
+	}
+	resp, err := srv.Versions(t.Context(), &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+	if err != nil || !slices.Equal(resp.Versions, []int64{1}) {
+		t.Errorf("Versions() = (%v, %v), want [1]", resp, err)
+	}
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if strings.HasSuffix(path, ".tmp") {
+			t.Errorf("the cancelled save left %s", path)
+		}
+		return err
+	})
+}
+
+func BenchmarkSmallObject(b *testing.B) {
+	srv, err := fsartifact.NewService(b.TempDir())
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := b.Context()
+	part := genai.NewPartFromBytes(bytes.Repeat([]byte("x"), 1<<10), "application/json")
+	save := &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "scratchpad", Part: part}
+	load := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "scratchpad", Version: 1}
+	if _, err := srv.Save(ctx, save); err != nil {
+		b.Fatal(err)
+	}
+	b.Run("Save", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			save.Version = 1 // overwrite, so that the directory does not grow
+			if _, err := srv.Save(ctx, save); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Load", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := srv.Load(ctx, load); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
 }
@@ -0,0 +1,261 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// SpaceLimit bounds the disk space used by a service, see [WithSpaceLimit].
+type SpaceLimit struct {
+	// MaxSize is the maximum total size in bytes of the files below the
+	// root directory, including metadata and pending uploads. Zero means
+	// no limit.
+	MaxSize int64
+	// MinFree is the free space in bytes to leave on the file system of
+	// the root directory. Zero means no limit.
+	MinFree int64
+	// Evict removes versions, of any artifact, until a write fits the
+	// limit, the earliest saved first: versions are ordered by the time
+	// they were saved, not by the time they were last read. Pinned
+	// versions and the latest version of every artifact are never
+	// evicted. Without it, writes exceeding the limit fail.
+	Evict bool
+}
+
+// SpaceLimiter is implemented by file system services, whose space limit
+// can be changed while they serve requests:
+//
+//	if l, ok := artifactx.As[fsartifact.SpaceLimiter](srv); ok {
+//		err = l.SetSpaceLimit(fsartifact.SpaceLimit{MaxSize: 2 << 30})
+//	}
+type SpaceLimiter interface {
+	// SetSpaceLimit replaces the limit of [WithSpaceLimit], checked from
+	// the next write on.
+	SetSpaceLimit(l SpaceLimit) error
+}
+
+var _ SpaceLimiter = (*fsService)(nil)
+
+// SetSpaceLimit implements [SpaceLimiter].
+func (s *fsService) SetSpaceLimit(l SpaceLimit) error {
+	if l.MinFree > 0 {
+		if _, err := freeSpace(s.rootDir); err != nil {
+			return fmt.Errorf("failed to get free disk space: %w", err)
+		}
+	}
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+	s.space = l
+	return nil
+}
+
+func (s *fsService) currentSpace() SpaceLimit {
+	s.settingsMu.RLock()
+	defer s.settingsMu.RUnlock()
+	return s.space
+}
+
+// version is a version file found by usage.
+type version struct {
+	path    string
+	size    int64 // including the sidecar
+	modTime time.Time
+}
+
+// usage returns the total size of the files below the root directory and
+// the version files among them.
+func (s *fsService) usage(ctx context.Context) (int64, []version, error) {
+	var used int64
+	var versions []version
+	err := filepath.WalkDir(s.rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil // removed since listing
+			}
+			return err
+		}
+		used += info.Size()
+		rel, err := filepath.Rel(s.rootDir, path)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(rel, artifactx.UploadsPrefix+string(filepath.Separator)) {
+			return nil
+		}
+		if _, err := s.keys.ParseKey(filepath.ToSlash(rel)); err == nil {
+			versions = append(versions, version{path: path, size: info.Size(), modTime: info.ModTime()})
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	for i, v := range versions {
+		if info, err := s.lstat(v.path + ".meta"); err == nil {
+			versions[i].size += info.Size()
+		}
+	}
+	return used, versions, nil
+}
+
+// makeRoom checks that n more bytes fit the space limit, evicting versions
+// if the limit allows it, and returns [artifactx.ErrStorageFull] otherwise.
+// locked is the directory of the artifact whose lock the caller holds, if
+// any.
+//
+// The usage is counted by walking the root directory at the first write,
+// then kept up to date with the writes and evictions of the service. Other
+// removals only lower the usage, so the count is only walked again when a
+// write seems not to fit, which also accounts for other writers of the root
+// directory. Concurrent writes are checked one at a time but may all be
+// admitted before any of them is written, so the limit is a soft one.
+func (s *fsService) makeRoom(ctx context.Context, n int64, locked string) error {
+	space := s.currentSpace()
+	if space == (SpaceLimit{}) {
+		return nil
+	}
+	s.spaceMu.Lock()
+	defer s.spaceMu.Unlock()
+
+	var excess int64
+	var versions []version
+	if space.MaxSize > 0 {
+		if !s.usedKnown || s.used+n > space.MaxSize {
+			used, vs, err := s.usage(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to compute disk usage: %w", err)
+			}
+			s.used, s.usedKnown, versions = used, true, vs
+		}
+		excess = s.used + n - space.MaxSize
+	}
+	if space.MinFree > 0 {
+		free, err := freeSpace(s.rootDir)
+		if err != nil {
+			return fmt.Errorf("failed to get free disk space: %w", err)
+		}
+		excess = max(excess, space.MinFree-(free-n))
+	}
+	if excess > 0 && !space.Evict {
+		return fmt.Errorf("%w: %d bytes over the limit", artifactx.ErrStorageFull, excess)
+	}
+	if excess > 0 {
+		if versions == nil {
+			var err error
+			if _, versions, err = s.usage(ctx); err != nil {
+				return fmt.Errorf("failed to compute disk usage: %w", err)
+			}
+		}
+		slices.SortFunc(versions, func(a, b version) int {
+			return cmp.Or(a.modTime.Compare(b.modTime), strings.Compare(a.path, b.path))
+		})
+		_, dryRun := artifactx.DryRunFrom(ctx)
+		for _, v := range versions {
+			if excess <= 0 {
+				break
+			}
+			evicted, err := s.evict(ctx, v, locked)
+			if err != nil {
+				s.logger.WarnContext(ctx, "failed to evict version", "path", v.path, "error", err)
+				continue
+			}
+			if evicted {
+				excess -= v.size
+				if !dryRun {
+					s.used -= v.size
+				}
+			}
+		}
+		if excess > 0 {
+			return fmt.Errorf("%w: %d bytes over the limit after eviction", artifactx.ErrStorageFull, excess)
+		}
+	}
+	s.used += n
+	return nil
+}
+
+// addUsage adds n bytes written without makeRoom, such as sidecars, to the
+// usage counted by makeRoom.
+func (s *fsService) addUsage(n int64) {
+	s.spaceMu.Lock()
+	defer s.spaceMu.Unlock()
+	if s.usedKnown {
+		s.used += n
+	}
+}
+
+// evict removes the version v, unless it is pinned or the latest version of
+// its artifact, under the lock of the artifact, and reports whether it did.
+// Artifacts whose lock is held by another operation are skipped rather than
+// waited for: that operation may be making room in turn.
+func (s *fsService) evict(ctx context.Context, v version, locked string) (bool, error) {
+	dir := filepath.Dir(v.path)
+	if dir != locked {
+		unlock, ok, err := s.tryLockArtifact(dir)
+		if err != nil || !ok {
+			return false, err
+		}
+		defer unlock()
+	}
+	ref, ok := s.ref(v.path)
+	if !ok {
+		return false, nil
+	}
+	resp, err := s.versions(ctx, &artifact.VersionsRequest{
+		AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName,
+	})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil // removed since the walk
+		}
+		return false, err
+	}
+	if !slices.Contains(resp.Versions, ref.Version) || ref.Version == slices.Max(resp.Versions) || s.pinned(v.path) {
+		return false, nil
+	}
+	if s.dryRun(ctx, v.path, artifactx.ByEviction) {
+		return true, nil
+	}
+	if err := s.remove(v.path); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	s.remove(v.path + ".meta")
+	s.logger.InfoContext(ctx, "evicted version to free disk space", "path", v.path, "size", v.size)
+	return true, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"slices"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.JSONUpdater = (*fsService)(nil)
+
+// UpdateJSON implements [artifactx.JSONUpdater]. The artifact stays locked
+// from reading the latest version to writing the new one, as in Save.
+func (s *fsService) UpdateJSON(ctx context.Context, req *artifactx.UpdateJSONRequest) (resp *artifact.SaveResponse, err error) {
+	defer func() {
+		var version int64
+		if resp != nil {
+			version = resp.Version
+		}
+		s.audit(ctx, "update", req.AppName, req.UserID, req.SessionID, req.FileName, version, err)
+	}()
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return nil, err
+	}
+	if err := artifactx.CheckSessionID(s.keys, req.SessionID, req.FileName); err != nil {
+		return nil, err
+	}
+	unlock, err := s.lockArtifacts(s.buildDir(req.AppName, req.UserID, req.SessionID, req.FileName))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	response, err := s.versions(ctx, &artifact.VersionsRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Versions) == 0 {
+		return nil, fmt.Errorf("artifact '%s' not found: %w", req.FileName, fs.ErrNotExist)
+	}
+	latest := slices.Max(response.Versions)
+	path := s.buildPath(req.AppName, req.UserID, req.SessionID, req.FileName, latest)
+	if err := s.checkMutable(path, req.FileName); err != nil {
+		return nil, err
+	}
+	m, err := s.readMeta(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := artifactx.CheckJSON(req.FileName, m.ContentType); err != nil {
+		return nil, err
+	}
+	data, err := s.readFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	data, err = req.Apply(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.writeVersion(ctx, s.buildPath(req.AppName, req.UserID, req.SessionID, req.FileName, latest+1), data, m.ContentType); err != nil {
+		return nil, err
+	}
+
+	s.applyRetention(ctx, req.AppName, req.UserID, req.SessionID, req.FileName)
+	return &artifact.SaveResponse{Version: latest + 1}, nil
+}
@@ -0,0 +1,238 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.Uploader = (*fsService)(nil)
+
+// An upload is kept in rootDir/.uploads/<id>/ as a "state" JSON file and a
+// "data" file the chunks are appended to. The size of the data file is the
+// source of truth for the upload size, so a crash between writing a chunk and
+// anything else never loses track of received bytes.
+
+func (s *fsService) uploadDir(id string) string {
+	return filepath.Join(s.rootDir, artifactx.UploadsPrefix, id)
+}
+
+// readUpload returns the state of the upload with its current size.
+func (s *fsService) readUpload(id string) (*artifactx.Upload, error) {
+	if err := artifactx.ValidUploadID(id); err != nil {
+		return nil, err
+	}
+	dir := s.uploadDir(id)
+	if err := s.checkPath(dir); err != nil {
+		return nil, err
+	}
+	data, err := s.readFileNoFollow(filepath.Join(dir, "state"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("upload %q not found: %w", id, fs.ErrNotExist)
+		}
+		return nil, fmt.Errorf("failed to read upload state: %w", err)
+	}
+	u := &artifactx.Upload{}
+	if err := json.Unmarshal(data, u); err != nil {
+		return nil, fmt.Errorf("failed to decode upload state: %w", err)
+	}
+	info, err := s.lstatNoFollow(filepath.Join(dir, "data"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat upload data: %w", err)
+	}
+	u.Size = info.Size()
+	return u, nil
+}
+
+// BeginUpload implements [artifactx.Uploader].
+func (s *fsService) BeginUpload(ctx context.Context, req *artifactx.UploadRequest) (*artifactx.Upload, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	if err := artifactx.CheckAppName(req.AppName); err != nil {
+		return nil, err
+	}
+	if err := artifactx.CheckSessionID(s.keys, req.SessionID, req.FileName); err != nil {
+		return nil, err
+	}
+	u := &artifactx.Upload{
+		ID:            artifactx.NewUploadID(),
+		UploadRequest: *req,
+		Created:       s.clock.Now(),
+		Metadata:      artifactx.MetadataFrom(ctx),
+	}
+	dir := s.uploadDir(u.ID)
+	if err := s.mkdirAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	data, err := json.Marshal(u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode upload state: %w", err)
+	}
+	if err := s.writeFile(filepath.Join(dir, "data"), nil); err != nil {
+		s.removeAll(dir)
+		return nil, fmt.Errorf("failed to create upload data: %w", err)
+	}
+	// The state file is written last: an upload without one is incomplete
+	// and reported as not found.
+	if err := s.writeFile(filepath.Join(dir, "state"), data); err != nil {
+		s.removeAll(dir)
+		return nil, fmt.Errorf("failed to write upload state: %w", err)
+	}
+	return u, nil
+}
+
+// AppendChunk implements [artifactx.Uploader].
+func (s *fsService) AppendChunk(ctx context.Context, uploadID string, offset int64, data []byte) (*artifactx.Upload, error) {
+	u, err := s.readUpload(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if offset != u.Size {
+		return nil, fmt.Errorf("%w: upload %q has %d bytes, chunk starts at %d", artifactx.ErrOffsetMismatch, uploadID, u.Size, offset)
+	}
+	if err := s.makeRoom(ctx, int64(len(data)), ""); err != nil {
+		return nil, err
+	}
+	f, err := s.openNoFollow(filepath.Join(s.uploadDir(uploadID), "data"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload data: %w", err)
+	}
+	n, err := f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	u.Size += int64(n)
+	if err != nil {
+		return u, fmt.Errorf("failed to append chunk: %w", err)
+	}
+	return u, nil
+}
+
+// UploadStatus implements [artifactx.Uploader].
+func (s *fsService) UploadStatus(ctx context.Context, uploadID string) (*artifactx.Upload, error) {
+	return s.readUpload(uploadID)
+}
+
+// CommitUpload implements [artifactx.Uploader]. The data file is moved into
+// place, so committing does not copy the upload.
+func (s *fsService) CommitUpload(ctx context.Context, uploadID string) (*artifact.SaveResponse, error) {
+	u, err := s.readUpload(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := s.lockArtifacts(s.buildDir(u.AppName, u.UserID, u.SessionID, u.FileName))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	nextVersion := int64(1)
+	response, err := s.versions(ctx, &artifact.VersionsRequest{
+		AppName: u.AppName, UserID: u.UserID, SessionID: u.SessionID, FileName: u.FileName,
+	})
+	if err == nil && len(response.Versions) > 0 {
+		latest := slices.Max(response.Versions)
+		if err := s.checkMutable(s.buildPath(u.AppName, u.UserID, u.SessionID, u.FileName, latest), u.FileName); err != nil {
+			return nil, err
+		}
+		nextVersion = latest + 1
+	}
+
+	path := s.buildPath(u.AppName, u.UserID, u.SessionID, u.FileName, nextVersion)
+	if err := s.mkdirAll(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	done, err := s.intent(ctx, "save", path)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	if err := s.rename(filepath.Join(s.uploadDir(uploadID), "data"), path); err != nil {
+		return nil, fmt.Errorf("failed to move upload data: %w", err)
+	}
+	if err := s.stamp(ctx, path); err != nil {
+		s.remove(path)
+		return nil, err
+	}
+	sum, err := s.hashFile(path, s.checksum)
+	if err != nil {
+		s.remove(path)
+		return nil, fmt.Errorf("failed to hash upload data: %w", err)
+	}
+	contentType, err := s.uploadContentType(path, u.MIMEType)
+	if err != nil {
+		s.remove(path)
+		return nil, err
+	}
+	m := &meta{ContentType: contentType, Metadata: u.Metadata, Immutable: artifactx.ImmutableFrom(ctx)}
+	m.SetChecksum(s.checksum, sum)
+	if err := s.writeMeta(path, m); err != nil {
+		// Best effort cleanup
+		s.remove(path)
+		return nil, err
+	}
+	if err := s.removeAll(s.uploadDir(uploadID)); err != nil {
+		return nil, fmt.Errorf("failed to remove upload: %w", err)
+	}
+	s.applyRetention(ctx, u.AppName, u.UserID, u.SessionID, u.FileName)
+	return &artifact.SaveResponse{Version: nextVersion}, nil
+}
+
+// AbortUpload implements [artifactx.Uploader].
+func (s *fsService) AbortUpload(ctx context.Context, uploadID string) error {
+	if err := artifactx.ValidUploadID(uploadID); err != nil {
+		return err
+	}
+	if err := s.removeAll(s.uploadDir(uploadID)); err != nil {
+		return fmt.Errorf("failed to remove upload: %w", err)
+	}
+	return nil
+}
+
+// uploadContentType returns the content type of the uploaded version file
+// at path, contentType or the default, with the charset of text recorded.
+func (s *fsService) uploadContentType(path, contentType string) (string, error) {
+	contentType = cmp.Or(contentType, s.defaultContentType)
+	if !artifactx.IsText(contentType) {
+		return contentType, nil
+	}
+	f, err := s.openNoFollow(path, os.O_RDONLY, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to read upload data: %w", err)
+	}
+	defer f.Close()
+	head := make([]byte, 3)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("failed to read upload data: %w", err)
+	}
+	return artifactx.WithCharset(contentType, head[:n]), nil
+}
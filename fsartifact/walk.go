@@ -0,0 +1,57 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.Walker = (*fsService)(nil)
+
+// Walk implements [artifactx.Walker]. Files that do not follow the artifact
+// layout are skipped; use [fsService.Check] to find them.
+func (s *fsService) Walk(ctx context.Context, fn func(artifactx.Ref) error) error {
+	return filepath.WalkDir(s.rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path == filepath.Join(s.rootDir, artifactx.UploadsPrefix) || path == filepath.Join(s.rootDir, locksDir) ||
+				path == filepath.Join(s.rootDir, journalsDir) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil // symbolic links are never followed
+		}
+		rel, err := filepath.Rel(s.rootDir, path)
+		if err != nil {
+			return err
+		}
+		ref, err := s.keys.ParseKey(filepath.ToSlash(rel))
+		if err != nil {
+			return nil
+		}
+		return fn(ref)
+	})
+}
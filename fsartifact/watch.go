@@ -0,0 +1,140 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.ChangeWatcher = (*fsService)(nil)
+
+// WatchChanges implements [artifactx.ChangeWatcher] by watching the
+// directories of the tree for changes, with inotify, kqueue or
+// ReadDirectoryChangesW depending on the OS. A version is reported saved
+// when its sidecar is written, which a save does last, and artifacts moved
+// or deleted as a whole are reported with a zero version.
+//
+// Watches are set on every directory of the tree, which may exceed the
+// limits of the OS (e.g. fs.inotify.max_user_watches) for large trees.
+func (s *fsService) WatchChanges(ctx context.Context, fn func(artifactx.Change)) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer w.Close()
+	if err := s.watchTree(w, s.rootDir, nil); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-w.Errors:
+			return fmt.Errorf("failed to watch %q: %w", s.rootDir, err)
+		case ev := <-w.Events:
+			if err := s.handleEvent(w, ev, fn); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// watchTree adds watches for dir and its subdirectories, calling fn with
+// the versions found in them if fn is not nil: they may have been written
+// before the watch was set.
+func (s *fsService) watchTree(w *fsnotify.Watcher, dir string, fn func(artifactx.Change)) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path != s.rootDir {
+				return nil // removed since listing
+			}
+			return err
+		}
+		if d.IsDir() {
+			if s.unwatched(path) {
+				return fs.SkipDir
+			}
+			if err := w.Add(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to watch %q: %w", path, err)
+			}
+			return nil
+		}
+		if fn != nil && d.Type().IsRegular() {
+			if base, ok := strings.CutSuffix(path, ".meta"); ok {
+				if ref, ok := s.ref(base); ok {
+					fn(artifactx.Change{Ref: ref, Op: artifactx.VersionSaved})
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (s *fsService) handleEvent(w *fsnotify.Watcher, ev fsnotify.Event, fn func(artifactx.Change)) error {
+	if s.unwatched(ev.Name) {
+		return nil
+	}
+	switch {
+	case ev.Has(fsnotify.Create):
+		if info, err := os.Lstat(ev.Name); err == nil && info.IsDir() {
+			return s.watchTree(w, ev.Name, fn)
+		}
+	case ev.Has(fsnotify.Write):
+		if base, ok := strings.CutSuffix(ev.Name, ".meta"); ok {
+			if ref, ok := s.ref(base); ok {
+				fn(artifactx.Change{Ref: ref, Op: artifactx.VersionSaved})
+			}
+		}
+	case ev.Has(fsnotify.Remove), ev.Has(fsnotify.Rename):
+		if ref, ok := s.ref(ev.Name); ok {
+			fn(artifactx.Change{Ref: ref, Op: artifactx.VersionDeleted})
+		} else if ref, ok := s.ref(filepath.Join(ev.Name, "0")); ok {
+			// the directory of an artifact
+			ref.Version = 0
+			fn(artifactx.Change{Ref: ref, Op: artifactx.VersionDeleted})
+		}
+	}
+	return nil
+}
+
+// ref parses the path of a version file.
+func (s *fsService) ref(path string) (artifactx.Ref, bool) {
+	rel, err := filepath.Rel(s.rootDir, path)
+	if err != nil {
+		return artifactx.Ref{}, false
+	}
+	ref, err := s.keys.ParseKey(filepath.ToSlash(rel))
+	return ref, err == nil
+}
+
+// unwatched reports whether path is below the directories of the service
+// that hold no artifacts.
+func (s *fsService) unwatched(path string) bool {
+	rel, err := filepath.Rel(s.rootDir, path)
+	if err != nil {
+		return true
+	}
+	top, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	return top == artifactx.UploadsPrefix || top == locksDir || top == journalsDir || top == journalFile
+}
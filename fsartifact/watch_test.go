@@ -0,0 +1,101 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fsartifact_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+)
+
+func TestWatchChanges(t *testing.T) {
+	root := t.TempDir()
+	watched, err := fsartifact.NewService(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Changes are made by another service, as another process would.
+	other, err := fsartifact.NewService(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(t.Context())
+	changes := make(chan artifactx.Change, 100)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- watched.(artifactx.ChangeWatcher).WatchChanges(ctx, func(c artifactx.Change) { changes <- c })
+	}()
+
+	save := func(fileName string) {
+		t.Helper()
+		if _, err := other.Save(t.Context(), &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: fileName, Part: genai.NewPartFromText("data"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// want waits for a change, skipping others.
+	want := func(want artifactx.Change) bool {
+		t.Helper()
+		timeout := time.After(time.Second)
+		for {
+			select {
+			case c := <-changes:
+				if c == want {
+					return true
+				}
+			case <-timeout:
+				return false
+			}
+		}
+	}
+
+	// The watcher starts asynchronously: save until it sees a save.
+	probe := artifactx.Change{Ref: artifactx.Ref{AppName: "app", UserID: "user", SessionID: "session", FileName: "probe"}, Op: artifactx.VersionSaved}
+	for i := int64(1); ; i++ {
+		save("probe")
+		probe.Version = i
+		if want(probe) {
+			break
+		}
+		if i == 50 {
+			t.Fatal("no change reported")
+		}
+	}
+
+	save("report")
+	ref := artifactx.Ref{AppName: "app", UserID: "user", SessionID: "session", FileName: "report", Version: 1}
+	if !want(artifactx.Change{Ref: ref, Op: artifactx.VersionSaved}) {
+		t.Errorf("save of %v not reported", ref)
+	}
+	if err := other.Delete(t.Context(), &artifact.DeleteRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "report"}); err != nil {
+		t.Fatal(err)
+	}
+	ref.Version = 0
+	if !want(artifactx.Change{Ref: ref, Op: artifactx.VersionDeleted}) {
+		t.Errorf("delete of %v not reported", ref)
+	}
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Errorf("WatchChanges() = %v, want nil after cancellation", err)
+	}
+}
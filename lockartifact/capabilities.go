@@ -0,0 +1,113 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lockartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var (
+	_ artifactx.JSONUpdater = (*Service)(nil)
+	_ artifactx.Uploader    = (*Service)(nil)
+)
+
+// UpdateJSON implements [artifactx.JSONUpdater], checking the lease like
+// Save.
+func (s *Service) UpdateJSON(ctx context.Context, req *artifactx.UpdateJSONRequest) (*artifact.SaveResponse, error) {
+	end, err := s.begin(ctx, lockKey(req.AppName, req.UserID, req.SessionID, req.FileName))
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	return artifactx.UpdateJSON(ctx, s.Service, req)
+}
+
+// uploader returns the uploader of the decorated service.
+func (s *Service) uploader() (artifactx.Uploader, error) {
+	u, ok := artifactx.As[artifactx.Uploader](s.Service)
+	if !ok {
+		return nil, fmt.Errorf("upload: %w", errors.ErrUnsupported)
+	}
+	return u, nil
+}
+
+// BeginUpload implements [artifactx.Uploader]. Uploads to an artifact
+// leased by another caller are refused from the start, and checked again
+// when committed.
+func (s *Service) BeginUpload(ctx context.Context, req *artifactx.UploadRequest) (*artifactx.Upload, error) {
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	end, err := s.begin(ctx, lockKey(req.AppName, req.UserID, req.SessionID, req.FileName))
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	return u.BeginUpload(ctx, req)
+}
+
+// AppendChunk implements [artifactx.Uploader]. Chunks are staged outside of
+// the artifact, so they are not checked.
+func (s *Service) AppendChunk(ctx context.Context, uploadID string, offset int64, data []byte) (*artifactx.Upload, error) {
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	return u.AppendChunk(ctx, uploadID, offset, data)
+}
+
+// UploadStatus implements [artifactx.Uploader].
+func (s *Service) UploadStatus(ctx context.Context, uploadID string) (*artifactx.Upload, error) {
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	return u.UploadStatus(ctx, uploadID)
+}
+
+// CommitUpload implements [artifactx.Uploader], checking the lease on the
+// artifact of the upload like Save.
+func (s *Service) CommitUpload(ctx context.Context, uploadID string) (*artifact.SaveResponse, error) {
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	up, err := u.UploadStatus(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	end, err := s.begin(ctx, lockKey(up.AppName, up.UserID, up.SessionID, up.FileName))
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	return u.CommitUpload(ctx, uploadID)
+}
+
+// AbortUpload implements [artifactx.Uploader].
+func (s *Service) AbortUpload(ctx context.Context, uploadID string) error {
+	u, err := s.uploader()
+	if err != nil {
+		return err
+	}
+	return u.AbortUpload(ctx, uploadID)
+}
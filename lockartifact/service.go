@@ -0,0 +1,258 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lockartifact provides an [artifact.Service] decorator granting
+// exclusive write leases, so that an agent can perform a multi-step edit of
+// an artifact without other writers interleaving.
+//
+//	srv := lockartifact.NewService(backend, lockartifact.Options{TTL: 5 * time.Minute})
+//	lease, err := srv.Lock(ctx, &artifactx.LockRequest{
+//		AppName: "app", UserID: "user", SessionID: "session", FileName: "report.md", Owner: "editor",
+//	})
+//	ctx = artifactx.WithLease(ctx, lease.Token) // Saves with ctx are made as the holder
+//	...
+//	err = srv.Unlock(ctx, lease)
+//
+// Leases are kept in memory, so they exclude writers sharing the decorated
+// service; processes sharing a store must share the service too. A lease is
+// only granted once the writes of others already in progress on its artifact
+// are done, so none of them lands after it. JSON updates and uploads are
+// checked like saves; the other capabilities of the decorated service that
+// write, such as [artifactx.Relabeler], are not forwarded by [artifactx.As],
+// so that they do not bypass the leases.
+package lockartifact
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// DefaultTTL is the lease duration if neither the request nor the options
+// set one.
+const DefaultTTL = time.Minute
+
+// Options configures [NewService].
+type Options struct {
+	// TTL is the default lease duration; zero means [DefaultTTL].
+	TTL time.Duration
+	// Clock defaults to [artifactx.SystemClock].
+	Clock artifactx.Clock
+}
+
+// Service is an [artifact.Service] that enforces write leases.
+type Service struct {
+	artifact.Service
+	ttl   time.Duration
+	clock artifactx.Clock
+
+	mu     sync.Mutex
+	leases map[artifactx.Ref]*artifactx.Lease
+	// writes counts the writes in progress per artifact, and idle has a
+	// channel per artifact with writes, closed when they are done.
+	writes map[artifactx.Ref]int
+	idle   map[artifactx.Ref]chan struct{}
+}
+
+var (
+	_ artifactx.Forwarder = (*Service)(nil)
+	_ artifactx.Locker    = (*Service)(nil)
+	_ artifactx.Mover     = (*Service)(nil)
+)
+
+// NewService returns a service that forwards to next, rejecting writes to
+// artifacts leased by others.
+func NewService(next artifact.Service, opts Options) *Service {
+	s := &Service{
+		Service: next,
+		ttl:     opts.TTL,
+		clock:   opts.Clock,
+		leases:  map[artifactx.Ref]*artifactx.Lease{},
+		writes:  map[artifactx.Ref]int{},
+		idle:    map[artifactx.Ref]chan struct{}{},
+	}
+	if s.ttl <= 0 {
+		s.ttl = DefaultTTL
+	}
+	if s.clock == nil {
+		s.clock = artifactx.SystemClock
+	}
+	return s
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// Forwards implements [artifactx.Forwarder]. Only the capabilities of the
+// decorated service that read are forwarded, since leases guard writes.
+func (s *Service) Forwards(capability any) bool {
+	return artifactx.IsReadCapability(capability)
+}
+
+// lockKey identifies the artifact a lease is on. User scoped artifacts are
+// shared by all sessions of the user, so the session is left out for them.
+func lockKey(appName, userID, sessionID, fileName string) artifactx.Ref {
+	if artifactx.IsUserScoped(fileName) {
+		sessionID = ""
+	}
+	return artifactx.Ref{AppName: appName, UserID: userID, SessionID: sessionID, FileName: fileName}
+}
+
+// holder returns the unexpired lease on key, dropping an expired one. The
+// caller must hold s.mu.
+func (s *Service) holder(key artifactx.Ref) *artifactx.Lease {
+	l := s.leases[key]
+	if l != nil && !s.clock.Now().Before(l.Expires) {
+		delete(s.leases, key)
+		return nil
+	}
+	return l
+}
+
+// lockedError describes the lease held by another caller.
+func lockedError(l *artifactx.Lease) error {
+	return fmt.Errorf("artifact %q: %w by %q until %s", l.FileName, artifactx.ErrLocked, l.Owner, l.Expires.Format(time.RFC3339))
+}
+
+// Lock implements [artifactx.Locker].
+func (s *Service) Lock(ctx context.Context, req *artifactx.LockRequest) (*artifactx.Lease, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	ttl := req.TTL
+	if ttl == 0 {
+		ttl = s.ttl
+	}
+	key := lockKey(req.AppName, req.UserID, req.SessionID, req.FileName)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token := artifactx.NewUploadID()
+	for {
+		if l := s.holder(key); l != nil {
+			if l.Token != artifactx.LeaseFrom(ctx) {
+				return nil, lockedError(l)
+			}
+			token = l.Token
+			break
+		}
+		// Without a lease, the writes in progress are by others.
+		idle, ok := s.idle[key]
+		if !ok {
+			break
+		}
+		s.mu.Unlock()
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			s.mu.Lock()
+			return nil, ctx.Err()
+		}
+		s.mu.Lock()
+	}
+	l := &artifactx.Lease{LockRequest: *req, Token: token, Expires: s.clock.Now().Add(ttl)}
+	l.TTL = ttl
+	s.leases[key] = l
+	lease := *l
+	return &lease, nil
+}
+
+// Unlock implements [artifactx.Locker].
+func (s *Service) Unlock(ctx context.Context, lease *artifactx.Lease) error {
+	key := lockKey(lease.AppName, lease.UserID, lease.SessionID, lease.FileName)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.holder(key)
+	if l == nil {
+		return nil
+	}
+	if l.Token != lease.Token {
+		return lockedError(l)
+	}
+	delete(s.leases, key)
+	return nil
+}
+
+// begin returns [artifactx.ErrLocked] if another caller than the one of ctx
+// holds a lease on one of the artifacts, and otherwise records a write to
+// them in progress until end is called, so that no lease is granted on them
+// meanwhile.
+func (s *Service) begin(ctx context.Context, keys ...artifactx.Ref) (end func(), err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		if l := s.holder(key); l != nil && l.Token != artifactx.LeaseFrom(ctx) {
+			return nil, lockedError(l)
+		}
+	}
+	for _, key := range keys {
+		if s.writes[key] == 0 {
+			s.idle[key] = make(chan struct{})
+		}
+		s.writes[key]++
+	}
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, key := range keys {
+			if s.writes[key]--; s.writes[key] == 0 {
+				close(s.idle[key])
+				delete(s.idle, key)
+				delete(s.writes, key)
+			}
+		}
+	}, nil
+}
+
+// Save implements [artifact.Service].
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	end, err := s.begin(ctx, lockKey(req.AppName, req.UserID, req.SessionID, req.FileName))
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	return s.Service.Save(ctx, req)
+}
+
+// Delete implements [artifact.Service].
+func (s *Service) Delete(ctx context.Context, req *artifact.DeleteRequest) error {
+	end, err := s.begin(ctx, lockKey(req.AppName, req.UserID, req.SessionID, req.FileName))
+	if err != nil {
+		return err
+	}
+	defer end()
+	return s.Service.Delete(ctx, req)
+}
+
+// Move implements [artifactx.Mover], requiring both the source and the
+// destination to be writable by the caller.
+func (s *Service) Move(ctx context.Context, req *artifactx.MoveRequest) error {
+	end, err := s.begin(ctx,
+		lockKey(req.AppName, req.UserID, req.SessionID, req.FileName),
+		lockKey(req.AppName, req.UserID, req.SessionID, req.NewFileName),
+	)
+	if err != nil {
+		return err
+	}
+	defer end()
+	return artifactx.Move(ctx, s.Service, req)
+}
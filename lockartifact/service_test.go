@@ -0,0 +1,222 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lockartifact_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"github.com/chinglinwen/adk-artifact/lockartifact"
+)
+
+func TestLock(t *testing.T) {
+	ctx := t.Context()
+	clock := artifactx.NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	srv := lockartifact.NewService(artifact.InMemoryService(), lockartifact.Options{TTL: time.Minute, Clock: clock})
+	save := func(ctx context.Context, sessionID, fileName string) error {
+		_, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: sessionID, FileName: fileName, Part: genai.NewPartFromText("x"),
+		})
+		return err
+	}
+	if err := save(ctx, "session", "report.md"); err != nil {
+		t.Fatalf("Save() before Lock() failed: %v", err)
+	}
+
+	locker, ok := artifactx.As[artifactx.Locker](srv)
+	if !ok {
+		t.Fatal("service does not implement artifactx.Locker")
+	}
+	req := &artifactx.LockRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "report.md", Owner: "editor"}
+	lease, err := locker.Lock(ctx, req)
+	if err != nil {
+		t.Fatalf("Lock() failed: %v", err)
+	}
+	if want := clock.Now().Add(time.Minute); !lease.Expires.Equal(want) {
+		t.Errorf("Lock() expires = %v, want %v", lease.Expires, want)
+	}
+	held := artifactx.WithLease(ctx, lease.Token)
+
+	if err := save(ctx, "session", "report.md"); !errors.Is(err, artifactx.ErrLocked) {
+		t.Errorf("Save() by another writer error = %v, want %v", err, artifactx.ErrLocked)
+	}
+	if err := srv.Delete(ctx, &artifact.DeleteRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "report.md"}); !errors.Is(err, artifactx.ErrLocked) {
+		t.Errorf("Delete() by another writer error = %v, want %v", err, artifactx.ErrLocked)
+	}
+	if err := artifactx.Move(ctx, srv, &artifactx.MoveRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "report.md", NewFileName: "old.md"}); !errors.Is(err, artifactx.ErrLocked) {
+		t.Errorf("Move() by another writer error = %v, want %v", err, artifactx.ErrLocked)
+	}
+	if _, err := locker.Lock(ctx, req); !errors.Is(err, artifactx.ErrLocked) {
+		t.Errorf("Lock() by another caller error = %v, want %v", err, artifactx.ErrLocked)
+	}
+	if err := save(held, "session", "report.md"); err != nil {
+		t.Errorf("Save() by the holder failed: %v", err)
+	}
+	if err := save(ctx, "session", "other.md"); err != nil {
+		t.Errorf("Save() of another artifact failed: %v", err)
+	}
+	if _, err := srv.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "report.md"}); err != nil {
+		t.Errorf("Load() of a locked artifact failed: %v", err)
+	}
+
+	clock.Advance(45 * time.Second)
+	renewed, err := locker.Lock(held, req)
+	if err != nil || renewed.Token != lease.Token {
+		t.Fatalf("Lock() renewal = (%v, %v), want the same token", renewed, err)
+	}
+	clock.Advance(45 * time.Second)
+	if err := save(ctx, "session", "report.md"); !errors.Is(err, artifactx.ErrLocked) {
+		t.Errorf("Save() within the renewed lease error = %v, want %v", err, artifactx.ErrLocked)
+	}
+	if err := locker.Unlock(ctx, &artifactx.Lease{LockRequest: *req, Token: "forged"}); !errors.Is(err, artifactx.ErrLocked) {
+		t.Errorf("Unlock() with another token error = %v, want %v", err, artifactx.ErrLocked)
+	}
+	if err := locker.Unlock(ctx, renewed); err != nil {
+		t.Fatalf("Unlock() failed: %v", err)
+	}
+	if err := save(ctx, "session", "report.md"); err != nil {
+		t.Errorf("Save() after Unlock() failed: %v", err)
+	}
+
+	if _, err := locker.Lock(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	if err := save(ctx, "session", "report.md"); err != nil {
+		t.Errorf("Save() after the lease expired failed: %v", err)
+	}
+}
+
+func TestLockUserScoped(t *testing.T) {
+	ctx := t.Context()
+	srv := lockartifact.NewService(artifact.InMemoryService(), lockartifact.Options{})
+	if _, err := srv.Lock(ctx, &artifactx.LockRequest{AppName: "app", UserID: "user", SessionID: "s1", FileName: "user:profile"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "s2", FileName: "user:profile", Part: genai.NewPartFromText("x"),
+	})
+	if !errors.Is(err, artifactx.ErrLocked) {
+		t.Errorf("Save() from another session error = %v, want %v", err, artifactx.ErrLocked)
+	}
+}
+
+// blockingService blocks saves until release is closed.
+type blockingService struct {
+	artifact.Service
+	started, release chan struct{}
+}
+
+func (s *blockingService) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	close(s.started)
+	<-s.release
+	return s.Service.Save(ctx, req)
+}
+
+func TestLockWaitsForWrites(t *testing.T) {
+	ctx := t.Context()
+	next := &blockingService{Service: artifact.InMemoryService(), started: make(chan struct{}), release: make(chan struct{})}
+	srv := lockartifact.NewService(next, lockartifact.Options{})
+	saved := make(chan error, 1)
+	go func() {
+		_, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "report.md", Part: genai.NewPartFromText("x"),
+		})
+		saved <- err
+	}()
+	<-next.started
+
+	req := &artifactx.LockRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "report.md", Owner: "editor"}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := srv.Lock(cancelled, req); !errors.Is(err, context.Canceled) {
+		t.Errorf("Lock() with a save in progress and a cancelled context = %v, want %v", err, context.Canceled)
+	}
+	locked := make(chan error, 1)
+	go func() {
+		_, err := srv.Lock(ctx, req)
+		locked <- err
+	}()
+	select {
+	case err := <-locked:
+		t.Fatalf("Lock() = %v while a save was in progress, want it to wait", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(next.release)
+	if err := <-saved; err != nil {
+		t.Errorf("Save() failed: %v", err)
+	}
+	if err := <-locked; err != nil {
+		t.Errorf("Lock() after the save failed: %v", err)
+	}
+}
+
+func TestLockCapabilities(t *testing.T) {
+	ctx := t.Context()
+	next, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := lockartifact.NewService(next, lockartifact.Options{})
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "state.json",
+		Part: genai.NewPartFromBytes([]byte(`{"step":1}`), "application/json"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	uploader, ok := artifactx.As[artifactx.Uploader](srv)
+	if !ok {
+		t.Fatal("service does not implement artifactx.Uploader")
+	}
+	upReq := &artifactx.UploadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "state.json", MIMEType: "application/json"}
+	before, err := uploader.BeginUpload(ctx, upReq)
+	if err != nil {
+		t.Fatalf("BeginUpload() before Lock() failed: %v", err)
+	}
+	if _, err := uploader.AppendChunk(ctx, before.ID, 0, []byte(`{"step":9}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	lease, err := srv.Lock(ctx, &artifactx.LockRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "state.json", Owner: "editor"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	held := artifactx.WithLease(ctx, lease.Token)
+	update := &artifactx.UpdateJSONRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "state.json", Type: artifactx.MergePatch, Patch: []byte(`{"step":2}`),
+	}
+	if _, err := artifactx.UpdateJSON(ctx, srv, update); !errors.Is(err, artifactx.ErrLocked) {
+		t.Errorf("UpdateJSON() by another writer = %v, want %v", err, artifactx.ErrLocked)
+	}
+	if _, err := artifactx.UpdateJSON(held, srv, update); err != nil {
+		t.Errorf("UpdateJSON() by the holder failed: %v", err)
+	}
+	if _, err := uploader.BeginUpload(ctx, upReq); !errors.Is(err, artifactx.ErrLocked) {
+		t.Errorf("BeginUpload() by another writer = %v, want %v", err, artifactx.ErrLocked)
+	}
+	if _, err := uploader.CommitUpload(ctx, before.ID); !errors.Is(err, artifactx.ErrLocked) {
+		t.Errorf("CommitUpload() of an upload begun before the lease = %v, want %v", err, artifactx.ErrLocked)
+	}
+	if resp, err := uploader.CommitUpload(held, before.ID); err != nil || resp.Version != 3 {
+		t.Errorf("CommitUpload() by the holder = (%v, %v), want version 3", resp, err)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaartifact
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// duration returns the playing time of samples at rate, to the millisecond.
+func duration(samples, rate uint64) time.Duration {
+	if rate == 0 {
+		return 0
+	}
+	return time.Duration(float64(samples) / float64(rate) * float64(time.Second)).Round(time.Millisecond)
+}
+
+// wavCodecs names the common audio formats of WAV files.
+var wavCodecs = map[uint16]string{1: "pcm", 3: "pcm-float", 6: "alaw", 7: "mulaw"}
+
+// wavInfo returns the duration and codec of a WAV file from its fmt and
+// data chunks.
+func wavInfo(data []byte) (*Info, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("%w: missing WAV header", errMalformed)
+	}
+	var format uint16
+	var byteRate uint32
+	for i := 12; i+8 <= len(data); {
+		id, size := string(data[i:i+4]), int64(binary.LittleEndian.Uint32(data[i+4:]))
+		body := data[i+8:]
+		switch id {
+		case "fmt ":
+			if len(body) < 16 {
+				return nil, fmt.Errorf("%w: truncated fmt chunk", errMalformed)
+			}
+			format = binary.LittleEndian.Uint16(body)
+			byteRate = binary.LittleEndian.Uint32(body[8:])
+			if format == 0xfffe && size >= 26 && len(body) >= 26 { // extensible: the sub format follows
+				format = binary.LittleEndian.Uint16(body[24:])
+			}
+		case "data":
+			if byteRate == 0 {
+				return nil, fmt.Errorf("%w: missing fmt chunk", errMalformed)
+			}
+			// Streaming writers leave the size at its maximum.
+			size = min(size, int64(len(body)))
+			codec := wavCodecs[format]
+			if codec == "" {
+				codec = fmt.Sprintf("wav-%#04x", format)
+			}
+			return &Info{Duration: duration(uint64(size), uint64(byteRate)), Codec: codec}, nil
+		}
+		if size > int64(len(body)) {
+			break
+		}
+		i += 8 + int(size) + int(size&1) // chunks are padded to even sizes
+	}
+	return nil, fmt.Errorf("%w: missing data chunk", errMalformed)
+}
+
+// flacInfo returns the duration of a FLAC file from its STREAMINFO block.
+func flacInfo(data []byte) (*Info, error) {
+	if len(data) < 26 || string(data[:4]) != "fLaC" || data[4]&0x7f != 0 {
+		return nil, fmt.Errorf("%w: missing FLAC stream info", errMalformed)
+	}
+	// Sample rate (20 bits), channels (3), bits per sample (5) and total
+	// samples (36) follow the block sizes and frame sizes.
+	v := binary.BigEndian.Uint64(data[18:])
+	rate, samples := v>>44, v&(1<<36-1)
+	return &Info{Duration: duration(samples, rate), Codec: "flac"}, nil
+}
+
+// MPEG audio layer III tables, by version: 0 for MPEG-1, 1 for MPEG-2 and
+// MPEG-2.5.
+var (
+	mp3Bitrates = [2][15]uint64{
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	}
+	mp3SamplesPerFrame = [2]uint64{1152, 576}
+)
+
+// mp3Info returns the duration of an MP3 file from the frame count of its
+// Xing or VBRI header, or from the bitrate of its first frame for constant
+// bitrate files without one.
+func mp3Info(data []byte) (*Info, error) {
+	start := 0
+	if len(data) >= 10 && string(data[:3]) == "ID3" {
+		// Syncsafe size of the tag, without its header and footer.
+		size := int(data[6])<<21 | int(data[7])<<14 | int(data[8])<<7 | int(data[9])
+		start = 10 + size
+		if data[5]&0x10 != 0 {
+			start += 10
+		}
+	}
+	end := len(data)
+	if end-128 >= start && string(data[end-128:end-125]) == "TAG" { // ID3v1
+		end -= 128
+	}
+	if start+4 > end || data[start] != 0xff || data[start+1]&0xe0 != 0xe0 {
+		return nil, fmt.Errorf("%w: missing MPEG frame", errMalformed)
+	}
+	h := data[start : start+4]
+	versionBits, layer := h[1]>>3&3, h[1]>>1&3
+	bitrateIndex, rateIndex := h[2]>>4, h[2]>>2&3
+	if versionBits == 1 || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return nil, fmt.Errorf("%w: not an MPEG layer III frame", errMalformed)
+	}
+	version := 0
+	rate := [3]uint64{44100, 48000, 32000}[rateIndex]
+	switch versionBits {
+	case 2: // MPEG-2
+		version, rate = 1, rate/2
+	case 0: // MPEG-2.5
+		version, rate = 1, rate/4
+	}
+	// The Xing header follows the side information, whose size depends on
+	// the version and the channel mode.
+	sideInfo := 32
+	switch mono := h[3]>>6 == 3; {
+	case version == 0 && mono, version == 1 && !mono:
+		sideInfo = 17
+	case version == 1 && mono:
+		sideInfo = 9
+	}
+
+	info := &Info{Codec: "mp3"}
+	if x := start + 4 + sideInfo; x+12 <= end && (string(data[x:x+4]) == "Xing" || string(data[x:x+4]) == "Info") {
+		if flags := binary.BigEndian.Uint32(data[x+4:]); flags&1 != 0 {
+			frames := uint64(binary.BigEndian.Uint32(data[x+8:]))
+			info.Duration = duration(frames*mp3SamplesPerFrame[version], rate)
+			return info, nil
+		}
+	}
+	if v := start + 36; v+18 <= end && string(data[v:v+4]) == "VBRI" {
+		frames := uint64(binary.BigEndian.Uint32(data[v+14:]))
+		info.Duration = duration(frames*mp3SamplesPerFrame[version], rate)
+		return info, nil
+	}
+	bitrate := mp3Bitrates[version][bitrateIndex] * 1000
+	info.Duration = duration(uint64(end-start)*8, bitrate)
+	return info, nil
+}
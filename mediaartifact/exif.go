@@ -0,0 +1,197 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaartifact
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EXIF tags recorded by [Options.EXIF], by tag number. Only camera settings
+// are listed: location, serial numbers, owner and maker notes are never
+// recorded.
+var exifTags = map[uint16]string{
+	0x010f: "make",
+	0x0110: "model",
+	0x0112: "orientation",
+	0x0131: "software",
+	0x0132: "datetime",
+	0x829a: "exposure-time",
+	0x829d: "f-number",
+	0x8827: "iso",
+	0x9003: "datetime-original",
+	0x920a: "focal-length",
+	0xa434: "lens-model",
+}
+
+const (
+	exifOrientationTag = 0x0112
+	// exifIFDTag points to the IFD holding the camera settings.
+	exifIFDTag = 0x8769
+	// maxEXIFEntries bounds the entries read from an IFD, against corrupt
+	// counts.
+	maxEXIFEntries = 512
+	// maxEXIFValueLength bounds the length of recorded values.
+	maxEXIFValueLength = 64
+)
+
+// tiff reads the TIFF structure of EXIF data. All reads are bounds checked.
+type tiff struct {
+	b     []byte
+	order binary.ByteOrder
+}
+
+func (t tiff) u16(off int) (uint16, bool) {
+	if off < 0 || off+2 > len(t.b) {
+		return 0, false
+	}
+	return t.order.Uint16(t.b[off:]), true
+}
+
+func (t tiff) u32(off int) (uint32, bool) {
+	if off < 0 || off+4 > len(t.b) {
+		return 0, false
+	}
+	return t.order.Uint32(t.b[off:]), true
+}
+
+// typeSizes are the sizes of the EXIF value types, by type number.
+var typeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+// ifd calls fn with the tag, type, count and value offset of every entry of
+// the IFD at off.
+func (t tiff) ifd(off uint32, fn func(tag, typ uint16, count, valueOff int)) {
+	n, ok := t.u16(int(off))
+	if !ok {
+		return
+	}
+	for i := range min(int(n), maxEXIFEntries) {
+		e := int(off) + 2 + 12*i
+		tag, ok1 := t.u16(e)
+		typ, ok2 := t.u16(e + 2)
+		count, ok3 := t.u32(e + 4)
+		if !ok1 || !ok2 || !ok3 {
+			return
+		}
+		size, ok := typeSizes[typ]
+		if !ok || count > math.MaxInt32/8 {
+			continue
+		}
+		valueOff := e + 8
+		if size*int(count) > 4 {
+			v, ok := t.u32(e + 8)
+			if !ok {
+				return
+			}
+			valueOff = int(v)
+		}
+		if valueOff+size*int(count) > len(t.b) {
+			continue
+		}
+		fn(tag, typ, int(count), valueOff)
+	}
+}
+
+// value formats the first value of an entry, or returns "" for types that
+// are not recorded.
+func (t tiff) value(typ uint16, count, off int) string {
+	switch typ {
+	case 2: // ASCII
+		s, _, _ := strings.Cut(string(t.b[off:off+count]), "\x00")
+		return sanitize(s)
+	case 3:
+		v, _ := t.u16(off)
+		return strconv.Itoa(int(v))
+	case 4:
+		v, _ := t.u32(off)
+		return strconv.FormatUint(uint64(v), 10)
+	case 5: // unsigned rational
+		num, _ := t.u32(off)
+		den, _ := t.u32(off + 4)
+		if den == 0 {
+			return ""
+		}
+		if num > 0 && num < den && den%num == 0 {
+			return "1/" + strconv.FormatUint(uint64(den/num), 10)
+		}
+		return strconv.FormatFloat(math.Round(float64(num)/float64(den)*100)/100, 'f', -1, 64)
+	}
+	return ""
+}
+
+// parseEXIF returns the orientation and the recorded tags of the TIFF
+// structure of an EXIF segment. Malformed data yields what could be read.
+func parseEXIF(data []byte) (orientation int, tags map[string]string) {
+	t := tiff{b: data}
+	switch {
+	case strings.HasPrefix(string(data), "II*\x00"):
+		t.order = binary.LittleEndian
+	case strings.HasPrefix(string(data), "MM\x00*"):
+		t.order = binary.BigEndian
+	default:
+		return 0, nil
+	}
+	ifd0, ok := t.u32(4)
+	if !ok {
+		return 0, nil
+	}
+	tags = map[string]string{}
+	var exifIFD uint32
+	visit := func(tag, typ uint16, count, off int) {
+		switch tag {
+		case exifIFDTag:
+			exifIFD, _ = t.u32(off)
+			return
+		case exifOrientationTag:
+			v, _ := t.u16(off)
+			orientation = int(v)
+		}
+		if name, ok := exifTags[tag]; ok {
+			if v := t.value(typ, count, off); v != "" {
+				tags[name] = v
+			}
+		}
+	}
+	t.ifd(ifd0, visit)
+	if exifIFD != 0 && exifIFD != ifd0 {
+		t.ifd(exifIFD, func(tag, typ uint16, count, off int) {
+			if tag != exifIFDTag {
+				visit(tag, typ, count, off)
+			}
+		})
+	}
+	if len(tags) == 0 {
+		tags = nil
+	}
+	return orientation, tags
+}
+
+// sanitize keeps the printable characters of s, trimmed and shortened to
+// [maxEXIFValueLength] characters, so that values are safe as metadata.
+func sanitize(s string) string {
+	s = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r == unicode.ReplacementChar || !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, s))
+	if r := []rune(s); len(r) > maxEXIFValueLength {
+		s = strings.TrimSpace(string(r[:maxEXIFValueLength]))
+	}
+	return s
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaartifact
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/png"
+)
+
+// errMalformed is returned for content that is not valid media of its type.
+var errMalformed = errors.New("malformed media")
+
+// imageInfo returns the dimensions and format of an image in a format
+// registered with the image package.
+func imageInfo(data []byte) (*Info, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return &Info{Width: cfg.Width, Height: cfg.Height, Codec: format}, nil
+}
+
+// jpegSegment is a marker segment of the header of a JPEG image.
+type jpegSegment struct {
+	marker byte
+	// start and end are the offsets of the marker and of the end of the
+	// segment in the image.
+	start, end int
+	payload    []byte
+}
+
+// jpegSegments returns the segments of the JPEG image data up to the start
+// of the scan, and the offset of the start of the scan marker.
+func jpegSegments(data []byte) ([]jpegSegment, int, error) {
+	if len(data) < 2 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, 0, fmt.Errorf("%w: missing start of image", errMalformed)
+	}
+	var segments []jpegSegment
+	for i := 2; ; {
+		start := i
+		if i >= len(data) || data[i] != 0xff {
+			return nil, 0, fmt.Errorf("%w: missing marker at %d", errMalformed, i)
+		}
+		for i < len(data) && data[i] == 0xff { // fill bytes
+			i++
+		}
+		if i >= len(data) {
+			return nil, 0, fmt.Errorf("%w: truncated marker", errMalformed)
+		}
+		marker := data[i]
+		i++
+		switch {
+		case marker == 0xda || marker == 0xd9: // start of scan, end of image
+			return segments, start, nil
+		case marker == 0x01 || marker >= 0xd0 && marker <= 0xd7: // no payload
+			segments = append(segments, jpegSegment{marker: marker, start: start, end: i})
+			continue
+		}
+		if i+2 > len(data) {
+			return nil, 0, fmt.Errorf("%w: truncated segment", errMalformed)
+		}
+		n := int(binary.BigEndian.Uint16(data[i:]))
+		if n < 2 || i+n > len(data) {
+			return nil, 0, fmt.Errorf("%w: invalid segment length %d", errMalformed, n)
+		}
+		segments = append(segments, jpegSegment{marker: marker, start: start, end: i + n, payload: data[i+2 : i+n]})
+		i += n
+	}
+}
+
+// exifHeader starts the APP1 segment holding the EXIF tags of a JPEG image.
+var exifHeader = []byte("Exif\x00\x00")
+
+// jpegInfo returns the dimensions of a JPEG image from its frame header,
+// and its EXIF tags if exif is set.
+func jpegInfo(data []byte, exif bool) (*Info, error) {
+	segments, _, err := jpegSegments(data)
+	if err != nil {
+		return nil, err
+	}
+	info := &Info{Codec: "jpeg"}
+	orientation := 0
+	for _, seg := range segments {
+		switch {
+		case seg.marker >= 0xc0 && seg.marker <= 0xcf && seg.marker != 0xc4 && seg.marker != 0xc8 && seg.marker != 0xcc:
+			// Start of frame: precision, height, width.
+			if len(seg.payload) < 5 {
+				return nil, fmt.Errorf("%w: truncated frame header", errMalformed)
+			}
+			info.Height = int(binary.BigEndian.Uint16(seg.payload[1:]))
+			info.Width = int(binary.BigEndian.Uint16(seg.payload[3:]))
+		case seg.marker == 0xe1 && bytes.HasPrefix(seg.payload, exifHeader):
+			var tags map[string]string
+			orientation, tags = parseEXIF(seg.payload[len(exifHeader):])
+			if exif {
+				info.EXIF = tags
+			}
+		}
+	}
+	if info.Width == 0 || info.Height == 0 {
+		return nil, fmt.Errorf("%w: missing frame header", errMalformed)
+	}
+	if orientation >= 5 && orientation <= 8 { // rotated by 90 degrees
+		info.Width, info.Height = info.Height, info.Width
+	}
+	return info, nil
+}
+
+// webpInfo returns the dimensions of a WebP image from its first chunk.
+func webpInfo(data []byte) (*Info, error) {
+	if len(data) < 20 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, fmt.Errorf("%w: missing WebP header", errMalformed)
+	}
+	chunk, payload := string(data[12:16]), data[20:]
+	info := &Info{Codec: "webp"}
+	switch {
+	case chunk == "VP8 " && len(payload) >= 10 && bytes.Equal(payload[3:6], []byte{0x9d, 0x01, 0x2a}):
+		info.Width = int(binary.LittleEndian.Uint16(payload[6:]) & 0x3fff)
+		info.Height = int(binary.LittleEndian.Uint16(payload[8:]) & 0x3fff)
+	case chunk == "VP8L" && len(payload) >= 5 && payload[0] == 0x2f:
+		bits := binary.LittleEndian.Uint32(payload[1:])
+		info.Width = int(bits&0x3fff) + 1
+		info.Height = int(bits>>14&0x3fff) + 1
+	case chunk == "VP8X" && len(payload) >= 10:
+		info.Width = int(uint32(payload[4])|uint32(payload[5])<<8|uint32(payload[6])<<16) + 1
+		info.Height = int(uint32(payload[7])|uint32(payload[8])<<8|uint32(payload[9])<<16) + 1
+	default:
+		return nil, fmt.Errorf("%w: unknown WebP chunk %q", errMalformed, chunk)
+	}
+	return info, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaartifact
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// boxes calls fn with the type and payload of every box of an ISO base media
+// file in data, stopping at the first error.
+func boxes(data []byte, fn func(typ string, payload []byte) error) error {
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return fmt.Errorf("%w: truncated box", errMalformed)
+		}
+		size, header := uint64(binary.BigEndian.Uint32(data)), uint64(8)
+		typ := string(data[4:8])
+		switch size {
+		case 0: // up to the end of the file
+			size = uint64(len(data))
+		case 1: // 64-bit size
+			if len(data) < 16 {
+				return fmt.Errorf("%w: truncated box", errMalformed)
+			}
+			size, header = binary.BigEndian.Uint64(data[8:]), 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return fmt.Errorf("%w: invalid size of box %q", errMalformed, typ)
+		}
+		if err := fn(typ, data[header:size]); err != nil {
+			return err
+		}
+		data = data[size:]
+	}
+	return nil
+}
+
+// child returns the payload of the first box of type typ in data.
+func child(data []byte, typ string) []byte {
+	var found []byte
+	boxes(data, func(t string, payload []byte) error {
+		if t == typ && found == nil {
+			found = payload
+		}
+		return nil
+	})
+	return found
+}
+
+// mp4Info returns the duration of an MP4 or QuickTime file from its movie
+// header, the dimensions of its first video track and the codecs of its
+// tracks.
+func mp4Info(data []byte) (*Info, error) {
+	var moov []byte
+	if err := boxes(data, func(typ string, payload []byte) error {
+		if typ == "moov" {
+			moov = payload
+		}
+		return nil
+	}); err != nil && moov == nil {
+		return nil, err
+	}
+	if moov == nil {
+		return nil, fmt.Errorf("%w: missing movie box", errMalformed)
+	}
+	info := &Info{}
+	var codecs []string
+	err := boxes(moov, func(typ string, payload []byte) error {
+		switch typ {
+		case "mvhd":
+			info.Duration = mvhdDuration(payload)
+		case "trak":
+			mdia := child(payload, "mdia")
+			if hdlr := child(mdia, "hdlr"); len(hdlr) >= 12 && string(hdlr[8:12]) == "vide" && info.Width == 0 {
+				// The dimensions are 16.16 fixed point numbers ending the
+				// track header.
+				if tkhd := child(payload, "tkhd"); len(tkhd) >= 8 {
+					info.Width = int(binary.BigEndian.Uint32(tkhd[len(tkhd)-8:]) >> 16)
+					info.Height = int(binary.BigEndian.Uint32(tkhd[len(tkhd)-4:]) >> 16)
+				}
+			}
+			// The sample description box: version and flags, entry count,
+			// then the size and type of the first entry.
+			stsd := child(child(child(mdia, "minf"), "stbl"), "stsd")
+			if len(stsd) >= 16 {
+				if codec := strings.TrimSpace(string(stsd[12:16])); codec != "" && !slices.Contains(codecs, codec) {
+					codecs = append(codecs, codec)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	info.Codec = sanitize(strings.Join(codecs, ","))
+	return info, nil
+}
+
+// mvhdDuration returns the duration recorded in a movie header box.
+func mvhdDuration(mvhd []byte) time.Duration {
+	switch {
+	case len(mvhd) >= 20 && mvhd[0] == 0:
+		return duration(uint64(binary.BigEndian.Uint32(mvhd[16:])), uint64(binary.BigEndian.Uint32(mvhd[12:])))
+	case len(mvhd) >= 32 && mvhd[0] == 1:
+		return duration(binary.BigEndian.Uint64(mvhd[24:]), uint64(binary.BigEndian.Uint32(mvhd[20:])))
+	}
+	return 0
+}
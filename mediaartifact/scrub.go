@@ -0,0 +1,179 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaartifact
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"mime"
+	"slices"
+)
+
+// Scrub returns data of the media type mimeType without the metadata that
+// can identify where, when or by whom an image was taken: EXIF (including
+// GPS), XMP, IPTC and comments in JPEG images, EXIF, text and time chunks
+// in PNG images, and EXIF and XMP chunks in WebP images. The EXIF
+// orientation of JPEG images is kept so that they still display upright.
+// The pixels are not re-encoded.
+//
+// It reports whether anything was removed, and fails with [ErrUnsupported]
+// for other media types.
+func Scrub(mimeType string, data []byte) ([]byte, bool, error) {
+	mediaType, _, _ := mime.ParseMediaType(mimeType)
+	switch mediaType {
+	case "image/jpeg":
+		return scrubJPEG(data)
+	case "image/png":
+		return scrubPNG(data)
+	case "image/webp":
+		return scrubWebP(data)
+	}
+	return nil, false, ErrUnsupported
+}
+
+// keptJPEGMarkers are the application segments kept by Scrub: JFIF, ICC
+// profiles and Adobe color transforms, which decoders need.
+var keptJPEGMarkers = []byte{0xe0, 0xe2, 0xee}
+
+func scrubJPEG(data []byte) ([]byte, bool, error) {
+	segments, scan, err := jpegSegments(data)
+	if err != nil {
+		return nil, false, err
+	}
+	orientation := 0
+	for _, seg := range segments {
+		if seg.marker == 0xe1 && bytes.HasPrefix(seg.payload, exifHeader) {
+			orientation, _ = parseEXIF(seg.payload[len(exifHeader):])
+		}
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+	// The orientation goes right after the JFIF segment, which must come
+	// first.
+	removed, pending := false, orientation > 1 && orientation <= 8
+	minimal := orientationSegment(orientation)
+	for _, seg := range segments {
+		isApp := seg.marker >= 0xe0 && seg.marker <= 0xef
+		if pending && bytes.Equal(data[seg.start:seg.end], minimal) { // scrubbed before
+			pending = false
+		} else if (isApp && !slices.Contains(keptJPEGMarkers, seg.marker)) || seg.marker == 0xfe {
+			removed = true
+			continue
+		}
+		if pending && seg.marker != 0xe0 {
+			out = append(out, minimal...)
+			pending = false
+		}
+		out = append(out, data[seg.start:seg.end]...)
+	}
+	if !removed {
+		return data, false, nil
+	}
+	if pending {
+		out = append(out, minimal...)
+	}
+	return append(out, data[scan:]...), true, nil
+}
+
+// orientationSegment returns an EXIF segment holding only orientation.
+func orientationSegment(orientation int) []byte {
+	tiff := []byte("MM\x00*\x00\x00\x00\x08\x00\x01") // header, IFD of one entry
+	tiff = binary.BigEndian.AppendUint16(tiff, exifOrientationTag)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, uint16(orientation))
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0) // padding, no next IFD
+	payload := append(slices.Clone(exifHeader), tiff...)
+	seg := []byte{0xff, 0xe1}
+	seg = binary.BigEndian.AppendUint16(seg, uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// scrubbedPNGChunks are the chunks removed from PNG images.
+var scrubbedPNGChunks = []string{"eXIf", "tEXt", "zTXt", "iTXt", "tIME"}
+
+func scrubPNG(data []byte) ([]byte, bool, error) {
+	const signature = "\x89PNG\r\n\x1a\n"
+	if !bytes.HasPrefix(data, []byte(signature)) {
+		return nil, false, fmt.Errorf("%w: missing PNG signature", errMalformed)
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, signature...)
+	removed := false
+	for i := len(signature); i < len(data); {
+		// Length, type, data and CRC.
+		if i+12 > len(data) {
+			return nil, false, fmt.Errorf("%w: truncated chunk", errMalformed)
+		}
+		n := int64(binary.BigEndian.Uint32(data[i:]))
+		if n > int64(len(data)-i-12) {
+			return nil, false, fmt.Errorf("%w: invalid chunk length %d", errMalformed, n)
+		}
+		end := i + 12 + int(n)
+		if slices.Contains(scrubbedPNGChunks, string(data[i+4:i+8])) {
+			removed = true
+		} else {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	if !removed {
+		return data, false, nil
+	}
+	return out, true, nil
+}
+
+// VP8X flags of the metadata chunks of extended WebP images.
+const (
+	webpEXIFFlag = 0x08
+	webpXMPFlag  = 0x04
+)
+
+func scrubWebP(data []byte) ([]byte, bool, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, false, fmt.Errorf("%w: missing WebP header", errMalformed)
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:12]...)
+	removed := false
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, false, fmt.Errorf("%w: truncated chunk", errMalformed)
+		}
+		n := int64(binary.LittleEndian.Uint32(data[i+4:]))
+		n += n & 1 // chunks are padded to even sizes
+		if n > int64(len(data)-i-8) {
+			return nil, false, fmt.Errorf("%w: invalid chunk length %d", errMalformed, n)
+		}
+		end := i + 8 + int(n)
+		switch string(data[i : i+4]) {
+		case "EXIF", "XMP ":
+			removed = true
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	if !removed {
+		return data, false, nil
+	}
+	// Clear the flags announcing the removed chunks and fix the size.
+	if len(out) >= 21 && string(out[12:16]) == "VP8X" {
+		out[20] &^= webpEXIFFlag | webpXMPFlag
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, true, nil
+}
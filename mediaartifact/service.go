@@ -0,0 +1,224 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mediaartifact provides an [artifact.Service] decorator that
+// records the dimensions, duration and codec of media artifacts on Save, so
+// that renderers can lay out a gallery from [artifactx.Stater] alone instead
+// of downloading every file:
+//
+//	srv := mediaartifact.NewService(backend, mediaartifact.Options{EXIF: true})
+//	...
+//	attrs, err := srv.Stat(ctx, req)
+//	info := mediaartifact.FromMetadata(attrs.Metadata)
+//	fmt.Println(info.Width, info.Height, info.Duration)
+//
+// PNG, JPEG, GIF and WebP images, WAV, FLAC and MP3 audio, and MP4 and
+// QuickTime files are understood, with the standard library only. Other
+// artifacts, and media that cannot be parsed, are saved without media
+// metadata. With [Options.EXIF], a few camera settings of JPEG images are
+// recorded as well; location, serial numbers and other identifying tags
+// never are.
+//
+// With [Options.Scrub], the EXIF, GPS and other identifying metadata of
+// JPEG, PNG and WebP images is removed before they are persisted, see
+// [Scrub], so that user uploaded photos do not leak where they were taken.
+//
+// Save is decorated, and uploads are forwarded, except those of images when
+// scrubbing, which are refused since their content is never seen whole. The
+// other capabilities of the decorated service that write, such as
+// [artifactx.JSONUpdater], are not forwarded by [artifactx.As], so that no
+// image is written unscrubbed.
+package mediaartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Metadata keys recorded on media artifacts.
+const (
+	WidthMetadataKey    = "media-width"
+	HeightMetadataKey   = "media-height"
+	DurationMetadataKey = "media-duration"
+	CodecMetadataKey    = "media-codec"
+	// ScrubbedMetadataKey is "true" on images whose metadata was removed
+	// by [Options.Scrub].
+	ScrubbedMetadataKey = "media-scrubbed"
+	// EXIFMetadataPrefix starts the keys of the EXIF tags, such as
+	// "exif-model".
+	EXIFMetadataPrefix = "exif-"
+)
+
+// ErrUnsupported is returned by [Extract] for media types it does not parse.
+var ErrUnsupported = errors.New("unsupported media type")
+
+// Info describes media content.
+type Info struct {
+	// Width and Height are the displayed dimensions in pixels, with the EXIF
+	// orientation of JPEG images applied; zero for audio.
+	Width, Height int
+	// Duration is the playing time of audio and video.
+	Duration time.Duration
+	// Codec is the image format, such as "png", or the codecs of the
+	// tracks of audio and video, comma separated, such as "avc1,mp4a".
+	Codec string
+	// EXIF holds the sanitized EXIF tags by name, such as "model", if
+	// requested and present.
+	EXIF map[string]string
+}
+
+// Metadata returns the metadata recording i.
+func (i *Info) Metadata() map[string]string {
+	md := map[string]string{}
+	if i.Width > 0 && i.Height > 0 {
+		md[WidthMetadataKey] = strconv.Itoa(i.Width)
+		md[HeightMetadataKey] = strconv.Itoa(i.Height)
+	}
+	if i.Duration > 0 {
+		md[DurationMetadataKey] = i.Duration.String()
+	}
+	if i.Codec != "" {
+		md[CodecMetadataKey] = i.Codec
+	}
+	for k, v := range i.EXIF {
+		md[EXIFMetadataPrefix+k] = v
+	}
+	return md
+}
+
+// FromMetadata returns the media information recorded in md, such as the
+// [artifactx.Attributes.Metadata] of a version. Missing or malformed values
+// are left zero.
+func FromMetadata(md map[string]string) Info {
+	var i Info
+	i.Width, _ = strconv.Atoi(md[WidthMetadataKey])
+	i.Height, _ = strconv.Atoi(md[HeightMetadataKey])
+	i.Duration, _ = time.ParseDuration(md[DurationMetadataKey])
+	i.Codec = md[CodecMetadataKey]
+	for k, v := range md {
+		if name, ok := strings.CutPrefix(k, EXIFMetadataPrefix); ok {
+			if i.EXIF == nil {
+				i.EXIF = map[string]string{}
+			}
+			i.EXIF[name] = v
+		}
+	}
+	return i
+}
+
+// Extract parses data of the media type mimeType, recording the EXIF tags
+// too if exif is set. It fails with [ErrUnsupported] for types it does not
+// parse.
+func Extract(mimeType string, data []byte, exif bool) (*Info, error) {
+	mediaType, _, _ := mime.ParseMediaType(mimeType)
+	switch mediaType {
+	case "image/png", "image/gif":
+		return imageInfo(data)
+	case "image/jpeg":
+		return jpegInfo(data, exif)
+	case "image/webp":
+		return webpInfo(data)
+	case "audio/wav", "audio/x-wav", "audio/wave", "audio/vnd.wave":
+		return wavInfo(data)
+	case "audio/flac", "audio/x-flac":
+		return flacInfo(data)
+	case "audio/mpeg", "audio/mp3":
+		return mp3Info(data)
+	case "video/mp4", "audio/mp4", "audio/x-m4a", "video/quicktime":
+		return mp4Info(data)
+	}
+	return nil, ErrUnsupported
+}
+
+// Options configures [NewService].
+type Options struct {
+	// EXIF records sanitized EXIF tags of JPEG images.
+	EXIF bool
+	// Scrub removes identifying metadata from images before saving them.
+	// Images that cannot be parsed are rejected rather than saved with
+	// their metadata.
+	Scrub bool
+}
+
+// Service is an [artifact.Service] that records media metadata on Save.
+type Service struct {
+	artifact.Service
+	exif  bool
+	scrub bool
+}
+
+var _ artifactx.Forwarder = (*Service)(nil)
+
+// NewService returns a service that saves to next, recording the media
+// metadata of inline images, audio and video.
+func NewService(next artifact.Service, opts Options) *Service {
+	return &Service{Service: next, exif: opts.EXIF, scrub: opts.Scrub}
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// Forwards implements [artifactx.Forwarder]. Only the capabilities of the
+// decorated service that read are forwarded, since writes are scrubbed.
+func (s *Service) Forwards(capability any) bool {
+	return artifactx.IsReadCapability(capability)
+}
+
+// Save implements [artifact.Service]. Metadata attached by the caller with
+// [artifactx.WithMetadata] takes precedence over the extracted metadata,
+// which is extracted before the image is scrubbed.
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	if req.Part == nil || req.Part.InlineData == nil {
+		return s.Service.Save(ctx, req)
+	}
+	blob := req.Part.InlineData
+	md := map[string]string{}
+	if info, err := Extract(blob.MIMEType, blob.Data, s.exif); err == nil {
+		md = info.Metadata()
+	}
+	if s.scrub {
+		data, removed, err := Scrub(blob.MIMEType, blob.Data)
+		switch {
+		case errors.Is(err, ErrUnsupported):
+		case err != nil:
+			return nil, fmt.Errorf("failed to scrub metadata of %q: %w", req.FileName, err)
+		case removed:
+			md[ScrubbedMetadataKey] = "true"
+			// The caller's request and part are left untouched.
+			part, scrubbed := *req.Part, *blob
+			scrubbed.Data = data
+			part.InlineData = &scrubbed
+			r := *req
+			r.Part = &part
+			req = &r
+		}
+	}
+	if len(md) > 0 {
+		maps.Copy(md, artifactx.MetadataFrom(ctx))
+		ctx = artifactx.WithMetadata(ctx, md)
+	}
+	return s.Service.Save(ctx, req)
+}
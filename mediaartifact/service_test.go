@@ -0,0 +1,433 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaartifact_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"github.com/chinglinwen/adk-artifact/mediaartifact"
+)
+
+// ifdEntry is an entry of a little endian EXIF IFD; value holds the value
+// or, for values over 4 bytes, the data the offset points to.
+type ifdEntry struct {
+	tag, typ uint16
+	count    uint32
+	value    []byte
+}
+
+// exifJPEG returns a 40x20 JPEG image with an EXIF segment holding ifd0 and
+// an EXIF IFD holding sub.
+func exifJPEG(t *testing.T, ifd0, sub []ifdEntry) []byte {
+	t.Helper()
+	var img bytes.Buffer
+	if err := jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 40, 20)), nil); err != nil {
+		t.Fatal(err)
+	}
+	le := binary.LittleEndian
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	// writeIFD appends an IFD whose values over 4 bytes follow it.
+	writeIFD := func(entries []ifdEntry) (pointer int) {
+		start := len(tiff)
+		tiff = le.AppendUint16(tiff, uint16(len(entries)))
+		extra := start + 2 + 12*len(entries) + 4
+		var data []byte
+		for _, e := range entries {
+			tiff = le.AppendUint16(tiff, e.tag)
+			tiff = le.AppendUint16(tiff, e.typ)
+			tiff = le.AppendUint32(tiff, e.count)
+			if len(e.value) > 4 {
+				tiff = le.AppendUint32(tiff, uint32(extra+len(data)))
+				data = append(data, e.value...)
+			} else {
+				if e.tag == 0x8769 {
+					pointer = len(tiff)
+				}
+				tiff = append(tiff, append(e.value, make([]byte, 4-len(e.value))...)...)
+			}
+		}
+		tiff = le.AppendUint32(tiff, 0) // no next IFD
+		tiff = append(tiff, data...)
+		return pointer
+	}
+	if sub != nil {
+		ifd0 = append(ifd0, ifdEntry{0x8769, 4, 1, []byte{0, 0, 0, 0}})
+	}
+	pointer := writeIFD(ifd0)
+	if sub != nil {
+		le.PutUint32(tiff[pointer:], uint32(len(tiff)))
+		writeIFD(sub)
+	}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	seg := append([]byte{0xff, 0xe1}, binary.BigEndian.AppendUint16(nil, uint16(len(payload)+2))...)
+	data := img.Bytes()
+	return append(append(append([]byte{}, data[:2]...), append(seg, payload...)...), data[2:]...)
+}
+
+func ascii(s string) ifdEntry {
+	return ifdEntry{typ: 2, count: uint32(len(s) + 1), value: append([]byte(s), 0)}
+}
+
+func tagged(tag uint16, e ifdEntry) ifdEntry {
+	e.tag = tag
+	return e
+}
+
+func rational(num, den uint32) ifdEntry {
+	return ifdEntry{typ: 5, count: 1, value: binary.LittleEndian.AppendUint32(binary.LittleEndian.AppendUint32(nil, num), den)}
+}
+
+func short(v uint16) ifdEntry {
+	return ifdEntry{typ: 3, count: 1, value: binary.LittleEndian.AppendUint16(nil, v)}
+}
+
+// box returns an ISO base media box of typ holding the concatenated
+// payloads.
+func box(typ string, payloads ...[]byte) []byte {
+	payload := bytes.Join(payloads, nil)
+	return append(binary.BigEndian.AppendUint32([]byte{}, uint32(8+len(payload))), append([]byte(typ), payload...)...)
+}
+
+func mp4File() []byte {
+	be := binary.BigEndian
+	mvhd := make([]byte, 100)
+	be.PutUint32(mvhd[12:], 1000) // time scale
+	be.PutUint32(mvhd[16:], 2500) // duration
+	tkhd := make([]byte, 84)
+	be.PutUint32(tkhd[76:], 1920<<16)
+	be.PutUint32(tkhd[80:], 1080<<16)
+	hdlr := func(handler string) []byte {
+		return box("hdlr", make([]byte, 8), []byte(handler), make([]byte, 12))
+	}
+	stsd := func(codec string) []byte {
+		return box("minf", box("stbl", box("stsd", []byte{0, 0, 0, 0, 0, 0, 0, 1}, box(codec, make([]byte, 8)))))
+	}
+	return bytes.Join([][]byte{
+		box("ftyp", []byte("isom\x00\x00\x02\x00isom")),
+		box("moov",
+			box("mvhd", mvhd),
+			box("trak", box("tkhd", tkhd), box("mdia", hdlr("vide"), stsd("avc1"))),
+			box("trak", box("tkhd", make([]byte, 84)), box("mdia", hdlr("soun"), stsd("mp4a"))),
+		),
+		box("mdat", make([]byte, 64)),
+	}, nil)
+}
+
+func wavFile() []byte {
+	le := binary.LittleEndian
+	data := []byte("RIFF\x00\x00\x00\x00WAVE")
+	data = append(data, "fmt "...)
+	data = le.AppendUint32(data, 16)
+	data = le.AppendUint16(data, 1)     // PCM
+	data = le.AppendUint16(data, 1)     // mono
+	data = le.AppendUint32(data, 8000)  // sample rate
+	data = le.AppendUint32(data, 16000) // byte rate
+	data = le.AppendUint16(data, 2)
+	data = le.AppendUint16(data, 16)
+	data = append(data, "data"...)
+	data = le.AppendUint32(data, 24000)
+	return append(data, make([]byte, 24000)...)
+}
+
+func flacFile() []byte {
+	data := []byte("fLaC\x00\x00\x00\x22")
+	streamInfo := make([]byte, 34)
+	// 44100 Hz, 2 channels, 16 bits, 88200 samples.
+	binary.BigEndian.PutUint64(streamInfo[10:], 44100<<44|1<<41|15<<36|88200)
+	return append(data, streamInfo...)
+}
+
+func mp3File() []byte {
+	// An MPEG-1 layer III frame header at 128 kbit/s and 44.1 kHz, in
+	// 32000 bytes: 2 seconds.
+	data := make([]byte, 32000)
+	copy(data, []byte{0xff, 0xfb, 0x90, 0x00})
+	return append([]byte("ID3\x04\x00\x00\x00\x00\x00\x02\x00\x00"), data...)
+}
+
+func webpFile() []byte {
+	bits := uint32(300-1) | uint32(200-1)<<14
+	chunk := append([]byte{0x2f}, binary.LittleEndian.AppendUint32(nil, bits)...)
+	data := append([]byte("RIFF\x00\x00\x00\x00WEBPVP8L"), binary.LittleEndian.AppendUint32(nil, uint32(len(chunk)))...)
+	return append(data, append(chunk, 0)...) // padded to an even size
+}
+
+func TestExtract(t *testing.T) {
+	var pngData, gifData bytes.Buffer
+	if err := png.Encode(&pngData, image.NewGray(image.Rect(0, 0, 64, 48))); err != nil {
+		t.Fatal(err)
+	}
+	if err := gif.Encode(&gifData, image.NewPaletted(image.Rect(0, 0, 8, 16), color.Palette{color.Black, color.White}), nil); err != nil {
+		t.Fatal(err)
+	}
+	jpegData := exifJPEG(t,
+		[]ifdEntry{
+			tagged(0x010f, ascii("Acme")),
+			tagged(0x0110, ascii("  Snap\x01 3000  ")),
+			tagged(0x0112, short(6)), // rotated by 90 degrees
+			tagged(0x013b, ascii("Jane Doe")),
+			{0x8825, 4, 1, []byte{0x40, 0, 0, 0}}, // GPS IFD pointer
+		},
+		[]ifdEntry{
+			tagged(0x829a, rational(1, 125)),
+			tagged(0x829d, rational(28, 10)),
+			tagged(0x9003, ascii("2025:01:02 03:04:05")),
+			tagged(0xa431, ascii("SN123456")),
+		},
+	)
+	for _, tc := range []struct {
+		name, mimeType string
+		data           []byte
+		want           mediaartifact.Info
+	}{
+		{"png", "image/png", pngData.Bytes(), mediaartifact.Info{Width: 64, Height: 48, Codec: "png"}},
+		{"gif", "image/gif", gifData.Bytes(), mediaartifact.Info{Width: 8, Height: 16, Codec: "gif"}},
+		{"webp", "image/webp", webpFile(), mediaartifact.Info{Width: 300, Height: 200, Codec: "webp"}},
+		{"jpeg", "image/jpeg", jpegData, mediaartifact.Info{Width: 20, Height: 40, Codec: "jpeg", EXIF: map[string]string{
+			"make": "Acme", "model": "Snap 3000", "orientation": "6", "exposure-time": "1/125", "f-number": "2.8", "datetime-original": "2025:01:02 03:04:05",
+		}}},
+		{"wav", "audio/wav", wavFile(), mediaartifact.Info{Duration: 1500 * time.Millisecond, Codec: "pcm"}},
+		{"flac", "audio/flac", flacFile(), mediaartifact.Info{Duration: 2 * time.Second, Codec: "flac"}},
+		{"mp3", "audio/mpeg", mp3File(), mediaartifact.Info{Duration: 2 * time.Second, Codec: "mp3"}},
+		{"mp4", "video/mp4", mp4File(), mediaartifact.Info{Width: 1920, Height: 1080, Duration: 2500 * time.Millisecond, Codec: "avc1,mp4a"}},
+	} {
+		got, err := mediaartifact.Extract(tc.mimeType, tc.data, true)
+		if err != nil {
+			t.Errorf("Extract(%s) failed: %v", tc.name, err)
+			continue
+		}
+		if got.Width != tc.want.Width || got.Height != tc.want.Height || got.Duration != tc.want.Duration || got.Codec != tc.want.Codec || !maps.Equal(got.EXIF, tc.want.EXIF) {
+			t.Errorf("Extract(%s) = %+v, want %+v", tc.name, got, tc.want)
+		}
+		if back := mediaartifact.FromMetadata(got.Metadata()); back.Width != got.Width || back.Duration != got.Duration || back.Codec != got.Codec || !maps.Equal(back.EXIF, got.EXIF) {
+			t.Errorf("FromMetadata(Metadata()) of %s = %+v, want %+v", tc.name, back, got)
+		}
+		// Truncated content must fail or yield partial information, never
+		// panic.
+		for n := range len(tc.data) {
+			mediaartifact.Extract(tc.mimeType, tc.data[:n], true)
+		}
+	}
+	if _, err := mediaartifact.Extract("application/pdf", []byte("%PDF"), true); err != mediaartifact.ErrUnsupported {
+		t.Errorf("Extract(pdf) error = %v, want %v", err, mediaartifact.ErrUnsupported)
+	}
+}
+
+func TestService(t *testing.T) {
+	ctx := t.Context()
+	backend, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	jpegData := exifJPEG(t, []ifdEntry{tagged(0x0110, ascii("Snap 3000"))}, nil)
+	for _, exif := range []bool{false, true} {
+		srv := mediaartifact.NewService(backend, mediaartifact.Options{EXIF: exif})
+		for _, part := range []*genai.Part{
+			genai.NewPartFromBytes(jpegData, "image/jpeg"),
+			genai.NewPartFromText("not media"),
+		} {
+			if _, err := srv.Save(artifactx.WithMetadata(ctx, map[string]string{mediaartifact.CodecMetadataKey: "custom"}), &artifact.SaveRequest{
+				AppName: "app", UserID: "user", SessionID: "session", FileName: "photo", Part: part,
+			}); err != nil {
+				t.Fatal(err)
+			}
+			stater, _ := artifactx.As[artifactx.Stater](srv)
+			attrs, err := stater.Stat(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "photo"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			info := mediaartifact.FromMetadata(attrs.Metadata)
+			wantWidth, wantEXIF := 40, map[string]string(nil)
+			if part.InlineData == nil {
+				wantWidth = 0
+			} else if exif {
+				wantEXIF = map[string]string{"model": "Snap 3000"}
+			}
+			if info.Width != wantWidth || info.Codec != "custom" || !maps.Equal(info.EXIF, wantEXIF) {
+				t.Errorf("EXIF %t: media metadata of %v = %+v, want width %d, the caller's codec and EXIF %v", exif, part.InlineData != nil, info, wantWidth, wantEXIF)
+			}
+		}
+	}
+}
+
+// pngChunk returns a PNG chunk of typ; the CRC is not checked by Scrub.
+func pngChunk(typ, data string) []byte {
+	c := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	return append(append(append(c, typ...), data...), 0, 0, 0, 0)
+}
+
+func TestScrub(t *testing.T) {
+	jpegData := exifJPEG(t,
+		[]ifdEntry{tagged(0x010f, ascii("Acme")), tagged(0x0112, short(6)), {0x8825, 4, 1, []byte{0x40, 0, 0, 0}}},
+		[]ifdEntry{tagged(0xa431, ascii("SN123456"))},
+	)
+	// A comment after the EXIF segment.
+	comment := append([]byte{0xff, 0xfe, 0x00, 0x0e}, "Home, 4th"...)
+	comment = append(comment, 0, 0, 0)
+	jpegData = append(jpegData[:2], append(comment, jpegData[2:]...)...)
+
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	// A text chunk after the header chunk of 13 bytes.
+	ihdrEnd := 8 + 12 + 13
+	pngWithText := append(append(slices.Clone(pngData.Bytes()[:ihdrEnd]), pngChunk("tEXt", "Author\x00Jane Doe")...), pngData.Bytes()[ihdrEnd:]...)
+
+	vp8x := append([]byte("VP8X\x0a\x00\x00\x00"), 0x08, 0, 0, 0, 9, 0, 0, 9, 0, 0)
+	webpData := append([]byte("RIFF\x00\x00\x00\x00WEBP"), vp8x...)
+	webpData = append(webpData, append([]byte("EXIF\x05\x00\x00\x00Acme!"), 0)...) // padded
+	webpData = append(webpData, webpFile()[12:]...)
+	binary.LittleEndian.PutUint32(webpData[4:], uint32(len(webpData)-8))
+
+	for _, tc := range []struct {
+		name, mimeType string
+		data           []byte
+		secrets        []string
+	}{
+		{"jpeg", "image/jpeg", jpegData, []string{"Acme", "SN123456", "Home"}},
+		{"png", "image/png", pngWithText, []string{"Jane Doe"}},
+		{"webp", "image/webp", webpData, []string{"Acme"}},
+	} {
+		got, removed, err := mediaartifact.Scrub(tc.mimeType, tc.data)
+		if err != nil || !removed {
+			t.Errorf("Scrub(%s) = (removed %t, %v), want metadata removed", tc.name, removed, err)
+			continue
+		}
+		for _, secret := range tc.secrets {
+			if bytes.Contains(got, []byte(secret)) {
+				t.Errorf("Scrub(%s) kept %q", tc.name, secret)
+			}
+		}
+		if again, removed, err := mediaartifact.Scrub(tc.mimeType, got); err != nil || removed || !bytes.Equal(again, got) {
+			t.Errorf("Scrub(%s) of scrubbed content = (removed %t, %v), want it unchanged", tc.name, removed, err)
+		}
+		for n := range len(tc.data) {
+			mediaartifact.Scrub(tc.mimeType, tc.data[:n])
+		}
+	}
+
+	// Scrubbed images still decode, and JPEG images keep their orientation.
+	got, _, _ := mediaartifact.Scrub("image/jpeg", jpegData)
+	if _, err := jpeg.Decode(bytes.NewReader(got)); err != nil {
+		t.Errorf("decoding the scrubbed JPEG image failed: %v", err)
+	}
+	if info, err := mediaartifact.Extract("image/jpeg", got, true); err != nil || info.Width != 20 || !maps.Equal(info.EXIF, map[string]string{"orientation": "6"}) {
+		t.Errorf("Extract() of the scrubbed JPEG image = (%+v, %v), want it rotated with only the orientation", info, err)
+	}
+	got, _, _ = mediaartifact.Scrub("image/png", pngWithText)
+	if _, err := png.Decode(bytes.NewReader(got)); err != nil {
+		t.Errorf("decoding the scrubbed PNG image failed: %v", err)
+	}
+	got, _, _ = mediaartifact.Scrub("image/webp", webpData)
+	if size := binary.LittleEndian.Uint32(got[4:]); int(size) != len(got)-8 || got[20]&0x08 != 0 {
+		t.Errorf("scrubbed WebP image has size %d and flags %#x, want %d without the EXIF flag", size, got[20], len(got)-8)
+	}
+}
+
+func TestServiceScrub(t *testing.T) {
+	ctx := t.Context()
+	backend, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := mediaartifact.NewService(backend, mediaartifact.Options{EXIF: true, Scrub: true})
+	jpegData := exifJPEG(t, []ifdEntry{tagged(0x0110, ascii("Snap 3000")), {0x8825, 4, 1, []byte{0x40, 0, 0, 0}}}, nil)
+	part := genai.NewPartFromBytes(jpegData, "image/jpeg")
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "photo.jpg", Part: part}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(part.InlineData.Data, jpegData) {
+		t.Errorf("Save() changed the part of the caller")
+	}
+	req := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "photo.jpg"}
+	resp, err := srv.Load(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(resp.Part.InlineData.Data, []byte("Snap 3000")) {
+		t.Errorf("Load() returned the EXIF tags, want them scrubbed")
+	}
+	stater, _ := artifactx.As[artifactx.Stater](srv)
+	attrs, err := stater.Stat(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The sanitized tags are recorded from the original image.
+	if attrs.Metadata[mediaartifact.ScrubbedMetadataKey] != "true" || attrs.Metadata["exif-model"] != "Snap 3000" {
+		t.Errorf("Stat().Metadata = %v, want the image marked as scrubbed with its model", attrs.Metadata)
+	}
+
+	// Images that cannot be scrubbed are not saved.
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "broken.jpg", Part: genai.NewPartFromBytes(jpegData[:20], "image/jpeg"),
+	}); err == nil {
+		t.Errorf("Save() of a truncated JPEG image succeeded, want an error")
+	}
+}
+
+func TestServiceUpload(t *testing.T) {
+	ctx := t.Context()
+	backend, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, scrub := range []bool{false, true} {
+		srv := mediaartifact.NewService(backend, mediaartifact.Options{Scrub: scrub})
+		u, ok := artifactx.As[artifactx.Uploader](srv)
+		if !ok {
+			t.Fatal("service does not implement artifactx.Uploader")
+		}
+		for _, mimeType := range []string{"image/jpeg", "image/png; charset=binary", ""} {
+			_, err := u.BeginUpload(ctx, &artifactx.UploadRequest{
+				AppName: "app", UserID: "user", SessionID: "session", FileName: "photo", MIMEType: mimeType,
+			})
+			if scrub && !errors.Is(err, errors.ErrUnsupported) {
+				t.Errorf("BeginUpload(%q) when scrubbing = %v, want %v", mimeType, err, errors.ErrUnsupported)
+			}
+			if !scrub && err != nil {
+				t.Errorf("BeginUpload(%q) = %v", mimeType, err)
+			}
+		}
+		up, err := u.BeginUpload(ctx, &artifactx.UploadRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "notes.txt", MIMEType: "text/plain",
+		})
+		if err != nil {
+			t.Fatalf("BeginUpload() of text = %v", err)
+		}
+		if _, err := u.AppendChunk(ctx, up.ID, 0, []byte("notes")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := u.CommitUpload(ctx, up.ID); err != nil {
+			t.Errorf("CommitUpload() of text = %v", err)
+		}
+	}
+}
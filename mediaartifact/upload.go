@@ -0,0 +1,100 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.Uploader = (*Service)(nil)
+
+// Uploads are streamed to the decorated service, so their content is never
+// seen whole: with [Options.Scrub], uploads of images, or of content whose
+// type is left to the backend to detect, are refused, and the images must
+// be saved instead. Other uploads are forwarded without media metadata.
+
+// scrubbable reports whether [Scrub] handles the media type mimeType.
+func scrubbable(mimeType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(mimeType)
+	switch mediaType {
+	case "image/jpeg", "image/png", "image/webp":
+		return true
+	}
+	return false
+}
+
+// uploader returns the uploader of the decorated service.
+func (s *Service) uploader() (artifactx.Uploader, error) {
+	u, ok := artifactx.As[artifactx.Uploader](s.Service)
+	if !ok {
+		return nil, fmt.Errorf("upload: %w", errors.ErrUnsupported)
+	}
+	return u, nil
+}
+
+// BeginUpload implements [artifactx.Uploader].
+func (s *Service) BeginUpload(ctx context.Context, req *artifactx.UploadRequest) (*artifactx.Upload, error) {
+	if s.scrub && (req.MIMEType == "" || scrubbable(req.MIMEType)) {
+		return nil, fmt.Errorf("upload of %q cannot be scrubbed, save it instead: %w", req.FileName, errors.ErrUnsupported)
+	}
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	return u.BeginUpload(ctx, req)
+}
+
+// AppendChunk implements [artifactx.Uploader].
+func (s *Service) AppendChunk(ctx context.Context, uploadID string, offset int64, data []byte) (*artifactx.Upload, error) {
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	return u.AppendChunk(ctx, uploadID, offset, data)
+}
+
+// UploadStatus implements [artifactx.Uploader].
+func (s *Service) UploadStatus(ctx context.Context, uploadID string) (*artifactx.Upload, error) {
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	return u.UploadStatus(ctx, uploadID)
+}
+
+// CommitUpload implements [artifactx.Uploader].
+func (s *Service) CommitUpload(ctx context.Context, uploadID string) (*artifact.SaveResponse, error) {
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	return u.CommitUpload(ctx, uploadID)
+}
+
+// AbortUpload implements [artifactx.Uploader].
+func (s *Service) AbortUpload(ctx context.Context, uploadID string) error {
+	u, err := s.uploader()
+	if err != nil {
+		return err
+	}
+	return u.AbortUpload(ctx, uploadID)
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package piiartifact
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var (
+	_ artifactx.JSONUpdater = (*Service)(nil)
+	_ artifactx.Uploader    = (*Service)(nil)
+)
+
+// errFindings stops an update of the decorated service whose document has
+// findings, so that it is saved through Save instead.
+var errFindings = errors.New("personal data found")
+
+// UpdateJSON implements [artifactx.JSONUpdater]. Patched documents without
+// findings are saved by the decorated service, atomically if it implements
+// [artifactx.JSONUpdater]; the others are saved with Save, which tags,
+// redacts or blocks them like any other text.
+func (s *Service) UpdateJSON(ctx context.Context, req *artifactx.UpdateJSONRequest) (*artifact.SaveResponse, error) {
+	if u, ok := artifactx.As[artifactx.JSONUpdater](s.Service); ok {
+		r := *req
+		r.Check = func(doc []byte) error {
+			if req.Check != nil {
+				if err := req.Check(doc); err != nil {
+					return err
+				}
+			}
+			findings, err := s.Scan(ctx, string(doc))
+			if err != nil {
+				return err
+			}
+			if len(findings) > 0 {
+				return errFindings
+			}
+			return nil
+		}
+		resp, err := u.UpdateJSON(ctx, &r)
+		if !errors.Is(err, errFindings) {
+			return resp, err
+		}
+	}
+	// Hide the JSONUpdater of s, so that the update loads and saves.
+	return artifactx.UpdateJSON(ctx, struct{ artifact.Service }{s}, req)
+}
+
+// Uploads are streamed to the decorated service, so their content is never
+// seen whole: uploads of text, or of content whose type is left to the
+// backend to detect, are refused, and the text must be saved instead. Other
+// uploads are forwarded, since Save does not scan them either.
+
+// uploader returns the uploader of the decorated service.
+func (s *Service) uploader() (artifactx.Uploader, error) {
+	u, ok := artifactx.As[artifactx.Uploader](s.Service)
+	if !ok {
+		return nil, fmt.Errorf("upload: %w", errors.ErrUnsupported)
+	}
+	return u, nil
+}
+
+// BeginUpload implements [artifactx.Uploader].
+func (s *Service) BeginUpload(ctx context.Context, req *artifactx.UploadRequest) (*artifactx.Upload, error) {
+	if artifactx.IsText(cmp.Or(req.MIMEType, "text/plain")) {
+		return nil, fmt.Errorf("upload of %q cannot be scanned, save it instead: %w", req.FileName, errors.ErrUnsupported)
+	}
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	return u.BeginUpload(ctx, req)
+}
+
+// AppendChunk implements [artifactx.Uploader].
+func (s *Service) AppendChunk(ctx context.Context, uploadID string, offset int64, data []byte) (*artifactx.Upload, error) {
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	return u.AppendChunk(ctx, uploadID, offset, data)
+}
+
+// UploadStatus implements [artifactx.Uploader].
+func (s *Service) UploadStatus(ctx context.Context, uploadID string) (*artifactx.Upload, error) {
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	return u.UploadStatus(ctx, uploadID)
+}
+
+// CommitUpload implements [artifactx.Uploader].
+func (s *Service) CommitUpload(ctx context.Context, uploadID string) (*artifact.SaveResponse, error) {
+	u, err := s.uploader()
+	if err != nil {
+		return nil, err
+	}
+	return u.CommitUpload(ctx, uploadID)
+}
+
+// AbortUpload implements [artifactx.Uploader].
+func (s *Service) AbortUpload(ctx context.Context, uploadID string) error {
+	u, err := s.uploader()
+	if err != nil {
+		return err
+	}
+	return u.AbortUpload(ctx, uploadID)
+}
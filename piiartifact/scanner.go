@@ -0,0 +1,142 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package piiartifact
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// Kinds of personal data found by [DefaultPatterns].
+const (
+	CreditCard = "credit-card"
+	Email      = "email"
+	SSN        = "ssn"
+	IBAN       = "iban"
+)
+
+// Finding is personal data found in a text.
+type Finding struct {
+	// Kind names the type of data, such as [CreditCard].
+	Kind string
+	// Start and End are the byte offsets of the data in the text.
+	Start, End int
+}
+
+// Scanner finds personal data in text. Implementations can call out to a
+// DLP service; an error makes Save fail rather than store unscanned text.
+type Scanner interface {
+	Scan(ctx context.Context, text string) ([]Finding, error)
+}
+
+// ScannerFunc adapts a function to a [Scanner].
+type ScannerFunc func(ctx context.Context, text string) ([]Finding, error)
+
+// Scan implements [Scanner].
+func (f ScannerFunc) Scan(ctx context.Context, text string) ([]Finding, error) {
+	return f(ctx, text)
+}
+
+// Pattern finds one kind of personal data with a regular expression.
+type Pattern struct {
+	Kind   string
+	Regexp *regexp.Regexp
+	// Valid, if set, filters the matches, e.g. with a checksum.
+	Valid func(match string) bool
+}
+
+// DefaultPatterns returns patterns for payment card numbers passing the
+// Luhn check, email addresses, US social security numbers and IBANs
+// passing their check digits.
+func DefaultPatterns() []Pattern {
+	return []Pattern{
+		{Kind: CreditCard, Regexp: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), Valid: luhn},
+		{Kind: Email, Regexp: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+		{Kind: SSN, Regexp: regexp.MustCompile(`\b(?:00[1-9]|0[1-9]\d|[1-578]\d\d|6[0-57-9]\d|66[0-57-9])-(?:0[1-9]|[1-9]\d)-(?:000[1-9]|00[1-9]\d|0[1-9]\d\d|[1-9]\d{3})\b`)},
+		{Kind: IBAN, Regexp: regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]){11,30}\b`), Valid: ibanValid},
+	}
+}
+
+// RegexScanner is a [Scanner] matching patterns.
+type RegexScanner struct {
+	patterns []Pattern
+}
+
+// NewRegexScanner returns a scanner matching patterns, or
+// [DefaultPatterns] if none are given.
+func NewRegexScanner(patterns ...Pattern) *RegexScanner {
+	if len(patterns) == 0 {
+		patterns = DefaultPatterns()
+	}
+	return &RegexScanner{patterns: patterns}
+}
+
+// Scan implements [Scanner].
+func (s *RegexScanner) Scan(ctx context.Context, text string) ([]Finding, error) {
+	var findings []Finding
+	for _, p := range s.patterns {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for _, m := range p.Regexp.FindAllStringIndex(text, -1) {
+			if p.Valid == nil || p.Valid(text[m[0]:m[1]]) {
+				findings = append(findings, Finding{Kind: p.Kind, Start: m[0], End: m[1]})
+			}
+		}
+	}
+	return findings, nil
+}
+
+// luhn reports whether the digits of s pass the Luhn check of payment card
+// numbers.
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// ibanValid reports whether s passes the mod 97 check of IBANs.
+func ibanValid(s string) bool {
+	s = strings.ReplaceAll(s, " ", "")
+	if len(s) < 15 || len(s) > 34 {
+		return false
+	}
+	rem := 0
+	for _, c := range s[4:] + s[:4] {
+		switch {
+		case c >= '0' && c <= '9':
+			rem = (rem*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			rem = (rem*100 + int(c-'A'+10)) % 97
+		default:
+			return false
+		}
+	}
+	return rem == 1
+}
@@ -0,0 +1,247 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package piiartifact provides an [artifact.Service] decorator that scans
+// text artifacts for personal data on Save, so that text holding payment
+// card numbers and the like never lands in storage unflagged:
+//
+//	srv := piiartifact.NewService(backend, piiartifact.Options{
+//		Actions: map[string]piiartifact.Action{piiartifact.CreditCard: piiartifact.Redact},
+//	})
+//
+// Text parts and inline data of textual media types are passed to the
+// [Scanner]s, [NewRegexScanner] by default; a DLP service can be plugged in
+// as another Scanner. What happens to a version with findings depends on
+// the [Action] of their kinds: [Tag] records the kinds in the metadata of
+// the version under [FindingsMetadataKey], [Redact] also replaces the data
+// before saving, and [Block] fails the Save with [ErrBlocked].
+//
+// Save and JSON updates are scanned, and uploads are forwarded, except those
+// of text, which are refused since their content is never seen whole. The
+// other capabilities of the decorated service that write, such as
+// [artifactx.Relabeler], are not forwarded by [artifactx.As], so that
+// nothing is written unscanned.
+package piiartifact
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Metadata keys recorded on versions with findings.
+const (
+	// FindingsMetadataKey lists the kinds of personal data found, sorted
+	// and comma separated.
+	FindingsMetadataKey = "pii"
+	// RedactedMetadataKey lists the kinds of personal data redacted.
+	RedactedMetadataKey = "pii-redacted"
+)
+
+// ErrBlocked is returned by Save for text holding personal data whose kind
+// has the [Block] action.
+var ErrBlocked = errors.New("artifact contains personal data")
+
+// Action is what Save does with a kind of personal data.
+type Action int
+
+const (
+	// Tag saves the text as is and records the kind in its metadata.
+	Tag Action = iota
+	// Redact replaces the data with a placeholder such as
+	// "[REDACTED:credit-card]" before saving, and records the kind.
+	Redact
+	// Block fails the Save.
+	Block
+)
+
+// Options configures [NewService].
+type Options struct {
+	// Scanners find personal data; nil means [NewRegexScanner] with the
+	// [DefaultPatterns].
+	Scanners []Scanner
+	// Action applies to kinds missing from Actions; the zero value is Tag.
+	Action Action
+	// Actions sets the action of kinds of personal data.
+	Actions map[string]Action
+}
+
+// Service is an [artifact.Service] that scans text for personal data on
+// Save.
+type Service struct {
+	artifact.Service
+	scanners []Scanner
+	action   Action
+	actions  map[string]Action
+}
+
+var _ artifactx.Forwarder = (*Service)(nil)
+
+// NewService returns a service that saves to next after scanning text
+// artifacts and applying the actions of opts.
+func NewService(next artifact.Service, opts Options) *Service {
+	s := &Service{Service: next, scanners: opts.Scanners, action: opts.Action, actions: opts.Actions}
+	if s.scanners == nil {
+		s.scanners = []Scanner{NewRegexScanner()}
+	}
+	return s
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// Forwards implements [artifactx.Forwarder]. Only the capabilities of the
+// decorated service that read are forwarded, since writes are scanned.
+func (s *Service) Forwards(capability any) bool {
+	return artifactx.IsReadCapability(capability)
+}
+
+func (s *Service) actionOf(kind string) Action {
+	if a, ok := s.actions[kind]; ok {
+		return a
+	}
+	return s.action
+}
+
+// Scan returns the findings of all scanners of s in text, sorted by offset.
+func (s *Service) Scan(ctx context.Context, text string) ([]Finding, error) {
+	var findings []Finding
+	for _, sc := range s.scanners {
+		f, err := sc.Scan(ctx, text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan for personal data: %w", err)
+		}
+		findings = append(findings, f...)
+	}
+	slices.SortFunc(findings, func(a, b Finding) int {
+		return cmp.Or(cmp.Compare(a.Start, b.Start), cmp.Compare(b.End, a.End))
+	})
+	return findings, nil
+}
+
+// Save implements [artifact.Service].
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	text, ok := textOf(req.Part)
+	if !ok {
+		return s.Service.Save(ctx, req)
+	}
+	findings, err := s.Scan(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	if len(findings) == 0 {
+		return s.Service.Save(ctx, req)
+	}
+
+	var found, blocked, redacted []string
+	var redactions []Finding
+	for _, f := range findings {
+		found = append(found, f.Kind)
+		switch s.actionOf(f.Kind) {
+		case Block:
+			blocked = append(blocked, f.Kind)
+		case Redact:
+			redacted = append(redacted, f.Kind)
+			redactions = append(redactions, f)
+		}
+	}
+	if len(blocked) > 0 {
+		return nil, fmt.Errorf("%w: %s in %q", ErrBlocked, kinds(blocked), req.FileName)
+	}
+	md := map[string]string{FindingsMetadataKey: kinds(found)}
+	if len(redactions) > 0 {
+		md[RedactedMetadataKey] = kinds(redacted)
+		r := *req
+		r.Part = withText(req.Part, RedactText(text, redactions))
+		req = &r
+	}
+	// The findings take precedence over metadata attached by the caller.
+	merged := maps.Clone(artifactx.MetadataFrom(ctx))
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	maps.Copy(merged, md)
+	return s.Service.Save(artifactx.WithMetadata(ctx, merged), req)
+}
+
+// kinds returns the sorted distinct kinds, comma separated.
+func kinds(ks []string) string {
+	slices.Sort(ks)
+	return strings.Join(slices.Compact(ks), ",")
+}
+
+// textOf returns the text of part if it is text or inline data of a textual
+// media type.
+func textOf(part *genai.Part) (string, bool) {
+	switch {
+	case part == nil:
+		return "", false
+	case part.InlineData != nil:
+		if !artifactx.IsText(part.InlineData.MIMEType) {
+			return "", false
+		}
+		return string(part.InlineData.Data), true
+	case part.Text != "":
+		return part.Text, true
+	}
+	return "", false
+}
+
+// withText returns a copy of part holding text instead of its content.
+func withText(part *genai.Part, text string) *genai.Part {
+	p := *part
+	if p.InlineData != nil {
+		blob := *p.InlineData
+		blob.Data = []byte(text)
+		p.InlineData = &blob
+	} else {
+		p.Text = text
+	}
+	return &p
+}
+
+// RedactText returns text with the data of findings replaced by
+// "[REDACTED:<kind>]". Overlapping findings are replaced once, with the
+// kind of the first.
+func RedactText(text string, findings []Finding) string {
+	findings = slices.Clone(findings)
+	slices.SortFunc(findings, func(a, b Finding) int { return cmp.Compare(a.Start, b.Start) })
+	var b strings.Builder
+	last := 0
+	for _, f := range findings {
+		switch {
+		case f.Start < 0 || f.Start > f.End || f.End > len(text): // invalid
+			continue
+		case f.Start < last: // overlaps the previous replacement
+			last = max(last, f.End)
+			continue
+		}
+		b.WriteString(text[last:f.Start])
+		b.WriteString("[REDACTED:" + f.Kind + "]")
+		last = f.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
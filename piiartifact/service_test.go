@@ -0,0 +1,215 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package piiartifact_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"github.com/chinglinwen/adk-artifact/piiartifact"
+)
+
+func TestRegexScanner(t *testing.T) {
+	text := "pay with 4111 1111 1111 1111, not 4111 1111 1111 1112; mail jane.doe@example.com, ssn 123-45-6789, iban GB82 WEST 1234 5698 7654 32, order 2025-01-02"
+	findings, err := piiartifact.NewRegexScanner().Scan(t.Context(), text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Kind+"="+text[f.Start:f.End])
+	}
+	want := []string{
+		"credit-card=4111 1111 1111 1111",
+		"email=jane.doe@example.com",
+		"ssn=123-45-6789",
+		"iban=GB82 WEST 1234 5698 7654 32",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Scan() = %q, want %q", got, want)
+	}
+}
+
+func TestRedactText(t *testing.T) {
+	text := "card 4111111111111111 and a@b.co"
+	got := piiartifact.RedactText(text, []piiartifact.Finding{
+		{Kind: "email", Start: 26, End: 32},
+		{Kind: "credit-card", Start: 5, End: 21},
+		{Kind: "number", Start: 10, End: 21}, // overlaps the card number
+		{Kind: "invalid", Start: 30, End: 100},
+	})
+	if want := "card [REDACTED:credit-card] and [REDACTED:email]"; got != want {
+		t.Errorf("RedactText() = %q, want %q", got, want)
+	}
+}
+
+func TestService(t *testing.T) {
+	ctx := t.Context()
+	backend, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := piiartifact.NewService(backend, piiartifact.Options{
+		Actions: map[string]piiartifact.Action{
+			piiartifact.CreditCard: piiartifact.Redact,
+			piiartifact.SSN:        piiartifact.Block,
+		},
+	})
+	stater, _ := artifactx.As[artifactx.Stater](srv)
+	save := func(name string, part *genai.Part) error {
+		_, err := srv.Save(artifactx.WithMetadata(ctx, map[string]string{piiartifact.FindingsMetadataKey: "none"}), &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: name, Part: part,
+		})
+		return err
+	}
+	load := func(name string) (string, map[string]string) {
+		t.Helper()
+		req := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: name}
+		resp, err := srv.Load(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		attrs, err := stater.Stat(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Part.InlineData != nil {
+			return string(resp.Part.InlineData.Data), attrs.Metadata
+		}
+		return resp.Part.Text, attrs.Metadata
+	}
+
+	part := genai.NewPartFromText("card 4111 1111 1111 1111, contact a@b.co")
+	if err := save("notes.txt", part); err != nil {
+		t.Fatal(err)
+	}
+	if part.Text != "card 4111 1111 1111 1111, contact a@b.co" {
+		t.Errorf("Save() changed the part of the caller to %q", part.Text)
+	}
+	text, md := load("notes.txt")
+	if text != "card [REDACTED:credit-card], contact a@b.co" || md[piiartifact.FindingsMetadataKey] != "credit-card,email" || md[piiartifact.RedactedMetadataKey] != "credit-card" {
+		t.Errorf("saved notes.txt = (%q, %v), want the card redacted and both kinds recorded", text, md)
+	}
+
+	if err := save("data.json", genai.NewPartFromBytes([]byte(`{"email":"a@b.co"}`), "application/json")); err != nil {
+		t.Fatal(err)
+	}
+	if text, md := load("data.json"); text != `{"email":"a@b.co"}` || md[piiartifact.FindingsMetadataKey] != "email" {
+		t.Errorf("saved data.json = (%q, %v), want it tagged", text, md)
+	}
+
+	if err := save("image.png", genai.NewPartFromBytes([]byte("a@b.co"), "image/png")); err != nil {
+		t.Fatal(err)
+	}
+	if _, md := load("image.png"); md[piiartifact.FindingsMetadataKey] != "none" {
+		t.Errorf("saved image.png has metadata %v, want binary content not scanned", md)
+	}
+
+	if err := save("ssn.txt", genai.NewPartFromText("ssn 123-45-6789")); !errors.Is(err, piiartifact.ErrBlocked) {
+		t.Errorf("Save() of an SSN error = %v, want %v", err, piiartifact.ErrBlocked)
+	}
+	if _, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "ssn.txt"}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Versions() of the blocked artifact = %v, want %v", err, fs.ErrNotExist)
+	}
+
+	failing := piiartifact.NewService(backend, piiartifact.Options{Scanners: []piiartifact.Scanner{
+		piiartifact.ScannerFunc(func(ctx context.Context, text string) ([]piiartifact.Finding, error) {
+			return nil, errors.New("dlp unavailable")
+		}),
+	}})
+	if _, err := failing.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "unscanned.txt", Part: genai.NewPartFromText("hello"),
+	}); err == nil {
+		t.Errorf("Save() with a failing scanner succeeded, want an error")
+	}
+}
+
+func TestServiceCapabilities(t *testing.T) {
+	ctx := t.Context()
+	backend, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := piiartifact.NewService(backend, piiartifact.Options{
+		Actions: map[string]piiartifact.Action{piiartifact.SSN: piiartifact.Block},
+	})
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "state.json",
+		Part: genai.NewPartFromBytes([]byte(`{"step":1}`), "application/json"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	update := func(patch string) (*artifact.SaveResponse, error) {
+		return artifactx.UpdateJSON(ctx, srv, &artifactx.UpdateJSONRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "state.json", Type: artifactx.MergePatch, Patch: []byte(patch),
+		})
+	}
+	stat := func(version int64) map[string]string {
+		t.Helper()
+		stater, _ := artifactx.As[artifactx.Stater](srv)
+		attrs, err := stater.Stat(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "state.json", Version: version})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return attrs.Metadata
+	}
+	if resp, err := update(`{"step":2}`); err != nil || resp.Version != 2 {
+		t.Fatalf("UpdateJSON() without findings = (%v, %v), want version 2", resp, err)
+	}
+	if md := stat(2); md[piiartifact.FindingsMetadataKey] != "" {
+		t.Errorf("Stat().Metadata = %v, want no findings", md)
+	}
+	if resp, err := update(`{"email":"a@b.co"}`); err != nil || resp.Version != 3 {
+		t.Fatalf("UpdateJSON() with an email = (%v, %v), want version 3", resp, err)
+	}
+	if md := stat(3); md[piiartifact.FindingsMetadataKey] != "email" {
+		t.Errorf("Stat().Metadata = %v, want the email tagged", md)
+	}
+	if _, err := update(`{"ssn":"123-45-6789"}`); !errors.Is(err, piiartifact.ErrBlocked) {
+		t.Errorf("UpdateJSON() with an SSN = %v, want %v", err, piiartifact.ErrBlocked)
+	}
+
+	u, ok := artifactx.As[artifactx.Uploader](srv)
+	if !ok {
+		t.Fatal("service does not implement artifactx.Uploader")
+	}
+	for _, mimeType := range []string{"text/csv", "application/json", ""} {
+		if _, err := u.BeginUpload(ctx, &artifactx.UploadRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "upload", MIMEType: mimeType,
+		}); !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("BeginUpload(%q) = %v, want %v", mimeType, err, errors.ErrUnsupported)
+		}
+	}
+	up, err := u.BeginUpload(ctx, &artifactx.UploadRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "image.png", MIMEType: "image/png",
+	})
+	if err != nil {
+		t.Fatalf("BeginUpload() of an image = %v", err)
+	}
+	if _, err := u.AppendChunk(ctx, up.ID, 0, []byte("png")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.CommitUpload(ctx, up.ID); err != nil {
+		t.Errorf("CommitUpload() of an image = %v", err)
+	}
+}
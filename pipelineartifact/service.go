@@ -0,0 +1,385 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pipelineartifact provides an [artifact.Service] decorator that runs
+// pipelines of processors on saved artifacts in the background, replacing
+// the goroutines callers would otherwise start around Save.
+//
+// A [Pipeline] applies to the saves of an app, of a media type, or both. Its
+// steps run in order, each receiving the part returned by the previous one,
+// and the result of the last step can be saved as a derived artifact. Steps
+// are retried with exponential backoff; runs whose step still fails are
+// kept as dead letters, which [Service.Retry] runs again. The progress of
+// every run is reported by [Service.Status].
+package pipelineartifact
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"mime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Processor transforms the part of an artifact version. Processors that only
+// have side effects return part unchanged.
+type Processor func(ctx context.Context, ref artifactx.Ref, part *genai.Part) (*genai.Part, error)
+
+// Step is a named processor of a pipeline.
+type Step struct {
+	Name    string
+	Process Processor
+	// Attempts is how often the step is tried before the run fails; zero
+	// means once.
+	Attempts int
+	// Backoff is the delay before the second attempt, doubled for every
+	// further one.
+	Backoff time.Duration
+}
+
+// Pipeline is an ordered list of steps run on saved versions.
+type Pipeline struct {
+	Name string
+	// AppName restricts the pipeline to the saves of an app; empty matches
+	// all apps.
+	AppName string
+	// MIMEType restricts the pipeline to saves of a media type, which may be
+	// a wildcard such as "image/*"; empty matches all types.
+	MIMEType string
+	Steps    []Step
+	// Output returns the name the result of the last step is saved as, in
+	// the session of the source. Nil discards the result. Outputs are saved
+	// to the decorated service, so they do not trigger pipelines themselves.
+	Output func(fileName string) string
+}
+
+func (p *Pipeline) matches(appName, contentType string) bool {
+	if p.AppName != "" && p.AppName != appName {
+		return false
+	}
+	if p.MIMEType == "" || p.MIMEType == "*/*" {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if major, ok := strings.CutSuffix(p.MIMEType, "/*"); ok {
+		return strings.HasPrefix(mediaType, major+"/")
+	}
+	return p.MIMEType == mediaType
+}
+
+// State is the state of a pipeline run.
+type State string
+
+const (
+	Pending   State = "pending"
+	Running   State = "running"
+	Succeeded State = "succeeded"
+	Failed    State = "failed"
+)
+
+// Status is the progress of a pipeline run on a version.
+type Status struct {
+	Pipeline string        `json:"pipeline"`
+	Ref      artifactx.Ref `json:"ref"`
+	State    State         `json:"state"`
+	// Step is the running step, or the failed one.
+	Step string `json:"step,omitempty"`
+	// Attempts is the number of attempts of Step so far.
+	Attempts int `json:"attempts,omitempty"`
+	// Err is the error of the last failed attempt.
+	Err string `json:"error,omitempty"`
+	// Output is the version the result was saved as, if the pipeline has an
+	// output.
+	Output   *artifactx.Ref `json:"output,omitempty"`
+	Started  time.Time      `json:"started,omitzero"`
+	Finished time.Time      `json:"finished,omitzero"`
+}
+
+// Options configures [NewService].
+type Options struct {
+	// Concurrency bounds the runs in progress at a time; zero means 4.
+	Concurrency int
+	// History bounds the finished runs whose status is kept, oldest first;
+	// zero means 1000. Dead letters are kept until retried.
+	History int
+	// Logger defaults to [slog.Default].
+	Logger *slog.Logger
+	// Clock defaults to [artifactx.SystemClock].
+	Clock artifactx.Clock
+}
+
+type runKey struct {
+	pipeline string
+	ref      artifactx.Ref
+}
+
+// Service is an [artifact.Service] running pipelines on saved versions.
+type Service struct {
+	artifact.Service
+	pipelines []Pipeline
+	history   int
+	logger    *slog.Logger
+	clock     artifactx.Clock
+	sem       chan struct{}
+	wg        sync.WaitGroup
+
+	mu       sync.Mutex
+	statuses map[runKey]*Status
+	finished []runKey
+	dead     map[runKey]bool
+}
+
+var _ artifactx.Wrapper = (*Service)(nil)
+
+// NewService returns a service that saves to next and runs the matching
+// pipelines on every saved version.
+func NewService(next artifact.Service, pipelines []Pipeline, opts Options) *Service {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	s := &Service{
+		Service:   next,
+		pipelines: pipelines,
+		history:   opts.History,
+		logger:    opts.Logger,
+		clock:     opts.Clock,
+		sem:       make(chan struct{}, concurrency),
+		statuses:  map[runKey]*Status{},
+		dead:      map[runKey]bool{},
+	}
+	if s.history <= 0 {
+		s.history = 1000
+	}
+	if s.logger == nil {
+		s.logger = slog.Default()
+	}
+	s.logger = s.logger.With("component", "pipelineartifact")
+	if s.clock == nil {
+		s.clock = artifactx.SystemClock
+	}
+	return s
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// Wait blocks until the runs started so far are done.
+func (s *Service) Wait() {
+	s.wg.Wait()
+}
+
+// Save implements [artifact.Service]. The matching pipelines are started once
+// the version is saved; their failures do not fail the Save.
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	resp, err := s.Service.Save(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	_, contentType, err := artifactx.EncodePart(req.Part)
+	if err != nil {
+		return resp, nil
+	}
+	ref := artifactx.Ref{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: resp.Version}
+	for i := range s.pipelines {
+		if p := &s.pipelines[i]; p.matches(req.AppName, contentType) {
+			s.start(ctx, p, ref, req.Part)
+		}
+	}
+	return resp, nil
+}
+
+// Status returns the status of the runs on the version ref, in the order of
+// the pipelines.
+func (s *Service) Status(ref artifactx.Ref) []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var statuses []Status
+	for _, p := range s.pipelines {
+		if st, ok := s.statuses[runKey{p.Name, ref}]; ok {
+			statuses = append(statuses, *st)
+		}
+	}
+	return statuses
+}
+
+// DeadLetters returns the status of the failed runs not retried since,
+// oldest failure first.
+func (s *Service) DeadLetters() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var statuses []Status
+	for key := range s.dead {
+		statuses = append(statuses, *s.statuses[key])
+	}
+	slices.SortFunc(statuses, func(a, b Status) int { return a.Finished.Compare(b.Finished) })
+	return statuses
+}
+
+// Retry runs the failed run of the named pipeline on ref again, from the
+// first step, with the version loaded from the decorated service.
+func (s *Service) Retry(ctx context.Context, pipeline string, ref artifactx.Ref) error {
+	key := runKey{pipeline, ref}
+	s.mu.Lock()
+	dead := s.dead[key]
+	s.mu.Unlock()
+	if !dead {
+		return fmt.Errorf("no failed run of pipeline %q on %s", pipeline, ref)
+	}
+	var p *Pipeline
+	for i := range s.pipelines {
+		if s.pipelines[i].Name == pipeline {
+			p = &s.pipelines[i]
+		}
+	}
+	resp, err := s.Service.Load(ctx, ref.LoadRequest())
+	if err != nil {
+		return err
+	}
+	s.start(ctx, p, ref, resp.Part)
+	return nil
+}
+
+// start records a pending run of p on ref and runs it in the background,
+// detached from the cancellation of ctx and with [artifactx.Background]
+// priority.
+func (s *Service) start(ctx context.Context, p *Pipeline, ref artifactx.Ref, part *genai.Part) {
+	key := runKey{p.Name, ref}
+	s.mu.Lock()
+	delete(s.dead, key)
+	s.statuses[key] = &Status{Pipeline: p.Name, Ref: ref, State: Pending}
+	s.mu.Unlock()
+
+	ctx = artifactx.WithPriority(context.WithoutCancel(ctx), artifactx.Background)
+	s.wg.Go(func() {
+		s.sem <- struct{}{}
+		defer func() { <-s.sem }()
+		s.run(ctx, p, key, part)
+	})
+}
+
+// update applies fn to the status of key.
+func (s *Service) update(key runKey, fn func(st *Status)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.statuses[key]; ok {
+		fn(st)
+	}
+}
+
+// finish records the end of the run of key and evicts the oldest finished
+// runs beyond the history.
+func (s *Service) finish(key runKey, fn func(st *Status)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.statuses[key]
+	if !ok {
+		return
+	}
+	fn(st)
+	st.Finished = s.clock.Now()
+	if st.State == Failed {
+		s.dead[key] = true
+		return
+	}
+	s.finished = append(s.finished, key)
+	for len(s.finished) > s.history {
+		old := s.finished[0]
+		s.finished = s.finished[1:]
+		if st, ok := s.statuses[old]; ok && st.State == Succeeded {
+			delete(s.statuses, old)
+		}
+	}
+}
+
+func (s *Service) run(ctx context.Context, p *Pipeline, key runKey, part *genai.Part) {
+	s.update(key, func(st *Status) {
+		st.State = Running
+		st.Started = s.clock.Now()
+	})
+	for _, step := range p.Steps {
+		out, err := s.runStep(ctx, key, step, part)
+		if err != nil {
+			s.logger.Warn("pipeline step failed", "pipeline", p.Name, "step", step.Name, "ref", key.ref.String(), "error", err)
+			s.finish(key, func(st *Status) {
+				st.State = Failed
+				st.Err = err.Error()
+			})
+			return
+		}
+		part = out
+	}
+
+	var output *artifactx.Ref
+	if p.Output != nil && part != nil {
+		ref := key.ref
+		ref.FileName = p.Output(ref.FileName)
+		resp, err := s.Service.Save(ctx, &artifact.SaveRequest{
+			AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName, Part: part,
+		})
+		if err != nil {
+			s.logger.Warn("pipeline output save failed", "pipeline", p.Name, "ref", key.ref.String(), "error", err)
+			s.finish(key, func(st *Status) {
+				st.State = Failed
+				st.Step = ""
+				st.Err = fmt.Sprintf("failed to save output: %v", err)
+			})
+			return
+		}
+		ref.Version = resp.Version
+		output = &ref
+	}
+	s.finish(key, func(st *Status) {
+		st.State = Succeeded
+		st.Step = ""
+		st.Attempts = 0
+		st.Err = ""
+		st.Output = output
+	})
+}
+
+// runStep runs step on part, retrying with exponential backoff.
+func (s *Service) runStep(ctx context.Context, key runKey, step Step, part *genai.Part) (*genai.Part, error) {
+	backoff := step.Backoff
+	for attempt := 1; ; attempt++ {
+		s.update(key, func(st *Status) {
+			st.Step = step.Name
+			st.Attempts = attempt
+		})
+		out, err := step.Process(ctx, key.ref, part)
+		if err == nil {
+			return out, nil
+		}
+		s.update(key, func(st *Status) { st.Err = err.Error() })
+		if attempt >= max(step.Attempts, 1) {
+			return nil, fmt.Errorf("step %q failed after %d attempts: %w", step.Name, attempt, err)
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipelineartifact_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/pipelineartifact"
+)
+
+func upper(ctx context.Context, ref artifactx.Ref, part *genai.Part) (*genai.Part, error) {
+	return genai.NewPartFromText(strings.ToUpper(part.Text)), nil
+}
+
+func suffix(s string) pipelineartifact.Processor {
+	return func(ctx context.Context, ref artifactx.Ref, part *genai.Part) (*genai.Part, error) {
+		return genai.NewPartFromText(part.Text + s), nil
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	ctx := t.Context()
+	mem := artifact.InMemoryService()
+	var flaky atomic.Int32
+	srv := pipelineartifact.NewService(mem, []pipelineartifact.Pipeline{
+		{
+			Name:     "shout",
+			MIMEType: "text/*",
+			Steps: []pipelineartifact.Step{
+				{Name: "upper", Process: upper},
+				{Name: "flaky", Attempts: 3, Process: func(ctx context.Context, ref artifactx.Ref, part *genai.Part) (*genai.Part, error) {
+					if flaky.Add(1) < 3 {
+						return nil, errors.New("transient")
+					}
+					return part, nil
+				}},
+				{Name: "suffix", Process: suffix("!")},
+			},
+			Output: func(fileName string) string { return fileName + ".loud" },
+		},
+		{Name: "images", MIMEType: "image/*", Steps: []pipelineartifact.Step{{Name: "noop", Process: upper}}},
+	}, pipelineartifact.Options{})
+
+	resp, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "note", Part: genai.NewPartFromText("hello"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Wait()
+
+	ref := artifactx.Ref{AppName: "app", UserID: "user", SessionID: "session", FileName: "note", Version: resp.Version}
+	statuses := srv.Status(ref)
+	if len(statuses) != 1 {
+		t.Fatalf("Status() = %+v, want only the text pipeline", statuses)
+	}
+	st := statuses[0]
+	if st.State != pipelineartifact.Succeeded || st.Output == nil || st.Finished.IsZero() {
+		t.Fatalf("Status() = %+v, want succeeded with an output", st)
+	}
+	out, err := mem.Load(ctx, st.Output.LoadRequest())
+	if err != nil {
+		t.Fatalf("Load(output) failed: %v", err)
+	}
+	if out.Part.Text != "HELLO!" {
+		t.Errorf("output = %q, want HELLO!", out.Part.Text)
+	}
+	if got := flaky.Load(); got != 3 {
+		t.Errorf("flaky step ran %d times, want 3", got)
+	}
+}
+
+func TestPipelineDeadLetter(t *testing.T) {
+	ctx := t.Context()
+	var broken atomic.Bool
+	broken.Store(true)
+	srv := pipelineartifact.NewService(artifact.InMemoryService(), []pipelineartifact.Pipeline{{
+		Name:    "fragile",
+		AppName: "app",
+		Steps: []pipelineartifact.Step{{Name: "check", Attempts: 2, Process: func(ctx context.Context, ref artifactx.Ref, part *genai.Part) (*genai.Part, error) {
+			if broken.Load() {
+				return nil, errors.New("downstream unavailable")
+			}
+			return part, nil
+		}}},
+	}}, pipelineartifact.Options{})
+
+	for _, app := range []string{"app", "other"} {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: app, UserID: "user", SessionID: "session", FileName: "data", Part: genai.NewPartFromText("x"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv.Wait()
+
+	dead := srv.DeadLetters()
+	if len(dead) != 1 || dead[0].Ref.AppName != "app" || dead[0].Step != "check" || dead[0].Attempts != 2 || !strings.Contains(dead[0].Err, "downstream unavailable") {
+		t.Fatalf("DeadLetters() = %+v, want the failed run of app", dead)
+	}
+
+	broken.Store(false)
+	if err := srv.Retry(ctx, "fragile", dead[0].Ref); err != nil {
+		t.Fatalf("Retry() failed: %v", err)
+	}
+	srv.Wait()
+	if st := srv.Status(dead[0].Ref); len(st) != 1 || st[0].State != pipelineartifact.Succeeded {
+		t.Errorf("Status() after Retry() = %+v, want succeeded", st)
+	}
+	if dead := srv.DeadLetters(); len(dead) != 0 {
+		t.Errorf("DeadLetters() after Retry() = %+v, want none", dead)
+	}
+	if err := srv.Retry(ctx, "fragile", dead[0].Ref); err == nil {
+		t.Error("Retry() of a succeeded run succeeded")
+	}
+}
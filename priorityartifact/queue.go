@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priorityartifact
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// queue hands out a bounded number of slots, always to waiting foreground
+// requests before background ones.
+type queue struct {
+	slots, background int
+
+	mu      sync.Mutex
+	running [2]int // by priority
+	waiting [2][]chan struct{}
+}
+
+// admits reports whether a request of priority p can run now. It must be
+// called with q.mu held.
+func (q *queue) admits(p artifactx.Priority) bool {
+	if q.running[artifactx.Foreground]+q.running[artifactx.Background] >= q.slots {
+		return false
+	}
+	if p == artifactx.Foreground {
+		return true
+	}
+	return len(q.waiting[artifactx.Foreground]) == 0 && q.running[artifactx.Background] < q.background
+}
+
+// acquire blocks until a request of priority p can run and returns the
+// function releasing its slot.
+func (q *queue) acquire(ctx context.Context, p artifactx.Priority) (func(), error) {
+	q.mu.Lock()
+	if len(q.waiting[p]) == 0 && q.admits(p) {
+		q.running[p]++
+		q.mu.Unlock()
+		return q.releaser(p), nil
+	}
+	ready := make(chan struct{})
+	q.waiting[p] = append(q.waiting[p], ready)
+	q.mu.Unlock()
+
+	select {
+	case <-ready:
+		return q.releaser(p), nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		select {
+		case <-ready:
+			// Admitted meanwhile: hand the slot over.
+			q.running[p]--
+			q.dispatch()
+		default:
+			for i, c := range q.waiting[p] {
+				if c == ready {
+					q.waiting[p] = append(q.waiting[p][:i:i], q.waiting[p][i+1:]...)
+					break
+				}
+			}
+			// Background requests may have waited on this one.
+			q.dispatch()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (q *queue) releaser(p artifactx.Priority) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			q.running[p]--
+			q.dispatch()
+		})
+	}
+}
+
+// dispatch admits waiting requests, foreground ones first. It must be
+// called with q.mu held.
+func (q *queue) dispatch() {
+	for _, p := range []artifactx.Priority{artifactx.Foreground, artifactx.Background} {
+		for len(q.waiting[p]) > 0 && q.admits(p) {
+			close(q.waiting[p][0])
+			q.waiting[p] = q.waiting[p][1:]
+			q.running[p]++
+		}
+	}
+}
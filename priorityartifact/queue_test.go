@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priorityartifact
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// waitQueued waits until n requests of priority p wait in q.
+func waitQueued(t *testing.T, q *queue, p artifactx.Priority, n int) {
+	t.Helper()
+	var got int
+	for range 1000 {
+		q.mu.Lock()
+		got = len(q.waiting[p])
+		q.mu.Unlock()
+		if got == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%d %s requests waiting, want %d", got, p, n)
+}
+
+func TestQueueForegroundFirst(t *testing.T) {
+	ctx := t.Context()
+	q := &queue{slots: 1, background: 1}
+	release, err := q.acquire(ctx, artifactx.Background)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu    sync.Mutex
+		order []artifactx.Priority
+		wg    sync.WaitGroup
+	)
+	run := func(p artifactx.Priority) {
+		wg.Go(func() {
+			release, err := q.acquire(ctx, p)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			order = append(order, p)
+			mu.Unlock()
+			release()
+		})
+	}
+	// The background request queues first, the foreground one overtakes it.
+	run(artifactx.Background)
+	waitQueued(t, q, artifactx.Background, 1)
+	run(artifactx.Foreground)
+	waitQueued(t, q, artifactx.Foreground, 1)
+	release()
+	wg.Wait()
+	if len(order) != 2 || order[0] != artifactx.Foreground {
+		t.Errorf("admission order = %v, want foreground first", order)
+	}
+}
+
+func TestQueueBackgroundSlots(t *testing.T) {
+	ctx := t.Context()
+	q := &queue{slots: 3, background: 1}
+	release, err := q.acquire(ctx, artifactx.Background)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := q.acquire(short, artifactx.Background); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire() beyond the background slots = %v, want %v", err, context.DeadlineExceeded)
+	}
+	waitQueued(t, q, artifactx.Background, 0)
+	for range 2 {
+		release, err := q.acquire(ctx, artifactx.Foreground)
+		if err != nil {
+			t.Fatalf("acquire() of a foreground slot failed: %v", err)
+		}
+		defer release()
+	}
+	if q.running != [2]int{2, 1} {
+		t.Errorf("running = %v, want 2 foreground and 1 background", q.running)
+	}
+}
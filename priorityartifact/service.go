@@ -0,0 +1,168 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package priorityartifact provides an [artifact.Service] decorator that
+// schedules requests sharing a backend by priority, so that interactive
+// Saves and Loads are served before background maintenance such as
+// retention pruning, backups and replication.
+//
+//	srv := priorityartifact.NewService(backend, priorityartifact.Options{Slots: 8})
+//	// in a maintenance job
+//	ctx = artifactx.WithPriority(ctx, artifactx.Background)
+//
+// Requests run within a bounded number of slots. A free slot always goes to
+// a waiting foreground request first, and background requests never hold
+// more than [Options.Background] slots, so the others stay available to
+// foreground traffic. A background request that is running is not
+// interrupted.
+package priorityartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// DefaultSlots is the number of concurrent requests if [Options.Slots] is zero.
+const DefaultSlots = 8
+
+// Options configures [NewService].
+type Options struct {
+	// Slots bounds the requests running on the decorated service at once;
+	// zero means [DefaultSlots].
+	Slots int
+	// Background bounds the slots held by background requests; zero means
+	// one. With more than one slot it is capped to Slots-1, so that a slot
+	// is always left to foreground requests.
+	Background int
+}
+
+// Service is an [artifact.Service] that schedules requests by the
+// [artifactx.Priority] of their context.
+type Service struct {
+	artifact.Service
+	queue *queue
+}
+
+var (
+	_ artifactx.Wrapper = (*Service)(nil)
+	_ artifactx.Opener  = (*Service)(nil)
+)
+
+// NewService returns a service that forwards to next, running at most
+// opts.Slots requests at once.
+func NewService(next artifact.Service, opts Options) *Service {
+	slots := opts.Slots
+	if slots <= 0 {
+		slots = DefaultSlots
+	}
+	background := max(opts.Background, 1)
+	background = min(background, max(slots-1, 1))
+	return &Service{Service: next, queue: &queue{slots: slots, background: background}}
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// acquire waits for a slot for a request made with ctx.
+func (s *Service) acquire(ctx context.Context) (func(), error) {
+	return s.queue.acquire(ctx, artifactx.PriorityFrom(ctx))
+}
+
+// Save implements [artifact.Service].
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.Service.Save(ctx, req)
+}
+
+// Load implements [artifact.Service].
+func (s *Service) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.Service.Load(ctx, req)
+}
+
+// Delete implements [artifact.Service].
+func (s *Service) Delete(ctx context.Context, req *artifact.DeleteRequest) error {
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return s.Service.Delete(ctx, req)
+}
+
+// List implements [artifact.Service].
+func (s *Service) List(ctx context.Context, req *artifact.ListRequest) (*artifact.ListResponse, error) {
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.Service.List(ctx, req)
+}
+
+// Versions implements [artifact.Service].
+func (s *Service) Versions(ctx context.Context, req *artifact.VersionsRequest) (*artifact.VersionsResponse, error) {
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.Service.Versions(ctx, req)
+}
+
+// Open implements [artifactx.Opener]. The slot is held until the reader is
+// closed, since reading is where the I/O happens.
+func (s *Service) Open(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Reader, error) {
+	opener, ok := artifactx.As[artifactx.Opener](s.Service)
+	if !ok {
+		return nil, fmt.Errorf("open: %w", errors.ErrUnsupported)
+	}
+	release, err := s.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r, err := opener.Open(ctx, req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &artifactx.Reader{ReadCloser: &reader{ReadCloser: r.ReadCloser, release: release}, Attributes: r.Attributes}, nil
+}
+
+// reader releases the slot of an Open when closed.
+type reader struct {
+	io.ReadCloser
+	release func()
+}
+
+func (r *reader) Close() error {
+	defer r.release()
+	return r.ReadCloser.Close()
+}
@@ -0,0 +1,35 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priorityartifact_test
+
+import (
+	"testing"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"github.com/chinglinwen/adk-artifact/priorityartifact"
+	"github.com/chinglinwen/adk-artifact/tests"
+)
+
+func TestPriorityArtifactService(t *testing.T) {
+	tests.TestArtifactService(t, "Priority", func(t *testing.T) (artifact.Service, error) {
+		next, err := fsartifact.NewService(t.TempDir())
+		if err != nil {
+			return nil, err
+		}
+		return priorityartifact.NewService(next, priorityartifact.Options{Slots: 2}), nil
+	})
+}
@@ -0,0 +1,170 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package promartifact provides an [artifact.Service] decorator that exposes
+// Prometheus metrics about the operations of the decorated service.
+//
+// The following metrics are registered, all labelled by operation (save,
+// load, delete, list or versions):
+//
+//	adk_artifact_operations_total{operation,code}  completed operations; code is "ok" or an error class
+//	adk_artifact_operation_duration_seconds        operation latency
+//	adk_artifact_payload_bytes                     size of saved and loaded artifacts
+//	adk_artifact_in_flight_operations              operations in progress
+package promartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+const namespace = "adk_artifact"
+
+// Service is an [artifact.Service] that records Prometheus metrics.
+type Service struct {
+	artifact.Service
+	operations *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+	payload    *prometheus.HistogramVec
+	inFlight   *prometheus.GaugeVec
+}
+
+var _ artifactx.Wrapper = (*Service)(nil)
+
+// NewService returns a service that forwards to next and registers its
+// metrics on reg. It fails if the metrics are already registered on reg.
+func NewService(next artifact.Service, reg prometheus.Registerer) (*Service, error) {
+	s := &Service{
+		Service: next,
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "operations_total",
+			Help:      "Number of completed artifact operations by result code.",
+		}, []string{"operation", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "operation_duration_seconds",
+			Help:      "Latency of artifact operations.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 9), // 1ms to ~65s
+		}, []string{"operation"}),
+		payload: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "payload_bytes",
+			Help:      "Size of saved and loaded artifacts.",
+			Buckets:   prometheus.ExponentialBuckets(1<<10, 4, 10), // 1KiB to 256MiB
+		}, []string{"operation"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "in_flight_operations",
+			Help:      "Number of artifact operations in progress.",
+		}, []string{"operation"}),
+	}
+	for _, c := range []prometheus.Collector{s.operations, s.duration, s.payload, s.inFlight} {
+		if err := reg.Register(c); err != nil {
+			return nil, fmt.Errorf("failed to register metrics: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// errorCode classifies err for the code label, keeping its cardinality low.
+func errorCode(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, fs.ErrNotExist):
+		return "not_found"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	default:
+		return "error"
+	}
+}
+
+// observe starts recording an operation and returns the function that ends it.
+func (s *Service) observe(operation string) func(error) {
+	start := time.Now()
+	inFlight := s.inFlight.WithLabelValues(operation)
+	inFlight.Inc()
+	return func(err error) {
+		inFlight.Dec()
+		s.duration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+		s.operations.WithLabelValues(operation, errorCode(err)).Inc()
+	}
+}
+
+// observeSize records the encoded size of a saved or loaded artifact.
+func (s *Service) observeSize(operation string, data func() ([]byte, string, error)) {
+	if b, _, err := data(); err == nil {
+		s.payload.WithLabelValues(operation).Observe(float64(len(b)))
+	}
+}
+
+// Save implements [artifact.Service].
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (_ *artifact.SaveResponse, err error) {
+	done := s.observe("save")
+	defer func() { done(err) }()
+	resp, err := s.Service.Save(ctx, req)
+	if err == nil {
+		s.observeSize("save", func() ([]byte, string, error) { return artifactx.EncodePart(req.Part) })
+	}
+	return resp, err
+}
+
+// Load implements [artifact.Service].
+func (s *Service) Load(ctx context.Context, req *artifact.LoadRequest) (_ *artifact.LoadResponse, err error) {
+	done := s.observe("load")
+	defer func() { done(err) }()
+	resp, err := s.Service.Load(ctx, req)
+	if err == nil {
+		s.observeSize("load", func() ([]byte, string, error) { return artifactx.EncodePart(resp.Part) })
+	}
+	return resp, err
+}
+
+// Delete implements [artifact.Service].
+func (s *Service) Delete(ctx context.Context, req *artifact.DeleteRequest) (err error) {
+	done := s.observe("delete")
+	defer func() { done(err) }()
+	return s.Service.Delete(ctx, req)
+}
+
+// List implements [artifact.Service].
+func (s *Service) List(ctx context.Context, req *artifact.ListRequest) (_ *artifact.ListResponse, err error) {
+	done := s.observe("list")
+	defer func() { done(err) }()
+	return s.Service.List(ctx, req)
+}
+
+// Versions implements [artifact.Service].
+func (s *Service) Versions(ctx context.Context, req *artifact.VersionsRequest) (_ *artifact.VersionsResponse, err error) {
+	done := s.observe("versions")
+	defer func() { done(err) }()
+	return s.Service.Versions(ctx, req)
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package promartifact_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/promartifact"
+)
+
+func TestMetrics(t *testing.T) {
+	ctx := t.Context()
+	reg := prometheus.NewPedanticRegistry()
+	srv, err := promartifact.NewService(artifact.InMemoryService(), reg)
+	if err != nil {
+		t.Fatalf("NewService() failed: %v", err)
+	}
+
+	for range 2 {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+			Part: genai.NewPartFromBytes(make([]byte, 2048), "application/octet-stream"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := srv.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "missing"}); err == nil {
+		t.Fatal("Load(missing) succeeded")
+	}
+
+	want := `
+# HELP adk_artifact_operations_total Number of completed artifact operations by result code.
+# TYPE adk_artifact_operations_total counter
+adk_artifact_operations_total{code="not_found",operation="load"} 1
+adk_artifact_operations_total{code="ok",operation="load"} 1
+adk_artifact_operations_total{code="ok",operation="save"} 2
+# HELP adk_artifact_in_flight_operations Number of artifact operations in progress.
+# TYPE adk_artifact_in_flight_operations gauge
+adk_artifact_in_flight_operations{operation="load"} 0
+adk_artifact_in_flight_operations{operation="save"} 0
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "adk_artifact_operations_total", "adk_artifact_in_flight_operations"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(reg, "adk_artifact_payload_bytes"); n != 2 {
+		t.Errorf("payload_bytes has %d series, want save and load", n)
+	}
+	if n := testutil.CollectAndCount(reg, "adk_artifact_operation_duration_seconds"); n != 2 {
+		t.Errorf("operation_duration_seconds has %d series, want save and load", n)
+	}
+
+	if _, err := promartifact.NewService(artifact.InMemoryService(), reg); err == nil {
+		t.Error("NewService() on the same registry succeeded, want already registered error")
+	}
+}
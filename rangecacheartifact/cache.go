@@ -0,0 +1,207 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rangecacheartifact
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// diskCache holds blocks in files below dir, evicting the least recently
+// used ones once they take more than max bytes. Files are touched when
+// read, so that the order of use survives restarts.
+type diskCache struct {
+	dir string
+	max int64
+
+	mu      sync.Mutex
+	lru     *list.List // of *entry, most recently used first
+	entries map[string]*list.Element
+	size    int64
+}
+
+type entry struct {
+	name string
+	size int64
+}
+
+// openCache returns the cache in dir, creating dir if needed and indexing
+// the blocks it holds. Temporary files left by interrupted writes are
+// removed.
+func openCache(dir string, max int64) (*diskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("could not create cache directory '%s': %w", dir, err)
+	}
+	c := &diskCache{dir: dir, max: max, lru: list.New(), entries: map[string]*list.Element{}}
+	type file struct {
+		name string
+		size int64
+		mod  time.Time
+	}
+	var files []file
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if strings.HasSuffix(path, ".tmp") {
+			return os.Remove(path)
+		}
+		if name := d.Name(); len(name) < 2 || filepath.Base(filepath.Dir(path)) != name[:2] {
+			return nil // not a block
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, file{d.Name(), info.Size(), info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not index cache directory '%s': %w", dir, err)
+	}
+	slices.SortFunc(files, func(a, b file) int { return b.mod.Compare(a.mod) })
+	for _, f := range files {
+		c.entries[f.name] = c.lru.PushBack(&entry{f.name, f.size})
+		c.size += f.size
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.evictLocked()
+	return c, nil
+}
+
+// path returns the file of the block name, in a subdirectory named after
+// its first two characters.
+func (c *diskCache) path(name string) string {
+	return filepath.Join(c.dir, name[:2], name)
+}
+
+// get returns the block name if it is cached with size bytes.
+func (c *diskCache) get(name string, size int64) ([]byte, bool) {
+	c.mu.Lock()
+	e, ok := c.entries[name]
+	if ok {
+		c.lru.MoveToFront(e)
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	path := c.path(name)
+	data, err := os.ReadFile(path)
+	if err != nil || int64(len(data)) != size {
+		// evicted since, or damaged
+		c.remove(name)
+		return nil, false
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return data, true
+}
+
+// has reports whether the block name is cached.
+func (c *diskCache) has(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.entries[name]
+	return ok
+}
+
+// put caches data as the block name. Failures only cost a later miss, so
+// they are not reported.
+func (c *diskCache) put(name string, data []byte) {
+	if int64(len(data)) > c.max {
+		return
+	}
+	path := c.path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), name+"-*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(data)
+	err = errors.Join(err, f.Close())
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[name]; ok {
+		c.size -= e.Value.(*entry).size
+		c.lru.Remove(e)
+	}
+	c.entries[name] = c.lru.PushFront(&entry{name, int64(len(data))})
+	c.size += int64(len(data))
+	c.evictLocked()
+}
+
+// remove drops the block name.
+func (c *diskCache) remove(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[name]; ok {
+		c.removeLocked(e)
+	}
+}
+
+func (c *diskCache) removeLocked(e *list.Element) {
+	ent := e.Value.(*entry)
+	c.lru.Remove(e)
+	delete(c.entries, ent.name)
+	c.size -= ent.size
+	os.Remove(c.path(ent.name))
+}
+
+// evictLocked removes the least recently used blocks until the cache fits
+// in max bytes.
+func (c *diskCache) evictLocked() {
+	for c.size > c.max {
+		c.removeLocked(c.lru.Back())
+	}
+}
+
+// clear removes every block and returns the number of blocks and bytes
+// removed.
+func (c *diskCache) clear() (int, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	blocks, size := c.lru.Len(), c.size
+	for c.lru.Len() > 0 {
+		c.removeLocked(c.lru.Back())
+	}
+	return blocks, size
+}
+
+// usage returns the number of blocks and bytes cached.
+func (c *diskCache) usage() (int, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len(), c.size
+}
@@ -0,0 +1,70 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rangecacheartifact
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Prefetch implements [artifactx.Prefetcher]. Up to
+// [Options.PrefetchConcurrency] versions are read at once, the others wait
+// their turn; a ref already being prefetched is skipped. Versions larger
+// than the cache are not read.
+func (s *Service) Prefetch(ctx context.Context, refs ...artifactx.Ref) {
+	for _, ref := range refs {
+		s.mu.Lock()
+		running := s.prefetching[ref]
+		s.prefetching[ref] = true
+		s.mu.Unlock()
+		if running {
+			continue
+		}
+		go func() {
+			err := s.prefetch(ctx, ref)
+			s.mu.Lock()
+			delete(s.prefetching, ref)
+			s.mu.Unlock()
+			if s.onPrefetch != nil {
+				s.onPrefetch(ref, err)
+			}
+		}()
+	}
+}
+
+// prefetch reads the version ref into the cache.
+func (s *Service) prefetch(ctx context.Context, ref artifactx.Ref) error {
+	select {
+	case s.prefetchSem <- struct{}{}:
+		defer func() { <-s.prefetchSem }()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	r, err := s.OpenRange(ctx, ref.LoadRequest(), 0, -1)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if r.Size > s.cache.max {
+		return fmt.Errorf("artifact '%s' version %d of %d bytes is larger than the cache", ref.FileName, r.Version, r.Size)
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return fmt.Errorf("failed to read artifact '%s' version %d: %w", ref.FileName, r.Version, err)
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rangecacheartifact provides an [artifact.Service] decorator that
+// keeps recently read byte ranges of artifacts on local disk, so that
+// repeated partial reads of large objects, such as an agent seeking within
+// a CSV stored in S3, are served without downloading them again:
+//
+//	srv, err := rangecacheartifact.NewService(backend, rangecacheartifact.Options{
+//		Dir:      "/var/cache/adk-artifacts",
+//		MaxBytes: 10 << 30,
+//	})
+//
+// OpenRange, and so [artifactx.OpenRange] and [artifactx.Preview], reads
+// versions in blocks of [Options.BlockSize] bytes. Each block is cached in
+// a file named after the hash of the artifact, its version, ETag and size,
+// followed by the range of the block, so that versions saved again are
+// never served stale. Consecutive blocks missing from the cache are
+// downloaded with a single ranged read. The least recently used blocks
+// are evicted once the cache takes more than [Options.MaxBytes].
+//
+// [Service.Prefetch] pulls whole versions into the cache in the background
+// before they are read.
+//
+// The decorated service must implement [artifactx.Stater] and
+// [artifactx.RangeOpener]; otherwise OpenRange is forwarded uncached. Load
+// and Open read whole versions and are not cached.
+package rangecacheartifact
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"sync"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Defaults of [Options].
+const (
+	DefaultMaxBytes            = 1 << 30
+	DefaultBlockSize           = 1 << 20
+	DefaultPrefetchConcurrency = 4
+)
+
+// maxRunBlocks bounds the blocks downloaded with a single ranged read.
+const maxRunBlocks = 16
+
+// Options configures [NewService].
+type Options struct {
+	// Dir is the directory of the cache, created if needed. Blocks cached
+	// in it by an earlier process are used.
+	Dir string
+	// MaxBytes bounds the size of the cache; zero means [DefaultMaxBytes].
+	MaxBytes int64
+	// BlockSize is the size of the cached blocks; zero means
+	// [DefaultBlockSize].
+	BlockSize int64
+	// PrefetchConcurrency is the number of versions [Service.Prefetch]
+	// reads at once; zero means [DefaultPrefetchConcurrency].
+	PrefetchConcurrency int
+	// OnPrefetch, if set, is called when the prefetch of a version ends,
+	// with the error that stopped it, if any.
+	OnPrefetch func(ref artifactx.Ref, err error)
+}
+
+// Service is an [artifact.Service] that caches byte ranges on disk.
+type Service struct {
+	artifact.Service
+	cache      *diskCache
+	blockSize  int64
+	onPrefetch func(artifactx.Ref, error)
+	// prefetchSem bounds the running prefetches; prefetching holds the
+	// refs being prefetched.
+	prefetchSem chan struct{}
+	mu          sync.Mutex
+	prefetching map[artifactx.Ref]bool
+}
+
+var (
+	_ artifactx.Wrapper     = (*Service)(nil)
+	_ artifactx.Opener      = (*Service)(nil)
+	_ artifactx.RangeOpener = (*Service)(nil)
+	_ artifactx.Prefetcher  = (*Service)(nil)
+)
+
+// NewService returns a service that caches the byte ranges read from next
+// below opts.Dir.
+func NewService(next artifact.Service, opts Options) (*Service, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("cache directory is required")
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	if opts.BlockSize <= 0 {
+		opts.BlockSize = DefaultBlockSize
+	}
+	if opts.PrefetchConcurrency <= 0 {
+		opts.PrefetchConcurrency = DefaultPrefetchConcurrency
+	}
+	cache, err := openCache(opts.Dir, opts.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{
+		Service:     next,
+		cache:       cache,
+		blockSize:   opts.BlockSize,
+		onPrefetch:  opts.OnPrefetch,
+		prefetchSem: make(chan struct{}, opts.PrefetchConcurrency),
+		prefetching: map[artifactx.Ref]bool{},
+	}, nil
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// Usage returns the number of blocks and bytes in the cache.
+func (s *Service) Usage() (blocks int, bytes int64) {
+	return s.cache.usage()
+}
+
+// Flush empties the cache and returns the number of blocks and bytes
+// removed. Blocks read afterwards are downloaded and cached again.
+func (s *Service) Flush() (blocks int, bytes int64) {
+	return s.cache.clear()
+}
+
+// Open implements [artifactx.Opener] by forwarding to the decorated
+// service.
+func (s *Service) Open(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Reader, error) {
+	return artifactx.Open(ctx, s.Service, req)
+}
+
+// OpenRange implements [artifactx.RangeOpener]. The blocks of the range
+// missing from the cache are downloaded and cached as they are read.
+func (s *Service) OpenRange(ctx context.Context, req *artifact.LoadRequest, offset, length int64) (*artifactx.Reader, error) {
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid offset %d", offset)
+	}
+	stater, ok := artifactx.As[artifactx.Stater](s.Service)
+	if !ok {
+		return artifactx.OpenRange(ctx, s.Service, req, offset, length)
+	}
+	opener, _ := artifactx.As[artifactx.Opener](s.Service)
+	ro, ok := opener.(artifactx.RangeOpener)
+	if !ok {
+		return artifactx.OpenRange(ctx, s.Service, req, offset, length)
+	}
+	attrs, err := stater.Stat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	end := attrs.Size
+	if length >= 0 && offset+length < end {
+		end = offset + length
+	}
+	versioned := &artifact.LoadRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: attrs.Version,
+	}
+	return &artifactx.Reader{
+		ReadCloser: &rangeReader{
+			ctx: ctx, s: s, ro: ro, req: versioned, attrs: attrs,
+			prefix: versionHash(versioned, attrs), pos: offset, end: end,
+		},
+		Attributes: *attrs,
+	}, nil
+}
+
+// versionHash identifies the content of a version in the names of its
+// blocks.
+func versionHash(req *artifact.LoadRequest, attrs *artifactx.Attributes) string {
+	h := sha256.New()
+	for _, s := range []string{
+		req.AppName, req.UserID, req.SessionID, req.FileName, strconv.FormatInt(attrs.Version, 10),
+		attrs.ETag, strconv.FormatInt(attrs.Size, 10), strconv.FormatInt(attrs.ModTime.UnixNano(), 10),
+	} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// rangeReader reads the bytes from pos to end of a version block by
+// block.
+type rangeReader struct {
+	ctx    context.Context
+	s      *Service
+	ro     artifactx.RangeOpener
+	req    *artifact.LoadRequest
+	attrs  *artifactx.Attributes
+	prefix string
+
+	pos, end int64
+	// buf holds the blocks read last, starting at offset bufStart.
+	buf      []byte
+	bufStart int64
+	err      error
+}
+
+// block returns the offset and size of block i.
+func (r *rangeReader) block(i int64) (int64, int64) {
+	start := i * r.s.blockSize
+	return start, min(r.s.blockSize, r.attrs.Size-start)
+}
+
+func (r *rangeReader) blockName(i int64) string {
+	start, size := r.block(i)
+	return r.prefix + "-" + strconv.FormatInt(start, 10) + "-" + strconv.FormatInt(size, 10)
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if r.pos >= r.end {
+		return 0, io.EOF
+	}
+	if r.pos < r.bufStart || r.pos >= r.bufStart+int64(len(r.buf)) {
+		if r.err = r.fill(r.pos / r.s.blockSize); r.err != nil {
+			return 0, r.err
+		}
+	}
+	avail := r.buf[r.pos-r.bufStart : min(int64(len(r.buf)), r.end-r.bufStart)]
+	n := copy(p, avail)
+	r.pos += int64(n)
+	return n, nil
+}
+
+// fill reads block i into buf, from the cache if it holds it, and
+// otherwise along with the following blocks of the range missing from the
+// cache.
+func (r *rangeReader) fill(i int64) error {
+	start, size := r.block(i)
+	if data, ok := r.s.cache.get(r.blockName(i), size); ok {
+		r.buf, r.bufStart = data, start
+		return nil
+	}
+	last := (r.end - 1) / r.s.blockSize
+	n := int64(1)
+	for n < maxRunBlocks && i+n <= last && !r.s.cache.has(r.blockName(i+n)) {
+		n++
+	}
+	runEnd, runSize := r.block(i + n - 1)
+	runEnd += runSize
+
+	rd, err := r.ro.OpenRange(r.ctx, r.req, start, runEnd-start)
+	if err != nil {
+		return err
+	}
+	defer rd.Close()
+	if rd.ETag != r.attrs.ETag || rd.Size != r.attrs.Size {
+		return fmt.Errorf("artifact '%s' version %d changed while read", r.req.FileName, r.req.Version)
+	}
+	data := make([]byte, runEnd-start)
+	if _, err := io.ReadFull(rd, data); err != nil {
+		return fmt.Errorf("failed to read artifact '%s' version %d at %d: %w", r.req.FileName, r.req.Version, start, err)
+	}
+	for j := range n {
+		bstart, bsize := r.block(i + j)
+		r.s.cache.put(r.blockName(i+j), data[bstart-start:bstart-start+bsize])
+	}
+	r.buf, r.bufStart = data, start
+	return nil
+}
+
+func (r *rangeReader) Close() error {
+	r.err = fs.ErrClosed
+	return nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rangecacheartifact_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"github.com/chinglinwen/adk-artifact/rangecacheartifact"
+)
+
+// countingService counts the ranged reads of the service it decorates.
+type countingService struct {
+	artifact.Service
+	reads int
+	bytes int64
+}
+
+func (s *countingService) Unwrap() artifact.Service { return s.Service }
+
+func (s *countingService) Open(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Reader, error) {
+	return artifactx.Open(ctx, s.Service, req)
+}
+
+func (s *countingService) OpenRange(ctx context.Context, req *artifact.LoadRequest, offset, length int64) (*artifactx.Reader, error) {
+	s.reads++
+	s.bytes += length
+	return artifactx.OpenRange(ctx, s.Service, req, offset, length)
+}
+
+func TestService(t *testing.T) {
+	ctx := t.Context()
+	fsSrv, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := &countingService{Service: fsSrv}
+	dir := t.TempDir()
+	srv, err := rangecacheartifact.NewService(backend, rangecacheartifact.Options{Dir: dir, BlockSize: 1024, MaxBytes: 8 << 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, 10000)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	req := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "data.csv"}
+	save := func(data []byte) {
+		t.Helper()
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Part: genai.NewPartFromBytes(data, "text/csv")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	read := func(srv artifact.Service, offset, length int64) []byte {
+		t.Helper()
+		r, err := artifactx.OpenRange(ctx, srv, req, offset, length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	check := func(srv artifact.Service, data []byte, offset, length int64, reads int, n int64) {
+		t.Helper()
+		backend.reads, backend.bytes = 0, 0
+		want := data[offset:min(offset+length, int64(len(data)))]
+		if got := read(srv, offset, length); !bytes.Equal(got, want) {
+			t.Errorf("OpenRange(%d, %d) read %d bytes, want %d bytes of the version", offset, length, len(got), len(want))
+		}
+		if backend.reads != reads || backend.bytes != n {
+			t.Errorf("OpenRange(%d, %d) read (%d ranges, %d bytes) from the backend, want (%d, %d)", offset, length, backend.reads, backend.bytes, reads, n)
+		}
+	}
+	save(data)
+
+	check(srv, data, 2000, 1500, 1, 3072) // blocks 1 to 3
+	check(srv, data, 2000, 1500, 0, 0)    // cached
+	check(srv, data, 3500, 1000, 1, 1024) // block 4 only
+	check(srv, data, 9500, 1000, 1, 784)  // short last block
+	check(srv, data, 0, 10, 1, 1024)      // block 0
+	check(srv, data, 10000, 10, 0, 0)     // past the end
+	if blocks, size := srv.Usage(); blocks != 6 || size != 5*1024+784 {
+		t.Errorf("Usage() = (%d, %d), want (6, %d)", blocks, size, 5*1024+784)
+	}
+
+	// A new version is not served from the blocks of the previous one.
+	edited := bytes.Clone(data)
+	edited[2500] = 'x'
+	save(edited)
+	check(srv, edited, 2000, 1500, 1, 3072)
+
+	// Blocks survive restarts, and the least recently used are evicted.
+	reopened, err := rangecacheartifact.NewService(backend, rangecacheartifact.Options{Dir: dir, BlockSize: 1024, MaxBytes: 8 << 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blocks, size := reopened.Usage(); blocks != 8 || size > 8<<10 {
+		t.Errorf("Usage() after reopening = (%d, %d), want 8 blocks within %d bytes", blocks, size, 8<<10)
+	}
+	check(reopened, edited, 2000, 1500, 0, 0)
+	check(reopened, edited, 5000, 5000, 1, 10000-4096) // blocks 4 to 9
+}
+
+func TestPrefetch(t *testing.T) {
+	ctx := t.Context()
+	fsSrv, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := &countingService{Service: fsSrv}
+	done := make(chan error, 3)
+	srv, err := rangecacheartifact.NewService(backend, rangecacheartifact.Options{
+		Dir: t.TempDir(), BlockSize: 1024, MaxBytes: 64 << 10,
+		OnPrefetch: func(ref artifactx.Ref, err error) { done <- err },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.csv", "b.csv"} {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: name, Part: genai.NewPartFromBytes(make([]byte, 3000), "text/csv")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !artifactx.Prefetch(ctx, srv,
+		artifactx.Ref{AppName: "app", UserID: "user", SessionID: "session", FileName: "a.csv"},
+		artifactx.Ref{AppName: "app", UserID: "user", SessionID: "session", FileName: "b.csv", Version: 1},
+		artifactx.Ref{AppName: "app", UserID: "user", SessionID: "session", FileName: "missing.csv"},
+	) {
+		t.Fatal("Prefetch() = false, want the cache to be found")
+	}
+	var failed int
+	for range 3 {
+		if err := <-done; err != nil {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("%d prefetches failed, want 1 for the missing artifact", failed)
+	}
+	if blocks, _ := srv.Usage(); blocks != 6 {
+		t.Errorf("Usage() after prefetching = %d blocks, want 6", blocks)
+	}
+	backend.reads = 0
+	r, err := artifactx.OpenRange(ctx, srv, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "b.csv"}, 100, 2500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, r)
+	r.Close()
+	if backend.reads != 0 {
+		t.Errorf("OpenRange() of a prefetched version read %d ranges from the backend, want 0", backend.reads)
+	}
+
+	if artifactx.Prefetch(ctx, fsSrv, artifactx.Ref{AppName: "app", UserID: "user", SessionID: "session", FileName: "a.csv"}) {
+		t.Error("Prefetch() without a cache = true, want false")
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package refartifact provides an [artifact.Service] decorator resolving
+// reference artifacts, versions that point at a version of another artifact
+// instead of holding content, so that cloned sessions and shared corpora do
+// not duplicate their data.
+//
+// References are saved as parts made with [artifactx.NewRefPart] and are
+// replaced by the referenced version on Load, Stat and Open. Versions are
+// immutable, so saving to a referencing artifact adds a version of its own
+// and leaves the referenced one untouched. Deleting a referenced version
+// leaves its references dangling; loading them fails with [fs.ErrNotExist].
+package refartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// MaxDepth bounds the references followed to resolve one version. Saves
+// point references at the final version, so chains only form from
+// references saved to the decorated service directly.
+const MaxDepth = 8
+
+// Service is an [artifact.Service] that resolves reference artifacts.
+type Service struct {
+	artifact.Service
+}
+
+var (
+	_ artifactx.Wrapper       = (*Service)(nil)
+	_ artifactx.Stater        = (*Service)(nil)
+	_ artifactx.Opener        = (*Service)(nil)
+	_ artifactx.SessionCloner = (*Service)(nil)
+)
+
+// NewService returns a service that stores to next and resolves the
+// references stored there.
+func NewService(next artifact.Service) *Service {
+	return &Service{Service: next}
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// resolve loads the version req names, following references. It returns the
+// loaded part and the request of the version holding it.
+func (s *Service) resolve(ctx context.Context, req *artifact.LoadRequest) (*genai.Part, *artifact.LoadRequest, error) {
+	for range MaxDepth + 1 {
+		resp, err := s.Service.Load(ctx, req)
+		if err != nil {
+			return nil, nil, err
+		}
+		ref, ok, err := artifactx.ParseRefPart(resp.Part)
+		if err != nil {
+			return nil, nil, fmt.Errorf("artifact '%s': %w", req.FileName, err)
+		}
+		if !ok {
+			return resp.Part, req, nil
+		}
+		from := req.FileName
+		req = ref.LoadRequest()
+		if _, err := s.Service.Versions(ctx, &artifact.VersionsRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+		}); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, nil, fmt.Errorf("artifact '%s' references missing %s: %w", from, ref, fs.ErrNotExist)
+			}
+			return nil, nil, err
+		}
+	}
+	return nil, nil, fmt.Errorf("artifact '%s': more than %d nested references", req.FileName, MaxDepth)
+}
+
+// target returns the version holding the content of the reference ref,
+// failing if it does not exist.
+func (s *Service) target(ctx context.Context, ref artifactx.Ref) (artifactx.Ref, error) {
+	_, req, err := s.resolve(ctx, ref.LoadRequest())
+	if err != nil {
+		return artifactx.Ref{}, err
+	}
+	return artifactx.Ref{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: req.Version}, nil
+}
+
+// Save implements [artifact.Service]. References are checked to resolve and
+// saved pointing at the version holding the content.
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	ref, ok, err := artifactx.ParseRefPart(req.Part)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		target, err := s.target(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		part, err := artifactx.NewRefPart(target)
+		if err != nil {
+			return nil, err
+		}
+		saved := *req
+		saved.Part = part
+		req = &saved
+	}
+	return s.Service.Save(ctx, req)
+}
+
+// Load implements [artifact.Service].
+func (s *Service) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	part, _, err := s.resolve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &artifact.LoadResponse{Part: part}, nil
+}
+
+// stated resolves req to the version holding its content, reporting its
+// attributes under the version req resolves to.
+func (s *Service) stated(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Attributes, *artifact.LoadRequest, error) {
+	stater, ok := artifactx.As[artifactx.Stater](s.Service)
+	if !ok {
+		return nil, nil, fmt.Errorf("stat: %w", errors.ErrUnsupported)
+	}
+	attrs, err := stater.Stat(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if attrs.ContentType != artifactx.RefContentType {
+		return attrs, req, nil
+	}
+	version := attrs.Version
+	resolved := *req
+	resolved.Version = version
+	_, target, err := s.resolve(ctx, &resolved)
+	if err != nil {
+		return nil, nil, err
+	}
+	if attrs, err = stater.Stat(ctx, target); err != nil {
+		return nil, nil, err
+	}
+	attrs.Version = version
+	return attrs, target, nil
+}
+
+// Stat implements [artifactx.Stater]. The attributes of a reference are
+// those of the referenced version, under the version number of the
+// reference.
+func (s *Service) Stat(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Attributes, error) {
+	attrs, _, err := s.stated(ctx, req)
+	return attrs, err
+}
+
+// Open implements [artifactx.Opener].
+func (s *Service) Open(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Reader, error) {
+	opener, ok := artifactx.As[artifactx.Opener](s.Service)
+	if !ok {
+		return nil, fmt.Errorf("open: %w", errors.ErrUnsupported)
+	}
+	attrs, target, err := s.stated(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	r, err := opener.Open(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	r.Attributes.Version = attrs.Version
+	return r, nil
+}
+
+// CloneSession implements [artifactx.SessionCloner] by saving a reference to
+// every version instead of copying it.
+func (s *Service) CloneSession(ctx context.Context, req *artifactx.CloneRequest) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("request validation failed: %w", err)
+	}
+	names, err := artifactx.SessionFileNames(ctx, s.Service, req.AppName, req.UserID, req.SessionID)
+	if err != nil {
+		return err
+	}
+	existing, err := artifactx.SessionFileNames(ctx, s.Service, req.AppName, req.UserID, req.NewSessionID)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("session %q has artifacts: %w", req.NewSessionID, fs.ErrExist)
+	}
+	stater, _ := artifactx.As[artifactx.Stater](s.Service)
+
+	for _, name := range names {
+		resp, err := s.Service.Versions(ctx, &artifact.VersionsRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: name,
+		})
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // deleted since listing
+		}
+		if err != nil {
+			return err
+		}
+		// Oldest first, so that services ignoring SaveRequest.Version still
+		// keep the numbers of versions without gaps.
+		for _, v := range slices.Sorted(slices.Values(resp.Versions)) {
+			src := artifactx.Ref{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: name, Version: v}
+			saveCtx := ctx
+			if stater != nil {
+				attrs, err := stater.Stat(ctx, src.LoadRequest())
+				if err != nil {
+					return fmt.Errorf("failed to stat %s: %w", src, err)
+				}
+				saveCtx = artifactx.WithMetadata(ctx, attrs.Metadata)
+			}
+			part, err := artifactx.NewRefPart(src)
+			if err != nil {
+				return err
+			}
+			if _, err := s.Save(saveCtx, &artifact.SaveRequest{
+				AppName: req.AppName, UserID: req.UserID, SessionID: req.NewSessionID, FileName: name,
+				Part: part, Version: v,
+			}); err != nil {
+				return fmt.Errorf("failed to reference %s: %w", src, err)
+			}
+		}
+	}
+	return nil
+}
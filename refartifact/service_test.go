@@ -0,0 +1,140 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package refartifact_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"github.com/chinglinwen/adk-artifact/refartifact"
+)
+
+func newService(t *testing.T) *refartifact.Service {
+	t.Helper()
+	next, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return refartifact.NewService(next)
+}
+
+func TestLoadResolvesReferences(t *testing.T) {
+	ctx := t.Context()
+	srv := newService(t)
+	corpus := artifactx.Scoped(srv, "app", "user", "corpus")
+	if _, err := corpus.Save(ctx, "doc", genai.NewPartFromBytes([]byte("content"), "text/markdown")); err != nil {
+		t.Fatal(err)
+	}
+
+	session := artifactx.Scoped(srv, "app", "user", "session")
+	ref, err := artifactx.NewRefPart(artifactx.Ref{AppName: "app", UserID: "user", SessionID: "corpus", FileName: "doc", Version: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.Save(ctx, "doc", ref); err != nil {
+		t.Fatal(err)
+	}
+	part, err := session.Load(ctx, "doc")
+	if err != nil || part.InlineData == nil || string(part.InlineData.Data) != "content" || part.InlineData.MIMEType != "text/markdown" {
+		t.Fatalf("Load() = (%v, %v), want the referenced content", part, err)
+	}
+
+	attrs, err := srv.Stat(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "doc"})
+	if err != nil || attrs.ContentType != "text/markdown" || attrs.Size != int64(len("content")) || attrs.Version != 1 {
+		t.Errorf("Stat() = (%+v, %v), want the referenced attributes as version 1", attrs, err)
+	}
+	r, err := srv.Open(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "doc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || string(data) != "content" {
+		t.Errorf("Open() read (%q, %v), want content", data, err)
+	}
+
+	// A reference to a reference points at the content.
+	chained, err := artifactx.NewRefPart(artifactx.Ref{AppName: "app", UserID: "user", SessionID: "session", FileName: "doc", Version: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.Save(ctx, "copy", chained); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := srv.Unwrap().Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "copy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target, ok, err := artifactx.ParseRefPart(raw.Part); err != nil || !ok || target.SessionID != "corpus" {
+		t.Errorf("stored reference = (%v, %t, %v), want a reference to the corpus", target, ok, err)
+	}
+
+	if err := corpus.Delete(ctx, "doc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.Load(ctx, "doc"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Load() of a dangling reference = %v, want %v", err, fs.ErrNotExist)
+	}
+	if _, err := session.Save(ctx, "other", ref); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Save() of a dangling reference = %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestCloneSavesReferences(t *testing.T) {
+	ctx := t.Context()
+	srv := newService(t)
+	scope := artifactx.Scoped(srv, "app", "user", "session")
+	for _, text := range []string{"v1", "v2"} {
+		if _, err := scope.Save(artifactx.WithMetadata(ctx, map[string]string{"text": text}), "notes", genai.NewPartFromText(text)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	branch, err := scope.Clone(ctx, "branch")
+	if err != nil {
+		t.Fatalf("Clone() failed: %v", err)
+	}
+	raw, err := srv.Unwrap().Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "branch", FileName: "notes", Version: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := artifactx.ParseRefPart(raw.Part); !ok {
+		t.Errorf("cloned version = %v, want a reference", raw.Part)
+	}
+	if part, err := branch.LoadVersion(ctx, "notes", 1); err != nil || part.Text != "v1" {
+		t.Errorf("LoadVersion(1) in the clone = (%v, %v), want v1", part, err)
+	}
+	attrs, err := srv.Stat(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "branch", FileName: "notes", Version: 2})
+	if err != nil || attrs.Metadata["text"] != "v2" {
+		t.Errorf("Stat(2) in the clone = (%+v, %v), want the metadata of v2", attrs, err)
+	}
+
+	if _, err := branch.Save(ctx, "notes", genai.NewPartFromText("branched")); err != nil {
+		t.Fatal(err)
+	}
+	if part, err := scope.Load(ctx, "notes"); err != nil || part.Text != "v2" {
+		t.Errorf("Load() in the source after saving to the clone = (%v, %v), want v2", part, err)
+	}
+	if _, err := scope.Clone(ctx, "branch"); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("Clone() onto a session with artifacts = %v, want %v", err, fs.ErrExist)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3artifact
+
+import (
+	"slices"
+	"testing"
+
+	"gocloud.dev/blob"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+func TestCheck(t *testing.T) {
+	ctx := t.Context()
+	s := newMemService(t)
+	for range 3 {
+		if _, err := s.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+			Part: genai.NewPartFromText("hello"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Bucket().Delete(ctx, "app/user/session/file/2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Bucket().WriteAll(ctx, "app/user/session/file/latest", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Bucket().WriteAll(ctx, "stray", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := s.Check(ctx, &artifactx.CheckOptions{VerifyChecksums: true})
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	var got []artifactx.AnomalyKind
+	for _, a := range report.Anomalies {
+		got = append(got, a.Kind)
+	}
+	slices.Sort(got)
+	want := []artifactx.AnomalyKind{artifactx.InvalidVersion, artifactx.OrphanedObject, artifactx.VersionGap}
+	if !slices.Equal(got, want) {
+		t.Errorf("Check() anomalies = %v, want %v", got, want)
+	}
+	if report.Objects != 4 {
+		t.Errorf("Check() objects = %d, want 4", report.Objects)
+	}
+}
+
+func TestCheckMetadata(t *testing.T) {
+	ctx := t.Context()
+	s := newMemService(t)
+	if _, err := s.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+		Part: genai.NewPartFromText("hello"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// An object written before the format was versioned, or by another ADK.
+	legacy := &blob.WriterOptions{ContentType: "text/plain", Metadata: map[string]string{"source": "python"}}
+	if err := s.Bucket().WriteAll(ctx, "app/user/session/file/2", []byte("hello"), legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &artifactx.CheckOptions{VerifyMetadata: true}
+	report, err := s.Check(ctx, opts)
+	if err != nil {
+		t.Fatalf("Check() failed: %v", err)
+	}
+	if len(report.Anomalies) != 1 || report.Anomalies[0].Kind != artifactx.OutdatedMetadata {
+		t.Fatalf("Check() anomalies = %v, want the outdated version 2", report.Anomalies)
+	}
+	if n, err := s.Repair(ctx, report); err != nil || n != 1 {
+		t.Fatalf("Repair() = (%d, %v), want (1, nil)", n, err)
+	}
+	if report, err := s.Check(ctx, opts); err != nil || len(report.Anomalies) != 0 {
+		t.Errorf("Check() after Repair() = (%v, %v), want no anomalies", report, err)
+	}
+	attrs, err := s.Stat(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Version: 2})
+	if err != nil || attrs.Metadata["source"] != "python" || attrs.ContentType != "text/plain" {
+		t.Errorf("Stat() of the upgraded version = (%+v, %v), want its metadata kept", attrs, err)
+	}
+}
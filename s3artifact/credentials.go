@@ -0,0 +1,119 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3artifact
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+// AssumeRole configures [WithAssumeRole].
+type AssumeRole struct {
+	// RoleARN is the role to assume, e.g. a role of the account owning the
+	// bucket.
+	RoleARN string
+	// ExternalID is required by the trust policy of roles assumed by third
+	// parties.
+	ExternalID string
+	// SessionName identifies the role session in CloudTrail; defaults to a
+	// name generated by the SDK.
+	SessionName string
+	// Tags are the session tags passed to the role session.
+	Tags map[string]string
+	// Duration of the credentials; defaults to 15 minutes.
+	Duration time.Duration
+}
+
+// WithAssumeRole makes the service use temporary credentials of role,
+// obtained from STS with the credentials of the AWS configuration and
+// refreshed before they expire. It has no effect on a client passed to
+// [WithClient].
+func WithAssumeRole(role AssumeRole) Option {
+	return func(o *options) {
+		o.assumeRole = &role
+	}
+}
+
+// WithCredentials makes the service use provider instead of the credentials
+// of the AWS configuration, as the source credentials of [WithAssumeRole]
+// if it is set. The provider is used as is, without the caching
+// [config.LoadDefaultConfig] adds, so a [SwappableCredentials] takes effect
+// on the next request.
+func WithCredentials(provider aws.CredentialsProvider) Option {
+	return func(o *options) {
+		o.credentials = provider
+	}
+}
+
+// assumeRoleProvider returns a caching provider of the credentials of role,
+// assumed with the credentials of cfg.
+func assumeRoleProvider(cfg aws.Config, role *AssumeRole) aws.CredentialsProvider {
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), role.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+		if role.ExternalID != "" {
+			o.ExternalID = aws.String(role.ExternalID)
+		}
+		if role.SessionName != "" {
+			o.RoleSessionName = role.SessionName
+		}
+		if role.Duration > 0 {
+			o.Duration = role.Duration
+		}
+		for _, key := range slices.Sorted(maps.Keys(role.Tags)) {
+			o.Tags = append(o.Tags, types.Tag{Key: aws.String(key), Value: aws.String(role.Tags[key])})
+		}
+	})
+	return aws.NewCredentialsCache(provider)
+}
+
+// SwappableCredentials is an [aws.CredentialsProvider] whose underlying
+// provider can be replaced at runtime, e.g. after rotating an access key,
+// without recreating the service. Credentials of the current provider are
+// cached until they expire or the provider is swapped.
+type SwappableCredentials struct {
+	mu    sync.RWMutex
+	cache *aws.CredentialsCache
+}
+
+var _ aws.CredentialsProvider = (*SwappableCredentials)(nil)
+
+// NewSwappableCredentials returns credentials retrieved from provider until
+// [SwappableCredentials.Swap] is called.
+func NewSwappableCredentials(provider aws.CredentialsProvider) *SwappableCredentials {
+	return &SwappableCredentials{cache: aws.NewCredentialsCache(provider)}
+}
+
+// Swap makes the following requests use credentials of provider.
+func (c *SwappableCredentials) Swap(provider aws.CredentialsProvider) {
+	cache := aws.NewCredentialsCache(provider)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache = cache
+}
+
+// Retrieve implements [aws.CredentialsProvider].
+func (c *SwappableCredentials) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	c.mu.RLock()
+	cache := c.cache
+	c.mu.RUnlock()
+	return cache.Retrieve(ctx)
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3artifact
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const assumeRoleResponse = `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASSUMED</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>2030-01-01T00:00:00Z</Expiration>
+    </Credentials>
+    <AssumedRoleUser>
+      <Arn>arn:aws:sts::111111111111:assumed-role/artifacts/adk</Arn>
+      <AssumedRoleId>ARO:adk</AssumedRoleId>
+    </AssumedRoleUser>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`
+
+// clientCredentials returns the credentials the S3 client of srv signs with.
+func clientCredentials(t *testing.T, srv any) aws.Credentials {
+	t.Helper()
+	var client *s3.Client
+	if !srv.(*s3Service).Bucket().As(&client) {
+		t.Fatal("bucket is not an S3 bucket")
+	}
+	creds, err := client.Options().Credentials.Retrieve(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return creds
+}
+
+func TestWithAssumeRole(t *testing.T) {
+	forms := make(chan url.Values, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		forms <- r.PostForm
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(assumeRoleResponse))
+	}))
+	defer server.Close()
+
+	srv, err := New(t.Context(), "bucket",
+		WithAWSConfig(
+			config.WithRegion("us-east-1"),
+			config.WithBaseEndpoint(server.URL),
+			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("SOURCE", "secret", "")),
+		),
+		WithAssumeRole(AssumeRole{
+			RoleARN:     "arn:aws:iam::111111111111:role/artifacts",
+			ExternalID:  "ext",
+			SessionName: "adk",
+			Tags:        map[string]string{"team": "agents"},
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds := clientCredentials(t, srv); creds.AccessKeyID != "ASSUMED" || !creds.CanExpire {
+		t.Errorf("credentials = %+v, want the expiring assumed role credentials", creds)
+	}
+	form := <-forms
+	for key, want := range map[string]string{
+		"Action":              "AssumeRole",
+		"RoleArn":             "arn:aws:iam::111111111111:role/artifacts",
+		"ExternalId":          "ext",
+		"RoleSessionName":     "adk",
+		"Tags.member.1.Key":   "team",
+		"Tags.member.1.Value": "agents",
+	} {
+		if got := form.Get(key); got != want {
+			t.Errorf("AssumeRole %s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestSwappableCredentials(t *testing.T) {
+	creds := NewSwappableCredentials(credentials.NewStaticCredentialsProvider("OLD", "secret", ""))
+	srv, err := New(t.Context(), "bucket", WithAWSConfig(config.WithRegion("us-east-1")), WithCredentials(creds))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := clientCredentials(t, srv); got.AccessKeyID != "OLD" {
+		t.Errorf("credentials = %+v, want OLD", got)
+	}
+	creds.Swap(credentials.NewStaticCredentialsProvider("NEW", "secret", ""))
+	if got := clientCredentials(t, srv); got.AccessKeyID != "NEW" {
+		t.Errorf("credentials after Swap() = %+v, want NEW", got)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3artifact
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ServerSideEncryption configures how S3 encrypts the objects the service
+// writes.
+type ServerSideEncryption struct {
+	// Algorithm is AES256 or aws:kms.
+	Algorithm types.ServerSideEncryption
+	// KMSKeyID is the key of aws:kms encryption; empty means the AWS managed
+	// key of the account.
+	KMSKeyID string
+	// BucketKey makes aws:kms encryption use an S3 Bucket Key, which cuts
+	// the KMS requests.
+	BucketKey bool
+}
+
+// WithServerSideEncryption makes the service request sse for every version
+// it writes, instead of relying on the default encryption of the bucket.
+// Objects copied by Move and Clone get the default encryption of the
+// bucket.
+func WithServerSideEncryption(sse ServerSideEncryption) Option {
+	return func(o *options) {
+		o.sse = &sse
+	}
+}
+
+// apply sets the encryption on in.
+func (e *ServerSideEncryption) apply(in *s3.PutObjectInput) {
+	in.ServerSideEncryption = e.Algorithm
+	if e.KMSKeyID != "" {
+		in.SSEKMSKeyId = aws.String(e.KMSKeyID)
+	}
+	if e.BucketKey {
+		in.BucketKeyEnabled = aws.Bool(true)
+	}
+}
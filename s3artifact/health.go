@@ -0,0 +1,40 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3artifact
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+var _ artifactx.HealthChecker = (*s3Service)(nil)
+
+// HealthCheck implements [artifactx.HealthChecker] with a HeadBucket request,
+// which fails if the bucket is missing or the credentials lack access to it.
+func (s *s3Service) HealthCheck(ctx context.Context) error {
+	var client *s3.Client
+	if s.bucketName != "" && s.Bucket().As(&client) {
+		if _, err := client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(s.bucketName)}); err != nil {
+			return fmt.Errorf("bucket %q is not accessible: %w", s.bucketName, err)
+		}
+		return nil
+	}
+	return s.Service.HealthCheck(ctx)
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build integration
+
+// The integration tests run the service suite against real S3 compatible
+// servers started in containers with testcontainers-go, so they need
+// Docker:
+//
+//	go test -tags integration ./s3artifact
+//
+// MinIO always runs; SeaweedFS runs too unless -short is set.
+
+package s3artifact_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/s3artifact"
+	"github.com/chinglinwen/adk-artifact/tests"
+)
+
+const (
+	minioImage     = "minio/minio:RELEASE.2024-01-16T16-07-38Z"
+	seaweedfsImage = "chrislusf/seaweedfs:3.80"
+)
+
+// s3Server is an S3 compatible server started for the tests.
+type s3Server struct {
+	endpoint             string
+	accessKey, secretKey string
+	buckets              atomic.Int64
+}
+
+// client returns a client of the server.
+func (srv *s3Server) client() *s3.Client {
+	return s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(srv.endpoint),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider(srv.accessKey, srv.secretKey, ""),
+	})
+}
+
+// factory returns a service factory for the suite. Every service gets a
+// new bucket, so that the tests do not see each other's artifacts.
+func (srv *s3Server) factory(t *testing.T) (artifact.Service, error) {
+	ctx := t.Context()
+	client := srv.client()
+	bucket := fmt.Sprintf("test-%d", srv.buckets.Add(1))
+	if _, err := client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String(bucket)}); err != nil {
+		return nil, fmt.Errorf("could not create bucket %s: %w", bucket, err)
+	}
+	return s3artifact.NewServiceFromClient(ctx, client, bucket)
+}
+
+// startContainer starts the container req describes and returns the
+// address of its first exposed port.
+func startContainer(ctx context.Context, t *testing.T, name string, req testcontainers.ContainerRequest) string {
+	t.Helper()
+	ctr, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{ContainerRequest: req, Started: true})
+	testcontainers.CleanupContainer(t, ctr)
+	if err != nil {
+		t.Fatalf("could not start %s: %v", name, err)
+	}
+	host, err := ctr.Host(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := ctr.MappedPort(ctx, req.ExposedPorts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("http://%s:%s", host, port.Port())
+}
+
+func startMinIO(ctx context.Context, t *testing.T) *s3Server {
+	t.Helper()
+	endpoint := startContainer(ctx, t, "MinIO", testcontainers.ContainerRequest{
+		Image:        minioImage,
+		Cmd:          []string{"server", "/data"},
+		Env:          map[string]string{"MINIO_ROOT_USER": "minioadmin", "MINIO_ROOT_PASSWORD": "minioadmin"},
+		ExposedPorts: []string{"9000/tcp"},
+		WaitingFor:   wait.ForHTTP("/minio/health/live").WithPort("9000/tcp"),
+	})
+	return &s3Server{endpoint: endpoint, accessKey: "minioadmin", secretKey: "minioadmin"}
+}
+
+func startSeaweedFS(ctx context.Context, t *testing.T) *s3Server {
+	t.Helper()
+	endpoint := startContainer(ctx, t, "SeaweedFS", testcontainers.ContainerRequest{
+		Image:        seaweedfsImage,
+		Cmd:          []string{"server", "-s3", "-dir=/data"},
+		ExposedPorts: []string{"8333/tcp"},
+		WaitingFor:   wait.ForListeningPort("8333/tcp"),
+	})
+	// Without an identity configuration, SeaweedFS accepts any credentials.
+	return &s3Server{endpoint: endpoint, accessKey: "any", secretKey: "any"}
+}
+
+func TestMinIOArtifactService(t *testing.T) {
+	srv := startMinIO(t.Context(), t)
+	tests.TestArtifactService(t, "MinIO", srv.factory)
+}
+
+func TestSeaweedFSArtifactService(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping SeaweedFS in short mode")
+	}
+	srv := startSeaweedFS(t.Context(), t)
+	tests.TestArtifactService(t, "SeaweedFS", srv.factory)
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3artifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// MultipartCleaner is implemented by the S3 service returned by [NewService]
+// and aborts the incomplete multipart uploads of failed large saves. S3
+// keeps and bills their parts, which no listing shows, until they are
+// aborted or a lifecycle rule expires them.
+type MultipartCleaner interface {
+	// AbortIncompleteUploads aborts the multipart uploads of versions below
+	// the prefix of the service initiated more than olderThan ago, and
+	// returns the number of uploads aborted. olderThan should exceed the
+	// longest save, so that running uploads are left alone.
+	AbortIncompleteUploads(ctx context.Context, olderThan time.Duration) (int, error)
+}
+
+var _ MultipartCleaner = (*s3Service)(nil)
+
+// AbortIncompleteUploads implements [MultipartCleaner]. Uploads of keys
+// that are not versions of the key layout of the service are left alone,
+// even below its prefix.
+func (s *s3Service) AbortIncompleteUploads(ctx context.Context, olderThan time.Duration) (int, error) {
+	var client *s3.Client
+	if s.bucketName == "" || !s.Bucket().As(&client) {
+		return 0, fmt.Errorf("aborting uploads requires a bucket opened by name with an S3 client")
+	}
+	cutoff := s.clock.Now().Add(-olderThan)
+	aborted := 0
+	pages := s3.NewListMultipartUploadsPaginator(client, &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(s.bucketName),
+		Prefix: aws.String(s.prefix),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return aborted, fmt.Errorf("failed to list multipart uploads: %w", err)
+		}
+		for _, u := range page.Uploads {
+			key, _ := strings.CutPrefix(aws.ToString(u.Key), s.prefix)
+			if _, err := s.keys.ParseKey(key); err != nil {
+				continue
+			}
+			if u.Initiated == nil || !u.Initiated.Before(cutoff) {
+				continue
+			}
+			_, err := client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(s.bucketName),
+				Key:      u.Key,
+				UploadId: u.UploadId,
+			})
+			var nsu *types.NoSuchUpload
+			if errors.As(err, &nsu) {
+				continue // completed or aborted since listing
+			}
+			if err != nil {
+				return aborted, fmt.Errorf("failed to abort the upload of %q: %w", key, err)
+			}
+			aborted++
+		}
+	}
+	return aborted, nil
+}
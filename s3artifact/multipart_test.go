@@ -0,0 +1,88 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3artifact
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+const listUploadsResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<ListMultipartUploadsResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Bucket>bucket</Bucket>
+  <IsTruncated>false</IsTruncated>
+  <Upload><Key>adk/app/user/session/file/1</Key><UploadId>stale</UploadId><Initiated>2025-01-01T00:00:00.000Z</Initiated></Upload>
+  <Upload><Key>adk/app/user/session/file/2</Key><UploadId>running</UploadId><Initiated>2025-01-01T23:30:00.000Z</Initiated></Upload>
+  <Upload><Key>adk/notes.txt</Key><UploadId>foreign</UploadId><Initiated>2025-01-01T00:00:00.000Z</Initiated></Upload>
+  <Upload><Key>adk/app/user/session/other/1</Key><UploadId>gone</UploadId><Initiated>2025-01-01T00:00:00.000Z</Initiated></Upload>
+</ListMultipartUploadsResult>`
+
+func TestAbortIncompleteUploads(t *testing.T) {
+	var mu sync.Mutex
+	var prefix string
+	var aborted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Query().Has("uploads"):
+			prefix = r.URL.Query().Get("prefix")
+			w.Write([]byte(listUploadsResponse))
+		case r.Method == http.MethodDelete && r.URL.Query().Get("uploadId") == "gone":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<Error><Code>NoSuchUpload</Code></Error>`))
+		case r.Method == http.MethodDelete:
+			aborted = append(aborted, r.URL.Path+"?"+r.URL.Query().Get("uploadId"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	defer server.Close()
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("key", "secret", ""),
+	})
+	clock := artifactx.NewManualClock(time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC))
+	srv, err := New(t.Context(), "bucket", WithClient(client), WithPrefix("adk/"), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := srv.(MultipartCleaner).AbortIncompleteUploads(t.Context(), time.Hour)
+	if err != nil || n != 1 {
+		t.Errorf("AbortIncompleteUploads() = (%d, %v), want (1, nil)", n, err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if prefix != "adk/" {
+		t.Errorf("listed uploads with prefix %q, want adk/", prefix)
+	}
+	if want := []string{"/bucket/adk/app/user/session/file/1?stale"}; !slices.Equal(aborted, want) {
+		t.Errorf("aborted %q, want %q", aborted, want)
+	}
+}
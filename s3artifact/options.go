@@ -0,0 +1,287 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3artifact
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"gocloud.dev/blob"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/blobartifact"
+)
+
+type options struct {
+	bucket      *blob.Bucket
+	client      *s3.Client
+	awsConfig   []func(*config.LoadOptions) error
+	s3Options   []func(*s3.Options)
+	assumeRole  *AssumeRole
+	credentials aws.CredentialsProvider
+	tlsConfig   *tls.Config
+	keys        artifactx.KeyBuilder
+	logger      *slog.Logger
+	retention   artifactx.Retention
+	ranged      *rangedDownload
+	clock       artifactx.Clock
+	prefix      string
+	maxLoad     int64
+	hedge       time.Duration
+	index       bool
+	listingTTL  *time.Duration
+	sharedLoads bool
+	bulkDelete  *bulkDelete
+	checksum    *artifactx.ChecksumAlgorithm
+	// defaultContentType is the content type of versions saved without
+	// one, or empty to have the bucket detect it.
+	defaultContentType string
+	// serverChecksum is the algorithm of WithServerChecksum, if set.
+	serverChecksum types.ChecksumAlgorithm
+	sse            *ServerSideEncryption
+}
+
+// Option configures a service created with [New].
+type Option func(*options)
+
+// WithBucket makes the service use bucket instead of opening the bucket
+// named in [New]. The AWS configuration is not loaded then, and the name is
+// only used for S3 specific requests such as the health check.
+func WithBucket(bucket *blob.Bucket) Option {
+	return func(o *options) {
+		o.bucket = bucket
+	}
+}
+
+// WithPrefix keeps all objects of the service below prefix, such as
+// "adk/prod/", so that it can share a bucket with other data. A "/" is
+// appended to prefix if it does not end with one.
+//
+// The bucket is wrapped with [blob.PrefixedBucket], so a bucket passed to
+// [WithBucket] is taken over by the service and closed with it.
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		if prefix != "" && !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+		o.prefix = prefix
+	}
+}
+
+// WithClient makes the service open the bucket named in [New] with client
+// instead of a client from [config.LoadDefaultConfig], for applications that
+// configure their own transport, credentials or endpoint.
+func WithClient(client *s3.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+// WithAWSConfig passes optFns to [config.LoadDefaultConfig] when opening the bucket.
+func WithAWSConfig(optFns ...func(*config.LoadOptions) error) Option {
+	return func(o *options) {
+		o.awsConfig = append(o.awsConfig, optFns...)
+	}
+}
+
+// WithClientOptions passes fns to [s3.NewFromConfig] when creating the client,
+// e.g. to add middleware such as the Register method of a costartifact.Meter.
+// It has no effect on a client passed to [WithClient].
+func WithClientOptions(fns ...func(*s3.Options)) Option {
+	return func(o *options) {
+		o.s3Options = append(o.s3Options, fns...)
+	}
+}
+
+// WithAcceleration sends requests to the S3 Transfer Acceleration endpoint
+// of the bucket, which routes uploads and downloads of distant clients over
+// the AWS network. Acceleration must be enabled on the bucket. Like the other
+// client settings, it has no effect on a client passed to [WithClient].
+func WithAcceleration() Option {
+	return func(o *options) {
+		o.s3Options = append(o.s3Options, func(so *s3.Options) {
+			so.UseAccelerate = true
+		})
+	}
+}
+
+// WithDualStack sends requests to the dual-stack endpoints of S3, which
+// accept IPv6 as well as IPv4 connections.
+func WithDualStack() Option {
+	return func(o *options) {
+		o.s3Options = append(o.s3Options, func(so *s3.Options) {
+			so.EndpointOptions.UseDualStackEndpoint = aws.DualStackEndpointStateEnabled
+		})
+	}
+}
+
+// WithRegionEndpoints overrides the endpoint URL by region: if the region of
+// the client has an entry in endpoints, requests are sent there, e.g. to a
+// regional proxy or an S3 compatible store near the users of that region.
+func WithRegionEndpoints(endpoints map[string]string) Option {
+	return func(o *options) {
+		o.s3Options = append(o.s3Options, func(so *s3.Options) {
+			if endpoint, ok := endpoints[so.Region]; ok {
+				so.BaseEndpoint = aws.String(endpoint)
+			}
+		})
+	}
+}
+
+// WithChecksumAlgorithm makes Save record the checksum of every version with
+// a instead of SHA-256, as [blobartifact.WithChecksumAlgorithm] does.
+func WithChecksumAlgorithm(a *artifactx.ChecksumAlgorithm) Option {
+	return func(o *options) {
+		o.checksum = a
+	}
+}
+
+// WithServerChecksum has S3 verify every upload with a checksum of alg,
+// such as [types.ChecksumAlgorithmCrc32c], that the SDK computes while
+// streaming the content and sends as a trailer, instead of the service
+// hashing the content before the upload. S3 stores the checksum with the
+// object. The service then records no checksum of its own, so ETags come
+// from S3, and [WithChecksumAlgorithm] has no effect.
+//
+// It saves a pass over the content of large uploads; S3 compatible stores
+// must support additional checksums.
+func WithServerChecksum(alg types.ChecksumAlgorithm) Option {
+	return func(o *options) {
+		o.serverChecksum = alg
+	}
+}
+
+// rangedDownload holds the settings of [WithRangedDownload] until they are
+// passed on to [blobartifact.WithRangedDownload].
+type rangedDownload struct {
+	threshold, partSize int64
+	concurrency         int
+}
+
+// WithLogger sets the logger for failures of best effort work, such as
+// removing expired versions. The default is [slog.Default].
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithKeyBuilder sets the layout of object keys. The default is
+// [artifactx.DefaultKeyBuilder].
+func WithKeyBuilder(kb artifactx.KeyBuilder) Option {
+	return func(o *options) {
+		o.keys = kb
+	}
+}
+
+// WithClock sets the clock stamping saved versions and driving retention.
+// The default is [artifactx.SystemClock]. S3 sets its own object times, so
+// the stamp is kept in the object metadata and reported as
+// [artifactx.Attributes.Created].
+func WithClock(c artifactx.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithRetention removes the versions r does not keep after every Save.
+func WithRetention(r artifactx.Retention) Option {
+	return func(o *options) {
+		o.retention = r
+	}
+}
+
+// WithMaxLoadSize makes Load fail with [artifactx.ErrTooLarge] for objects
+// of more than n bytes instead of reading them into memory. Zero, the
+// default, means no limit; [artifactx.WithMaxLoadSize] lowers it per request.
+func WithMaxLoadSize(n int64) Option {
+	return func(o *options) {
+		o.maxLoad = n
+	}
+}
+
+// WithHedgedLoad makes Load start a second request if the first has not
+// completed after delay, and return whichever completes first, cancelling
+// the other, to cut the p99 latency of S3. Zero, the default, disables
+// hedging.
+func WithHedgedLoad(delay time.Duration) Option {
+	return func(o *options) {
+		o.hedge = delay
+	}
+}
+
+// WithVersionsIndex keeps an index object with the versions of every
+// artifact, updated on every write, so that Versions and loading the latest
+// version take a single GET instead of a LIST request.
+func WithVersionsIndex() Option {
+	return func(o *options) {
+		o.index = true
+	}
+}
+
+// WithListingCache caches version listings for ttl and shares concurrent
+// listings of the same artifact, as [blobartifact.WithListingCache] does, so
+// that a burst of loads of one artifact makes a single LIST request.
+func WithListingCache(ttl time.Duration) Option {
+	return func(o *options) {
+		o.listingTTL = &ttl
+	}
+}
+
+// WithSharedLoads makes concurrent loads of the same version share a single
+// GET, as [blobartifact.WithSharedLoads] does.
+func WithSharedLoads() Option {
+	return func(o *options) {
+		o.sharedLoads = true
+	}
+}
+
+type bulkDelete struct {
+	concurrency int
+	policy      blobartifact.DeleteErrorPolicy
+}
+
+// WithBulkDelete sets how many versions deleting all the versions of an
+// artifact deletes at once, and what it does when one of them fails, as
+// [blobartifact.WithBulkDelete] does.
+func WithBulkDelete(concurrency int, policy blobartifact.DeleteErrorPolicy) Option {
+	return func(o *options) {
+		o.bulkDelete = &bulkDelete{concurrency: concurrency, policy: policy}
+	}
+}
+
+// WithDefaultContentType sets the content type of versions saved without
+// one, as [blobartifact.WithDefaultContentType] does.
+func WithDefaultContentType(contentType string) Option {
+	return func(o *options) {
+		o.defaultContentType = contentType
+	}
+}
+
+// WithRangedDownload makes Load fetch objects of threshold bytes or more as
+// concurrent byte ranges of partSize bytes, at most concurrency at a time.
+// A zero threshold disables ranged downloads. The default is 16MiB ranges,
+// 8 at a time, for objects from 64MiB.
+func WithRangedDownload(threshold, partSize int64, concurrency int) Option {
+	return func(o *options) {
+		o.ranged = &rangedDownload{threshold: threshold, partSize: partSize, concurrency: concurrency}
+	}
+}
@@ -0,0 +1,355 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3artifact
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/fileblob"
+	"gocloud.dev/blob/memblob"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+func TestWithRetention(t *testing.T) {
+	ctx := t.Context()
+	s := newMemService(t, WithRetention(artifactx.Retention{MaxVersions: 1}))
+	for i := range 3 {
+		if _, err := s.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+			Part: genai.NewPartFromText(fmt.Sprint(i)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp, err := s.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+	if err != nil || !slices.Equal(resp.Versions, []int64{3}) {
+		t.Errorf("Versions() = (%v, %v), want [3]", resp, err)
+	}
+}
+
+func TestRetentionDryRun(t *testing.T) {
+	ctx := t.Context()
+	s := newMemService(t, WithRetention(artifactx.Retention{MaxVersions: 1}))
+	var removed []int64
+	ctx = artifactx.WithDryRun(ctx, func(r artifactx.Removal) {
+		if r.Reason == artifactx.ByRetention {
+			removed = append(removed, r.Version)
+		}
+	})
+	for i := range 3 {
+		if _, err := s.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+			Part: genai.NewPartFromText(fmt.Sprint(i)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Each Save reports what the policy would expire at that point.
+	if !slices.Equal(removed, []int64{1, 1, 2}) {
+		t.Errorf("dry run reported versions %v, want [1 1 2]", removed)
+	}
+	resp, err := s.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+	if err != nil || !slices.Equal(resp.Versions, []int64{1, 2, 3}) {
+		t.Errorf("Versions() = (%v, %v), want [1 2 3]", resp, err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	ctx := t.Context()
+	s := newMemService(t)
+	for _, fileName := range []string{"file", "user:profile"} {
+		for i := range 3 {
+			if _, err := s.Save(ctx, &artifact.SaveRequest{
+				AppName: "app", UserID: "user", SessionID: "session", FileName: fileName,
+				Part: genai.NewPartFromText(fmt.Sprint(i)),
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	s.SetRetention(artifactx.Retention{MaxVersions: 1})
+	if n, err := s.Prune(ctx); err != nil || n != 4 {
+		t.Errorf("Prune() = (%d, %v), want 4 versions deleted", n, err)
+	}
+	for _, fileName := range []string{"file", "user:profile"} {
+		resp, err := s.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: fileName})
+		if err != nil || !slices.Equal(resp.Versions, []int64{3}) {
+			t.Errorf("Versions(%s) = (%v, %v), want [3]", fileName, resp, err)
+		}
+	}
+}
+
+func TestPinnedVersionsAreKept(t *testing.T) {
+	ctx := t.Context()
+	s := newMemService(t, WithRetention(artifactx.Retention{MaxVersions: 1}))
+	for i := range 3 {
+		if _, err := s.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+			Part: genai.NewPartFromText(fmt.Sprint(i)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			if err := s.Pin(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	resp, err := s.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+	if err != nil || !slices.Equal(resp.Versions, []int64{1, 3}) {
+		t.Errorf("Versions() = (%v, %v), want [1 3]", resp, err)
+	}
+}
+
+func TestWithVersionsIndex(t *testing.T) {
+	ctx := t.Context()
+	s := newMemService(t, WithVersionsIndex())
+	req := &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"}
+	if _, err := s.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("v1"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// A version written behind the back of the service is not indexed, and
+	// so not seen by reads, until the next write.
+	if err := s.Bucket().WriteAll(ctx, s.buildKey("app", "user", "session", "file", 2), []byte("v2"), nil); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := s.Versions(ctx, req)
+	if err != nil || !slices.Equal(resp.Versions, []int64{1}) {
+		t.Errorf("Versions() = (%v, %v), want the indexed [1]", resp, err)
+	}
+	save, err := s.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("v3"),
+	})
+	if err != nil || save.Version != 3 {
+		t.Fatalf("Save() = (%v, %v), want version 3 numbered from a listing", save, err)
+	}
+	resp, err = s.Versions(ctx, req)
+	if err != nil || !slices.Equal(resp.Versions, []int64{1, 2, 3}) {
+		t.Errorf("Versions() after Save() = (%v, %v), want [1 2 3]", resp, err)
+	}
+	report, err := s.Check(ctx, nil)
+	if err != nil || len(report.Anomalies) != 0 {
+		t.Errorf("Check() = (%+v, %v), want the index ignored", report, err)
+	}
+
+	if err := s.Delete(ctx, &artifact.DeleteRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Versions(ctx, req); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Versions() after Delete() = %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestPythonKeyBuilder(t *testing.T) {
+	ctx := t.Context()
+	s := newMemService(t, WithKeyBuilder(artifactx.PythonKeyBuilder))
+	// Objects as the GcsArtifactService of the Python ADK writes them.
+	for key, contentType := range map[string]string{
+		"app/alice/s1/chart.png/0":      "image/png",
+		"app/alice/user/user:profile/0": "text/plain",
+	} {
+		if err := s.Bucket().WriteAll(ctx, key, []byte(key), &blob.WriterOptions{ContentType: contentType}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := s.List(ctx, &artifact.ListRequest{AppName: "app", UserID: "alice", SessionID: "s1"})
+	if err != nil || !slices.Equal(list.FileNames, []string{"chart.png", "user:profile"}) {
+		t.Errorf("List() = (%v, %v), want [chart.png user:profile]", list, err)
+	}
+	resp, err := s.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "alice", SessionID: "s1", FileName: "chart.png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Part.InlineData; got == nil || got.MIMEType != "image/png" || string(got.Data) != "app/alice/s1/chart.png/0" {
+		t.Errorf("Load() = %+v, want the image saved by Python", got)
+	}
+	save, err := s.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "alice", SessionID: "s1", FileName: "chart.png", Part: genai.NewPartFromBytes([]byte("v2"), "image/png"),
+	})
+	if err != nil || save.Version != 2 {
+		t.Fatalf("Save() = (%v, %v), want version 2", save, err)
+	}
+	if ok, err := s.Bucket().Exists(ctx, "app/alice/s1/chart.png/1"); err != nil || !ok {
+		t.Errorf("Exists() of the key Python reads as version 1 = (%t, %v), want true", ok, err)
+	}
+}
+
+func TestWithRangedDownload(t *testing.T) {
+	ctx := t.Context()
+	s := newMemService(t, WithRangedDownload(1024, 100, 4))
+	data := bytes.Repeat([]byte("0123456789"), 500)
+	if _, err := s.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+		Part: genai.NewPartFromBytes(data, "application/octet-stream"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := s.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+	if err != nil || !bytes.Equal(resp.Part.InlineData.Data, data) {
+		t.Errorf("Load() = %v, want the saved data", err)
+	}
+}
+
+func TestWithHedgedLoad(t *testing.T) {
+	ctx := t.Context()
+	s := newMemService(t, WithHedgedLoad(time.Nanosecond))
+	if _, err := s.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+		Part: genai.NewPartFromText("hedged"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := s.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+	if err != nil || resp.Part.Text != "hedged" {
+		t.Errorf("Load() = (%v, %v), want the saved text", resp, err)
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	ctx := t.Context()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := artifactx.NewManualClock(start)
+	s := newMemService(t, WithClock(clock), WithRetention(artifactx.Retention{MaxAge: time.Hour}))
+	save := func() {
+		t.Helper()
+		if _, err := s.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+			Part: genai.NewPartFromText("data"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save()
+	clock.Advance(30 * time.Minute)
+	save()
+	clock.Advance(45 * time.Minute)
+	save() // version 1 is now older than an hour
+
+	resp, err := s.Versions(ctx, &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+	if err != nil || !slices.Equal(resp.Versions, []int64{2, 3}) {
+		t.Errorf("Versions() = (%v, %v), want [2 3]", resp, err)
+	}
+	attrs, err := s.Stat(ctx, &artifact.LoadRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Version: 2,
+	})
+	if want := start.Add(30 * time.Minute); err != nil || !attrs.Created.Equal(want) || attrs.Metadata != nil {
+		t.Errorf("Stat() = (%+v, %v), want created at %v and no metadata", attrs, err, want)
+	}
+}
+
+func TestWithPrefix(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "unrelated"), []byte("other data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bucket, err := fileblob.OpenBucket(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := New(ctx, "bucket", WithBucket(bucket), WithPrefix("adk/prod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := srv.(*s3Service)
+	defer s.Close()
+
+	if _, err := s.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+		Part: genai.NewPartFromText("data"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "adk", "prod", "app", "user", "session", "file", "1")); err != nil {
+		t.Errorf("version not stored below the prefix: %v", err)
+	}
+	report, err := s.Check(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Objects != 1 || len(report.Anomalies) != 0 {
+		t.Errorf("Check() = %+v, want only the saved version", report)
+	}
+}
+
+func TestNewServiceFromBucket(t *testing.T) {
+	ctx := t.Context()
+	srv, err := NewServiceFromBucket(memblob.OpenBucket(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+		Part: genai.NewPartFromText("data"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.(artifactx.HealthChecker).HealthCheck(ctx); err != nil {
+		t.Errorf("HealthCheck() = %v, want nil", err)
+	}
+}
+
+func TestNewServiceFromClient(t *testing.T) {
+	client := s3.New(s3.Options{Region: "us-east-1"})
+	srv, err := NewServiceFromClient(t.Context(), client, "bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got *s3.Client
+	if !srv.(*s3Service).Bucket().As(&got) || got != client {
+		t.Errorf("bucket does not use the given client")
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	srv, err := New(t.Context(), "bucket",
+		WithAWSConfig(config.WithRegion("ap-southeast-1"), config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("key", "secret", ""))),
+		WithAcceleration(),
+		WithDualStack(),
+		WithRegionEndpoints(map[string]string{"ap-southeast-1": "https://s3-proxy.sg.example.com", "us-east-1": "https://unused.example.com"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var client *s3.Client
+	if !srv.(*s3Service).Bucket().As(&client) {
+		t.Fatal("bucket is not an S3 bucket")
+	}
+	o := client.Options()
+	if !o.UseAccelerate || o.EndpointOptions.UseDualStackEndpoint != aws.DualStackEndpointStateEnabled {
+		t.Errorf("UseAccelerate = %t, UseDualStackEndpoint = %v, want both enabled", o.UseAccelerate, o.EndpointOptions.UseDualStackEndpoint)
+	}
+	if o.BaseEndpoint == nil || *o.BaseEndpoint != "https://s3-proxy.sg.example.com" {
+		t.Errorf("BaseEndpoint = %v, want the endpoint of the region", o.BaseEndpoint)
+	}
+}
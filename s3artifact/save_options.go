@@ -0,0 +1,77 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3artifact
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"gocloud.dev/blob"
+)
+
+// SaveOptions are per-request object settings applied by Save.
+// The zero value keeps the bucket defaults.
+type SaveOptions struct {
+	// StorageClass of the new object, e.g. STANDARD_IA or INTELLIGENT_TIERING.
+	StorageClass types.StorageClass
+	// CacheControl, ContentDisposition and ContentEncoding set the
+	// corresponding HTTP headers served with the object.
+	CacheControl       string
+	ContentDisposition string
+	ContentEncoding    string
+}
+
+type saveOptionsKey struct{}
+
+// WithSaveOptions returns a copy of ctx that makes Save apply opts to the
+// object it writes:
+//
+//	ctx = s3artifact.WithSaveOptions(ctx, s3artifact.SaveOptions{StorageClass: types.StorageClassStandardIa})
+//	srv.Save(ctx, req)
+func WithSaveOptions(ctx context.Context, opts SaveOptions) context.Context {
+	return context.WithValue(ctx, saveOptionsKey{}, opts)
+}
+
+func saveOptionsFrom(ctx context.Context) SaveOptions {
+	opts, _ := ctx.Value(saveOptionsKey{}).(SaveOptions)
+	return opts
+}
+
+// apply sets the options on the blob writer options of a version, along
+// with the checksum algorithm of [WithServerChecksum] if checksum is set and
+// the encryption of [WithServerSideEncryption] if sse is set.
+func (o SaveOptions) apply(opts *blob.WriterOptions, checksum types.ChecksumAlgorithm, sse *ServerSideEncryption) {
+	opts.CacheControl = o.CacheControl
+	opts.ContentDisposition = o.ContentDisposition
+	opts.ContentEncoding = o.ContentEncoding
+	if o.StorageClass != "" || checksum != "" || sse != nil {
+		opts.BeforeWrite = func(asFunc func(any) bool) error {
+			var in *s3.PutObjectInput
+			if asFunc(&in) {
+				if o.StorageClass != "" {
+					in.StorageClass = o.StorageClass
+				}
+				if checksum != "" {
+					in.ChecksumAlgorithm = checksum
+				}
+				if sse != nil {
+					sse.apply(in)
+				}
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3artifact
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"gocloud.dev/blob"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+func TestSaveOptions(t *testing.T) {
+	s := newMemService(t)
+	ctx := WithSaveOptions(t.Context(), SaveOptions{
+		StorageClass:       types.StorageClassStandardIa,
+		CacheControl:       "max-age=3600",
+		ContentDisposition: `attachment; filename="report.pdf"`,
+		ContentEncoding:    "gzip",
+	})
+	if _, err := s.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "report.pdf",
+		Part: genai.NewPartFromBytes([]byte("%PDF"), "application/pdf"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	attrs, err := s.Bucket().Attributes(ctx, "app/user/session/report.pdf/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attrs.CacheControl != "max-age=3600" || attrs.ContentDisposition != `attachment; filename="report.pdf"` || attrs.ContentEncoding != "gzip" {
+		t.Errorf("Attributes() = %+v, want the headers from SaveOptions", attrs)
+	}
+	if attrs.ContentType != "application/pdf" {
+		t.Errorf("Attributes().ContentType = %q, want %q", attrs.ContentType, "application/pdf")
+	}
+}
+
+func TestWithServerChecksum(t *testing.T) {
+	opts := &blob.WriterOptions{}
+	SaveOptions{StorageClass: types.StorageClassStandardIa}.apply(opts, types.ChecksumAlgorithmCrc32c, nil)
+	in := &s3.PutObjectInput{}
+	if err := opts.BeforeWrite(func(i any) bool {
+		p, ok := i.(**s3.PutObjectInput)
+		if ok {
+			*p = in
+		}
+		return ok
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if in.ChecksumAlgorithm != types.ChecksumAlgorithmCrc32c || in.StorageClass != types.StorageClassStandardIa {
+		t.Errorf("PutObjectInput = %+v, want the CRC32C checksum and the storage class", in)
+	}
+
+	// The service records no checksum of its own.
+	s := newMemService(t, WithServerChecksum(types.ChecksumAlgorithmCrc32c))
+	if _, err := s.Save(t.Context(), &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file", Part: genai.NewPartFromText("v1"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	attrs, err := s.Bucket().Attributes(t.Context(), "app/user/session/file/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := attrs.Metadata[artifactx.ChecksumMetadataKey]; ok {
+		t.Errorf("Attributes().Metadata = %v, want no checksum computed by the service", attrs.Metadata)
+	}
+}
+
+func TestWithServerSideEncryption(t *testing.T) {
+	opts := &blob.WriterOptions{}
+	SaveOptions{}.apply(opts, "", &ServerSideEncryption{Algorithm: types.ServerSideEncryptionAwsKms, KMSKeyID: "alias/artifacts", BucketKey: true})
+	in := &s3.PutObjectInput{}
+	if err := opts.BeforeWrite(func(i any) bool {
+		p, ok := i.(**s3.PutObjectInput)
+		if ok {
+			*p = in
+		}
+		return ok
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if in.ServerSideEncryption != types.ServerSideEncryptionAwsKms || aws.ToString(in.SSEKMSKeyId) != "alias/artifacts" || !aws.ToBool(in.BucketKeyEnabled) {
+		t.Errorf("PutObjectInput = %+v, want KMS encryption with the key and a bucket key", in)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3artifact
+
+import (
+	"testing"
+
+	"gocloud.dev/blob/memblob"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/tests"
+)
+
+// newMemService returns a service backed by an in-memory bucket.
+func newMemService(t *testing.T, opts ...Option) *s3Service {
+	t.Helper()
+	srv, err := New(t.Context(), "bucket", append([]Option{WithBucket(memblob.OpenBucket(nil))}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv.(*s3Service)
+}
+
+func TestMemBlobArtifactService(t *testing.T) {
+	factory := func(t *testing.T) (artifact.Service, error) {
+		return newMemService(t), nil
+	}
+	tests.TestArtifactService(t, "MemBlob", factory)
+}
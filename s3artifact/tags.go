@@ -0,0 +1,65 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3artifact
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// TagRequests adds the tags of [artifactx.WithRequestTags] to the User-Agent
+// of every request of an S3 client, as "md/adk-<key>#<value>" entries that
+// S3 server access logs and CloudTrail record. Clients created by [New] are
+// configured with it; pass it to [s3.NewFromConfig] for clients given to
+// [WithClient]:
+//
+//	client := s3.NewFromConfig(cfg, s3artifact.TagRequests)
+func TagRequests(o *s3.Options) {
+	o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+		return stack.Build.Add(middleware.BuildMiddlewareFunc("ADKRequestTags", tagRequest), middleware.After)
+	})
+}
+
+func tagRequest(ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler) (middleware.BuildOutput, middleware.Metadata, error) {
+	tags := artifactx.RequestTagsFrom(ctx)
+	if req, ok := in.Request.(*smithyhttp.Request); ok && len(tags) > 0 {
+		ua := req.Header.Get("User-Agent")
+		for _, key := range slices.Sorted(maps.Keys(tags)) {
+			ua += " md/adk-" + userAgentToken(key) + "#" + userAgentToken(tags[key])
+		}
+		req.Header.Set("User-Agent", strings.TrimSpace(ua))
+	}
+	return next.HandleBuild(ctx, in)
+}
+
+// userAgentToken replaces the characters not allowed in a User-Agent token
+// with "-".
+func userAgentToken(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', strings.ContainsRune("!$%&'*+-.^_`|~", r):
+			return r
+		}
+		return '-'
+	}, s)
+}
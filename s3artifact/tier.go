@@ -0,0 +1,186 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3artifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// ErrArchived is returned by Load when the requested version has been moved
+// to an archival storage class and must be restored with
+// [Archiver.RestoreArchived] before it can be read.
+var ErrArchived = errors.New("artifact version is archived")
+
+// TierOptions configures [Archiver.Tier].
+type TierOptions struct {
+	// OlderThan selects the versions created more than OlderThan ago,
+	// according to the clock of the service.
+	OlderThan time.Duration
+	// StorageClass is the archival class to transition to. Defaults to GLACIER.
+	StorageClass types.StorageClass
+	// KeepLatest leaves the latest version of every artifact in its current class.
+	KeepLatest bool
+}
+
+// Archiver is implemented by the S3 service returned by [NewService] and
+// moves old versions to archival storage classes.
+type Archiver interface {
+	// Tier transitions versions matching opts to an archival storage class
+	// and returns the number of versions transitioned. Versions larger than
+	// a single S3 copy allows are skipped and reported in the returned
+	// error, wrapping [artifactx.ErrTooLarge], once the others are tiered.
+	Tier(ctx context.Context, opts TierOptions) (int, error)
+	// RestoreArchived initiates the restore of an archived version, which
+	// stays readable for the given number of days. Restores complete
+	// asynchronously; Load keeps returning [ErrArchived] until then.
+	RestoreArchived(ctx context.Context, req *artifact.LoadRequest, days int32) error
+}
+
+var _ Archiver = (*s3Service)(nil)
+
+// archivalClasses are the storage classes whose objects cannot be read without a restore.
+var archivalClasses = map[types.ObjectStorageClass]bool{
+	types.ObjectStorageClassGlacier:     true,
+	types.ObjectStorageClassDeepArchive: true,
+}
+
+// maxCopySize is the largest object S3 copies in a single request, 5 GiB.
+// It is a variable for tests.
+var maxCopySize int64 = 5 << 30
+
+// Tier implements [Archiver].
+func (s *s3Service) Tier(ctx context.Context, opts TierOptions) (int, error) {
+	if opts.StorageClass == "" {
+		opts.StorageClass = types.StorageClassGlacier
+	}
+	cutoff := s.clock.Now().Add(-opts.OlderThan)
+
+	// artifact prefix -> latest version, only needed with KeepLatest
+	latest := map[string]int64{}
+	if opts.KeepLatest {
+		err := s.Walk(ctx, func(ref artifactx.Ref) error {
+			prefix := s.buildKeyPrefix(ref.AppName, ref.UserID, ref.SessionID, ref.FileName)
+			latest[prefix] = max(latest[prefix], ref.Version)
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	tiered := 0
+	var tooLarge []error
+	iter := s.Bucket().List(nil)
+	for {
+		obj, err := iter.Next(ctx)
+		if err == io.EOF {
+			return tiered, errors.Join(tooLarge...)
+		}
+		if err != nil {
+			return tiered, fmt.Errorf("error iterating objects: %w", err)
+		}
+		ref, err := s.keys.ParseKey(obj.Key)
+		if err != nil {
+			continue
+		}
+		if opts.KeepLatest && latest[s.buildKeyPrefix(ref.AppName, ref.UserID, ref.SessionID, ref.FileName)] == ref.Version {
+			continue
+		}
+		var o types.Object
+		if obj.As(&o) && (o.StorageClass == types.ObjectStorageClass(opts.StorageClass) || archivalClasses[o.StorageClass]) {
+			continue
+		}
+		// The listing only reports when the storage last wrote the object,
+		// which copies, including tiering and restores, reset.
+		attrs, err := s.Bucket().Attributes(ctx, obj.Key)
+		if err != nil {
+			if gcerrors.Code(err) == gcerrors.NotFound {
+				continue // deleted since listing
+			}
+			return tiered, fmt.Errorf("failed to get attributes of %q: %w", obj.Key, err)
+		}
+		if _, created := artifactx.SplitCreated(attrs.Metadata, obj.ModTime); !created.Before(cutoff) {
+			continue
+		}
+		if obj.Size > maxCopySize {
+			tooLarge = append(tooLarge, fmt.Errorf("cannot transition %q of %d bytes with a single copy: %w", obj.Key, obj.Size, artifactx.ErrTooLarge))
+			continue
+		}
+
+		// An in-place copy with a new storage class transitions the object.
+		err = s.Bucket().Copy(ctx, obj.Key, obj.Key, &blob.CopyOptions{
+			BeforeCopy: func(asFunc func(any) bool) error {
+				var in *s3.CopyObjectInput
+				if asFunc(&in) {
+					in.StorageClass = opts.StorageClass
+					in.MetadataDirective = types.MetadataDirectiveCopy
+				}
+				return nil
+			},
+		})
+		if err != nil {
+			return tiered, fmt.Errorf("failed to transition %q to %s: %w", obj.Key, opts.StorageClass, err)
+		}
+		tiered++
+	}
+}
+
+// RestoreArchived implements [Archiver].
+func (s *s3Service) RestoreArchived(ctx context.Context, req *artifact.LoadRequest, days int32) error {
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("request validation failed: %w", err)
+	}
+	if req.Version == 0 {
+		return fmt.Errorf("a version is required to restore an archived artifact")
+	}
+	var client *s3.Client
+	if s.bucketName == "" || !s.Bucket().As(&client) {
+		return fmt.Errorf("restoring requires a bucket opened by name with an S3 client")
+	}
+	key := s.buildKey(req.AppName, req.UserID, req.SessionID, req.FileName, req.Version)
+	_, err := client.RestoreObject(ctx, &s3.RestoreObjectInput{
+		Bucket:         aws.String(s.bucketName),
+		Key:            aws.String(s.prefix + key),
+		RestoreRequest: &types.RestoreRequest{Days: aws.Int32(days)},
+	})
+	if err != nil {
+		var ae smithy.APIError
+		if errors.As(err, &ae) && ae.ErrorCode() == "RestoreAlreadyInProgress" {
+			return nil
+		}
+		return fmt.Errorf("failed to restore %q: %w", key, err)
+	}
+	return nil
+}
+
+// isArchived reports whether err is S3 refusing to read an archived object.
+func (s *s3Service) isArchived(err error) bool {
+	var ae smithy.APIError
+	return s.Bucket().ErrorAs(err, &ae) && ae.ErrorCode() == "InvalidObjectState"
+}
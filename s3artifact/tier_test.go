@@ -0,0 +1,89 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3artifact
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+func TestTier(t *testing.T) {
+	ctx := t.Context()
+	s := newMemService(t)
+	for _, fileName := range []string{"a", "a", "a", "b"} {
+		if _, err := s.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: fileName,
+			Part: genai.NewPartFromText("hello"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name string
+		opts TierOptions
+		want int
+	}{
+		{"too recent", TierOptions{OlderThan: time.Hour}, 0},
+		{"keep latest", TierOptions{OlderThan: -time.Hour, KeepLatest: true}, 2},
+		{"all", TierOptions{OlderThan: -time.Hour}, 4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := s.Tier(ctx, tc.opts)
+			if err != nil || got != tc.want {
+				t.Errorf("Tier(%+v) = (%d, %v), want (%d, nil)", tc.opts, got, err, tc.want)
+			}
+		})
+	}
+}
+
+// TestTierCreated tiers versions by the time they were saved according to
+// the clock of the service, not by when the storage last wrote them.
+func TestTierCreated(t *testing.T) {
+	ctx := t.Context()
+	clock := artifactx.NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := newMemService(t, WithClock(clock))
+	for range 2 {
+		if _, err := s.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+			Part: genai.NewPartFromText("hello"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := TierOptions{OlderThan: time.Hour}
+	clock.Advance(30 * time.Minute)
+	if got, err := s.Tier(ctx, opts); err != nil || got != 0 {
+		t.Errorf("Tier() after 30 minutes = (%d, %v), want (0, nil)", got, err)
+	}
+	clock.Advance(time.Hour)
+	if got, err := s.Tier(ctx, opts); err != nil || got != 2 {
+		t.Errorf("Tier() after 90 minutes = (%d, %v), want (2, nil)", got, err)
+	}
+
+	// Objects over the single copy limit are reported, not copied.
+	defer func(size int64) { maxCopySize = size }(maxCopySize)
+	maxCopySize = 1
+	got, err := s.Tier(ctx, opts)
+	if got != 0 || !errors.Is(err, artifactx.ErrTooLarge) {
+		t.Errorf("Tier() of objects over the copy limit = (%d, %v), want (0, error(%v))", got, err, artifactx.ErrTooLarge)
+	}
+}
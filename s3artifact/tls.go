@@ -0,0 +1,86 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3artifact
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// WithFIPS sends requests to the FIPS 140 validated endpoints of S3. It
+// only selects the endpoints; build with GOFIPS140 or run with
+// GODEBUG=fips140=on for the Go cryptography to run in FIPS mode as well.
+func WithFIPS() Option {
+	return func(o *options) {
+		o.s3Options = append(o.s3Options, func(so *s3.Options) {
+			so.EndpointOptions.UseFIPSEndpoint = aws.FIPSEndpointStateEnabled
+		})
+	}
+}
+
+// WithTLSConfig makes the HTTP client of the service, and of the STS
+// requests of [WithAssumeRole], use cfg for TLS connections, e.g. one
+// returned by [NewTLSConfig]. It has no effect on a client passed to
+// [WithClient].
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = cfg
+	}
+}
+
+// NewTLSConfig returns a TLS configuration trusting the certificates of the
+// PEM file caBundle in addition to the system ones, and presenting the
+// client certificate of certFile and keyFile for mutual TLS. Empty file
+// names leave the respective default.
+func NewTLSConfig(caBundle, certFile, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caBundle != "" {
+		pem, err := os.ReadFile(caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %q", caBundle)
+		}
+		cfg.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// newHTTPClient returns the default HTTP client of the SDK with cfg as TLS
+// configuration.
+func newHTTPClient(cfg *tls.Config) aws.HTTPClient {
+	return awshttp.NewBuildableClient().WithTransportOptions(func(tr *http.Transport) {
+		tr.TLSClientConfig = cfg
+	})
+}
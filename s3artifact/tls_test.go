@@ -0,0 +1,132 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3artifact
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// writeClientCert writes a self-signed client certificate and its key as
+// PEM files to dir.
+func writeClientCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "adk"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestWithTLSConfig(t *testing.T) {
+	clientCerts := make(chan int, 1)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case clientCerts <- len(r.TLS.PeerCertificates):
+		default:
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	caBundle := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caBundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := writeClientCert(t, dir)
+	tlsConfig, err := NewTLSConfig(caBundle, certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv, err := New(t.Context(), "bucket",
+		WithAWSConfig(
+			config.WithRegion("us-east-1"),
+			config.WithBaseEndpoint(server.URL),
+			config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("key", "secret", "")),
+		),
+		WithTLSConfig(tlsConfig),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.(artifactx.HealthChecker).HealthCheck(t.Context()); err != nil {
+		t.Fatalf("HealthCheck() over mutual TLS failed: %v", err)
+	}
+	if n := <-clientCerts; n != 1 {
+		t.Errorf("server got %d client certificates, want 1", n)
+	}
+
+	if _, err := NewTLSConfig(certFile+".missing", "", ""); err == nil {
+		t.Error("NewTLSConfig() with a missing CA bundle succeeded")
+	}
+}
+
+func TestWithFIPS(t *testing.T) {
+	srv, err := New(t.Context(), "bucket", WithAWSConfig(config.WithRegion("us-gov-west-1")), WithFIPS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var client *s3.Client
+	if !srv.(*s3Service).Bucket().As(&client) {
+		t.Fatal("bucket is not an S3 bucket")
+	}
+	if got := client.Options().EndpointOptions.UseFIPSEndpoint; got != aws.FIPSEndpointStateEnabled {
+		t.Errorf("UseFIPSEndpoint = %v, want enabled", got)
+	}
+}
@@ -0,0 +1,624 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schemaartifact
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Schema is a compiled JSON Schema. It supports the validation keywords of
+// draft 2020-12 for types, enumerations, numbers, strings, arrays, objects
+// and their combinations, and local references such as "#/$defs/item".
+// Annotations such as "format" and unknown keywords are ignored.
+type Schema struct {
+	// bool is the result of the boolean schemas true and false.
+	bool *bool
+	ref  *Schema
+
+	types  []string
+	enum   []any
+	consts []any // at most one
+
+	minimum, maximum, exclusiveMinimum, exclusiveMaximum, multipleOf *big.Rat
+
+	minLength, maxLength int
+	pattern              *regexp.Regexp
+
+	items              []*Schema // prefixItems then items
+	rest               *Schema   // items after prefixItems
+	minItems, maxItems int
+	uniqueItems        bool
+	properties         map[string]*Schema
+	patternProperties  []patternSchema
+	additional         *Schema
+	required           []string
+	minProps, maxProps int
+
+	allOf, anyOf, oneOf []*Schema
+	not                 *Schema
+}
+
+type patternSchema struct {
+	pattern *regexp.Regexp
+	schema  *Schema
+}
+
+// Compile parses the JSON Schema data.
+func Compile(data []byte) (*Schema, error) {
+	root, err := decodeJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	c := &compiler{root: root, schemas: map[string]*Schema{}}
+	s, err := c.compile(root, "")
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return s, nil
+}
+
+// MustCompile is [Compile] for schemas known to be valid, such as literals;
+// it panics on errors.
+func MustCompile(data []byte) *Schema {
+	s, err := Compile(data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// decodeJSON decodes one JSON value, keeping numbers as [json.Number].
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		var serr *json.SyntaxError
+		if errors.As(err, &serr) {
+			return nil, fmt.Errorf("invalid JSON at offset %d: %w", serr.Offset, err)
+		}
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid JSON: unexpected data at offset %d", dec.InputOffset())
+	}
+	return v, nil
+}
+
+type compiler struct {
+	root    any
+	schemas map[string]*Schema // by JSON pointer, for references
+}
+
+func (c *compiler) compile(v any, ptr string) (*Schema, error) {
+	if s, ok := c.schemas[ptr]; ok {
+		return s, nil
+	}
+	s := &Schema{minLength: -1, maxLength: -1, minItems: -1, maxItems: -1, minProps: -1, maxProps: -1}
+	c.schemas[ptr] = s
+	if b, ok := v.(bool); ok {
+		s.bool = &b
+		return s, nil
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object or a boolean", location(ptr))
+	}
+	var err error
+	sub := func(key string) (*Schema, error) {
+		return c.compile(m[key], ptr+"/"+escape(key))
+	}
+	subs := func(key string) ([]*Schema, error) {
+		a, ok := m[key].([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: %s must be an array", location(ptr), key)
+		}
+		out := make([]*Schema, len(a))
+		for i, e := range a {
+			if out[i], err = c.compile(e, ptr+"/"+key+"/"+strconv.Itoa(i)); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+	for key, value := range m {
+		switch key {
+		case "$ref":
+			ref, ok := value.(string)
+			if !ok || !strings.HasPrefix(ref, "#") {
+				return nil, fmt.Errorf("%s: only local references are supported, got %v", location(ptr), value)
+			}
+			target, err := url.PathUnescape(ref[1:])
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid reference %q: %w", location(ptr), ref, err)
+			}
+			tv, err := resolve(c.root, target)
+			if err != nil {
+				return nil, fmt.Errorf("%s: invalid reference %q: %w", location(ptr), ref, err)
+			}
+			s.ref, err = c.compile(tv, target)
+		case "type":
+			switch t := value.(type) {
+			case string:
+				s.types = []string{t}
+			case []any:
+				for _, e := range t {
+					name, ok := e.(string)
+					if !ok {
+						return nil, fmt.Errorf("%s: type must hold strings", location(ptr))
+					}
+					s.types = append(s.types, name)
+				}
+			default:
+				return nil, fmt.Errorf("%s: type must be a string or an array", location(ptr))
+			}
+		case "enum":
+			a, ok := value.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: enum must be an array", location(ptr))
+			}
+			s.enum = a
+		case "const":
+			s.consts = []any{value}
+		case "minimum":
+			s.minimum, err = number(value, ptr, key)
+		case "maximum":
+			s.maximum, err = number(value, ptr, key)
+		case "exclusiveMinimum":
+			s.exclusiveMinimum, err = number(value, ptr, key)
+		case "exclusiveMaximum":
+			s.exclusiveMaximum, err = number(value, ptr, key)
+		case "multipleOf":
+			if s.multipleOf, err = number(value, ptr, key); err == nil && s.multipleOf.Sign() <= 0 {
+				err = fmt.Errorf("%s: multipleOf must be positive", location(ptr))
+			}
+		case "minLength":
+			s.minLength, err = count(value, ptr, key)
+		case "maxLength":
+			s.maxLength, err = count(value, ptr, key)
+		case "pattern":
+			s.pattern, err = pattern(value, ptr)
+		case "prefixItems":
+			var prefix []*Schema
+			if prefix, err = subs(key); err == nil {
+				s.items = append(prefix, s.items...)
+			}
+		case "items":
+			s.rest, err = sub(key)
+		case "minItems":
+			s.minItems, err = count(value, ptr, key)
+		case "maxItems":
+			s.maxItems, err = count(value, ptr, key)
+		case "uniqueItems":
+			s.uniqueItems = value == true
+		case "properties":
+			props, ok := value.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: properties must be an object", location(ptr))
+			}
+			s.properties = map[string]*Schema{}
+			for name, pv := range props {
+				if s.properties[name], err = c.compile(pv, ptr+"/properties/"+escape(name)); err != nil {
+					return nil, err
+				}
+			}
+		case "patternProperties":
+			props, ok := value.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: patternProperties must be an object", location(ptr))
+			}
+			for expr, pv := range props {
+				ps := patternSchema{}
+				if ps.pattern, err = pattern(expr, ptr); err != nil {
+					return nil, err
+				}
+				if ps.schema, err = c.compile(pv, ptr+"/patternProperties/"+escape(expr)); err != nil {
+					return nil, err
+				}
+				s.patternProperties = append(s.patternProperties, ps)
+			}
+		case "additionalProperties":
+			s.additional, err = sub(key)
+		case "required":
+			a, ok := value.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: required must be an array", location(ptr))
+			}
+			for _, e := range a {
+				name, ok := e.(string)
+				if !ok {
+					return nil, fmt.Errorf("%s: required must hold strings", location(ptr))
+				}
+				s.required = append(s.required, name)
+			}
+		case "minProperties":
+			s.minProps, err = count(value, ptr, key)
+		case "maxProperties":
+			s.maxProps, err = count(value, ptr, key)
+		case "allOf":
+			s.allOf, err = subs(key)
+		case "anyOf":
+			s.anyOf, err = subs(key)
+		case "oneOf":
+			s.oneOf, err = subs(key)
+		case "not":
+			s.not, err = sub(key)
+		case "$defs", "definitions":
+			// compiled when referenced
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range s.types {
+		if !slices.Contains([]string{"null", "boolean", "object", "array", "number", "integer", "string"}, t) {
+			return nil, fmt.Errorf("%s: unknown type %q", location(ptr), t)
+		}
+	}
+	return s, nil
+}
+
+func number(v any, ptr, key string) (*big.Rat, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s must be a number", location(ptr), key)
+	}
+	r, ok := new(big.Rat).SetString(n.String())
+	if !ok {
+		return nil, fmt.Errorf("%s: invalid number %s", location(ptr), n)
+	}
+	return r, nil
+}
+
+func count(v any, ptr, key string) (int, error) {
+	n, ok := v.(json.Number)
+	if ok {
+		if i, err := strconv.Atoi(n.String()); err == nil && i >= 0 {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%s: %s must be a non-negative integer", location(ptr), key)
+}
+
+func pattern(v any, ptr string) (*regexp.Regexp, error) {
+	expr, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s: pattern must be a string", location(ptr))
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid pattern: %w", location(ptr), err)
+	}
+	return re, nil
+}
+
+// resolve returns the value at the JSON pointer ptr in v.
+func resolve(v any, ptr string) (any, error) {
+	if ptr == "" {
+		return v, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON pointer %q", ptr)
+	}
+	for _, token := range strings.Split(ptr[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch c := v.(type) {
+		case map[string]any:
+			child, ok := c[token]
+			if !ok {
+				return nil, fmt.Errorf("%q not found", token)
+			}
+			v = child
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, fmt.Errorf("index %q out of range", token)
+			}
+			v = c[i]
+		default:
+			return nil, fmt.Errorf("%q not found", token)
+		}
+	}
+	return v, nil
+}
+
+// escape escapes a reference token of a JSON pointer.
+func escape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// location formats the JSON pointer ptr as a URI fragment, "#" for the root.
+func location(ptr string) string {
+	return "#" + ptr
+}
+
+// Violation is a failed constraint of a schema.
+type Violation struct {
+	// Path is the JSON pointer of the offending value in the document, ""
+	// for the document itself.
+	Path string
+	// Keyword is the schema keyword that failed, such as "required".
+	Keyword string
+	Message string
+}
+
+func (v Violation) String() string {
+	return location(v.Path) + ": " + v.Message
+}
+
+// maxViolations bounds the violations reported for a document, so that a
+// long array of broken items does not produce a huge error.
+const maxViolations = 20
+
+// Validate checks the JSON document data against the schema and returns a
+// [*ValidationError] listing the violations, or the syntax error of data,
+// both matching [ErrInvalid].
+func (s *Schema) Validate(data []byte) error {
+	v, err := decodeJSON(data)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	var violations []Violation
+	s.validate(v, "", &violations)
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+func (s *Schema) validate(v any, ptr string, out *[]Violation) {
+	report := func(keyword, format string, args ...any) {
+		if len(*out) < maxViolations {
+			*out = append(*out, Violation{Path: ptr, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+	if s.bool != nil {
+		if !*s.bool {
+			report("false", "no value is allowed")
+		}
+		return
+	}
+	if s.ref != nil {
+		s.ref.validate(v, ptr, out)
+	}
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(t string) bool { return hasType(v, t) }) {
+		report("type", "expected %s, got %s", strings.Join(s.types, " or "), typeOf(v))
+		return
+	}
+	if s.enum != nil && !slices.ContainsFunc(s.enum, func(e any) bool { return equal(v, e) }) {
+		report("enum", "%s is not one of %s", encode(v), encode(s.enum))
+	}
+	if len(s.consts) > 0 && !equal(v, s.consts[0]) {
+		report("const", "%s is not %s", encode(v), encode(s.consts[0]))
+	}
+	switch v := v.(type) {
+	case json.Number:
+		s.validateNumber(v, report)
+	case string:
+		if n := utf8.RuneCountInString(v); s.minLength >= 0 && n < s.minLength {
+			report("minLength", "string of %d characters is shorter than %d", n, s.minLength)
+		} else if s.maxLength >= 0 && n > s.maxLength {
+			report("maxLength", "string of %d characters is longer than %d", n, s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			report("pattern", "%s does not match %q", encode(v), s.pattern)
+		}
+	case []any:
+		s.validateArray(v, ptr, out, report)
+	case map[string]any:
+		s.validateObject(v, ptr, out, report)
+	}
+	for _, sub := range s.allOf {
+		sub.validate(v, ptr, out)
+	}
+	if len(s.anyOf) > 0 && !slices.ContainsFunc(s.anyOf, func(sub *Schema) bool { return sub.matches(v) }) {
+		report("anyOf", "value matches none of the %d alternatives", len(s.anyOf))
+	}
+	if len(s.oneOf) > 0 {
+		n := 0
+		for _, sub := range s.oneOf {
+			if sub.matches(v) {
+				n++
+			}
+		}
+		if n != 1 {
+			report("oneOf", "value matches %d of the %d alternatives, want exactly one", n, len(s.oneOf))
+		}
+	}
+	if s.not != nil && s.not.matches(v) {
+		report("not", "value matches a disallowed schema")
+	}
+}
+
+// matches reports whether v is valid against s.
+func (s *Schema) matches(v any) bool {
+	var out []Violation
+	s.validate(v, "", &out)
+	return len(out) == 0
+}
+
+func (s *Schema) validateNumber(n json.Number, report func(keyword, format string, args ...any)) {
+	r, ok := new(big.Rat).SetString(n.String())
+	if !ok {
+		return
+	}
+	if s.minimum != nil && r.Cmp(s.minimum) < 0 {
+		report("minimum", "%s is less than %s", n, s.minimum.RatString())
+	}
+	if s.maximum != nil && r.Cmp(s.maximum) > 0 {
+		report("maximum", "%s is greater than %s", n, s.maximum.RatString())
+	}
+	if s.exclusiveMinimum != nil && r.Cmp(s.exclusiveMinimum) <= 0 {
+		report("exclusiveMinimum", "%s is not greater than %s", n, s.exclusiveMinimum.RatString())
+	}
+	if s.exclusiveMaximum != nil && r.Cmp(s.exclusiveMaximum) >= 0 {
+		report("exclusiveMaximum", "%s is not less than %s", n, s.exclusiveMaximum.RatString())
+	}
+	if s.multipleOf != nil && !new(big.Rat).Quo(r, s.multipleOf).IsInt() {
+		report("multipleOf", "%s is not a multiple of %s", n, s.multipleOf.RatString())
+	}
+}
+
+func (s *Schema) validateArray(a []any, ptr string, out *[]Violation, report func(keyword, format string, args ...any)) {
+	if s.minItems >= 0 && len(a) < s.minItems {
+		report("minItems", "array of %d items has fewer than %d", len(a), s.minItems)
+	}
+	if s.maxItems >= 0 && len(a) > s.maxItems {
+		report("maxItems", "array of %d items has more than %d", len(a), s.maxItems)
+	}
+	if s.uniqueItems {
+	unique:
+		for i := range a {
+			for j := range i {
+				if equal(a[i], a[j]) {
+					report("uniqueItems", "items %d and %d are equal", j, i)
+					break unique
+				}
+			}
+		}
+	}
+	for i, e := range a {
+		item := s.rest
+		if i < len(s.items) {
+			item = s.items[i]
+		}
+		if item != nil {
+			item.validate(e, ptr+"/"+strconv.Itoa(i), out)
+		}
+	}
+}
+
+func (s *Schema) validateObject(m map[string]any, ptr string, out *[]Violation, report func(keyword, format string, args ...any)) {
+	for _, name := range s.required {
+		if _, ok := m[name]; !ok {
+			report("required", "missing required property %q", name)
+		}
+	}
+	if s.minProps >= 0 && len(m) < s.minProps {
+		report("minProperties", "object of %d properties has fewer than %d", len(m), s.minProps)
+	}
+	if s.maxProps >= 0 && len(m) > s.maxProps {
+		report("maxProperties", "object of %d properties has more than %d", len(m), s.maxProps)
+	}
+	// Sorted, so that errors are stable.
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		value, path := m[name], ptr+"/"+escape(name)
+		matched := false
+		if sub, ok := s.properties[name]; ok {
+			matched = true
+			sub.validate(value, path, out)
+		}
+		for _, ps := range s.patternProperties {
+			if ps.pattern.MatchString(name) {
+				matched = true
+				ps.schema.validate(value, path, out)
+			}
+		}
+		if !matched && s.additional != nil {
+			if s.additional.bool != nil && !*s.additional.bool {
+				report("additionalProperties", "property %q is not allowed", name)
+				continue
+			}
+			s.additional.validate(value, path, out)
+		}
+	}
+}
+
+func hasType(v any, t string) bool {
+	switch v := v.(type) {
+	case nil:
+		return t == "null"
+	case bool:
+		return t == "boolean"
+	case string:
+		return t == "string"
+	case []any:
+		return t == "array"
+	case map[string]any:
+		return t == "object"
+	case json.Number:
+		if t == "number" {
+			return true
+		}
+		r, ok := new(big.Rat).SetString(v.String())
+		return t == "integer" && ok && r.IsInt()
+	}
+	return false
+}
+
+func typeOf(v any) string {
+	for _, t := range []string{"null", "boolean", "string", "array", "object", "integer", "number"} {
+		if hasType(v, t) {
+			return t
+		}
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// equal reports whether a and b are equal JSON values, comparing numbers
+// by value.
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			w, ok := b[k]
+			if !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		return ok && slices.EqualFunc(a, b, equal)
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, okx := new(big.Rat).SetString(a.String())
+		y, oky := new(big.Rat).SetString(b.String())
+		return okx && oky && x.Cmp(y) == 0
+	}
+	return a == b
+}
+
+// encode formats v for messages, truncated to keep errors readable.
+func encode(v any) string {
+	data, _ := json.Marshal(v)
+	if s := []rune(string(data)); len(s) > 64 {
+		return string(s[:61]) + "..."
+	}
+	return string(data)
+}
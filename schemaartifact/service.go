@@ -0,0 +1,204 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schemaartifact provides an [artifact.Service] decorator that
+// validates structured artifacts against JSON Schemas on Save, so that
+// malformed output of LLM tools is rejected with a detailed error instead
+// of breaking the steps that read it:
+//
+//	plan := schemaartifact.MustCompile(planSchema)
+//	srv, err := schemaartifact.NewService(backend, schemaartifact.Options{
+//		Rules: []schemaartifact.Rule{{Pattern: "plan*.json", Schema: plan}},
+//	})
+//
+// A Save of a file matching the pattern of a rule fails with a
+// [*ValidationError] listing the violations, such as
+// "#/steps/0: missing required property \"title\"", unless the content is
+// JSON valid against the schema of every matching rule. Files matching no
+// rule are saved as is.
+//
+// Save and [artifactx.JSONUpdater] are decorated. The other capabilities of
+// the decorated service that write, such as [artifactx.Uploader], are not
+// forwarded by [artifactx.As], so that nothing is written unvalidated.
+package schemaartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// ErrInvalid is matched by the errors of Saves rejected by a schema.
+var ErrInvalid = errors.New("artifact does not match its schema")
+
+// ValidationError lists the violations of a schema by a document. It
+// matches [ErrInvalid].
+type ValidationError struct {
+	// FileName is the artifact, if known.
+	FileName   string
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	if e.FileName != "" {
+		fmt.Fprintf(&b, "artifact %q does not match its schema: ", e.FileName)
+	} else {
+		b.WriteString(ErrInvalid.Error() + ": ")
+	}
+	for i, v := range e.Violations {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString(v.String())
+	}
+	return b.String()
+}
+
+// Is reports whether target is [ErrInvalid].
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+// Rule applies a schema to the files whose name matches a pattern.
+type Rule struct {
+	// Pattern is matched against the file name with [path.Match], e.g.
+	// "*.json" or "user:profile.json".
+	Pattern string
+	Schema  *Schema
+}
+
+// Options configures [NewService].
+type Options struct {
+	Rules []Rule
+}
+
+// Service is an [artifact.Service] that validates structured artifacts on
+// Save.
+type Service struct {
+	artifact.Service
+	rules []Rule
+}
+
+var (
+	_ artifactx.Forwarder   = (*Service)(nil)
+	_ artifactx.JSONUpdater = (*Service)(nil)
+)
+
+// NewService returns a service that validates Saves against the rules of
+// opts before forwarding them to next, or an error if a rule has a malformed
+// pattern or no schema.
+func NewService(next artifact.Service, opts Options) (*Service, error) {
+	for _, r := range opts.Rules {
+		if _, err := path.Match(r.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", r.Pattern, err)
+		}
+		if r.Schema == nil {
+			return nil, fmt.Errorf("rule %q has no schema", r.Pattern)
+		}
+	}
+	return &Service{Service: next, rules: opts.Rules}, nil
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// Forwards implements [artifactx.Forwarder]. Only the capabilities of the
+// decorated service that read are forwarded, since writes are validated.
+func (s *Service) Forwards(capability any) bool {
+	return artifactx.IsReadCapability(capability)
+}
+
+// Validate checks data, the content of fileName, against the schemas of
+// the rules matching fileName.
+func (s *Service) Validate(fileName string, data []byte) error {
+	for _, r := range s.rules {
+		if ok, _ := path.Match(r.Pattern, fileName); !ok {
+			continue
+		}
+		if err := r.Schema.Validate(data); err != nil {
+			var verr *ValidationError
+			if errors.As(err, &verr) {
+				verr.FileName = fileName
+				return verr
+			}
+			return fmt.Errorf("artifact %q: %w", fileName, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) matches(fileName string) bool {
+	for _, r := range s.rules {
+		if ok, _ := path.Match(r.Pattern, fileName); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Save implements [artifact.Service].
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	if !s.matches(req.FileName) {
+		return s.Service.Save(ctx, req)
+	}
+	data, ok := dataOf(req.Part)
+	if !ok {
+		return nil, fmt.Errorf("artifact %q: %w: content is not inline", req.FileName, ErrInvalid)
+	}
+	if err := s.Validate(req.FileName, data); err != nil {
+		return nil, err
+	}
+	return s.Service.Save(ctx, req)
+}
+
+// UpdateJSON implements [artifactx.JSONUpdater], validating the patched
+// document before the decorated service saves it.
+func (s *Service) UpdateJSON(ctx context.Context, req *artifactx.UpdateJSONRequest) (*artifact.SaveResponse, error) {
+	if !s.matches(req.FileName) {
+		return artifactx.UpdateJSON(ctx, s.Service, req)
+	}
+	r := *req
+	r.Check = func(doc []byte) error {
+		if req.Check != nil {
+			if err := req.Check(doc); err != nil {
+				return err
+			}
+		}
+		return s.Validate(req.FileName, doc)
+	}
+	return artifactx.UpdateJSON(ctx, s.Service, &r)
+}
+
+// dataOf returns the content of parts with inline content.
+func dataOf(part *genai.Part) ([]byte, bool) {
+	switch {
+	case part == nil:
+		return nil, false
+	case part.InlineData != nil:
+		return part.InlineData.Data, true
+	case part.Text != "":
+		return []byte(part.Text), true
+	}
+	return nil, false
+}
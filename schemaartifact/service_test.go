@@ -0,0 +1,192 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schemaartifact_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"github.com/chinglinwen/adk-artifact/schemaartifact"
+)
+
+var planSchema = []byte(`{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"required": ["goal", "steps"],
+	"properties": {
+		"goal": {"type": "string", "minLength": 1},
+		"priority": {"enum": ["low", "high"]},
+		"steps": {"type": "array", "maxItems": 3, "items": {"$ref": "#/$defs/step"}}
+	},
+	"additionalProperties": false,
+	"$defs": {
+		"step": {
+			"type": "object",
+			"required": ["title"],
+			"properties": {
+				"title": {"type": "string"},
+				"hours": {"type": "number", "exclusiveMinimum": 0, "multipleOf": 0.5},
+				"next": {"$ref": "#/$defs/step"}
+			}
+		}
+	}
+}`)
+
+func TestSchemaValidate(t *testing.T) {
+	schema, err := schemaartifact.Compile(planSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		doc  string
+		want []string // violations
+	}{
+		{`{"goal":"ship","steps":[{"title":"build","hours":1.5,"next":{"title":"test"}}]}`, nil},
+		{`{"goal":"","steps":[]}`, []string{"#/goal: string of 0 characters is shorter than 1"}},
+		{`{"steps":[{"hours":0}],"extra":1}`, []string{
+			`#: missing required property "goal"`,
+			`#: property "extra" is not allowed`,
+			`#/steps/0: missing required property "title"`,
+			"#/steps/0/hours: 0 is not greater than 0",
+		}},
+		{`{"goal":"ship","steps":[{"title":"a","next":{"title":1}}],"priority":"urgent"}`, []string{
+			`#/priority: "urgent" is not one of ["low","high"]`,
+			"#/steps/0/next/title: expected string, got integer",
+		}},
+		{`{"goal":"ship","steps":[{"title":"a","hours":0.7}]}`, []string{"#/steps/0/hours: 0.7 is not a multiple of 1/2"}},
+		{`{"goal":"ship","steps":[{"title":"a"},{"title":"b"},{"title":"c"},{"title":"d"}]}`, []string{"#/steps: array of 4 items has more than 3"}},
+		{`[]`, []string{"#: expected object, got array"}},
+	} {
+		err := schema.Validate([]byte(tc.doc))
+		if tc.want == nil {
+			if err != nil {
+				t.Errorf("Validate(%s) = %v, want nil", tc.doc, err)
+			}
+			continue
+		}
+		var verr *schemaartifact.ValidationError
+		if !errors.As(err, &verr) || !errors.Is(err, schemaartifact.ErrInvalid) {
+			t.Errorf("Validate(%s) = %v, want a ValidationError", tc.doc, err)
+			continue
+		}
+		var got []string
+		for _, v := range verr.Violations {
+			got = append(got, v.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+			t.Errorf("Validate(%s) violations:\n%s\nwant:\n%s", tc.doc, strings.Join(got, "\n"), strings.Join(tc.want, "\n"))
+		}
+	}
+	if err := schema.Validate([]byte(`{"goal":`)); !errors.Is(err, schemaartifact.ErrInvalid) {
+		t.Errorf("Validate() of broken JSON = %v, want error(%v)", err, schemaartifact.ErrInvalid)
+	}
+}
+
+func TestSchemaCombinations(t *testing.T) {
+	schema := schemaartifact.MustCompile([]byte(`{
+		"anyOf": [{"type": "string", "pattern": "^v[0-9]+$"}, {"type": "integer", "minimum": 1}],
+		"not": {"const": 7}
+	}`))
+	for doc, valid := range map[string]bool{
+		`"v2"`: true, `3`: true, `3.0`: true, `"x"`: false, `0`: false, `7`: false, `null`: false,
+	} {
+		if err := schema.Validate([]byte(doc)); (err == nil) != valid {
+			t.Errorf("Validate(%s) = %v, want valid %v", doc, err, valid)
+		}
+	}
+	oneOf := schemaartifact.MustCompile([]byte(`{"oneOf": [{"type": "number"}, {"type": "integer"}]}`))
+	if err := oneOf.Validate([]byte(`1`)); err == nil {
+		t.Error("Validate() of a value matching two alternatives of oneOf succeeded")
+	}
+	if err := oneOf.Validate([]byte(`1.5`)); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+	unique := schemaartifact.MustCompile([]byte(`{"uniqueItems": true}`))
+	if err := unique.Validate([]byte(`[1, {"a": [2]}, 1.0]`)); err == nil || !strings.Contains(err.Error(), "items 0 and 2 are equal") {
+		t.Errorf("Validate() of repeated items = %v, want a uniqueItems violation", err)
+	}
+	for _, bad := range []string{`{"type":"text"}`, `{"$ref":"https://example.com/s.json"}`, `{"$ref":"#/$defs/missing"}`, `{"pattern":"("}`, `{"minLength":-1}`, `3`} {
+		if _, err := schemaartifact.Compile([]byte(bad)); err == nil {
+			t.Errorf("Compile(%s) succeeded", bad)
+		}
+	}
+}
+
+func TestService(t *testing.T) {
+	ctx := t.Context()
+	backend, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := schemaartifact.NewService(backend, schemaartifact.Options{
+		Rules: []schemaartifact.Rule{{Pattern: "plan*.json", Schema: schemaartifact.MustCompile(planSchema)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	save := func(fileName string, part *genai.Part) error {
+		_, err := srv.Save(ctx, &artifact.SaveRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: fileName, Part: part})
+		return err
+	}
+	if err := save("plan.json", genai.NewPartFromBytes([]byte(`{"goal":"ship","steps":[]}`), "application/json")); err != nil {
+		t.Fatalf("Save() of a valid plan failed: %v", err)
+	}
+	err = save("plan-v2.json", genai.NewPartFromText(`{"goal":"ship","steps":[{}]}`))
+	if !errors.Is(err, schemaartifact.ErrInvalid) || !strings.Contains(err.Error(), `artifact "plan-v2.json" does not match its schema: #/steps/0: missing required property "title"`) {
+		t.Errorf("Save() of an invalid plan = %v, want a detailed error", err)
+	}
+	if err := save("plan.json", genai.NewPartFromText("Sure! Here is the plan:")); !errors.Is(err, schemaartifact.ErrInvalid) {
+		t.Errorf("Save() of prose = %v, want error(%v)", err, schemaartifact.ErrInvalid)
+	}
+	if err := save("plan.json", genai.NewPartFromURI("gs://bucket/plan.json", "application/json")); !errors.Is(err, schemaartifact.ErrInvalid) {
+		t.Errorf("Save() of file data = %v, want error(%v)", err, schemaartifact.ErrInvalid)
+	}
+	if err := save("notes.txt", genai.NewPartFromText("free text")); err != nil {
+		t.Errorf("Save() of a file without schema failed: %v", err)
+	}
+
+	update := &artifactx.UpdateJSONRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "plan.json",
+		Patch: []byte(`[{"op":"add","path":"/steps/-","value":{"hours":2}}]`),
+	}
+	if _, err := artifactx.UpdateJSON(ctx, srv, update); !errors.Is(err, schemaartifact.ErrInvalid) {
+		t.Errorf("UpdateJSON() breaking the schema = %v, want error(%v)", err, schemaartifact.ErrInvalid)
+	}
+	update.Patch = []byte(`[{"op":"add","path":"/steps/-","value":{"title":"build"}}]`)
+	if resp, err := artifactx.UpdateJSON(ctx, srv, update); err != nil || resp.Version != 2 {
+		t.Errorf("UpdateJSON() = (%v, %v), want version 2", resp, err)
+	}
+}
+
+func TestNewServiceInvalidRules(t *testing.T) {
+	backend, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []schemaartifact.Rule{
+		{Pattern: "plan[.json", Schema: schemaartifact.MustCompile(planSchema)},
+		{Pattern: "plan*.json"},
+	} {
+		if srv, err := schemaartifact.NewService(backend, schemaartifact.Options{Rules: []schemaartifact.Rule{r}}); err == nil {
+			t.Errorf("NewService() with rule %q = %v, want an error", r.Pattern, srv)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semanticartifact
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+type memoryIndex struct {
+	mu      sync.RWMutex
+	vectors map[artifactx.Ref][]float32
+}
+
+// NewMemoryIndex returns an [Index] that keeps vectors in memory and searches
+// them exhaustively. It suits tests and small deployments.
+func NewMemoryIndex() Index {
+	return &memoryIndex{vectors: map[artifactx.Ref][]float32{}}
+}
+
+func (m *memoryIndex) Upsert(ctx context.Context, ref artifactx.Ref, vector []float32) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.vectors[ref] = slices.Clone(vector)
+	return nil
+}
+
+func (m *memoryIndex) Delete(ctx context.Context, ref artifactx.Ref) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ref.Version != 0 {
+		delete(m.vectors, ref)
+		return nil
+	}
+	for r := range m.vectors {
+		if r.AppName == ref.AppName && r.UserID == ref.UserID && r.SessionID == ref.SessionID && r.FileName == ref.FileName {
+			delete(m.vectors, r)
+		}
+	}
+	return nil
+}
+
+func (m *memoryIndex) Search(ctx context.Context, appName, userID string, vector []float32, k int) ([]Match, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var matches []Match
+	for ref, v := range m.vectors {
+		if ref.AppName != appName || ref.UserID != userID {
+			continue
+		}
+		matches = append(matches, Match{Ref: ref, Score: cosine(vector, v)})
+	}
+	slices.SortFunc(matches, func(a, b Match) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	if k >= 0 && len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+// cosine returns the cosine similarity of a and b, or 0 if either is a zero
+// vector or their lengths differ.
+func cosine(a, b []float32) float32 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(na) * math.Sqrt(nb)))
+}
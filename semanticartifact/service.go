@@ -0,0 +1,171 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package semanticartifact provides an [artifact.Service] decorator that
+// embeds text artifacts on Save and searches them by meaning.
+//
+// Embeddings are computed by a pluggable [Embedder], such as the one returned
+// by [NewGenAIEmbedder], and stored in a pluggable [Index], such as the one
+// returned by [NewMemoryIndex].
+package semanticartifact
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Embedder computes embedding vectors for texts.
+type Embedder interface {
+	// Embed returns one vector per text, in order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Match is a search result.
+type Match struct {
+	Ref artifactx.Ref
+	// Score is the cosine similarity between the query and the artifact, in [-1, 1].
+	Score float32
+}
+
+// Index stores artifact embeddings.
+type Index interface {
+	// Upsert stores the vector of a version, replacing any previous one.
+	Upsert(ctx context.Context, ref artifactx.Ref, vector []float32) error
+	// Delete removes the vector of a version, or of all versions of the
+	// artifact if ref.Version is zero.
+	Delete(ctx context.Context, ref artifactx.Ref) error
+	// Search returns the k versions of the user's artifacts closest to vector,
+	// best first.
+	Search(ctx context.Context, appName, userID string, vector []float32, k int) ([]Match, error)
+}
+
+// Service is an [artifact.Service] that indexes the embeddings of text artifacts.
+type Service struct {
+	artifact.Service
+	embedder Embedder
+	index    Index
+}
+
+var _ artifactx.Wrapper = (*Service)(nil)
+
+// NewService returns a service that saves to next and indexes every text
+// artifact it saves with embedder into index.
+func NewService(next artifact.Service, embedder Embedder, index Index) *Service {
+	return &Service{Service: next, embedder: embedder, index: index}
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// textOf returns the text content of part, if it has any.
+func textOf(part *genai.Part) (string, bool) {
+	if part.Text != "" {
+		return part.Text, true
+	}
+	if part.InlineData == nil {
+		return "", false
+	}
+	mediaType, _, _ := mime.ParseMediaType(part.InlineData.MIMEType)
+	if strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		return string(part.InlineData.Data), true
+	}
+	return "", false
+}
+
+// Save implements [artifact.Service]. Text artifacts are embedded after they
+// are saved; if indexing fails the version stays saved and the error is
+// returned along with the response.
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	resp, err := s.Service.Save(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	text, ok := textOf(req.Part)
+	if !ok || strings.TrimSpace(text) == "" {
+		return resp, nil
+	}
+	ref := artifactx.Ref{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: resp.Version,
+	}
+	vectors, err := s.embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return resp, fmt.Errorf("failed to embed %s: %w", ref, err)
+	}
+	if len(vectors) != 1 {
+		return resp, fmt.Errorf("failed to embed %s: embedder returned %d vectors, want 1", ref, len(vectors))
+	}
+	if err := s.index.Upsert(ctx, ref, vectors[0]); err != nil {
+		return resp, fmt.Errorf("failed to index %s: %w", ref, err)
+	}
+	return resp, nil
+}
+
+// Delete implements [artifact.Service].
+func (s *Service) Delete(ctx context.Context, req *artifact.DeleteRequest) error {
+	if err := s.Service.Delete(ctx, req); err != nil {
+		return err
+	}
+	return s.index.Delete(ctx, artifactx.Ref{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: req.Version,
+	})
+}
+
+// SemanticSearch returns the k artifact versions of the user, across all of
+// their sessions, whose content is closest in meaning to query.
+func (s *Service) SemanticSearch(ctx context.Context, appName, userID, query string, k int) ([]Match, error) {
+	vectors, err := s.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("failed to embed query: embedder returned %d vectors, want 1", len(vectors))
+	}
+	return s.index.Search(ctx, appName, userID, vectors[0], k)
+}
+
+type genaiEmbedder struct {
+	client *genai.Client
+	model  string
+}
+
+// NewGenAIEmbedder returns an [Embedder] backed by a Gemini embedding model,
+// e.g. "gemini-embedding-001".
+func NewGenAIEmbedder(client *genai.Client, model string) Embedder {
+	return &genaiEmbedder{client: client, model: model}
+}
+
+func (e *genaiEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	contents := make([]*genai.Content, len(texts))
+	for i, text := range texts {
+		contents[i] = genai.NewContentFromText(text, genai.RoleUser)
+	}
+	resp, err := e.client.Models.EmbedContent(ctx, e.model, contents, nil)
+	if err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, len(resp.Embeddings))
+	for i, emb := range resp.Embeddings {
+		vectors[i] = emb.Values
+	}
+	return vectors, nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semanticartifact_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/semanticartifact"
+)
+
+// topicEmbedder embeds a text as the number of occurrences of each topic word.
+type topicEmbedder []string
+
+func (e topicEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = make([]float32, len(e))
+		for j, topic := range e {
+			vectors[i][j] = float32(strings.Count(strings.ToLower(text), topic))
+		}
+	}
+	return vectors, nil
+}
+
+func TestSemanticSearch(t *testing.T) {
+	ctx := t.Context()
+	srv := semanticartifact.NewService(artifact.InMemoryService(), topicEmbedder{"cat", "rocket", "soup"}, semanticartifact.NewMemoryIndex())
+
+	save := func(sessionID, fileName string, part *genai.Part) int64 {
+		t.Helper()
+		resp, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: sessionID, FileName: fileName, Part: part,
+		})
+		if err != nil {
+			t.Fatalf("Save(%q) failed: %v", fileName, err)
+		}
+		return resp.Version
+	}
+	save("s1", "pets.txt", genai.NewPartFromText("My cat sleeps. The cat purrs."))
+	save("s1", "launch.md", genai.NewPartFromBytes([]byte("# Rocket launch\nThe rocket lifts off."), "text/markdown"))
+	save("s2", "recipe.json", genai.NewPartFromBytes([]byte(`{"dish":"tomato soup"}`), "application/json"))
+	save("s2", "photo.png", genai.NewPartFromBytes([]byte{0x89, 'P', 'N', 'G'}, "image/png"))
+	// Another user's artifacts never match.
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "other", SessionID: "s1", FileName: "rockets.txt", Part: genai.NewPartFromText("rocket rocket"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	matches, err := srv.SemanticSearch(ctx, "app", "user", "when does the rocket launch?", 2)
+	if err != nil {
+		t.Fatalf("SemanticSearch() failed: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("SemanticSearch() returned %d matches, want 2", len(matches))
+	}
+	want := artifactx.Ref{AppName: "app", UserID: "user", SessionID: "s1", FileName: "launch.md", Version: 1}
+	if matches[0].Ref != want || matches[0].Score < 0.99 {
+		t.Errorf("SemanticSearch()[0] = %+v, want %v with score 1", matches[0], want)
+	}
+
+	matches, err = srv.SemanticSearch(ctx, "app", "user", "soup", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 3 {
+		t.Errorf("SemanticSearch() returned %d matches, want the 3 text artifacts", len(matches))
+	}
+	if matches[0].Ref.FileName != "recipe.json" {
+		t.Errorf("SemanticSearch()[0] = %v, want recipe.json", matches[0].Ref)
+	}
+
+	if err := srv.Delete(ctx, &artifact.DeleteRequest{AppName: "app", UserID: "user", SessionID: "s2", FileName: "recipe.json"}); err != nil {
+		t.Fatal(err)
+	}
+	matches, err = srv.SemanticSearch(ctx, "app", "user", "soup", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range matches {
+		if m.Ref.FileName == "recipe.json" {
+			t.Errorf("SemanticSearch() returned deleted artifact %v", m.Ref)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package shadowartifact provides an [artifact.Service] decorator for
+// migrating between backends.
+//
+// The primary backend stays authoritative: every result returned to callers
+// comes from it. Writes are mirrored to the shadow backend, and reads are
+// repeated against it in the background and compared, with divergences
+// logged. Once no divergences are reported, the shadow can take over.
+package shadowartifact
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Service is an [artifact.Service] that mirrors a primary backend to a shadow.
+type Service struct {
+	artifact.Service
+	shadow      artifact.Service
+	logger      *slog.Logger
+	wg          sync.WaitGroup
+	divergences atomic.Int64
+}
+
+var _ artifactx.Wrapper = (*Service)(nil)
+
+// NewService returns a service backed by primary that mirrors writes to
+// shadow and compares reads with it. Divergences and shadow failures are
+// logged to logger, or to [slog.Default] if it is nil.
+func NewService(primary, shadow artifact.Service, logger *slog.Logger) *Service {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Service{Service: primary, shadow: shadow, logger: logger.With("component", "shadowartifact")}
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// Wait blocks until the background comparisons started so far are done.
+func (s *Service) Wait() {
+	s.wg.Wait()
+}
+
+// Divergences returns the number of divergences found so far, including
+// failed shadow operations.
+func (s *Service) Divergences() int64 {
+	return s.divergences.Load()
+}
+
+func (s *Service) diverged(msg string, args ...any) {
+	s.divergences.Add(1)
+	s.logger.Warn(msg, args...)
+}
+
+// compare runs fn in the background, detached from the cancellation of ctx
+// so that a finished request does not abort its comparison, and with
+// [artifactx.Background] priority.
+func (s *Service) compare(ctx context.Context, fn func(ctx context.Context)) {
+	ctx = artifactx.WithPriority(context.WithoutCancel(ctx), artifactx.Background)
+	s.wg.Go(func() { fn(ctx) })
+}
+
+// Save implements [artifact.Service]. The version is saved to the primary
+// first, then to the shadow under the same version number.
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	resp, err := s.Service.Save(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	mirror := *req
+	mirror.Version = resp.Version
+	shadowResp, err := s.shadow.Save(ctx, &mirror)
+	switch {
+	case err != nil:
+		s.diverged("shadow save failed", "file", req.FileName, "version", resp.Version, "error", err)
+	case shadowResp.Version != resp.Version:
+		s.diverged("shadow saved a different version", "file", req.FileName, "version", resp.Version, "shadow_version", shadowResp.Version)
+	}
+	return resp, nil
+}
+
+// Load implements [artifact.Service].
+func (s *Service) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	resp, err := s.Service.Load(ctx, req)
+	s.compare(ctx, func(ctx context.Context) {
+		shadowResp, shadowErr := s.shadow.Load(ctx, req)
+		args := []any{"file", req.FileName, "version", req.Version}
+		switch {
+		case (err == nil) != (shadowErr == nil):
+			s.diverged("shadow load result differs", append(args, "error", err, "shadow_error", shadowErr)...)
+		case err == nil:
+			want, wantType, _ := artifactx.EncodePart(resp.Part)
+			got, gotType, _ := artifactx.EncodePart(shadowResp.Part)
+			if wantType != gotType || !bytes.Equal(want, got) {
+				s.diverged("shadow load content differs", append(args,
+					"content_type", wantType, "shadow_content_type", gotType, "size", len(want), "shadow_size", len(got))...)
+			}
+		}
+	})
+	return resp, err
+}
+
+// Delete implements [artifact.Service].
+func (s *Service) Delete(ctx context.Context, req *artifact.DeleteRequest) error {
+	if err := s.Service.Delete(ctx, req); err != nil {
+		return err
+	}
+	if err := s.shadow.Delete(ctx, req); err != nil {
+		s.diverged("shadow delete failed", "file", req.FileName, "version", req.Version, "error", err)
+	}
+	return nil
+}
+
+// List implements [artifact.Service].
+func (s *Service) List(ctx context.Context, req *artifact.ListRequest) (*artifact.ListResponse, error) {
+	resp, err := s.Service.List(ctx, req)
+	if err == nil {
+		s.compare(ctx, func(ctx context.Context) {
+			shadowResp, err := s.shadow.List(ctx, req)
+			if err != nil {
+				s.diverged("shadow list failed", "session", req.SessionID, "error", err)
+				return
+			}
+			want, got := slices.Sorted(slices.Values(resp.FileNames)), slices.Sorted(slices.Values(shadowResp.FileNames))
+			if !slices.Equal(want, got) {
+				s.diverged("shadow list differs", "session", req.SessionID, "files", want, "shadow_files", got)
+			}
+		})
+	}
+	return resp, err
+}
+
+// Versions implements [artifact.Service].
+func (s *Service) Versions(ctx context.Context, req *artifact.VersionsRequest) (*artifact.VersionsResponse, error) {
+	resp, err := s.Service.Versions(ctx, req)
+	if err == nil {
+		s.compare(ctx, func(ctx context.Context) {
+			shadowResp, err := s.shadow.Versions(ctx, req)
+			if err != nil {
+				s.diverged("shadow versions failed", "file", req.FileName, "error", err)
+				return
+			}
+			want, got := slices.Sorted(slices.Values(resp.Versions)), slices.Sorted(slices.Values(shadowResp.Versions))
+			if !slices.Equal(want, got) {
+				s.diverged("shadow versions differ", "file", req.FileName, "versions", want, "shadow_versions", got)
+			}
+		})
+	}
+	return resp, err
+}
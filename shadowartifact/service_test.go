@@ -0,0 +1,110 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shadowartifact_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"github.com/chinglinwen/adk-artifact/shadowartifact"
+)
+
+type failingSave struct {
+	artifact.Service
+}
+
+func (failingSave) Save(context.Context, *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	return nil, errors.New("shadow unavailable")
+}
+
+func newFS(t *testing.T) artifact.Service {
+	t.Helper()
+	srv, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+func TestShadow(t *testing.T) {
+	ctx := t.Context()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	primary, shadow := newFS(t), newFS(t)
+	srv := shadowartifact.NewService(primary, shadow, logger)
+
+	save := &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+		Part: genai.NewPartFromBytes([]byte("data"), "application/octet-stream"),
+	}
+	load := &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"}
+	versions := &artifact.VersionsRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"}
+
+	for range 2 {
+		if _, err := srv.Save(ctx, save); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+	}
+	if _, err := srv.Load(ctx, load); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Versions(ctx, versions); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.List(ctx, &artifact.ListRequest{AppName: "app", UserID: "user", SessionID: "session"}); err != nil {
+		t.Fatal(err)
+	}
+	srv.Wait()
+	if n := srv.Divergences(); n != 0 {
+		t.Fatalf("Divergences() = %d after mirrored writes, want 0", n)
+	}
+
+	// A write that bypasses the decorator makes the backends diverge.
+	if _, err := shadow.Save(ctx, save); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Load(ctx, load); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Versions(ctx, versions); err != nil {
+		t.Fatal(err)
+	}
+	srv.Wait()
+	if n := srv.Divergences(); n != 1 {
+		t.Errorf("Divergences() = %d, want 1 for the differing versions", n)
+	}
+}
+
+func TestShadowFailureIsNotReturned(t *testing.T) {
+	ctx := t.Context()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	srv := shadowartifact.NewService(newFS(t), failingSave{newFS(t)}, logger)
+	resp, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+		Part: genai.NewPartFromText("data"),
+	})
+	if err != nil || resp.Version != 1 {
+		t.Fatalf("Save() = (%v, %v), want version 1 from the primary", resp, err)
+	}
+	if n := srv.Divergences(); n != 1 {
+		t.Errorf("Divergences() = %d, want 1", n)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shardartifact
+
+import (
+	"hash/fnv"
+	"slices"
+	"strconv"
+)
+
+// DefaultReplicas is the number of points of every shard on the ring unless
+// configured.
+const DefaultReplicas = 128
+
+// Ring is a consistent hash ring of shard names. Adding or removing a shard
+// only moves the keys of that shard, about 1/n of all keys.
+type Ring struct {
+	points []uint64
+	names  map[uint64]string
+}
+
+// NewRing returns a ring of names with replicas points per name, or
+// [DefaultReplicas] if replicas is not positive.
+func NewRing(names []string, replicas int) *Ring {
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+	r := &Ring{names: make(map[uint64]string, len(names)*replicas)}
+	for _, name := range names {
+		for i := range replicas {
+			p := hash(name + "#" + strconv.Itoa(i))
+			if other, ok := r.names[p]; ok && other < name {
+				continue // keep collisions independent of the order of names
+			}
+			r.names[p] = name
+		}
+	}
+	for p := range r.names {
+		r.points = append(r.points, p)
+	}
+	slices.Sort(r.points)
+	return r
+}
+
+// Locate returns the name owning key: the first point at or after the hash
+// of key, wrapping around. It returns "" for an empty ring.
+func (r *Ring) Locate(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	i, _ := slices.BinarySearch(r.points, hash(key))
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.names[r.points[i]]
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
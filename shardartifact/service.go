@@ -0,0 +1,288 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package shardartifact provides an [artifact.Service] distributing apps and
+// users across several backends, such as buckets, with consistent hashing,
+// to spread load beyond the request rate limits of a single bucket.
+//
+// All artifacts of a user of an app, session and user scoped, live on the
+// same shard, so that List only queries one backend. Shards are identified
+// by name; the names, not the order of the shards, determine placement, so
+// they must stay the same across restarts. After adding or removing shards,
+// artifacts still on their previous shard are not found until
+// [Service.Rebalance] has moved them.
+package shardartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"strings"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Shard is a named backend.
+type Shard struct {
+	Name    string
+	Service artifact.Service
+}
+
+// Options configures [NewService].
+type Options struct {
+	// Replicas is the number of ring points of every shard; defaults to
+	// [DefaultReplicas]. More points spread keys more evenly.
+	Replicas int
+}
+
+// Service is an [artifact.Service] routing every request to the shard
+// owning its app and user.
+type Service struct {
+	ring   *Ring
+	names  []string
+	shards map[string]artifact.Service
+}
+
+var (
+	_ artifact.Service  = (*Service)(nil)
+	_ artifactx.Stater  = (*Service)(nil)
+	_ artifactx.Opener  = (*Service)(nil)
+	_ artifactx.Walker  = (*Service)(nil)
+	_ artifactx.Browser = (*Service)(nil)
+)
+
+// NewService returns a service distributing artifacts across shards.
+func NewService(shards []Shard, opts Options) (*Service, error) {
+	if len(shards) == 0 {
+		return nil, errors.New("at least one shard is required")
+	}
+	s := &Service{shards: make(map[string]artifact.Service, len(shards))}
+	for _, shard := range shards {
+		if shard.Name == "" || shard.Service == nil {
+			return nil, errors.New("shards need a name and a service")
+		}
+		if _, ok := s.shards[shard.Name]; ok {
+			return nil, fmt.Errorf("duplicate shard name %q", shard.Name)
+		}
+		s.shards[shard.Name] = shard.Service
+		s.names = append(s.names, shard.Name)
+	}
+	slices.Sort(s.names)
+	s.ring = NewRing(s.names, opts.Replicas)
+	return s, nil
+}
+
+// ShardName returns the name of the shard owning the artifacts of a user of
+// an app.
+func (s *Service) ShardName(appName, userID string) string {
+	return s.ring.Locate(appName + "/" + userID)
+}
+
+func (s *Service) shard(appName, userID string) artifact.Service {
+	return s.shards[s.ShardName(appName, userID)]
+}
+
+// Save implements [artifact.Service].
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	return s.shard(req.AppName, req.UserID).Save(ctx, req)
+}
+
+// Load implements [artifact.Service].
+func (s *Service) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	return s.shard(req.AppName, req.UserID).Load(ctx, req)
+}
+
+// Delete implements [artifact.Service].
+func (s *Service) Delete(ctx context.Context, req *artifact.DeleteRequest) error {
+	return s.shard(req.AppName, req.UserID).Delete(ctx, req)
+}
+
+// List implements [artifact.Service].
+func (s *Service) List(ctx context.Context, req *artifact.ListRequest) (*artifact.ListResponse, error) {
+	return s.shard(req.AppName, req.UserID).List(ctx, req)
+}
+
+// Versions implements [artifact.Service].
+func (s *Service) Versions(ctx context.Context, req *artifact.VersionsRequest) (*artifact.VersionsResponse, error) {
+	return s.shard(req.AppName, req.UserID).Versions(ctx, req)
+}
+
+// Stat implements [artifactx.Stater] if the owning shard does.
+func (s *Service) Stat(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Attributes, error) {
+	stater, ok := artifactx.As[artifactx.Stater](s.shard(req.AppName, req.UserID))
+	if !ok {
+		return nil, fmt.Errorf("stat: %w", errors.ErrUnsupported)
+	}
+	return stater.Stat(ctx, req)
+}
+
+// Open implements [artifactx.Opener] if the owning shard does.
+func (s *Service) Open(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Reader, error) {
+	opener, ok := artifactx.As[artifactx.Opener](s.shard(req.AppName, req.UserID))
+	if !ok {
+		return nil, fmt.Errorf("open: %w", errors.ErrUnsupported)
+	}
+	return opener.Open(ctx, req)
+}
+
+// walker returns the walker of the named shard.
+func (s *Service) walker(name string) (artifactx.Walker, error) {
+	w, ok := artifactx.As[artifactx.Walker](s.shards[name])
+	if !ok {
+		return nil, fmt.Errorf("shard %q does not support enumerating artifacts: %w", name, errors.ErrUnsupported)
+	}
+	return w, nil
+}
+
+// Walk implements [artifactx.Walker] if every shard does, walking the shards
+// one after the other in name order.
+func (s *Service) Walk(ctx context.Context, fn func(artifactx.Ref) error) error {
+	for _, name := range s.names {
+		w, err := s.walker(name)
+		if err != nil {
+			return err
+		}
+		if err := w.Walk(ctx, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListUsers implements [artifactx.Browser] if every shard does, merging the
+// users of all shards.
+func (s *Service) ListUsers(ctx context.Context, appName string) ([]string, error) {
+	var users []string
+	for _, name := range s.names {
+		b, ok := artifactx.As[artifactx.Browser](s.shards[name])
+		if !ok {
+			return nil, fmt.Errorf("shard %q does not support browsing: %w", name, errors.ErrUnsupported)
+		}
+		names, err := b.ListUsers(ctx, appName)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, names...)
+	}
+	slices.Sort(users)
+	return slices.Compact(users), nil
+}
+
+// ListSessions implements [artifactx.Browser] if the owning shard does.
+func (s *Service) ListSessions(ctx context.Context, appName, userID string) ([]string, error) {
+	b, ok := artifactx.As[artifactx.Browser](s.shard(appName, userID))
+	if !ok {
+		return nil, fmt.Errorf("list sessions: %w", errors.ErrUnsupported)
+	}
+	return b.ListSessions(ctx, appName, userID)
+}
+
+// RebalanceResult reports what [Service.Rebalance] moved.
+type RebalanceResult struct {
+	// Artifacts is the number of artifacts moved.
+	Artifacts int
+	// Versions is the number of versions moved.
+	Versions int
+}
+
+// Rebalance moves every artifact not on the shard owning it there, keeping
+// version numbers and metadata, and deletes it from its previous shard once
+// all its versions are copied. Every shard must implement
+// [artifactx.Walker].
+//
+// Rebalancing is meant to run right after the shards changed, before the
+// moved users save again: an artifact that exists on both shards is not
+// merged, and fails the rebalance with [fs.ErrExist].
+func (s *Service) Rebalance(ctx context.Context) (*RebalanceResult, error) {
+	res := &RebalanceResult{}
+	for _, name := range s.names {
+		w, err := s.walker(name)
+		if err != nil {
+			return res, err
+		}
+		misplaced := map[artifactx.Ref][]int64{}
+		err = w.Walk(ctx, func(ref artifactx.Ref) error {
+			if s.ShardName(ref.AppName, ref.UserID) != name {
+				version := ref.Version
+				ref.Version = 0
+				misplaced[ref] = append(misplaced[ref], version)
+			}
+			return nil
+		})
+		if err != nil {
+			return res, fmt.Errorf("failed to walk shard %q: %w", name, err)
+		}
+		refs := slices.SortedFunc(maps.Keys(misplaced), func(a, b artifactx.Ref) int {
+			return strings.Compare(a.String(), b.String())
+		})
+		for _, ref := range refs {
+			versions := misplaced[ref]
+			slices.Sort(versions)
+			if err := s.move(ctx, s.shards[name], ref, versions); err != nil {
+				return res, err
+			}
+			res.Artifacts++
+			res.Versions += len(versions)
+		}
+	}
+	return res, nil
+}
+
+// move copies the versions of ref from src to the shard owning it, oldest
+// first, and then deletes ref from src.
+func (s *Service) move(ctx context.Context, src artifact.Service, ref artifactx.Ref, versions []int64) error {
+	dst := s.shard(ref.AppName, ref.UserID)
+	_, err := dst.Versions(ctx, &artifact.VersionsRequest{
+		AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName,
+	})
+	if err == nil {
+		return fmt.Errorf("%s exists on shard %q: %w", ref, s.ShardName(ref.AppName, ref.UserID), fs.ErrExist)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	stater, _ := artifactx.As[artifactx.Stater](src)
+	for _, v := range versions {
+		req := &artifact.LoadRequest{AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName, Version: v}
+		resp, err := src.Load(ctx, req)
+		if err != nil {
+			return fmt.Errorf("failed to load %s version %d: %w", ref, v, err)
+		}
+		saveCtx := ctx
+		if stater != nil {
+			attrs, err := stater.Stat(ctx, req)
+			if err != nil {
+				return fmt.Errorf("failed to stat %s version %d: %w", ref, v, err)
+			}
+			saveCtx = artifactx.WithMetadata(ctx, attrs.Metadata)
+		}
+		if _, err := dst.Save(saveCtx, &artifact.SaveRequest{
+			AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName,
+			Part: resp.Part, Version: v,
+		}); err != nil {
+			return fmt.Errorf("failed to save %s version %d: %w", ref, v, err)
+		}
+	}
+	if err := src.Delete(ctx, &artifact.DeleteRequest{
+		AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName,
+	}); err != nil {
+		return fmt.Errorf("failed to delete moved %s: %w", ref, err)
+	}
+	return nil
+}
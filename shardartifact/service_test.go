@@ -0,0 +1,113 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shardartifact_test
+
+import (
+	"fmt"
+	"testing"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"github.com/chinglinwen/adk-artifact/shardartifact"
+)
+
+func TestRingMovesOnlyToNewShard(t *testing.T) {
+	before := shardartifact.NewRing([]string{"a", "b", "c"}, 0)
+	after := shardartifact.NewRing([]string{"a", "b", "c", "d"}, 0)
+	moved := 0
+	for i := range 1000 {
+		key := fmt.Sprintf("app/user%d", i)
+		from, to := before.Locate(key), after.Locate(key)
+		if from != to {
+			if to != "d" {
+				t.Fatalf("key %q moved from %q to %q, want only moves to the new shard", key, from, to)
+			}
+			moved++
+		}
+	}
+	if moved < 100 || moved > 400 {
+		t.Errorf("%d of 1000 keys moved to the new shard, want about 250", moved)
+	}
+}
+
+func newShards(t *testing.T, names ...string) []shardartifact.Shard {
+	t.Helper()
+	var shards []shardartifact.Shard
+	for _, name := range names {
+		srv, err := fsartifact.NewService(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		shards = append(shards, shardartifact.Shard{Name: name, Service: srv})
+	}
+	return shards
+}
+
+func TestRebalance(t *testing.T) {
+	ctx := t.Context()
+	shards := newShards(t, "a", "b")
+	srv, err := shardartifact.NewService(shards[:1], shardartifact.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := make([]string, 20)
+	for i := range users {
+		users[i] = fmt.Sprintf("user%d", i)
+		scope := artifactx.Scoped(srv, "app", users[i], "session")
+		for _, name := range []string{"notes", "notes", "user:profile"} {
+			if _, err := scope.Save(artifactx.WithMetadata(ctx, map[string]string{"user": users[i]}), name, genai.NewPartFromText(users[i])); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	srv, err = shardartifact.NewService(shards, shardartifact.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := srv.Rebalance(ctx)
+	if err != nil {
+		t.Fatalf("Rebalance() failed: %v", err)
+	}
+	movedUsers := 0
+	for _, user := range users {
+		if srv.ShardName("app", user) == "b" {
+			movedUsers++
+		}
+	}
+	if movedUsers == 0 || res.Artifacts != 2*movedUsers || res.Versions != 3*movedUsers {
+		t.Errorf("Rebalance() = %+v, want 2 artifacts and 3 versions for each of the %d moved users", res, movedUsers)
+	}
+
+	for _, user := range users {
+		scope := artifactx.Scoped(srv, "app", user, "session")
+		if names, err := scope.List(ctx); err != nil || len(names) != 2 {
+			t.Errorf("List() for %s = (%v, %v), want notes and user:profile", user, names, err)
+		}
+		if versions, err := scope.Versions(ctx, "notes"); err != nil || len(versions) != 2 {
+			t.Errorf("Versions(notes) for %s = (%v, %v), want 2 versions", user, versions, err)
+		}
+		attrs, err := srv.Stat(ctx, &artifact.LoadRequest{AppName: "app", UserID: user, SessionID: "session", FileName: "user:profile"})
+		if err != nil || attrs.Metadata["user"] != user {
+			t.Errorf("Stat(user:profile) for %s = (%+v, %v), want the saved metadata", user, attrs, err)
+		}
+	}
+	if res, err := srv.Rebalance(ctx); err != nil || res.Artifacts != 0 {
+		t.Errorf("second Rebalance() = (%+v, %v), want nothing moved", res, err)
+	}
+}
@@ -0,0 +1,255 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package shareartifact mints revocable share links to artifact versions,
+// so that agents can hand users a link to what they generated.
+//
+// A [Sharer] records every share in a [Store] under a random token. Shares
+// are resolved by the [Sharer] itself, which is an [http.Handler] serving
+// the shared version, or turned into a presigned URL of the storage when the
+// service implements [artifactx.URLSigner]:
+//
+//	sharer := shareartifact.NewSharer(srv, shareartifact.NewMemoryStore(), shareartifact.Options{
+//		BaseURL: "https://example.com/share/",
+//	})
+//	http.Handle("/share/", http.StripPrefix("/share/", sharer))
+//	share, err := sharer.Share(ctx, &shareartifact.ShareRequest{
+//		AppName: "app", UserID: "user", SessionID: "session", FileName: "report.pdf",
+//		TTL: 24 * time.Hour, MaxDownloads: 3,
+//	})
+//	link := sharer.URL(share)
+package shareartifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// ErrExpired is returned when resolving a share that expired or reached its
+// download limit.
+var ErrExpired = errors.New("share expired")
+
+// ShareRequest asks for a share of an artifact version.
+type ShareRequest struct {
+	AppName, UserID, SessionID, FileName string
+	// Version is the shared version; zero shares the latest version at the
+	// time of the request, not later ones.
+	Version int64
+	// TTL is how long the share lasts; zero means it does not expire.
+	TTL time.Duration
+	// MaxDownloads limits how often the share resolves; zero means no limit.
+	MaxDownloads int
+}
+
+// Validate checks that the request names an artifact and sane limits.
+func (r *ShareRequest) Validate() error {
+	if r.AppName == "" || r.UserID == "" || r.SessionID == "" || r.FileName == "" {
+		return fmt.Errorf("app name, user ID, session ID and file name are required")
+	}
+	if r.Version < 0 || r.TTL < 0 || r.MaxDownloads < 0 {
+		return fmt.Errorf("version, TTL and max downloads must not be negative")
+	}
+	return nil
+}
+
+// Share is a share link to an artifact version.
+type Share struct {
+	Token string `json:"token"`
+	// Ref is the shared version.
+	Ref          artifactx.Ref `json:"ref"`
+	Created      time.Time     `json:"created"`
+	Expires      time.Time     `json:"expires,omitzero"`
+	MaxDownloads int           `json:"maxDownloads,omitempty"`
+	Downloads    int           `json:"downloads"`
+}
+
+// claimable returns [ErrExpired] if the share can no longer be downloaded
+// at now.
+func (s *Share) claimable(now time.Time) error {
+	if !s.Expires.IsZero() && !now.Before(s.Expires) {
+		return fmt.Errorf("%w at %s", ErrExpired, s.Expires.Format(time.RFC3339))
+	}
+	if s.MaxDownloads > 0 && s.Downloads >= s.MaxDownloads {
+		return fmt.Errorf("%w after %d downloads", ErrExpired, s.Downloads)
+	}
+	return nil
+}
+
+// Options configures [NewSharer].
+type Options struct {
+	// BaseURL is prepended to tokens by [Sharer.URL], e.g. the address the
+	// sharer is served at.
+	BaseURL string
+	// Clock defaults to [artifactx.SystemClock].
+	Clock artifactx.Clock
+}
+
+// Sharer mints and resolves shares of the artifacts of a service.
+type Sharer struct {
+	srv     artifact.Service
+	store   Store
+	baseURL string
+	clock   artifactx.Clock
+}
+
+var _ http.Handler = (*Sharer)(nil)
+
+// NewSharer returns a sharer of the artifacts of srv, recording shares in
+// store.
+func NewSharer(srv artifact.Service, store Store, opts Options) *Sharer {
+	s := &Sharer{srv: srv, store: store, baseURL: opts.BaseURL, clock: opts.Clock}
+	if s.clock == nil {
+		s.clock = artifactx.SystemClock
+	}
+	return s
+}
+
+// Share mints a share of the requested version, which must exist.
+func (s *Sharer) Share(ctx context.Context, req *ShareRequest) (*Share, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("request validation failed: %w", err)
+	}
+	version := req.Version
+	resp, err := s.srv.Versions(ctx, &artifact.VersionsRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		version = slices.Max(resp.Versions)
+	} else if !slices.Contains(resp.Versions, version) {
+		return nil, fmt.Errorf("artifact '%s' version %d not found: %w", req.FileName, version, fs.ErrNotExist)
+	}
+
+	now := s.clock.Now()
+	share := &Share{
+		Token: artifactx.NewUploadID(),
+		Ref: artifactx.Ref{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName, Version: version,
+		},
+		Created:      now,
+		MaxDownloads: req.MaxDownloads,
+	}
+	if req.TTL > 0 {
+		share.Expires = now.Add(req.TTL)
+	}
+	if err := s.store.Put(ctx, share); err != nil {
+		return nil, fmt.Errorf("failed to store share: %w", err)
+	}
+	return share, nil
+}
+
+// Revoke revokes the share with token, so that it no longer resolves.
+// Presigned URLs already issued for it stay valid until they expire.
+func (s *Sharer) Revoke(ctx context.Context, token string) error {
+	return s.store.Delete(ctx, token)
+}
+
+// URL returns the link to share served by the sharer at the base URL.
+func (s *Sharer) URL(share *Share) string {
+	return s.baseURL + share.Token
+}
+
+// Resolve counts a download of the share with token and returns it.
+func (s *Sharer) Resolve(ctx context.Context, token string) (*Share, error) {
+	return s.store.Claim(ctx, token, s.clock.Now())
+}
+
+// PresignedURL counts a download of the share with token and returns a
+// presigned URL of the storage valid for at most expiry, or until the share
+// expires if that is sooner. The URL is not subject to revocation and can
+// be downloaded any number of times until it expires, so expiry should be
+// short. The service must implement [artifactx.URLSigner].
+func (s *Sharer) PresignedURL(ctx context.Context, token string, expiry time.Duration) (string, error) {
+	signer, ok := artifactx.As[artifactx.URLSigner](s.srv)
+	if !ok {
+		return "", fmt.Errorf("presigned urls: %w", errors.ErrUnsupported)
+	}
+	share, err := s.Resolve(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	if !share.Expires.IsZero() {
+		expiry = min(expiry, share.Expires.Sub(s.clock.Now()))
+	}
+	return signer.SignedURL(ctx, share.Ref.LoadRequest(), expiry)
+}
+
+// ServeHTTP serves the version shared under the token in the last segment
+// of the request path, e.g. GET /share/<token>, as an attachment. Unknown
+// and revoked tokens are answered with 404 Not Found and expired ones with
+// 410 Gone. Only GET requests whose version could be opened count as a
+// download; HEAD requests do not.
+func (s *Sharer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := path.Base(r.URL.Path)
+	share, err := s.store.Get(r.Context(), token, s.clock.Now())
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	reader, err := artifactx.Open(r.Context(), s.srv, share.Ref.LoadRequest())
+	if err != nil {
+		httpError(w, err)
+		return
+	}
+	defer reader.Close()
+	if r.Method == http.MethodGet {
+		// Claimed after Open, so that failed downloads do not count, and
+		// checked again, since concurrent downloads may have used it up.
+		if _, err := s.Resolve(r.Context(), token); err != nil {
+			httpError(w, err)
+			return
+		}
+	}
+
+	name := strings.TrimPrefix(share.Ref.FileName, "user:")
+	w.Header().Set("Content-Type", reader.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(reader.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(name)}))
+	if r.Method == http.MethodHead {
+		return
+	}
+	io.Copy(w, reader)
+}
+
+func httpError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		http.Error(w, "share not found", http.StatusNotFound)
+	case errors.Is(err, ErrExpired):
+		http.Error(w, "share expired", http.StatusGone)
+	default:
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
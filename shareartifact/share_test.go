@@ -0,0 +1,198 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shareartifact_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"gocloud.dev/blob/fileblob"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/blobartifact"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"github.com/chinglinwen/adk-artifact/shareartifact"
+)
+
+func TestShare(t *testing.T) {
+	ctx := t.Context()
+	srv, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := artifactx.Scoped(srv, "app", "user", "session")
+	for _, text := range []string{"draft", "final"} {
+		if _, err := scope.Save(ctx, "report.md", genai.NewPartFromBytes([]byte(text), "text/markdown")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clock := artifactx.NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	sharer := shareartifact.NewSharer(srv, shareartifact.NewMemoryStore(), shareartifact.Options{Clock: clock})
+	ts := httptest.NewServer(http.StripPrefix("/share/", sharer))
+	defer ts.Close()
+	get := func(share *shareartifact.Share) (int, string, http.Header) {
+		t.Helper()
+		resp, err := http.Get(ts.URL + "/share/" + share.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body), resp.Header
+	}
+
+	limited, err := sharer.Share(ctx, &shareartifact.ShareRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "report.md", MaxDownloads: 2,
+	})
+	if err != nil {
+		t.Fatalf("Share() failed: %v", err)
+	}
+	if limited.Ref.Version != 2 {
+		t.Errorf("Share() version = %d, want the latest, 2", limited.Ref.Version)
+	}
+	if _, err := scope.Save(ctx, "report.md", genai.NewPartFromText("later")); err != nil {
+		t.Fatal(err)
+	}
+	// HEAD requests do not count as downloads.
+	for range 3 {
+		resp, err := http.Head(ts.URL + "/share/" + limited.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || resp.ContentLength != int64(len("final")) {
+			t.Fatalf("HEAD = (%d, %d), want (200, %d)", resp.StatusCode, resp.ContentLength, len("final"))
+		}
+	}
+	for range 2 {
+		code, body, header := get(limited)
+		if code != http.StatusOK || body != "final" {
+			t.Fatalf("GET = (%d, %q), want (200, final)", code, body)
+		}
+		if got := header.Get("Content-Type"); got != "text/markdown" {
+			t.Errorf("Content-Type = %q, want text/markdown", got)
+		}
+		if got := header.Get("Content-Disposition"); !strings.Contains(got, "report.md") {
+			t.Errorf("Content-Disposition = %q, want the file name", got)
+		}
+	}
+	for range 2 {
+		if code, _, _ := get(limited); code != http.StatusGone {
+			t.Errorf("GET after the download limit = %d, want 410", code)
+		}
+	}
+
+	timed, err := sharer.Share(ctx, &shareartifact.ShareRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "report.md", Version: 1, TTL: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code, body, _ := get(timed); code != http.StatusOK || body != "draft" {
+		t.Errorf("GET version 1 = (%d, %q), want (200, draft)", code, body)
+	}
+	clock.Advance(time.Hour)
+	if _, err := sharer.Resolve(ctx, timed.Token); !errors.Is(err, shareartifact.ErrExpired) {
+		t.Errorf("Resolve() after the TTL error = %v, want %v", err, shareartifact.ErrExpired)
+	}
+	if code, _, _ := get(timed); code != http.StatusGone {
+		t.Errorf("GET after the TTL = %d, want 410", code)
+	}
+
+	// A download of a version that cannot be opened does not count.
+	once, err := sharer.Share(ctx, &shareartifact.ShareRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "report.md", Version: 3, MaxDownloads: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Delete(ctx, &artifact.DeleteRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "report.md", Version: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if code, _, _ := get(once); code != http.StatusNotFound {
+		t.Errorf("GET of a deleted version = %d, want 404", code)
+	}
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "report.md", Version: 3, Part: genai.NewPartFromText("restored"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if code, body, _ := get(once); code != http.StatusOK || body != "restored" {
+		t.Errorf("GET of a restored version = (%d, %q), want (200, restored)", code, body)
+	}
+
+	revoked, err := sharer.Share(ctx, &shareartifact.ShareRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "report.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sharer.Revoke(ctx, revoked.Token); err != nil {
+		t.Fatal(err)
+	}
+	if code, _, _ := get(revoked); code != http.StatusNotFound {
+		t.Errorf("GET after Revoke() = %d, want 404", code)
+	}
+
+	if _, err := sharer.Share(ctx, &shareartifact.ShareRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "report.md", Version: 9,
+	}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Share() of a missing version error = %v, want %v", err, fs.ErrNotExist)
+	}
+}
+
+func TestPresignedURL(t *testing.T) {
+	ctx := t.Context()
+	base, err := url.Parse("https://example.com/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bucket, err := fileblob.OpenBucket(t.TempDir(), &fileblob.Options{URLSigner: fileblob.NewURLSignerHMAC(base, []byte("secret"))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := blobartifact.New(bucket)
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "report.md", Part: genai.NewPartFromText("final"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	sharer := shareartifact.NewSharer(srv, shareartifact.NewMemoryStore(), shareartifact.Options{})
+	share, err := sharer.Share(ctx, &shareartifact.ShareRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "report.md", MaxDownloads: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := sharer.PresignedURL(ctx, share.Token, time.Minute)
+	if err != nil {
+		t.Fatalf("PresignedURL() failed: %v", err)
+	}
+	if !strings.HasPrefix(signed, base.String()) {
+		t.Errorf("PresignedURL() = %q, want a URL below %s", signed, base)
+	}
+	if _, err := sharer.PresignedURL(ctx, share.Token, time.Minute); !errors.Is(err, shareartifact.ErrExpired) {
+		t.Errorf("PresignedURL() after the download limit error = %v, want %v", err, shareartifact.ErrExpired)
+	}
+}
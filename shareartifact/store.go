@@ -0,0 +1,98 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shareartifact
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// Store keeps the shares minted by a [Sharer].
+type Store interface {
+	// Put stores a new share.
+	Put(ctx context.Context, share *Share) error
+	// Get returns the share with token without counting a download, failing
+	// like Claim.
+	Get(ctx context.Context, token string, now time.Time) (*Share, error)
+	// Claim counts a download of the share with token and returns it, failing
+	// with [ErrExpired] if it expired or reached its download limit before
+	// now, and with [fs.ErrNotExist] if there is no such share.
+	Claim(ctx context.Context, token string, now time.Time) (*Share, error)
+	// Delete removes the share with token; removing a missing share is not
+	// an error.
+	Delete(ctx context.Context, token string) error
+}
+
+// MemoryStore is a [Store] kept in memory.
+type MemoryStore struct {
+	mu     sync.Mutex
+	shares map[string]Share
+}
+
+// NewMemoryStore returns an empty [MemoryStore].
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{shares: map[string]Share{}}
+}
+
+// Put implements [Store].
+func (m *MemoryStore) Put(ctx context.Context, share *Share) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shares[share.Token] = *share
+	return nil
+}
+
+// Get implements [Store].
+func (m *MemoryStore) Get(ctx context.Context, token string, now time.Time) (*Share, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	share, ok := m.shares[token]
+	if !ok {
+		return nil, fmt.Errorf("share not found: %w", fs.ErrNotExist)
+	}
+	if err := share.claimable(now); err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+// Claim implements [Store]. Shares that can no longer be claimed are kept
+// until deleted, so that they keep failing with [ErrExpired] rather than as
+// unknown.
+func (m *MemoryStore) Claim(ctx context.Context, token string, now time.Time) (*Share, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	share, ok := m.shares[token]
+	if !ok {
+		return nil, fmt.Errorf("share not found: %w", fs.ErrNotExist)
+	}
+	if err := share.claimable(now); err != nil {
+		return nil, err
+	}
+	share.Downloads++
+	m.shares[token] = share
+	return &share, nil
+}
+
+// Delete implements [Store].
+func (m *MemoryStore) Delete(ctx context.Context, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.shares, token)
+	return nil
+}
@@ -0,0 +1,211 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package statsartifact provides an [artifact.Service] decorator that keeps
+// per-app usage statistics, so that dashboards can query them without
+// scanning the store every time.
+//
+// Statistics are computed by walking the decorated service, which must
+// implement [artifactx.Walker], and are kept up to date incrementally by
+// Saves through the decorator. Deletes mark them stale instead, since the
+// size of what was deleted is not known; they are then recomputed on the
+// next query, as they are once they are older than [Options.MaxAge]. Saves
+// racing with a walk may be missed until the next one.
+package statsartifact
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Usage are the aggregate counts of an app.
+type Usage struct {
+	// Artifacts is the number of distinct artifacts.
+	Artifacts int64 `json:"artifacts"`
+	// Versions is the number of stored versions.
+	Versions int64 `json:"versions"`
+	// Bytes is the total size of the stored versions. It is zero if the
+	// decorated service does not implement [artifactx.Stater].
+	Bytes int64 `json:"bytes"`
+}
+
+func (u *Usage) add(o Usage) {
+	u.Artifacts += o.Artifacts
+	u.Versions += o.Versions
+	u.Bytes += o.Bytes
+}
+
+// Snapshot is the usage of the store at some point.
+type Snapshot struct {
+	// Apps is the usage by app name.
+	Apps map[string]Usage `json:"apps"`
+	// Refreshed is when the store was last walked.
+	Refreshed time.Time `json:"refreshed"`
+}
+
+// Total returns the usage of all apps together.
+func (s *Snapshot) Total() Usage {
+	var total Usage
+	for _, u := range s.Apps {
+		total.add(u)
+	}
+	return total
+}
+
+// Options configures [NewService].
+type Options struct {
+	// MaxAge is how long statistics are served before the store is walked
+	// again. Zero means they are only recomputed after Deletes or an
+	// explicit [Service.Refresh].
+	MaxAge time.Duration
+	// Clock defaults to [artifactx.SystemClock].
+	Clock artifactx.Clock
+}
+
+// Service is an [artifact.Service] that maintains usage statistics.
+type Service struct {
+	artifact.Service
+	opts Options
+
+	refreshMu sync.Mutex // serializes walks
+
+	mu        sync.Mutex
+	apps      map[string]*Usage
+	refreshed time.Time
+	stale     bool
+}
+
+var _ artifactx.Wrapper = (*Service)(nil)
+
+// NewService returns a service that forwards to next and keeps statistics
+// about it. Nothing is computed until the first query.
+func NewService(next artifact.Service, opts Options) *Service {
+	if opts.Clock == nil {
+		opts.Clock = artifactx.SystemClock
+	}
+	return &Service{Service: next, opts: opts, stale: true}
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// Stats returns the current statistics, walking the store first if they are
+// stale.
+func (s *Service) Stats(ctx context.Context) (*Snapshot, error) {
+	if s.needsRefresh() {
+		if err := s.Refresh(ctx); err != nil {
+			return nil, err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap := &Snapshot{Apps: make(map[string]Usage, len(s.apps)), Refreshed: s.refreshed}
+	for app, u := range s.apps {
+		snap.Apps[app] = *u
+	}
+	return snap, nil
+}
+
+func (s *Service) needsRefresh() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stale || (s.opts.MaxAge > 0 && s.opts.Clock.Now().Sub(s.refreshed) > s.opts.MaxAge)
+}
+
+// Refresh recomputes the statistics by walking the store.
+func (s *Service) Refresh(ctx context.Context) error {
+	walker, ok := artifactx.As[artifactx.Walker](s.Service)
+	if !ok {
+		return fmt.Errorf("statistics require a service implementing artifactx.Walker")
+	}
+	stater, _ := artifactx.As[artifactx.Stater](s.Service)
+
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	started := s.opts.Clock.Now()
+	apps := map[string]*Usage{}
+	artifacts := map[[4]string]bool{}
+	err := walker.Walk(ctx, func(ref artifactx.Ref) error {
+		u := apps[ref.AppName]
+		if u == nil {
+			u = &Usage{}
+			apps[ref.AppName] = u
+		}
+		u.Versions++
+		if id := [4]string{ref.AppName, ref.UserID, ref.SessionID, ref.FileName}; !artifacts[id] {
+			artifacts[id] = true
+			u.Artifacts++
+		}
+		if stater != nil {
+			attrs, err := stater.Stat(ctx, &artifact.LoadRequest{
+				AppName: ref.AppName, UserID: ref.UserID, SessionID: ref.SessionID, FileName: ref.FileName, Version: ref.Version,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to stat %s: %w", ref, err)
+			}
+			u.Bytes += attrs.Size
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk artifacts: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apps, s.refreshed, s.stale = apps, started, false
+	return nil
+}
+
+// Save implements [artifact.Service] and counts the saved version.
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	resp, err := s.Service.Save(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	delta := Usage{Versions: 1}
+	if resp.Version == 1 {
+		delta.Artifacts = 1
+	}
+	if data, _, err := artifactx.EncodePart(req.Part); err == nil {
+		delta.Bytes = int64(len(data))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.apps != nil {
+		if s.apps[req.AppName] == nil {
+			s.apps[req.AppName] = &Usage{}
+		}
+		s.apps[req.AppName].add(delta)
+	}
+	return resp, nil
+}
+
+// Delete implements [artifact.Service] and marks the statistics stale.
+func (s *Service) Delete(ctx context.Context, req *artifact.DeleteRequest) error {
+	err := s.Service.Delete(ctx, req)
+	s.mu.Lock()
+	s.stale = true
+	s.mu.Unlock()
+	return err
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statsartifact_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"github.com/chinglinwen/adk-artifact/statsartifact"
+)
+
+func TestStats(t *testing.T) {
+	ctx := t.Context()
+	next, err := fsartifact.NewService(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := artifactx.NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	srv := statsartifact.NewService(next, statsartifact.Options{MaxAge: time.Minute, Clock: clock})
+	save := func(target artifact.Service, app, file, data string) {
+		t.Helper()
+		if _, err := target.Save(ctx, &artifact.SaveRequest{
+			AppName: app, UserID: "user", SessionID: "session", FileName: file,
+			Part: genai.NewPartFromText(data),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(want map[string]statsartifact.Usage) {
+		t.Helper()
+		snap, err := srv.Stats(ctx)
+		if err != nil {
+			t.Fatalf("Stats() failed: %v", err)
+		}
+		if diff := cmp.Diff(want, snap.Apps); diff != "" {
+			t.Errorf("Stats().Apps mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	save(srv, "a", "f1", "12345")
+	save(srv, "a", "f1", "123")
+	save(srv, "b", "f1", "1")
+	check(map[string]statsartifact.Usage{
+		"a": {Artifacts: 1, Versions: 2, Bytes: 8},
+		"b": {Artifacts: 1, Versions: 1, Bytes: 1},
+	})
+
+	// Saves through the decorator are counted without walking again.
+	save(srv, "b", "f2", "12")
+	// A Save around the decorator is only seen once the statistics expire.
+	save(next, "c", "f1", "1234")
+	check(map[string]statsartifact.Usage{
+		"a": {Artifacts: 1, Versions: 2, Bytes: 8},
+		"b": {Artifacts: 2, Versions: 2, Bytes: 3},
+	})
+	clock.Advance(2 * time.Minute)
+	check(map[string]statsartifact.Usage{
+		"a": {Artifacts: 1, Versions: 2, Bytes: 8},
+		"b": {Artifacts: 2, Versions: 2, Bytes: 3},
+		"c": {Artifacts: 1, Versions: 1, Bytes: 4},
+	})
+
+	if err := srv.Delete(ctx, &artifact.DeleteRequest{AppName: "a", UserID: "user", SessionID: "session", FileName: "f1", Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	check(map[string]statsartifact.Usage{
+		"a": {Artifacts: 1, Versions: 1, Bytes: 3},
+		"b": {Artifacts: 2, Versions: 2, Bytes: 3},
+		"c": {Artifacts: 1, Versions: 1, Bytes: 4},
+	})
+}
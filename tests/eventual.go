@@ -0,0 +1,346 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/driver"
+	"gocloud.dev/gcerrors"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// EventualBucketOptions configures the consistency of [OpenEventualBucket].
+type EventualBucketOptions struct {
+	// Clock measures the delays. The default is [artifactx.SystemClock]; an
+	// [artifactx.ManualClock] makes tests deterministic.
+	Clock artifactx.Clock
+	// ListDelay is how long a write or delete takes to show in listings.
+	ListDelay time.Duration
+	// ReadDelay is how long reads of a key keep returning what it held
+	// before a write or delete: not found after it is first written, the
+	// previous content after it is overwritten, and the deleted content
+	// after it is deleted.
+	ReadDelay time.Duration
+	// OnList, if set, is called with the prefix of every page listed, for
+	// tests counting LIST requests.
+	OnList func(prefix string)
+	// OnRead, if set, is called with the key of every object read, for
+	// tests counting GET requests.
+	OnRead func(key string)
+	// OnDelete, if set, is called with the key of every object deleted;
+	// an error fails the deletion, for tests of partial failures.
+	OnDelete func(key string) error
+	// IgnoreIfNotExist makes conditional writes overwrite existing objects,
+	// like stores that ignore If-None-Match.
+	IgnoreIfNotExist bool
+}
+
+// OpenEventualBucket returns an in-memory bucket with the eventual
+// consistency of some S3 compatible stores, such as older Ceph and Swift
+// gateways, so that backends and decorators can be tested against delayed
+// listing visibility and stale reads. Conditional writes and deletes see
+// the latest state, as they do in those stores. With zero delays, it
+// behaves like memblob.
+func OpenEventualBucket(opts *EventualBucketOptions) *blob.Bucket {
+	b := &eventualBucket{clock: artifactx.SystemClock, objects: map[string][]*revision{}}
+	if opts != nil {
+		if opts.Clock != nil {
+			b.clock = opts.Clock
+		}
+		b.listDelay, b.readDelay = opts.ListDelay, opts.ReadDelay
+		b.onList, b.onRead, b.onDelete = opts.OnList, opts.OnRead, opts.OnDelete
+		b.ignoreIfNotExist = opts.IgnoreIfNotExist
+	}
+	return blob.NewBucket(b)
+}
+
+var (
+	errNotFound       = errors.New("blob not found")
+	errExists         = errors.New("blob exists")
+	errNotImplemented = errors.New("not implemented")
+)
+
+// revision is a write or delete of a key.
+type revision struct {
+	at      time.Time
+	deleted bool
+	data    []byte
+	attrs   driver.Attributes
+}
+
+type eventualBucket struct {
+	clock                artifactx.Clock
+	listDelay, readDelay time.Duration
+	onList               func(prefix string)
+	onRead               func(key string)
+	onDelete             func(key string) error
+	ignoreIfNotExist     bool
+
+	mu sync.Mutex
+	// objects holds the revisions of every key, oldest first.
+	objects map[string][]*revision
+}
+
+// visible returns the latest revision of key written delay ago or earlier,
+// or nil if the key did not exist then. b.mu must be held.
+func (b *eventualBucket) visible(key string, delay time.Duration) *revision {
+	cutoff := b.clock.Now().Add(-delay)
+	revs := b.objects[key]
+	for i := len(revs) - 1; i >= 0; i-- {
+		if !revs[i].at.After(cutoff) {
+			if revs[i].deleted {
+				return nil
+			}
+			return revs[i]
+		}
+	}
+	return nil
+}
+
+// add records a revision of key. b.mu must be held.
+func (b *eventualBucket) add(key string, rev *revision) {
+	b.objects[key] = append(b.objects[key], rev)
+}
+
+// exists reports whether key exists now, whatever the delays. b.mu must be
+// held.
+func (b *eventualBucket) exists(key string) bool {
+	revs := b.objects[key]
+	return len(revs) > 0 && !revs[len(revs)-1].deleted
+}
+
+func (b *eventualBucket) ErrorCode(err error) gcerrors.ErrorCode {
+	switch {
+	case errors.Is(err, errNotFound):
+		return gcerrors.NotFound
+	case errors.Is(err, errExists):
+		return gcerrors.FailedPrecondition
+	case errors.Is(err, errNotImplemented):
+		return gcerrors.Unimplemented
+	}
+	return gcerrors.Unknown
+}
+
+func (b *eventualBucket) As(any) bool { return false }
+
+func (b *eventualBucket) ErrorAs(error, any) bool { return false }
+
+func (b *eventualBucket) Attributes(ctx context.Context, key string) (*driver.Attributes, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	rev := b.visible(key, b.readDelay)
+	if rev == nil {
+		return nil, errNotFound
+	}
+	attrs := rev.attrs
+	attrs.Metadata = maps.Clone(rev.attrs.Metadata)
+	return &attrs, nil
+}
+
+func (b *eventualBucket) ListPaged(ctx context.Context, opts *driver.ListOptions) (*driver.ListPage, error) {
+	if opts.BeforeList != nil {
+		if err := opts.BeforeList(func(any) bool { return false }); err != nil {
+			return nil, err
+		}
+	}
+	if b.onList != nil {
+		b.onList(opts.Prefix)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	pageSize := opts.PageSize
+	if pageSize == 0 {
+		pageSize = 1000
+	}
+	var page driver.ListPage
+	var lastDir string
+	for _, key := range slices.Sorted(maps.Keys(b.objects)) {
+		if !strings.HasPrefix(key, opts.Prefix) {
+			continue
+		}
+		rev := b.visible(key, b.listDelay)
+		if rev == nil {
+			continue
+		}
+		obj := &driver.ListObject{Key: key, ModTime: rev.attrs.ModTime, Size: rev.attrs.Size, MD5: rev.attrs.MD5}
+		if opts.Delimiter != "" {
+			// Collapse the keys of a "directory".
+			if i := strings.Index(key[len(opts.Prefix):], opts.Delimiter); i >= 0 {
+				dir := key[:len(opts.Prefix)+i+len(opts.Delimiter)]
+				if dir == lastDir {
+					continue
+				}
+				lastDir = dir
+				obj = &driver.ListObject{Key: dir, IsDir: true}
+			}
+		}
+		if len(opts.PageToken) > 0 && obj.Key <= string(opts.PageToken) {
+			continue
+		}
+		if len(page.Objects) == pageSize {
+			page.NextPageToken = []byte(page.Objects[pageSize-1].Key)
+			break
+		}
+		page.Objects = append(page.Objects, obj)
+	}
+	return &page, nil
+}
+
+func (b *eventualBucket) NewRangeReader(ctx context.Context, key string, offset, length int64, opts *driver.ReaderOptions) (driver.Reader, error) {
+	if b.onRead != nil {
+		b.onRead(key)
+	}
+	b.mu.Lock()
+	rev := b.visible(key, b.readDelay)
+	b.mu.Unlock()
+	if rev == nil {
+		return nil, errNotFound
+	}
+	if opts.BeforeRead != nil {
+		if err := opts.BeforeRead(func(any) bool { return false }); err != nil {
+			return nil, err
+		}
+	}
+	data := rev.data[min(offset, int64(len(rev.data))):]
+	if length >= 0 && length < int64(len(data)) {
+		data = data[:length]
+	}
+	return &eventualReader{
+		Reader: bytes.NewReader(data),
+		attrs:  driver.ReaderAttributes{ContentType: rev.attrs.ContentType, ModTime: rev.attrs.ModTime, Size: rev.attrs.Size},
+	}, nil
+}
+
+func (b *eventualBucket) NewTypedWriter(ctx context.Context, key, contentType string, opts *driver.WriterOptions) (driver.Writer, error) {
+	if key == "" {
+		return nil, errors.New("invalid key (empty string)")
+	}
+	if opts.BeforeWrite != nil {
+		if err := opts.BeforeWrite(func(any) bool { return false }); err != nil {
+			return nil, err
+		}
+	}
+	return &eventualWriter{ctx: ctx, b: b, key: key, contentType: contentType, opts: opts}, nil
+}
+
+func (b *eventualBucket) Copy(ctx context.Context, dstKey, srcKey string, opts *driver.CopyOptions) error {
+	if opts.BeforeCopy != nil {
+		if err := opts.BeforeCopy(func(any) bool { return false }); err != nil {
+			return err
+		}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	src := b.visible(srcKey, b.readDelay)
+	if src == nil {
+		return errNotFound
+	}
+	now := b.clock.Now()
+	dst := &revision{at: now, data: src.data, attrs: src.attrs}
+	dst.attrs.Metadata = maps.Clone(src.attrs.Metadata)
+	dst.attrs.CreateTime, dst.attrs.ModTime = now, now
+	b.add(dstKey, dst)
+	return nil
+}
+
+func (b *eventualBucket) Delete(ctx context.Context, key string) error {
+	if b.onDelete != nil {
+		if err := b.onDelete(key); err != nil {
+			return err
+		}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.exists(key) {
+		return errNotFound
+	}
+	b.add(key, &revision{at: b.clock.Now(), deleted: true})
+	return nil
+}
+
+func (b *eventualBucket) SignedURL(ctx context.Context, key string, opts *driver.SignedURLOptions) (string, error) {
+	return "", errNotImplemented
+}
+
+func (b *eventualBucket) Close() error { return nil }
+
+type eventualReader struct {
+	*bytes.Reader
+	attrs driver.ReaderAttributes
+}
+
+func (r *eventualReader) Close() error { return nil }
+
+func (r *eventualReader) Attributes() *driver.ReaderAttributes { return &r.attrs }
+
+func (r *eventualReader) As(any) bool { return false }
+
+type eventualWriter struct {
+	ctx         context.Context
+	b           *eventualBucket
+	key         string
+	contentType string
+	opts        *driver.WriterOptions
+	buf         bytes.Buffer
+}
+
+func (w *eventualWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+// Close commits the write unless its context was cancelled, in which case
+// nothing is written, like the writers of real buckets.
+func (w *eventualWriter) Close() error {
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+	w.b.mu.Lock()
+	defer w.b.mu.Unlock()
+	if w.opts.IfNotExist && !w.b.ignoreIfNotExist && w.b.exists(w.key) {
+		return fmt.Errorf("%w: %s", errExists, w.key)
+	}
+	now := w.b.clock.Now()
+	data := slices.Clone(w.buf.Bytes())
+	sum := md5.Sum(data)
+	w.b.add(w.key, &revision{at: now, data: data, attrs: driver.Attributes{
+		CacheControl:       w.opts.CacheControl,
+		ContentDisposition: w.opts.ContentDisposition,
+		ContentEncoding:    w.opts.ContentEncoding,
+		ContentLanguage:    w.opts.ContentLanguage,
+		ContentType:        w.contentType,
+		Metadata:           maps.Clone(w.opts.Metadata),
+		CreateTime:         now,
+		ModTime:            now,
+		Size:               int64(len(data)),
+		MD5:                sum[:],
+		ETag:               fmt.Sprintf(`"%x"`, sum),
+	}})
+	return nil
+}
+
+var _ io.WriteCloser = (*eventualWriter)(nil)
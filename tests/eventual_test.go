@@ -0,0 +1,128 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests_test
+
+import (
+	"context"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+	"github.com/chinglinwen/adk-artifact/tests"
+)
+
+func listKeys(ctx context.Context, t *testing.T, b *blob.Bucket) []string {
+	t.Helper()
+	var keys []string
+	iter := b.List(nil)
+	for {
+		obj, err := iter.Next(ctx)
+		if err == io.EOF {
+			return keys
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, obj.Key)
+	}
+}
+
+func TestEventualBucket(t *testing.T) {
+	ctx := t.Context()
+	clock := artifactx.NewManualClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := tests.OpenEventualBucket(&tests.EventualBucketOptions{
+		Clock: clock, ListDelay: time.Minute, ReadDelay: time.Second,
+	})
+	defer b.Close()
+
+	if err := b.WriteAll(ctx, "a", []byte("v1"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.ReadAll(ctx, "a"); gcerrors.Code(err) != gcerrors.NotFound {
+		t.Errorf("ReadAll() right after the first write = %v, want NotFound", err)
+	}
+	// Conditional writes see the latest state.
+	err := b.WriteAll(ctx, "a", []byte("v1"), &blob.WriterOptions{IfNotExist: true})
+	if gcerrors.Code(err) != gcerrors.FailedPrecondition {
+		t.Errorf("WriteAll(IfNotExist) of an existing key = %v, want FailedPrecondition", err)
+	}
+
+	clock.Advance(time.Second)
+	if data, err := b.ReadAll(ctx, "a"); err != nil || string(data) != "v1" {
+		t.Errorf("ReadAll() = (%q, %v), want v1", data, err)
+	}
+	if keys := listKeys(ctx, t, b); len(keys) != 0 {
+		t.Errorf("listed %q before the list delay, want nothing", keys)
+	}
+	if err := b.WriteAll(ctx, "a", []byte("v2"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := b.ReadAll(ctx, "a"); err != nil || string(data) != "v1" {
+		t.Errorf("ReadAll() right after an overwrite = (%q, %v), want the stale v1", data, err)
+	}
+
+	clock.Advance(time.Minute)
+	if data, err := b.ReadAll(ctx, "a"); err != nil || string(data) != "v2" {
+		t.Errorf("ReadAll() = (%q, %v), want v2", data, err)
+	}
+	if keys := listKeys(ctx, t, b); !slices.Equal(keys, []string{"a"}) {
+		t.Errorf("listed %q, want [a]", keys)
+	}
+	if err := b.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := b.ReadAll(ctx, "a"); err != nil || string(data) != "v2" {
+		t.Errorf("ReadAll() right after a delete = (%q, %v), want the stale v2", data, err)
+	}
+	if keys := listKeys(ctx, t, b); !slices.Equal(keys, []string{"a"}) {
+		t.Errorf("listed %q right after a delete, want the stale [a]", keys)
+	}
+	if err := b.Delete(ctx, "a"); gcerrors.Code(err) != gcerrors.NotFound {
+		t.Errorf("Delete() of a deleted key = %v, want NotFound", err)
+	}
+
+	clock.Advance(time.Minute)
+	if exists, err := b.Exists(ctx, "a"); err != nil || exists {
+		t.Errorf("Exists() = (%v, %v), want false", exists, err)
+	}
+	if keys := listKeys(ctx, t, b); len(keys) != 0 {
+		t.Errorf("listed %q, want nothing", keys)
+	}
+}
+
+func TestEventualBucketCancelledWrite(t *testing.T) {
+	b := tests.OpenEventualBucket(nil)
+	defer b.Close()
+	ctx, cancel := context.WithCancel(t.Context())
+	w, err := b.NewWriter(ctx, "a", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("partial")); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := w.Close(); err == nil {
+		t.Error("Close() after cancelling = nil, want an error")
+	}
+	if exists, err := b.Exists(t.Context(), "a"); err != nil || exists {
+		t.Errorf("Exists() = (%v, %v), want false", exists, err)
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"math/rand"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+)
+
+// The properties are checked with testing/quick: every check runs a random
+// sequence of saves and deletes against a new service and, after each
+// operation, compares the service with an in-memory model of it.
+
+const propertiesApp, propertiesUser = "propapp", "propuser"
+
+var (
+	propertySessions = []string{"s1", "s2"}
+	// propertyFiles includes a user scoped file, shared by the sessions.
+	propertyFiles = []string{"a.txt", "b.bin", "user:c.dat"}
+)
+
+// propertyOp is a save or a delete.
+type propertyOp struct {
+	SessionID, FileName string
+	// Part is the saved part, or nil for a delete.
+	Part *genai.Part
+	// Version is the deleted version; zero deletes every version. A
+	// version that was never saved is chosen sometimes.
+	Version int64
+}
+
+func (op propertyOp) String() string {
+	if op.Part == nil {
+		return fmt.Sprintf("Delete(%s/%s@%d)", op.SessionID, op.FileName, op.Version)
+	}
+	return fmt.Sprintf("Save(%s/%s)", op.SessionID, op.FileName)
+}
+
+// propertyOps is a sequence of operations generated by testing/quick.
+type propertyOps []propertyOp
+
+// Generate implements [quick.Generator].
+func (propertyOps) Generate(r *rand.Rand, size int) reflect.Value {
+	ops := make(propertyOps, 1+r.Intn(size))
+	for i := range ops {
+		op := propertyOp{
+			SessionID: propertySessions[r.Intn(len(propertySessions))],
+			FileName:  propertyFiles[r.Intn(len(propertyFiles))],
+		}
+		if r.Intn(4) == 0 {
+			op.Version = int64(r.Intn(4))
+		} else {
+			op.Part = randomPart(r)
+		}
+		ops[i] = op
+	}
+	return reflect.ValueOf(ops)
+}
+
+// randomPart returns a text part, or inline data of a random content type
+// whose bytes are arbitrary unless it is text.
+func randomPart(r *rand.Rand) *genai.Part {
+	const letters = "abcdefghijklmnopqrstuvwxyz {}\n"
+	text := make([]byte, 1+r.Intn(64))
+	for i := range text {
+		text[i] = letters[r.Intn(len(letters))]
+	}
+	switch r.Intn(4) {
+	case 0:
+		return genai.NewPartFromText(string(text))
+	case 1:
+		return genai.NewPartFromBytes(text, "text/plain")
+	}
+	data := make([]byte, 1+r.Intn(256))
+	r.Read(data)
+	return genai.NewPartFromBytes(data, []string{"application/octet-stream", "image/png"}[r.Intn(2)])
+}
+
+// propertyModel is what the service is expected to hold: the parts of the
+// versions of every artifact, by session and file name. User scoped files
+// are kept under the first session.
+type propertyModel map[[2]string]map[int64]*genai.Part
+
+func (m propertyModel) key(sessionID, fileName string) [2]string {
+	if strings.HasPrefix(fileName, "user:") {
+		sessionID = propertySessions[0]
+	}
+	return [2]string{sessionID, fileName}
+}
+
+// TestArtifactServiceProperties checks, for random sequences of saves and
+// deletes, that:
+//
+//   - Load returns what Save saved, for the latest and for any version;
+//   - Save returns a version greater than every existing version;
+//   - Versions and List return exactly the saved, not deleted, versions
+//     and files.
+//
+// It runs against any factory, like [TestArtifactService].
+func TestArtifactServiceProperties(t *testing.T, name string, factory func(t *testing.T) (artifact.Service, error)) {
+	t.Run(fmt.Sprintf("Test%sArtifactService_Properties", name), func(t *testing.T) {
+		ctx := t.Context()
+		config := &quick.Config{MaxCount: 20}
+		if testing.Short() {
+			config.MaxCount = 5
+		}
+		check := func(ops propertyOps) bool {
+			srv, err := factory(t)
+			if err != nil {
+				t.Fatalf("Failed to set up service: %v", err)
+			}
+			if err := checkProperties(ctx, srv, ops); err != nil {
+				t.Log(err)
+				return false
+			}
+			return true
+		}
+		if err := quick.Check(check, config); err != nil {
+			if cerr, ok := err.(*quick.CheckError); ok {
+				// Print the operations rather than pointers to parts.
+				t.Errorf("#%d: failed on %v", cerr.Count, cerr.In[0])
+				return
+			}
+			t.Error(err)
+		}
+	})
+}
+
+// checkProperties runs ops against srv, comparing srv with the model after
+// every operation.
+func checkProperties(ctx context.Context, srv artifact.Service, ops propertyOps) error {
+	model := propertyModel{}
+	for i, op := range ops {
+		key := model.key(op.SessionID, op.FileName)
+		versions := model[key]
+		if op.Part != nil {
+			resp, err := srv.Save(ctx, &artifact.SaveRequest{
+				AppName: propertiesApp, UserID: propertiesUser, SessionID: op.SessionID, FileName: op.FileName, Part: op.Part,
+			})
+			if err != nil {
+				return fmt.Errorf("[%d] %v failed: %w", i, op, err)
+			}
+			if len(versions) > 0 && resp.Version <= slices.Max(slices.Collect(maps.Keys(versions))) {
+				return fmt.Errorf("[%d] %v = version %d, want more than the existing versions %v", i, op, resp.Version, slices.Sorted(maps.Keys(versions)))
+			}
+			if versions == nil {
+				versions = map[int64]*genai.Part{}
+				model[key] = versions
+			}
+			versions[resp.Version] = op.Part
+		} else {
+			err := srv.Delete(ctx, &artifact.DeleteRequest{
+				AppName: propertiesApp, UserID: propertiesUser, SessionID: op.SessionID, FileName: op.FileName, Version: op.Version,
+			})
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("[%d] %v failed: %w", i, op, err)
+			}
+			if op.Version == 0 {
+				delete(model, key)
+			} else if delete(versions, op.Version); len(versions) == 0 {
+				delete(model, key)
+			}
+		}
+		if err := checkArtifact(ctx, srv, op.SessionID, op.FileName, model[key]); err != nil {
+			return fmt.Errorf("[%d] after %v: %w", i, op, err)
+		}
+		for _, sessionID := range propertySessions {
+			if err := checkList(ctx, srv, sessionID, model); err != nil {
+				return fmt.Errorf("[%d] after %v: %w", i, op, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkArtifact compares the versions of an artifact with the model.
+func checkArtifact(ctx context.Context, srv artifact.Service, sessionID, fileName string, want map[int64]*genai.Part) error {
+	resp, err := srv.Versions(ctx, &artifact.VersionsRequest{
+		AppName: propertiesApp, UserID: propertiesUser, SessionID: sessionID, FileName: fileName,
+	})
+	if err != nil && !(len(want) == 0 && errors.Is(err, fs.ErrNotExist)) {
+		return fmt.Errorf("Versions(%s/%s) failed: %w", sessionID, fileName, err)
+	}
+	var got []int64
+	if resp != nil {
+		got = slices.Sorted(slices.Values(resp.Versions))
+	}
+	if wantVersions := slices.Sorted(maps.Keys(want)); !slices.Equal(got, wantVersions) {
+		return fmt.Errorf("Versions(%s/%s) = %v, want %v", sessionID, fileName, got, wantVersions)
+	}
+	load := func(version int64) (*genai.Part, error) {
+		resp, err := srv.Load(ctx, &artifact.LoadRequest{
+			AppName: propertiesApp, UserID: propertiesUser, SessionID: sessionID, FileName: fileName, Version: version,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Part, nil
+	}
+	if len(want) == 0 {
+		if _, err := load(0); !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("Load(%s/%s) of a deleted artifact = %v, want fs.ErrNotExist", sessionID, fileName, err)
+		}
+		return nil
+	}
+	for version, wantPart := range want {
+		got, err := load(version)
+		if err != nil || !cmp.Equal(got, wantPart) {
+			return fmt.Errorf("Load(%s/%s@%d) = (%v, %v), want (%v, nil)", sessionID, fileName, version, got, err, wantPart)
+		}
+	}
+	latest := slices.Max(slices.Collect(maps.Keys(want)))
+	if got, err := load(0); err != nil || !cmp.Equal(got, want[latest]) {
+		return fmt.Errorf("Load(%s/%s) = (%v, %v), want version %d", sessionID, fileName, got, err, latest)
+	}
+	return nil
+}
+
+// checkList compares the files listed in a session with the model.
+func checkList(ctx context.Context, srv artifact.Service, sessionID string, model propertyModel) error {
+	resp, err := srv.List(ctx, &artifact.ListRequest{AppName: propertiesApp, UserID: propertiesUser, SessionID: sessionID})
+	if err != nil {
+		return fmt.Errorf("List(%s) failed: %w", sessionID, err)
+	}
+	var want []string
+	for key := range model {
+		if key[0] == sessionID || strings.HasPrefix(key[1], "user:") {
+			want = append(want, key[1])
+		}
+	}
+	slices.Sort(want)
+	if got := slices.Sorted(slices.Values(resp.FileNames)); !slices.Equal(got, want) {
+		return fmt.Errorf("List(%s) = %v, want %v", sessionID, got, want)
+	}
+	return nil
+}
@@ -16,16 +16,22 @@ package tests
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/genai"
 
 	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
 )
 
 func TestArtifactService(t *testing.T, name string, factory func(t *testing.T) (artifact.Service, error)) {
@@ -56,6 +62,197 @@ func TestArtifactService(t *testing.T, name string, factory func(t *testing.T) (
 		}
 		testArtifactService_UserScoped(ctx, t, srv, name)
 	})
+	t.Run(fmt.Sprintf("Test%sArtifactService_FileData", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_FileData(ctx, t, srv, name)
+	})
+	t.Run(fmt.Sprintf("Test%sArtifactService_StructuredParts", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_StructuredParts(ctx, t, srv, name)
+	})
+	t.Run(fmt.Sprintf("Test%sArtifactService_Attributes", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_Attributes(ctx, t, srv, name)
+	})
+	t.Run(fmt.Sprintf("Test%sArtifactService_ResumableUpload", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_ResumableUpload(ctx, t, srv, name)
+	})
+	t.Run(fmt.Sprintf("Test%sArtifactService_Browse", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_Browse(ctx, t, srv, name)
+	})
+
+	t.Run(fmt.Sprintf("Test%sArtifactService_Move", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_Move(ctx, t, srv, name)
+	})
+
+	t.Run(fmt.Sprintf("Test%sArtifactService_Clone", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_Clone(ctx, t, srv)
+	})
+
+	t.Run(fmt.Sprintf("Test%sArtifactService_MaxLoadSize", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_MaxLoadSize(ctx, t, srv)
+	})
+
+	t.Run(fmt.Sprintf("Test%sArtifactService_Pin", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_Pin(ctx, t, srv)
+	})
+
+	t.Run(fmt.Sprintf("Test%sArtifactService_Immutable", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_Immutable(ctx, t, srv)
+	})
+
+	t.Run(fmt.Sprintf("Test%sArtifactService_UpdateJSON", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_UpdateJSON(ctx, t, srv)
+	})
+
+	t.Run(fmt.Sprintf("Test%sArtifactService_Relabel", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_Relabel(ctx, t, srv)
+	})
+
+	t.Run(fmt.Sprintf("Test%sArtifactService_DryRun", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_DryRun(ctx, t, srv)
+	})
+
+	t.Run(fmt.Sprintf("Test%sArtifactService_ListDetailed", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_ListDetailed(ctx, t, srv)
+	})
+
+	t.Run(fmt.Sprintf("Test%sArtifactService_ListRecent", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_ListRecent(ctx, t, srv)
+	})
+
+	t.Run(fmt.Sprintf("Test%sArtifactService_FindFile", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_FindFile(ctx, t, srv)
+	})
+
+	t.Run(fmt.Sprintf("Test%sArtifactService_CancelledSave", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_CancelledSave(ctx, t, srv)
+	})
+
+	t.Run(fmt.Sprintf("Test%sArtifactService_Compact", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		testArtifactService_Compact(ctx, t, srv)
+	})
+
+	t.Run(fmt.Sprintf("Test%sArtifactService_HealthCheck", name), func(t *testing.T) {
+		ctx := t.Context()
+		// Create the service using the factory for this sub-test
+		srv, err := factory(t)
+		if err != nil {
+			t.Fatalf("Failed to set up service: %v", err)
+		}
+		checker, ok := srv.(artifactx.HealthChecker)
+		if !ok {
+			t.Skip("service does not implement artifactx.HealthChecker")
+		}
+		if err := checker.HealthCheck(ctx); err != nil {
+			t.Errorf("HealthCheck() = %v, want nil", err)
+		}
+	})
 }
 
 func testArtifactService(ctx context.Context, t *testing.T, srv artifact.Service, testSuffix string) {
@@ -409,3 +606,1112 @@ func testArtifactService_Empty(ctx context.Context, t *testing.T, srv artifact.S
 		}
 	})
 }
+
+func testArtifactService_FileData(ctx context.Context, t *testing.T, srv artifact.Service, testSuffix string) {
+	appName := "testapp"
+	userID := "testuser"
+	sessionID := "testsession"
+
+	want := &genai.Part{FileData: &genai.FileData{
+		FileURI: "https://example.com/report.pdf", MIMEType: "application/pdf", DisplayName: "report.pdf",
+	}}
+	got, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: appName, UserID: userID, SessionID: sessionID, FileName: "link",
+		Part: want,
+	})
+	if err != nil || got.Version != 1 {
+		t.Fatalf("Save(FileData) = (%v, %v), want (1, nil)", got, err)
+	}
+
+	t.Run(fmt.Sprintf("Load_%s", testSuffix), func(t *testing.T) {
+		resp, err := srv.Load(ctx, &artifact.LoadRequest{
+			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "link",
+		})
+		if err != nil {
+			t.Fatalf("Load('link') failed: %v", err)
+		}
+		if diff := cmp.Diff(want, resp.Part); diff != "" {
+			t.Errorf("Load('link') mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run(fmt.Sprintf("SaveMissingURI_%s", testSuffix), func(t *testing.T) {
+		_, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "link",
+			Part: &genai.Part{FileData: &genai.FileData{MIMEType: "application/pdf"}},
+		})
+		if err == nil {
+			t.Errorf("Save(FileData without URI) succeeded, want error")
+		}
+	})
+}
+
+func testArtifactService_StructuredParts(ctx context.Context, t *testing.T, srv artifact.Service, testSuffix string) {
+	appName := "testapp"
+	userID := "testuser"
+	sessionID := "testsession"
+
+	for _, tc := range []struct {
+		fileName string
+		part     *genai.Part
+	}{
+		{"call", genai.NewPartFromFunctionCall("lookup", map[string]any{"city": "Paris"})},
+		{"response", genai.NewPartFromFunctionResponse("lookup", map[string]any{"temp": 21.5})},
+		{"code", genai.NewPartFromExecutableCode("print(1)", genai.LanguagePython)},
+		// Text and text/plain inline data load back as they were saved.
+		{"text", genai.NewPartFromText("Summarize the report.")},
+		{"plain", genai.NewPartFromBytes([]byte("col1,col2\n"), "text/plain")},
+		// Fields qualifying the content load back with it.
+		{"thought", &genai.Part{Text: "Summarize first.", Thought: true, ThoughtSignature: []byte{0xca, 0xfe}}},
+		{"video", &genai.Part{
+			InlineData:    &genai.Blob{Data: []byte("ftypmp42"), MIMEType: "video/mp4", DisplayName: "clip.mp4"},
+			VideoMetadata: &genai.VideoMetadata{StartOffset: 1500 * time.Millisecond, EndOffset: 4 * time.Second},
+		}},
+		{"clip", &genai.Part{
+			FileData:      &genai.FileData{FileURI: "gs://bucket/clip.mp4", MIMEType: "video/mp4"},
+			VideoMetadata: &genai.VideoMetadata{EndOffset: 30 * time.Second},
+		}},
+	} {
+		t.Run(fmt.Sprintf("%s_%s", tc.fileName, testSuffix), func(t *testing.T) {
+			if _, err := srv.Save(ctx, &artifact.SaveRequest{
+				AppName: appName, UserID: userID, SessionID: sessionID, FileName: tc.fileName,
+				Part: tc.part,
+			}); err != nil {
+				t.Fatalf("Save(%q) failed: %v", tc.fileName, err)
+			}
+			resp, err := srv.Load(ctx, &artifact.LoadRequest{
+				AppName: appName, UserID: userID, SessionID: sessionID, FileName: tc.fileName,
+			})
+			if err != nil {
+				t.Fatalf("Load(%q) failed: %v", tc.fileName, err)
+			}
+			if diff := cmp.Diff(tc.part, resp.Part); diff != "" {
+				t.Errorf("Load(%q) mismatch (-want +got):\n%s", tc.fileName, diff)
+			}
+		})
+	}
+}
+
+// testArtifactService_Attributes covers the optional [artifactx.Stater],
+// [artifactx.Opener] and [artifactx.RangeOpener] interfaces and is skipped
+// for services without the first two.
+func testArtifactService_Attributes(ctx context.Context, t *testing.T, srv artifact.Service, testSuffix string) {
+	stater, ok := srv.(artifactx.Stater)
+	if !ok {
+		t.Skip("service does not implement artifactx.Stater")
+	}
+	opener, ok := srv.(artifactx.Opener)
+	if !ok {
+		t.Skip("service does not implement artifactx.Opener")
+	}
+	appName := "testapp"
+	userID := "testuser"
+	sessionID := "testsession"
+
+	md := map[string]string{"origin": "test"}
+	for _, data := range []string{"v1", "version 2"} {
+		if _, err := srv.Save(artifactx.WithMetadata(ctx, md), &artifact.SaveRequest{
+			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "file",
+			Part: genai.NewPartFromBytes([]byte(data), "application/octet-stream"),
+		}); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+	}
+
+	t.Run(fmt.Sprintf("Stat_%s", testSuffix), func(t *testing.T) {
+		got, err := stater.Stat(ctx, &artifact.LoadRequest{
+			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "file",
+		})
+		if err != nil {
+			t.Fatalf("Stat() failed: %v", err)
+		}
+		if got.Version != 2 || got.Size != int64(len("version 2")) || got.ContentType != "application/octet-stream" || got.ModTime.IsZero() {
+			t.Errorf("Stat() = %+v, want version 2 of 9 bytes", got)
+		}
+		if diff := cmp.Diff(md, got.Metadata); diff != "" {
+			t.Errorf("Stat().Metadata mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run(fmt.Sprintf("ETag_%s", testSuffix), func(t *testing.T) {
+		req := &artifact.LoadRequest{AppName: appName, UserID: userID, SessionID: sessionID, FileName: "file"}
+		got, err := stater.Stat(ctx, req)
+		if err != nil {
+			t.Fatalf("Stat() failed: %v", err)
+		}
+		if want := artifactx.ChecksumETag(artifactx.Checksum([]byte("version 2"))); got.ETag != want {
+			t.Errorf("Stat().ETag = %q, want %q", got.ETag, want)
+		}
+		if _, err := srv.Load(artifactx.WithIfNoneMatch(ctx, `"other"`, got.ETag), req); !errors.Is(err, artifactx.ErrNotModified) {
+			t.Errorf("Load() with a matching If-None-Match error = %v, want %v", err, artifactx.ErrNotModified)
+		}
+		v1 := &artifact.LoadRequest{AppName: appName, UserID: userID, SessionID: sessionID, FileName: "file", Version: 1}
+		resp, err := srv.Load(artifactx.WithIfNoneMatch(ctx, got.ETag), v1)
+		if err != nil || string(resp.Part.InlineData.Data) != "v1" {
+			t.Errorf("Load(version 1) with another ETag = (%v, %v), want v1", resp, err)
+		}
+	})
+
+	t.Run(fmt.Sprintf("Conditional_%s", testSuffix), func(t *testing.T) {
+		req := &artifact.LoadRequest{AppName: appName, UserID: userID, SessionID: sessionID, FileName: "file"}
+		got, err := stater.Stat(ctx, req)
+		if err != nil {
+			t.Fatalf("Stat() failed: %v", err)
+		}
+		if _, err := srv.Load(artifactx.WithIfNewerThan(ctx, got.Version), req); !errors.Is(err, artifactx.ErrNotModified) {
+			t.Errorf("Load() newer than the latest version error = %v, want %v", err, artifactx.ErrNotModified)
+		}
+		if resp, err := srv.Load(artifactx.WithIfNewerThan(ctx, got.Version-1), req); err != nil || string(resp.Part.InlineData.Data) != "version 2" {
+			t.Errorf("Load() newer than the previous version = (%v, %v), want version 2", resp, err)
+		}
+		if _, err := srv.Load(artifactx.WithIfModifiedSince(ctx, got.Created), req); !errors.Is(err, artifactx.ErrNotModified) {
+			t.Errorf("Load() modified since its creation error = %v, want %v", err, artifactx.ErrNotModified)
+		}
+		if resp, err := srv.Load(artifactx.WithIfModifiedSince(ctx, got.Created.Add(-time.Second)), req); err != nil || string(resp.Part.InlineData.Data) != "version 2" {
+			t.Errorf("Load() modified since before its creation = (%v, %v), want version 2", resp, err)
+		}
+	})
+
+	t.Run(fmt.Sprintf("Open_%s", testSuffix), func(t *testing.T) {
+		r, err := opener.Open(ctx, &artifact.LoadRequest{
+			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "file", Version: 1,
+		})
+		if err != nil {
+			t.Fatalf("Open() failed: %v", err)
+		}
+		defer r.Close()
+		data, err := io.ReadAll(r)
+		if err != nil || string(data) != "v1" || r.Version != 1 {
+			t.Errorf("Open() = (%q, version %d, %v), want (%q, version 1, nil)", data, r.Version, err, "v1")
+		}
+	})
+
+	t.Run(fmt.Sprintf("OpenMissing_%s", testSuffix), func(t *testing.T) {
+		_, err := opener.Open(ctx, &artifact.LoadRequest{
+			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "missing",
+		})
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Open('missing') = %v, want error(%v)", err, fs.ErrNotExist)
+		}
+	})
+
+	t.Run(fmt.Sprintf("OpenRange_%s", testSuffix), func(t *testing.T) {
+		ro, ok := srv.(artifactx.RangeOpener)
+		if !ok {
+			t.Skip("service does not implement artifactx.RangeOpener")
+		}
+		for _, tc := range []struct {
+			offset, length int64
+			want           string
+		}{
+			{0, 7, "version"},
+			{8, -1, "2"},
+			{3, 100, "sion 2"},
+			{9, -1, ""},
+			{20, 5, ""},
+		} {
+			r, err := ro.OpenRange(ctx, &artifact.LoadRequest{
+				AppName: appName, UserID: userID, SessionID: sessionID, FileName: "file",
+			}, tc.offset, tc.length)
+			if err != nil {
+				t.Fatalf("OpenRange(%d, %d) failed: %v", tc.offset, tc.length, err)
+			}
+			data, err := io.ReadAll(r)
+			r.Close()
+			if err != nil || string(data) != tc.want || r.Size != int64(len("version 2")) {
+				t.Errorf("OpenRange(%d, %d) = (%q, size %d, %v), want %q of a 9 byte version", tc.offset, tc.length, data, r.Size, err, tc.want)
+			}
+		}
+	})
+
+	t.Run(fmt.Sprintf("ListVersions_%s", testSuffix), func(t *testing.T) {
+		// More than 9 versions, so that lexicographic key order differs.
+		for i := range 12 {
+			if _, err := srv.Save(artifactx.WithAuthor(ctx, fmt.Sprintf("author%d", i+1)), &artifact.SaveRequest{
+				AppName: appName, UserID: userID, SessionID: sessionID, FileName: "many",
+				Part: genai.NewPartFromText(fmt.Sprint(i + 1)),
+			}); err != nil {
+				t.Fatalf("Save() failed: %v", err)
+			}
+		}
+		versions, err := srv.Versions(ctx, &artifact.VersionsRequest{
+			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "many",
+		})
+		if err != nil || !slices.IsSorted(versions.Versions) {
+			t.Errorf("Versions() = (%v, %v), want ascending versions", versions, err)
+		}
+
+		got, err := artifactx.ListVersions(ctx, srv, &artifactx.ListVersionsRequest{
+			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "many", Limit: 3,
+		})
+		if err != nil {
+			t.Fatalf("ListVersions() failed: %v", err)
+		}
+		var summary []string
+		for _, a := range got {
+			summary = append(summary, fmt.Sprintf("%d:%s:%d", a.Version, a.Author(), a.Size))
+		}
+		if want := []string{"12:author12:2", "11:author11:2", "10:author10:2"}; !slices.Equal(summary, want) {
+			t.Errorf("ListVersions() = %v, want %v", summary, want)
+		}
+
+		_, err = artifactx.ListVersions(ctx, srv, &artifactx.ListVersionsRequest{
+			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "missing",
+		})
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("ListVersions('missing') = %v, want error(%v)", err, fs.ErrNotExist)
+		}
+	})
+}
+
+func testArtifactService_ResumableUpload(ctx context.Context, t *testing.T, srv artifact.Service, testSuffix string) {
+	uploader, ok := srv.(artifactx.Uploader)
+	if !ok {
+		t.Skip("service does not implement artifactx.Uploader")
+	}
+	appName := "testapp"
+	userID := "testuser"
+	sessionID := "testsession"
+
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: appName, UserID: userID, SessionID: sessionID, FileName: "video",
+		Part: genai.NewPartFromBytes([]byte("v1"), "video/mp4"),
+	}); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	upload, err := uploader.BeginUpload(artifactx.WithMetadata(ctx, map[string]string{"origin": "mobile"}), &artifactx.UploadRequest{
+		AppName: appName, UserID: userID, SessionID: sessionID, FileName: "video", MIMEType: "video/mp4",
+	})
+	if err != nil {
+		t.Fatalf("BeginUpload() failed: %v", err)
+	}
+	id := upload.ID
+
+	t.Run(fmt.Sprintf("AppendChunk_%s", testSuffix), func(t *testing.T) {
+		if _, err := uploader.AppendChunk(ctx, id, 0, []byte("hello ")); err != nil {
+			t.Fatalf("AppendChunk(0) failed: %v", err)
+		}
+		// A retried chunk the server already received is rejected.
+		if _, err := uploader.AppendChunk(ctx, id, 0, []byte("hello ")); !errors.Is(err, artifactx.ErrOffsetMismatch) {
+			t.Errorf("AppendChunk(0) again = %v, want error(%v)", err, artifactx.ErrOffsetMismatch)
+		}
+		// Resume from the reported size.
+		status, err := uploader.UploadStatus(ctx, id)
+		if err != nil || status.Size != 6 {
+			t.Fatalf("UploadStatus() = (%+v, %v), want size 6", status, err)
+		}
+		got, err := uploader.AppendChunk(ctx, id, status.Size, []byte("world"))
+		if err != nil || got.Size != 11 {
+			t.Fatalf("AppendChunk(6) = (%+v, %v), want size 11", got, err)
+		}
+	})
+
+	t.Run(fmt.Sprintf("CommitUpload_%s", testSuffix), func(t *testing.T) {
+		resp, err := uploader.CommitUpload(ctx, id)
+		if err != nil || resp.Version != 2 {
+			t.Fatalf("CommitUpload() = (%v, %v), want version 2", resp, err)
+		}
+		got, err := srv.Load(ctx, &artifact.LoadRequest{
+			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "video", Version: 2,
+		})
+		if err != nil {
+			t.Fatalf("Load() failed: %v", err)
+		}
+		if diff := cmp.Diff(genai.NewPartFromBytes([]byte("hello world"), "video/mp4"), got.Part); diff != "" {
+			t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+		}
+		if stater, ok := srv.(artifactx.Stater); ok {
+			attrs, err := stater.Stat(ctx, &artifact.LoadRequest{
+				AppName: appName, UserID: userID, SessionID: sessionID, FileName: "video", Version: 2,
+			})
+			if err != nil || attrs.Metadata["origin"] != "mobile" {
+				t.Errorf("Stat() = (%+v, %v), want metadata from BeginUpload", attrs, err)
+			}
+		}
+		if _, err := uploader.UploadStatus(ctx, id); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("UploadStatus() after commit = %v, want error(%v)", err, fs.ErrNotExist)
+		}
+	})
+
+	t.Run(fmt.Sprintf("AbortUpload_%s", testSuffix), func(t *testing.T) {
+		upload, err := uploader.BeginUpload(ctx, &artifactx.UploadRequest{
+			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "video", MIMEType: "video/mp4",
+		})
+		if err != nil {
+			t.Fatalf("BeginUpload() failed: %v", err)
+		}
+		if _, err := uploader.AppendChunk(ctx, upload.ID, 0, []byte("partial")); err != nil {
+			t.Fatal(err)
+		}
+		if err := uploader.AbortUpload(ctx, upload.ID); err != nil {
+			t.Fatalf("AbortUpload() failed: %v", err)
+		}
+		if _, err := uploader.AppendChunk(ctx, upload.ID, 7, []byte("more")); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("AppendChunk() after abort = %v, want error(%v)", err, fs.ErrNotExist)
+		}
+		versions, err := srv.Versions(ctx, &artifact.VersionsRequest{
+			AppName: appName, UserID: userID, SessionID: sessionID, FileName: "video",
+		})
+		if err != nil || !slices.Equal(versions.Versions, []int64{1, 2}) {
+			t.Errorf("Versions() = (%v, %v), want [1 2]", versions, err)
+		}
+	})
+}
+
+// testArtifactService_Browse covers the optional [artifactx.Browser]
+// interface and is skipped for services without it.
+func testArtifactService_Browse(ctx context.Context, t *testing.T, srv artifact.Service, testSuffix string) {
+	browser, ok := srv.(artifactx.Browser)
+	if !ok {
+		t.Skip("service does not implement artifactx.Browser")
+	}
+	appName := "testapp"
+	for _, ref := range []struct{ userID, sessionID, fileName string }{
+		{"alice", "s1", "file"},
+		{"alice", "s2", "file"},
+		{"alice", "s2", "user:profile"},
+		{"bob", "s3", "user:profile"},
+	} {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: appName, UserID: ref.userID, SessionID: ref.sessionID, FileName: ref.fileName,
+			Part: genai.NewPartFromText("data"),
+		}); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+	}
+
+	t.Run(fmt.Sprintf("ListUsers_%s", testSuffix), func(t *testing.T) {
+		users, err := browser.ListUsers(ctx, appName)
+		if errors.Is(err, errors.ErrUnsupported) {
+			t.Skip("key layout cannot be browsed")
+		}
+		if err != nil || !slices.Equal(users, []string{"alice", "bob"}) {
+			t.Errorf("ListUsers() = (%v, %v), want [alice bob]", users, err)
+		}
+		if users, err := browser.ListUsers(ctx, "otherapp"); err != nil || len(users) != 0 {
+			t.Errorf("ListUsers('otherapp') = (%v, %v), want none", users, err)
+		}
+	})
+
+	t.Run(fmt.Sprintf("ListSessions_%s", testSuffix), func(t *testing.T) {
+		for user, want := range map[string][]string{"alice": {"s1", "s2"}, "bob": nil, "carol": nil} {
+			sessions, err := browser.ListSessions(ctx, appName, user)
+			if errors.Is(err, errors.ErrUnsupported) {
+				t.Skip("key layout cannot be browsed")
+			}
+			if err != nil || !slices.Equal(sessions, want) {
+				t.Errorf("ListSessions(%q) = (%v, %v), want %v", user, sessions, err, want)
+			}
+		}
+	})
+}
+
+// testArtifactService_Move covers [artifactx.Promote] and [artifactx.Demote],
+// which use [artifactx.Mover] if the service implements it.
+func testArtifactService_Move(ctx context.Context, t *testing.T, srv artifact.Service, testSuffix string) {
+	appName := "testapp"
+	userID := "testuser"
+	md := map[string]string{"origin": "test"}
+	for _, data := range []string{"v1", "v2"} {
+		if _, err := srv.Save(artifactx.WithMetadata(ctx, md), &artifact.SaveRequest{
+			AppName: appName, UserID: userID, SessionID: "s1", FileName: "report",
+			Part: genai.NewPartFromBytes([]byte(data), "application/pdf"),
+		}); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+	}
+
+	t.Run(fmt.Sprintf("Promote_%s", testSuffix), func(t *testing.T) {
+		name, err := artifactx.Promote(ctx, srv, appName, userID, "s1", "report")
+		if err != nil || name != "user:report" {
+			t.Fatalf("Promote() = (%q, %v), want user:report", name, err)
+		}
+		// The promoted artifact is visible from other sessions.
+		list, err := srv.List(ctx, &artifact.ListRequest{AppName: appName, UserID: userID, SessionID: "s2"})
+		if err != nil || !slices.Equal(list.FileNames, []string{"user:report"}) {
+			t.Errorf("List(s2) = (%v, %v), want [user:report]", list, err)
+		}
+		got, err := srv.Load(ctx, &artifact.LoadRequest{AppName: appName, UserID: userID, SessionID: "s2", FileName: name, Version: 1})
+		if err != nil {
+			t.Fatalf("Load() failed: %v", err)
+		}
+		if diff := cmp.Diff(genai.NewPartFromBytes([]byte("v1"), "application/pdf"), got.Part); diff != "" {
+			t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+		}
+		if stater, ok := srv.(artifactx.Stater); ok {
+			attrs, err := stater.Stat(ctx, &artifact.LoadRequest{AppName: appName, UserID: userID, SessionID: "s2", FileName: name})
+			if err != nil || attrs.Version != 2 || attrs.Metadata["origin"] != "test" {
+				t.Errorf("Stat() = (%+v, %v), want version 2 with its metadata", attrs, err)
+			}
+		}
+		_, err = srv.Versions(ctx, &artifact.VersionsRequest{AppName: appName, UserID: userID, SessionID: "s1", FileName: "report"})
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Versions() of the source = %v, want error(%v)", err, fs.ErrNotExist)
+		}
+	})
+
+	t.Run(fmt.Sprintf("Demote_%s", testSuffix), func(t *testing.T) {
+		name, err := artifactx.Demote(ctx, srv, appName, userID, "s2", "user:report")
+		if err != nil || name != "report" {
+			t.Fatalf("Demote() = (%q, %v), want report", name, err)
+		}
+		versions, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: appName, UserID: userID, SessionID: "s2", FileName: "report"})
+		if err != nil || !slices.Equal(slices.Sorted(slices.Values(versions.Versions)), []int64{1, 2}) {
+			t.Errorf("Versions() = (%v, %v), want [1 2]", versions, err)
+		}
+	})
+
+	t.Run(fmt.Sprintf("MoveErrors_%s", testSuffix), func(t *testing.T) {
+		if _, err := artifactx.Promote(ctx, srv, appName, userID, "s1", "missing"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Promote('missing') = %v, want error(%v)", err, fs.ErrNotExist)
+		}
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: appName, UserID: userID, SessionID: "s3", FileName: "user:report", Part: genai.NewPartFromText("x"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := artifactx.Promote(ctx, srv, appName, userID, "s2", "report"); !errors.Is(err, fs.ErrExist) {
+			t.Errorf("Promote() onto an existing artifact = %v, want error(%v)", err, fs.ErrExist)
+		}
+	})
+}
+
+// testArtifactService_Clone covers [artifactx.CloneSession], which uses
+// [artifactx.SessionCloner] if the service implements it.
+func testArtifactService_Clone(ctx context.Context, t *testing.T, srv artifact.Service) {
+	appName, userID := "testapp", "testuser"
+	md := map[string]string{"origin": "test"}
+	for _, data := range []string{"v1", "v2"} {
+		if _, err := srv.Save(artifactx.WithMetadata(ctx, md), &artifact.SaveRequest{
+			AppName: appName, UserID: userID, SessionID: "s1", FileName: "report",
+			Part: genai.NewPartFromBytes([]byte(data), "application/pdf"),
+		}); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+	}
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: appName, UserID: userID, SessionID: "s1", FileName: "user:profile", Part: genai.NewPartFromText("shared"),
+	}); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	req := &artifactx.CloneRequest{AppName: appName, UserID: userID, SessionID: "s1", NewSessionID: "s2"}
+	if err := artifactx.CloneSession(ctx, srv, req); err != nil {
+		t.Fatalf("CloneSession() failed: %v", err)
+	}
+	list, err := srv.List(ctx, &artifact.ListRequest{AppName: appName, UserID: userID, SessionID: "s2"})
+	if err != nil || !slices.Equal(list.FileNames, []string{"report", "user:profile"}) {
+		t.Errorf("List(s2) = (%v, %v), want [report user:profile]", list, err)
+	}
+	versions, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: appName, UserID: userID, SessionID: "s2", FileName: "report"})
+	if err != nil || !slices.Equal(versions.Versions, []int64{1, 2}) {
+		t.Errorf("Versions(s2) = (%v, %v), want [1 2]", versions, err)
+	}
+	got, err := srv.Load(ctx, &artifact.LoadRequest{AppName: appName, UserID: userID, SessionID: "s2", FileName: "report", Version: 1})
+	if err != nil || string(got.Part.InlineData.Data) != "v1" || got.Part.InlineData.MIMEType != "application/pdf" {
+		t.Errorf("Load(s2, 1) = (%v, %v), want v1 as application/pdf", got, err)
+	}
+	if stater, ok := srv.(artifactx.Stater); ok {
+		attrs, err := stater.Stat(ctx, &artifact.LoadRequest{AppName: appName, UserID: userID, SessionID: "s2", FileName: "report"})
+		if err != nil {
+			t.Fatalf("Stat(s2) failed: %v", err)
+		}
+		if diff := cmp.Diff(md, attrs.Metadata); diff != "" {
+			t.Errorf("Stat(s2).Metadata mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	// The clone is independent of its source.
+	if err := srv.Delete(ctx, &artifact.DeleteRequest{AppName: appName, UserID: userID, SessionID: "s1", FileName: "report"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Load(ctx, &artifact.LoadRequest{AppName: appName, UserID: userID, SessionID: "s2", FileName: "report"}); err != nil {
+		t.Errorf("Load(s2) after deleting the source failed: %v", err)
+	}
+	if err := artifactx.CloneSession(ctx, srv, &artifactx.CloneRequest{AppName: appName, UserID: userID, SessionID: "s2", NewSessionID: "s2"}); err == nil {
+		t.Error("CloneSession() onto itself succeeded")
+	}
+	if err := artifactx.CloneSession(ctx, srv, req); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("CloneSession() onto a session with artifacts = %v, want %v", err, fs.ErrExist)
+	}
+}
+
+// testArtifactService_MaxLoadSize covers [artifactx.WithMaxLoadSize].
+func testArtifactService_MaxLoadSize(ctx context.Context, t *testing.T, srv artifact.Service) {
+	req := &artifact.LoadRequest{AppName: "testapp", UserID: "testuser", SessionID: "s1", FileName: "big.bin"}
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+		Part: genai.NewPartFromBytes(make([]byte, 1024), "application/octet-stream"),
+	}); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	if _, err := srv.Load(artifactx.WithMaxLoadSize(ctx, 1023), req); !errors.Is(err, artifactx.ErrTooLarge) {
+		t.Errorf("Load() above the limit error = %v, want %v", err, artifactx.ErrTooLarge)
+	}
+	resp, err := srv.Load(artifactx.WithMaxLoadSize(ctx, 1024), req)
+	if err != nil {
+		t.Fatalf("Load() at the limit failed: %v", err)
+	}
+	if got := len(resp.Part.InlineData.Data); got != 1024 {
+		t.Errorf("Load() returned %d bytes, want 1024", got)
+	}
+}
+
+// testArtifactService_Pin covers [artifactx.Pinner].
+func testArtifactService_Pin(ctx context.Context, t *testing.T, srv artifact.Service) {
+	pinner, ok := srv.(artifactx.Pinner)
+	if !ok {
+		t.Skip("service does not implement artifactx.Pinner")
+	}
+	md := map[string]string{"origin": "test"}
+	for _, data := range []string{"v1", "v2"} {
+		if _, err := srv.Save(artifactx.WithMetadata(ctx, md), &artifact.SaveRequest{
+			AppName: "testapp", UserID: "testuser", SessionID: "s1", FileName: "report",
+			Part: genai.NewPartFromBytes([]byte(data), "application/pdf"),
+		}); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+	}
+	req := &artifact.LoadRequest{AppName: "testapp", UserID: "testuser", SessionID: "s1", FileName: "report", Version: 1}
+	if err := pinner.Pin(ctx, req); err != nil {
+		t.Fatalf("Pin() failed: %v", err)
+	}
+	// Pinning twice is harmless.
+	if err := pinner.Pin(ctx, req); err != nil {
+		t.Fatalf("Pin() of a pinned version failed: %v", err)
+	}
+	if stater, ok := srv.(artifactx.Stater); ok {
+		attrs, err := stater.Stat(ctx, req)
+		if err != nil || !attrs.Pinned || attrs.Size != 2 || attrs.ContentType != "application/pdf" {
+			t.Errorf("Stat() = (%+v, %v), want the pinned version", attrs, err)
+		} else if diff := cmp.Diff(md, attrs.Metadata); diff != "" {
+			t.Errorf("Stat().Metadata mismatch (-want +got):\n%s", diff)
+		}
+		if attrs, err := stater.Stat(ctx, &artifact.LoadRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName}); err != nil || attrs.Pinned {
+			t.Errorf("Stat() of the latest version = (%+v, %v), want it not pinned", attrs, err)
+		}
+	}
+	got, err := srv.Load(ctx, req)
+	if err != nil || string(got.Part.InlineData.Data) != "v1" {
+		t.Errorf("Load() of the pinned version = (%v, %v), want v1", got, err)
+	}
+
+	if err := pinner.Unpin(ctx, req); err != nil {
+		t.Fatalf("Unpin() failed: %v", err)
+	}
+	if stater, ok := srv.(artifactx.Stater); ok {
+		if attrs, err := stater.Stat(ctx, req); err != nil || attrs.Pinned {
+			t.Errorf("Stat() after Unpin() = (%+v, %v), want it not pinned", attrs, err)
+		}
+	}
+	missing := &artifact.LoadRequest{AppName: "testapp", UserID: "testuser", SessionID: "s1", FileName: "report", Version: 3}
+	if err := pinner.Pin(ctx, missing); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Pin() of a missing version = %v, want error(%v)", err, fs.ErrNotExist)
+	}
+}
+
+// testArtifactService_Immutable covers Save under [artifactx.WithImmutable].
+func testArtifactService_Immutable(ctx context.Context, t *testing.T, srv artifact.Service) {
+	stater, ok := srv.(artifactx.Stater)
+	if !ok {
+		t.Skip("service does not implement artifactx.Stater")
+	}
+	save := func(ctx context.Context, fileName string, version int64, data string) error {
+		_, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "testapp", UserID: "testuser", SessionID: "s1", FileName: fileName, Version: version,
+			Part: genai.NewPartFromBytes([]byte(data), "application/pdf"),
+		})
+		return err
+	}
+	if err := save(artifactx.WithImmutable(ctx), "upload.pdf", 0, "original"); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	req := &artifact.LoadRequest{AppName: "testapp", UserID: "testuser", SessionID: "s1", FileName: "upload.pdf"}
+	attrs, err := stater.Stat(ctx, req)
+	if err != nil {
+		t.Fatalf("Stat() failed: %v", err)
+	}
+	if !attrs.Immutable {
+		t.Skip("service does not support artifactx.WithImmutable")
+	}
+	if err := save(ctx, "upload.pdf", 0, "replaced"); !errors.Is(err, artifactx.ErrImmutable) {
+		t.Errorf("Save() of an immutable artifact = %v, want error(%v)", err, artifactx.ErrImmutable)
+	}
+	if err := save(ctx, "upload.pdf", 1, "replaced"); !errors.Is(err, artifactx.ErrImmutable) {
+		t.Errorf("Save() over an immutable version = %v, want error(%v)", err, artifactx.ErrImmutable)
+	}
+	got, err := srv.Load(ctx, req)
+	if err != nil || string(got.Part.InlineData.Data) != "original" {
+		t.Errorf("Load() = (%v, %v), want the original version", got, err)
+	}
+	if resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName}); err != nil || len(resp.Versions) != 1 {
+		t.Errorf("Versions() = (%v, %v), want one version", resp, err)
+	}
+
+	// Other files are unaffected, and Delete still removes the artifact.
+	for range 2 {
+		if err := save(ctx, "notes.txt", 0, "draft"); err != nil {
+			t.Errorf("Save() of a mutable artifact failed: %v", err)
+		}
+	}
+	if err := srv.Delete(ctx, &artifact.DeleteRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName}); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if err := save(ctx, "upload.pdf", 0, "new"); err != nil {
+		t.Errorf("Save() after Delete() failed: %v", err)
+	}
+}
+
+// testArtifactService_UpdateJSON covers [artifactx.JSONUpdater].
+func testArtifactService_UpdateJSON(ctx context.Context, t *testing.T, srv artifact.Service) {
+	updater, ok := srv.(artifactx.JSONUpdater)
+	if !ok {
+		t.Skip("service does not implement artifactx.JSONUpdater")
+	}
+	req := &artifact.LoadRequest{AppName: "testapp", UserID: "testuser", SessionID: "s1", FileName: "scratchpad.json"}
+	update := func(typ artifactx.PatchType, patch string) (*artifact.SaveResponse, error) {
+		return updater.UpdateJSON(ctx, &artifactx.UpdateJSONRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+			Type: typ, Patch: []byte(patch),
+		})
+	}
+	load := func() string {
+		t.Helper()
+		resp, err := srv.Load(ctx, req)
+		if err != nil {
+			t.Fatalf("Load() failed: %v", err)
+		}
+		return string(resp.Part.InlineData.Data)
+	}
+	if _, err := update(artifactx.JSONPatch, `[]`); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("UpdateJSON() of a missing artifact = %v, want error(%v)", err, fs.ErrNotExist)
+	}
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+		Part: genai.NewPartFromBytes([]byte(`{"goal":"plan","todo":[]}`), "application/json"),
+	}); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+
+	resp, err := update(artifactx.JSONPatch, `[{"op":"add","path":"/todo/-","value":"draft"}]`)
+	if err != nil || resp.Version != 2 {
+		t.Fatalf("UpdateJSON() = (%v, %v), want version 2", resp, err)
+	}
+	if got, want := load(), `{"goal":"plan","todo":["draft"]}`; got != want {
+		t.Errorf("Load() after UpdateJSON() = %s, want %s", got, want)
+	}
+	if resp, err := update(artifactx.MergePatch, `{"goal":"ship"}`); err != nil || resp.Version != 3 {
+		t.Fatalf("UpdateJSON() with a merge patch = (%v, %v), want version 3", resp, err)
+	}
+	if got, want := load(), `{"goal":"ship","todo":["draft"]}`; got != want {
+		t.Errorf("Load() after UpdateJSON() with a merge patch = %s, want %s", got, want)
+	}
+	if _, err := update(artifactx.JSONPatch, `[{"op":"test","path":"/goal","value":"plan"}]`); !errors.Is(err, artifactx.ErrPatchFailed) {
+		t.Errorf("UpdateJSON() with a failing test = %v, want error(%v)", err, artifactx.ErrPatchFailed)
+	}
+
+	// Only application/json is patched: not text, nor the internal
+	// encodings of other parts, which are JSON too.
+	for name, part := range map[string]*genai.Part{
+		"notes.json": genai.NewPartFromText(`{"goal":"plan"}`),
+		"ref.json":   genai.NewPartFromURI("gs://bucket/doc.json", "application/json"),
+	} {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: name, Part: part,
+		}); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+		if _, err := updater.UpdateJSON(ctx, &artifactx.UpdateJSONRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: name,
+			Type: artifactx.MergePatch, Patch: []byte(`{"goal":"ship"}`),
+		}); !errors.Is(err, artifactx.ErrNotJSON) {
+			t.Errorf("UpdateJSON() of %s = %v, want error(%v)", name, err, artifactx.ErrNotJSON)
+		}
+	}
+
+	// Concurrent updates are not lost.
+	const writers = 8
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Go(func() {
+			if _, err := update(artifactx.JSONPatch, fmt.Sprintf(`[{"op":"add","path":"/todo/-","value":%d}]`, i)); err != nil {
+				t.Errorf("concurrent UpdateJSON() failed: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+	versions, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName})
+	if err != nil || len(versions.Versions) != 3+writers {
+		t.Errorf("Versions() = (%v, %v), want %d versions", versions, err, 3+writers)
+	}
+	var doc struct{ Todo []any }
+	if err := json.Unmarshal([]byte(load()), &doc); err != nil || len(doc.Todo) != 1+writers {
+		t.Errorf("Load() after concurrent updates = (%+v, %v), want %d items", doc, err, 1+writers)
+	}
+}
+
+// testArtifactService_Relabel covers [artifactx.Relabeler].
+func testArtifactService_Relabel(ctx context.Context, t *testing.T, srv artifact.Service) {
+	relabeler, ok := srv.(artifactx.Relabeler)
+	if !ok {
+		t.Skip("service does not implement artifactx.Relabeler")
+	}
+	md := map[string]string{"origin": "test", "stage": "draft"}
+	for _, data := range []string{"v1", "v2"} {
+		if _, err := srv.Save(artifactx.WithMetadata(ctx, md), &artifact.SaveRequest{
+			AppName: "testapp", UserID: "testuser", SessionID: "s1", FileName: "report",
+			Part: genai.NewPartFromBytes([]byte(data), "application/pdf"),
+		}); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+	}
+	req := &artifact.LoadRequest{AppName: "testapp", UserID: "testuser", SessionID: "s1", FileName: "report", Version: 1}
+	change := &artifactx.MetadataChange{Set: map[string]string{"class": "internal"}, Remove: []string{"stage"}}
+	if err := relabeler.Relabel(ctx, req, change); err != nil {
+		t.Fatalf("Relabel() failed: %v", err)
+	}
+	// Relabeling twice is harmless.
+	if err := relabeler.Relabel(ctx, req, change); err != nil {
+		t.Fatalf("Relabel() of a relabeled version failed: %v", err)
+	}
+	if stater, ok := srv.(artifactx.Stater); ok {
+		attrs, err := stater.Stat(ctx, req)
+		if err != nil || attrs.Size != 2 || attrs.ContentType != "application/pdf" {
+			t.Errorf("Stat() = (%+v, %v), want the relabeled version", attrs, err)
+		} else if diff := cmp.Diff(map[string]string{"origin": "test", "class": "internal"}, attrs.Metadata); diff != "" {
+			t.Errorf("Stat().Metadata after Relabel() mismatch (-want +got):\n%s", diff)
+		}
+		latest := &artifact.LoadRequest{AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName}
+		if attrs, err := stater.Stat(ctx, latest); err != nil {
+			t.Errorf("Stat() of the latest version failed: %v", err)
+		} else if diff := cmp.Diff(md, attrs.Metadata); diff != "" {
+			t.Errorf("Stat().Metadata of the latest version mismatch (-want +got):\n%s", diff)
+		}
+	}
+	got, err := srv.Load(ctx, req)
+	if err != nil || string(got.Part.InlineData.Data) != "v1" {
+		t.Errorf("Load() of the relabeled version = (%v, %v), want v1", got, err)
+	}
+	resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: "testapp", UserID: "testuser", SessionID: "s1", FileName: "report"})
+	if err != nil || !slices.Equal(resp.Versions, []int64{1, 2}) {
+		t.Errorf("Versions() after Relabel() = (%v, %v), want [1 2]", resp, err)
+	}
+
+	reserved := &artifactx.MetadataChange{Set: map[string]string{artifactx.PinnedMetadataKey: "true"}}
+	if err := relabeler.Relabel(ctx, req, reserved); err == nil {
+		t.Errorf("Relabel() of a reserved key succeeded, want an error")
+	}
+	missing := &artifact.LoadRequest{AppName: "testapp", UserID: "testuser", SessionID: "s1", FileName: "report", Version: 3}
+	if err := relabeler.Relabel(ctx, missing, change); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Relabel() of a missing version = %v, want error(%v)", err, fs.ErrNotExist)
+	}
+}
+
+// testArtifactService_DryRun covers Delete under [artifactx.WithDryRun].
+func testArtifactService_DryRun(ctx context.Context, t *testing.T, srv artifact.Service) {
+	for _, data := range []string{"v1", "v2"} {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "testapp", UserID: "testuser", SessionID: "s1", FileName: "report", Part: genai.NewPartFromText(data),
+		}); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+	}
+	var removed []artifactx.Removal
+	dryRun := artifactx.WithDryRun(ctx, func(r artifactx.Removal) { removed = append(removed, r) })
+	ref := artifactx.Ref{AppName: "testapp", UserID: "testuser", SessionID: "s1", FileName: "report"}
+
+	if err := srv.Delete(dryRun, &artifact.DeleteRequest{AppName: "testapp", UserID: "testuser", SessionID: "s1", FileName: "report", Version: 1}); err != nil {
+		t.Fatalf("Delete() of version 1 in a dry run failed: %v", err)
+	}
+	ref.Version = 1
+	if want := []artifactx.Removal{{Ref: ref, Reason: artifactx.ByDelete}}; !slices.Equal(removed, want) {
+		t.Errorf("dry run of Delete(version 1) reported %v, want %v", removed, want)
+	}
+	removed = nil
+	if err := srv.Delete(dryRun, &artifact.DeleteRequest{AppName: "testapp", UserID: "testuser", SessionID: "s1", FileName: "report"}); err != nil {
+		t.Fatalf("Delete() in a dry run failed: %v", err)
+	}
+	slices.SortFunc(removed, func(a, b artifactx.Removal) int { return int(a.Version - b.Version) })
+	ref2 := ref
+	ref2.Version = 2
+	if want := []artifactx.Removal{{Ref: ref, Reason: artifactx.ByDelete}, {Ref: ref2, Reason: artifactx.ByDelete}}; !slices.Equal(removed, want) {
+		t.Errorf("dry run of Delete() reported %v, want %v", removed, want)
+	}
+
+	resp, err := srv.Versions(ctx, &artifact.VersionsRequest{AppName: "testapp", UserID: "testuser", SessionID: "s1", FileName: "report"})
+	if err != nil || !slices.Equal(resp.Versions, []int64{1, 2}) {
+		t.Errorf("Versions() after dry runs = (%v, %v), want [1 2]", resp, err)
+	}
+}
+
+// testArtifactService_ListDetailed covers the orders of
+// [artifactx.ListDetailed], served by the service or by its fallback.
+func testArtifactService_ListDetailed(ctx context.Context, t *testing.T, srv artifact.Service) {
+	if _, ok := artifactx.As[artifactx.DetailedLister](srv); !ok {
+		if _, ok := artifactx.As[artifactx.Stater](srv); !ok {
+			t.Skip("service implements neither artifactx.DetailedLister nor artifactx.Stater")
+		}
+	}
+	// More than 9 versions of "notes", so that lexicographic key order
+	// differs; the latest version is what is described.
+	for _, save := range []struct{ fileName, data string }{
+		{"big", "0123456789"},
+		{"user:profile", "12345"},
+		{"notes", "1"}, {"notes", "2"}, {"notes", "3"}, {"notes", "4"}, {"notes", "5"}, {"notes", "6"},
+		{"notes", "7"}, {"notes", "8"}, {"notes", "9"}, {"notes", "10"}, {"notes", "11 chars!!"},
+	} {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "testapp", UserID: "testuser", SessionID: "s1", FileName: save.fileName,
+			Part: genai.NewPartFromBytes([]byte(save.data), "text/plain"),
+		}); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+		// Storage times may be coarse.
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, tc := range []struct {
+		order artifactx.ListOrder
+		limit int
+		want  []string
+	}{
+		{artifactx.ByName, 0, []string{"big:1:10", "notes:11:10", "user:profile:1:5"}},
+		{artifactx.ByNameDesc, 0, []string{"user:profile:1:5", "notes:11:10", "big:1:10"}},
+		{artifactx.ByModTime, 0, []string{"notes:11:10", "user:profile:1:5", "big:1:10"}},
+		// Ties are broken by name.
+		{artifactx.BySize, 0, []string{"big:1:10", "notes:11:10", "user:profile:1:5"}},
+		{artifactx.ByModTime, 1, []string{"notes:11:10"}},
+	} {
+		files, err := artifactx.ListDetailed(ctx, srv, &artifactx.ListDetailedRequest{
+			AppName: "testapp", UserID: "testuser", SessionID: "s1", Order: tc.order, Limit: tc.limit,
+		})
+		if err != nil {
+			t.Fatalf("ListDetailed(%v) failed: %v", tc.order, err)
+		}
+		var got []string
+		for _, f := range files {
+			got = append(got, fmt.Sprintf("%s:%d:%d", f.FileName, f.Version, f.Size))
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("ListDetailed(%v, limit %d) = %v, want %v", tc.order, tc.limit, got, tc.want)
+		}
+	}
+}
+
+// testArtifactService_ListRecent covers [artifactx.ListRecent] across the
+// sessions of a user.
+func testArtifactService_ListRecent(ctx context.Context, t *testing.T, srv artifact.Service) {
+	if _, ok := artifactx.As[artifactx.RecentLister](srv); !ok {
+		if _, ok := artifactx.As[artifactx.Browser](srv); !ok {
+			t.Skip("service implements neither artifactx.RecentLister nor artifactx.Browser")
+		}
+	}
+	for _, save := range []struct{ userID, sessionID, fileName string }{
+		{"testuser", "s1", "draft"},
+		{"testuser", "s2", "budget.xlsx"},
+		{"otheruser", "s1", "secret"},
+		{"testuser", "s1", "user:profile"},
+		{"testuser", "s1", "draft"},
+	} {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "testapp", UserID: save.userID, SessionID: save.sessionID, FileName: save.fileName,
+			Part: genai.NewPartFromText(save.fileName),
+		}); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+		// Storage times may be coarse.
+		time.Sleep(10 * time.Millisecond)
+	}
+	recent, err := artifactx.ListRecent(ctx, srv, "testapp", "testuser", 0)
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skipf("ListRecent() = %v", err)
+	}
+	if err != nil {
+		t.Fatalf("ListRecent() failed: %v", err)
+	}
+	var got []string
+	for _, a := range recent {
+		got = append(got, fmt.Sprintf("%s/%s/%d", a.SessionID, a.FileName, a.Version))
+	}
+	want := []string{"s1/draft/2", artifactx.UserNamespace + "/user:profile/1", "s2/budget.xlsx/1"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ListRecent() = %v, want %v", got, want)
+	}
+	if recent, err := artifactx.ListRecent(ctx, srv, "testapp", "testuser", 1); err != nil || len(recent) != 1 || recent[0].FileName != "draft" {
+		t.Errorf("ListRecent(limit 1) = (%v, %v), want the draft", recent, err)
+	}
+	if recent, err := artifactx.ListRecent(ctx, srv, "testapp", "nobody", 0); err != nil || len(recent) != 0 {
+		t.Errorf("ListRecent() of a user without artifacts = (%v, %v), want none", recent, err)
+	}
+}
+
+// testArtifactService_FindFile covers [artifactx.FindFile] across the
+// sessions of a user.
+func testArtifactService_FindFile(ctx context.Context, t *testing.T, srv artifact.Service) {
+	if _, ok := artifactx.As[artifactx.FileFinder](srv); !ok {
+		if _, ok := artifactx.As[artifactx.Browser](srv); !ok {
+			t.Skip("service implements neither artifactx.FileFinder nor artifactx.Browser")
+		}
+	}
+	for _, save := range []struct{ userID, sessionID, fileName string }{
+		{"testuser", "s1", "budget.xlsx"},
+		{"testuser", "s2", "budget.xlsx"},
+		{"testuser", "s1", "budget.xlsx"},
+		{"testuser", "s3", "budget-2.xlsx"},
+		{"otheruser", "s4", "budget.xlsx"},
+		{"testuser", "s1", "user:profile"},
+	} {
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "testapp", UserID: save.userID, SessionID: save.sessionID, FileName: save.fileName,
+			Part: genai.NewPartFromText(save.fileName),
+		}); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+	}
+	for _, tc := range []struct {
+		fileName string
+		want     []string
+	}{
+		{"budget.xlsx", []string{"s1/2", "s2/1"}},
+		{"user:profile", []string{artifactx.UserNamespace + "/1"}},
+		{"missing", nil},
+	} {
+		refs, err := artifactx.FindFile(ctx, srv, "testapp", "testuser", tc.fileName)
+		if errors.Is(err, errors.ErrUnsupported) {
+			t.Skipf("FindFile() = %v", err)
+		}
+		if err != nil {
+			t.Fatalf("FindFile(%q) failed: %v", tc.fileName, err)
+		}
+		var got []string
+		for _, ref := range refs {
+			if ref.FileName != tc.fileName {
+				t.Errorf("FindFile(%q) returned %v", tc.fileName, ref)
+			}
+			got = append(got, fmt.Sprintf("%s/%d", ref.SessionID, ref.Version))
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("FindFile(%q) = %v, want %v", tc.fileName, got, tc.want)
+		}
+	}
+}
+
+func testArtifactService_CancelledSave(ctx context.Context, t *testing.T, srv artifact.Service) {
+	req := &artifact.SaveRequest{
+		AppName: "testapp", UserID: "testuser", SessionID: "testsession", FileName: "report.txt",
+		Part: genai.NewPartFromBytes([]byte("v1"), "text/plain"),
+	}
+	if _, err := srv.Save(ctx, req); err != nil {
+		t.Fatalf("Save() failed: %v", err)
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	req.Part = genai.NewPartFromBytes([]byte("v2"), "text/plain")
+	if resp, err := srv.Save(cancelled, req); err == nil {
+		t.Errorf("Save() with a cancelled context = version %d, want an error", resp.Version)
+	}
+
+	// The cancelled save left nothing behind.
+	versions, err := srv.Versions(ctx, &artifact.VersionsRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+	})
+	if err != nil || !slices.Equal(versions.Versions, []int64{1}) {
+		t.Errorf("Versions() = (%v, %v), want [1]", versions, err)
+	}
+	got, err := srv.Load(ctx, &artifact.LoadRequest{
+		AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+	})
+	if want := genai.NewPartFromBytes([]byte("v1"), "text/plain"); err != nil || !cmp.Equal(got.Part, want) {
+		t.Errorf("Load() = (%v, %v), want v1", got, err)
+	}
+	if resp, err := srv.Save(ctx, req); err != nil || resp.Version != 2 {
+		t.Errorf("Save() after a cancelled save = (%v, %v), want version 2", resp, err)
+	}
+}
+
+func testArtifactService_Compact(ctx context.Context, t *testing.T, srv artifact.Service) {
+	c, ok := artifactx.As[artifactx.Compactor](srv)
+	if !ok {
+		t.Skip("service does not implement artifactx.Compactor")
+	}
+	versionsReq := &artifact.VersionsRequest{AppName: "testapp", UserID: "testuser", SessionID: "testsession", FileName: "log.txt"}
+	save := func(i int) {
+		t.Helper()
+		if _, err := srv.Save(artifactx.WithMetadata(ctx, map[string]string{"run": fmt.Sprint(i)}), &artifact.SaveRequest{
+			AppName: versionsReq.AppName, UserID: versionsReq.UserID, SessionID: versionsReq.SessionID, FileName: versionsReq.FileName,
+			Part: genai.NewPartFromBytes([]byte(fmt.Sprintf("run %d", i)), "text/plain"),
+		}); err != nil {
+			t.Fatalf("Save() failed: %v", err)
+		}
+	}
+	check := func(wantLive, wantArchived []int64) {
+		t.Helper()
+		resp, err := srv.Versions(ctx, versionsReq)
+		if err != nil || !slices.Equal(resp.Versions, wantLive) {
+			t.Errorf("Versions() = (%v, %v), want %v", resp, err, wantLive)
+		}
+		archived, err := c.ArchivedVersions(ctx, versionsReq)
+		if err != nil || !slices.Equal(archived, wantArchived) {
+			t.Errorf("ArchivedVersions() = (%v, %v), want %v", archived, err, wantArchived)
+		}
+	}
+	for i := 1; i <= 5; i++ {
+		save(i)
+	}
+	compactReq := &artifactx.CompactRequest{
+		AppName: versionsReq.AppName, UserID: versionsReq.UserID, SessionID: versionsReq.SessionID, FileName: versionsReq.FileName,
+	}
+	if _, err := c.Compact(ctx, compactReq); err == nil {
+		t.Error("Compact() keeping no version = nil, want an error")
+	}
+	compactReq.Keep = 2
+	if moved, err := c.Compact(ctx, compactReq); err != nil || !slices.Equal(moved, []int64{1, 2, 3}) {
+		t.Fatalf("Compact() = (%v, %v), want [1 2 3]", moved, err)
+	}
+	check([]int64{4, 5}, []int64{1, 2, 3})
+
+	loadReq := &artifact.LoadRequest{
+		AppName: versionsReq.AppName, UserID: versionsReq.UserID, SessionID: versionsReq.SessionID, FileName: versionsReq.FileName, Version: 2,
+	}
+	if _, err := srv.Load(ctx, loadReq); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Load() of an archived version = %v, want fs.ErrNotExist", err)
+	}
+	if err := c.RestoreVersion(ctx, loadReq); err != nil {
+		t.Fatalf("RestoreVersion() failed: %v", err)
+	}
+	if err := c.RestoreVersion(ctx, loadReq); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("RestoreVersion() of a restored version = %v, want fs.ErrExist", err)
+	}
+	got, err := srv.Load(ctx, loadReq)
+	if want := genai.NewPartFromBytes([]byte("run 2"), "text/plain"); err != nil || !cmp.Equal(got.Part, want) {
+		t.Errorf("Load() of a restored version = (%v, %v), want %v", got, err, want)
+	}
+	if stater, ok := artifactx.As[artifactx.Stater](srv); ok {
+		if attrs, err := stater.Stat(ctx, loadReq); err != nil || attrs.Metadata["run"] != "2" {
+			t.Errorf("Stat() of a restored version = (%+v, %v), want its metadata", attrs, err)
+		}
+	}
+	check([]int64{2, 4, 5}, []int64{1, 2, 3})
+
+	// New versions are numbered after the archived ones; compacting again
+	// archives the restored version once.
+	save(6)
+	compactReq.Keep = 1
+	if moved, err := c.Compact(ctx, compactReq); err != nil || !slices.Equal(moved, []int64{2, 4, 5}) {
+		t.Fatalf("Compact() = (%v, %v), want [2 4 5]", moved, err)
+	}
+	check([]int64{6}, []int64{1, 2, 3, 4, 5})
+	loadReq.Version = 1
+	if err := c.RestoreVersion(ctx, loadReq); err != nil {
+		t.Errorf("RestoreVersion() after compacting again failed: %v", err)
+	}
+
+	if err := srv.Delete(ctx, &artifact.DeleteRequest{
+		AppName: versionsReq.AppName, UserID: versionsReq.UserID, SessionID: versionsReq.SessionID, FileName: versionsReq.FileName,
+	}); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+	if archived, err := c.ArchivedVersions(ctx, versionsReq); err != nil || len(archived) != 0 {
+		t.Errorf("ArchivedVersions() after deleting the artifact = (%v, %v), want none", archived, err)
+	}
+}
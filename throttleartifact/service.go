@@ -0,0 +1,308 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package throttleartifact provides an [artifact.Service] decorator that
+// limits the bandwidth used by uploads and downloads.
+package throttleartifact
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"golang.org/x/time/rate"
+	"google.golang.org/adk/artifact"
+
+	"github.com/chinglinwen/adk-artifact/artifactx"
+)
+
+// Limits configures [NewService]. Limits are in bytes per second; zero means
+// unlimited.
+type Limits struct {
+	// Upload and Download limit every single Save or Load.
+	Upload   int
+	Download int
+	// GlobalUpload and GlobalDownload limit all Saves or Loads together.
+	GlobalUpload   int
+	GlobalDownload int
+}
+
+// maxChunk bounds the bytes accounted for at once, so that transfers are
+// paced smoothly rather than in bursts of a second worth of data.
+const maxChunk = 32 << 10
+
+// Service is an [artifact.Service] that limits transfer bandwidth.
+//
+// Downloads through Load, Open and OpenRange are paced as they are read
+// when the decorated service implements [artifactx.Opener]. Saves hold the
+// whole artifact in memory already, so they are delayed until the limit
+// allows their size instead.
+type Service struct {
+	artifact.Service
+	// mu guards the limits, which [Service.SetLimits] changes at runtime.
+	mu             sync.RWMutex
+	limits         Limits
+	globalUpload   *rate.Limiter
+	globalDownload *rate.Limiter
+}
+
+var (
+	_ artifactx.Wrapper     = (*Service)(nil)
+	_ artifactx.Opener      = (*Service)(nil)
+	_ artifactx.RangeOpener = (*Service)(nil)
+)
+
+// NewService returns a service that forwards to next within limits.
+func NewService(next artifact.Service, limits Limits) *Service {
+	return &Service{
+		Service:        next,
+		limits:         limits,
+		globalUpload:   newLimiter(limits.GlobalUpload),
+		globalDownload: newLimiter(limits.GlobalDownload),
+	}
+}
+
+// SetLimits replaces the limits of the service. Transfers already running
+// keep the per-operation limits they started with; global limits apply to
+// them as soon as they are changed, but not once they are removed.
+func (s *Service) SetLimits(limits Limits) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits = limits
+	s.globalUpload = setLimiter(s.globalUpload, limits.GlobalUpload)
+	s.globalDownload = setLimiter(s.globalDownload, limits.GlobalDownload)
+}
+
+// setLimiter returns l changed to bytesPerSec, or a new limiter if l is
+// nil, or nil if bytesPerSec is unlimited.
+func setLimiter(l *rate.Limiter, bytesPerSec int) *rate.Limiter {
+	if l == nil || bytesPerSec <= 0 {
+		return newLimiter(bytesPerSec)
+	}
+	l.SetLimit(rate.Limit(bytesPerSec))
+	l.SetBurst(min(bytesPerSec, maxChunk))
+	return l
+}
+
+// uploadLimiters and downloadLimiters return the limiters applying to one
+// operation.
+func (s *Service) uploadLimiters() []*rate.Limiter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return limiters(s.limits.Upload, s.globalUpload)
+}
+
+func (s *Service) downloadLimiters() []*rate.Limiter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return limiters(s.limits.Download, s.globalDownload)
+}
+
+// Unwrap implements [artifactx.Wrapper].
+func (s *Service) Unwrap() artifact.Service {
+	return s.Service
+}
+
+// newLimiter returns a limiter for bytesPerSec, or nil if it is unlimited.
+func newLimiter(bytesPerSec int) *rate.Limiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSec), min(bytesPerSec, maxChunk))
+}
+
+// limiters returns the limiters applying to one operation.
+func limiters(perOp int, global *rate.Limiter) []*rate.Limiter {
+	var ls []*rate.Limiter
+	if l := newLimiter(perOp); l != nil {
+		ls = append(ls, l)
+	}
+	if global != nil {
+		ls = append(ls, global)
+	}
+	return ls
+}
+
+// chunkSize returns the largest number of bytes all of ls can grant at once.
+func chunkSize(ls []*rate.Limiter) int {
+	n := maxChunk
+	for _, l := range ls {
+		n = min(n, l.Burst())
+	}
+	return n
+}
+
+// wait blocks until all of ls allow n bytes.
+func wait(ctx context.Context, ls []*rate.Limiter, n int) error {
+	for _, l := range ls {
+		for left := n; left > 0; {
+			chunk := min(left, l.Burst())
+			if err := l.WaitN(ctx, chunk); err != nil {
+				return err
+			}
+			left -= chunk
+		}
+	}
+	return nil
+}
+
+// Save implements [artifact.Service].
+func (s *Service) Save(ctx context.Context, req *artifact.SaveRequest) (*artifact.SaveResponse, error) {
+	if ls := s.uploadLimiters(); len(ls) > 0 && req.Part != nil {
+		if data, _, err := artifactx.EncodePart(req.Part); err == nil {
+			if err := wait(ctx, ls, len(data)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s.Service.Save(ctx, req)
+}
+
+// Load implements [artifact.Service].
+func (s *Service) Load(ctx context.Context, req *artifact.LoadRequest) (*artifact.LoadResponse, error) {
+	ls := s.downloadLimiters()
+	if len(ls) == 0 {
+		return s.Service.Load(ctx, req)
+	}
+	if _, ok := artifactx.As[artifactx.Opener](s.Service); !ok {
+		resp, err := s.Service.Load(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if data, _, err := artifactx.EncodePart(resp.Part); err == nil {
+			if err := wait(ctx, ls, len(data)); err != nil {
+				return nil, err
+			}
+		}
+		return resp, nil
+	}
+
+	r, err := s.Open(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	limit := artifactx.MaxLoadSize(ctx, 0)
+	if err := artifactx.CheckLoadSize(r.Size, limit); err != nil {
+		return nil, fmt.Errorf("artifact '%s': %w", req.FileName, err)
+	}
+	data, err := artifactx.ReadAllLimit(r, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact '%s': %w", req.FileName, err)
+	}
+	part, err := artifactx.DecodePart(data, r.ContentType)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode artifact '%s': %w", req.FileName, err)
+	}
+	return &artifact.LoadResponse{Part: part}, nil
+}
+
+// Open implements [artifactx.Opener]. If the decorated service cannot stream,
+// the version is loaded and served from memory.
+func (s *Service) Open(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Reader, error) {
+	var r *artifactx.Reader
+	if opener, ok := artifactx.As[artifactx.Opener](s.Service); ok {
+		var err error
+		if r, err = opener.Open(ctx, req); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if r, err = s.openLoaded(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	ls := s.downloadLimiters()
+	if len(ls) == 0 {
+		return r, nil
+	}
+	return &artifactx.Reader{
+		ReadCloser: &reader{ctx: ctx, r: r.ReadCloser, limiters: ls, chunk: chunkSize(ls)},
+		Attributes: r.Attributes,
+	}, nil
+}
+
+// OpenRange implements [artifactx.RangeOpener], pacing the bytes read like
+// Open does. Only the requested range is read if the decorated service
+// supports it.
+func (s *Service) OpenRange(ctx context.Context, req *artifact.LoadRequest, offset, length int64) (*artifactx.Reader, error) {
+	r, err := artifactx.OpenRange(ctx, s.Service, req, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	ls := s.downloadLimiters()
+	if len(ls) == 0 {
+		return r, nil
+	}
+	return &artifactx.Reader{
+		ReadCloser: &reader{ctx: ctx, r: r.ReadCloser, limiters: ls, chunk: chunkSize(ls)},
+		Attributes: r.Attributes,
+	}, nil
+}
+
+// openLoaded implements Open on top of Load.
+func (s *Service) openLoaded(ctx context.Context, req *artifact.LoadRequest) (*artifactx.Reader, error) {
+	version := req.Version
+	if version == 0 {
+		resp, err := s.Service.Versions(ctx, &artifact.VersionsRequest{
+			AppName: req.AppName, UserID: req.UserID, SessionID: req.SessionID, FileName: req.FileName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		version = slices.Max(resp.Versions)
+	}
+	loadReq := *req
+	loadReq.Version = version
+	resp, err := s.Service.Load(ctx, &loadReq)
+	if err != nil {
+		return nil, err
+	}
+	data, contentType, err := artifactx.EncodePart(resp.Part)
+	if err != nil {
+		return nil, err
+	}
+	return &artifactx.Reader{
+		ReadCloser: io.NopCloser(bytes.NewReader(data)),
+		Attributes: artifactx.Attributes{Version: version, ContentType: contentType, Size: int64(len(data))},
+	}, nil
+}
+
+// reader paces reads from r.
+type reader struct {
+	ctx      context.Context
+	r        io.ReadCloser
+	limiters []*rate.Limiter
+	chunk    int
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if len(p) > r.chunk {
+		p = p[:r.chunk]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := wait(r.ctx, r.limiters, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+func (r *reader) Close() error {
+	return r.r.Close()
+}
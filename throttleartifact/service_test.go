@@ -0,0 +1,137 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package throttleartifact_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/artifact"
+	"google.golang.org/genai"
+
+	"github.com/chinglinwen/adk-artifact/fsartifact"
+	"github.com/chinglinwen/adk-artifact/throttleartifact"
+)
+
+const mib = 1 << 20
+
+func TestThrottle(t *testing.T) {
+	ctx := t.Context()
+	data := bytes.Repeat([]byte("x"), 256<<10)
+	req := func(fileName string) *artifact.SaveRequest {
+		return &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: fileName,
+			Part: genai.NewPartFromBytes(data, "application/octet-stream"),
+		}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		limits throttleartifact.Limits
+		save   bool
+	}{
+		{"Download", throttleartifact.Limits{Download: 1 * mib}, false},
+		{"Upload", throttleartifact.Limits{Upload: 1 * mib}, true},
+		{"GlobalUpload", throttleartifact.Limits{GlobalUpload: 2 * mib}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			next, err := fsartifact.NewService(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			srv := throttleartifact.NewService(next, tc.limits)
+
+			start := time.Now()
+			if tc.save {
+				// Two sequential saves of 256KiB at 1MiB/s, or two such
+				// goroutines sharing 2MiB/s: either way about 500ms.
+				n := 1
+				if tc.limits.GlobalUpload > 0 {
+					n = 2
+				}
+				var wg sync.WaitGroup
+				for range n {
+					wg.Go(func() {
+						for range 2 {
+							if _, err := srv.Save(ctx, req("file")); err != nil {
+								t.Error(err)
+							}
+						}
+					})
+				}
+				wg.Wait()
+			} else {
+				if _, err := next.Save(ctx, req("file")); err != nil {
+					t.Fatal(err)
+				}
+				resp, err := srv.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"})
+				if err != nil {
+					t.Fatalf("Load() failed: %v", err)
+				}
+				if !bytes.Equal(resp.Part.InlineData.Data, data) {
+					t.Error("Load() returned different data")
+				}
+			}
+			if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+				t.Errorf("transfer took %v, want it throttled", elapsed)
+			}
+		})
+	}
+}
+
+func TestUnlimited(t *testing.T) {
+	ctx := t.Context()
+	srv := throttleartifact.NewService(artifact.InMemoryService(), throttleartifact.Limits{})
+	data := bytes.Repeat([]byte("x"), 64*mib)
+	start := time.Now()
+	if _, err := srv.Save(ctx, &artifact.SaveRequest{
+		AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+		Part: genai.NewPartFromBytes(data, "application/octet-stream"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Load(ctx, &artifact.LoadRequest{AppName: "app", UserID: "user", SessionID: "session", FileName: "file"}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("unlimited transfer took %v", elapsed)
+	}
+}
+
+func TestSetLimits(t *testing.T) {
+	ctx := t.Context()
+	srv := throttleartifact.NewService(artifact.InMemoryService(), throttleartifact.Limits{})
+	save := func() time.Duration {
+		start := time.Now()
+		if _, err := srv.Save(ctx, &artifact.SaveRequest{
+			AppName: "app", UserID: "user", SessionID: "session", FileName: "file",
+			Part: genai.NewPartFromBytes(bytes.Repeat([]byte("x"), 512<<10), "application/octet-stream"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return time.Since(start)
+	}
+
+	srv.SetLimits(throttleartifact.Limits{GlobalUpload: 2 * mib})
+	if elapsed := save(); elapsed < 150*time.Millisecond {
+		t.Errorf("save after SetLimits() took %v, want it throttled", elapsed)
+	}
+	srv.SetLimits(throttleartifact.Limits{})
+	if elapsed := save(); elapsed > 100*time.Millisecond {
+		t.Errorf("save after removing the limits took %v, want it unthrottled", elapsed)
+	}
+}